@@ -0,0 +1,104 @@
+package thermoprint
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rusq/thermoprint/locale"
+)
+
+// maxAlerts is the number of alerts kept in the alert history.
+const maxAlerts = 32
+
+// AlertCode identifies the condition that raised the alert.
+type AlertCode string
+
+const (
+	AlertNoPaper         AlertCode = "no-paper"
+	AlertMediaLow        AlertCode = "media-low"
+	AlertBatteryLow      AlertCode = "battery-low"
+	AlertBatteryCritical AlertCode = "battery-critical"
+	AlertCooldown        AlertCode = "cooldown"
+)
+
+// AlertSeverity is the severity of the alert.
+type AlertSeverity string
+
+const (
+	SeverityWarning  AlertSeverity = "warning"
+	SeverityCritical AlertSeverity = "critical"
+)
+
+// Alert is a device condition that requires attention, such as no paper or
+// low battery.
+type Alert struct {
+	Time     time.Time
+	Code     AlertCode
+	Severity AlertSeverity
+	Message  string
+}
+
+// alertLog is a rolling log of alerts, it holds up to maxAlerts most recent
+// alerts.  It is safe for concurrent use.
+type alertLog struct {
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+// add records the alert.  If the latest alert has the same code, i.e. the
+// printer repeats the cooldown notification, it is updated instead, not to
+// push the rest of the history out.
+func (l *alertLog) add(code AlertCode, severity AlertSeverity, message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	a := Alert{
+		Time:     time.Now(),
+		Code:     code,
+		Severity: severity,
+		Message:  message,
+	}
+	if n := len(l.alerts); n > 0 && l.alerts[n-1].Code == code {
+		l.alerts[n-1] = a
+		return
+	}
+	l.alerts = append(l.alerts, a)
+	if len(l.alerts) > maxAlerts {
+		l.alerts = slices.Delete(l.alerts, 0, len(l.alerts)-maxAlerts)
+	}
+}
+
+func (l *alertLog) list() []Alert {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.alerts)
+}
+
+// batteryBand returns the battery condition for the level: 0 - ok, 1 - low,
+// 2 - critical.
+func batteryBand(level uint8) int {
+	switch {
+	case level < gBatCritical:
+		return 2
+	case level < gBatLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// statusAlerts records the alerts for the conditions that appeared in the
+// status cur since the previous status prev.  If seen is false, there was no
+// previous status.
+func (l *alertLog) statusAlerts(prev lxd02status, seen bool, cur lxd02status) {
+	if cur.NoPaper && (!seen || !prev.NoPaper) {
+		l.add(AlertNoPaper, SeverityCritical, locale.T("Printer is out of paper"))
+	}
+	if band := batteryBand(cur.BatteryLevel); band > 0 && (!seen || band > batteryBand(prev.BatteryLevel)) {
+		if band == 2 {
+			l.add(AlertBatteryCritical, SeverityCritical, locale.Sprintf("Battery level is critical: %d%%", cur.BatteryLevel))
+		} else {
+			l.add(AlertBatteryLow, SeverityWarning, locale.Sprintf("Battery level is low: %d%%", cur.BatteryLevel))
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package thermoprint
+
+import "testing"
+
+func TestStatusAlerts(t *testing.T) {
+	tests := []struct {
+		name  string
+		prev  lxd02status
+		seen  bool
+		cur   lxd02status
+		codes []AlertCode
+	}{
+		{"all ok", lxd02status{BatteryLevel: 90}, true, lxd02status{BatteryLevel: 90}, nil},
+		{"paper runs out", lxd02status{BatteryLevel: 90}, true, lxd02status{BatteryLevel: 90, NoPaper: true}, []AlertCode{AlertNoPaper}},
+		{"still no paper", lxd02status{BatteryLevel: 90, NoPaper: true}, true, lxd02status{BatteryLevel: 90, NoPaper: true}, nil},
+		{"first status", lxd02status{}, false, lxd02status{BatteryLevel: 5, NoPaper: true}, []AlertCode{AlertNoPaper, AlertBatteryCritical}},
+		{"battery low", lxd02status{BatteryLevel: 21}, true, lxd02status{BatteryLevel: 19}, []AlertCode{AlertBatteryLow}},
+		{"battery still low", lxd02status{BatteryLevel: 19}, true, lxd02status{BatteryLevel: 15}, nil},
+		{"battery critical", lxd02status{BatteryLevel: 15}, true, lxd02status{BatteryLevel: 9}, []AlertCode{AlertBatteryCritical}},
+		{"battery charging", lxd02status{BatteryLevel: 9}, true, lxd02status{BatteryLevel: 15}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var l alertLog
+			l.statusAlerts(tt.prev, tt.seen, tt.cur)
+			alerts := l.list()
+			if len(alerts) != len(tt.codes) {
+				t.Fatalf("alerts = %+v, want codes %v", alerts, tt.codes)
+			}
+			for i, a := range alerts {
+				if a.Code != tt.codes[i] {
+					t.Errorf("alert[%d].Code = %q, want %q", i, a.Code, tt.codes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAlertLogKeepsMostRecent(t *testing.T) {
+	var l alertLog
+	for i := range maxAlerts + 5 {
+		if i%2 == 0 {
+			l.add(AlertCooldown, SeverityWarning, "cooldown")
+		} else {
+			l.add(AlertMediaLow, SeverityWarning, "media low")
+		}
+	}
+	l.add(AlertNoPaper, SeverityCritical, "no paper")
+
+	alerts := l.list()
+	if len(alerts) != maxAlerts {
+		t.Fatalf("len(alerts) = %d, want %d", len(alerts), maxAlerts)
+	}
+	if last := alerts[len(alerts)-1]; last.Code != AlertNoPaper {
+		t.Fatalf("last alert = %+v, want %q", last, AlertNoPaper)
+	}
+}
+
+func TestAlertLogUpdatesRepeated(t *testing.T) {
+	var l alertLog
+	l.add(AlertNoPaper, SeverityCritical, "no paper")
+	for range maxAlerts + 5 {
+		l.add(AlertCooldown, SeverityWarning, "cooldown")
+	}
+
+	alerts := l.list()
+	if len(alerts) != 2 {
+		t.Fatalf("alerts = %+v, want the no paper and one cooldown", alerts)
+	}
+	if alerts[0].Code != AlertNoPaper || alerts[1].Code != AlertCooldown {
+		t.Fatalf("alerts = %+v, want codes %q, %q", alerts, AlertNoPaper, AlertCooldown)
+	}
+}
@@ -0,0 +1,180 @@
+package bitmap
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/code39"
+	"github.com/boombuler/barcode/ean"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// BarcodeType is the symbology of the linear barcode.
+type BarcodeType string
+
+const (
+	// Code128 encodes any ASCII text, it is the most compact of the three.
+	Code128 BarcodeType = "code128"
+	// EAN13 encodes 12 digits and the check digit, that is calculated if
+	// omitted, it is the product barcode.
+	EAN13 BarcodeType = "ean13"
+	// Code39 encodes any ASCII text, the lowercase letters and symbols take
+	// two characters.
+	Code39 BarcodeType = "code39"
+)
+
+const (
+	// barcodeQuietZone is the width of the white space on both sides of the
+	// code, in modules.  It satisfies all supported symbologies, the
+	// largest is 11 modules on the left of EAN-13.
+	barcodeQuietZone = 11
+	// barcodeTextGap is the gap between the bars and the text beneath.
+	barcodeTextGap = 2
+)
+
+// BarcodeTypes returns the names of the supported barcode symbologies.
+func BarcodeTypes() []string {
+	return []string{string(Code128), string(EAN13), string(Code39)}
+}
+
+// ParseBarcodeType parses the name of the barcode symbology.
+func ParseBarcodeType(s string) (BarcodeType, error) {
+	t := BarcodeType(strings.ToLower(s))
+	switch t {
+	case Code128, EAN13, Code39:
+		return t, nil
+	}
+	return "", fmt.Errorf("unknown barcode type %q, must be one of: %s", s, strings.Join(BarcodeTypes(), ", "))
+}
+
+// encodeBarcode encodes the data with the symbology.
+func encodeBarcode(kind BarcodeType, data string) (barcode.Barcode, error) {
+	if data == "" {
+		return nil, errors.New("barcode: no data")
+	}
+	var (
+		code barcode.Barcode
+		err  error
+	)
+	switch kind {
+	case Code128:
+		code, err = code128.Encode(data)
+	case EAN13:
+		if len(data) != 12 && len(data) != 13 || strings.Trim(data, "0123456789") != "" {
+			return nil, fmt.Errorf("barcode: EAN-13 requires 12 or 13 digits, got %q", data)
+		}
+		code, err = ean.Encode(data)
+	case Code39:
+		code, err = code39.Encode(data, false, true)
+	default:
+		return nil, fmt.Errorf("barcode: unknown type %q", kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("barcode: %w", err)
+	}
+	return code, nil
+}
+
+// Barcode renders the linear barcode of the data with the bars of the given
+// height and the human-readable text beneath.  Each module is the largest
+// whole number of pixels, so that the code with the quiet zones is not wider
+// than width pixels, which keeps the bars crisp.  The image is pure black
+// and white.
+func Barcode(kind BarcodeType, data string, width, height int) (*image.Gray, error) {
+	code, err := encodeBarcode(kind, data)
+	if err != nil {
+		return nil, err
+	}
+	if height < 1 {
+		return nil, errors.New("barcode: height must be positive")
+	}
+	modules := code.Bounds().Dx() + 2*barcodeQuietZone
+	scale := width / modules
+	if scale < 1 {
+		return nil, fmt.Errorf("barcode: the code of %d modules does not fit in %d pixels", modules, width)
+	}
+
+	face := basicfont.Face7x13
+	img := image.NewGray(image.Rect(0, 0, modules*scale, height+barcodeTextGap+face.Height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for x := range code.Bounds().Dx() {
+		if color.GrayModel.Convert(code.At(code.Bounds().Min.X+x, code.Bounds().Min.Y)).(color.Gray).Y >= 128 {
+			continue
+		}
+		x0 := (x + barcodeQuietZone) * scale
+		draw.Draw(img, image.Rect(x0, 0, x0+scale, height), image.Black, image.Point{}, draw.Src)
+	}
+
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.Black,
+		Face: face,
+	}
+	text := code.Content() // includes the EAN check digit
+	d.Dot = fixed.P((img.Bounds().Dx()-d.MeasureString(text).Ceil())/2, height+barcodeTextGap+face.Ascent)
+	d.DrawString(text)
+	return img, nil
+}
+
+// AppendBarcode appends the barcode of the data with the bars of the given
+// height, that is not wider than the canvas, in the middle of the canvas.
+// The code is not dithered.
+func (c *Composer) AppendBarcode(kind BarcodeType, data string, height int) error {
+	img, err := Barcode(kind, data, c.dst.Bounds().Dx(), height)
+	if err != nil {
+		return err
+	}
+	c.AppendImageDither(centre(img, c.dst.Bounds().Dx()), DitherThresholdFn(DefaultThreshold))
+	return nil
+}
+
+// defaultBarcodeHeight is the default height of the bars, in millimetres.
+const defaultBarcodeHeight = 10
+
+// cmdBarcode embeds the barcode, the first argument is the type, see
+// [BarcodeTypes], followed by the data, and the optional height of the bars,
+// if it is the length with the unit suffix, see [ParseLength], 10mm by
+// default.  The data is interpolated like the text, see
+// [Document.interpolate].
+func (d *Document) cmdBarcode(args ...string) error {
+	if len(args) < 2 {
+		return errors.New("expected the barcode type and the data")
+	}
+	kind, err := ParseBarcodeType(args[0])
+	if err != nil {
+		return err
+	}
+	args = args[1:]
+	height := MMToDots(defaultBarcodeHeight, d.dpi)
+	if last := args[len(args)-1]; len(args) > 1 {
+		if h, ok := parseUnitLength(last, d.dpi); ok {
+			if h == 0 {
+				return fmt.Errorf("barcode height %s must be positive", last)
+			}
+			height, args = h, args[:len(args)-1]
+		}
+	}
+	data, err := d.interpolate(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	inner := d.width - 2*d.margin
+	img, err := Barcode(kind, data, inner, height)
+	if err != nil {
+		return err
+	}
+	line := centre(img, inner)
+	if d.margin > 0 {
+		line = inset(line, d.margin, d.width)
+	}
+	d.c.AppendImageDither(line, DitherThresholdFn(DefaultThreshold))
+	return nil
+}
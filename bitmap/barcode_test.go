@@ -0,0 +1,104 @@
+package bitmap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBarcode(t *testing.T) {
+	tests := []struct {
+		name      string
+		kind      BarcodeType
+		data      string
+		wantWidth int // in modules, with the quiet zones
+		wantErr   bool
+	}{
+		// start, 5 characters and the checksum of 11 modules, stop of 13.
+		{name: "code128", kind: Code128, data: "hello", wantWidth: 11*7 + 13 + 2*barcodeQuietZone},
+		{name: "ean13", kind: EAN13, data: "400638133393", wantWidth: 95 + 2*barcodeQuietZone},
+		{name: "ean13 with check digit", kind: EAN13, data: "4006381333931", wantWidth: 95 + 2*barcodeQuietZone},
+		{name: "ean13 wrong check digit", kind: EAN13, data: "4006381333932", wantErr: true},
+		{name: "ean13 letters", kind: EAN13, data: "40063813339x", wantErr: true},
+		// start, 3 characters and stop of 13 modules with the gaps.
+		{name: "code39", kind: Code39, data: "ABC", wantWidth: 5*13 - 1 + 2*barcodeQuietZone},
+		{name: "no data", kind: Code128, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Barcode(tt.kind, tt.data, 384, 40)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			scale := 384 / tt.wantWidth
+			assert.Equal(t, tt.wantWidth*scale, img.Bounds().Dx())
+			assert.Equal(t, 40+barcodeTextGap+13, img.Bounds().Dy())
+			// the quiet zone is white, all supported codes start with a bar.
+			assert.False(t, isBlack(img, barcodeQuietZone*scale-1, 0))
+			assert.True(t, isBlack(img, barcodeQuietZone*scale, 0))
+			assert.True(t, isBlack(img, barcodeQuietZone*scale, 39))
+			assert.False(t, isBlack(img, barcodeQuietZone*scale, 40), "gap above the text")
+		})
+	}
+
+	_, err := Barcode(Code128, strings.Repeat("long text ", 10), 384, 40)
+	assert.Error(t, err, "code doesn't fit")
+	_, err = Barcode(Code128, "hello", 384, 0)
+	assert.Error(t, err)
+}
+
+func TestParseBarcodeType(t *testing.T) {
+	for _, s := range BarcodeTypes() {
+		kind, err := ParseBarcodeType(s)
+		require.NoError(t, err)
+		assert.Equal(t, s, string(kind))
+	}
+	kind, err := ParseBarcodeType("EAN13")
+	require.NoError(t, err)
+	assert.Equal(t, EAN13, kind)
+	_, err = ParseBarcodeType("upc")
+	assert.Error(t, err)
+}
+
+func TestDocument_Barcode(t *testing.T) {
+	tests := []struct {
+		name       string
+		script     string
+		wantHeight int // of the bars
+		wantErr    bool
+	}{
+		{name: "default height", script: ".barcode code128 hello world", wantHeight: 80},
+		{name: "height", script: ".barcode ean13 400638133393 5mm", wantHeight: 40},
+		{name: "number is data", script: ".barcode code128 call 555", wantHeight: 80},
+		{name: "unknown type", script: ".barcode upc 123", wantErr: true},
+		{name: "no data", script: ".barcode code128", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := NewDocument(NewComposer(384), 203)
+			err := doc.Parse(strings.NewReader(tt.script + "\n"))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			img := doc.Image()
+			require.Equal(t, 384, img.Bounds().Dx())
+			// find the first bar, and measure its height.
+			x := 0
+			for x < 384 && !isBlack(img, x, 0) {
+				x++
+			}
+			require.Less(t, x, 384, "no bars")
+			y := 0
+			for y < img.Bounds().Dy() && isBlack(img, x, y) {
+				y++
+			}
+			assert.Equal(t, tt.wantHeight, y)
+		})
+	}
+}
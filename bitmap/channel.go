@@ -0,0 +1,108 @@
+package bitmap
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+)
+
+// Channel is the weights of the red, green and blue channels, with which
+// the colour image is converted to grey before dithering.  The single
+// channel hides the marks of its colour: the red stamp or the yellow
+// highlighter is almost white in the red channel, and the text under it
+// stays readable.  The zero Channel leaves the conversion to the dither
+// function.
+type Channel struct {
+	R, G, B float64
+}
+
+var (
+	ChannelLuminance = Channel{R: 0.299, G: 0.587, B: 0.114} // ITU-R BT.601 luma
+	ChannelRed       = Channel{R: 1}
+	ChannelGreen     = Channel{G: 1}
+	ChannelBlue      = Channel{B: 1}
+)
+
+var channels = map[string]Channel{
+	"luminance": ChannelLuminance,
+	"red":       ChannelRed,
+	"green":     ChannelGreen,
+	"blue":      ChannelBlue,
+}
+
+// ParseChannel returns the channel of the name: "red", "green", "blue",
+// "luminance", or the weights of the channels "R,G,B", i.e. "0.2,0.7,0.1".
+// The empty string is the zero Channel.
+func ParseChannel(s string) (Channel, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return Channel{}, nil
+	}
+	if ch, ok := channels[s]; ok {
+		return ch, nil
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return Channel{}, fmt.Errorf("unknown channel %q, expected red, green, blue, luminance or the weights R,G,B", s)
+	}
+	var w [3]float64
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil || v < 0 {
+			return Channel{}, fmt.Errorf("invalid channel weight %q, want a non-negative number", p)
+		}
+		w[i] = v
+	}
+	if w[0]+w[1]+w[2] == 0 {
+		return Channel{}, fmt.Errorf("channel weights %q are all zero", s)
+	}
+	return Channel{R: w[0], G: w[1], B: w[2]}, nil
+}
+
+// String returns the name of the channel, or its weights.
+func (c Channel) String() string {
+	for name, ch := range channels {
+		if c == ch {
+			return name
+		}
+	}
+	f := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	return f(c.R) + "," + f(c.G) + "," + f(c.B)
+}
+
+// IsZero reports whether the channel is not set.
+func (c Channel) IsZero() bool {
+	return c == Channel{}
+}
+
+// Gray returns the grey image of the channel, the weights are normalised,
+// so that white stays white.  The zero Channel returns the image unchanged.
+func (c Channel) Gray(img image.Image) image.Image {
+	sum := c.R + c.G + c.B
+	if c.IsZero() || sum <= 0 {
+		return img
+	}
+	r, g, b := c.R/sum, c.G/sum, c.B/sum
+	bounds := img.Bounds()
+	dst := image.NewGray(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			cr, cg, cb, _ := img.At(x, y).RGBA()
+			v := r*float64(cr) + g*float64(cg) + b*float64(cb)
+			dst.Pix[dst.PixOffset(x, y)] = uint8(min(v, 0xffff) / 0x101)
+		}
+	}
+	return dst
+}
+
+// Dither returns the dither function, that converts the image to grey with
+// the channel before dithering it with dfn.  The zero Channel returns dfn.
+func (c Channel) Dither(dfn DitherFunc) DitherFunc {
+	if c.IsZero() {
+		return dfn
+	}
+	return func(img image.Image, gamma float64) image.Image {
+		return dfn(c.Gray(img), gamma)
+	}
+}
@@ -0,0 +1,82 @@
+package bitmap
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestParseChannel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Channel
+	}{
+		{"", Channel{}},
+		{"red", ChannelRed},
+		{" Green ", ChannelGreen},
+		{"blue", ChannelBlue},
+		{"luminance", ChannelLuminance},
+		{"0.2, 0.7, 0.1", Channel{R: 0.2, G: 0.7, B: 0.1}},
+	}
+	for _, tt := range tests {
+		got, err := ParseChannel(tt.in)
+		if err != nil {
+			t.Errorf("ParseChannel(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseChannel(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"purple", "1,2", "0,0,0", "-1,1,1", "a,b,c"} {
+		if _, err := ParseChannel(in); err == nil {
+			t.Errorf("ParseChannel(%q): expected an error", in)
+		}
+	}
+}
+
+func TestChannelString(t *testing.T) {
+	for _, s := range []string{"red", "luminance", "0.2,0.7,0.1"} {
+		ch, err := ParseChannel(s)
+		if err != nil {
+			t.Fatalf("ParseChannel(%q): %v", s, err)
+		}
+		if got := ch.String(); got != s {
+			t.Errorf("String() = %q, want %q", got, s)
+		}
+	}
+}
+
+func TestChannelGray(t *testing.T) {
+	// the black text under the red stamp.
+	img := image.NewRGBA(image.Rect(0, 0, 3, 1))
+	img.Set(0, 0, color.White)
+	img.Set(1, 0, color.RGBA{R: 0xe0, G: 0x20, B: 0x20, A: 0xff}) // stamp
+	img.Set(2, 0, color.Black)
+
+	gray := ChannelRed.Gray(img)
+	for x, want := range []uint8{0xff, 0xe0, 0x00} {
+		if got := color.GrayModel.Convert(gray.At(x, 0)).(color.Gray).Y; got != want {
+			t.Errorf("red channel at %d = %#x, want %#x", x, got, want)
+		}
+	}
+	// the stamp is printed as white, the text stays black.
+	bw := ChannelRed.Dither(DitherThresholdFn(DefaultThreshold))(img, DefaultGamma)
+	if PixelBit(bw, 1, 0, DefaultThreshold) {
+		t.Error("the red stamp is printed")
+	}
+	if !PixelBit(bw, 2, 0, DefaultThreshold) {
+		t.Error("the text is not printed")
+	}
+	// the weights are normalised: white stays white.
+	if got := (Channel{R: 2, G: 2, B: 2}).Gray(img).(*image.Gray).Pix[0]; got != 0xff {
+		t.Errorf("white = %#x, want 0xff", got)
+	}
+}
+
+func TestChannelZero(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	if got := (Channel{}).Gray(img); got != image.Image(img) {
+		t.Error("zero channel must return the image unchanged")
+	}
+}
@@ -0,0 +1,106 @@
+package bitmap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rusq/thermoprint/chart"
+)
+
+const (
+	dcChart    = ".chart"
+	dcEndChart = ".endchart"
+)
+
+const (
+	// sparklineHeight is the default height of the sparkline, in
+	// millimetres.
+	sparklineHeight = 6.0
+	// chartHeight is the default height of the bar and line charts, in
+	// millimetres.
+	chartHeight = 30.0
+	// chartGap is the blank space above and below the chart, in pixels.
+	chartGap = 4
+)
+
+// chartBlock is the open .chart block, that collects the CSV data until
+// .endchart.
+type chartBlock struct {
+	line   int // line of the .chart command
+	kind   chart.Kind
+	height int
+	data   strings.Builder
+}
+
+// AppendChart renders the chart of the composer width and the given height,
+// and appends it at the bottom of the canvas.
+func (c *Composer) AppendChart(kind chart.Kind, data chart.Data, height int) error {
+	img, err := chart.Render(kind, data, c.dst.Bounds().Dx(), height)
+	if err != nil {
+		return err
+	}
+	c.AppendImageDither(img, nil)
+	return nil
+}
+
+// cmdChart opens the chart block, the lines until .endchart are the CSV
+// data of the chart, see [chart.ParseCSV].  The arguments are the kind of
+// the chart and the optional height, see [ParseLength].
+func (d *Document) cmdChart(args ...string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("invalid argument count, expected 1 or 2, provided: %d", len(args))
+	}
+	kind, err := chart.ParseKind(args[0])
+	if err != nil {
+		return err
+	}
+	height := MMToDots(chartHeight, d.dpi)
+	if kind == chart.Sparkline {
+		height = MMToDots(sparklineHeight, d.dpi)
+	}
+	if len(args) == 2 {
+		if height, err = ParseLength(args[1], d.dpi); err != nil {
+			return err
+		}
+		if height == 0 {
+			return fmt.Errorf("chart height %s must be positive", args[1])
+		}
+	}
+	d.chart = &chartBlock{kind: kind, height: height}
+	return nil
+}
+
+// chartLine adds the line to the open chart block, or renders the chart at
+// .endchart.  The data lines are interpolated like the text.
+func (d *Document) chartLine(text string) error {
+	if text != dcEndChart {
+		if strings.HasPrefix(text, dcChart+" ") {
+			return fmt.Errorf("%s inside the chart started on line %d", dcChart, d.chart.line)
+		}
+		line, err := d.interpolate(text)
+		if err != nil {
+			return err
+		}
+		d.chart.data.WriteString(line + "\n")
+		return nil
+	}
+	blk := d.chart
+	d.chart = nil
+	data, err := chart.ParseCSV(strings.NewReader(blk.data.String()))
+	if err != nil {
+		return err
+	}
+	inner := d.width - 2*d.margin
+	img, err := chart.Render(blk.kind, data, inner, blk.height)
+	if err != nil {
+		return err
+	}
+	line := centre(img, inner)
+	if d.margin > 0 {
+		line = inset(line, d.margin, d.width)
+	}
+	d.c.Feed(chartGap)
+	d.c.AppendImageDither(line, nil)
+	d.c.Feed(chartGap)
+	return nil
+}
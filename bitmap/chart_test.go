@@ -0,0 +1,67 @@
+package bitmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_Chart(t *testing.T) {
+	tests := []struct {
+		name       string
+		script     string
+		wantHeight int
+		wantErr    string
+	}{
+		{
+			name:       "sparkline",
+			script:     ".chart sparkline\n1,3,2,5\n.endchart\n",
+			wantHeight: MMToDots(sparklineHeight, 203) + 2*chartGap,
+		},
+		{
+			name:       "bar with the height",
+			script:     ".chart bar 20mm\nday,kWh\nMon,4\nTue,5\n.endchart\n",
+			wantHeight: MMToDots(20, 203) + 2*chartGap,
+		},
+		{
+			name:       "variables",
+			script:     ".chart line 100px\n1,${value}\n2,3\n.endchart\n",
+			wantHeight: 100 + 2*chartGap,
+		},
+		{
+			name:    "unknown kind",
+			script:  ".chart pie\n1,2\n.endchart\n",
+			wantErr: "unknown chart",
+		},
+		{
+			name:    "not closed",
+			script:  "text\n.chart bar\n1,2\n",
+			wantErr: "line 2: .chart without .endchart",
+		},
+		{
+			name:    "no data",
+			script:  ".chart bar\n.endchart\n",
+			wantErr: "no rows",
+		},
+		{
+			name:    "bad data",
+			script:  ".chart bar\na,1\nb,x\n.endchart\n",
+			wantErr: "line 4: chart data: row 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("value", "7")
+			img, err := parseDoc(t, tt.script)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 384, img.Bounds().Dx())
+			assert.Equal(t, tt.wantHeight, img.Bounds().Dy())
+		})
+	}
+}
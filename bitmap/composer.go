@@ -8,10 +8,10 @@ import (
 	"image"
 	"image/draw"
 	"io"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/image/font"
 
@@ -105,18 +105,25 @@ func (c *Composer) AppendImageDither(img image.Image, dfn DitherFunc) {
 }
 
 // AppendText renders text at the bottom of the image, growing the underlying
-// image canvas if needed to fit the text lines.
-func (c *Composer) AppendText(face font.Face, text string) error {
-	img, err := RenderTTF(text, face, c.dst.Bounds().Dx())
+// image canvas if needed to fit the text lines.  The options are passed to
+// [RenderTTF].
+func (c *Composer) AppendText(face font.Face, text string, opts ...TextOption) error {
+	img, err := RenderTTF(text, face, c.dst.Bounds().Dx(), opts...)
 	if err != nil {
 		return err
 	}
+	c.appendText(img)
+	return nil
+}
+
+// appendText appends the rendered text, it is dithered only if the text
+// dithering is enabled.
+func (c *Composer) appendText(img image.Image) {
 	if c.ditherText {
 		c.AppendImageDither(img, c.ditherFunc)
 	} else {
 		c.AppendImageDither(img, nil) // no dithering for text
 	}
-	return nil
 }
 
 // Image returns the composed image.
@@ -130,56 +137,85 @@ func (c *Composer) Bounds() image.Rectangle {
 }
 
 const (
-	dcImage  = ".image"
-	dcImageS = ".im"
-	dcFont   = ".font"
-	dcFontS  = ".ft"
-	dcAlign  = ".align"
-	dcAlignS = ".al"
+	dcImage   = ".image"
+	dcImageS  = ".im"
+	dcFont    = ".font"
+	dcFontS   = ".ft"
+	dcAlign   = ".align"
+	dcAlignS  = ".al"
+	dcExec    = ".exec"
+	dcCut     = ".cut"
+	dcSpace   = ".space"
+	dcMargin  = ".margin"
+	dcTitle   = ".title"
+	dcQR      = ".qr"
+	dcBarcode = ".barcode"
 )
 
 var commands = map[string]func(doc *Document, args ...string) error{
-	dcImage:  (*Document).cmdImage, // embed image
-	dcImageS: (*Document).cmdImage, // embed image
-	dcFont:   (*Document).cmdFont,  // set font
-	dcFontS:  (*Document).cmdFont,  // set font
-	dcAlign:  (*Document).cmdAlign, // align text
-	dcAlignS: (*Document).cmdAlign, // align text
+	dcImage:   (*Document).cmdImage,   // embed image
+	dcImageS:  (*Document).cmdImage,   // embed image
+	dcFont:    (*Document).cmdFont,    // set font
+	dcFontS:   (*Document).cmdFont,    // set font
+	dcAlign:   (*Document).cmdAlign,   // align text
+	dcAlignS:  (*Document).cmdAlign,   // align text
+	dcExec:    (*Document).cmdExec,    // embed command output, see WithExec
+	dcCut:     (*Document).cmdCut,     // start a new page
+	dcSpace:   (*Document).cmdSpace,   // blank space
+	dcMargin:  (*Document).cmdMargin,  // left and right margins
+	dcTitle:   (*Document).cmdTitle,   // document title, not printed
+	dcQR:      (*Document).cmdQR,      // QR code
+	dcBarcode: (*Document).cmdBarcode, // linear barcode
+	dcRule:    (*Document).cmdRule,    // horizontal rule
+	dcInvert:  (*Document).cmdInvert,  // white on black text
+	dcColumns: (*Document).cmdColumns, // text in columns
+	dcChart:   (*Document).cmdChart,   // chart of the CSV data until .endchart
+	dcFrame:   (*Document).cmdFrame,   // decorative frame
 }
 
-type textAlign int
-
-const (
-	alignLeft textAlign = iota
-	alignCenter
-	alignRight
-)
-
 // Document is an abstraction that allows to manipulate composer with simple
 // text scripts.
 type Document struct {
-	c         *Composer
-	dpi       float64
-	width     int
-	alignment textAlign // current text alignment
-	font      font.Face // selected font
-	buf       bytes.Buffer
+	c          *Composer
+	dpi        float64
+	width      int
+	alignment  Alignment   // current text alignment
+	margin     int         // left and right margins, pixels
+	invert     bool        // white on black text
+	columns    int         // number of text columns, 0 or 1 is no columns
+	chart      *chartBlock // open .chart block
+	frame      FrameStyle  // open .frame around the content
+	frameTop   int         // canvas row, where the open .frame starts
+	imageFrame FrameStyle  // frame of the images, .frame image
+	font       font.Face   // selected font
+	buf        bytes.Buffer
+	exec       *execPolicy       // nil, unless .exec is enabled
+	vars       map[string]string // variables, see WithVariables
+	now        func() time.Time  // current time for ${date:...}
+	cond       []condFrame       // open .if blocks
+	pages      []image.Image     // pages ended with .cut
+	title      string            // set with .title
+	fetch      FetchFunc         // nil, unless remote images are enabled
 }
 
 // NewDocument creates a new document over the composer.
-func NewDocument(c *Composer, dpi float64) *Document {
-	return &Document{
+func NewDocument(c *Composer, dpi float64, opt ...DocumentOption) *Document {
+	d := &Document{
 		c:         c,
 		dpi:       dpi,
 		width:     c.Bounds().Dx(),
-		alignment: alignLeft,
+		alignment: AlignLeft,
 		font:      fontmgr.DefaultFont,
+		now:       time.Now,
+	}
+	for _, o := range opt {
+		o(d)
 	}
+	return d
 }
 
 // WriteString adds a line of text to the buffer with the current alignment.
 func (d *Document) WriteString(s string) (n int, err error) {
-	// TODO: alignment
 	return d.buf.WriteString(s)
 }
 
@@ -188,7 +224,10 @@ func (d *Document) flush() error {
 	if d.buf.Len() == 0 {
 		return nil
 	}
-	if err := d.c.AppendText(d.font, d.buf.String()); err != nil {
+	if d.columns > 1 {
+		return d.flushColumns()
+	}
+	if err := d.c.AppendText(d.font, d.buf.String(), d.textOptions()...); err != nil {
 		return fmt.Errorf("append text: %w", err)
 	}
 	d.buf.Reset()
@@ -203,12 +242,36 @@ func (d *Document) Parse(r io.Reader) error {
 		if text == "" {
 			continue // skip empty lines
 		}
+		if d.chart != nil {
+			if err := d.chartLine(text); err != nil {
+				return fmt.Errorf("line %d: %w", n, err)
+			}
+			continue
+		}
+		if text[0] == '.' {
+			if ok, err := d.conditional(n, text); ok {
+				if err != nil {
+					return fmt.Errorf("line %d: %w", n, err)
+				}
+				continue
+			}
+		}
+		if !d.active() {
+			continue // inside the false branch of .if
+		}
 		if text[0] == '.' {
 			if err := d.parseCommand(text); err != nil {
 				return fmt.Errorf("line %d: %w", n, err)
 			}
+			if d.chart != nil {
+				d.chart.line = n // .chart opened the block
+			}
 			continue
 		}
+		text, err := d.interpolate(text)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
 		if _, err := d.WriteString(text + "\n"); err != nil {
 			return err
 		}
@@ -216,6 +279,12 @@ func (d *Document) Parse(r io.Reader) error {
 	if err := s.Err(); err != nil {
 		return err
 	}
+	if len(d.cond) > 0 {
+		return fmt.Errorf("line %d: .if without .endif", d.cond[len(d.cond)-1].line)
+	}
+	if d.chart != nil {
+		return fmt.Errorf("line %d: %s without %s", d.chart.line, dcChart, dcEndChart)
+	}
 	if err := d.flush(); err != nil {
 		return fmt.Errorf("flush document: %w", err)
 	}
@@ -238,23 +307,43 @@ func (d *Document) parseCommand(text string) error {
 	return nil
 }
 
+// cmdTitle sets the title of the document, that names the print job.  The
+// title is interpolated like the text, see [Document.interpolate].
+func (d *Document) cmdTitle(args ...string) error {
+	title, err := d.interpolate(strings.TrimSpace(strings.Join(args, " ")))
+	if err != nil {
+		return err
+	}
+	if title == "" {
+		return errors.New("empty title")
+	}
+	d.title = title
+	return nil
+}
+
+// Title returns the title of the document set with the .title command, or
+// an empty string.
+func (d *Document) Title() string {
+	return d.title
+}
+
 func (d *Document) cmdAlign(args ...string) error {
 	if len(args) == 0 {
 		return errors.New("no alignment instruction")
 	}
 	switch args[0] {
 	case "left", "l":
-		return d.align(alignLeft)
+		return d.align(AlignLeft)
 	case "right", "r":
-		return d.align(alignRight)
+		return d.align(AlignRight)
 	case "center", "c":
-		return d.align(alignCenter)
+		return d.align(AlignCenter)
 	default:
 		return fmt.Errorf("unknown alignment %q", args[0])
 	}
 }
 
-func (d *Document) align(a textAlign) error {
+func (d *Document) align(a Alignment) error {
 	if d.alignment == a {
 		return nil // already aligned
 	}
@@ -265,24 +354,45 @@ func (d *Document) align(a textAlign) error {
 	return nil
 }
 
+// cmdImage embeds the image, the optional argument is the width of the
+// image, see [ParseLength], by default the image is fitted within the
+// margins.
 func (d *Document) cmdImage(args ...string) error {
-	const numArgs = 1
-	if len(args) != numArgs {
-		return fmt.Errorf("invalid argument count, expected %d, provided: %d", numArgs, len(args))
+	if argc := len(args); argc < 1 || 2 < argc {
+		return fmt.Errorf("invalid argument count, expected 1 or 2, provided: %d", argc)
 	}
-	filename := args[0]
-	f, err := os.Open(filename)
+	img, err := d.loadImage(args[0])
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	img, _, err := image.Decode(f)
-	if err != nil {
-		return err
+	inner := d.width - 2*d.margin - 2*d.imageFrame.Inset()
+	if inner < 1 {
+		return errors.New("no room for the framed image")
+	}
+	if len(args) > 1 {
+		w, err := ParseLength(args[1], d.dpi)
+		if err != nil {
+			return err
+		}
+		if w == 0 || w > inner {
+			return fmt.Errorf("image width %s must be between 1 and %d pixels", args[1], inner)
+		}
+		img = ScaleToWidth(img, w)
 	}
 	if err := d.flush(); err != nil {
 		return err
 	}
+	if d.imageFrame != FrameNone {
+		// the frame is around the image, not the canvas it is fitted on.
+		if img.Bounds().Dx() > inner {
+			img = ResizeToFit(img, inner)
+		}
+		img = Frame(img, d.imageFrame)
+		inner += 2 * d.imageFrame.Inset()
+	}
+	if d.margin > 0 {
+		img = inset(ResizeToFit(img, inner), d.margin, d.width)
+	}
 	d.c.AppendImage(img)
 	return nil
 }
@@ -330,14 +440,27 @@ func (d *Document) cmdFont(args ...string) error {
 // For compatibility, errors encountered while flushing buffered text are not
 // returned. Call [Document.Render] when the error must be handled.
 func (d *Document) Image() image.Image {
-	_ = d.flush()
-	return d.c.Image()
+	img, _ := d.Render()
+	if img == nil {
+		return d.c.Image()
+	}
+	return img
 }
 
-// Render flushes buffered text and returns the document image.
+// Render flushes buffered text and returns the document image.  If the
+// document has several pages, see [Document.Pages], they are joined into
+// one image.
 func (d *Document) Render() (image.Image, error) {
-	if err := d.flush(); err != nil {
+	pages, err := d.Pages()
+	if err != nil {
 		return nil, err
 	}
-	return d.c.Image(), nil
+	if len(pages) == 1 {
+		return pages[0], nil
+	}
+	c := NewComposer(d.width)
+	for _, pg := range pages {
+		c.AppendImageDither(pg, nil)
+	}
+	return c.Image(), nil
 }
@@ -73,7 +73,7 @@ func TestDocument_ParseImageCommandRequiresArgument(t *testing.T) {
 
 			require.Error(t, err)
 			assert.ErrorContains(t, err, "line 1")
-			assert.ErrorContains(t, err, "invalid argument count, expected 1, provided: 0")
+			assert.ErrorContains(t, err, "invalid argument count, expected 1 or 2, provided: 0")
 		})
 	}
 }
@@ -157,3 +157,12 @@ func TestComposer_appendImageDither(t *testing.T) {
 		})
 	}
 }
+
+func TestDocument_Title(t *testing.T) {
+	doc := NewDocument(NewComposer(64), 203, WithVariables(map[string]string{"shop": "Bakery"}))
+
+	require.NoError(t, doc.Parse(strings.NewReader(".title ${shop} receipt\nbread\n")))
+
+	assert.Equal(t, "Bakery receipt", doc.Title())
+	assert.Error(t, NewDocument(NewComposer(64), 203).Parse(strings.NewReader(".title\n")))
+}
@@ -4,6 +4,8 @@ import (
 	"image"
 	"image/color"
 	"sort"
+	"strings"
+	"sync"
 
 	"github.com/disintegration/imaging"
 	"github.com/makeworld-the-better-one/dither/v2"
@@ -12,35 +14,63 @@ import (
 
 type DitherFunc func(img image.Image, gamma float64) image.Image
 
-var ditherFunctions = map[string]func(image.Image, float64) image.Image{
-	"floyd-steinberg": DFloydSteinberg,
-	"atkinson":        DAtkinson,
-	"stucki":          DStucki,
-	"bayer":           DBayer,
-	"no-dither":       DitherThresholdFn(DefaultThreshold),
+// DefaultDither is the name of the default dither function, see
+// [DitherDefault].
+const DefaultDither = "floyd-steinberg"
+
+var (
+	ditherMu        sync.RWMutex
+	ditherFunctions = map[string]func(image.Image, float64) image.Image{
+		"floyd-steinberg": DFloydSteinberg,
+		"atkinson":        DAtkinson,
+		"stucki":          DStucki,
+		"bayer":           DBayer,
+		"hatch":           DHatch,
+		"no-dither":       DitherThresholdFn(DefaultThreshold),
+	}
+)
+
+// DitherName returns the canonical name of the dither function: the names
+// are case-insensitive, and the underscores are the same as the dashes, so
+// that "Floyd_Steinberg" names "floyd-steinberg".  The parameters of the
+// name, see [ParseDither], are kept, "Bayer: 4X4" is "bayer:4x4".
+func DitherName(name string) string {
+	parts := strings.Split(strings.ToLower(name), ":")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	parts[0] = strings.ReplaceAll(parts[0], "_", "-")
+	return strings.Join(parts, ":")
 }
 
-// DitherFunction returns a registered dither function by name.
+// DitherFunction returns a registered dither function by name, see
+// [DitherName] and [ParseDither].  The empty name is the default dither
+// function.
 func DitherFunction(name string) (DitherFunc, bool) {
-	if name == "" {
-		return DitherDefault, true
-	}
-	fn, ok := ditherFunctions[name]
-	if !ok {
+	fn, err := ParseDither(name)
+	if err != nil {
 		return nil, false // function not found
 	}
 	return fn, true
 }
 
 // RegisterDitherFunction allows to register a new dither function by name.
+// The function is available everywhere the dither function is selected by
+// name, i.e. the -dither flag of tp, the printer drivers and the IPP jobs.
 func RegisterDitherFunction(name string, fn DitherFunc) {
+	name = DitherName(name)
 	if name == "" {
 		panic("dither function name cannot be empty")
 	}
 	if fn == nil {
 		panic("dither function cannot be nil")
 	}
-	if _, exists := ditherFunctions[name]; exists {
+	if strings.Contains(name, ":") {
+		panic("dither function name cannot contain a colon: " + name)
+	}
+	ditherMu.Lock()
+	defer ditherMu.Unlock()
+	if registeredLocked(name) {
 		panic("dither function already registered: " + name)
 	}
 	ditherFunctions[name] = fn
@@ -49,10 +79,17 @@ func RegisterDitherFunction(name string, fn DitherFunc) {
 // AllDitherFunctions returns a sorted list of all available dither function
 // names.
 func AllDitherFunctions() []string {
-	keys := make([]string, 0, len(ditherFunctions))
+	ditherMu.RLock()
+	defer ditherMu.RUnlock()
+	keys := make([]string, 0, len(ditherFunctions)+len(ditherFactories))
 	for k := range ditherFunctions {
 		keys = append(keys, k)
 	}
+	for k := range ditherFactories {
+		if _, ok := ditherFunctions[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
 	sort.Strings(keys) // sort for consistent order
 	return keys
 }
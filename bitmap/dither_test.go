@@ -3,6 +3,7 @@ package bitmap
 import (
 	"image"
 	"image/color"
+	"slices"
 	"testing"
 )
 
@@ -62,3 +63,27 @@ func assertBlackWhite(t *testing.T, img image.Image) {
 		}
 	}
 }
+
+func TestDitherFunction_Names(t *testing.T) {
+	for _, name := range []string{"", "floyd-steinberg", "Floyd_Steinberg", " ATKINSON "} {
+		if _, ok := DitherFunction(name); !ok {
+			t.Errorf("DitherFunction(%q) is not found", name)
+		}
+	}
+	if _, ok := DitherFunction("no-such-dither"); ok {
+		t.Error("DitherFunction of the unknown name is found")
+	}
+	if _, ok := DitherFunction(DefaultDither); !ok {
+		t.Errorf("the default %q is not registered", DefaultDither)
+	}
+}
+
+func TestRegisterDitherFunction(t *testing.T) {
+	RegisterDitherFunction("Test_Threshold", DitherThresholdFn(64))
+	if !slices.Contains(AllDitherFunctions(), "test-threshold") {
+		t.Fatalf("AllDitherFunctions() = %v, want the canonical name of the registered function", AllDitherFunctions())
+	}
+	if _, ok := DitherFunction("test_threshold"); !ok {
+		t.Fatal("the registered function is not found")
+	}
+}
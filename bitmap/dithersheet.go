@@ -0,0 +1,32 @@
+package bitmap
+
+import (
+	"errors"
+	"image"
+
+	"github.com/rusq/thermoprint/fontmgr"
+)
+
+// DitherSheet returns the comparison sheet of the dithering functions: the
+// image processed by every registered dither function with the given gamma,
+// each under the label with the name of the function.  Images wider than
+// width are scaled down to fit.
+func DitherSheet(img image.Image, width int, gamma float64) (image.Image, error) {
+	if img == nil || img.Bounds().Empty() {
+		return nil, errors.New("empty image")
+	}
+	if img.Bounds().Dx() > width {
+		img = ResizeToFit(img, width)
+	}
+	c := NewComposer(width)
+	for _, name := range AllDitherFunctions() {
+		if err := c.AppendText(fontmgr.DefaultFont, name); err != nil {
+			return nil, err
+		}
+		dfn, _ := DitherFunction(name)
+		// the dithered image is black and white, the default threshold
+		// leaves it as is.
+		c.AppendImageDither(dfn(img, gamma), nil)
+	}
+	return c.Image(), nil
+}
@@ -0,0 +1,33 @@
+package bitmap
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/rusq/thermoprint/fontmgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDitherSheet(t *testing.T) {
+	src := testColorImage(image.Rect(0, 0, 128, 20), color.Gray{Y: 128})
+
+	t.Run("a section per function", func(t *testing.T) {
+		sheet, err := DitherSheet(src, 64, DefaultGamma)
+		require.NoError(t, err)
+
+		// the image is scaled down to 64x10.
+		var want int
+		for _, name := range AllDitherFunctions() {
+			label, err := RenderTTF(name, fontmgr.DefaultFont, 64)
+			require.NoError(t, err)
+			want += label.Bounds().Dy() + 10
+		}
+		assert.Equal(t, image.Rect(0, 0, 64, want), sheet.Bounds())
+	})
+	t.Run("empty image", func(t *testing.T) {
+		_, err := DitherSheet(image.NewGray(image.Rectangle{}), 64, DefaultGamma)
+		assert.Error(t, err)
+	})
+}
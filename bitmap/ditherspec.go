@@ -0,0 +1,191 @@
+package bitmap
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/makeworld-the-better-one/dither/v2"
+)
+
+// DitherFactory makes the dither function with the parameters of the dither
+// name, i.e. "4x4" and "0.8" of "bayer:4x4:0.8".  It is called with no
+// parameters for the plain name of the function, that is not registered
+// with [RegisterDitherFunction].
+type DitherFactory func(params ...string) (DitherFunc, error)
+
+// ditherFactories are the dither functions, that accept the parameters.
+var ditherFactories = map[string]DitherFactory{
+	"atkinson":  diffusionFactory(dither.Atkinson, 3.0),
+	"stucki":    diffusionFactory(dither.Stucki, 3.5),
+	"bayer":     bayerFactory,
+	"threshold": thresholdFactory,
+}
+
+// RegisterDitherFactory registers the dither function, that accepts the
+// parameters, by name, see [ParseDither].
+func RegisterDitherFactory(name string, f DitherFactory) {
+	name = DitherName(name)
+	if name == "" {
+		panic("dither factory name cannot be empty")
+	}
+	if f == nil {
+		panic("dither factory cannot be nil")
+	}
+	if strings.Contains(name, ":") {
+		panic("dither factory name cannot contain a colon: " + name)
+	}
+	ditherMu.Lock()
+	defer ditherMu.Unlock()
+	if registeredLocked(name) {
+		panic("dither function already registered: " + name)
+	}
+	ditherFactories[name] = f
+}
+
+// registeredLocked reports whether the name is taken in either registry.
+func registeredLocked(name string) bool {
+	_, fnOK := ditherFunctions[name]
+	_, factoryOK := ditherFactories[name]
+	return fnOK || factoryOK
+}
+
+// ParseDither returns the dither function of the name, that may carry the
+// parameters after the colons:
+//
+//	bayer:4x4:0.8    - 4x4 Bayer matrix with the strength 0.8
+//	bayer:16         - 16x16 Bayer matrix
+//	atkinson:0.8     - Atkinson diffusion with the strength 0.8
+//	threshold:160    - no dithering, pixels darker than 160 are black
+//
+// The empty name is the default dither function.
+func ParseDither(name string) (DitherFunc, error) {
+	name = DitherName(name)
+	if name == "" {
+		return DitherDefault, nil
+	}
+	fname, params, _ := strings.Cut(name, ":")
+	ditherMu.RLock()
+	fn, fnOK := ditherFunctions[fname]
+	factory, factoryOK := ditherFactories[fname]
+	ditherMu.RUnlock()
+	if !fnOK && !factoryOK {
+		return nil, fmt.Errorf("unknown dither function: %s", fname)
+	}
+	if params == "" && fnOK {
+		return fn, nil
+	}
+	if !factoryOK {
+		return nil, fmt.Errorf("dither function %s has no parameters", fname)
+	}
+	var pp []string
+	if params != "" {
+		pp = strings.Split(params, ":")
+	}
+	fn, err := factory(pp...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return fn, nil
+}
+
+var errTooManyParams = errors.New("too many parameters")
+
+// parseStrength parses the strength of the dithering, the value in (0, 1].
+func parseStrength(s string) (float32, error) {
+	v, err := strconv.ParseFloat(s, 32)
+	if err != nil || v <= 0 || v > 1 {
+		return 0, fmt.Errorf("invalid strength %q, want a number in (0, 1]", s)
+	}
+	return float32(v), nil
+}
+
+// diffusionFactory returns the factory of the error diffusion dithering,
+// the parameter is the strength.
+func diffusionFactory(matrix dither.ErrorDiffusionMatrix, defaultGamma float64) DitherFactory {
+	return func(params ...string) (DitherFunc, error) {
+		switch len(params) {
+		case 0:
+			return diffusionDither(matrix, defaultGamma), nil
+		case 1:
+			strength, err := parseStrength(params[0])
+			if err != nil {
+				return nil, err
+			}
+			return diffusionDither(dither.ErrorDiffusionStrength(matrix, strength), defaultGamma), nil
+		default:
+			return nil, errTooManyParams
+		}
+	}
+}
+
+// bayerFactory is the factory of the Bayer ordered dithering, the parameters
+// are the size of the matrix, "4x4" or "4", and the strength.
+func bayerFactory(params ...string) (DitherFunc, error) {
+	if len(params) > 2 {
+		return nil, errTooManyParams
+	}
+	var (
+		x, y     uint    = 8, 8
+		strength float32 = 1.0
+	)
+	if len(params) > 0 {
+		var err error
+		if x, y, err = parseBayerSize(params[0]); err != nil {
+			return nil, err
+		}
+	}
+	if len(params) > 1 {
+		var err error
+		if strength, err = parseStrength(params[1]); err != nil {
+			return nil, err
+		}
+	}
+	return patternDither(dither.Bayer(x, y, strength), 3.5), nil
+}
+
+// maxBayerSize is the largest side of the Bayer matrix.
+const maxBayerSize = 64
+
+// parseBayerSize parses the size of the Bayer matrix, the sides must be the
+// powers of two, or 3x3, 3x5 and 5x3, the sizes supported by
+// [dither.Bayer].
+func parseBayerSize(s string) (x, y uint, err error) {
+	xs, ys, ok := strings.Cut(s, "x")
+	if !ok {
+		ys = xs
+	}
+	xv, errX := strconv.ParseUint(xs, 10, 8)
+	yv, errY := strconv.ParseUint(ys, 10, 8)
+	if errX != nil || errY != nil {
+		return 0, 0, fmt.Errorf("invalid Bayer matrix size %q", s)
+	}
+	x, y = uint(xv), uint(yv)
+	isPow2 := func(v uint) bool { return v > 0 && v <= maxBayerSize && v&(v-1) == 0 }
+	switch {
+	case isPow2(x) && isPow2(y):
+	case x == 3 && y == 3, x == 3 && y == 5, x == 5 && y == 3:
+	default:
+		return 0, 0, fmt.Errorf("unsupported Bayer matrix size %q, the sides must be powers of two up to %d, or 3x3, 3x5, 5x3", s, maxBayerSize)
+	}
+	return x, y, nil
+}
+
+// thresholdFactory is the factory of the threshold "dithering", that makes
+// the pixels darker than the threshold black, the parameter is the
+// threshold 1-255.
+func thresholdFactory(params ...string) (DitherFunc, error) {
+	switch len(params) {
+	case 0:
+		return DitherThresholdFn(DefaultThreshold), nil
+	case 1:
+		v, err := strconv.ParseUint(params[0], 10, 8)
+		if err != nil || v == 0 {
+			return nil, fmt.Errorf("invalid threshold %q, want a number 1-255", params[0])
+		}
+		return DitherThresholdFn(uint8(v)), nil
+	default:
+		return nil, errTooManyParams
+	}
+}
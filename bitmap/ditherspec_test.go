@@ -0,0 +1,89 @@
+package bitmap
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+)
+
+func TestParseDither(t *testing.T) {
+	valid := []string{
+		"",
+		"bayer",
+		"bayer:4x4:0.8",
+		"Bayer: 16",
+		"bayer:3x5",
+		"atkinson:0.5",
+		"stucki:1",
+		"threshold",
+		"threshold:160",
+	}
+	for _, name := range valid {
+		if _, err := ParseDither(name); err != nil {
+			t.Errorf("ParseDither(%q): %v", name, err)
+		}
+	}
+	invalid := []string{
+		"no-such-dither:1",
+		"hatch:1",          // no parameters
+		"bayer:6x6",        // not a power of two
+		"bayer:128",        // too large
+		"bayer:4x4:1.5",    // strength out of range
+		"bayer:4x4:0.8:1",  // too many parameters
+		"atkinson:0",       // zero strength
+		"threshold:0",      // out of range
+		"threshold:256",    // out of range
+		"threshold:dark",   // not a number
+		"threshold:160:10", // too many parameters
+	}
+	for _, name := range invalid {
+		if _, err := ParseDither(name); err == nil {
+			t.Errorf("ParseDither(%q) succeeded, want an error", name)
+		}
+	}
+}
+
+func TestParseDither_Threshold(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 1, 1))
+	img.SetGray(0, 0, color.Gray{Y: 150})
+	for _, tc := range []struct {
+		name  string
+		black bool
+	}{
+		{"threshold:100", false},
+		{"threshold:200", true},
+	} {
+		fn, err := ParseDither(tc.name)
+		if err != nil {
+			t.Fatalf("ParseDither(%q): %v", tc.name, err)
+		}
+		r, _, _, _ := fn(img, DefaultGamma).At(0, 0).RGBA()
+		if got := r == 0; got != tc.black {
+			t.Errorf("%s: black = %v, want %v", tc.name, got, tc.black)
+		}
+	}
+}
+
+func TestDitherName_Params(t *testing.T) {
+	if got, want := DitherName(" Bayer : 4X4 : 0.8 "), "bayer:4x4:0.8"; got != want {
+		t.Errorf("DitherName() = %q, want %q", got, want)
+	}
+}
+
+func TestRegisterDitherFactory(t *testing.T) {
+	var got []string
+	RegisterDitherFactory("Test_Factory", func(params ...string) (DitherFunc, error) {
+		got = params
+		return DitherDefault, nil
+	})
+	if !slices.Contains(AllDitherFunctions(), "test-factory") {
+		t.Fatalf("AllDitherFunctions() = %v, want the registered factory", AllDitherFunctions())
+	}
+	if _, err := ParseDither("test_factory:a:b"); err != nil {
+		t.Fatalf("ParseDither: %v", err)
+	}
+	if !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("params = %v, want [a b]", got)
+	}
+}
@@ -0,0 +1,138 @@
+package bitmap
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Conditional document commands.
+const (
+	dcIf    = ".if"
+	dcElse  = ".else"
+	dcEndif = ".endif"
+)
+
+// condFrame is the state of the .if block.
+type condFrame struct {
+	line   int  // line of the .if, for errors
+	parent bool // the enclosing block is active
+	taken  bool // the .if branch is active
+	inElse bool // .else has been seen
+	active bool // lines of the current branch are processed
+}
+
+// active returns true if the lines at the current nesting level are
+// processed.
+func (d *Document) active() bool {
+	if len(d.cond) == 0 {
+		return true
+	}
+	return d.cond[len(d.cond)-1].active
+}
+
+// conditional processes the .if, .else and .endif commands on the line n.
+// It returns false if text is not a conditional command.
+func (d *Document) conditional(n int, text string) (bool, error) {
+	name, expr, _ := strings.Cut(text, " ")
+	switch name {
+	case dcIf:
+		parent := d.active()
+		ok := false
+		if parent { // the condition is not evaluated in the skipped blocks
+			var err error
+			if ok, err = d.eval(strings.TrimSpace(expr)); err != nil {
+				return true, fmt.Errorf("%s: %w", dcIf, err)
+			}
+		}
+		d.cond = append(d.cond, condFrame{line: n, parent: parent, taken: ok, active: ok})
+	case dcElse:
+		if len(d.cond) == 0 {
+			return true, errors.New(".else without .if")
+		}
+		top := &d.cond[len(d.cond)-1]
+		if top.inElse {
+			return true, fmt.Errorf("duplicate .else for .if on line %d", top.line)
+		}
+		top.inElse = true
+		top.active = top.parent && !top.taken
+	case dcEndif:
+		if len(d.cond) == 0 {
+			return true, errors.New(".endif without .if")
+		}
+		d.cond = d.cond[:len(d.cond)-1]
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// eval evaluates the .if condition, which is one of:
+//
+//	name             variable is defined and not empty
+//	!name            variable is undefined or empty
+//	name == value    variable equals the value
+//	name != value    variable does not equal the value
+//	name < value     numeric comparisons, also <=, > and >=
+//
+// The value is interpolated, and may be quoted, i.e. "two words".
+func (d *Document) eval(expr string) (bool, error) {
+	if expr == "" {
+		return false, errors.New("missing condition")
+	}
+	name, rest, _ := strings.Cut(expr, " ")
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		if neg, ok := strings.CutPrefix(name, "!"); ok {
+			v, _ := d.lookup(neg)
+			return v == "", nil
+		}
+		v, _ := d.lookup(name)
+		return v != "", nil
+	}
+	op, value, _ := strings.Cut(rest, " ")
+	value, err := d.interpolate(strings.TrimSpace(value))
+	if err != nil {
+		return false, err
+	}
+	if len(value) > 1 && value[0] == '"' {
+		if value, err = strconv.Unquote(value); err != nil {
+			return false, fmt.Errorf("invalid quoted value: %w", err)
+		}
+	}
+	left, _ := d.lookup(name)
+	switch op {
+	case "==":
+		return left == value, nil
+	case "!=":
+		return left != value, nil
+	case "<", "<=", ">", ">=":
+		return compareNumbers(name, left, op, value)
+	default:
+		return false, fmt.Errorf("unknown operator %q", op)
+	}
+}
+
+// compareNumbers compares the value of the variable name with the value
+// numerically.
+func compareNumbers(name, left, op, value string) (bool, error) {
+	a, err := strconv.ParseFloat(left, 64)
+	if err != nil {
+		return false, fmt.Errorf("variable %s: not a number: %q", name, left)
+	}
+	b, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return false, fmt.Errorf("not a number: %q", value)
+	}
+	switch op {
+	case "<":
+		return a < b, nil
+	case "<=":
+		return a <= b, nil
+	case ">":
+		return a > b, nil
+	default:
+		return a >= b, nil
+	}
+}
@@ -0,0 +1,113 @@
+package bitmap
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_eval(t *testing.T) {
+	t.Setenv("THERMOPRINT_EMPTY", "")
+	doc := NewDocument(NewComposer(64), 203, WithVariables(map[string]string{
+		"url":   "https://example.com",
+		"count": "3",
+		"name":  "two words",
+	}))
+	tests := []struct {
+		expr    string
+		want    bool
+		wantErr bool
+	}{
+		{expr: "url", want: true},
+		{expr: "THERMOPRINT_UNDEFINED", want: false},
+		{expr: "THERMOPRINT_EMPTY", want: false},
+		{expr: "!url", want: false},
+		{expr: "!THERMOPRINT_UNDEFINED", want: true},
+		{expr: "count == 3", want: true},
+		{expr: "count != 3", want: false},
+		{expr: `name == "two words"`, want: true},
+		{expr: "name == two words", want: true},
+		{expr: "url == ${url}", want: true},
+		{expr: "count > 2", want: true},
+		{expr: "count <= 2", want: false},
+		{expr: "count >= 3.0", want: true},
+		{expr: "name < 2", wantErr: true},
+		{expr: "count ~ 3", wantErr: true},
+		{expr: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := doc.eval(tt.expr)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDocument_ParseConditional(t *testing.T) {
+	render := func(t *testing.T, script string, vars map[string]string) image.Image {
+		t.Helper()
+		doc := NewDocument(NewComposer(64), 203, WithVariables(vars))
+		require.NoError(t, doc.Parse(strings.NewReader(script)))
+		return doc.Image()
+	}
+	const script = `header
+.if url
+link ${url}
+.if !short
+.align center
+long form
+.endif
+.else
+no link
+.endif
+footer
+`
+	tests := []struct {
+		name string
+		vars map[string]string
+		want string
+	}{
+		{"if", map[string]string{"url": "x"}, "header\nlink x\n.align center\nlong form\nfooter\n"},
+		{"nested", map[string]string{"url": "x", "short": "1"}, "header\nlink x\nfooter\n"},
+		{"else", nil, "header\nno link\nfooter\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, render(t, tt.want, nil), render(t, script, tt.vars))
+		})
+	}
+}
+
+func TestDocument_ParseConditionalErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{"else without if", "a\n.else\n", "line 2: .else without .if"},
+		{"endif without if", ".endif\n", "line 1: .endif without .if"},
+		{"unterminated", ".if a\n.if b\n.endif\n", "line 1: .if without .endif"},
+		{"duplicate else", ".if a\n.else\n.else\n.endif\n", "line 3: duplicate .else"},
+		{"bad operator", ".if a ~ b\n.endif\n", "line 1: .if: unknown operator"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := NewDocument(NewComposer(64), 203)
+			assert.ErrorContains(t, doc.Parse(strings.NewReader(tt.script)), tt.want)
+		})
+	}
+}
+
+func TestDocument_ParseConditionalSkipsCommands(t *testing.T) {
+	// commands in the false branch are not run, even if they would fail
+	doc := NewDocument(NewComposer(64), 203)
+	assert.NoError(t, doc.Parse(strings.NewReader(".if undefined_variable_xyz\n.exec date\n.image /nonexistent.png\n.if a ~ b\n.endif\n.endif\n")))
+}
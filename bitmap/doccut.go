@@ -0,0 +1,79 @@
+package bitmap
+
+import (
+	"fmt"
+	"image"
+)
+
+const (
+	// DefaultCutFeed is the paper fed after each page that ends with .cut,
+	// in millimetres, so that it can be torn off.
+	DefaultCutFeed = 10.0
+)
+
+// Cut returns the composed image and starts a new empty canvas of the same
+// width with the same options.
+func (c *Composer) Cut() image.Image {
+	img := c.dst
+	c.dst = image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), 1))
+	c.sp = image.Point{}
+	return img
+}
+
+// Feed appends the blank space of the given height in pixels.
+func (c *Composer) Feed(height int) {
+	if height <= 0 {
+		return
+	}
+	blank := image.NewRGBA(image.Rect(0, 0, c.dst.Bounds().Dx(), height))
+	for i := range blank.Pix {
+		blank.Pix[i] = 0xff
+	}
+	c.AppendImageDither(blank, nil)
+}
+
+// empty returns true if nothing has been appended to the canvas.
+func (c *Composer) empty() bool {
+	return c.sp.Y == 0
+}
+
+// cmdCut ends the page, the following lines start the new page, which is
+// printed as a separate job.  The optional argument is the feed after the
+// page, see [ParseLength], the default is [DefaultCutFeed] millimetres.
+func (d *Document) cmdCut(args ...string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("invalid argument count, expected 0 or 1, provided: %d", len(args))
+	}
+	feed := MMToDots(DefaultCutFeed, d.dpi)
+	if len(args) == 1 {
+		var err error
+		if feed, err = ParseLength(args[0], d.dpi); err != nil {
+			return fmt.Errorf("invalid feed: %w", err)
+		}
+	}
+	if err := d.flush(); err != nil {
+		return err
+	}
+	// the frame of the page is closed, and the next page is framed too.
+	frame := d.frame
+	d.closeFrame()
+	if !d.c.empty() {
+		d.c.Feed(feed)
+		d.pages = append(d.pages, d.c.Cut())
+	}
+	return d.openFrame(frame)
+}
+
+// Pages returns the pages of the document, split by the .cut command, each
+// page is meant to be printed as a separate job.  Empty pages are omitted.
+func (d *Document) Pages() ([]image.Image, error) {
+	if err := d.flush(); err != nil {
+		return nil, err
+	}
+	d.closeFrame()
+	pages := append([]image.Image(nil), d.pages...)
+	if !d.c.empty() || len(pages) == 0 {
+		pages = append(pages, d.c.Image())
+	}
+	return pages, nil
+}
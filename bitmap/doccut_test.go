@@ -0,0 +1,61 @@
+package bitmap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_Pages(t *testing.T) {
+	dpi := 203.0
+	feed := MMToDots(DefaultCutFeed, dpi)
+
+	single := func(t *testing.T, script string) int {
+		t.Helper()
+		doc := NewDocument(NewComposer(64), dpi)
+		require.NoError(t, doc.Parse(strings.NewReader(script)))
+		pages, err := doc.Pages()
+		require.NoError(t, err)
+		require.Len(t, pages, 1)
+		return pages[0].Bounds().Dy()
+	}
+	one, two := single(t, "first\n"), single(t, "second\nthird\n")
+
+	t.Run("cut", func(t *testing.T) {
+		doc := NewDocument(NewComposer(64), dpi)
+		require.NoError(t, doc.Parse(strings.NewReader("first\n.cut\nsecond\nthird\n")))
+		pages, err := doc.Pages()
+		require.NoError(t, err)
+		require.Len(t, pages, 2)
+		assert.Equal(t, one+feed, pages[0].Bounds().Dy())
+		assert.Equal(t, two, pages[1].Bounds().Dy())
+
+		img, err := doc.Render()
+		require.NoError(t, err)
+		assert.Equal(t, one+feed+two, img.Bounds().Dy(), "render joins the pages")
+	})
+	t.Run("feed", func(t *testing.T) {
+		doc := NewDocument(NewComposer(64), dpi)
+		require.NoError(t, doc.Parse(strings.NewReader("first\n.cut 0\nsecond\nthird\n.cut 5\n")))
+		pages, err := doc.Pages()
+		require.NoError(t, err)
+		require.Len(t, pages, 2, "no empty page after the last .cut")
+		assert.Equal(t, one, pages[0].Bounds().Dy())
+		assert.Equal(t, two+MMToDots(5, dpi), pages[1].Bounds().Dy())
+	})
+	t.Run("empty pages", func(t *testing.T) {
+		doc := NewDocument(NewComposer(64), dpi)
+		require.NoError(t, doc.Parse(strings.NewReader(".cut\n.cut\nfirst\n.cut\n.cut\n")))
+		pages, err := doc.Pages()
+		require.NoError(t, err)
+		assert.Len(t, pages, 1)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for _, script := range []string{".cut x\n", ".cut -1\n", ".cut 1 2\n"} {
+			doc := NewDocument(NewComposer(64), dpi)
+			assert.Error(t, doc.Parse(strings.NewReader(script)), script)
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package bitmap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultExecTimeout is the time the command started with the .exec
+	// document command may run.
+	DefaultExecTimeout = 5 * time.Second
+	// maxExecOutput is the maximum output of the command, that is
+	// embedded in the document.
+	maxExecOutput = 64 << 10
+	// execWaitDelay is the time the output of the command is waited for,
+	// once it is killed, the background processes it started may hold the
+	// output open.
+	execWaitDelay = time.Second
+)
+
+var (
+	// ErrExecDisabled is returned by the .exec document command, unless the
+	// execution is enabled with [WithExec].
+	ErrExecDisabled = errors.New("command execution is disabled")
+	// ErrExecNotAllowed is returned by the .exec document command for the
+	// command that is not in the allow list.
+	ErrExecNotAllowed = errors.New("command is not allowed")
+
+	errExecOutputTooLarge = fmt.Errorf("command output exceeds %d bytes", maxExecOutput)
+)
+
+// execEnv are the environment variables passed to the commands, the rest
+// of the environment is not exposed.
+var execEnv = []string{"PATH", "HOME", "LANG", "LC_ALL", "TZ"}
+
+// DocumentOption is a functional option for the [Document].
+type DocumentOption func(*Document)
+
+// WithExec enables the .exec document command for the commands in the
+// allow list, i.e. "fortune" or "date".  The command must be given in the
+// document exactly as it appears in the list.  It is run without the shell,
+// with the minimal environment and no input, and is killed after the
+// timeout; the standard output is embedded in the document as text.  If
+// timeout is zero, the [DefaultExecTimeout] is used.
+func WithExec(allowed []string, timeout time.Duration) DocumentOption {
+	return func(d *Document) {
+		if timeout <= 0 {
+			timeout = DefaultExecTimeout
+		}
+		d.exec = &execPolicy{allowed: slices.Clone(allowed), timeout: timeout}
+	}
+}
+
+// execPolicy restricts the commands that documents can run.
+type execPolicy struct {
+	allowed []string
+	timeout time.Duration
+}
+
+// run runs the allowed command and returns its output.
+func (p *execPolicy) run(args []string) ([]byte, error) {
+	if !slices.Contains(p.allowed, args[0]) {
+		return nil, fmt.Errorf("%w: %q", ErrExecNotAllowed, args[0])
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	var stdout, stderr limitedBuffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = environ(execEnv)
+	cmd.WaitDelay = execWaitDelay
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%s: timed out after %s", args[0], p.timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w: %s", args[0], err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// environ returns the environment with only the given variables.
+func environ(names []string) []string {
+	var env []string
+	for _, name := range names {
+		if v, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+v)
+		}
+	}
+	return env
+}
+
+// limitedBuffer is the buffer that fails to grow over maxExecOutput.
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > maxExecOutput {
+		return 0, errExecOutputTooLarge
+	}
+	return b.Buffer.Write(p)
+}
+
+// cmdExec runs the command and adds its output to the document text.
+func (d *Document) cmdExec(args ...string) error {
+	if d.exec == nil {
+		return ErrExecDisabled
+	}
+	args = strings.Fields(strings.Join(args, " "))
+	if len(args) == 0 {
+		return errors.New("no command to execute")
+	}
+	out, err := d.exec.run(args)
+	if err != nil {
+		return err
+	}
+	if text := strings.TrimRight(string(out), "\n"); text != "" {
+		if _, err := d.WriteString(text + "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
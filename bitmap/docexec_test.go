@@ -0,0 +1,96 @@
+package bitmap
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requireCommand(t *testing.T, name string) {
+	t.Helper()
+	if _, err := exec.LookPath(name); err != nil {
+		t.Skipf("%s is not available: %v", name, err)
+	}
+}
+
+func TestDocument_cmdExec(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		doc := NewDocument(NewComposer(64), 203)
+
+		err := doc.Parse(strings.NewReader(".exec echo hello\n"))
+
+		require.ErrorIs(t, err, ErrExecDisabled)
+		assert.ErrorContains(t, err, "line 1")
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		doc := NewDocument(NewComposer(64), 203, WithExec([]string{"date"}, 0))
+
+		err := doc.Parse(strings.NewReader(".exec echo hello\n"))
+
+		require.ErrorIs(t, err, ErrExecNotAllowed)
+	})
+
+	t.Run("embeds output", func(t *testing.T) {
+		requireCommand(t, "echo")
+		doc := NewDocument(NewComposer(64), 203, WithExec([]string{"echo"}, 0))
+
+		require.NoError(t, doc.cmdExec("echo", "hello", "", "world"))
+
+		assert.Equal(t, "hello world\n", doc.buf.String())
+	})
+
+	t.Run("renders", func(t *testing.T) {
+		requireCommand(t, "echo")
+		doc := NewDocument(NewComposer(64), 203, WithExec([]string{"echo"}, 0))
+
+		require.NoError(t, doc.Parse(strings.NewReader("before\n.exec echo hello\n")))
+
+		assert.Positive(t, doc.Image().Bounds().Dy())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		requireCommand(t, "sleep")
+		doc := NewDocument(NewComposer(64), 203, WithExec([]string{"sleep"}, 50*time.Millisecond))
+
+		err := doc.cmdExec("sleep", "5")
+
+		assert.ErrorContains(t, err, "timed out")
+	})
+
+	t.Run("timeout with background child", func(t *testing.T) {
+		requireCommand(t, "sh")
+		requireCommand(t, "sleep")
+		p := &execPolicy{allowed: []string{"sh"}, timeout: 50 * time.Millisecond}
+
+		start := time.Now()
+		_, err := p.run([]string{"sh", "-c", "sleep 30 & wait"})
+
+		assert.ErrorContains(t, err, "timed out")
+		assert.Less(t, time.Since(start), 10*time.Second, "the child holding the output must not block the command")
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		requireCommand(t, "env")
+		t.Setenv("THERMOPRINT_SECRET", "secret")
+		doc := NewDocument(NewComposer(64), 203, WithExec([]string{"env"}, 0))
+
+		require.NoError(t, doc.cmdExec("env"))
+
+		assert.NotContains(t, doc.buf.String(), "THERMOPRINT_SECRET")
+	})
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	var b limitedBuffer
+	_, err := b.Write(make([]byte, maxExecOutput))
+	require.NoError(t, err)
+
+	_, err = b.Write([]byte{0})
+
+	assert.ErrorIs(t, err, errExecOutputTooLarge)
+}
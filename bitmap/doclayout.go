@@ -0,0 +1,177 @@
+package bitmap
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+	"strings"
+)
+
+const (
+	dcRule    = ".rule"
+	dcInvert  = ".invert"
+	dcColumns = ".columns"
+)
+
+const (
+	// ruleThickness is the default thickness of the .rule, in pixels.
+	ruleThickness = 2
+	// ruleGap is the blank space above and below the .rule, in pixels.
+	ruleGap = 4
+	// invertPadding is the padding around the inverted text, in pixels.
+	invertPadding = 2
+	// columnGap is the gap between the columns, in pixels.
+	columnGap = 8
+	// maxColumns is the maximum number of the columns.
+	maxColumns = 4
+	// columnSep separates the cells of the line in the columns.
+	columnSep = "|"
+)
+
+// cmdRule draws the horizontal rule across the page within the margins, the
+// optional argument is the thickness, see [ParseLength], 2 pixels by
+// default.
+func (d *Document) cmdRule(args ...string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("invalid argument count, expected 0 or 1, provided: %d", len(args))
+	}
+	thickness := ruleThickness
+	if len(args) == 1 {
+		t, err := ParseLength(args[0], d.dpi)
+		if err != nil {
+			return err
+		}
+		if t == 0 {
+			return fmt.Errorf("rule thickness %s must be positive", args[0])
+		}
+		thickness = t
+	}
+	if err := d.flush(); err != nil {
+		return err
+	}
+	img := image.NewRGBA(image.Rect(0, 0, d.width, thickness+2*ruleGap))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(d.margin, ruleGap, d.width-d.margin, ruleGap+thickness), image.Black, image.Point{}, draw.Src)
+	d.c.AppendImageDither(img, nil)
+	return nil
+}
+
+// cmdInvert prints the following text white on black, "on" or no argument,
+// or black on white again, "off".
+func (d *Document) cmdInvert(args ...string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("invalid argument count, expected 0 or 1, provided: %d", len(args))
+	}
+	on := true
+	if len(args) == 1 {
+		switch args[0] {
+		case "on":
+		case "off":
+			on = false
+		default:
+			return fmt.Errorf("expected on or off, got %q", args[0])
+		}
+	}
+	if err := d.flush(); err != nil {
+		return err
+	}
+	d.invert = on
+	return nil
+}
+
+// cmdColumns lays out the following text lines in the columns, the argument
+// is the number of columns, or "off".  The cells of the line are separated
+// with "|", the first column is aligned left, the last one right, and the
+// ones in between are centred, i.e. for the item, the quantity and the
+// price on the receipt.  The line without "|" spans all columns.
+func (d *Document) cmdColumns(args ...string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("invalid argument count, expected 1, provided: %d", len(args))
+	}
+	n := 1
+	if args[0] != "off" {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 || maxColumns < n {
+			return fmt.Errorf("number of columns must be between 1 and %d, or off, got %q", maxColumns, args[0])
+		}
+	}
+	if err := d.flush(); err != nil {
+		return err
+	}
+	if inner := d.width - 2*d.margin; n > 1 && (inner-(n-1)*columnGap)/n < 1 {
+		return errors.New("no room for the columns")
+	}
+	d.columns = n
+	return nil
+}
+
+// textOptions returns the options of the text, that is flushed.
+func (d *Document) textOptions() []TextOption {
+	opts := []TextOption{WithAlignment(d.alignment), WithMargin(d.margin)}
+	if d.invert {
+		opts = append(opts, WithBackground(color.Black), WithPadding(invertPadding))
+	}
+	return opts
+}
+
+// flushColumns flushes the text lines in the columns, see
+// [Document.cmdColumns].
+func (d *Document) flushColumns() error {
+	for line := range strings.SplitSeq(strings.TrimSuffix(d.buf.String(), "\n"), "\n") {
+		if !strings.Contains(line, columnSep) {
+			if err := d.c.AppendText(d.font, line, d.textOptions()...); err != nil {
+				return fmt.Errorf("append text: %w", err)
+			}
+			continue
+		}
+		img, err := d.row(strings.SplitN(line, columnSep, d.columns))
+		if err != nil {
+			return fmt.Errorf("append columns: %w", err)
+		}
+		d.c.appendText(img)
+	}
+	d.buf.Reset()
+	return nil
+}
+
+// row renders the cells of the line in the columns, the cells are wrapped to
+// the width of the column.
+func (d *Document) row(cells []string) (image.Image, error) {
+	var (
+		pad  = 0 // padding of the inverted text
+		bg   = color.Color(color.White)
+		imgs = make([]image.Image, len(cells))
+	)
+	if d.invert {
+		pad, bg = invertPadding, color.Black
+	}
+	inner := d.width - 2*d.margin - 2*pad
+	width := (inner - (d.columns-1)*columnGap) / d.columns
+	height := 0
+	for i, cell := range cells {
+		align := AlignCenter
+		switch i {
+		case 0:
+			align = AlignLeft
+		case d.columns - 1:
+			align = AlignRight
+		}
+		img, err := RenderTTF(strings.TrimSpace(cell), d.font, width, WithAlignment(align), WithWrap(true), WithBackground(bg))
+		if err != nil {
+			return nil, err
+		}
+		imgs[i] = img
+		height = max(height, img.Bounds().Dy())
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, d.width, height+2*pad))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(d.margin, 0, d.width-d.margin, dst.Bounds().Dy()), image.NewUniform(bg), image.Point{}, draw.Src)
+	for i, img := range imgs {
+		x := d.margin + pad + i*(width+columnGap)
+		draw.Draw(dst, image.Rect(x, pad, x+width, pad+img.Bounds().Dy()), img, img.Bounds().Min, draw.Src)
+	}
+	return dst, nil
+}
@@ -0,0 +1,109 @@
+package bitmap
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseDoc(t *testing.T, script string) (image.Image, error) {
+	t.Helper()
+	doc := NewDocument(NewComposer(384), 203)
+	if err := doc.Parse(strings.NewReader(script)); err != nil {
+		return nil, err
+	}
+	return doc.Render()
+}
+
+func TestDocument_Rule(t *testing.T) {
+	tests := []struct {
+		name          string
+		script        string
+		wantThickness int
+		wantLeft      int
+		wantErr       bool
+	}{
+		{name: "default", script: ".rule\n", wantThickness: ruleThickness},
+		{name: "thickness", script: ".rule 5px\n", wantThickness: 5},
+		{name: "margin", script: ".margin 10px\n.rule\n", wantThickness: ruleThickness, wantLeft: 10},
+		{name: "zero", script: ".rule 0\n", wantErr: true},
+		{name: "too many arguments", script: ".rule 1 2\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := parseDoc(t, tt.script)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantThickness+2*ruleGap, img.Bounds().Dy())
+			assert.False(t, isBlack(img, 192, ruleGap-1), "gap above")
+			assert.True(t, isBlack(img, 192, ruleGap))
+			assert.True(t, isBlack(img, 192, ruleGap+tt.wantThickness-1))
+			assert.False(t, isBlack(img, 192, ruleGap+tt.wantThickness), "gap below")
+			assert.True(t, isBlack(img, tt.wantLeft, ruleGap))
+			assert.True(t, isBlack(img, 383-tt.wantLeft, ruleGap))
+			if tt.wantLeft > 0 {
+				assert.False(t, isBlack(img, tt.wantLeft-1, ruleGap), "rule in the margin")
+			}
+		})
+	}
+}
+
+func TestDocument_Invert(t *testing.T) {
+	img, err := parseDoc(t, ".invert\nhello\n.invert off\nhello\n")
+	require.NoError(t, err)
+	// the first line is on the black background, the second on white.
+	assert.True(t, isBlack(img, 383, 0))
+	assert.False(t, isBlack(img, 383, img.Bounds().Dy()-1))
+
+	_, err = parseDoc(t, ".invert maybe\n")
+	assert.Error(t, err)
+}
+
+func TestDocument_Columns(t *testing.T) {
+	face := 8 // width of the default font character
+	tests := []struct {
+		name      string
+		script    string
+		wantRight bool // the last cell is at the right edge
+		wantErr   bool
+	}{
+		{name: "two", script: ".columns 2\nitem | 1.50\n", wantRight: true},
+		{name: "three", script: ".columns 3\nitem | 2 | 1.50\n", wantRight: true},
+		{name: "missing cells", script: ".columns 3\nitem\n"},
+		{name: "off", script: ".columns 2\n.columns off\nitem | 1.50\n"},
+		{name: "too many", script: ".columns 5\n", wantErr: true},
+		{name: "not a number", script: ".columns two\n", wantErr: true},
+		{name: "no argument", script: ".columns\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := parseDoc(t, tt.script)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			ink := inkBounds(img, image.White)
+			assert.Less(t, ink.Min.X, 2, "first cell is on the left")
+			if tt.wantRight {
+				assert.Greater(t, ink.Max.X, 384-face, "last cell is on the right")
+			} else {
+				assert.Less(t, ink.Max.X, 384/2, "text is on the left")
+			}
+		})
+	}
+}
+
+func TestDocument_ColumnsWrap(t *testing.T) {
+	one, err := parseDoc(t, ".columns 2\nitem | 1.50\n")
+	require.NoError(t, err)
+	long, err := parseDoc(t, ".columns 2\n"+strings.Repeat("item ", 20)+"| 1.50\n")
+	require.NoError(t, err)
+	assert.Greater(t, long.Bounds().Dy(), one.Bounds().Dy(), "long cell is wrapped")
+}
@@ -0,0 +1,53 @@
+package bitmap
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"os"
+	"strings"
+)
+
+// ErrRemoteDisabled is returned by the .image document command for the
+// image URL, unless the remote images are enabled with [WithRemoteImages].
+var ErrRemoteDisabled = errors.New("remote images are not enabled")
+
+// FetchFunc returns the contents of the image at the url.
+type FetchFunc func(url string) ([]byte, error)
+
+// WithRemoteImages enables the http and https URLs in the .image document
+// command.  The images are retrieved with fetch, that is expected to cache
+// them, so that the recurring documents don't download the same logo on
+// every print.
+func WithRemoteImages(fetch FetchFunc) DocumentOption {
+	return func(d *Document) {
+		d.fetch = fetch
+	}
+}
+
+// isURL reports whether the image name is the http or https URL.
+func isURL(name string) bool {
+	return strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://")
+}
+
+// loadImage decodes the image from the file or URL.
+func (d *Document) loadImage(name string) (image.Image, error) {
+	if isURL(name) {
+		if d.fetch == nil {
+			return nil, ErrRemoteDisabled
+		}
+		data, err := d.fetch(name)
+		if err != nil {
+			return nil, err
+		}
+		img, _, err := image.Decode(bytes.NewReader(data))
+		return img, err
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
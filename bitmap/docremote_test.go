@@ -0,0 +1,46 @@
+package bitmap
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_remoteImage(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, testColorImage(image.Rect(0, 0, 8, 4), color.Black)))
+	const url = "https://example.com/logo.png"
+
+	t.Run("disabled", func(t *testing.T) {
+		d := NewDocument(NewComposer(8), 203)
+		err := d.Parse(strings.NewReader(".image " + url + "\n"))
+		assert.ErrorIs(t, err, ErrRemoteDisabled)
+	})
+	t.Run("fetched", func(t *testing.T) {
+		var got []string
+		fetch := func(u string) ([]byte, error) {
+			got = append(got, u)
+			return buf.Bytes(), nil
+		}
+		d := NewDocument(NewComposer(8), 203, WithRemoteImages(fetch))
+		require.NoError(t, d.Parse(strings.NewReader(".image "+url+"\n")))
+		assert.Equal(t, []string{url}, got)
+		img, err := d.Render()
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, img.Bounds().Dy(), 4)
+	})
+	t.Run("fetch error", func(t *testing.T) {
+		errFetch := errors.New("unreachable")
+		fetch := func(string) ([]byte, error) { return nil, errFetch }
+		d := NewDocument(NewComposer(8), 203, WithRemoteImages(fetch))
+		err := d.Parse(strings.NewReader(".image " + url + "\n"))
+		assert.ErrorIs(t, err, errFetch)
+	})
+}
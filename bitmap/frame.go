@@ -0,0 +1,232 @@
+package bitmap
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+const dcFrame = ".frame"
+
+const (
+	// frameLine is the thickness of the frame line, in pixels.
+	frameLine = 2
+	// frameGap is the gap between the lines of the double frame, in pixels.
+	frameGap = 2
+	// frameHalftone is the thickness of the halftone frame, in pixels.
+	frameHalftone = 6
+	// frameRadius is the radius of the corners of the rounded frame, in
+	// pixels.
+	frameRadius = 8
+	// framePadding is the blank space between the frame and the content, in
+	// pixels.
+	framePadding = 4
+)
+
+// FrameStyle is the style of the decorative frame, see [Frame].
+type FrameStyle int
+
+const (
+	FrameNone     FrameStyle = iota // no frame
+	FrameSingle                     // single line
+	FrameDouble                     // two lines
+	FrameRounded                    // single line with the rounded corners
+	FrameHalftone                   // wide band of the 50% grey halftone
+)
+
+var frameStyles = map[string]FrameStyle{
+	"off":      FrameNone,
+	"single":   FrameSingle,
+	"double":   FrameDouble,
+	"rounded":  FrameRounded,
+	"halftone": FrameHalftone,
+}
+
+// ParseFrameStyle returns the frame style of the name: "single", "double",
+// "rounded", "halftone", or "off".
+func ParseFrameStyle(s string) (FrameStyle, error) {
+	style, ok := frameStyles[s]
+	if !ok {
+		return FrameNone, fmt.Errorf("unknown frame style %q, expected single, double, rounded, halftone or off", s)
+	}
+	return style, nil
+}
+
+// width returns the thickness of the frame band.
+func (s FrameStyle) width() int {
+	switch s {
+	case FrameSingle, FrameRounded:
+		return frameLine
+	case FrameDouble:
+		return 2*frameLine + frameGap
+	case FrameHalftone:
+		return frameHalftone
+	default:
+		return 0
+	}
+}
+
+// Inset returns the space, that the frame takes on each side of the
+// content: the frame band and the padding.
+func (s FrameStyle) Inset() int {
+	if s == FrameNone {
+		return 0
+	}
+	return s.width() + framePadding
+}
+
+// DrawFrame draws the frame of the style along the inner edges of the
+// rectangle r of dst.
+func DrawFrame(dst draw.Image, r image.Rectangle, style FrameStyle) {
+	switch style {
+	case FrameSingle:
+		strokeRect(dst, r, frameLine)
+	case FrameDouble:
+		strokeRect(dst, r, frameLine)
+		strokeRect(dst, r.Inset(frameLine+frameGap), frameLine)
+	case FrameRounded:
+		roundRect(dst, r, frameLine, frameRadius)
+	case FrameHalftone:
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				if (x+y)%2 == 0 && !image.Pt(x, y).In(r.Inset(frameHalftone)) {
+					dst.Set(x, y, color.Black)
+				}
+			}
+		}
+	}
+}
+
+// Frame returns the image in the frame of the style, the image grows by
+// [FrameStyle.Inset] on each side.
+func Frame(img image.Image, style FrameStyle) image.Image {
+	in := style.Inset()
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx()+2*in, b.Dy()+2*in))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, b.Sub(b.Min).Add(image.Pt(in, in)), img, b.Min, draw.Src)
+	DrawFrame(dst, dst.Bounds(), style)
+	return dst
+}
+
+// strokeRect draws the outline of the thickness along the inner edges of r.
+func strokeRect(dst draw.Image, r image.Rectangle, thickness int) {
+	for _, edge := range []image.Rectangle{
+		{r.Min, image.Pt(r.Max.X, r.Min.Y+thickness)},
+		{image.Pt(r.Min.X, r.Max.Y-thickness), r.Max},
+		{r.Min, image.Pt(r.Min.X+thickness, r.Max.Y)},
+		{image.Pt(r.Max.X-thickness, r.Min.Y), r.Max},
+	} {
+		draw.Draw(dst, edge.Intersect(r), image.Black, image.Point{}, draw.Src)
+	}
+}
+
+// roundRect draws the outline with the corners rounded with the radius, the
+// corners are the quarter circles approximated with the pixels.
+func roundRect(dst draw.Image, r image.Rectangle, thickness, radius int) {
+	radius = min(radius, r.Dx()/2, r.Dy()/2)
+	for _, edge := range []image.Rectangle{
+		image.Rect(r.Min.X+radius, r.Min.Y, r.Max.X-radius, r.Min.Y+thickness),
+		image.Rect(r.Min.X+radius, r.Max.Y-thickness, r.Max.X-radius, r.Max.Y),
+		image.Rect(r.Min.X, r.Min.Y+radius, r.Min.X+thickness, r.Max.Y-radius),
+		image.Rect(r.Max.X-thickness, r.Min.Y+radius, r.Max.X, r.Max.Y-radius),
+	} {
+		draw.Draw(dst, edge, image.Black, image.Point{}, draw.Src)
+	}
+	// the centres of the corners, the pixel centres are at +0.5.
+	outer, inner := float64(radius), float64(radius-thickness)
+	for _, c := range []struct {
+		x, y   float64
+		corner image.Rectangle
+	}{
+		{float64(r.Min.X + radius), float64(r.Min.Y + radius), image.Rect(r.Min.X, r.Min.Y, r.Min.X+radius, r.Min.Y+radius)},
+		{float64(r.Max.X - radius), float64(r.Min.Y + radius), image.Rect(r.Max.X-radius, r.Min.Y, r.Max.X, r.Min.Y+radius)},
+		{float64(r.Min.X + radius), float64(r.Max.Y - radius), image.Rect(r.Min.X, r.Max.Y-radius, r.Min.X+radius, r.Max.Y)},
+		{float64(r.Max.X - radius), float64(r.Max.Y - radius), image.Rect(r.Max.X-radius, r.Max.Y-radius, r.Max.X, r.Max.Y)},
+	} {
+		for y := c.corner.Min.Y; y < c.corner.Max.Y; y++ {
+			for x := c.corner.Min.X; x < c.corner.Max.X; x++ {
+				dx, dy := float64(x)+0.5-c.x, float64(y)+0.5-c.y
+				if d2 := dx*dx + dy*dy; inner*inner <= d2 && d2 < outer*outer {
+					dst.Set(x, y, color.Black)
+				}
+			}
+		}
+	}
+}
+
+// frameRows frames the rows of the canvas from top to the current position
+// between the columns left and right: the rows are moved down to make room
+// for the frame above them.  The content must leave the room for the frame
+// at the sides.
+func (c *Composer) frameRows(top, left, right int, style FrameStyle) {
+	if c.sp.Y <= top {
+		return // nothing to frame
+	}
+	in := style.Inset()
+	width := c.dst.Bounds().Dx()
+	block := image.NewRGBA(image.Rect(0, 0, width, c.sp.Y-top+2*in))
+	draw.Draw(block, block.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(block, image.Rect(0, in, width, block.Bounds().Dy()-in), c.dst, image.Pt(0, top), draw.Src)
+	DrawFrame(block, image.Rect(left, 0, right, block.Bounds().Dy()), style)
+	c.sp.Y = top
+	c.AppendImageDither(block, nil)
+}
+
+// cmdFrame draws the decorative frame, the argument is the style, see
+// [ParseFrameStyle]:
+//
+//	.frame STYLE        frames the following content, until .frame off,
+//	                    at the top of the document it frames the whole
+//	                    document, each page ending with .cut is framed
+//	.frame image STYLE  frames each of the following images
+func (d *Document) cmdFrame(args ...string) error {
+	if len(args) == 2 && args[0] == "image" {
+		style, err := ParseFrameStyle(args[1])
+		if err != nil {
+			return err
+		}
+		d.imageFrame = style
+		return nil
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("invalid argument count, expected 1, or image and the style, provided: %d", len(args))
+	}
+	style, err := ParseFrameStyle(args[0])
+	if err != nil {
+		return err
+	}
+	if err := d.flush(); err != nil {
+		return err
+	}
+	d.closeFrame()
+	return d.openFrame(style)
+}
+
+// openFrame starts the frame of the following content: the margins grow by
+// the frame inset.
+func (d *Document) openFrame(style FrameStyle) error {
+	if style == FrameNone {
+		return nil
+	}
+	if 2*(d.margin+style.Inset()) >= d.width {
+		return errors.New("no room for the frame")
+	}
+	d.frame = style
+	d.frameTop = d.c.sp.Y
+	d.margin += style.Inset()
+	return nil
+}
+
+// closeFrame draws the open frame around the content since it was opened,
+// and restores the margins.
+func (d *Document) closeFrame() {
+	if d.frame == FrameNone {
+		return
+	}
+	d.margin -= d.frame.Inset()
+	d.c.frameRows(d.frameTop, d.margin, d.width-d.margin, d.frame)
+	d.frame = FrameNone
+}
@@ -0,0 +1,114 @@
+package bitmap
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFrameStyle(t *testing.T) {
+	for name, want := range frameStyles {
+		got, err := ParseFrameStyle(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParseFrameStyle("wavy")
+	assert.Error(t, err)
+}
+
+func whiteImage(w, h int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	return img
+}
+
+func TestFrame(t *testing.T) {
+	for _, style := range []FrameStyle{FrameSingle, FrameDouble, FrameRounded, FrameHalftone} {
+		img := Frame(whiteImage(50, 30), style)
+		in := style.Inset()
+		assert.Equal(t, image.Rect(0, 0, 50+2*in, 30+2*in), img.Bounds(), "style %d", style)
+		assert.True(t, isBlack(img, 30, 0), "top edge, style %d", style)
+		assert.True(t, isBlack(img, 0, 20), "left edge, style %d", style)
+		assert.False(t, isBlack(img, in, in), "content, style %d", style)
+		assert.False(t, isBlack(img, in-1, in+10), "padding, style %d", style)
+	}
+	assert.True(t, isBlack(Frame(whiteImage(50, 30), FrameSingle), 0, 0), "square corner")
+	assert.False(t, isBlack(Frame(whiteImage(50, 30), FrameRounded), 0, 0), "rounded corner")
+	double := Frame(whiteImage(50, 30), FrameDouble)
+	assert.False(t, isBlack(double, 30, frameLine), "gap between the lines")
+	assert.True(t, isBlack(double, 30, frameLine+frameGap), "inner line")
+	halftone := Frame(whiteImage(50, 30), FrameHalftone)
+	assert.False(t, isBlack(halftone, 31, 0), "halftone is the checkerboard")
+}
+
+func TestDocument_Frame(t *testing.T) {
+	img, err := parseDoc(t, ".frame single\nhello\n")
+	require.NoError(t, err)
+	assert.True(t, isBlack(img, 192, 0), "top")
+	assert.True(t, isBlack(img, 192, img.Bounds().Dy()-1), "bottom")
+	assert.True(t, isBlack(img, 0, img.Bounds().Dy()/2), "left")
+	assert.True(t, isBlack(img, 383, img.Bounds().Dy()/2), "right")
+
+	plain, err := parseDoc(t, "hello\n")
+	require.NoError(t, err)
+	assert.Equal(t, plain.Bounds().Dy()+2*FrameSingle.Inset(), img.Bounds().Dy())
+}
+
+func TestDocument_FrameOff(t *testing.T) {
+	img, err := parseDoc(t, "title\n.margin 10px\n.frame double\nhello\n.frame off\nbye\n")
+	require.NoError(t, err)
+	// the frame is within the margins, after the title, and before bye.
+	assert.False(t, isBlack(img, 5, img.Bounds().Dy()/2), "margin")
+	assert.True(t, isBlack(img, 10, img.Bounds().Dy()/2), "left edge")
+	assert.False(t, isBlack(img, 10, img.Bounds().Dy()-1), "bye is not framed")
+}
+
+func TestDocument_FramePages(t *testing.T) {
+	doc := NewDocument(NewComposer(384), 203)
+	require.NoError(t, doc.Parse(strings.NewReader(".frame rounded\none\n.cut 0\ntwo\n")))
+	pages, err := doc.Pages()
+	require.NoError(t, err)
+	require.Len(t, pages, 2)
+	for i, pg := range pages {
+		assert.True(t, isBlack(pg, 192, 0), "page %d top", i+1)
+		assert.True(t, isBlack(pg, 192, pg.Bounds().Dy()-1), "page %d bottom", i+1)
+	}
+}
+
+func TestDocument_FrameImage(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "white.png")
+	f, err := os.Create(name)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, whiteImage(40, 40)))
+	require.NoError(t, f.Close())
+
+	img, err := parseDoc(t, ".frame image single\n.image "+name+" 40px\n")
+	require.NoError(t, err)
+	in := FrameSingle.Inset()
+	assert.Equal(t, 40+2*in, img.Bounds().Dy())
+	assert.True(t, isBlack(img, 0, 20), "left edge")
+	assert.True(t, isBlack(img, 40+2*in-1, 20), "right edge")
+	assert.False(t, isBlack(img, 40+2*in, 20), "the frame is around the image")
+}
+
+func TestDocument_FrameErrors(t *testing.T) {
+	for _, script := range []string{
+		".frame\n",
+		".frame wavy\n",
+		".frame image\n",
+		".frame image wavy\n",
+		".frame single\n.margin 5px\n",
+		".margin 188px\n.frame double\n",
+	} {
+		_, err := parseDoc(t, script)
+		assert.Error(t, err, script)
+	}
+}
@@ -0,0 +1,116 @@
+package bitmap
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+const (
+	// hatchPeriod is the spacing of the hatch lines, in pixels.
+	hatchPeriod = 6
+	// hatchSaturation is the minimum saturation of the colour, that is
+	// hatched, the less saturated colours are grey.
+	hatchSaturation = 0.25
+	// hatchDark is the value, below which the colour is black.
+	hatchDark = 0.2
+	// hatchLight is the value of the grey, above which it is white.
+	hatchLight = 0.85
+)
+
+// hatchPatterns are the patterns of the hues, starting with red, each
+// covering 60 degrees of the colour wheel.
+var hatchPatterns = [6]func(x, y int) bool{
+	func(x, y int) bool { return (x+y)%hatchPeriod == 0 },                   // red: "/"
+	func(x, y int) bool { return x%hatchPeriod == 0 && y%hatchPeriod == 0 }, // yellow: dots
+	func(x, y int) bool { return x%hatchPeriod == 0 },                       // green: "|"
+	func(x, y int) bool { return y%hatchPeriod == 0 },                       // cyan: "-"
+	func(x, y int) bool { return x%hatchPeriod == 0 || y%hatchPeriod == 0 }, // blue: "+"
+	func(x, y int) bool { return (x-y)%hatchPeriod == 0 },                   // magenta: "\"
+}
+
+// HatchColours are the names of the colours in the order of their patterns,
+// see [DHatch].
+var HatchColours = [6]string{"red", "yellow", "green", "cyan", "blue", "magenta"}
+
+// DHatch maps the colours of the image to the hatch patterns instead of the
+// shades of grey, so that the areas of the map or the chart, that differ by
+// the colour of the same brightness, remain distinct on the printout.  The
+// hues are grouped into six: red is hatched with "/", yellow with dots,
+// green with "|", cyan with "-", blue with "+", and magenta with "\".  Black
+// and the dark colours are black, the greys are dithered with the ordered
+// dither, and white stays white.  Gamma is only applied to the greys.
+func DHatch(img image.Image, gamma float64) image.Image {
+	b := img.Bounds()
+	dst := image.NewPaletted(b, []color.Color{color.Black, color.White})
+	var greys *image.Paletted // dithered lazily, if there are any
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			h, s, v := hsv(img.At(x, y))
+			var black bool
+			switch {
+			case v < hatchDark:
+				black = true
+			case s >= hatchSaturation:
+				black = hatchPatterns[int(h/60)%6](x-b.Min.X, y-b.Min.Y)
+			case v > hatchLight:
+				black = false
+			default:
+				if greys == nil {
+					greys = toPaletted(DBayer(img, gamma))
+				}
+				black = greys.ColorIndexAt(x, y) == 0
+			}
+			if !black {
+				dst.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return dst
+}
+
+// hsv returns the hue in degrees, the saturation and the value of the colour.
+func hsv(c color.Color) (h, s, v float64) {
+	r16, g16, b16, _ := c.RGBA()
+	r, g, b := float64(r16)/0xffff, float64(g16)/0xffff, float64(b16)/0xffff
+	hi, lo := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	v = hi
+	if hi == 0 || hi == lo {
+		return 0, 0, v
+	}
+	d := hi - lo
+	s = d / hi
+	switch hi {
+	case r:
+		h = math.Mod((g-b)/d, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	// shift by half a sector, so that pure red is in the middle of its
+	// sector.
+	return math.Mod(h+30, 360), s, v
+}
+
+// toPaletted returns the black and white image as the paletted one, where
+// the index 0 is black.
+func toPaletted(img image.Image) *image.Paletted {
+	if p, ok := img.(*image.Paletted); ok && len(p.Palette) == 2 && p.Palette[0] == color.Color(color.Black) {
+		return p
+	}
+	b := img.Bounds()
+	dst := image.NewPaletted(b, []color.Color{color.Black, color.White})
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if ColorToGray(img.At(x, y)) >= 128 {
+				dst.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,88 @@
+package bitmap
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func uniform(c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 2*hatchPeriod, 2*hatchPeriod))
+	for y := range img.Bounds().Dy() {
+		for x := range img.Bounds().Dx() {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestDHatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		colour  color.Color
+		pattern func(x, y int) bool
+	}{
+		{"red", color.RGBA{255, 0, 0, 255}, hatchPatterns[0]},
+		{"orange red", color.RGBA{255, 60, 0, 255}, hatchPatterns[0]},
+		{"pinkish red", color.RGBA{255, 0, 60, 255}, hatchPatterns[0]},
+		{"yellow", color.RGBA{255, 220, 0, 255}, hatchPatterns[1]},
+		{"green", color.RGBA{0, 160, 0, 255}, hatchPatterns[2]},
+		{"cyan", color.RGBA{0, 200, 200, 255}, hatchPatterns[3]},
+		{"blue", color.RGBA{0, 0, 255, 255}, hatchPatterns[4]},
+		{"magenta", color.RGBA{200, 0, 200, 255}, hatchPatterns[5]},
+		{"white", color.White, func(x, y int) bool { return false }},
+		{"black", color.Black, func(x, y int) bool { return true }},
+		{"dark blue", color.RGBA{0, 0, 30, 255}, func(x, y int) bool { return true }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DHatch(uniform(tt.colour), DefaultGamma)
+			assertBlackWhite(t, got)
+			b := got.Bounds()
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					if black := ColorToGray(got.At(x, y)) == 0; black != tt.pattern(x, y) {
+						t.Fatalf("pixel (%d, %d) black = %v, want %v", x, y, black, !black)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDHatch_grey(t *testing.T) {
+	got := DHatch(uniform(color.Gray{Y: 128}), DefaultGamma)
+	assertBlackWhite(t, got)
+	var black int
+	b := got.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if ColorToGray(got.At(x, y)) == 0 {
+				black++
+			}
+		}
+	}
+	if total := b.Dx() * b.Dy(); black == 0 || black == total {
+		t.Errorf("grey is not dithered: %d of %d pixels are black", black, total)
+	}
+}
+
+func TestHsv(t *testing.T) {
+	tests := []struct {
+		colour  color.Color
+		h, s, v float64
+	}{
+		{color.RGBA{255, 0, 0, 255}, 30, 1, 1},
+		{color.RGBA{0, 255, 0, 255}, 150, 1, 1},
+		{color.RGBA{0, 0, 255, 255}, 270, 1, 1},
+		{color.RGBA{128, 128, 128, 255}, 0, 0, 128.0 / 255},
+		{color.Black, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		h, s, v := hsv(tt.colour)
+		if math.Abs(h-tt.h) > 0.01 || math.Abs(s-tt.s) > 0.01 || math.Abs(v-tt.v) > 0.01 {
+			t.Errorf("hsv(%v) = %.2f, %.2f, %.2f, want %.2f, %.2f, %.2f", tt.colour, h, s, v, tt.h, tt.s, tt.v)
+		}
+	}
+}
@@ -40,6 +40,9 @@ func ColorToGray(c color.Color) uint8 {
 	return uint8(gray >> 8)
 }
 
+// IsDocument returns true if the image looks like the document: more than
+// 85% of its pixels are darker than darkThreshold or lighter than
+// lightThreshold.  Zero thresholds are 50 and 200.
 func IsDocument(img image.Image, darkThreshold, lightThreshold uint8) bool {
 	if img == nil {
 		return false
@@ -52,7 +55,7 @@ func IsDocument(img image.Image, darkThreshold, lightThreshold uint8) bool {
 	}
 	bounds := img.Bounds()
 	dst := image.NewGray(img.Bounds())
-	draw.Draw(dst, bounds, img, image.Point{}, draw.Src)
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Src)
 	// create histogram of pixel brightness
 	histogram := make([]int, math.MaxUint8+1)
 	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
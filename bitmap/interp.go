@@ -0,0 +1,150 @@
+package bitmap
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rusq/thermoprint/locale"
+)
+
+// WithVariables sets the variables for the ${name} interpolation in the
+// document text, they take precedence over the environment variables.
+func WithVariables(vars map[string]string) DocumentOption {
+	return func(d *Document) {
+		if d.vars == nil {
+			d.vars = make(map[string]string, len(vars))
+		}
+		for k, v := range vars {
+			d.vars[k] = v
+		}
+	}
+}
+
+// lookup returns the value of the document variable or the environment
+// variable.
+func (d *Document) lookup(name string) (string, bool) {
+	if v, ok := d.vars[name]; ok {
+		return v, true
+	}
+	return os.LookupEnv(name)
+}
+
+// interpolate replaces ${name} with the value of the variable, see
+// [Document.lookup], and ${date:format} with the current time, see
+// [Interpolate].
+func (d *Document) interpolate(s string) (string, error) {
+	return Interpolate(s, d.lookup, d.now())
+}
+
+// Interpolate replaces ${name} in s with the value returned by lookup, and
+// ${date:format} with the time now in the strftime format, i.e.
+// ${date:%Y-%m-%d}.  Undefined variables are replaced with the empty
+// string, $${ is the literal ${.
+func Interpolate(s string, lookup func(name string) (string, bool), now time.Time) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			break
+		}
+		if i > 0 && s[i-1] == '$' { // escaped
+			b.WriteString(s[:i])
+			b.WriteString("{")
+			s = s[i+2:]
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			break // not terminated, left as is
+		}
+		b.WriteString(s[:i])
+		expr := s[i+2 : i+end]
+		s = s[i+end+1:]
+		if format, ok := strings.CutPrefix(expr, "date:"); ok {
+			b.WriteString(strftime(now, format))
+			continue
+		}
+		if expr == "" {
+			return "", fmt.Errorf("empty variable name")
+		}
+		v, _ := lookup(expr)
+		b.WriteString(v)
+	}
+	b.WriteString(s)
+	return b.String(), nil
+}
+
+// strftime formats the time t according to the strftime(3) format, the
+// names of the months and of the days are in the default language, see
+// [locale.SetDefault].  Unsupported conversions are copied as is.
+func strftime(t time.Time, format string) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i == len(format)-1 {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+		switch c := format[i]; c {
+		case 'Y':
+			fmt.Fprintf(&b, "%04d", t.Year())
+		case 'y':
+			fmt.Fprintf(&b, "%02d", t.Year()%100)
+		case 'm':
+			fmt.Fprintf(&b, "%02d", int(t.Month()))
+		case 'd':
+			fmt.Fprintf(&b, "%02d", t.Day())
+		case 'e':
+			fmt.Fprintf(&b, "%2d", t.Day())
+		case 'j':
+			fmt.Fprintf(&b, "%03d", t.YearDay())
+		case 'H':
+			fmt.Fprintf(&b, "%02d", t.Hour())
+		case 'I':
+			fmt.Fprintf(&b, "%02d", (t.Hour()+11)%12+1)
+		case 'M':
+			fmt.Fprintf(&b, "%02d", t.Minute())
+		case 'S':
+			fmt.Fprintf(&b, "%02d", t.Second())
+		case 'p':
+			b.WriteString(t.Format("PM"))
+		case 'a':
+			b.WriteString(locale.T(t.Format("Mon")))
+		case 'A':
+			b.WriteString(locale.T(t.Format("Monday")))
+		case 'b', 'h':
+			b.WriteString(locale.T(t.Format("Jan")))
+		case 'B':
+			b.WriteString(locale.T(t.Format("January")))
+		case 'u':
+			fmt.Fprintf(&b, "%d", (int(t.Weekday())+6)%7+1)
+		case 'w':
+			fmt.Fprintf(&b, "%d", int(t.Weekday()))
+		case 'Z':
+			b.WriteString(t.Format("MST"))
+		case 'z':
+			b.WriteString(t.Format("-0700"))
+		case 'F':
+			b.WriteString(t.Format(time.DateOnly))
+		case 'T':
+			b.WriteString(t.Format(time.TimeOnly))
+		case 'R':
+			b.WriteString(t.Format("15:04"))
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package bitmap
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rusq/thermoprint/locale"
+)
+
+func TestDocument_interpolate(t *testing.T) {
+	t.Setenv("THERMOPRINT_USER", "alice")
+	doc := NewDocument(NewComposer(64), 203, WithVariables(map[string]string{"title": "Shopping"}))
+	doc.now = func() time.Time { return time.Date(2024, time.March, 5, 14, 7, 9, 0, time.UTC) }
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "hello", "hello"},
+		{"variable", "${title} list", "Shopping list"},
+		{"environment", "for ${THERMOPRINT_USER}", "for alice"},
+		{"undefined", "[${THERMOPRINT_UNDEFINED}]", "[]"},
+		{"date", "${date:%Y-%m-%d %H:%M:%S}", "2024-03-05 14:07:09"},
+		{"date names", "${date:%a %d %b, %I:%M %p}", "Tue 05 Mar, 02:07 PM"},
+		{"escaped", "$${title}", "${title}"},
+		{"not terminated", "${title", "${title"},
+		{"several", "${title}/${title}", "Shopping/Shopping"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := doc.interpolate(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := doc.interpolate("${}")
+	assert.Error(t, err)
+}
+
+func TestDocument_ParseInterpolates(t *testing.T) {
+	doc := NewDocument(NewComposer(64), 203, WithVariables(map[string]string{"name": "Bob"}))
+
+	require.NoError(t, doc.Parse(strings.NewReader(".align center\nHello, ${name}\n")))
+
+	assert.Positive(t, doc.Image().Bounds().Dy())
+
+	err := NewDocument(NewComposer(64), 203).Parse(strings.NewReader("a\n${}\n"))
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestStrftime_Lang(t *testing.T) {
+	require.NoError(t, locale.SetDefault("de"))
+	t.Cleanup(func() { locale.SetDefault(locale.English) })
+
+	at := time.Date(2024, time.March, 5, 14, 7, 9, 0, time.UTC)
+	assert.Equal(t, "Di, 05 Mär 2024", strftime(at, "%a, %d %b %Y"))
+	assert.Equal(t, "Dienstag, März", strftime(at, "%A, %B"))
+}
@@ -0,0 +1,244 @@
+package bitmap
+
+import (
+	"errors"
+	"image"
+	"regexp"
+	"strings"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// headingScale is the magnification of the level 1 and 2 headings.
+const headingScale = 2
+
+var (
+	mdHeading = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdRule    = regexp.MustCompile(`^\s{0,3}([-*_])(\s*[-*_]){2,}\s*$`)
+	mdItem    = regexp.MustCompile(`^(\s*)([-*+]|\d{1,9}[.)])\s+(.*)$`)
+)
+
+// mdBlock is the block of the markdown document: the heading, the list
+// item, the paragraph or the horizontal rule.
+type mdBlock struct {
+	heading int    // level of the heading, or zero
+	rule    bool   // horizontal rule
+	marker  string // list item marker, "-" for the bullet, or "1." etc.
+	level   int    // nesting level of the list item
+	text    string // inline text
+}
+
+// parseMarkdown splits the markdown text into blocks.  The lines of the
+// paragraph are joined, the blank line ends the paragraph, the indented
+// line continues the list item.
+func parseMarkdown(text string) []mdBlock {
+	var (
+		blocks []mdBlock
+		cur    *mdBlock // current paragraph or list item, nil after a blank line
+	)
+	add := func(b mdBlock) {
+		blocks = append(blocks, b)
+		cur = nil
+	}
+	for line := range strings.SplitSeq(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = replacer.Replace(line)
+		switch {
+		case strings.TrimSpace(line) == "":
+			cur = nil
+		case mdRule.MatchString(line):
+			add(mdBlock{rule: true})
+		case mdHeading.MatchString(line):
+			m := mdHeading.FindStringSubmatch(line)
+			add(mdBlock{heading: len(m[1]), text: m[2]})
+		case mdItem.MatchString(line):
+			m := mdItem.FindStringSubmatch(line)
+			marker := m[2]
+			if !strings.ContainsAny(marker, "0123456789") {
+				marker = "-"
+			}
+			add(mdBlock{marker: marker, level: len(m[1]) / 2, text: m[3]})
+			cur = &blocks[len(blocks)-1]
+		case cur != nil && (cur.marker == "" || strings.HasPrefix(line, " ")):
+			cur.text += " " + strings.TrimSpace(line)
+		default:
+			add(mdBlock{text: strings.TrimSpace(line)})
+			cur = &blocks[len(blocks)-1]
+		}
+	}
+	return blocks
+}
+
+// mdWord is the word of the inline text.
+type mdWord struct {
+	s     string
+	bold  bool
+	space bool // preceded by the space
+}
+
+// parseInline splits the inline text into words, "**" and "__" toggle the
+// bold text.
+func parseInline(text string) []mdWord {
+	var (
+		words []mdWord
+		bold  bool
+		space bool
+		word  strings.Builder
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, mdWord{s: word.String(), bold: bold, space: space})
+			word.Reset()
+			space = false
+		}
+	}
+	for i := 0; i < len(text); i++ {
+		switch {
+		case strings.HasPrefix(text[i:], "**") || strings.HasPrefix(text[i:], "__"):
+			flush()
+			bold = !bold
+			i++
+		case text[i] == ' ':
+			flush()
+			space = len(words) > 0
+		default:
+			word.WriteByte(text[i])
+		}
+	}
+	flush()
+	return words
+}
+
+// mdFaces are the faces of the markdown text.
+type mdFaces struct {
+	regular, bold font.Face // bold is nil for the double-struck regular
+}
+
+func (f mdFaces) face(bold bool) font.Face {
+	if bold && f.bold != nil {
+		return f.bold
+	}
+	return f.regular
+}
+
+// lineHeight returns the height of the text line.
+func (f mdFaces) lineHeight() int {
+	h := f.regular.Metrics().Height.Ceil()
+	if f.bold != nil {
+		h = max(h, f.bold.Metrics().Height.Ceil())
+	}
+	return h
+}
+
+// ascent returns the distance from the top of the line to the baseline.
+func (f mdFaces) ascent() int {
+	return max(f.regular.Metrics().Ascent.Ceil(), f.face(true).Metrics().Ascent.Ceil())
+}
+
+// render renders the words wrapped to the width, the first line starts at
+// first, the rest at indent pixels from the left.
+func (f mdFaces) render(words []mdWord, width, first, indent int) *image.RGBA {
+	type placed struct {
+		mdWord
+		x int
+	}
+	var (
+		lines [][]placed
+		line  []placed
+		x     = first
+	)
+	for _, w := range words {
+		adv := font.MeasureString(f.face(w.bold), w.s).Ceil()
+		if w.space && len(line) > 0 {
+			sp := font.MeasureString(f.face(w.bold), " ").Ceil()
+			if x+sp+adv > width {
+				lines, line, x = append(lines, line), nil, indent
+			} else {
+				x += sp
+			}
+		}
+		line = append(line, placed{w, x})
+		x += adv
+	}
+	lines = append(lines, line)
+
+	lh, ascent := f.lineHeight(), f.ascent()
+	img := image.NewRGBA(image.Rect(0, 0, width, len(lines)*lh))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for i, line := range lines {
+		for _, w := range line {
+			d := font.Drawer{Dst: img, Src: image.Black, Face: f.face(w.bold)}
+			d.Dot = fixed.P(w.x, i*lh+ascent)
+			d.DrawString(w.s)
+			if w.bold && f.bold == nil {
+				d.Dot = fixed.P(w.x+1, i*lh+ascent)
+				d.DrawString(w.s)
+			}
+		}
+	}
+	return img
+}
+
+// RenderMarkdown renders the markdown text with the faces on the image of
+// the given width.  It interprets the subset of markdown, that makes sense
+// on the receipt: "#" headings, the first two levels are twice larger, the
+// "**bold**" text, the bulleted and numbered lists, nested by indenting
+// them with two spaces, and the horizontal rules.  Everything else is
+// printed as is.  If bold is nil, the bold text is double-struck with the
+// regular face.
+func RenderMarkdown(text string, regular, bold font.Face, width int) (image.Image, error) {
+	f := mdFaces{regular: regular, bold: bold}
+	lh := f.lineHeight()
+	indent := font.MeasureString(regular, "  ").Ceil()
+	if width < 4*indent {
+		return nil, errors.New("no room for text")
+	}
+	c := NewComposer(width)
+	blocks := parseMarkdown(text)
+	for i, b := range blocks {
+		if i > 0 && (b.marker == "" || blocks[i-1].marker == "") {
+			c.Feed(lh / 2) // the items of the list are not separated
+		}
+		words := parseInline(b.text)
+		switch {
+		case b.rule:
+			c.AppendImageDither(rule(width, lh/2), nil)
+		case b.heading > 0:
+			for i := range words {
+				words[i].bold = true
+			}
+			if b.heading > 2 {
+				c.AppendImageDither(f.render(words, width, 0, 0), nil)
+				break
+			}
+			img := f.render(words, width/headingScale, 0, 0)
+			scaled := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx()*headingScale, img.Bounds().Dy()*headingScale))
+			draw.NearestNeighbor.Scale(scaled, scaled.Bounds(), img, img.Bounds(), draw.Src, nil)
+			c.AppendImageDither(scaled, nil)
+			if b.heading == 1 {
+				c.AppendImageDither(rule(width, 2), nil)
+			}
+		case b.marker != "":
+			marker := b.marker
+			if marker == "-" {
+				marker = "•"
+				if _, ok := regular.GlyphAdvance('•'); !ok {
+					marker = "-"
+				}
+			}
+			// the marker is followed by the space, the wrapped lines are
+			// aligned with the text.
+			markerX := min(b.level*indent, width/2)
+			left := markerX + max(font.MeasureString(regular, marker+" ").Ceil(), indent)
+			img := f.render(words, width, left, left)
+			d := font.Drawer{Dst: img, Src: image.Black, Face: regular}
+			d.Dot = fixed.P(markerX, f.ascent())
+			d.DrawString(marker)
+			c.AppendImageDither(img, nil)
+		default:
+			c.AppendImageDither(f.render(words, width, 0, 0), nil)
+		}
+	}
+	return c.Image(), nil
+}
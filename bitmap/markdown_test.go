@@ -0,0 +1,88 @@
+package bitmap
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+
+	"github.com/rusq/thermoprint/fontmgr"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	text := "# Title #\n" +
+		"first line\nsecond line\n\n" +
+		"- item\n  continued\n  - nested\n10. ten\n" +
+		"after the list\n" +
+		"* * *\n" +
+		"### small"
+	want := []mdBlock{
+		{heading: 1, text: "Title"},
+		{text: "first line second line"},
+		{marker: "-", text: "item continued"},
+		{marker: "-", level: 1, text: "nested"},
+		{marker: "10.", text: "ten"},
+		{text: "after the list"},
+		{rule: true},
+		{heading: 3, text: "small"},
+	}
+	if got := parseMarkdown(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMarkdown() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseInline(t *testing.T) {
+	want := []mdWord{
+		{s: "buy"},
+		{s: "fresh", bold: true, space: true},
+		{s: "milk", bold: true, space: true},
+		{s: "today", space: true},
+		{s: "now", bold: true},
+	}
+	if got := parseInline("buy **fresh milk** today__now__"); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseInline() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	face := fontmgr.DefaultFont
+	bold, err := fontmgr.LoadByName("toshiba-bold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lh := face.Metrics().Height.Ceil()
+
+	img, err := RenderMarkdown("# Big\ntext", face, bold, 384)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// heading, the rule and the gap, and the text.
+	if got, want := img.Bounds().Dy(), 2*lh+lh; got < want {
+		t.Errorf("height = %d, want at least %d", got, want)
+	}
+	heading := inkBounds(img.(*image.RGBA).SubImage(image.Rect(0, 0, 384, 2*lh)), color.White)
+	plain, err := RenderTTF("Big", bold, 384)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := heading.Dx(), 2*inkBounds(plain, color.White).Dx(); got != want {
+		t.Errorf("heading width = %d, want twice the bold text %d", got, want)
+	}
+
+	// without the bold face, the bold text is double-struck.
+	regular, err := RenderMarkdown("text", face, nil, 384)
+	if err != nil {
+		t.Fatal(err)
+	}
+	struck, err := RenderMarkdown("**text**", face, nil, 384)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, s := inkBounds(regular, color.White), inkBounds(struck, color.White); s.Dx() != r.Dx()+1 {
+		t.Errorf("double-struck width = %d, want %d", s.Dx(), r.Dx()+1)
+	}
+
+	if _, err := RenderMarkdown("text", face, nil, 10); err == nil {
+		t.Error("expected an error for the narrow width")
+	}
+}
@@ -0,0 +1,178 @@
+package bitmap
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rusq/thermoprint/fontmgr"
+	"github.com/rusq/thermoprint/locale"
+)
+
+//go:embed notes/*.json
+var noteFS embed.FS
+
+// Border styles of the [NoteTemplate].
+const (
+	BorderNone   = "none"
+	BorderSingle = "single"
+	BorderDouble = "double"
+	BorderDashed = "dashed"
+)
+
+// dashLength is the length of a dash and a gap of the dashed border.
+const dashLength = 8
+
+// NoteTemplate describes the decoration of a sticky note.  Templates are
+// embedded, see [NoteTemplates].
+type NoteTemplate struct {
+	Name        string `json:"-"`
+	Description string `json:"description"`
+	Border      string `json:"border"`       // one of Border* constants
+	BorderWidth int    `json:"border_width"` // line width of the border, px
+	Padding     int    `json:"padding"`      // space between the border and the text, px
+	TitleFont   string `json:"title_font"`   // built-in font name for the title
+	BodyFont    string `json:"body_font"`    // built-in font name for the text and timestamp
+	Separator   bool   `json:"separator"`    // draw a rule between the title and the text
+	Timestamp   string `json:"timestamp"`    // time layout of the timestamp, empty to omit
+}
+
+// Note is the content of a sticky note.
+type Note struct {
+	Title string    // optional title
+	Text  string    // text of the note
+	Time  time.Time // printed in the timestamp
+}
+
+// NoteTemplates returns the names of the embedded note templates.
+func NoteTemplates() []string {
+	entries, err := fs.ReadDir(noteFS, "notes")
+	if err != nil {
+		panic(err) // embedded
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), path.Ext(e.Name())))
+	}
+	slices.Sort(names)
+	return names
+}
+
+// LoadNoteTemplate returns the embedded note template by name.
+func LoadNoteTemplate(name string) (NoteTemplate, error) {
+	data, err := noteFS.ReadFile(path.Join("notes", name+".json"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return NoteTemplate{}, fmt.Errorf("unknown note template %q, available: %v", name, NoteTemplates())
+		}
+		return NoteTemplate{}, err
+	}
+	var t NoteTemplate
+	if err := json.Unmarshal(data, &t); err != nil {
+		return NoteTemplate{}, fmt.Errorf("note template %q: %w", name, err)
+	}
+	t.Name = name
+	return t, nil
+}
+
+// Render renders the note with the template on the canvas of the given
+// width.
+func (t NoteTemplate) Render(width int, n Note) (image.Image, error) {
+	inset := t.Padding
+	if t.Border != BorderNone {
+		inset += t.borderSize()
+	}
+	inner := width - 2*inset
+	if inner <= 0 {
+		return nil, fmt.Errorf("note template %q does not fit the width %d", t.Name, width)
+	}
+	titleFace, err := fontmgr.LoadByName(t.TitleFont)
+	if err != nil {
+		return nil, fmt.Errorf("title font: %w", err)
+	}
+	bodyFace, err := fontmgr.LoadByName(t.BodyFont)
+	if err != nil {
+		return nil, fmt.Errorf("body font: %w", err)
+	}
+
+	c := NewComposer(inner)
+	if n.Title != "" {
+		if err := c.AppendText(titleFace, n.Title, WithWrap(true)); err != nil {
+			return nil, err
+		}
+		if t.Separator {
+			c.AppendImage(rule(inner, bodyFace.Metrics().Height.Ceil()/2))
+		}
+	}
+	if err := c.AppendText(bodyFace, n.Text, WithWrap(true)); err != nil {
+		return nil, err
+	}
+	if t.Timestamp != "" {
+		if err := c.AppendText(bodyFace, locale.Default().FormatTime(n.Time, t.Timestamp), WithAlignment(AlignRight)); err != nil {
+			return nil, err
+		}
+	}
+
+	body := c.Image()
+	dst := image.NewRGBA(image.Rect(0, 0, width, body.Bounds().Dy()+2*inset))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, body.Bounds().Add(image.Pt(inset, inset)), body, image.Point{}, draw.Src)
+	t.drawBorder(dst)
+	return dst, nil
+}
+
+// borderSize returns the total width of the border.
+func (t NoteTemplate) borderSize() int {
+	if t.Border == BorderDouble {
+		return 3 * t.BorderWidth // two lines and a gap
+	}
+	return t.BorderWidth
+}
+
+func (t NoteTemplate) drawBorder(dst *image.RGBA) {
+	r := dst.Bounds()
+	switch t.Border {
+	case BorderSingle:
+		drawFrame(dst, r, t.BorderWidth, 0)
+	case BorderDouble:
+		drawFrame(dst, r, t.BorderWidth, 0)
+		drawFrame(dst, r.Inset(2*t.BorderWidth), t.BorderWidth, 0)
+	case BorderDashed:
+		drawFrame(dst, r, t.BorderWidth, dashLength)
+	}
+}
+
+// drawFrame draws a frame of width w inside the rectangle r.  If dash is
+// not zero, the frame is dashed.
+func drawFrame(dst *image.RGBA, r image.Rectangle, w int, dash int) {
+	for x := r.Min.X; x < r.Max.X; x++ {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			onFrame := x < r.Min.X+w || x >= r.Max.X-w || y < r.Min.Y+w || y >= r.Max.Y-w
+			if !onFrame {
+				continue
+			}
+			if dash > 0 && ((x-r.Min.X)/dash+(y-r.Min.Y)/dash)%2 == 1 {
+				continue
+			}
+			dst.Set(x, y, color.Black)
+		}
+	}
+}
+
+// rule returns a horizontal rule of the given width with the vertical
+// spacing.
+func rule(width, spacing int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, 2*spacing+2))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, spacing, width, spacing+2), image.Black, image.Point{}, draw.Src)
+	return img
+}
@@ -0,0 +1,74 @@
+package bitmap
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/image/font"
+
+	"github.com/rusq/thermoprint/fontmgr"
+)
+
+func TestWrapText(t *testing.T) {
+	face := fontmgr.DefaultFont // 8px wide
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  string
+	}{
+		{"fits", "hello world", 88, "hello world"},
+		{"wraps on words", "hello world", 80, "hello\nworld"},
+		{"keeps line breaks", "a\nb c", 80, "a\nb c"},
+		{"breaks long words", "abcdefghij", 32, "abcd\nefgh\nij"},
+		{"collapses spaces", "a   b", 80, "a b"},
+		{"soft hyphen", "ab cd\u00adef", 48, "ab cd-\nef"},
+		{"last soft hyphen that fits", "a bc\u00adde\u00adfgh", 56, "a bcde-\nfgh"},
+		{"soft hyphen not needed", "ab cd\u00adef", 80, "ab cdef"},
+		{"soft hyphen on the empty line", "ab\u00adcdef", 32, "ab-\ncdef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := WrapText(face, tt.text, tt.width)
+			if got != tt.want {
+				t.Fatalf("WrapText() = %q, want %q", got, tt.want)
+			}
+			for line := range strings.SplitSeq(got, "\n") {
+				if w := font.MeasureString(face, line).Ceil(); w > tt.width {
+					t.Errorf("line %q is %dpx wide, want <= %d", line, w, tt.width)
+				}
+			}
+		})
+	}
+}
+
+func TestNoteTemplates(t *testing.T) {
+	names := NoteTemplates()
+	if len(names) == 0 {
+		t.Fatal("no note templates")
+	}
+	note := Note{Title: "Shopping", Text: "milk, bread and a very long list of things that must wrap", Time: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			tmpl, err := LoadNoteTemplate(name)
+			if err != nil {
+				t.Fatalf("LoadNoteTemplate() error = %v", err)
+			}
+			img, err := tmpl.Render(384, note)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if img.Bounds().Dx() != 384 {
+				t.Fatalf("width = %d, want 384", img.Bounds().Dx())
+			}
+			corner := PixelBit(img, 0, 0, DefaultThreshold)
+			if want := tmpl.Border != BorderNone; corner != want {
+				t.Errorf("corner pixel set = %v, want %v", corner, want)
+			}
+		})
+	}
+	if _, err := LoadNoteTemplate("missing"); err == nil {
+		t.Fatal("LoadNoteTemplate() succeeded for unknown template")
+	}
+}
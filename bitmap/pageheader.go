@@ -0,0 +1,90 @@
+package bitmap
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/rusq/thermoprint/locale"
+)
+
+const (
+	// pageHeaderBand is the height of the page header.
+	pageHeaderBand = 24
+	// pageHeaderGap is the gap between the label and the lines.
+	pageHeaderGap = 6
+	// footerGap is the blank space around the footer.
+	footerGap = 4
+)
+
+// PageHeader returns the band of the given width with the "page n/total"
+// label between two horizontal lines, that marks the start of the page of a
+// multi-page document on the continuous strip.  The label is in the default
+// language, see [locale.SetDefault].
+func PageHeader(width, n, total int) *image.RGBA {
+	return LabelHeader(width, locale.Sprintf("page %d/%d", n, total))
+}
+
+// LabelHeader returns the band of the given width with the label between
+// two horizontal lines, see [PageHeader].  The label is printed with the
+// ASCII font.
+func LabelHeader(width int, label string) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, pageHeaderBand))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+
+	face := basicfont.Face7x13
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  image.Black,
+		Face: face,
+	}
+	labelWidth := d.MeasureString(label).Ceil()
+	x := (width - labelWidth) / 2
+	mid := pageHeaderBand / 2
+	d.Dot = fixed.P(x, mid+(face.Ascent-face.Descent)/2)
+	d.DrawString(label)
+
+	line := image.Rect(0, mid-posterMark/2, x-pageHeaderGap, mid-posterMark/2+posterMark)
+	draw.Draw(dst, line, image.Black, image.Point{}, draw.Src)
+	line.Min.X, line.Max.X = x+labelWidth+pageHeaderGap, width
+	draw.Draw(dst, line, image.Black, image.Point{}, draw.Src)
+	return dst
+}
+
+// AddPageHeader returns the image with the [PageHeader] of the image width
+// above it.
+func AddPageHeader(img image.Image, n, total int) image.Image {
+	b := img.Bounds()
+	hdr := PageHeader(b.Dx(), n, total)
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), pageHeaderBand+b.Dy()))
+	draw.Draw(dst, hdr.Bounds(), hdr, image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(0, pageHeaderBand, b.Dx(), dst.Bounds().Max.Y), img, b.Min, draw.Src)
+	return dst
+}
+
+// AppendPageHeader appends the [PageHeader] of the canvas width at the
+// bottom of the canvas.
+func (c *Composer) AppendPageHeader(n, total int) {
+	c.AppendLabelHeader(locale.Sprintf("page %d/%d", n, total))
+}
+
+// AppendLabelHeader appends the [LabelHeader] of the canvas width at the
+// bottom of the canvas.
+func (c *Composer) AppendLabelHeader(label string) {
+	c.AppendImageDither(LabelHeader(c.dst.Bounds().Dx(), label), DitherThresholdFn(DefaultThreshold))
+}
+
+// AppendFooter appends the text in the small font, centred, at the bottom of
+// the canvas, i.e. the origin of the print job.  The text that is wider than
+// the canvas is wrapped.
+func (c *Composer) AppendFooter(text string) error {
+	img, err := RenderTTF(text, basicfont.Face7x13, c.dst.Bounds().Dx(), WithAlignment(AlignCenter), WithWrap(true), WithPadding(footerGap))
+	if err != nil {
+		return err
+	}
+	c.AppendImageDither(img, DitherThresholdFn(DefaultThreshold))
+	return nil
+}
@@ -0,0 +1,49 @@
+package bitmap
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestPageHeader(t *testing.T) {
+	hdr := PageHeader(384, 2, 5)
+	if got, want := hdr.Bounds(), image.Rect(0, 0, 384, pageHeaderBand); got != want {
+		t.Fatalf("bounds = %v, want %v", got, want)
+	}
+	mid := pageHeaderBand / 2
+	for _, x := range []int{0, 383} {
+		if c := color.GrayModel.Convert(hdr.At(x, mid)).(color.Gray); c.Y != 0 {
+			t.Errorf("pixel at %d,%d = %v, want the black line", x, mid, c)
+		}
+	}
+	if c := color.GrayModel.Convert(hdr.At(0, 0)).(color.Gray); c.Y != 255 {
+		t.Errorf("background = %v, want white", c)
+	}
+}
+
+func TestAddPageHeader(t *testing.T) {
+	page := image.NewGray(image.Rect(10, 10, 110, 60))
+	got := AddPageHeader(page, 1, 2)
+	if want := image.Rect(0, 0, 100, pageHeaderBand+50); got.Bounds() != want {
+		t.Fatalf("bounds = %v, want %v", got.Bounds(), want)
+	}
+	if c := color.GrayModel.Convert(got.At(50, pageHeaderBand)).(color.Gray); c.Y != 0 {
+		t.Errorf("page pixel = %v, want black", c)
+	}
+}
+
+func TestAppendFooter(t *testing.T) {
+	c := NewComposer(384)
+	c.AppendImage(image.NewGray(image.Rect(0, 0, 384, 10)))
+	if err := c.AppendFooter("user@host, job 1, 2026-10-16 14:02"); err != nil {
+		t.Fatal(err)
+	}
+	b := c.Image().Bounds()
+	if want := 10 + 13 + 2*footerGap; b.Dy() != want {
+		t.Errorf("height = %d, want %d", b.Dy(), want)
+	}
+	if err := NewComposer(2 * footerGap).AppendFooter("x"); err == nil {
+		t.Error("expected an error on the canvas too narrow for the footer")
+	}
+}
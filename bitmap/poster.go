@@ -0,0 +1,89 @@
+package bitmap
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// posterBand is the height of the bands with the alignment marks above
+	// and below each poster strip.
+	posterBand = 24
+	// posterMark is the thickness of the alignment marks.
+	posterMark = 2
+)
+
+// Poster scales the image to the width of n strips of the given width, and
+// slices it into n vertical strips, so that they can be printed one by one
+// and taped together into a larger picture.
+//
+// Each strip gets a band above and below the picture with the strip number
+// and the alignment marks: a horizontal line, that continues from strip to
+// strip, and the ticks at the edges, that meet the ticks of the neighbouring
+// strips.
+func Poster(img image.Image, n, width int) ([]image.Image, error) {
+	if n < 1 {
+		return nil, errors.New("number of poster strips must be positive")
+	}
+	if width <= 2*posterMark {
+		return nil, fmt.Errorf("strip width is too small: %d", width)
+	}
+	sb := img.Bounds()
+	if sb.Empty() {
+		return nil, errors.New("empty image")
+	}
+	// unlike ResizeToFit, small images are scaled up to fill the poster.
+	height := sb.Dy() * n * width / sb.Dx()
+	scaled := image.NewRGBA(image.Rect(0, 0, n*width, height))
+	draw.Draw(scaled, scaled.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, sb, draw.Over, nil)
+
+	strips := make([]image.Image, n)
+	for i := range n {
+		strip := image.NewRGBA(image.Rect(0, 0, width, height+2*posterBand))
+		draw.Draw(strip, strip.Bounds(), image.White, image.Point{}, draw.Src)
+		draw.Draw(strip, image.Rect(0, posterBand, width, posterBand+height), scaled, image.Pt(i*width, 0), draw.Src)
+		drawPosterMarks(strip, i, n)
+		strips[i] = strip
+	}
+	return strips, nil
+}
+
+// drawPosterMarks draws the alignment marks and the label of the strip i of
+// n.
+func drawPosterMarks(dst *image.RGBA, i, n int) {
+	r := dst.Bounds()
+	fill := func(x0, y0, x1, y1 int) {
+		draw.Draw(dst, image.Rect(x0, y0, x1, y1), image.Black, image.Point{}, draw.Src)
+	}
+	bands := []struct{ top, line int }{
+		{0, posterBand - 2*posterMark},                            // above the picture
+		{r.Max.Y - posterBand, r.Max.Y - posterBand + posterMark}, // below the picture
+	}
+	for _, b := range bands {
+		fill(r.Min.X, b.line, r.Max.X, b.line+posterMark)
+		if i > 0 {
+			fill(r.Min.X, b.top, r.Min.X+posterMark, b.top+posterBand)
+		}
+		if i < n-1 {
+			fill(r.Max.X-posterMark, b.top, r.Max.X, b.top+posterBand)
+		}
+	}
+
+	label := fmt.Sprintf("%d/%d", i+1, n)
+	face := basicfont.Face7x13
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  image.Black,
+		Face: face,
+	}
+	x := (r.Dx() - d.MeasureString(label).Ceil()) / 2
+	d.Dot = fixed.P(x, bands[0].line-posterMark-face.Descent)
+	d.DrawString(label)
+}
@@ -0,0 +1,69 @@
+package bitmap
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestPoster(t *testing.T) {
+	// a gradient 100 wide and 50 high, black on the left.
+	src := image.NewGray(image.Rect(0, 0, 100, 50))
+	for x := range 100 {
+		for y := range 50 {
+			src.SetGray(x, y, color.Gray{Y: uint8(x * 255 / 99)})
+		}
+	}
+	t.Run("strips", func(t *testing.T) {
+		strips, err := Poster(src, 3, 384)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(strips) != 3 {
+			t.Fatalf("got %d strips, want 3", len(strips))
+		}
+		// the image is scaled up to 3*384=1152 wide, so 576 high.
+		want := image.Rect(0, 0, 384, 576+2*posterBand)
+		for i, s := range strips {
+			if s.Bounds() != want {
+				t.Errorf("strip %d bounds = %v, want %v", i, s.Bounds(), want)
+			}
+		}
+		mid := posterBand + 288
+		if g := ColorToGray(strips[0].At(100, mid)); g > 64 {
+			t.Errorf("first strip must be dark, got %d", g)
+		}
+		if g := ColorToGray(strips[2].At(284, mid)); g < 192 {
+			t.Errorf("last strip must be light, got %d", g)
+		}
+	})
+	t.Run("edge ticks", func(t *testing.T) {
+		strips, err := Poster(src, 2, 384)
+		if err != nil {
+			t.Fatal(err)
+		}
+		black := func(img image.Image, x, y int) bool { return ColorToGray(img.At(x, y)) == 0 }
+		// the outer edges have no ticks, the joined edges do.
+		if black(strips[0], 0, 0) || black(strips[1], 383, 0) {
+			t.Error("tick on the outer edge")
+		}
+		if !black(strips[0], 383, 0) || !black(strips[1], 0, 0) {
+			t.Error("no tick on the joined edge")
+		}
+		// the horizontal lines are at the same height on all strips.
+		y := posterBand - 2*posterMark
+		for i, s := range strips {
+			if !black(s, 192, y) {
+				t.Errorf("strip %d: no alignment line at %d", i, y)
+			}
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		if _, err := Poster(src, 0, 384); err == nil {
+			t.Error("expected error for zero strips")
+		}
+		if _, err := Poster(image.NewGray(image.Rectangle{}), 2, 384); err == nil {
+			t.Error("expected error for empty image")
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package bitmap
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+
+	"golang.org/x/image/draw"
+	"rsc.io/qr"
+)
+
+// qrQuietZone is the width of the white border around the QR code, in
+// modules, as required by the standard.
+const qrQuietZone = 4
+
+// QRCode renders the QR code of the data with the largest whole number of
+// pixels per module, so that the code with the quiet zone is not wider than
+// size pixels.  The modules are not interpolated, the image is pure black
+// and white.
+func QRCode(data string, size int) (*image.Gray, error) {
+	if data == "" {
+		return nil, errors.New("qr: no data")
+	}
+	code, err := qr.Encode(data, qr.M)
+	if err != nil {
+		return nil, fmt.Errorf("qr: %w", err)
+	}
+	modules := code.Size + 2*qrQuietZone
+	scale := size / modules
+	if scale < 1 {
+		return nil, fmt.Errorf("qr: the code of %d modules does not fit in %d pixels", modules, size)
+	}
+	img := image.NewGray(image.Rect(0, 0, modules*scale, modules*scale))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for y := range code.Size {
+		for x := range code.Size {
+			if !code.Black(x, y) {
+				continue
+			}
+			x0, y0 := (x+qrQuietZone)*scale, (y+qrQuietZone)*scale
+			draw.Draw(img, image.Rect(x0, y0, x0+scale, y0+scale), image.Black, image.Point{}, draw.Src)
+		}
+	}
+	return img, nil
+}
+
+// centre returns the image in the middle of the white canvas of the given
+// width.
+func centre(img image.Image, width int) image.Image {
+	b := img.Bounds()
+	if b.Dx() >= width {
+		return img
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	x := (width - b.Dx()) / 2
+	draw.Draw(dst, image.Rect(x, 0, x+b.Dx(), b.Dy()), img, b.Min, draw.Src)
+	return dst
+}
+
+// AppendQR appends the QR code of the data, that is not wider than size
+// pixels, in the middle of the canvas.  The code is not dithered.
+func (c *Composer) AppendQR(data string, size int) error {
+	img, err := QRCode(data, min(size, c.dst.Bounds().Dx()))
+	if err != nil {
+		return err
+	}
+	c.AppendImageDither(centre(img, c.dst.Bounds().Dx()), DitherThresholdFn(DefaultThreshold))
+	return nil
+}
+
+// cmdQR embeds the QR code of the data, the optional last argument is the
+// size of the code, if it is the length with the unit suffix, see
+// [ParseLength], by default the code is fitted within the margins.  The data
+// is interpolated like the text, see [Document.interpolate].
+func (d *Document) cmdQR(args ...string) error {
+	if len(args) == 0 {
+		return errors.New("no data for the QR code")
+	}
+	inner := d.width - 2*d.margin
+	size := inner
+	if last := args[len(args)-1]; len(args) > 1 {
+		if w, ok := parseUnitLength(last, d.dpi); ok {
+			if w == 0 || w > inner {
+				return fmt.Errorf("QR code size %s must be between 1 and %d pixels", last, inner)
+			}
+			size, args = w, args[:len(args)-1]
+		}
+	}
+	data, err := d.interpolate(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	img, err := QRCode(data, size)
+	if err != nil {
+		return err
+	}
+	line := centre(img, inner)
+	if d.margin > 0 {
+		line = inset(line, d.margin, d.width)
+	}
+	d.c.AppendImageDither(line, DitherThresholdFn(DefaultThreshold))
+	return nil
+}
+
+// parseUnitLength parses the length, that has the unit suffix, see
+// [ParseLength], the numbers without the unit are not lengths.
+func parseUnitLength(s string, dpi float64) (int, bool) {
+	lower := strings.ToLower(s)
+	for _, unit := range []string{"mm", "cm", "in", "px"} {
+		if strings.HasSuffix(lower, unit) {
+			n, err := ParseLength(s, dpi)
+			return n, err == nil
+		}
+	}
+	return 0, false
+}
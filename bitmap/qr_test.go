@@ -0,0 +1,66 @@
+package bitmap
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func isBlack(img image.Image, x, y int) bool {
+	return color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y < 128
+}
+
+func TestQRCode(t *testing.T) {
+	img, err := QRCode("hello", 384)
+	require.NoError(t, err)
+	// version 1 code is 21 modules, with the quiet zone 29.
+	assert.Equal(t, image.Rect(0, 0, 29*13, 29*13), img.Bounds())
+	assert.False(t, isBlack(img, 0, 0), "quiet zone must be white")
+	assert.True(t, isBlack(img, 4*13, 4*13), "finder pattern corner must be black")
+	assert.False(t, isBlack(img, 5*13, 5*13), "finder pattern ring must be white")
+
+	_, err = QRCode("hello", 20)
+	assert.Error(t, err, "code doesn't fit")
+	_, err = QRCode("", 384)
+	assert.Error(t, err)
+}
+
+func TestDocument_QR(t *testing.T) {
+	tests := []struct {
+		name      string
+		script    string
+		wantWidth int // of the code
+		wantErr   bool
+	}{
+		{name: "printer width", script: ".qr hello world", wantWidth: 29 * 13},
+		{name: "size", script: ".qr hello 100px", wantWidth: 29 * 3},
+		{name: "number is data", script: ".qr call 555", wantWidth: 29 * 13},
+		{name: "too large", script: ".qr hello 100mm", wantErr: true},
+		{name: "no data", script: ".qr", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := NewDocument(NewComposer(384), 203)
+			err := doc.Parse(strings.NewReader(tt.script + "\n"))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			img := doc.Image()
+			require.Equal(t, 384, img.Bounds().Dx())
+			// the code is in the middle, find its left edge on the first
+			// row of the finder pattern.
+			y := img.Bounds().Min.Y + tt.wantWidth*4/29
+			left := 0
+			for left < 384 && !isBlack(img, left, y) {
+				left++
+			}
+			assert.Equal(t, (384-tt.wantWidth)/2+tt.wantWidth*4/29, left)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package bitmap
+
+import (
+	"image"
+	"math"
+	"slices"
+
+	"golang.org/x/image/draw"
+)
+
+// DefaultReflowLineHeight is the default height of the text lines of the
+// reflowed page, in pixels, that is about 3mm at 203 DPI.
+const DefaultReflowLineHeight = 24
+
+const (
+	// reflowWordGap is the share of the typical text line height, that is
+	// the minimum gap between the words, see [wordGap].
+	reflowWordGap = 0.2
+	// reflowFigure is the number of the line heights, the band taller than
+	// which is a figure, that is not split into words.
+	reflowFigure = 2.5
+	// reflowSpacing is the gap between the lines of the reflowed page.
+	reflowSpacing = 4
+	// reflowMinLines is the minimum number of the bands on the page, that
+	// is reflowed, i.e. the page with a single picture is not text.
+	reflowMinLines = 3
+)
+
+// Reflow re-stacks the text of the page image, i.e. the A4 page of the
+// document, on the narrow paper of the given width.  Instead of scaling down
+// the whole page, which makes the text illegible, it finds the text lines
+// and the words on them, scales the words so that the typical line becomes
+// lineHeight pixels high, and flows them into the lines of the paper width,
+// like the text editor wraps the paragraph.  The vertical gap larger than
+// the line starts a new paragraph.  Figures, the bands much taller than the
+// text line, are placed on their own lines, scaled to fit the width.
+//
+// The words are detected by the gaps between the dark pixels, no OCR is
+// involved, so the layout of the tables and columns is lost.  If lineHeight
+// is zero, [DefaultReflowLineHeight] is used.  The page with fewer than
+// three lines, i.e. a single picture, is scaled to fit the width.
+func Reflow(img image.Image, width, lineHeight int) image.Image {
+	if lineHeight <= 0 {
+		lineHeight = DefaultReflowLineHeight
+	}
+	src := grayOnWhite(img)
+	bands := inkBands(src)
+	if len(bands) < reflowMinLines {
+		return ResizeToFit(img, width)
+	}
+	heights := make([]int, len(bands))
+	for i, b := range bands {
+		heights[i] = b.Dy()
+	}
+	slices.Sort(heights)
+	median := heights[len(heights)/2]
+	scale := float64(lineHeight) / float64(median)
+	gap := wordGap(src, bands, max(2, int(float64(median)*reflowWordGap)))
+	space := max(1, lineHeight*2/5)
+
+	type placed struct{ src, dst image.Rectangle }
+	var (
+		words        []placed
+		x, y, height int // cursor and the height of the current line
+	)
+	breakLine := func() {
+		if x > 0 {
+			y += height + reflowSpacing
+			x, height = 0, 0
+		}
+	}
+	// place puts the rectangle of the source on the current line, or on the
+	// next one, if it doesn't fit.
+	place := func(r image.Rectangle) {
+		s := min(scale, float64(width)/float64(r.Dx()))
+		w, h := max(1, int(float64(r.Dx())*s+0.5)), max(1, int(float64(r.Dy())*s+0.5))
+		if x > 0 && x+space+w > width {
+			breakLine()
+		}
+		if x > 0 {
+			x += space
+		}
+		words = append(words, placed{r, image.Rect(x, y, x+w, y+h)})
+		x += w
+		height = max(height, h)
+	}
+	for i, b := range bands {
+		if i > 0 && b.Min.Y-bands[i-1].Max.Y > median {
+			breakLine()
+			y += lineHeight / 2
+		}
+		if float64(b.Dy()) > reflowFigure*float64(median) {
+			breakLine()
+			place(b)
+			breakLine()
+			continue
+		}
+		for _, w := range inkWords(inkColumns(src, b), b, gap) {
+			place(w)
+		}
+	}
+	breakLine()
+
+	dst := image.NewGray(image.Rect(0, 0, width, max(1, y-reflowSpacing)))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	for _, w := range words {
+		draw.CatmullRom.Scale(dst, w.dst, src, w.src, draw.Src, nil)
+	}
+	return dst
+}
+
+// grayOnWhite returns the grayscale copy of the image, the transparent
+// pixels are white.
+func grayOnWhite(img image.Image) *image.Gray {
+	dst := image.NewGray(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
+
+// inkBands returns the bands of the rows with the dark pixels, that are the
+// text lines, narrowed to the columns with the dark pixels.
+func inkBands(img *image.Gray) []image.Rectangle {
+	b := img.Bounds()
+	var (
+		bands []image.Rectangle
+		band  image.Rectangle
+		in    bool // inside the band
+	)
+	for y := b.Min.Y; y <= b.Max.Y; y++ {
+		left, right := math.MaxInt, -1
+		for x := b.Min.X; y < b.Max.Y && x < b.Max.X; x++ {
+			if img.GrayAt(x, y).Y < DefaultThreshold {
+				left, right = min(left, x), x
+			}
+		}
+		switch {
+		case right >= 0 && !in:
+			band, in = image.Rect(left, y, right+1, y+1), true
+		case right >= 0:
+			band = band.Union(image.Rect(left, y, right+1, y+1))
+		case in:
+			bands, in = append(bands, band), false
+		}
+	}
+	return bands
+}
+
+// inkColumns reports, whether each column of the band has dark pixels.
+func inkColumns(img *image.Gray, band image.Rectangle) []bool {
+	cols := make([]bool, band.Dx())
+	for x := band.Min.X; x < band.Max.X; x++ {
+		for y := band.Min.Y; y < band.Max.Y && !cols[x-band.Min.X]; y++ {
+			cols[x-band.Min.X] = img.GrayAt(x, y).Y < DefaultThreshold
+		}
+	}
+	return cols
+}
+
+// wordGap returns the width of the narrowest gap between the words.  The
+// gaps between the dark columns of the bands fall into the two groups: the
+// narrow ones between the letters and the wide ones between the words, the
+// threshold is in the middle between them.  The text set in the monospace
+// font has the wide gaps around the narrow letters, so the threshold can't
+// be derived from the line height alone, that only sets the minimum.
+func wordGap(img *image.Gray, bands []image.Rectangle, minimum int) int {
+	var gaps []float64
+	for _, b := range bands {
+		last := -1
+		for x, dark := range inkColumns(img, b) {
+			if !dark {
+				continue
+			}
+			if last >= 0 && x-last > 1 {
+				gaps = append(gaps, float64(x-last-1))
+			}
+			last = x
+		}
+	}
+	if len(gaps) < 2 {
+		return minimum
+	}
+	// two-means clustering of the gap widths.
+	lo, hi := slices.Min(gaps), slices.Max(gaps)
+	for range 10 {
+		var sum [2]float64
+		var n [2]int
+		for _, g := range gaps {
+			i := 0
+			if g-lo > hi-g {
+				i = 1
+			}
+			sum[i] += g
+			n[i]++
+		}
+		if n[0] == 0 || n[1] == 0 {
+			return minimum
+		}
+		lo, hi = sum[0]/float64(n[0]), sum[1]/float64(n[1])
+	}
+	return max(minimum, int((lo+hi)/2))
+}
+
+// inkWords splits the band into the words, separated by more than gap
+// columns without the dark pixels, cols are the [inkColumns] of the band.
+// The words span the whole height of the band, so that their baselines
+// remain aligned.
+func inkWords(cols []bool, band image.Rectangle, gap int) []image.Rectangle {
+	var (
+		words []image.Rectangle
+		start = -1 // first column of the current word
+		last  = -1 // last dark column of the current word
+	)
+	for i, dark := range cols {
+		if !dark {
+			continue
+		}
+		x := band.Min.X + i
+		if start >= 0 && x-last > gap+1 {
+			words = append(words, image.Rect(start, band.Min.Y, last+1, band.Max.Y))
+			start = -1
+		}
+		if start < 0 {
+			start = x
+		}
+		last = x
+	}
+	if start >= 0 {
+		words = append(words, image.Rect(start, band.Min.Y, last+1, band.Max.Y))
+	}
+	return words
+}
@@ -0,0 +1,76 @@
+package bitmap
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font/basicfont"
+)
+
+func TestReflow(t *testing.T) {
+	text := strings.Repeat("the quick brown fox jumps over the lazy dog ", 4) + "\n" +
+		strings.Repeat("pack my box with five dozen liquor jugs ", 4) + "\n\n\n" +
+		"sphinx of black quartz judge my vow"
+	page, err := RenderTTF(text, basicfont.Face7x13, 1200, WithPadding(40))
+	require.NoError(t, err)
+	require.Len(t, inkBands(grayOnWhite(page)), 3)
+
+	out := Reflow(page, 384, 26)
+	require.Equal(t, 384, out.Bounds().Dx())
+	bands := inkBands(grayOnWhite(out))
+	// two source lines of ~1100px at double size need at least 12 lines,
+	// and the paragraph at least 2.
+	assert.GreaterOrEqual(t, len(bands), 14)
+	for _, b := range bands {
+		assert.LessOrEqual(t, b.Dy(), 26, "line %v is taller than the line height", b)
+	}
+	// the gap before the paragraph is larger than between the lines.
+	var maxGap int
+	for i := 1; i < len(bands); i++ {
+		maxGap = max(maxGap, bands[i].Min.Y-bands[i-1].Max.Y)
+	}
+	assert.GreaterOrEqual(t, maxGap, 13)
+}
+
+func TestReflowPicture(t *testing.T) {
+	pic := image.NewGray(image.Rect(0, 0, 800, 400)) // black rectangle
+	out := Reflow(pic, 384, 0)
+	assert.Equal(t, image.Rect(0, 0, 384, 192), out.Bounds(), "scaled to fit")
+}
+
+func TestInkWords(t *testing.T) {
+	band := image.Rect(10, 0, 30, 5)
+	cols := make([]bool, band.Dx())
+	for _, x := range []int{0, 1, 3, 4, 9, 10, 15} { // gaps of 1, 4 and 4
+		cols[x] = true
+	}
+	assert.Equal(t, []image.Rectangle{
+		image.Rect(10, 0, 15, 5),
+		image.Rect(19, 0, 21, 5),
+		image.Rect(25, 0, 26, 5),
+	}, inkWords(cols, band, 2))
+	assert.Len(t, inkWords(cols, band, 4), 1)
+}
+
+func TestWordGap(t *testing.T) {
+	// letters 2px apart, words 9px apart.
+	img := grayOnWhite(image.NewAlpha(image.Rect(0, 0, 200, 10)))
+	x := 0
+	for w := range 5 {
+		for range 4 {
+			for y := range 10 {
+				img.Pix[y*img.Stride+x] = 0
+			}
+			x += 3
+		}
+		x += 9 - 2 + w%2 // 9 or 10
+	}
+	bands := inkBands(img)
+	require.Len(t, bands, 1)
+	gap := wordGap(img, bands, 2)
+	assert.True(t, gap >= 2 && gap < 8, "gap %d must separate the letters from the words", gap)
+	assert.Len(t, inkWords(inkColumns(img, bands[0]), bands[0], gap), 5)
+}
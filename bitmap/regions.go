@@ -0,0 +1,154 @@
+package bitmap
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// DefaultTileSize is the side of the square tile of the page segmentation,
+// in pixels, 4 mm at 203 dpi.
+const DefaultTileSize = 32
+
+// RegionKind is the kind of the page region.
+type RegionKind uint8
+
+const (
+	// RegionText is the text-like region: mostly dark and light pixels, the
+	// text, the line art, or the blank paper.
+	RegionText RegionKind = iota
+	// RegionPhoto is the photo-like region with the shades of grey.
+	RegionPhoto
+)
+
+// Segmentation is the page, split into the square tiles, each classified as
+// the text-like or the photo-like region.
+type Segmentation struct {
+	Bounds image.Rectangle // bounds of the page
+	Tile   int             // side of the tile, in pixels
+
+	cols, rows int
+	kinds      []RegionKind // row by row
+}
+
+// Segment splits the page into the tiles of the given size and classifies
+// them with the same histogram test as [IsDocument]: the tile, that is mostly
+// dark and light pixels, is text-like, the others are photo-like.  The
+// text-like tile between two photo-like ones, horizontally or vertically,
+// is the part of the photo, i.e. the light patch of the sky.  The tile of
+// zero size is [DefaultTileSize].
+func Segment(img image.Image, tile int) *Segmentation {
+	if tile <= 0 {
+		tile = DefaultTileSize
+	}
+	b := img.Bounds()
+	s := &Segmentation{
+		Bounds: b,
+		Tile:   tile,
+		cols:   (b.Dx() + tile - 1) / tile,
+		rows:   (b.Dy() + tile - 1) / tile,
+	}
+	s.kinds = make([]RegionKind, s.cols*s.rows)
+	if len(s.kinds) == 0 {
+		return s
+	}
+	gray := image.NewGray(b)
+	draw.Draw(gray, b, img, b.Min, draw.Src)
+	for row := range s.rows {
+		for col := range s.cols {
+			if !IsDocument(gray.SubImage(s.tileRect(col, row)), 0, 0) {
+				s.kinds[row*s.cols+col] = RegionPhoto
+			}
+		}
+	}
+	s.fillHoles()
+	return s
+}
+
+// tileRect returns the bounds of the tile.
+func (s *Segmentation) tileRect(col, row int) image.Rectangle {
+	p := s.Bounds.Min.Add(image.Pt(col*s.Tile, row*s.Tile))
+	return image.Rectangle{Min: p, Max: p.Add(image.Pt(s.Tile, s.Tile))}.Intersect(s.Bounds)
+}
+
+// kind returns the kind of the tile, the tiles outside the page are
+// text-like.
+func (s *Segmentation) kind(col, row int) RegionKind {
+	if col < 0 || col >= s.cols || row < 0 || row >= s.rows {
+		return RegionText
+	}
+	return s.kinds[row*s.cols+col]
+}
+
+// fillHoles makes the text-like tiles between the photo-like ones photo-like.
+func (s *Segmentation) fillHoles() {
+	var holes []int
+	for row := range s.rows {
+		for col := range s.cols {
+			if s.kind(col, row) == RegionPhoto {
+				continue
+			}
+			if (s.kind(col-1, row) == RegionPhoto && s.kind(col+1, row) == RegionPhoto) ||
+				(s.kind(col, row-1) == RegionPhoto && s.kind(col, row+1) == RegionPhoto) {
+				holes = append(holes, row*s.cols+col)
+			}
+		}
+	}
+	for _, i := range holes {
+		s.kinds[i] = RegionPhoto
+	}
+}
+
+// At returns the kind of the region at the pixel.
+func (s *Segmentation) At(x, y int) RegionKind {
+	if !image.Pt(x, y).In(s.Bounds) {
+		return RegionText
+	}
+	return s.kind((x-s.Bounds.Min.X)/s.Tile, (y-s.Bounds.Min.Y)/s.Tile)
+}
+
+// Count returns the number of the tiles of the kind.
+func (s *Segmentation) Count(kind RegionKind) int {
+	n := 0
+	for _, k := range s.kinds {
+		if k == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// RegionDither returns the dither function for the mixed pages: the page is
+// segmented, see [Segment], the text-like regions are binarised with the
+// threshold, so that the text stays crisp, and the photo-like regions are
+// dithered with dfn.  The page, that is all text or all photo, is processed
+// as a whole.  If dfn is nil, the default dither function is used.
+func RegionDither(dfn DitherFunc, threshold uint8) DitherFunc {
+	if dfn == nil {
+		dfn = DitherDefault
+	}
+	binarise := DitherThresholdFn(threshold)
+	return func(img image.Image, gamma float64) image.Image {
+		seg := Segment(img, DefaultTileSize)
+		switch {
+		case seg.Count(RegionPhoto) == 0:
+			return binarise(img, gamma)
+		case seg.Count(RegionText) == 0:
+			return dfn(img, gamma)
+		}
+		text := binarise(img, gamma)
+		photo := dfn(img, gamma)
+		b := img.Bounds()
+		dst := image.NewGray(b)
+		draw.Draw(dst, b, text, b.Min, draw.Src)
+		for row := range seg.rows {
+			for col := range seg.cols {
+				if seg.kind(col, row) == RegionPhoto {
+					r := seg.tileRect(col, row)
+					draw.Draw(dst, r, photo, r.Min, draw.Src)
+				}
+			}
+		}
+		return dst
+	}
+}
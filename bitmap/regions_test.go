@@ -0,0 +1,81 @@
+package bitmap
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// mixedPage returns the 128x128 page: the top half is the black text-like
+// bars on white, the bottom half is the grey gradient.
+func mixedPage() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 128, 128))
+	for y := range 128 {
+		for x := range 128 {
+			switch {
+			case y >= 64:
+				img.SetGray(x, y, color.Gray{Y: uint8(60 + x)}) // 60-187
+			case y%8 < 3:
+				img.SetGray(x, y, color.Gray{Y: 0})
+			default:
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestSegment(t *testing.T) {
+	seg := Segment(mixedPage(), 32)
+	if got := seg.At(10, 10); got != RegionText {
+		t.Errorf("At(10, 10) = %v, want text", got)
+	}
+	if got := seg.At(10, 100); got != RegionPhoto {
+		t.Errorf("At(10, 100) = %v, want photo", got)
+	}
+	if got, want := seg.Count(RegionPhoto), 8; got != want {
+		t.Errorf("Count(RegionPhoto) = %d, want %d", got, want)
+	}
+}
+
+func TestSegment_FillsHoles(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 96, 32))
+	for y := range 32 {
+		for x := range 96 {
+			c := color.Gray{Y: 128}
+			if x >= 32 && x < 64 {
+				c.Y = 230 // the light patch in the middle of the photo
+			}
+			img.SetGray(x, y, c)
+		}
+	}
+	if got := Segment(img, 32).At(40, 10); got != RegionPhoto {
+		t.Errorf("At(40, 10) = %v, want the light patch to be the part of the photo", got)
+	}
+}
+
+func TestSegment_Offset(t *testing.T) {
+	page := mixedPage()
+	sub := page.SubImage(image.Rect(0, 64, 128, 128))
+	if got := Segment(sub, 32).Count(RegionText); got != 0 {
+		t.Errorf("Count(RegionText) = %d, want 0 for the gradient", got)
+	}
+}
+
+func TestRegionDither(t *testing.T) {
+	page := mixedPage()
+	got := RegionDither(DAtkinson, DefaultThreshold)(page, DefaultGamma)
+	text := DitherThresholdFn(DefaultThreshold)(page, DefaultGamma)
+	photo := DAtkinson(page, DefaultGamma)
+	for y := range 128 {
+		for x := range 128 {
+			want := text
+			if y >= 64 {
+				want = photo
+			}
+			if ColorToGray(got.At(x, y)) != ColorToGray(want.At(x, y)) {
+				t.Fatalf("pixel (%d, %d) differs from the dithering of its region", x, y)
+			}
+		}
+	}
+}
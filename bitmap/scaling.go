@@ -31,6 +31,19 @@ func ResizeToFit(img image.Image, targetWidth int) image.Image {
 	return resized
 }
 
+// ScaleToWidth scales the image up or down to the target width while
+// maintaining aspect ratio.
+func ScaleToWidth(img image.Image, targetWidth int) image.Image {
+	if img.Bounds().Dx() == targetWidth || img.Bounds().Dx() == 0 {
+		return img
+	}
+	targetHeight := max(1, (img.Bounds().Dy()*targetWidth+img.Bounds().Dx()/2)/img.Bounds().Dx())
+	resized := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
+	draw.Draw(resized, resized.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(resized, resized.Bounds(), img, img.Bounds(), draw.Over, nil)
+	return resized
+}
+
 // ResizeCanvasY resizes the destination image to the new height, filling with white
 // if the new height is larger than the current height. If the new height is
 // smaller or equal to the current height, it returns the original image.
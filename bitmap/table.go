@@ -0,0 +1,264 @@
+package bitmap
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+)
+
+const (
+	// tableGap is the gap between the columns of the table, in pixels.
+	tableGap = columnGap
+	// headerGap is the blank space above and below the rule under the
+	// table header, in pixels.
+	headerGap = 2
+	// rowGap is the gap between the rows of the table, in pixels.
+	rowGap = 2
+)
+
+// TableOption is the option of the table, see [RenderTable].
+type TableOption func(*tableOptions)
+
+type tableOptions struct {
+	header     bool
+	headerFace font.Face // nil is the face of the table
+}
+
+// WithTableHeader sets whether the first row is the header.  The header is
+// printed with the face, if it is not nil, i.e. the bold variant of the
+// font, and is separated from the rest of the table with the rule.
+func WithTableHeader(header bool, face font.Face) TableOption {
+	return func(o *tableOptions) {
+		o.header, o.headerFace = header, face
+	}
+}
+
+// AppendTable renders the table of the composer width and appends it at the
+// bottom of the canvas, see [RenderTable].
+func (c *Composer) AppendTable(rows [][]string, face font.Face, opts ...TableOption) error {
+	img, err := RenderTable(rows, face, c.dst.Bounds().Dx(), opts...)
+	if err != nil {
+		return err
+	}
+	c.appendText(img)
+	return nil
+}
+
+// RenderTable renders the rows of the cells as the table of the width.  The
+// columns are as wide as their content, if the table fits the width,
+// otherwise the wide columns are narrowed and their cells are wrapped.  The
+// columns of the numbers are aligned right, the rest left.  The rows may
+// have the different number of cells, the missing ones are empty.
+func RenderTable(rows [][]string, face font.Face, width int, opts ...TableOption) (image.Image, error) {
+	var o tableOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("table has no rows")
+	}
+	n := 0
+	for _, row := range rows {
+		n = max(n, len(row))
+	}
+	if n == 0 {
+		return nil, errors.New("table has no columns")
+	}
+	faceOf := func(row int) font.Face {
+		if row == 0 && o.header && o.headerFace != nil {
+			return o.headerFace
+		}
+		return face
+	}
+
+	natural := make([]int, n)
+	minimal := make([]int, n) // the longest word
+	for r, row := range rows {
+		f := faceOf(r)
+		for i, cell := range row {
+			cell = strings.TrimSpace(cell)
+			natural[i] = max(natural[i], font.MeasureString(f, cell).Ceil())
+			for w := range strings.FieldsSeq(cell) {
+				minimal[i] = max(minimal[i], font.MeasureString(f, w).Ceil())
+			}
+		}
+	}
+	widths, err := columnWidths(natural, minimal, width-(n-1)*tableGap)
+	if err != nil {
+		return nil, err
+	}
+	numeric := numericColumns(rows, n, o.header)
+
+	var lines []image.Image
+	for r, row := range rows {
+		cells := make([]image.Image, n)
+		height := 0
+		for i := range n {
+			var cell string
+			if i < len(row) {
+				cell = strings.TrimSpace(row[i])
+			}
+			align := AlignLeft
+			if numeric[i] {
+				align = AlignRight
+			}
+			img, err := RenderTTF(cell, faceOf(r), widths[i], WithWrap(true), WithAlignment(align))
+			if err != nil {
+				return nil, fmt.Errorf("row %d, column %d: %w", r+1, i+1, err)
+			}
+			cells[i] = img
+			height = max(height, img.Bounds().Dy())
+		}
+		line := image.NewRGBA(image.Rect(0, 0, width, height+rowGap))
+		draw.Draw(line, line.Bounds(), image.White, image.Point{}, draw.Src)
+		x := 0
+		for i, img := range cells {
+			draw.Draw(line, image.Rect(x, 0, x+widths[i], img.Bounds().Dy()), img, img.Bounds().Min, draw.Src)
+			x += widths[i] + tableGap
+		}
+		lines = append(lines, line)
+		if r == 0 && o.header {
+			lines = append(lines, rule(width, headerGap))
+		}
+	}
+	return stack(lines, width), nil
+}
+
+// columnWidths returns the widths of the columns, that fit the available
+// width.  The columns narrower than their share keep the natural width, the
+// rest of the width is shared by the wider columns in proportion to their
+// natural width, but not narrower than their longest word, if possible.
+// The spare width, if any, is given to the widest column.
+func columnWidths(natural, minimal []int, avail int) ([]int, error) {
+	n := len(natural)
+	if avail < n {
+		return nil, errors.New("no room for the table columns")
+	}
+	widths := make([]int, n)
+	for i := range natural {
+		widths[i] = max(1, natural[i])
+	}
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+	if total <= avail {
+		widest := 0
+		for i, w := range widths {
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		widths[widest] += avail - total
+		return widths, nil
+	}
+	// narrow the wide columns.
+	fixed := make([]bool, n)
+	for {
+		rest, restNatural := avail, 0
+		for i := range n {
+			if fixed[i] {
+				rest -= widths[i]
+			} else {
+				restNatural += natural[i]
+			}
+		}
+		changed := false
+		for i := range n {
+			if fixed[i] {
+				continue
+			}
+			share := rest * natural[i] / max(1, restNatural)
+			if natural[i] <= share {
+				fixed[i], changed = true, true
+			}
+		}
+		if !changed {
+			for i := range n {
+				if !fixed[i] {
+					widths[i] = max(1, rest*natural[i]/max(1, restNatural))
+				}
+			}
+			break
+		}
+	}
+	// widen the columns, that split the words, at the expense of the
+	// columns, that have the room.
+	for i := range n {
+		for widths[i] < minimal[i] {
+			donor := -1
+			for j := range n {
+				if j != i && widths[j] > minimal[j] && (donor < 0 || widths[j]-minimal[j] > widths[donor]-minimal[donor]) {
+					donor = j
+				}
+			}
+			if donor < 0 {
+				break
+			}
+			d := min(minimal[i]-widths[i], widths[donor]-minimal[donor])
+			widths[i], widths[donor] = widths[i]+d, widths[donor]-d
+		}
+	}
+	return widths, nil
+}
+
+// numericColumns reports, for each column, whether all its non-empty cells,
+// except the header, are numbers.
+func numericColumns(rows [][]string, n int, header bool) []bool {
+	numeric := make([]bool, n)
+	seen := make([]bool, n)
+	for i := range numeric {
+		numeric[i] = true
+	}
+	if header {
+		rows = rows[1:]
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			cell = strings.TrimSpace(cell)
+			if cell == "" {
+				continue
+			}
+			seen[i] = true
+			if !isNumber(cell) {
+				numeric[i] = false
+			}
+		}
+	}
+	for i := range numeric {
+		numeric[i] = numeric[i] && seen[i]
+	}
+	return numeric
+}
+
+// isNumber reports whether s is the number, i.e. 12, -3.5, 1,200.00, 45%
+// or $9.99.
+func isNumber(s string) bool {
+	s = strings.TrimLeft(s, "$€£¥+-")
+	s = strings.TrimRight(s, "%")
+	s = strings.ReplaceAll(s, ",", "")
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// stack stacks the images one under another.
+func stack(imgs []image.Image, width int) image.Image {
+	height := 0
+	for _, img := range imgs {
+		height += img.Bounds().Dy()
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	y := 0
+	for _, img := range imgs {
+		b := img.Bounds()
+		draw.Draw(dst, image.Rect(0, y, b.Dx(), y+b.Dy()), img, b.Min, draw.Src)
+		y += b.Dy()
+	}
+	return dst
+}
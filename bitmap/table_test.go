@@ -0,0 +1,103 @@
+package bitmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rusq/thermoprint/fontmgr"
+)
+
+func TestColumnWidths(t *testing.T) {
+	tests := []struct {
+		name    string
+		natural []int
+		minimal []int
+		avail   int
+		want    []int
+		wantErr bool
+	}{
+		{
+			name:    "fits, spare to the widest",
+			natural: []int{50, 20, 30},
+			minimal: []int{20, 20, 30},
+			avail:   200,
+			want:    []int{150, 20, 30},
+		},
+		{
+			name:    "narrow columns keep the longest word",
+			natural: []int{300, 20, 100},
+			minimal: []int{40, 20, 40},
+			avail:   200,
+			want:    []int{131, 20, 47},
+		},
+		{
+			name:    "words are not split",
+			natural: []int{300, 300},
+			minimal: []int{10, 150},
+			avail:   200,
+			want:    []int{50, 150},
+		},
+		{
+			name:    "no room",
+			natural: []int{10, 10, 10},
+			minimal: []int{10, 10, 10},
+			avail:   2,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := columnWidths(tt.natural, tt.minimal, tt.avail)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			sum := 0
+			for _, w := range got {
+				sum += w
+			}
+			assert.LessOrEqual(t, sum, tt.avail)
+		})
+	}
+}
+
+func TestNumericColumns(t *testing.T) {
+	rows := [][]string{
+		{"item", "qty", "price", "note"},
+		{"tea", "2", "$3.50", ""},
+		{"cake", "", "1,200.00", "-"},
+	}
+	assert.Equal(t, []bool{false, true, true, false}, numericColumns(rows, 4, true))
+	assert.Equal(t, []bool{false, false, false, false}, numericColumns(rows, 4, false))
+}
+
+func TestRenderTable(t *testing.T) {
+	face := fontmgr.DefaultFont
+	lineHeight := face.Metrics().Height.Ceil()
+
+	t.Run("rows", func(t *testing.T) {
+		img, err := RenderTable([][]string{{"a", "1"}, {"b", "2", "extra"}}, face, 384)
+		require.NoError(t, err)
+		assert.Equal(t, 384, img.Bounds().Dx())
+		assert.Equal(t, 2*(lineHeight+rowGap), img.Bounds().Dy())
+	})
+	t.Run("header", func(t *testing.T) {
+		img, err := RenderTable([][]string{{"name", "n"}, {"b", "2"}}, face, 384, WithTableHeader(true, nil))
+		require.NoError(t, err)
+		assert.Equal(t, 2*(lineHeight+rowGap)+2*headerGap+2, img.Bounds().Dy())
+	})
+	t.Run("wrapped", func(t *testing.T) {
+		long := "the quick brown fox jumps over the lazy dog again and again"
+		img, err := RenderTable([][]string{{long, long}}, face, 384)
+		require.NoError(t, err)
+		assert.Greater(t, img.Bounds().Dy(), lineHeight+rowGap)
+	})
+	t.Run("empty", func(t *testing.T) {
+		_, err := RenderTable(nil, face, 384)
+		assert.Error(t, err)
+	})
+}
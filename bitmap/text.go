@@ -1,8 +1,11 @@
 package bitmap
 
 import (
+	"errors"
 	"image"
+	"image/color"
 	"strings"
+	"unicode"
 
 	"golang.org/x/image/draw"
 	"golang.org/x/image/font"
@@ -13,25 +16,282 @@ var (
 	replacer = strings.NewReplacer("\t", strings.Repeat(" ", 8))
 )
 
-func RenderTTF(text string, face font.Face, imgWidth int) (image.Image, error) {
+// Alignment is the horizontal alignment of the text lines.
+type Alignment int
+
+const (
+	AlignLeft Alignment = iota
+	AlignCenter
+	AlignRight
+)
+
+type textOptions struct {
+	align   Alignment
+	wrap    bool
+	hyphen  bool        // hyphenate the long words when wrapping
+	spacing int         // extra pixels between lines
+	padding int         // pixels around the text
+	margin  int         // extra pixels on the left and right
+	bg      color.Color // background colour
+}
+
+// TextOption is the option for [RenderTTF].
+type TextOption func(*textOptions)
+
+// WithAlignment sets the alignment of the text lines.
+func WithAlignment(a Alignment) TextOption {
+	return func(o *textOptions) {
+		o.align = a
+	}
+}
+
+// WithWrap enables wrapping of the lines that don't fit the width, see
+// [WrapText].
+func WithWrap(b bool) TextOption {
+	return func(o *textOptions) {
+		o.wrap = b
+	}
+}
+
+// WithHyphenation enables hyphenation of the long words, that don't fit
+// at the end of the wrapped line, see [Hyphenate].  It has no effect
+// without [WithWrap].
+func WithHyphenation(b bool) TextOption {
+	return func(o *textOptions) {
+		o.hyphen = b
+	}
+}
+
+// WithLineSpacing adds the extra pixels between the lines.
+func WithLineSpacing(px int) TextOption {
+	return func(o *textOptions) {
+		o.spacing = px
+	}
+}
+
+// WithPadding sets the padding around the text in pixels.
+func WithPadding(px int) TextOption {
+	return func(o *textOptions) {
+		o.padding = px
+	}
+}
+
+// WithMargin sets the left and right margins in pixels, unlike the padding
+// they are not filled with the background colour.
+func WithMargin(px int) TextOption {
+	return func(o *textOptions) {
+		o.margin = px
+	}
+}
+
+// WithBackground sets the background colour.  The text is drawn in black on
+// the light backgrounds, and in white on the dark ones.
+func WithBackground(c color.Color) TextOption {
+	return func(o *textOptions) {
+		o.bg = c
+	}
+}
+
+// RenderTTF renders the text with the face on the image of the given width.
+// The height of the image fits all lines of the text.  By default, the text
+// is aligned left, drawn in black on white, and the lines that don't fit are
+// cut off.
+func RenderTTF(text string, face font.Face, imgWidth int, opts ...TextOption) (image.Image, error) {
+	o := textOptions{bg: color.White}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.padding < 0 || o.spacing < 0 || o.margin < 0 {
+		return nil, errors.New("padding, margin and line spacing can't be negative")
+	}
+	inner := imgWidth - 2*o.padding - 2*o.margin
+	if inner <= 0 {
+		return nil, errors.New("no room for text")
+	}
+	if o.wrap {
+		if o.hyphen {
+			text = Hyphenate(text)
+		}
+		text = WrapText(face, text, inner)
+	}
+	text = strings.ReplaceAll(text, softHyphen, "")
 	lines := strings.Split(text, "\n")
-	imgHeight := len(lines) * face.Metrics().Height.Ceil()
-	// lineHeight := face.Metrics().Ascent.Ceil() + face.Metrics().Height.Ceil()
+	lineHeight := face.Metrics().Height.Ceil() + o.spacing
+	imgHeight := len(lines)*lineHeight - o.spacing + 2*o.padding
 
-	fg, bg := image.Black, image.White
+	var fg = image.Black
+	if color.GrayModel.Convert(o.bg).(color.Gray).Y < 0x80 {
+		fg = image.White
+	}
 	img := image.NewRGBA(image.Rect(0, 0, imgWidth, imgHeight))
-	draw.Draw(img, img.Bounds(), bg, image.Point{}, draw.Src)
+	if o.margin > 0 {
+		draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	}
+	// box is the text area with the padding, the text is clipped to it.
+	box := img.SubImage(image.Rect(o.margin, 0, imgWidth-o.margin, imgHeight)).(*image.RGBA)
+	draw.Draw(box, box.Bounds(), image.NewUniform(o.bg), image.Point{}, draw.Src)
 
 	var d = font.Drawer{
-		Dst:  img,
+		Dst:  box,
 		Src:  fg,
 		Face: face,
-		Dot:  fixed.P(0, face.Metrics().Ascent.Ceil()), // Start at the top
 	}
+	left := o.margin + o.padding
+	y := o.padding + face.Metrics().Ascent.Ceil() // Start at the top
 	for _, line := range lines {
-		d.DrawString(replacer.Replace(line))
-		d.Dot.X = fixed.I(0) // Reset X position to the start of the line
-		d.Dot.Y += face.Metrics().Height
+		line = replacer.Replace(line)
+		x := left
+		switch o.align {
+		case AlignCenter:
+			x += (inner - d.MeasureString(line).Ceil()) / 2
+		case AlignRight:
+			x += inner - d.MeasureString(line).Ceil()
+		}
+		d.Dot = fixed.P(max(left, x), y)
+		d.DrawString(line)
+		y += lineHeight
 	}
 	return img, nil
 }
+
+// WrapText wraps the text on word boundaries, so that every line fits the
+// width in pixels when rendered with the face.  The word that doesn't fit
+// the line is hyphenated at the soft hyphen (U+00AD), if there's one, see
+// [Hyphenate].  Words that are longer than the width are broken.  Existing
+// line breaks are preserved, the soft hyphens are removed.
+func WrapText(face font.Face, text string, width int) string {
+	fits := func(s string) bool {
+		return font.MeasureString(face, replacer.Replace(s)).Ceil() <= width
+	}
+	join := func(line, word string) string {
+		if line == "" {
+			return word
+		}
+		return line + " " + word
+	}
+	var out []string
+	for para := range strings.SplitSeq(text, "\n") {
+		var line string
+		for _, word := range strings.Fields(para) {
+			parts := strings.Split(word, softHyphen)
+			for len(parts) > 0 {
+				whole := strings.Join(parts, "")
+				if fits(join(line, whole)) {
+					line = join(line, whole)
+					break
+				}
+				// the longest part of the word, that fits with the hyphen.
+				k := len(parts) - 1
+				for k > 0 && !fits(join(line, strings.Join(parts[:k], "")+"-")) {
+					k--
+				}
+				if k > 0 {
+					out = append(out, join(line, strings.Join(parts[:k], "")+"-"))
+					line, parts = "", parts[k:]
+					continue
+				}
+				if line != "" {
+					out = append(out, line)
+					line = ""
+					continue // try it on the empty line
+				}
+				// break the words that don't fit on their own
+				for _, r := range whole {
+					if !fits(line + string(r)) {
+						out = append(out, line)
+						line = ""
+					}
+					line += string(r)
+				}
+				break
+			}
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// softHyphen is the invisible hyphenation point, that is printed as the
+// hyphen only at the end of the line.
+const softHyphen = "\u00ad"
+
+const (
+	// hyphenMinWord is the number of letters in the shortest word, that is
+	// hyphenated.
+	hyphenMinWord = 6
+	// hyphenMinLeft and hyphenMinRight are the minimum number of letters
+	// before and after the hyphen.
+	hyphenMinLeft  = 2
+	hyphenMinRight = 3
+)
+
+// Hyphenate inserts the soft hyphens into the long words of the text, so
+// that [WrapText] can break them.  It doesn't know the dictionary of any
+// language, instead, it breaks the word between the syllables, before the
+// consonant between two vowels (pa-per), between the two consonants between
+// the vowels (prin-ter), but not inside the consonant and "l" or "r", that
+// start the syllable together (thermo-printer).  It is right for most of the
+// words in most of the latin and cyrillic languages, and at least readable
+// for the rest.
+func Hyphenate(text string) string {
+	var (
+		b    strings.Builder
+		word []rune
+	)
+	flush := func() {
+		if len(word) >= hyphenMinWord {
+			for i, r := range word {
+				if i > 0 && hyphenBefore(word, i) {
+					b.WriteString(softHyphen)
+				}
+				b.WriteRune(r)
+			}
+		} else {
+			b.WriteString(string(word))
+		}
+		word = word[:0]
+	}
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		b.WriteRune(r)
+	}
+	flush()
+	return b.String()
+}
+
+// hyphenBefore reports whether the word can be hyphenated before the i-th
+// letter.
+func hyphenBefore(word []rune, i int) bool {
+	if i < hyphenMinLeft || len(word)-i < hyphenMinRight {
+		return false
+	}
+	if isVowel(word[i]) {
+		return false // the consonant starts the syllable
+	}
+	if isCluster(word[i], word[i+1]) {
+		return i+2 < len(word) && isVowel(word[i+2]) && (isVowel(word[i-1]) || isVowel(word[i-2])) // V-CCV, VC-CCV
+	}
+	if !isVowel(word[i+1]) {
+		return false
+	}
+	if isVowel(word[i-1]) {
+		return true // V-CV
+	}
+	return isVowel(word[i-2]) && !isCluster(word[i-1], word[i]) // VC-CV
+}
+
+// isCluster reports whether the consonants start the syllable together,
+// i.e. "pr" or "bl".
+func isCluster(a, b rune) bool {
+	return strings.ContainsRune("bcdfgkptбвгдкпт", unicode.ToLower(a)) && strings.ContainsRune("lrлр", unicode.ToLower(b))
+}
+
+// isVowel reports whether the letter is a vowel in the latin or cyrillic
+// alphabets.
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiouyàáâãäåæèéêëìíîïòóôõöøùúûüýÿаеёиоуыэюяіїє", unicode.ToLower(r))
+}
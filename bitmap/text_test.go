@@ -0,0 +1,198 @@
+package bitmap
+
+import (
+	"cmp"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/rusq/thermoprint/fontmgr"
+	"golang.org/x/image/font"
+)
+
+// inkBounds returns the bounds of the pixels that differ from the background
+// colour bg.
+func inkBounds(img image.Image, bg color.Color) image.Rectangle {
+	want := color.GrayModel.Convert(bg).(color.Gray).Y
+	var r image.Rectangle
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y != want {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return r
+}
+
+func TestRenderTTF(t *testing.T) {
+	face := fontmgr.DefaultFont
+	lineHeight := face.Metrics().Height.Ceil()
+	const width = 200
+	// fits "the quick brown fox" but not "the quick brown fox jumps".
+	wrapWidth := font.MeasureString(face, "the quick brown fox ").Ceil()
+
+	tests := []struct {
+		name       string
+		text       string
+		width      int
+		opts       []TextOption
+		bg         color.Color
+		wantHeight int
+		check      func(t *testing.T, ink image.Rectangle)
+	}{
+		{
+			name:       "defaults",
+			text:       "hello\nworld",
+			bg:         color.White,
+			wantHeight: 2 * lineHeight,
+			check: func(t *testing.T, ink image.Rectangle) {
+				if ink.Min.X > 2 {
+					t.Errorf("text is not aligned left: %v", ink)
+				}
+			},
+		},
+		{
+			name:       "right",
+			text:       "hello",
+			opts:       []TextOption{WithAlignment(AlignRight)},
+			bg:         color.White,
+			wantHeight: lineHeight,
+			check: func(t *testing.T, ink image.Rectangle) {
+				if ink.Max.X < width-2 {
+					t.Errorf("text is not aligned right: %v", ink)
+				}
+			},
+		},
+		{
+			name:       "center",
+			text:       "hello",
+			opts:       []TextOption{WithAlignment(AlignCenter)},
+			bg:         color.White,
+			wantHeight: lineHeight,
+			check: func(t *testing.T, ink image.Rectangle) {
+				if d := ink.Min.X - (width - ink.Max.X); d < -3 || 3 < d {
+					t.Errorf("text is not centered: %v", ink)
+				}
+			},
+		},
+		{
+			name:       "padding and spacing",
+			text:       "hello\nworld",
+			opts:       []TextOption{WithPadding(10), WithLineSpacing(5)},
+			bg:         color.White,
+			wantHeight: 2*lineHeight + 5 + 20,
+			check: func(t *testing.T, ink image.Rectangle) {
+				if ink.Min.X < 10 || ink.Min.Y < 10 {
+					t.Errorf("text is drawn over the padding: %v", ink)
+				}
+			},
+		},
+		{
+			name:       "margin",
+			text:       "hello",
+			opts:       []TextOption{WithMargin(30), WithAlignment(AlignRight)},
+			bg:         color.White,
+			wantHeight: lineHeight,
+			check: func(t *testing.T, ink image.Rectangle) {
+				if ink.Max.X > width-30 || ink.Max.X < width-32 {
+					t.Errorf("text is not aligned to the right margin: %v", ink)
+				}
+			},
+		},
+		{
+			name:       "wrap",
+			text:       "the quick brown fox jumps over the lazy dog",
+			width:      wrapWidth,
+			opts:       []TextOption{WithWrap(true)},
+			bg:         color.White,
+			wantHeight: 3 * lineHeight,
+			check: func(t *testing.T, ink image.Rectangle) {
+				if ink.Max.X > wrapWidth {
+					t.Errorf("wrapped text does not fit: %v", ink)
+				}
+			},
+		},
+		{
+			name:       "dark background",
+			text:       "hello",
+			opts:       []TextOption{WithBackground(color.Black)},
+			bg:         color.Black,
+			wantHeight: lineHeight,
+			check: func(t *testing.T, ink image.Rectangle) {
+				if ink.Empty() {
+					t.Error("no text on the dark background")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := cmp.Or(tt.width, width)
+			img, err := RenderTTF(tt.text, face, w, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := img.Bounds().Dy(); got != tt.wantHeight {
+				t.Errorf("height = %d, want %d", got, tt.wantHeight)
+			}
+			tt.check(t, inkBounds(img, tt.bg))
+		})
+	}
+}
+
+func TestHyphenate(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"v-cv", "papers", "pa-pers"},
+		{"vc-cv", "printer", "prin-ter"},
+		{"short word", "paper", "paper"},
+		{"several", "thermoprinter", "ther-mo-prin-ter"},
+		{"punctuation", "printer, paper.", "prin-ter, paper."},
+		{"cyrillic", "принтер", "прин-тер"},
+		{"capitals", "PRINTER", "PRIN-TER"},
+		{"cluster", "surprise", "sur-prise"},
+		{"two consonants", "window", "win-dow"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.ReplaceAll(Hyphenate(tt.text), softHyphen, "-")
+			if got != tt.want {
+				t.Errorf("Hyphenate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTTF_hyphenation(t *testing.T) {
+	face := fontmgr.DefaultFont // 8px wide
+	lineHeight := face.Metrics().Height.Ceil()
+	// fits "thermoprinter", but not "a thermoprinter".
+	width := font.MeasureString(face, "thermoprinter").Ceil()
+	if got := WrapText(face, "a thermoprinter", width); got != "a\nthermoprinter" {
+		t.Errorf("WrapText() = %q, want not hyphenated", got)
+	}
+	if got := WrapText(face, Hyphenate("a thermoprinter"), width); got != "a thermoprin-\nter" {
+		t.Errorf("WrapText() = %q, want hyphenated", got)
+	}
+	img, err := RenderTTF("a thermoprinter", face, width, WithWrap(true), WithHyphenation(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.Bounds().Dy(); got != 2*lineHeight {
+		t.Errorf("height = %d, want %d", got, 2*lineHeight)
+	}
+	// the soft hyphens are invisible without wrapping.
+	img, err = RenderTTF("ab\u00adcd", face, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ink := inkBounds(img, color.White); ink.Max.X > 4*8 {
+		t.Errorf("soft hyphen is printed: %v", ink)
+	}
+}
@@ -0,0 +1,97 @@
+package bitmap
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const mmPerInch = 25.4
+
+// ParseLength parses the length with the unit suffix, i.e. "5mm", "1.5cm",
+// "0.5in" or "40px", and returns it in dots at the given resolution.  The
+// number without the suffix is in millimetres.  Zero is allowed, negative
+// lengths are not.
+func ParseLength(s string, dpi float64) (int, error) {
+	units := []struct {
+		suffix string
+		dots   float64 // dots per unit
+	}{
+		{"mm", dpi / mmPerInch},
+		{"cm", dpi / mmPerInch * 10},
+		{"in", dpi},
+		{"px", 1},
+	}
+	num, scale := strings.TrimSpace(strings.ToLower(s)), dpi/mmPerInch
+	for _, u := range units {
+		if n, ok := strings.CutSuffix(num, u.suffix); ok {
+			num, scale = n, u.dots
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid length %q", s)
+	}
+	if !(v >= 0) || math.IsInf(v, 1) {
+		return 0, fmt.Errorf("invalid length %q: can't be negative", s)
+	}
+	return int(v*scale + 0.5), nil
+}
+
+// MMToDots converts millimetres to dots at the given resolution, rounding
+// to the nearest dot.
+func MMToDots(mm, dpi float64) int {
+	return int(mm*dpi/mmPerInch + 0.5)
+}
+
+// cmdSpace adds the blank space of the given length.
+func (d *Document) cmdSpace(args ...string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("invalid argument count, expected 1, provided: %d", len(args))
+	}
+	h, err := ParseLength(args[0], d.dpi)
+	if err != nil {
+		return err
+	}
+	if err := d.flush(); err != nil {
+		return err
+	}
+	d.c.Feed(h)
+	return nil
+}
+
+// cmdMargin sets the left and right margins of the following text and
+// images.
+func (d *Document) cmdMargin(args ...string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("invalid argument count, expected 1, provided: %d", len(args))
+	}
+	m, err := ParseLength(args[0], d.dpi)
+	if err != nil {
+		return err
+	}
+	if 2*m >= d.width {
+		return fmt.Errorf("margin %s leaves no room on the %d pixels wide page", args[0], d.width)
+	}
+	if d.frame != FrameNone {
+		return fmt.Errorf("margin can't be changed inside the frame, close it with %s off", dcFrame)
+	}
+	if err := d.flush(); err != nil {
+		return err
+	}
+	d.margin = m
+	return nil
+}
+
+// inset places the image at the left offset on the white canvas of the
+// given width.
+func inset(img image.Image, left, width int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, width, img.Bounds().Dy()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, img.Bounds().Sub(img.Bounds().Min).Add(image.Pt(left, 0)), img, img.Bounds().Min, draw.Src)
+	return dst
+}
@@ -0,0 +1,112 @@
+package bitmap
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLength(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    int
+		wantErr bool
+	}{
+		{s: "10mm", want: 80},
+		{s: "10", want: 80},
+		{s: "1cm", want: 80},
+		{s: "0.5in", want: 102},
+		{s: "40px", want: 40},
+		{s: " 2 MM ", want: 16},
+		{s: "0", want: 0},
+		{s: "-1mm", wantErr: true},
+		{s: "wide", wantErr: true},
+		{s: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseLength(tt.s, 203)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScaleToWidth(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	assert.Equal(t, image.Rect(0, 0, 40, 20), ScaleToWidth(src, 40).Bounds(), "upscale")
+	assert.Equal(t, image.Rect(0, 0, 10, 5), ScaleToWidth(src, 10).Bounds(), "downscale")
+	assert.Same(t, src, ScaleToWidth(src, 20))
+}
+
+// writePNG writes the black image of the given size and returns its name.
+func writePNG(t *testing.T, w, h int) string {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	name := filepath.Join(t.TempDir(), "black.png")
+	f, err := os.Create(name)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, png.Encode(f, img))
+	return name
+}
+
+func TestDocument_Units(t *testing.T) {
+	const width = 384
+	parse := func(t *testing.T, script string) image.Image {
+		t.Helper()
+		doc := NewDocument(NewComposer(width), 203)
+		require.NoError(t, doc.Parse(strings.NewReader(script)))
+		return doc.Image()
+	}
+
+	t.Run("space", func(t *testing.T) {
+		img := parse(t, ".space 10mm\n")
+		assert.Equal(t, 80, img.Bounds().Dy())
+		assert.Equal(t, image.Rectangle{}, inkBounds(img, color.White))
+	})
+	t.Run("image width", func(t *testing.T) {
+		name := writePNG(t, 10, 10)
+		img := parse(t, ".image "+name+" 1in\n")
+		assert.Equal(t, 203, img.Bounds().Dy())
+		ink := inkBounds(img.(*image.RGBA).SubImage(image.Rect(0, 1, width, 203)), color.White)
+		assert.Equal(t, image.Rect(0, 1, 203, 203), ink)
+	})
+	t.Run("image margin", func(t *testing.T) {
+		name := writePNG(t, 1000, 100)
+		img := parse(t, ".margin 20px\n.image "+name+"\n")
+		ink := inkBounds(img, color.White)
+		assert.Equal(t, 20, ink.Min.X)
+		assert.Equal(t, width-20, ink.Max.X)
+	})
+	t.Run("text margin", func(t *testing.T) {
+		img := parse(t, ".margin 5mm\n.align right\nhello\n")
+		ink := inkBounds(img, color.White)
+		assert.LessOrEqual(t, ink.Max.X, width-40)
+	})
+	t.Run("errors", func(t *testing.T) {
+		name := writePNG(t, 10, 10)
+		for _, script := range []string{
+			".space\n",
+			".space -1mm\n",
+			".margin 3in\n",
+			".margin\n",
+			".image " + name + " 0\n",
+			".image " + name + " 10in\n",
+		} {
+			doc := NewDocument(NewComposer(width), 203)
+			assert.Error(t, doc.Parse(strings.NewReader(script)), script)
+		}
+	})
+}
@@ -0,0 +1,532 @@
+package thermoprint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/image/font"
+
+	"github.com/rusq/thermoprint/bitmap"
+	"github.com/rusq/thermoprint/locale"
+)
+
+// CatPrinterModels are the names advertised by the printers of the "cat
+// printer" family, that are driven by [CatPrinter].
+var CatPrinterModels = []string{"GB01", "GB02", "GB03", "MX06"}
+
+// IsCatPrinter returns true if the name is one of the [CatPrinterModels].
+func IsCatPrinter(name string) bool {
+	return slices.Contains(CatPrinterModels, strings.ToUpper(name))
+}
+
+var catGATT = gattProfile{
+	service: "0000ae30-0000-1000-8000-00805f9b34fb",
+	tx:      "0000ae01-0000-1000-8000-00805f9b34fb",
+	rx:      "0000ae02-0000-1000-8000-00805f9b34fb",
+}
+
+// Commands of the cat printer protocol.  Every packet is framed as
+//
+//	51 78 <command> 00 <length, LE uint16> <data> <CRC8 of data> ff
+type catCommand byte
+
+const (
+	catFeed        catCommand = 0xa1 // feed the paper, data: lines, LE uint16
+	catPrintRow    catCommand = 0xa2 // print a bitmap row, LSB first
+	catGetState    catCommand = 0xa3 // request the device state
+	catQuality     catCommand = 0xa4 // set the print quality
+	catLattice     catCommand = 0xa6 // start or end of the print
+	catFlowControl catCommand = 0xae // notification: pause or resume sending
+	catEnergy      catCommand = 0xaf // set the energy, data: LE uint16
+	catApplyEnergy catCommand = 0xbe // apply the energy setting
+)
+
+var (
+	catMagic        = []byte{0x51, 0x78}
+	catLatticeStart = []byte{0xaa, 0x55, 0x17, 0x38, 0x44, 0x5f, 0x5f, 0x5f, 0x44, 0x38, 0x2c}
+	catLatticeEnd   = []byte{0xaa, 0x55, 0x17, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x17}
+)
+
+// Device state flags, reported in response to [catGetState].
+const (
+	catNoPaper    = 1 << 0
+	catCoverOpen  = 1 << 1
+	catOverheated = 1 << 2
+	catLowBattery = 1 << 3
+)
+
+const (
+	// catFeedLines is the paper fed after the print, so that the printout
+	// clears the tear bar.
+	catFeedLines = 80
+	// catPauseTimeout is the longest time the printer may hold the data
+	// flow, while it prints the buffered rows.
+	catPauseTimeout = 30 * time.Second
+	// catEnergyStep is the energy value of one energy level.
+	catEnergyStep = 0x2000
+)
+
+// crc8 returns the CRC-8 (polynomial 0x07) checksum of data.
+func crc8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for range 8 {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// catPacket returns the framed packet of the command with data.
+func catPacket(cmd catCommand, data ...byte) []byte {
+	pkt := make([]byte, 0, len(data)+8)
+	pkt = append(pkt, catMagic...)
+	pkt = append(pkt, byte(cmd), 0x00, byte(len(data)), byte(len(data)>>8))
+	pkt = append(pkt, data...)
+	return append(pkt, crc8(data), 0xff)
+}
+
+// parseCatPacket returns the command and the data of the packet received
+// from the printer.
+func parseCatPacket(pkt []byte) (catCommand, []byte, error) {
+	if len(pkt) < 8 || pkt[0] != catMagic[0] || pkt[1] != catMagic[1] {
+		return 0, nil, fmt.Errorf("invalid packet: % x", pkt)
+	}
+	n := int(pkt[4]) | int(pkt[5])<<8
+	if len(pkt) < 6+n+2 {
+		return 0, nil, fmt.Errorf("truncated packet: % x", pkt)
+	}
+	data := pkt[6 : 6+n]
+	if crc := crc8(data); crc != pkt[6+n] {
+		return 0, nil, fmt.Errorf("packet checksum mismatch: %02x != %02x", crc, pkt[6+n])
+	}
+	return catCommand(pkt[2]), data, nil
+}
+
+// catRows returns the print row packets of the bitmap, the first pixel of
+// the row is in the lowest bit of the first byte.
+func catRows(img image.Image, width int, threshold uint8) [][]byte {
+	b := img.Bounds()
+	rows := make([][]byte, b.Dy())
+	for y := range b.Dy() {
+		row := make([]byte, width/8)
+		for x := range min(width, b.Dx()) {
+			if bitmap.PixelBit(img, b.Min.X+x, b.Min.Y+y, threshold) {
+				row[x/8] |= 1 << (x % 8)
+			}
+		}
+		rows[y] = catPacket(catPrintRow, row...)
+	}
+	return rows
+}
+
+// catEnergyValue returns the energy command value for the energy level.
+func catEnergyValue(level uint8) uint16 {
+	return uint16(max(min(level, maxEnergy), minEnergy)) * catEnergyStep
+}
+
+// CatPrinter is a driver for the "cat printer" family, see
+// [CatPrinterModels].  It has the same API as [LXD02], and prints one job at
+// a time.  Zero value is unusable, initialise with [NewCatPrinter].
+type CatPrinter struct {
+	conn         Transport
+	connected    atomic.Bool
+	printing     atomic.Bool
+	disconnectMu sync.Mutex
+
+	rasteriser *GenericRasteriser
+	options    printOptions
+	alerts     alertLog
+	unknown    notificationRing // unrecognised notifications
+
+	mu       sync.Mutex
+	flags    byte // last reported device state
+	seen     bool // device state was reported
+	statusAt time.Time
+	stateCh  chan byte     // receives device state responses
+	resumeCh chan struct{} // not nil while paused, closed on resume
+}
+
+// NewCatPrinter connects to the cat printer.
+func NewCatPrinter(ctx context.Context, adapter *Adapter, sp SearchParameters, opt ...Option) (*CatPrinter, error) {
+	p := &CatPrinter{
+		rasteriser: &GenericRasteriser{
+			Width:      384,
+			Dpi:        203,
+			Threshold:  bitmap.DefaultThreshold,
+			DitherFunc: bitmap.DitherDefault,
+		},
+		options: printOptions{
+			energy:        2,
+			printInterval: DefaultPrintDelay,
+		},
+		stateCh: make(chan byte, 1),
+	}
+	for _, o := range opt {
+		o(&p.options)
+	}
+	if p.options.dithername != "" {
+		ditherFunc, ok := bitmap.DitherFunction(p.options.dithername)
+		if !ok {
+			return nil, fmt.Errorf("unknown dither function: %s", p.options.dithername)
+		}
+		p.rasteriser.SetDitherFunc(ditherFunc)
+	}
+	if !p.options.dryrun {
+		if err := p.Connect(ctx, adapter, sp); err != nil {
+			return nil, fmt.Errorf("failed to connect to printer: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// Connect connects to the cat printer using the provided adapter and search
+// parameters.
+func (p *CatPrinter) Connect(ctx context.Context, adapter *Adapter, sp SearchParameters) error {
+	if p.connected.Load() {
+		return nil
+	}
+	conn, err := p.options.dial(ctx, adapter, sp, catGATT)
+	if err != nil {
+		return err
+	}
+	p.conn = conn
+	if err := p.conn.Notify(p.notificationCallback); err != nil {
+		return fmt.Errorf("failed to enable notifications: %w", err)
+	}
+	p.connected.Store(true)
+	slog.Info("Connected to printer", "address", conn.Address())
+	return nil
+}
+
+func (p *CatPrinter) notificationCallback(value []byte) {
+	cmd, data, err := parseCatPacket(value)
+	if err != nil {
+		slog.Warn("Received invalid notification", "error", err)
+		p.unknown.add(value)
+		return
+	}
+	switch {
+	case cmd == catFlowControl && len(data) > 0:
+		p.setPaused(data[0] != 0)
+	case cmd == catGetState && len(data) > 0:
+		p.storeState(data[0])
+		select {
+		case p.stateCh <- data[0]:
+		default:
+		}
+	default:
+		slog.Debug("Received unknown notification", "command", fmt.Sprintf("%02x", byte(cmd)), "data", fmt.Sprintf("% x", data))
+		p.unknown.add(value)
+	}
+}
+
+// setPaused pauses or resumes sending the data to the printer.
+func (p *CatPrinter) setPaused(paused bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case paused && p.resumeCh == nil:
+		slog.Debug("printer paused the data flow")
+		p.resumeCh = make(chan struct{})
+	case !paused && p.resumeCh != nil:
+		slog.Debug("printer resumed the data flow")
+		close(p.resumeCh)
+		p.resumeCh = nil
+	}
+}
+
+// storeState stores the device state and records the alerts for the
+// conditions that appeared since the previous state.
+func (p *CatPrinter) storeState(flags byte) {
+	p.mu.Lock()
+	prev, seen := p.flags, p.seen
+	p.flags, p.seen, p.statusAt = flags, true, time.Now()
+	p.mu.Unlock()
+
+	raised := flags
+	if seen {
+		raised &^= prev
+	}
+	if raised&catNoPaper != 0 {
+		p.alerts.add(AlertNoPaper, SeverityCritical, locale.T("Printer is out of paper"))
+	}
+	if raised&catOverheated != 0 {
+		p.alerts.add(AlertCooldown, SeverityWarning, locale.T("Print head is cooling down"))
+	}
+	if raised&catLowBattery != 0 {
+		p.alerts.add(AlertBatteryLow, SeverityWarning, locale.T("Battery level is low"))
+	}
+}
+
+// requestState requests and returns the device state flags.
+func (p *CatPrinter) requestState(ctx context.Context) (byte, error) {
+	select { // drop the stale response
+	case <-p.stateCh:
+	default:
+	}
+	if err := p.send(ctx, catPacket(catGetState, 0x00)); err != nil {
+		return 0, err
+	}
+	select {
+	case flags := <-p.stateCh:
+		return flags, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-time.After(p.options.timeout()):
+		return 0, fmt.Errorf("%w waiting for the device state", ErrTimeout)
+	}
+}
+
+// checkCatState returns an error if the device state does not allow printing.
+func checkCatState(flags byte) error {
+	switch {
+	case flags&catNoPaper != 0:
+		return ErrNoPaper
+	case flags&catCoverOpen != 0:
+		return errors.New("printer cover is open")
+	}
+	return nil
+}
+
+// send writes the packet, waiting while the printer holds the data flow.
+func (p *CatPrinter) send(ctx context.Context, pkt []byte) error {
+	p.mu.Lock()
+	resumeCh := p.resumeCh
+	p.mu.Unlock()
+	if resumeCh != nil {
+		select {
+		case <-resumeCh:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(catPauseTimeout):
+			return fmt.Errorf("%w waiting for the printer to resume", ErrTimeout)
+		}
+	}
+	for i := range p.options.writeRetries() {
+		err := p.conn.Write(pkt)
+		if err == nil {
+			return nil
+		}
+		slog.Warn("send failed, retrying", "attempt", i+1, "error", err)
+		time.Sleep(p.options.writeRetryDelay())
+	}
+	return errors.New("BLE write failed after retries")
+}
+
+// PrintImage prints an image on the printer.  If dry run is enabled, it saves
+// the preview file to disk and exits.
+func (p *CatPrinter) PrintImage(ctx context.Context, img image.Image) error {
+	bmp := p.options.postProcess(p.rasteriser.ResizeAndDither(p.options.channel.Gray(img), p.options.gamma, p.options.autoDither))
+	if p.options.dryrun {
+		debugSaveImage(bmp, drRasteriseFile)
+		return nil
+	}
+	if !p.connected.Load() {
+		return ErrDisconnected
+	}
+	if !p.printing.CompareAndSwap(false, true) {
+		return ErrBusy
+	}
+	defer p.printing.Store(false)
+
+	flags, err := p.requestState(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkCatState(flags); err != nil {
+		return err
+	}
+
+	energy := catEnergyValue(p.options.energy)
+	packets := [][]byte{
+		catPacket(catQuality, 0x33),
+		catPacket(catEnergy, byte(energy), byte(energy>>8)),
+		catPacket(catApplyEnergy, 0x01),
+		catPacket(catLattice, catLatticeStart...),
+	}
+	packets = append(packets, catRows(bmp, p.rasteriser.LineWidth(), p.rasteriser.Threshold)...)
+	packets = append(packets,
+		catPacket(catFeed, catFeedLines, 0x00),
+		catPacket(catLattice, catLatticeEnd...),
+	)
+
+	t := time.NewTicker(p.options.printInterval)
+	defer t.Stop()
+	for i, pkt := range packets {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+		if err := p.send(ctx, pkt); err != nil {
+			return fmt.Errorf("send packet %d: %w", i, err)
+		}
+		p.options.reportProgress(i+1, len(packets))
+	}
+
+	// the printer reports running out of paper in the state.
+	if flags, err = p.requestState(ctx); err != nil {
+		return err
+	}
+	if flags&catNoPaper != 0 {
+		return ErrNoPaper
+	}
+	slog.Info("print completed successfully")
+	consumePaper(p.options.roll, &p.alerts, bmp.Bounds().Dy()+catFeedLines, p.rasteriser.DPI())
+	return nil
+}
+
+// PrintTextTTF renders the text with the font face and prints it.
+func (p *CatPrinter) PrintTextTTF(ctx context.Context, text string, face font.Face, opts ...bitmap.TextOption) error {
+	img, err := bitmap.RenderTTF(text, face, p.rasteriser.LineWidth(), opts...)
+	if err != nil {
+		return fmt.Errorf("failed to render TTF text: %w", err)
+	}
+	if p.options.dryrun {
+		debugSaveImage(img, drTextFile)
+	}
+	return p.PrintImage(ctx, img)
+}
+
+// PrintPattern prints the test pattern.  Buffer patterns are specific to
+// the LX-D02 protocol and are not supported.
+func (p *CatPrinter) PrintPattern(ctx context.Context, pattern string) error {
+	if imgFn, ok := TestImagePatterns[pattern]; ok {
+		return p.printImagePattern(ctx, imgFn)
+	}
+	if _, ok := GenerativePatterns[pattern]; ok {
+		return p.PrintGenerativePattern(ctx, pattern, 0)
+	}
+	if _, ok := TestBufferPatterns[pattern]; ok {
+		return fmt.Errorf("buffer pattern %s is not supported by this printer", pattern)
+	}
+	return fmt.Errorf("unknown test pattern: %s", pattern)
+}
+
+// PrintGenerativePattern prints one of the [GenerativePatterns] generated
+// from the seed.
+func (p *CatPrinter) PrintGenerativePattern(ctx context.Context, pattern string, seed uint64) error {
+	genFn, ok := GenerativePatterns[pattern]
+	if !ok {
+		return fmt.Errorf("unknown generative pattern: %s", pattern)
+	}
+	return p.printImagePattern(ctx, func(width int) image.Image {
+		return genFn(width, seed)
+	})
+}
+
+func (p *CatPrinter) printImagePattern(ctx context.Context, imgFn func(int) image.Image) error {
+	img := imgFn(p.rasteriser.LineWidth())
+	if img == nil {
+		return errors.New("test image pattern returned nil image")
+	}
+	if p.options.dryrun {
+		debugSaveImage(img, drPatternFile)
+	}
+	return p.PrintImage(ctx, img)
+}
+
+// SetOptions sets the print options.
+func (p *CatPrinter) SetOptions(opts ...Option) error {
+	for _, o := range opts {
+		o(&p.options)
+	}
+	if p.options.dithername != "" {
+		ditherFunc, ok := bitmap.DitherFunction(p.options.dithername)
+		if !ok {
+			return fmt.Errorf("unknown dither function: %s", p.options.dithername)
+		}
+		p.rasteriser.SetDitherFunc(ditherFunc)
+	}
+	return nil
+}
+
+// Width returns the maximum width of the print output in pixels.
+func (p *CatPrinter) Width() int {
+	return p.rasteriser.LineWidth()
+}
+
+func (p *CatPrinter) DPI() float64 {
+	return float64(p.rasteriser.DPI())
+}
+
+// Address returns the address of the connected printer, or an empty string,
+// if the printer is not connected.
+func (p *CatPrinter) Address() string {
+	if !p.connected.Load() {
+		return ""
+	}
+	return p.conn.Address()
+}
+
+// PrintInterval returns the interval between the data packets, see
+// [WithPrintInterval].
+func (p *CatPrinter) PrintInterval() time.Duration {
+	return p.options.printInterval
+}
+
+// Alerts returns the history of device alerts, oldest first.
+func (p *CatPrinter) Alerts() []Alert {
+	return p.alerts.list()
+}
+
+// UnknownNotifications returns the notifications that the driver did not
+// recognise, oldest first.
+func (p *CatPrinter) UnknownNotifications() []UnknownNotification {
+	return p.unknown.list()
+}
+
+// Snapshot returns the connection state and the last reported device state.
+// Cat printers do not report the battery level.
+func (p *CatPrinter) Snapshot() PrinterSnapshot {
+	state := stateIdle
+	if p.printing.Load() {
+		state = statePrinting
+	}
+	snap := PrinterSnapshot{
+		Connected: p.connected.Load(),
+		DryRun:    p.options.dryrun,
+		State:     state.String(),
+	}
+	p.mu.Lock()
+	if p.seen {
+		snap.NoPaper = p.flags&catNoPaper != 0
+		snap.LastStatusTime = p.statusAt
+	}
+	p.mu.Unlock()
+	snap.setRoll(p.options.roll)
+	return snap
+}
+
+// Disconnect disconnects from the printer.  It is safe to call concurrently
+// and more than once.
+func (p *CatPrinter) Disconnect() error {
+	p.disconnectMu.Lock()
+	defer p.disconnectMu.Unlock()
+
+	if p.options.dryrun || !p.connected.Swap(false) {
+		return nil
+	}
+	if err := p.conn.Notify(func([]byte) {}); err != nil {
+		slog.Warn("failed to disable notifications, never mind, let's continue", "error", err)
+	}
+	if err := p.conn.Disconnect(); err != nil {
+		return fmt.Errorf("failed to disconnect from printer: %w", err)
+	}
+	slog.Info("Disconnected from printer", "address", p.conn.Address())
+	return nil
+}
@@ -0,0 +1,196 @@
+package thermoprint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCRC8(t *testing.T) {
+	// CRC-8 check value, the checksum of "123456789".
+	if got := crc8([]byte("123456789")); got != 0xf4 {
+		t.Errorf("crc8 = %02x, want f4", got)
+	}
+}
+
+func TestCatPacket(t *testing.T) {
+	got := catPacket(catFeed, 0x50, 0x00)
+	want := []byte{0x51, 0x78, 0xa1, 0x00, 0x02, 0x00, 0x50, 0x00, crc8([]byte{0x50, 0x00}), 0xff}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("catPacket = % x, want % x", got, want)
+	}
+	cmd, data, err := parseCatPacket(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd != catFeed || !bytes.Equal(data, []byte{0x50, 0x00}) {
+		t.Errorf("parseCatPacket = %02x % x", byte(cmd), data)
+	}
+
+	bad := bytes.Clone(got)
+	bad[6] ^= 0xff
+	if _, _, err := parseCatPacket(bad); err == nil {
+		t.Error("parseCatPacket accepted corrupt data")
+	}
+	if _, _, err := parseCatPacket(got[:7]); err == nil {
+		t.Error("parseCatPacket accepted truncated packet")
+	}
+}
+
+func TestCatRows(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 16, 1))
+	for x := range 16 {
+		img.SetGray(x, 0, color.Gray{Y: 255})
+	}
+	img.SetGray(0, 0, color.Gray{}) // first pixel
+	img.SetGray(9, 0, color.Gray{}) // second byte, second pixel
+
+	rows := catRows(img, 16, 128)
+	if len(rows) != 1 {
+		t.Fatalf("got %d rows, want 1", len(rows))
+	}
+	_, data, err := parseCatPacket(rows[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0x01, 0x02}; !bytes.Equal(data, want) {
+		t.Errorf("row = %08b, want %08b", data, want)
+	}
+}
+
+func TestIsCatPrinter(t *testing.T) {
+	for name, want := range map[string]bool{"GB01": true, "mx06": true, "LX-D02": false, "": false} {
+		if got := IsCatPrinter(name); got != want {
+			t.Errorf("IsCatPrinter(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
+
+// fakeCatConn is the cat printer that responds to the state requests with
+// the flags.
+type fakeCatConn struct {
+	mu      sync.Mutex
+	flags   byte
+	written [][]byte
+	notify  func([]byte)
+	// pauseAt pauses the data flow when the packet is written, and closes
+	// paused, the flow resumes, when the test closes resume.
+	pauseAt int
+	paused  chan struct{}
+	resume  chan struct{}
+}
+
+func (c *fakeCatConn) Address() string { return "AABBCCDDEEFF" }
+
+func (c *fakeCatConn) Write(data []byte) error {
+	c.mu.Lock()
+	c.written = append(c.written, bytes.Clone(data))
+	n, notify, flags := len(c.written), c.notify, c.flags
+	c.mu.Unlock()
+	if catCommand(data[2]) == catGetState {
+		go notify(catPacket(catGetState, flags))
+	}
+	if n == c.pauseAt {
+		notify(catPacket(catFlowControl, 0x10))
+		close(c.paused)
+		go func() {
+			<-c.resume
+			notify(catPacket(catFlowControl, 0x00))
+		}()
+	}
+	return nil
+}
+
+func (c *fakeCatConn) Notify(fn func([]byte)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notify = fn
+	return nil
+}
+
+func (c *fakeCatConn) Disconnect() error { return nil }
+
+func (c *fakeCatConn) commands() []catCommand {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var cmds []catCommand
+	for _, pkt := range c.written {
+		cmds = append(cmds, catCommand(pkt[2]))
+	}
+	return cmds
+}
+
+func newTestCatPrinter(t *testing.T, conn *fakeCatConn) *CatPrinter {
+	t.Helper()
+	p, err := NewCatPrinter(context.Background(), nil, SearchParameters{Name: "GB01"},
+		WithDryRun(true), WithPrintInterval(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.options.dryrun = false
+	p.conn = conn
+	if err := conn.Notify(p.notificationCallback); err != nil {
+		t.Fatal(err)
+	}
+	p.connected.Store(true)
+	return p
+}
+
+func TestCatPrinter_PrintImage(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 384, 3))
+
+	t.Run("prints", func(t *testing.T) {
+		conn := &fakeCatConn{pauseAt: 5, paused: make(chan struct{}), resume: make(chan struct{})}
+		p := newTestCatPrinter(t, conn)
+		errc := make(chan error, 1)
+		go func() { errc <- p.PrintImage(context.Background(), img) }()
+		<-conn.paused
+		if got := len(conn.commands()); got != conn.pauseAt {
+			t.Errorf("sent %d packets while paused, want %d", got, conn.pauseAt)
+		}
+		close(conn.resume)
+		if err := <-errc; err != nil {
+			t.Fatal(err)
+		}
+		want := []catCommand{
+			catGetState, catQuality, catEnergy, catApplyEnergy, catLattice,
+			catPrintRow, catPrintRow, catPrintRow,
+			catFeed, catLattice, catGetState,
+		}
+		if got := conn.commands(); !slices.Equal(got, want) {
+			t.Errorf("commands = %x, want %x", got, want)
+		}
+	})
+	t.Run("no paper", func(t *testing.T) {
+		conn := &fakeCatConn{flags: catNoPaper}
+		p := newTestCatPrinter(t, conn)
+		if err := p.PrintImage(context.Background(), img); !errors.Is(err, ErrNoPaper) {
+			t.Fatalf("PrintImage error = %v, want %v", err, ErrNoPaper)
+		}
+		if got := len(conn.commands()); got != 1 {
+			t.Errorf("sent %d packets, want only the state request", got)
+		}
+		if !p.Snapshot().NoPaper {
+			t.Error("snapshot does not report no paper")
+		}
+		if alerts := p.Alerts(); len(alerts) != 1 || alerts[0].Code != AlertNoPaper {
+			t.Errorf("alerts = %v", alerts)
+		}
+	})
+	t.Run("cancelled while paused", func(t *testing.T) {
+		conn := &fakeCatConn{}
+		p := newTestCatPrinter(t, conn)
+		p.setPaused(true)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if err := p.PrintImage(ctx, img); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("PrintImage error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+}
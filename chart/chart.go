@@ -0,0 +1,458 @@
+// Package chart renders the small charts: sparklines, bar charts and line
+// charts, sized for the receipt paper, i.e. to print the sensor dashboards.
+// The charts are pure black and white, the series are told apart by the
+// fill patterns and the dashes instead of the colours.
+package chart
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Kind is the kind of the chart.
+type Kind string
+
+const (
+	// Sparkline is the line of the first series without the axes, the
+	// size of the text line.
+	Sparkline Kind = "sparkline"
+	// Bar is the bar chart, the series are side by side.
+	Bar Kind = "bar"
+	// Line is the line chart.
+	Line Kind = "line"
+)
+
+// Kinds returns the names of the chart kinds.
+func Kinds() []string {
+	return []string{string(Sparkline), string(Bar), string(Line)}
+}
+
+// ParseKind parses the name of the chart kind.
+func ParseKind(s string) (Kind, error) {
+	k := Kind(strings.ToLower(s))
+	switch k {
+	case Sparkline, Bar, Line:
+		return k, nil
+	}
+	return "", fmt.Errorf("unknown chart %q, must be one of: %s", s, strings.Join(Kinds(), ", "))
+}
+
+// Series is the named series of the values.
+type Series struct {
+	Name   string
+	Values []float64
+}
+
+// Data is the data of the chart: the series, and the optional labels of the
+// values.
+type Data struct {
+	Labels []string // labels of the values, i.e. the time, may be empty
+	Series []Series
+}
+
+// ParseCSV parses the CSV data.  The first row is the header with the names
+// of the series, if it has the text in the value columns.  The first column
+// is the labels, if it has the text, every other column is the series.  A
+// single row of the numbers is one series, i.e. "1,3,2,5" for the
+// sparkline.
+func ParseCSV(r io.Reader) (Data, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return Data{}, fmt.Errorf("chart data: %w", err)
+	}
+	if len(rows) == 0 {
+		return Data{}, errors.New("chart data: no rows")
+	}
+	var header []string
+	if !numeric(rows[0][min(1, len(rows[0])-1):]) {
+		header, rows = rows[0], rows[1:]
+	}
+	if len(rows) == 0 {
+		return Data{}, errors.New("chart data: no values")
+	}
+	if header == nil && len(rows) == 1 && numeric(rows[0]) {
+		// single row of the values.
+		vs, _ := parseFloats(rows[0])
+		return Data{Series: []Series{{Values: vs}}}, nil
+	}
+	labels := !numeric([]string{rows[0][0]})
+	first := 0
+	if labels {
+		first = 1
+	}
+	columns := len(rows[0]) - first
+	if columns < 1 {
+		return Data{}, errors.New("chart data: no value columns")
+	}
+	var d Data
+	d.Series = make([]Series, columns)
+	for i := range d.Series {
+		if header != nil && first+i < len(header) {
+			d.Series[i].Name = header[first+i]
+		}
+	}
+	for n, row := range rows {
+		if len(row) != first+columns {
+			return Data{}, fmt.Errorf("chart data: row %d has %d columns, want %d", n+1, len(row), first+columns)
+		}
+		if labels {
+			d.Labels = append(d.Labels, row[0])
+		}
+		vs, err := parseFloats(row[first:])
+		if err != nil {
+			return Data{}, fmt.Errorf("chart data: row %d: %w", n+1, err)
+		}
+		for i, v := range vs {
+			d.Series[i].Values = append(d.Series[i].Values, v)
+		}
+	}
+	return d, nil
+}
+
+// numeric reports whether all cells are numbers.
+func numeric(cells []string) bool {
+	_, err := parseFloats(cells)
+	return err == nil
+}
+
+func parseFloats(cells []string) ([]float64, error) {
+	vs := make([]float64, len(cells))
+	for i, c := range cells {
+		v, err := strconv.ParseFloat(strings.TrimSpace(c), 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("not a number: %q", c)
+		}
+		vs[i] = v
+	}
+	return vs, nil
+}
+
+// Render renders the chart of the kind of the given size.
+func Render(kind Kind, d Data, width, height int) (*image.Gray, error) {
+	if len(d.Series) == 0 || len(d.Series[0].Values) == 0 {
+		return nil, errors.New("chart: no values")
+	}
+	switch kind {
+	case Sparkline:
+		return RenderSparkline(d.Series[0].Values, width, height)
+	case Bar:
+		return RenderBar(d, width, height)
+	case Line:
+		return RenderLine(d, width, height)
+	}
+	return nil, fmt.Errorf("chart: unknown kind %q", kind)
+}
+
+// face is the face of the labels.
+var face = basicfont.Face7x13
+
+const (
+	// lineWidth is the width of the lines of the data.
+	lineWidth = 2
+	// tick is the length of the tick marks on the axes.
+	tick = 3
+	// gap is the gap between the labels and the plot.
+	gap = 2
+)
+
+// RenderSparkline renders the values as the line, from the minimum at the
+// bottom to the maximum at the top, with the dot at the last value.
+func RenderSparkline(values []float64, width, height int) (*image.Gray, error) {
+	if len(values) == 0 {
+		return nil, errors.New("chart: no values")
+	}
+	if width < 2*lineWidth || height < 2*lineWidth {
+		return nil, errors.New("chart: too small")
+	}
+	img := canvas(width, height)
+	lo, hi := bounds(values)
+	// room for the dot at the last value.
+	plot := image.Rect(lineWidth, lineWidth, width-2*lineWidth, height-lineWidth)
+	pts := points(values, lo, hi, plot)
+	polyline(img, pts, solid)
+	last := pts[len(pts)-1]
+	fillRect(img, image.Rect(last.X-lineWidth, last.Y-lineWidth, last.X+lineWidth+1, last.Y+lineWidth+1), solidFill)
+	return img, nil
+}
+
+// RenderLine renders the line chart with the axes, the minimum and the
+// maximum on the vertical axis, the first and the last labels on the
+// horizontal one, and the legend, if there are several named series.  The
+// series are drawn with the solid, the dashed and the dotted lines.
+func RenderLine(d Data, width, height int) (*image.Gray, error) {
+	img, plot, lo, hi, err := axes(d, width, height, false)
+	if err != nil {
+		return nil, err
+	}
+	for i, s := range d.Series {
+		polyline(img, points(s.Values, lo, hi, plot.Inset(lineWidth)), dashes[i%len(dashes)])
+	}
+	return img, nil
+}
+
+// RenderBar renders the bar chart with the axes, the bars of the series are
+// side by side, filled with the different patterns.  The baseline is zero.
+func RenderBar(d Data, width, height int) (*image.Gray, error) {
+	img, plot, lo, hi, err := axes(d, width, height, true)
+	if err != nil {
+		return nil, err
+	}
+	n := len(d.Series[0].Values)
+	group := float64(plot.Dx()) / float64(n)
+	bar := max(1, int(group*0.8)/len(d.Series))
+	zero := yOf(0, lo, hi, plot)
+	for j := range n {
+		x0 := plot.Min.X + int(float64(j)*group+group*0.1)
+		for i, s := range d.Series {
+			y := yOf(s.Values[j], lo, hi, plot)
+			r := image.Rect(x0+i*bar, min(y, zero), x0+(i+1)*bar-1, max(y, zero)+1)
+			fillRect(img, r, fills[i%len(fills)])
+			outline(img, r)
+		}
+	}
+	return img, nil
+}
+
+// axes draws the axes with the labels and the legend, and returns the
+// canvas, the plot rectangle, and the range of the values.  The range of
+// the bar chart includes zero.
+func axes(d Data, width, height int, zero bool) (img *image.Gray, plot image.Rectangle, lo, hi float64, err error) {
+	if len(d.Series) == 0 || len(d.Series[0].Values) == 0 {
+		return nil, plot, 0, 0, errors.New("chart: no values")
+	}
+	var all []float64
+	for _, s := range d.Series {
+		if len(s.Values) != len(d.Series[0].Values) {
+			return nil, plot, 0, 0, errors.New("chart: series have different lengths")
+		}
+		all = append(all, s.Values...)
+	}
+	lo, hi = bounds(all)
+	if zero {
+		lo, hi = min(lo, 0), max(hi, 0)
+		if lo == hi {
+			hi = 1
+		}
+	}
+	top, bottom := formatValue(hi), formatValue(lo)
+	left := max(textWidth(top), textWidth(bottom)) + gap + tick
+	lh := face.Height
+	legend := 0
+	if len(d.Series) > 1 && d.Series[0].Name != "" {
+		legend = lh + gap
+	}
+	labels := 0
+	if len(d.Labels) > 0 {
+		labels = lh + gap + tick
+	}
+	plot = image.Rect(left, legend+lh/2, width-textWidth(last(d.Labels))/2-1, height-labels-lh/2)
+	if plot.Dx() < 4*lineWidth || plot.Dy() < 4*lineWidth {
+		return nil, plot, 0, 0, errors.New("chart: too small")
+	}
+
+	img = canvas(width, height)
+	fillRect(img, image.Rect(plot.Min.X, plot.Min.Y, plot.Min.X+1, plot.Max.Y+1), solidFill)
+	fillRect(img, image.Rect(plot.Min.X, plot.Max.Y, plot.Max.X+1, plot.Max.Y+1), solidFill)
+	for _, v := range []struct {
+		s string
+		y int
+	}{{top, plot.Min.Y + lineWidth}, {bottom, plot.Max.Y - lineWidth}} {
+		fillRect(img, image.Rect(plot.Min.X-tick, v.y, plot.Min.X, v.y+1), solidFill)
+		drawText(img, v.s, left-tick-gap-textWidth(v.s), v.y+face.Ascent/2)
+	}
+	if len(d.Labels) > 0 {
+		labelAxis(img, d.Labels, plot, zero)
+	}
+	if zero && lo < 0 {
+		y := yOf(0, lo, hi, plot)
+		fillRect(img, image.Rect(plot.Min.X, y, plot.Max.X+1, y+1), solidFill)
+	}
+	if legend > 0 {
+		x := plot.Min.X
+		for i, s := range d.Series {
+			sample := image.Rect(x, 0, x+lh, lh-2)
+			if zero {
+				fillRect(img, sample, fills[i%len(fills)])
+				outline(img, sample)
+			} else {
+				polyline(img, []image.Point{{x, lh / 2}, {x + lh, lh / 2}}, dashes[i%len(dashes)])
+			}
+			drawText(img, s.Name, x+lh+gap, face.Ascent)
+			x += lh + 2*gap + textWidth(s.Name) + lh
+		}
+	}
+	return img, plot, lo, hi, nil
+}
+
+// labelAxis draws the labels under the horizontal axis.  The labels of the
+// bar chart are under the bars, all of them, if they fit, otherwise the
+// first and the last ones, as on the line chart.
+func labelAxis(img *image.Gray, labels []string, plot image.Rectangle, bars bool) {
+	xs := make([]int, len(labels))
+	fit := bars
+	group := float64(plot.Dx()) / float64(len(labels))
+	pts := points(make([]float64, len(labels)), 0, 1, plot.Inset(lineWidth))
+	for i, l := range labels {
+		if bars {
+			xs[i] = plot.Min.X + int((float64(i)+0.5)*group)
+			fit = fit && textWidth(l) < int(group)
+		} else {
+			xs[i] = pts[i].X
+		}
+	}
+	y := plot.Max.Y + tick + gap + face.Ascent
+	for i, l := range labels {
+		if !fit && i != 0 && i != len(labels)-1 {
+			continue
+		}
+		fillRect(img, image.Rect(xs[i], plot.Max.Y, xs[i]+1, plot.Max.Y+tick+1), solidFill)
+		x := min(max(0, xs[i]-textWidth(l)/2), img.Bounds().Dx()-textWidth(l))
+		drawText(img, l, x, y)
+	}
+}
+
+// bounds returns the minimum and the maximum of the values, that are not
+// equal.
+func bounds(values []float64) (lo, hi float64) {
+	lo, hi = values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	if lo == hi {
+		lo, hi = lo-1, hi+1
+	}
+	return lo, hi
+}
+
+// points returns the points of the values spread across the rectangle.
+func points(values []float64, lo, hi float64, r image.Rectangle) []image.Point {
+	pts := make([]image.Point, len(values))
+	for i, v := range values {
+		x := r.Min.X + r.Dx()/2
+		if len(values) > 1 {
+			x = r.Min.X + i*(r.Dx()-1)/(len(values)-1)
+		}
+		pts[i] = image.Pt(x, yOf(v, lo, hi, r))
+	}
+	return pts
+}
+
+// yOf returns the vertical position of the value in the rectangle.
+func yOf(v, lo, hi float64, r image.Rectangle) int {
+	return r.Max.Y - 1 - int(math.Round((v-lo)/(hi-lo)*float64(r.Dy()-1)))
+}
+
+// formatValue formats the value for the axis label.
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+func last(s []string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return s[len(s)-1]
+}
+
+// dash reports, whether the pixel at the distance along the line is drawn.
+type dash func(n int) bool
+
+var (
+	solid  dash = func(int) bool { return true }
+	dashes      = []dash{
+		solid,
+		func(n int) bool { return n%10 < 6 }, // dashed
+		func(n int) bool { return n%4 < 2 },  // dotted
+	}
+)
+
+// fill reports, whether the pixel of the area is black.
+type fill func(x, y int) bool
+
+var (
+	solidFill fill = func(x, y int) bool { return true }
+	fills          = []fill{
+		solidFill,
+		func(x, y int) bool { return (x+y)%4 == 0 },         // hatched
+		func(x, y int) bool { return x%3 == 0 && y%3 == 0 }, // dotted
+	}
+)
+
+func canvas(width, height int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	return img
+}
+
+func fillRect(img *image.Gray, r image.Rectangle, f fill) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if f(x, y) {
+				img.SetGray(x, y, color.Gray{})
+			}
+		}
+	}
+}
+
+func outline(img *image.Gray, r image.Rectangle) {
+	fillRect(img, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1), solidFill)
+	fillRect(img, image.Rect(r.Min.X, r.Max.Y-1, r.Max.X, r.Max.Y), solidFill)
+	fillRect(img, image.Rect(r.Min.X, r.Min.Y, r.Min.X+1, r.Max.Y), solidFill)
+	fillRect(img, image.Rect(r.Max.X-1, r.Min.Y, r.Max.X, r.Max.Y), solidFill)
+}
+
+// polyline draws the lines between the points, lineWidth pixels wide, with
+// the dash pattern.
+func polyline(img *image.Gray, pts []image.Point, d dash) {
+	n := 0 // distance along the line
+	for i := 1; i < len(pts); i++ {
+		a, b := pts[i-1], pts[i]
+		steps := max(abs(b.X-a.X), abs(b.Y-a.Y), 1)
+		for s := range steps {
+			if d(n) {
+				x := a.X + (b.X-a.X)*s/steps
+				y := a.Y + (b.Y-a.Y)*s/steps
+				fillRect(img, image.Rect(x, y, x+lineWidth, y+lineWidth), solidFill)
+			}
+			n++
+		}
+	}
+	if len(pts) == 1 {
+		p := pts[0]
+		fillRect(img, image.Rect(p.X, p.Y, p.X+lineWidth, p.Y+lineWidth), solidFill)
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func textWidth(s string) int {
+	return font.MeasureString(face, s).Ceil()
+}
+
+// drawText draws the text with the baseline at y.
+func drawText(img *image.Gray, s string, x, y int) {
+	d := font.Drawer{Dst: img, Src: image.Black, Face: face, Dot: fixed.P(x, y)}
+	d.DrawString(s)
+}
@@ -0,0 +1,158 @@
+package chart
+
+import (
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    Data
+		wantErr bool
+	}{
+		{
+			name: "single row",
+			csv:  "1, 3,2.5",
+			want: Data{Series: []Series{{Values: []float64{1, 3, 2.5}}}},
+		},
+		{
+			name: "column",
+			csv:  "1\n3\n2\n",
+			want: Data{Series: []Series{{Values: []float64{1, 3, 2}}}},
+		},
+		{
+			name: "header and labels",
+			csv:  "time,temp,rh\n06:00,12,80\n09:00,16,70\n",
+			want: Data{
+				Labels: []string{"06:00", "09:00"},
+				Series: []Series{
+					{Name: "temp", Values: []float64{12, 16}},
+					{Name: "rh", Values: []float64{80, 70}},
+				},
+			},
+		},
+		{
+			name: "labels without header",
+			csv:  "Mon,4\nTue,-1\n",
+			want: Data{
+				Labels: []string{"Mon", "Tue"},
+				Series: []Series{{Values: []float64{4, -1}}},
+			},
+		},
+		{
+			name:    "empty",
+			csv:     "",
+			wantErr: true,
+		},
+		{
+			name:    "header only",
+			csv:     "a,b\n",
+			wantErr: true,
+		},
+		{
+			name:    "not a number",
+			csv:     "Mon,4\nTue,x\n",
+			wantErr: true,
+		},
+		{
+			name:    "ragged",
+			csv:     "Mon,4,5\nTue,1\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCSV(strings.NewReader(tt.csv))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCSV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCSV() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	for _, k := range Kinds() {
+		if _, err := ParseKind(strings.ToUpper(k)); err != nil {
+			t.Errorf("ParseKind(%q) error = %v", k, err)
+		}
+	}
+	if _, err := ParseKind("pie"); err == nil {
+		t.Error("ParseKind(pie) error = nil, want error")
+	}
+}
+
+func TestRender(t *testing.T) {
+	data := Data{
+		Labels: []string{"Mon", "Tue", "Wed"},
+		Series: []Series{
+			{Name: "a", Values: []float64{1, 5, 3}},
+			{Name: "b", Values: []float64{2, 2, 4}},
+		},
+	}
+	for _, k := range Kinds() {
+		t.Run(k, func(t *testing.T) {
+			img, err := Render(Kind(k), data, 384, 120)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := img.Bounds(); got != image.Rect(0, 0, 384, 120) {
+				t.Errorf("bounds = %v, want 384x120", got)
+			}
+			if !hasInk(img, img.Bounds()) {
+				t.Error("chart is blank")
+			}
+		})
+	}
+}
+
+func TestRenderSparkline(t *testing.T) {
+	img, err := RenderSparkline([]float64{0, 10}, 100, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the line goes from the bottom left to the top right.
+	if !hasInk(img, image.Rect(0, 15, 10, 20)) {
+		t.Error("no ink at the bottom left")
+	}
+	if !hasInk(img, image.Rect(90, 0, 100, 5)) {
+		t.Error("no ink at the top right")
+	}
+	if hasInk(img, image.Rect(0, 0, 10, 5)) {
+		t.Error("ink at the top left")
+	}
+}
+
+func TestRenderBar(t *testing.T) {
+	d := Data{Series: []Series{{Values: []float64{1, 2}}, {Values: []float64{3}}}}
+	if _, err := RenderBar(d, 384, 100); err == nil {
+		t.Error("series of different lengths: error = nil, want error")
+	}
+	if _, err := RenderBar(Data{Series: []Series{{Values: []float64{1}}}}, 10, 10); err == nil {
+		t.Error("too small: error = nil, want error")
+	}
+}
+
+func TestRenderEmpty(t *testing.T) {
+	if _, err := Render(Line, Data{}, 384, 100); err == nil {
+		t.Error("error = nil, want error")
+	}
+}
+
+// hasInk reports whether there is a black pixel in the rectangle.
+func hasInk(img *image.Gray, r image.Rectangle) bool {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if img.GrayAt(x, y).Y < 0x80 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -2,12 +2,17 @@ package bootstrap
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/rusq/thermoprint"
 	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
 	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+	"github.com/rusq/thermoprint/printers"
 )
 
 var enableAdapter = func() error {
@@ -15,28 +20,156 @@ var enableAdapter = func() error {
 }
 
 // Printer returns connected printer.
-func Printer(ctx context.Context) (*thermoprint.LXD02, error) {
-	if !cfg.DryRun {
+func Printer(ctx context.Context) (thermoprint.Printer, error) {
+	if cfg.Port != "" && cfg.Addr != "" {
+		base.SetExitStatus(base.SInvalidParameters)
+		return nil, errors.New("-port and -tcp are mutually exclusive")
+	}
+	if !cfg.DryRun && usesBluetooth() && cfg.Backend == thermoprint.BackendTinyGo {
 		if err := enableAdapter(); err != nil {
 			return nil, fmt.Errorf("failed to enable Bluetooth adapter: %w", err)
 		}
 	}
-	prn, err := thermoprint.NewLXD02(ctx, cfg.Adapter(), cfg.SearchParams,
-		thermoprint.WithEnergy(uint8(cfg.Energy)),
+	post, err := cfg.PostProcessors()
+	if err != nil {
+		base.SetExitStatus(base.SInvalidParameters)
+		return nil, err
+	}
+	if cfg.Transfer {
+		slog.InfoContext(ctx, "transfer mode: the printout is mirrored, place the transfer face down on the fabric", "energy", cfg.PrintEnergy())
+	}
+	opts := []thermoprint.Option{
+		thermoprint.WithEnergy(uint8(cfg.PrintEnergy())),
 		thermoprint.WithPrintInterval(cfg.PrintDelay),
 		thermoprint.WithCrop(cfg.Crop),
 		thermoprint.WithDither(cfg.Dither),
 		thermoprint.WithDryRun(cfg.DryRun),
 		thermoprint.WithGamma(cfg.Gamma),
 		thermoprint.WithAutoDither(cfg.AutoDither),
-	)
+		thermoprint.WithChannel(cfg.Channel),
+		thermoprint.WithPostProcessors(post...),
+		thermoprint.WithBackend(cfg.Backend),
+		thermoprint.WithResponseTimeout(cfg.ResponseTimeout),
+		thermoprint.WithConnectRetries(cfg.ConnectRetries, 0),
+		thermoprint.WithReconnects(cfg.Reconnects),
+	}
+	var serial thermoprint.Transport
+	if cfg.Port != "" && !cfg.DryRun {
+		if serial, err = thermoprint.OpenSerial(cfg.Port); err != nil {
+			return nil, err
+		}
+		opts = append(opts, thermoprint.WithTransport(serial))
+	}
+	if cfg.Addr != "" {
+		opts = append(opts, printers.WithTCPTransport(cfg.Addr))
+	}
+	if rc := rollCounter(ctx); rc != nil {
+		opts = append(opts, thermoprint.WithRollCounter(rc))
+	}
+	if showProgress() {
+		opts = append(opts, thermoprint.WithProgress(new(progressBar).update))
+	}
+	sp := searchParams(ctx)
+	prn, err := printers.New(ctx, cfg.Model, cfg.Adapter(), sp, opts...)
 	if err != nil {
+		if serial != nil {
+			serial.Disconnect()
+		}
+		if errors.Is(err, printers.ErrUnknownModel) {
+			base.SetExitStatus(base.SInvalidParameters)
+		}
 		return nil, fmt.Errorf("failed to create printer: %w", err)
 	}
+	if usesBluetooth() {
+		rememberDevice(ctx, sp.Name, prn.Address())
+	}
 	base.AtExit(func() {
+		if cfg.Verbose {
+			dumpUnknownNotifications(ctx, prn)
+		}
 		if err := prn.Disconnect(); err != nil {
 			slog.ErrorContext(ctx, "error disconnecting from printer", "error", err)
 		}
 	})
 	return prn, nil
 }
+
+// usesBluetooth returns true if the printer is connected over Bluetooth.
+func usesBluetooth() bool {
+	return cfg.Port == "" && cfg.Addr == "" && !strings.EqualFold(cfg.Model, printers.ModelMock)
+}
+
+// dumpUnknownNotifications logs the notifications that the printer driver
+// did not recognise, so that they can be attached to the bug report.
+func dumpUnknownNotifications(ctx context.Context, prn thermoprint.Printer) {
+	c, ok := prn.(thermoprint.NotificationCapturer)
+	if !ok {
+		return
+	}
+	for _, n := range c.UnknownNotifications() {
+		slog.DebugContext(ctx, "unknown notification", "time", n.Time, "data", fmt.Sprintf("% x", n.Data))
+	}
+}
+
+// searchParams returns the search parameters for the printer.  Unless the
+// address is given, the address of the last connected printer with the same
+// name is used, so that the printer is found by the identifier that the
+// platform provides, even if it does not advertise its name.  The printer
+// chosen with tp scan -save is used, unless another name is given with -p.
+func searchParams(ctx context.Context) thermoprint.SearchParameters {
+	sp := cfg.SearchParams
+	if sp.MACAddress != "" {
+		return sp
+	}
+	kd, err := cfg.LoadKnownDevice()
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.WarnContext(ctx, "ignoring last connected printer", "error", err)
+		}
+		return sp
+	}
+	if kd.Chosen && sp.Name == cfg.DefaultPrinterName && kd.Address != "" {
+		slog.DebugContext(ctx, "using the chosen printer", "name", kd.Name, "address", kd.Address)
+		sp.Name = kd.Name
+		sp.MACAddress = kd.Address
+		return sp
+	}
+	if kd.Name == sp.Name && kd.Address != "" {
+		slog.DebugContext(ctx, "using the address of the last connected printer", "name", kd.Name, "address", kd.Address)
+		sp.MACAddress = kd.Address
+	}
+	return sp
+}
+
+// rememberDevice saves the address of the connected printer.  The printer
+// stays chosen, if it was.  Errors are not fatal.
+func rememberDevice(ctx context.Context, name, address string) {
+	if address == "" {
+		return // dry run
+	}
+	kd := cfg.KnownDevice{Name: name, Address: address, LastSeen: time.Now()}
+	if prev, err := cfg.LoadKnownDevice(); err == nil && prev.Chosen && strings.EqualFold(prev.Address, address) {
+		kd.Chosen = true
+	}
+	if err := cfg.SaveKnownDevice(kd); err != nil {
+		slog.WarnContext(ctx, "failed to save the printer address", "error", err)
+	}
+}
+
+// rollCounter returns the paper roll counter, or nil, if the roll is not
+// tracked.  Errors are not fatal, as the counter is only an estimate.
+func rollCounter(ctx context.Context) *thermoprint.RollCounter {
+	filename, err := cfg.RollFilename()
+	if err != nil {
+		slog.WarnContext(ctx, "paper roll tracking disabled", "error", err)
+		return nil
+	}
+	rc, err := thermoprint.LoadRollCounter(filename)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.WarnContext(ctx, "paper roll tracking disabled", "error", err)
+		}
+		return nil
+	}
+	return rc
+}
@@ -3,8 +3,11 @@ package bootstrap
 import (
 	"context"
 	"errors"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/rusq/thermoprint"
 	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
 )
 
@@ -43,6 +46,93 @@ func TestPrinterNonDryRunEnablesAdapter(t *testing.T) {
 	}
 }
 
+func TestSearchParamsUsesKnownDevice(t *testing.T) {
+	t.Cleanup(setDeviceFile(t, filepath.Join(t.TempDir(), "device.json")))
+	t.Cleanup(setSearchParams(thermoprint.SearchParameters{Name: "LX-D02"}))
+
+	if got := searchParams(context.Background()); got.MACAddress != "" {
+		t.Fatalf("MACAddress = %q without known device, want empty", got.MACAddress)
+	}
+
+	rememberDevice(context.Background(), "LX-D02", "3F2504E0-4F89-11D3-9A0C-0305E82C3301")
+	if got := searchParams(context.Background()); got.MACAddress != "3F2504E0-4F89-11D3-9A0C-0305E82C3301" {
+		t.Fatalf("MACAddress = %q, want the known device address", got.MACAddress)
+	}
+
+	// explicit address takes precedence
+	cfg.SearchParams.MACAddress = "AA:BB:CC:DD:EE:FF"
+	if got := searchParams(context.Background()); got.MACAddress != "AA:BB:CC:DD:EE:FF" {
+		t.Fatalf("MACAddress = %q, want the explicit address", got.MACAddress)
+	}
+
+	// known device with another name is ignored
+	cfg.SearchParams = thermoprint.SearchParameters{Name: "LX-D03"}
+	if got := searchParams(context.Background()); got.MACAddress != "" {
+		t.Fatalf("MACAddress = %q for another printer, want empty", got.MACAddress)
+	}
+}
+
+func TestSearchParamsUsesChosenDevice(t *testing.T) {
+	t.Cleanup(setDeviceFile(t, filepath.Join(t.TempDir(), "device.json")))
+	t.Cleanup(setSearchParams(thermoprint.SearchParameters{Name: cfg.DefaultPrinterName}))
+
+	chosen := cfg.KnownDevice{Name: "MX10", Address: "AA:BB:CC:DD:EE:FF", Chosen: true}
+	if err := cfg.SaveKnownDevice(chosen); err != nil {
+		t.Fatal(err)
+	}
+	if got := searchParams(context.Background()); got.Name != "MX10" || got.MACAddress != "AA:BB:CC:DD:EE:FF" {
+		t.Fatalf("search parameters = %+v, want the chosen printer", got)
+	}
+
+	// printer name given with -p takes precedence
+	cfg.SearchParams = thermoprint.SearchParameters{Name: "M02"}
+	if got := searchParams(context.Background()); got.Name != "M02" || got.MACAddress != "" {
+		t.Fatalf("search parameters = %+v, want the given name", got)
+	}
+
+	// connecting to the chosen printer keeps it chosen
+	rememberDevice(context.Background(), "MX10", "aa:bb:cc:dd:ee:ff")
+	if kd, err := cfg.LoadKnownDevice(); err != nil || !kd.Chosen {
+		t.Fatalf("known device = %+v, %v, want chosen", kd, err)
+	}
+	rememberDevice(context.Background(), "M02", "11:22:33:44:55:66")
+	if kd, err := cfg.LoadKnownDevice(); err != nil || kd.Chosen {
+		t.Fatalf("known device = %+v, %v, want not chosen", kd, err)
+	}
+}
+
+func TestRememberDevice(t *testing.T) {
+	t.Cleanup(setDeviceFile(t, filepath.Join(t.TempDir(), "device.json")))
+
+	before := time.Now()
+	rememberDevice(context.Background(), "LX-D02", "AA:BB:CC:DD:EE:FF")
+	kd, err := cfg.LoadKnownDevice()
+	if err != nil {
+		t.Fatalf("LoadKnownDevice() error = %v", err)
+	}
+	if kd.Name != "LX-D02" || kd.Address != "AA:BB:CC:DD:EE:FF" || kd.LastSeen.Before(before) {
+		t.Fatalf("known device = %+v", kd)
+	}
+}
+
+func setDeviceFile(t *testing.T, filename string) func() {
+	t.Helper()
+
+	original := cfg.DeviceFile
+	cfg.DeviceFile = filename
+	return func() {
+		cfg.DeviceFile = original
+	}
+}
+
+func setSearchParams(sp thermoprint.SearchParameters) func() {
+	original := cfg.SearchParams
+	cfg.SearchParams = sp
+	return func() {
+		cfg.SearchParams = original
+	}
+}
+
 func setDryRun(t *testing.T, dryRun bool) func() {
 	t.Helper()
 
@@ -0,0 +1,43 @@
+package bootstrap
+
+import (
+	"os"
+	"sync"
+
+	"github.com/pterm/pterm"
+	"golang.org/x/term"
+
+	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
+)
+
+// showProgress returns true if the print progress bar should be shown: the
+// standard error is a terminal, and it is not used for the JSON log.
+func showProgress() bool {
+	return !cfg.DryRun && !cfg.JSONHandler && cfg.LogFile == "" && term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// progressBar shows the print progress on the standard error.  A new bar is
+// started for every print job.
+type progressBar struct {
+	mu  sync.Mutex
+	bar *pterm.ProgressbarPrinter
+}
+
+// update is the [thermoprint.WithProgress] callback.
+func (p *progressBar) update(sent, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.bar == nil || p.bar.Total != total || !p.bar.IsActive {
+		bar, err := pterm.DefaultProgressbar.
+			WithTotal(total).
+			WithTitle("Printing").
+			WithWriter(os.Stderr).
+			WithRemoveWhenDone(true).
+			Start()
+		if err != nil {
+			return
+		}
+		p.bar = bar
+	}
+	p.bar.Add(sent - p.bar.Current) // goes back on retransmit
+}
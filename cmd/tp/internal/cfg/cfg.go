@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pterm/pterm"
@@ -14,25 +16,50 @@ import (
 
 	"github.com/rusq/thermoprint"
 	"github.com/rusq/thermoprint/bitmap"
+	"github.com/rusq/thermoprint/locale"
+	"github.com/rusq/thermoprint/printers"
 )
 
 var adapter = bluetooth.DefaultAdapter
 
+// DefaultPrinterName is the name of the printer that tp looks for, unless
+// another name is given with -p.
+const DefaultPrinterName = "LX-D02"
+
 var (
 	TraceFile   string = os.Getenv("TRACE_FILE")
 	LogFile     string = os.Getenv("LOG_FILE")
 	JSONHandler bool   = os.Getenv("JSON_LOG") != ""
 	Verbose     bool   = os.Getenv("DEBUG") != ""
+	RollFile    string = os.Getenv("ROLL_FILE")
+	DeviceFile  string = os.Getenv("DEVICE_FILE")
+	ConfigFile  string = os.Getenv("CONFIG_FILE")
+	ProfileName string = os.Getenv("PRINTER_PROFILE")
+	ImageCache  string = os.Getenv("IMAGE_CACHE_DIR")
+	Backend     string = envOr("BLE_BACKEND", thermoprint.BackendTinyGo)
+	Model       string = envOr("PRINTER_MODEL", printers.ModelAuto)
+	Port        string = os.Getenv("PRINTER_PORT")
+	Addr        string = os.Getenv("PRINTER_ADDR")
 
 	SearchParams thermoprint.SearchParameters
 	Energy       uint
 	PrintDelay   time.Duration
 	DryRun       bool = os.Getenv("DRY_RUN") == "1"
 
+	ResponseTimeout time.Duration
+	ConnectRetries  int
+	Reconnects      int
+
 	Gamma      float64
 	Crop       bool
 	Dither     string
 	AutoDither bool
+	Channel    bitmap.Channel
+	Mirror     bool
+	Post       string
+	Transfer   bool
+
+	Lang string
 
 	Log *slog.Logger = slog.Default()
 )
@@ -76,21 +103,143 @@ func SetBaseFlags(fs *flag.FlagSet, mask FlagMask) {
 	fs.StringVar(&LogFile, "log", LogFile, "log `file`, if not specified, messages are printed to STDERR")
 	fs.BoolVar(&JSONHandler, "log-json", JSONHandler, "log in JSON format")
 	fs.BoolVar(&Verbose, "v", Verbose, "verbose messages")
+	fs.StringVar(&Lang, "lang", locale.English, fmt.Sprintf("`language` of the printed page headers, dates, receipt labels and alerts, one of: %s", strings.Join(locale.Languages(), ", ")))
 
 	if mask&OmitConnectFlags == 0 {
-		fs.StringVar(&SearchParams.Name, "p", "LX-D02", "Printer name to use")
-		fs.StringVar(&SearchParams.MACAddress, "mac", "", "MAC address of the printer")
+		fs.StringVar(&SearchParams.Name, "p", DefaultPrinterName, "Printer name to use")
+		fs.StringVar(&SearchParams.MACAddress, "mac", "", "MAC address of the printer, or UUID on macOS")
+		fs.StringVar(&Port, "port", Port, "serial `port` of the printer, i.e. /dev/ttyUSB0 or COM3, to print over USB instead of Bluetooth")
+		fs.StringVar(&Addr, "tcp", Addr, "network `address` of the printer, i.e. 192.168.1.50:9100, to print over raw TCP instead of Bluetooth")
+		fs.StringVar(&Model, "model", Model, fmt.Sprintf("printer `model`, one of: %s, %s; %[1]s selects the model by the printer name", printers.ModelAuto, strings.Join(printers.Models(), ", ")))
 		fs.UintVar(&Energy, "e", 2, "Thermal energy `level` (0-6), higher is darker printout")
 		fs.DurationVar(&PrintDelay, "d", thermoprint.DefaultPrintDelay, "Delay between print commands")
 		fs.BoolVar(&DryRun, "dry", DryRun, "dry run, do not print, but create preview files")
+		fs.DurationVar(&ResponseTimeout, "response-timeout", 3*time.Second, "time to wait for the printer to respond, increase for slow Bluetooth stacks")
+		fs.IntVar(&ConnectRetries, "connect-retries", 5, "number of `attempts` to connect to the printer")
+		fs.IntVar(&Reconnects, "reconnects", 3, "`number` of times to reconnect, if the Bluetooth link drops during the print, 0 disables")
+		fs.StringVar(&Backend, "ble", Backend, fmt.Sprintf("Bluetooth `backend`, one of: %s, %s (Linux only)", thermoprint.BackendTinyGo, thermoprint.BackendBlueZ))
 	}
 
 	if mask&OmitCommonImageFlags == 0 {
 		fs.Float64Var(&Gamma, "gamma", bitmap.DefaultGamma, "Gamma correction for dithering")
 		fs.BoolVar(&Crop, "crop", false, "Crop image to printer width instead of resizing")
-		fs.StringVar(&Dither, "dither", "", fmt.Sprintf("Dithering algorithm to use, one of: %v", bitmap.AllDitherFunctions()))
-		fs.BoolVar(&AutoDither, "auto-dither", false, "automatically disables dithering if a document is detected")
+		fs.Func("dither", fmt.Sprintf("Dithering `algorithm` to use, one of: %s (default %s), with the parameters, i.e. bayer:4x4:0.8 or threshold:160", strings.Join(bitmap.AllDitherFunctions(), ", "), bitmap.DefaultDither), setDither)
+		fs.BoolVar(&AutoDither, "auto-dither", false, "dither only the photo-like regions of the page, the text-like ones are printed without dithering")
+		fs.Func("channel", "colour `channel` the images are dithered from: red, green, blue, luminance, or the weights R,G,B, i.e. red hides the red stamps", setChannel)
+		fs.BoolVar(&Mirror, "mirror", false, "mirror the printout left to right, i.e. for the iron-on transfer paper")
+		fs.BoolVar(&Transfer, "transfer", false, "iron-on transfer mode: mirror the printout and raise the thermal energy")
+		fs.StringVar(&Post, "post", "", fmt.Sprintf("comma separated `list` of post-processors, applied after dithering, any of: %s", strings.Join(thermoprint.PostProcessorNames(), ", ")))
+	}
+}
+
+const (
+	// transferBoost is the thermal energy added in the transfer mode, the
+	// transfer media needs more heat to release the ink.
+	transferBoost = 2
+	// maxTransferEnergy caps the energy in the transfer mode, the highest
+	// level is left for the explicit -e, as it wears out the print head.
+	maxTransferEnergy = 5
+)
+
+// setDither sets the dither function name, -dither, it must name the
+// function registered in the bitmap package, with the valid parameters.
+func setDither(s string) error {
+	if _, err := bitmap.ParseDither(s); err != nil {
+		return fmt.Errorf("%w, one of: %s", err, strings.Join(bitmap.AllDitherFunctions(), ", "))
+	}
+	Dither = bitmap.DitherName(s)
+	return nil
+}
+
+// setChannel sets the colour channel, -channel.
+func setChannel(s string) (err error) {
+	Channel, err = bitmap.ParseChannel(s)
+	return err
+}
+
+// PrintEnergy returns the thermal energy level, -e, raised in the transfer
+// mode, see -transfer, but not above the safe level, unless -e is higher.
+func PrintEnergy() uint {
+	if !Transfer {
+		return Energy
+	}
+	return max(Energy, min(Energy+transferBoost, maxTransferEnergy))
+}
+
+// PostProcessors returns the chain of the post-processors, selected with
+// -post, followed by the mirror, if -mirror or -transfer is set.
+func PostProcessors() ([]thermoprint.PostProcessor, error) {
+	chain, err := thermoprint.ParsePostProcessors(Post)
+	if err != nil {
+		return nil, err
+	}
+	if Mirror || Transfer {
+		chain = append(chain, thermoprint.Mirror)
+	}
+	return chain, nil
+}
+
+// RollFilename returns the name of the file that holds the paper roll
+// counter.  Unless overridden with ROLL_FILE environment variable, the file
+// resides in the user configuration directory.
+func RollFilename() (string, error) {
+	return configFilename(RollFile, "roll.json")
+}
+
+// DeviceFilename returns the name of the file that holds the last connected
+// printer.  Unless overridden with DEVICE_FILE environment variable, the
+// file resides in the user configuration directory.
+func DeviceFilename() (string, error) {
+	return configFilename(DeviceFile, "device.json")
+}
+
+// TLSFilenames returns the names of the certificate and the key files of
+// tp server -tls.  Unless overridden, the files reside in the user
+// configuration directory.
+func TLSFilenames(cert, key string) (string, string, error) {
+	cert, err := configFilename(cert, "ipps.crt")
+	if err != nil {
+		return "", "", err
+	}
+	key, err = configFilename(key, "ipps.key")
+	if err != nil {
+		return "", "", err
+	}
+	return cert, key, nil
+}
+
+// ImageCacheDirname returns the directory of the remote images cache.
+// Unless overridden with IMAGE_CACHE_DIR environment variable, the directory
+// resides in the user cache directory.
+func ImageCacheDirname() (string, error) {
+	if ImageCache != "" {
+		return ImageCache, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to determine cache directory: %w", err)
+	}
+	return filepath.Join(dir, "thermoprint", "images"), nil
+}
+
+// configFilename returns override, if it is not empty, or the name of the
+// file in the thermoprint directory in the user configuration directory.
+func configFilename(override, name string) (string, error) {
+	if override != "" {
+		return override, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to determine configuration directory: %w", err)
+	}
+	return filepath.Join(dir, "thermoprint", name), nil
+}
+
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
 	}
+	return def
 }
 
 func Adapter() *bluetooth.Adapter {
@@ -0,0 +1,75 @@
+package cfg
+
+import "testing"
+
+func TestPrintEnergy(t *testing.T) {
+	tests := []struct {
+		name     string
+		energy   uint
+		transfer bool
+		want     uint
+	}{
+		{"normal", 2, false, 2},
+		{"transfer", 2, true, 4},
+		{"transfer capped", 4, true, 5},
+		{"transfer explicit maximum", 6, true, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			energy, transfer := Energy, Transfer
+			t.Cleanup(func() { Energy, Transfer = energy, transfer })
+			Energy, Transfer = tt.energy, tt.transfer
+
+			if got := PrintEnergy(); got != tt.want {
+				t.Errorf("PrintEnergy() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostProcessors(t *testing.T) {
+	tests := []struct {
+		name     string
+		post     string
+		mirror   bool
+		transfer bool
+		want     int
+		wantErr  bool
+	}{
+		{name: "none", want: 0},
+		{name: "mirror", mirror: true, want: 1},
+		{name: "transfer", transfer: true, want: 1},
+		{name: "post and mirror", post: "quiet-zone", mirror: true, want: 2},
+		{name: "unknown", post: "sepia", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post, mirror, transfer := Post, Mirror, Transfer
+			t.Cleanup(func() { Post, Mirror, Transfer = post, mirror, transfer })
+			Post, Mirror, Transfer = tt.post, tt.mirror, tt.transfer
+
+			got, err := PostProcessors()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PostProcessors() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("PostProcessors() = %d post-processors, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestSetDither(t *testing.T) {
+	dither := Dither
+	t.Cleanup(func() { Dither = dither })
+
+	if err := setDither("Atkinson"); err != nil {
+		t.Fatalf("setDither: %v", err)
+	}
+	if Dither != "atkinson" {
+		t.Errorf("Dither = %q, want the canonical name", Dither)
+	}
+	if err := setDither("no-such-dither"); err == nil {
+		t.Error("setDither of the unknown function succeeded")
+	}
+}
@@ -0,0 +1,197 @@
+package cfg
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the configuration file, that holds the defaults for the flags,
+// so that they don't have to be repeated on every invocation.  The flags
+// given on the command line override the values from the file.
+type Config struct {
+	Profile  `yaml:",inline"`
+	Server   ServerConfig       `yaml:"server"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Profile holds the defaults for the printer flags.  The values of the
+// named profile, selected with tp -profile, override the values at the top
+// level of the file.
+type Profile struct {
+	Printer    string   `yaml:"printer"`     // -p
+	MAC        string   `yaml:"mac"`         // -mac
+	Model      string   `yaml:"model"`       // -model
+	Backend    string   `yaml:"ble"`         // -ble
+	Energy     *uint    `yaml:"energy"`      // -e
+	Dither     string   `yaml:"dither"`      // -dither
+	Gamma      *float64 `yaml:"gamma"`       // -gamma
+	AutoDither *bool    `yaml:"auto_dither"` // -auto-dither
+	Channel    string   `yaml:"channel"`     // -channel
+	Font       string   `yaml:"font"`        // -font
+	FontSize   *float64 `yaml:"font_size"`   // -font-size
+	JobFooter  *bool    `yaml:"job_footer"`  // -job-footer, tp server
+	Lang       string   `yaml:"lang"`        // -lang
+}
+
+// ServerConfig holds the defaults for the flags of tp server.
+type ServerConfig struct {
+	Addr           string   `yaml:"addr"`            // -addr
+	Fit            string   `yaml:"fit"`             // -fit
+	BlankThreshold *float64 `yaml:"blank_threshold"` // -blank-threshold
+	NoMDNS         *bool    `yaml:"no_mdns"`         // -no-mdns
+	PDFText        *bool    `yaml:"pdf_text"`        // -pdf-text
+	DedupWindow    string   `yaml:"dedup_window"`    // -dedup-window
+	Dedup          string   `yaml:"dedup"`           // -dedup
+	JobTTL         string   `yaml:"job_ttl"`         // -job-ttl
+	SpoolDir       string   `yaml:"spool_dir"`       // -spool
+	SpoolNaming    string   `yaml:"spool_naming"`    // -spool-naming
+	MaxQueue       *int     `yaml:"max_queue"`       // -max-queue
+	TLS            *bool    `yaml:"tls"`             // -tls
+	TLSCert        string   `yaml:"tls_cert"`        // -tls-cert
+	TLSKey         string   `yaml:"tls_key"`         // -tls-key
+	Htpasswd       string   `yaml:"htpasswd"`        // -htpasswd
+	Auth           string   `yaml:"auth"`            // -auth
+	DrainTimeout   string   `yaml:"drain_timeout"`   // -drain-timeout
+	RequirePrinter *bool    `yaml:"require_printer"` // -require-printer
+	HealthTTL      string   `yaml:"health_ttl"`      // -health-ttl
+
+	// Quality are the print-quality profiles, -quality, by the name of the
+	// quality, i.e. draft: energy=1,dither=threshold.
+	Quality map[string]string `yaml:"quality"`
+}
+
+// ConfigFilename returns the name of the configuration file.  Unless
+// overridden with CONFIG_FILE environment variable, the file resides in the
+// user configuration directory.
+func ConfigFilename() (string, error) {
+	return configFilename(ConfigFile, "config.yaml")
+}
+
+// LoadConfig loads the configuration file.  If there is none, the returned
+// error wraps [os.ErrNotExist].
+func LoadConfig() (Config, error) {
+	filename, err := ConfigFilename()
+	if err != nil {
+		return Config{}, err
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return Config{}, fmt.Errorf("load configuration: %w", err)
+	}
+	defer f.Close()
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	var c Config
+	if err := dec.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("decode configuration %s: %w", filename, err)
+	}
+	return c, nil
+}
+
+// ApplyConfig sets the flags of the command to the values from the
+// configuration file and the profile, if [ProfileName] is set, the flags
+// that the command doesn't have are ignored.  It must be called before the
+// flags are parsed, so that the command line takes precedence.  A missing
+// file is not an error, unless the profile is requested.
+func ApplyConfig(fs *flag.FlagSet, cmdName string) error {
+	c, err := LoadConfig()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && ProfileName == "" {
+			return nil
+		}
+		return err
+	}
+	values, err := c.flagValues(cmdName, ProfileName)
+	if err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		if fs.Lookup(name) == nil {
+			continue
+		}
+		if err := fs.Set(name, values[name]); err != nil {
+			return fmt.Errorf("configuration: invalid value %q for -%s: %w", values[name], name, err)
+		}
+	}
+	return nil
+}
+
+// flagValues returns the values of the flags, that are set in the
+// configuration, for the command and the profile.
+func (c Config) flagValues(cmdName, profile string) (map[string]string, error) {
+	v := make(map[string]string)
+	c.Profile.setFlags(v)
+	if profile != "" {
+		p, ok := c.Profiles[profile]
+		if !ok {
+			return nil, fmt.Errorf("configuration: unknown profile %q", profile)
+		}
+		p.setFlags(v)
+	}
+	if cmdName == "server" {
+		setString(v, "addr", c.Server.Addr)
+		setString(v, "fit", c.Server.Fit)
+		setValue(v, "blank-threshold", c.Server.BlankThreshold)
+		setValue(v, "no-mdns", c.Server.NoMDNS)
+		setValue(v, "pdf-text", c.Server.PDFText)
+		setString(v, "dedup-window", c.Server.DedupWindow)
+		setString(v, "dedup", c.Server.Dedup)
+		setString(v, "job-ttl", c.Server.JobTTL)
+		setString(v, "drain-timeout", c.Server.DrainTimeout)
+		setValue(v, "require-printer", c.Server.RequirePrinter)
+		setString(v, "health-ttl", c.Server.HealthTTL)
+		setString(v, "spool", c.Server.SpoolDir)
+		setString(v, "spool-naming", c.Server.SpoolNaming)
+		setValue(v, "max-queue", c.Server.MaxQueue)
+		setValue(v, "tls", c.Server.TLS)
+		setString(v, "tls-cert", c.Server.TLSCert)
+		setString(v, "tls-key", c.Server.TLSKey)
+		setString(v, "htpasswd", c.Server.Htpasswd)
+		setString(v, "auth", c.Server.Auth)
+		if len(c.Server.Quality) > 0 {
+			var specs []string
+			for _, q := range slices.Sorted(maps.Keys(c.Server.Quality)) {
+				specs = append(specs, q+":"+c.Server.Quality[q])
+			}
+			v["quality"] = strings.Join(specs, ";")
+		}
+	}
+	return v, nil
+}
+
+// setFlags sets the values of the flags, that are set in the profile.
+func (p Profile) setFlags(v map[string]string) {
+	setString(v, "p", p.Printer)
+	setString(v, "mac", p.MAC)
+	setString(v, "model", p.Model)
+	setString(v, "ble", p.Backend)
+	setValue(v, "e", p.Energy)
+	setString(v, "dither", p.Dither)
+	setValue(v, "gamma", p.Gamma)
+	setValue(v, "auto-dither", p.AutoDither)
+	setString(v, "channel", p.Channel)
+	setString(v, "font", p.Font)
+	setValue(v, "font-size", p.FontSize)
+	setValue(v, "job-footer", p.JobFooter)
+	setString(v, "lang", p.Lang)
+}
+
+func setString(v map[string]string, flag, s string) {
+	if s != "" {
+		v[flag] = s
+	}
+}
+
+func setValue[T any](v map[string]string, flag string, p *T) {
+	if p != nil {
+		v[flag] = fmt.Sprint(*p)
+	}
+}
@@ -0,0 +1,139 @@
+package cfg
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func setConfigFile(t *testing.T, content string) {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	if content != "" {
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	original := ConfigFile
+	ConfigFile = filename
+	t.Cleanup(func() { ConfigFile = original })
+}
+
+func setProfile(t *testing.T, name string) {
+	t.Helper()
+	original := ProfileName
+	ProfileName = name
+	t.Cleanup(func() { ProfileName = original })
+}
+
+func TestApplyConfig(t *testing.T) {
+	newFlags := func() (*flag.FlagSet, *string, *uint, *string) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		name := fs.String("p", DefaultPrinterName, "")
+		energy := fs.Uint("e", 2, "")
+		addr := fs.String("addr", ":6310", "")
+		return fs, name, energy, addr
+	}
+	t.Run("values from the file", func(t *testing.T) {
+		setConfigFile(t, "printer: M02\nenergy: 0\nfont: unknown-flag-is-ignored\nserver:\n  addr: :631\n")
+		fs, name, energy, addr := newFlags()
+		if err := ApplyConfig(fs, "image"); err != nil {
+			t.Fatalf("ApplyConfig() error = %v", err)
+		}
+		if *name != "M02" || *energy != 0 {
+			t.Errorf("p = %q, e = %d, want M02 and 0", *name, *energy)
+		}
+		if *addr != ":6310" {
+			t.Errorf("addr = %q, server section must apply only to the server", *addr)
+		}
+		if err := ApplyConfig(fs, "server"); err != nil || *addr != ":631" {
+			t.Errorf("addr = %q, %v, want :631", *addr, err)
+		}
+	})
+	t.Run("job footer per profile", func(t *testing.T) {
+		setConfigFile(t, "profiles:\n  shared:\n    job_footer: true\n  home: {}\n")
+		for profile, want := range map[string]bool{"shared": true, "home": false} {
+			setProfile(t, profile)
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			footer := fs.Bool("job-footer", false, "")
+			if err := ApplyConfig(fs, "server"); err != nil {
+				t.Fatal(err)
+			}
+			if *footer != want {
+				t.Errorf("%s: job-footer = %v, want %v", profile, *footer, want)
+			}
+		}
+	})
+	t.Run("language", func(t *testing.T) {
+		setConfigFile(t, "lang: de\n")
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		lang := fs.String("lang", "en", "")
+		if err := ApplyConfig(fs, "note"); err != nil {
+			t.Fatal(err)
+		}
+		if *lang != "de" {
+			t.Errorf("lang = %q, want de", *lang)
+		}
+	})
+	t.Run("quality profiles", func(t *testing.T) {
+		setConfigFile(t, "server:\n  quality:\n    high: energy=4\n    draft: energy=1,dither=threshold:160\n")
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		quality := fs.String("quality", "", "")
+		if err := ApplyConfig(fs, "server"); err != nil {
+			t.Fatal(err)
+		}
+		if want := "draft:energy=1,dither=threshold:160;high:energy=4"; *quality != want {
+			t.Errorf("quality = %q, want %q", *quality, want)
+		}
+	})
+	t.Run("flags override the file", func(t *testing.T) {
+		setConfigFile(t, "printer: M02\nenergy: 5\n")
+		fs, name, energy, _ := newFlags()
+		if err := ApplyConfig(fs, "image"); err != nil {
+			t.Fatal(err)
+		}
+		if err := fs.Parse([]string{"-p", "GB01"}); err != nil {
+			t.Fatal(err)
+		}
+		if *name != "GB01" || *energy != 5 {
+			t.Errorf("p = %q, e = %d, want GB01 and 5", *name, *energy)
+		}
+	})
+	t.Run("profile", func(t *testing.T) {
+		setConfigFile(t, "printer: M02\nenergy: 5\nprofiles:\n  receipt:\n    printer: GB01\n  label: {}\n")
+		setProfile(t, "receipt")
+		fs, name, energy, _ := newFlags()
+		if err := ApplyConfig(fs, "image"); err != nil {
+			t.Fatal(err)
+		}
+		if *name != "GB01" || *energy != 5 {
+			t.Errorf("p = %q, e = %d, want GB01 from the profile and 5 from the top level", *name, *energy)
+		}
+
+		setProfile(t, "missing")
+		if err := ApplyConfig(fs, "image"); err == nil {
+			t.Error("unknown profile is accepted")
+		}
+		setConfigFile(t, "")
+		if err := ApplyConfig(fs, "image"); err == nil {
+			t.Error("profile without the configuration file is accepted")
+		}
+	})
+	t.Run("missing file", func(t *testing.T) {
+		setConfigFile(t, "")
+		fs, name, _, _ := newFlags()
+		if err := ApplyConfig(fs, "image"); err != nil || *name != DefaultPrinterName {
+			t.Errorf("p = %q, %v, want the default", *name, err)
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		for _, content := range []string{"printr: M02\n", "energy: -1\n", "energy: [1]\n"} {
+			setConfigFile(t, content)
+			fs, _, _, _ := newFlags()
+			if err := ApplyConfig(fs, "image"); err == nil {
+				t.Errorf("ApplyConfig() with %q succeeded, want error", content)
+			}
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package cfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// KnownDevice is the printer that tp connected to last time.  The address is
+// the identifier that the platform provides: MAC address, or the
+// CoreBluetooth UUID on macOS, where the MAC addresses are hidden.
+type KnownDevice struct {
+	Name     string    `json:"name"`
+	Address  string    `json:"address"`
+	LastSeen time.Time `json:"last_seen"`
+	// Chosen is true if the printer was chosen with tp scan -save, it is
+	// used unless another printer is given with -p or -mac.
+	Chosen bool `json:"chosen,omitempty"`
+}
+
+// LoadKnownDevice loads the last connected printer.  If there is none, the
+// returned error wraps [os.ErrNotExist].
+func LoadKnownDevice() (KnownDevice, error) {
+	filename, err := DeviceFilename()
+	if err != nil {
+		return KnownDevice{}, err
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return KnownDevice{}, fmt.Errorf("load known device: %w", err)
+	}
+	var kd KnownDevice
+	if err := json.Unmarshal(data, &kd); err != nil {
+		return KnownDevice{}, fmt.Errorf("decode known device %s: %w", filename, err)
+	}
+	return kd, nil
+}
+
+// SaveKnownDevice saves the last connected printer.
+func SaveKnownDevice(kd KnownDevice) error {
+	filename, err := DeviceFilename()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(kd, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("create configuration directory: %w", err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("save known device: %w", err)
+	}
+	return nil
+}
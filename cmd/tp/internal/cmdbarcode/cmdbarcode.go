@@ -0,0 +1,78 @@
+// Package cmdbarcode provides the barcode printing subcommand.
+package cmdbarcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rusq/thermoprint"
+	"github.com/rusq/thermoprint/bitmap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/bootstrap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
+	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+)
+
+var CmdBarcode = &base.Command{
+	Run:        runBarcode,
+	UsageLine:  "tp barcode [flags] <data>",
+	Short:      "prints a barcode",
+	FlagMask:   cfg.OmitCommonImageFlags,
+	PrintFlags: true,
+	Long: `
+Prints the linear barcode of the data with the text beneath, i.e.:
+
+    tp barcode ORDER-1234
+    tp barcode -type ean13 400638133393
+
+-type selects the symbology: code128 (default) encodes any ASCII text, ean13
+encodes 12 digits, the check digit is added, and code39 is understood by
+the oldest scanners.  The bars are as wide as the printer allows, with
+every bar a whole number of dots.
+
+-height sets the height of the bars, in millimetres, unless followed by the
+unit: mm, cm, in or px.
+`,
+}
+
+var (
+	kind   = bitmap.Code128
+	height string
+)
+
+func init() {
+	CmdBarcode.Flag.Func("type", fmt.Sprintf("barcode `type`, one of: %s (default %s)", strings.Join(bitmap.BarcodeTypes(), ", "), bitmap.Code128), setType)
+	CmdBarcode.Flag.StringVar(&height, "height", "10mm", "`height` of the bars")
+}
+
+func setType(s string) (err error) {
+	kind, err = bitmap.ParseBarcodeType(s)
+	return err
+}
+
+func runBarcode(ctx context.Context, cmd *base.Command, args []string) error {
+	data := strings.Join(args, " ")
+	if data == "" {
+		base.SetExitStatus(base.SInvalidParameters)
+		return errors.New("expected the data to encode")
+	}
+	// the height is validated before connecting to the printer, the
+	// resolution is the same for all supported printers.
+	heightDots, err := thermoprint.ParseLength(height, float64(thermoprint.LXD02Rasteriser.DPI()))
+	if err != nil || heightDots == 0 {
+		base.SetExitStatus(base.SInvalidParameters)
+		return fmt.Errorf("-height: invalid height %q", height)
+	}
+
+	prn, err := bootstrap.Printer(ctx)
+	if err != nil {
+		return err
+	}
+	c := bitmap.NewComposer(prn.Width())
+	if err := c.AppendBarcode(kind, data, heightDots); err != nil {
+		base.SetExitStatus(base.SBadInput)
+		return err
+	}
+	return prn.PrintImage(ctx, c.Image())
+}
@@ -1,15 +1,24 @@
 package cmdcompose
 
 import (
+	"cmp"
 	"context"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/rusq/thermoprint/bitmap"
 	"github.com/rusq/thermoprint/cmd/tp/internal/bootstrap"
 	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
 	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+	"github.com/rusq/thermoprint/internal/imgcache"
 )
 
 var CmdCompose = &base.Command{
@@ -18,14 +27,102 @@ var CmdCompose = &base.Command{
 	Short:      "compose image and text into a single printout",
 	PrintFlags: true,
 	Long: `
-This is a sample command to get you started.
+Composes the printout from the document, where lines are printed as text,
+and the lines that start with the dot are commands:
+
+    .font <name or file.ttf> [size]   select the font (.ft)
+    .align left|center|right          align the following text (.al)
+    .image <file or url> [width]      embed the image (.im)
+    .margin <length>                  set the left and right margins
+    .space <length>                   add the blank space
+    .exec <command> [args...]         embed the output of the command
+    .cut [feed]                       end the page and feed the paper
+    .title <text>                     name the print job, not printed
+    .qr <data> [size]                 embed the QR code of the data
+    .barcode <type> <data> [height]   embed the code128, ean13 or code39 barcode
+    .rule [thickness]                 draw the horizontal rule, 2px by default
+    .invert [on|off]                  print the following text white on black
+    .columns <2-4>|off                lay out the "a | b | c" lines in columns
+    .chart <kind> [height]            chart of the CSV lines up to .endchart
+    .frame <style>|off                frame the following content
+    .frame image <style>|off          frame each of the following images
+
+Lengths are in millimetres, or with the unit: 5mm, 1.5cm, 0.5in or 40px.
+The size of the QR code and the height of the barcode must have the unit,
+otherwise they are a part of the data.
+
+In the columns, the first cell of the line is aligned left, the last one
+right, and the ones in between are centred; the line without "|" spans all
+columns.
+
+The frame style is single, double, rounded or halftone.  The frame at the
+top of the document frames the whole document, each page ending with .cut
+is framed separately.
+
+The chart is the sparkline, bar or line, of the CSV data:
+
+    .chart line 25mm
+    time,inside,outside
+    06:00,18,4
+    12:00,21,11
+    .endchart
+
+The first column is the labels, if it has the text, the header row names the
+series; the single row of numbers is the sparkline data, i.e. "3,5,4,8".
+
+.exec is disabled by default, allow the commands with -exec, i.e.:
+
+    tp compose -exec fortune,date note.txt
+
+The commands are run without the shell and are killed after -exec-timeout.
+
+Images referenced by http or https URL are kept in the disk cache, and are
+downloaded again only if they were modified on the server.  The cache is in
+the user cache directory, unless IMAGE_CACHE_DIR is set; disable the remote
+images with -remote-images=false.
+
+Text lines may refer to the environment variables as ${NAME}, and to the
+current date and time as ${date:format}, where format is strftime(3)-like,
+i.e. ${date:%Y-%m-%d %H:%M}.  Undefined variables are empty, $${ is the
+literal ${.  Variables can also be set with -var name=value, which takes
+precedence over the environment.
+
+Conditional blocks print the lines only if the condition holds:
+
+    .if url                           variable is defined and not empty
+    .if !url                          variable is undefined or empty
+    .if size == large                 also !=, and <, <=, >, >= for numbers
+    .else
+    .endif
+
+Blocks may be nested, the commands in the skipped lines are not run.
+
+.cut splits the document into pages, printed one after another as separate
+jobs, i.e. a batch of receipts.  Each page ends with the paper feed, 10mm by
+default, so that it can be torn off.
 `,
 }
 
-var ditherText bool
+var (
+	ditherText  bool
+	execAllow   string
+	execTimeout time.Duration
+	remoteImgs  bool
+	vars        = map[string]string{}
+)
 
 func init() {
-	CmdCompose.Flag.BoolVar(&ditherText, "dither-text", false, "dither text")
+	setDocumentFlags(&CmdCompose.Flag)
+}
+
+// setDocumentFlags sets the flags of the document processing, that are
+// shared by the commands, that print the documents.
+func setDocumentFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&ditherText, "dither-text", false, "dither text")
+	fs.StringVar(&execAllow, "exec", "", "comma separated `list` of commands that the .exec document command may run, disabled if empty")
+	fs.DurationVar(&execTimeout, "exec-timeout", bitmap.DefaultExecTimeout, "time the .exec command may run")
+	fs.BoolVar(&remoteImgs, "remote-images", true, "allow the .image document command to fetch images by URL")
+	fs.Func("var", "set the document variable, `name=value`, may be repeated", setVar)
 }
 
 func runCompose(ctx context.Context, cmd *base.Command, args []string) error {
@@ -41,11 +138,18 @@ func runCompose(ctx context.Context, cmd *base.Command, args []string) error {
 		var err error
 		f, err = os.Open(filename)
 		if err != nil {
-			base.SetExitStatus(base.SInvalidParameters)
+			base.SetExitStatus(base.SBadInput)
 			return fmt.Errorf("unable to open file %q: %w", filename, err)
 		}
 		defer f.Close()
 	}
+	return printDocument(ctx, f, jobName(filename))
+}
+
+// printDocument parses the document script from r and prints its pages.
+// The name is the name of the print job, unless the document has the
+// title.
+func printDocument(ctx context.Context, r io.Reader, name string) error {
 	prn, err := bootstrap.Printer(ctx)
 	if err != nil {
 		return err
@@ -61,15 +165,78 @@ func runCompose(ctx context.Context, cmd *base.Command, args []string) error {
 		bitmap.WithComposerEnableTextDither(ditherText),
 	)
 
-	doc := bitmap.NewDocument(c, prn.DPI())
-	if err := doc.Parse(f); err != nil {
-		base.SetExitStatus(base.SApplicationError)
+	docOpts := []bitmap.DocumentOption{bitmap.WithVariables(vars)}
+	if execAllow != "" {
+		docOpts = append(docOpts, bitmap.WithExec(strings.FieldsFunc(execAllow, isListSeparator), execTimeout))
+	}
+	if remoteImgs {
+		fetch, err := remoteFetcher(ctx)
+		if err != nil {
+			return err
+		}
+		docOpts = append(docOpts, bitmap.WithRemoteImages(fetch))
+	}
+	doc := bitmap.NewDocument(c, prn.DPI(), docOpts...)
+	if err := doc.Parse(r); err != nil {
+		base.SetExitStatus(base.SBadInput)
 		return err
 	}
-	img, err := doc.Render()
+	pages, err := doc.Pages()
 	if err != nil {
 		return fmt.Errorf("render document: %w", err)
 	}
+	job := cmp.Or(doc.Title(), name)
+	lg := slog.With("job", job)
+	lg.InfoContext(ctx, "printing document", "pages", len(pages))
+	for i, pg := range pages {
+		if len(pages) > 1 {
+			lg.InfoContext(ctx, "printing page", "page", i+1, "of", len(pages))
+		}
+		if err := prn.PrintImage(ctx, pg); err != nil {
+			if len(pages) > 1 {
+				return fmt.Errorf("%s: page %d: %w", job, i+1, err)
+			}
+			return fmt.Errorf("%s: %w", job, err)
+		}
+	}
+	lg.InfoContext(ctx, "document printed")
+	return nil
+}
+
+// remoteFetcher returns the function that fetches the remote images
+// through the disk cache.
+func remoteFetcher(ctx context.Context) (bitmap.FetchFunc, error) {
+	dir, err := cfg.ImageCacheDirname()
+	if err != nil {
+		return nil, err
+	}
+	cache, err := imgcache.New(dir, imgcache.DefaultMaxSize, nil)
+	if err != nil {
+		return nil, err
+	}
+	return func(url string) ([]byte, error) {
+		return cache.Get(ctx, url)
+	}, nil
+}
+
+// jobName returns the name of the print job for the document file, if the
+// document has no title.
+func jobName(filename string) string {
+	if filename == "-" {
+		return "stdin"
+	}
+	return filepath.Base(filename)
+}
+
+func setVar(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return errors.New("expected name=value")
+	}
+	vars[name] = value
+	return nil
+}
 
-	return prn.PrintImage(ctx, img)
+func isListSeparator(r rune) bool {
+	return r == ',' || unicode.IsSpace(r)
 }
@@ -0,0 +1,112 @@
+package cmdcompose
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+	"github.com/rusq/thermoprint/receipt"
+)
+
+var CmdTemplate = &base.Command{
+	Run:        runTemplate,
+	UsageLine:  "tp template [flags] <template> -data <data.json or - for stdin>",
+	Short:      "prints the document template filled with the data",
+	PrintFlags: true,
+	Long: `
+Expands the Go text/template with the JSON data, and prints the result as the
+document, see "tp help compose", so that the receipts, invoices or labels are
+printed with the same layout for every order:
+
+    .align center
+    Invoice {{.number}}
+    .columns 3
+    {{range .items}}{{.name}} | {{.qty}} | {{money (mul .qty .price)}}
+    {{end}}
+
+In addition to the built-in functions of text/template, the templates may
+use:
+
+    add a b...     sum of the numbers
+    sub a b...     a minus the rest of the numbers
+    mul a b...     product of the numbers
+    money n        the number with two decimals, i.e. 12.50
+    upper s        s in upper case
+    lower s        s in lower case
+    tr s           s translated to the language of -lang, i.e. {{tr "Total"}}
+
+The keys, that are missing in the data, are reported as an error.  The flags
+may follow the template name, i.e.:
+
+    tp template invoice.tpl -data order.json
+`,
+}
+
+var dataFile string
+
+func init() {
+	CmdTemplate.Flag.StringVar(&dataFile, "data", "", "JSON data `file`, - for stdin")
+	setDocumentFlags(&CmdTemplate.Flag)
+}
+
+func runTemplate(ctx context.Context, cmd *base.Command, args []string) error {
+	if len(args) > 1 {
+		// the flags after the template name.
+		if err := cmd.Flag.Parse(args[1:]); err != nil {
+			base.SetExitStatus(base.SInvalidParameters)
+			return err
+		}
+		args = append(args[:1], cmd.Flag.Args()...)
+	}
+	if len(args) != 1 {
+		base.SetExitStatus(base.SInvalidParameters)
+		return errors.New("expected exactly one argument: the template filename")
+	}
+	if dataFile == "" {
+		base.SetExitStatus(base.SInvalidParameters)
+		return errors.New("expected the data file, -data")
+	}
+	filename := args[0]
+	text, err := os.ReadFile(filename)
+	if err != nil {
+		base.SetExitStatus(base.SBadInput)
+		return err
+	}
+	tmpl, err := receipt.Parse(jobName(filename), string(text))
+	if err != nil {
+		base.SetExitStatus(base.SBadInput)
+		return err
+	}
+	data, err := loadData(dataFile)
+	if err != nil {
+		base.SetExitStatus(base.SBadInput)
+		return err
+	}
+	doc, err := tmpl.Execute(data)
+	if err != nil {
+		base.SetExitStatus(base.SBadInput)
+		return err
+	}
+	return printDocument(ctx, bytes.NewReader(doc), jobName(filename))
+}
+
+func loadData(filename string) (any, error) {
+	var r io.Reader = os.Stdin
+	if filename != "-" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	data, err := receipt.LoadData(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return data, nil
+}
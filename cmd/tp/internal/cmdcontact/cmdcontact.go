@@ -0,0 +1,109 @@
+// Package cmdcontact provides the contact card printing subcommand.
+package cmdcontact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rusq/thermoprint"
+	"github.com/rusq/thermoprint/cmd/tp/internal/bootstrap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
+	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+	"github.com/rusq/thermoprint/contact"
+	"github.com/rusq/thermoprint/fontmgr"
+)
+
+var CmdContact = &base.Command{
+	Run:        runContact,
+	UsageLine:  "tp contact [flags] -name <name>",
+	Short:      "prints a contact card with the vCard QR code",
+	FlagMask:   cfg.OmitCommonImageFlags,
+	PrintFlags: true,
+	Long: `
+Prints the contact card: the QR code of the vCard, that phones add to the
+contacts when scanned, and the name with the details under it, i.e.:
+
+    tp contact -name "Jane Doe" -title CTO -org "Example Ltd" \
+        -tel "+1 555 0100" -email jane@example.com -url https://example.com
+
+-tel and -email may be repeated.  -text=false prints only the QR code.
+
+-size sets the size of the code, the size is in millimetres, unless followed
+by the unit: mm, cm, in or px.  The code is two thirds of the printer width
+by default, the more details it has, the denser it is.
+`,
+}
+
+var (
+	card  contact.Card
+	size  string
+	text  bool
+	faceN string
+)
+
+func init() {
+	CmdContact.Flag.StringVar(&card.Name, "name", "", "full `name`, required")
+	CmdContact.Flag.StringVar(&card.Org, "org", "", "`organisation`")
+	CmdContact.Flag.StringVar(&card.Title, "title", "", "job `title`")
+	CmdContact.Flag.Func("tel", "`phone` number, may be repeated", func(s string) error {
+		card.Tel = append(card.Tel, s)
+		return nil
+	})
+	CmdContact.Flag.Func("email", "email `address`, may be repeated", func(s string) error {
+		card.Email = append(card.Email, s)
+		return nil
+	})
+	CmdContact.Flag.StringVar(&card.URL, "url", "", "web site `URL`")
+	CmdContact.Flag.StringVar(&card.Address, "address", "", "postal `address`")
+	CmdContact.Flag.StringVar(&card.Note, "note", "", "`note`")
+	CmdContact.Flag.StringVar(&size, "size", "", "QR code `size`, two thirds of the printer width if empty")
+	CmdContact.Flag.BoolVar(&text, "text", true, "print the details under the QR code")
+	CmdContact.Flag.StringVar(&faceN, "font", "toshiba", "select a built-in font `name`, the name is printed in its bold variant, if there is one")
+}
+
+func runContact(ctx context.Context, cmd *base.Command, args []string) error {
+	if len(args) > 0 {
+		base.SetExitStatus(base.SInvalidParameters)
+		return fmt.Errorf("unexpected arguments: %q, the details are set with the flags", args)
+	}
+	if err := card.Validate(); err != nil {
+		base.SetExitStatus(base.SInvalidParameters)
+		return err
+	}
+	var sizeDots int
+	if size != "" {
+		var err error
+		if sizeDots, err = thermoprint.ParseLength(size, float64(thermoprint.LXD02Rasteriser.DPI())); err != nil || sizeDots == 0 {
+			base.SetExitStatus(base.SInvalidParameters)
+			return fmt.Errorf("-size: invalid size %q", size)
+		}
+	}
+	face, err := fontmgr.LoadByName(faceN)
+	if err != nil {
+		base.SetExitStatus(base.SBadInput)
+		return err
+	}
+	bold, err := fontmgr.LoadByName(faceN + "-bold")
+	if err != nil {
+		if !errors.Is(err, fontmgr.ErrNotFound) {
+			base.SetExitStatus(base.SBadInput)
+			return err
+		}
+		bold = face
+	}
+
+	prn, err := bootstrap.Printer(ctx)
+	if err != nil {
+		return err
+	}
+	if sizeDots == 0 || sizeDots > prn.Width() {
+		sizeDots = prn.Width() * 2 / 3
+	}
+	img, err := card.Render(prn.Width(), sizeDots, bold, face, text)
+	if err != nil {
+		base.SetExitStatus(base.SBadInput)
+		return err
+	}
+	return prn.PrintImage(ctx, img)
+}
@@ -0,0 +1,154 @@
+// Package cmdcsv provides the CSV table printing subcommand.
+package cmdcsv
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+
+	"github.com/rusq/thermoprint/bitmap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/bootstrap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+	"github.com/rusq/thermoprint/fontmgr"
+)
+
+var CmdCSV = &base.Command{
+	Run:        runCSV,
+	UsageLine:  "tp csv [flags] <filename or - for stdin>",
+	Short:      "prints CSV or TSV data as a table",
+	PrintFlags: true,
+	Long: `
+Prints the CSV or TSV data as the table, with the columns aligned.
+
+The columns are as wide as their content, if the table fits the paper,
+otherwise the wide columns are narrowed and their cells are wrapped on the
+word boundaries.  The columns of the numbers are aligned right.
+
+The first row is the header, unless -header=false: it is printed with the
+bold variant of the font, if there is one, i.e. toshiba-bold for toshiba,
+and is underlined.
+
+The data is TSV, if the file name ends with .tsv, or if the first line has
+tabs and no commas, otherwise it is CSV; -comma sets the separator
+explicitly, i.e. -comma ';'.
+`,
+}
+
+var (
+	header   bool
+	comma    string
+	fontName string
+)
+
+func init() {
+	CmdCSV.Flag.BoolVar(&header, "header", true, "the first row is the header")
+	CmdCSV.Flag.StringVar(&comma, "comma", "", "field `separator`, detected if empty")
+	CmdCSV.Flag.StringVar(&fontName, "font", "toshiba", "select a built-in font `name`")
+}
+
+func runCSV(ctx context.Context, cmd *base.Command, args []string) error {
+	if len(args) != 1 {
+		base.SetExitStatus(base.SInvalidParameters)
+		return errors.New("expected exactly one argument: filename or '-' for stdin")
+	}
+	filename := args[0]
+
+	var (
+		data []byte
+		err  error
+	)
+	if filename == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		base.SetExitStatus(base.SBadInput)
+		return fmt.Errorf("error reading data: %w", err)
+	}
+	sep, err := separator(filename, data)
+	if err != nil {
+		base.SetExitStatus(base.SInvalidParameters)
+		return err
+	}
+	rows, err := readRows(bytes.NewReader(data), sep)
+	if err != nil {
+		base.SetExitStatus(base.SBadInput)
+		return err
+	}
+
+	face, err := fontmgr.LoadByName(fontName)
+	if err != nil {
+		base.SetExitStatus(base.SBadInput)
+		return err
+	}
+	var bold font.Face
+	if header {
+		bold, err = fontmgr.LoadByName(fontName + "-bold")
+		if err != nil && !errors.Is(err, fontmgr.ErrNotFound) {
+			base.SetExitStatus(base.SBadInput)
+			return err
+		}
+	}
+
+	prn, err := bootstrap.Printer(ctx)
+	if err != nil {
+		return err
+	}
+	img, err := bitmap.RenderTable(rows, face, prn.Width(), bitmap.WithTableHeader(header, bold))
+	if err != nil {
+		base.SetExitStatus(base.SBadInput)
+		return err
+	}
+	return prn.PrintImage(ctx, img)
+}
+
+// separator returns the field separator: -comma, if it is set, the tab for
+// the TSV files and the data with the tabs, but no commas, in the first line,
+// or the comma.
+func separator(filename string, data []byte) (rune, error) {
+	if comma != "" {
+		if comma == `\t` {
+			return '\t', nil
+		}
+		r, size := utf8.DecodeRuneInString(comma)
+		if size != len(comma) {
+			return 0, fmt.Errorf("-comma: expected a single character, got %q", comma)
+		}
+		return r, nil
+	}
+	if strings.EqualFold(filepath.Ext(filename), ".tsv") {
+		return '\t', nil
+	}
+	first, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.ContainsRune(first, '\t') && !bytes.ContainsRune(first, ',') {
+		return '\t', nil
+	}
+	return ',', nil
+}
+
+// readRows reads the records, that may have the different number of fields.
+func readRows(r io.Reader, sep rune) ([][]string, error) {
+	cr := csv.NewReader(bufio.NewReader(r))
+	cr.Comma = sep
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = sep == '\t' // the quotes in TSV are literal
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read table: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("no data")
+	}
+	return rows, nil
+}
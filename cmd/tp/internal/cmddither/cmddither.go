@@ -0,0 +1,92 @@
+// Package cmddither provides the dithering comparison subcommand.
+package cmddither
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+
+	"github.com/rusq/thermoprint"
+	"github.com/rusq/thermoprint/bitmap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/bootstrap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
+	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+)
+
+var CmdDitherCompare = &base.Command{
+	Run:        runDitherCompare,
+	UsageLine:  "tp dither-compare [flags] <image file>",
+	Short:      "prints the image with every dithering function",
+	PrintFlags: true,
+	Long: `
+Prints the comparison sheet: the same image processed by every dithering
+function, each under its name, to help choosing the -dither and -gamma
+settings.
+
+With -o, the sheet is saved to the PNG file instead, and the printer is not
+needed.
+`,
+}
+
+var output string
+
+func init() {
+	CmdDitherCompare.Flag.StringVar(&output, "o", "", "save the sheet to the PNG `file` instead of printing")
+}
+
+func runDitherCompare(ctx context.Context, cmd *base.Command, args []string) error {
+	if len(args) != 1 {
+		base.SetExitStatus(base.SInvalidParameters)
+		return errors.New("expected only one image")
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		base.SetExitStatus(base.SBadInput)
+		return err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		base.SetExitStatus(base.SBadInput)
+		return err
+	}
+
+	if output != "" {
+		sheet, err := bitmap.DitherSheet(img, thermoprint.LXD02Rasteriser.LineWidth(), cfg.Gamma)
+		if err != nil {
+			return err
+		}
+		return savePNG(output, sheet)
+	}
+
+	prn, err := bootstrap.Printer(ctx)
+	if err != nil {
+		return err
+	}
+	sheet, err := bitmap.DitherSheet(img, prn.Width(), cfg.Gamma)
+	if err != nil {
+		return err
+	}
+	// the sheet is already dithered.
+	if err := prn.SetOptions(thermoprint.WithDither("no-dither"), thermoprint.WithAutoDither(false)); err != nil {
+		return err
+	}
+	return prn.PrintImage(ctx, sheet)
+}
+
+func savePNG(filename string, img image.Image) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return fmt.Errorf("encode %s: %w", filename, err)
+	}
+	return f.Close()
+}
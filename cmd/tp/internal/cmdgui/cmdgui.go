@@ -0,0 +1,89 @@
+// Package cmdgui provides the graphical user interface subcommand.
+package cmdgui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+)
+
+var CmdGUI = &base.Command{
+	Run:        runGUI,
+	UsageLine:  "tp gui [flags]",
+	Short:      "opens the graphical user interface",
+	PrintFlags: true,
+	Long: `
+Opens a window in the web browser to print without the command line: drop
+an image or type the text, pick the dithering and energy, check the preview
+and press Print.
+
+The interface is served on the local address only (-addr), and the printer
+is connected on the first print.  The print requests from the other web
+pages are rejected.  Press Ctrl+C to quit.
+`,
+}
+
+var (
+	addr      string
+	noBrowser bool
+)
+
+func init() {
+	CmdGUI.Flag.StringVar(&addr, "addr", "localhost:6320", "`address` to serve the interface on")
+	CmdGUI.Flag.BoolVar(&noBrowser, "no-browser", false, "do not open the web browser")
+}
+
+func runGUI(ctx context.Context, cmd *base.Command, args []string) error {
+	if len(args) > 0 {
+		base.SetExitStatus(base.SInvalidParameters)
+		return fmt.Errorf("unexpected arguments: %v", args)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		base.SetExitStatus(base.SApplicationError)
+		return err
+	}
+	url := "http://" + ln.Addr().String() + "/"
+	ui := newUI(ctx, "http://"+ln.Addr().String(), "http://"+addr)
+	srv := &http.Server{Handler: ui.handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		if err := srv.Shutdown(context.Background()); err != nil {
+			slog.Error("error shutting down the interface", "error", err)
+		}
+	}()
+
+	slog.Info("interface is ready, press Ctrl+C to quit", "url", url)
+	if !noBrowser {
+		if err := openBrowser(url); err != nil {
+			slog.Warn("unable to open the browser, open the url manually", "url", url, "error", err)
+		}
+	}
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		base.SetExitStatus(base.SApplicationError)
+		return err
+	}
+	return nil
+}
+
+// openBrowser opens the url in the default web browser.
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
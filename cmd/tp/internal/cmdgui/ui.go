@@ -0,0 +1,298 @@
+package cmdgui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/rusq/thermoprint"
+	"github.com/rusq/thermoprint/bitmap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/bootstrap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
+	"github.com/rusq/thermoprint/fontmgr"
+)
+
+// maxUpload is the maximum size of the uploaded image.
+const maxUpload = 32 << 20
+
+// the energy range of [thermoprint.WithEnergy].
+const (
+	minEnergy = 1
+	maxEnergy = 6
+)
+
+// printer is the subset of the printer used by the interface.
+type printer interface {
+	SetOptions(opts ...thermoprint.Option) error
+	PrintImage(ctx context.Context, img image.Image) error
+}
+
+// ui is the graphical interface.  It connects to the printer on the first
+// print, so that the interface opens without waiting for Bluetooth.
+type ui struct {
+	// ctx is the context of the command, the printer is connected with it,
+	// as the connection outlives the request.
+	ctx     context.Context
+	connect func(ctx context.Context) (printer, error)
+	// origins are the origins of the interface, the requests from the other
+	// web pages are rejected.
+	origins []string
+
+	mu  sync.Mutex
+	prn printer
+}
+
+func newUI(ctx context.Context, origins ...string) *ui {
+	return &ui{
+		ctx: ctx,
+		connect: func(ctx context.Context) (printer, error) {
+			return bootstrap.Printer(ctx)
+		},
+		origins: origins,
+	}
+}
+
+func (u *ui) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", u.handleIndex)
+	mux.HandleFunc("POST /preview", u.sameOrigin(u.handlePreview))
+	mux.HandleFunc("POST /print", u.sameOrigin(u.handlePrint))
+	return mux
+}
+
+// sameOrigin rejects the requests, that the other web pages send, so that
+// any site, that is open in the browser, can't print on the local printer.
+// The requests without the Origin, i.e. from curl, are not cross-site.
+func (u *ui) sameOrigin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && !slices.Contains(u.origins, origin) {
+			slog.WarnContext(r.Context(), "rejected the cross-origin request", "origin", origin, "path", r.URL.Path)
+			http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+			return
+		}
+		if site := r.Header.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" && site != "none" {
+			http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (u *ui) handleIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	data := struct {
+		Dithers []string
+		Dither  string
+		Energy  uint
+	}{bitmap.AllDitherFunctions(), cfg.Dither, cfg.Energy}
+	if err := pageTemplate.Execute(w, data); err != nil {
+		slog.Error("failed to render the page", "error", err)
+	}
+}
+
+// request is the parsed preview or print request.
+type request struct {
+	img    image.Image
+	dither string
+	gamma  float64
+	energy uint8
+}
+
+// parseRequest parses the form with either the "image" file or the "text".
+func parseRequest(r *http.Request) (request, error) {
+	if err := r.ParseMultipartForm(maxUpload); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return request{}, fmt.Errorf("invalid form: %w", err)
+	}
+	req := request{
+		dither: r.FormValue("dither"),
+		gamma:  cfg.Gamma,
+		energy: uint8(cfg.Energy),
+	}
+	if _, ok := bitmap.DitherFunction(req.dither); !ok {
+		return request{}, fmt.Errorf("unknown dither function: %s", req.dither)
+	}
+	if v := r.FormValue("energy"); v != "" {
+		e, err := strconv.ParseUint(v, 10, 8)
+		if err != nil || e < minEnergy || e > maxEnergy {
+			return request{}, fmt.Errorf("invalid energy %q, expected %d-%d", v, minEnergy, maxEnergy)
+		}
+		req.energy = uint8(e)
+	}
+	if f, _, err := r.FormFile("image"); err == nil {
+		defer f.Close()
+		img, _, err := image.Decode(f)
+		if err != nil {
+			return request{}, fmt.Errorf("unable to decode the image: %w", err)
+		}
+		req.img = img
+		return req, nil
+	}
+	text := r.FormValue("text")
+	if text == "" {
+		return request{}, errors.New("nothing to print: drop an image or type the text")
+	}
+	face, err := fontmgr.LoadByName("toshiba")
+	if err != nil {
+		return request{}, err
+	}
+	img, err := bitmap.RenderTTF(text, face, thermoprint.LXD02Rasteriser.LineWidth())
+	if err != nil {
+		return request{}, fmt.Errorf("failed to render the text: %w", err)
+	}
+	req.img = img
+	return req, nil
+}
+
+// handlePreview responds with the PNG image as it will be printed.
+func (u *ui) handlePreview(w http.ResponseWriter, r *http.Request) {
+	req, err := parseRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rst := thermoprint.NewLXD02Rasteriser()
+	dfn, _ := bitmap.DitherFunction(req.dither)
+	rst.SetDitherFunc(dfn)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, rst.ResizeAndDither(req.img, req.gamma, cfg.AutoDither)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(buf.Bytes())
+}
+
+func (u *ui) handlePrint(w http.ResponseWriter, r *http.Request) {
+	req, err := parseRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// the options are set on the shared driver, the print holds the lock,
+	// so that the concurrent prints don't mix them up.
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	prn, err := u.printerLocked()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err := prn.SetOptions(thermoprint.WithEnergy(req.energy), thermoprint.WithDither(req.dither)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := prn.PrintImage(r.Context(), req.img); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, thermoprint.ErrBusy) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	fmt.Fprintln(w, "printed")
+}
+
+// printerLocked returns the connected printer, connecting on the first call
+// with the context of the command: the notifications of the printer stop
+// with the context, that it is connected with.  The caller holds u.mu.
+func (u *ui) printerLocked() (printer, error) {
+	if u.prn != nil {
+		return u.prn, nil
+	}
+	prn, err := u.connect(u.ctx)
+	if err != nil {
+		return nil, err
+	}
+	u.prn = prn
+	return prn, nil
+}
+
+var pageTemplate = template.Must(template.New("gui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Thermoprint</title>
+<style>
+body { font-family: sans-serif; margin: 2em; max-width: 40em; }
+#drop { border: 2px dashed #aaa; padding: 2em; text-align: center; margin-bottom: 1em; }
+#drop.over { border-color: #333; }
+textarea { width: 100%; }
+img { border: 1px solid #ccc; max-width: 100%; image-rendering: pixelated; }
+#status { color: #555; }
+</style>
+</head>
+<body>
+<h1>Thermoprint</h1>
+<div id="drop">Drop an image here or <input id="file" type="file" accept="image/*"></div>
+<p><textarea id="text" rows="4" placeholder="…or type the text"></textarea></p>
+<p>
+<label>Dither <select id="dither"><option value="">default</option>{{range .Dithers}}<option{{if eq . $.Dither}} selected{{end}}>{{.}}</option>{{end}}</select></label>
+<label>Energy <input id="energy" type="number" min="1" max="6" value="{{.Energy}}"></label>
+<button id="print">Print</button>
+</p>
+<p id="status"></p>
+<p><img id="preview" alt=""></p>
+<script>
+const $ = (id) => document.getElementById(id);
+let image = null;
+
+function form() {
+  const f = new FormData();
+  if (image) f.append("image", image); else f.append("text", $("text").value);
+  f.append("dither", $("dither").value);
+  f.append("energy", $("energy").value);
+  return f;
+}
+
+async function post(path) {
+  const resp = await fetch(path, { method: "POST", body: form() });
+  if (!resp.ok) throw new Error(await resp.text());
+  return resp;
+}
+
+async function preview() {
+  if (!image && !$("text").value) return;
+  try {
+    const resp = await post("preview");
+    $("preview").src = URL.createObjectURL(await resp.blob());
+    $("status").textContent = "";
+  } catch (e) {
+    $("status").textContent = e.message;
+  }
+}
+
+function setImage(file) { image = file; $("text").value = ""; preview(); }
+
+$("file").onchange = (e) => setImage(e.target.files[0]);
+$("drop").ondragover = (e) => { e.preventDefault(); $("drop").classList.add("over"); };
+$("drop").ondragleave = () => $("drop").classList.remove("over");
+$("drop").ondrop = (e) => { e.preventDefault(); $("drop").classList.remove("over"); setImage(e.dataTransfer.files[0]); };
+$("text").oninput = () => { image = null; preview(); };
+$("dither").onchange = preview;
+$("print").onclick = async () => {
+  $("print").disabled = true;
+  $("status").textContent = "Printing…";
+  try {
+    await post("print");
+    $("status").textContent = "Printed.";
+  } catch (e) {
+    $("status").textContent = e.message;
+  } finally {
+    $("print").disabled = false;
+  }
+};
+</script>
+</body>
+</html>
+`))
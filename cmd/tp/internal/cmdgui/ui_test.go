@@ -0,0 +1,150 @@
+package cmdgui
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/rusq/thermoprint"
+)
+
+type fakePrinter struct {
+	opts   int
+	images []image.Image
+}
+
+func (p *fakePrinter) SetOptions(opts ...thermoprint.Option) error {
+	p.opts += len(opts)
+	return nil
+}
+
+func (p *fakePrinter) PrintImage(_ context.Context, img image.Image) error {
+	p.images = append(p.images, img)
+	return nil
+}
+
+func postForm(t *testing.T, h http.Handler, path string, fields map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	return postFormHeader(t, h, path, fields, nil)
+}
+
+func postFormHeader(t *testing.T, h http.Handler, path string, fields map[string]string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUI(t *testing.T) {
+	prn := &fakePrinter{}
+	var (
+		connects   int
+		connectCtx context.Context
+	)
+	u := newUI(t.Context(), "http://localhost:6320")
+	u.connect = func(ctx context.Context) (printer, error) {
+		connects++
+		connectCtx = ctx
+		return prn, nil
+	}
+	h := u.handler()
+
+	t.Run("preview", func(t *testing.T) {
+		rec := postForm(t, h, "/preview", map[string]string{"text": "hello", "dither": "atkinson"})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		img, err := png.Decode(rec.Body)
+		if err != nil {
+			t.Fatalf("preview is not a PNG: %v", err)
+		}
+		if w := img.Bounds().Dx(); w != thermoprint.LXD02Rasteriser.LineWidth() {
+			t.Fatalf("preview width = %d, want %d", w, thermoprint.LXD02Rasteriser.LineWidth())
+		}
+		if connects != 0 {
+			t.Fatal("preview connected to the printer")
+		}
+	})
+	t.Run("print", func(t *testing.T) {
+		for range 2 {
+			rec := postForm(t, h, "/print", map[string]string{"text": "hello", "energy": "4"})
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+			}
+		}
+		if connects != 1 {
+			t.Fatalf("connected %d times, want 1", connects)
+		}
+		if len(prn.images) != 2 || prn.opts != 4 {
+			t.Fatalf("printed %d images with %d options", len(prn.images), prn.opts)
+		}
+		if connectCtx != u.ctx {
+			t.Fatal("the printer is not connected with the context of the command")
+		}
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Go(func() {
+				postForm(t, h, "/print", map[string]string{"text": "hello", "dither": "atkinson"})
+				postForm(t, h, "/preview", map[string]string{"text": "hello", "dither": "bayer"})
+			})
+		}
+		wg.Wait()
+		if len(prn.images) != 6 {
+			t.Fatalf("printed %d images, want 6", len(prn.images))
+		}
+		prn.images = prn.images[:2]
+	})
+	t.Run("origin", func(t *testing.T) {
+		for _, tt := range []struct {
+			header http.Header
+			want   int
+		}{
+			{header: http.Header{"Origin": {"http://localhost:6320"}, "Sec-Fetch-Site": {"same-origin"}}, want: http.StatusOK},
+			{header: http.Header{"Origin": {"https://example.com"}}, want: http.StatusForbidden},
+			{header: http.Header{"Sec-Fetch-Site": {"cross-site"}}, want: http.StatusForbidden},
+		} {
+			rec := postFormHeader(t, h, "/print", map[string]string{"text": "hello"}, tt.header)
+			if rec.Code != tt.want {
+				t.Errorf("header %v: status = %d, want %d", tt.header, rec.Code, tt.want)
+			}
+		}
+		if len(prn.images) != 3 {
+			t.Fatalf("printed %d images, want 3", len(prn.images))
+		}
+	})
+	t.Run("bad requests", func(t *testing.T) {
+		for _, fields := range []map[string]string{
+			{},
+			{"text": "hello", "dither": "unknown"},
+			{"text": "hello", "energy": "7"},
+			{"text": "hello", "energy": "0"},
+		} {
+			if rec := postForm(t, h, "/print", fields); rec.Code != http.StatusBadRequest {
+				t.Errorf("fields %v: status = %d, want %d", fields, rec.Code, http.StatusBadRequest)
+			}
+		}
+	})
+}
@@ -2,46 +2,216 @@
 package cmdimage
 
 import (
+	"bytes"
+	"cmp"
 	"context"
 	"errors"
+	"fmt"
 	"image"
+	"log/slog"
 	"os"
+	"strings"
 
+	"github.com/rusq/thermoprint"
+	"github.com/rusq/thermoprint/bitmap"
 	"github.com/rusq/thermoprint/cmd/tp/internal/bootstrap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
 	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+	"github.com/rusq/thermoprint/fontmgr"
+	"github.com/rusq/thermoprint/ippsrv"
 )
 
 var CmdImage = &base.Command{
 	Run:        runImage,
-	UsageLine:  "tp image [flags] <image file>",
-	Short:      "prints an image file",
+	UsageLine:  "tp image [flags] <image or document file>",
+	Short:      "prints an image or document file",
 	PrintFlags: true,
 	Long: `
 Prints an image.
+
+Files that are not images, i.e. PDF, are rasterised with ImageMagick, and
+the pages are printed one after another.  If ImageMagick is not installed,
+PDF pages are rasterised natively at the printer resolution: the text and
+the rules are printed, but the images and the drawings are not.  -pages
+selects the pages to print, i.e. -pages 2-4 or -pages 1,3,5-; only the
+selected pages are rasterised.
+
+-fit sets how the pages wider than the printer are printed: fit-width scales
+them down, crop cuts them at the right margin, and actual-size prints them
+in actual size, in strips of the printer width from left to right.  reflow
+finds the lines and words of the text on the page, and re-stacks them in the
+lines of the printer width, so that the text remains legible.  Without -fit,
+the images are scaled or cropped according to -crop.
+
+Blank document pages, that have fewer dark pixels than the -blank-threshold
+share, are not printed.  With -page-numbers, each page of a multi-page
+document starts with the "page n/N" header.
+
+With -pdf-text, the text of PDF documents is extracted and reprinted with
+the built-in font on the printer width, instead of rasterising the pages,
+which is far more legible for text-heavy documents.  The layout and images
+are lost.  PDFs without text, i.e. scans, are rasterised as usual.
+
+With -poster N, the image is scaled to the width of N strips and printed
+strip by strip, with the alignment marks above and below each strip, so
+that the strips can be taped together into a poster.
 `,
 }
 
+var (
+	posterStrips int
+	pageRanges   ippsrv.PageRanges
+	fit          ippsrv.Fit
+	blank        float64
+	pageNumbers  bool
+	pdfText      bool
+)
+
+func init() {
+	CmdImage.Flag.IntVar(&posterStrips, "poster", 0, "print the image as a poster of `N` strips")
+	CmdImage.Flag.Func("pages", "document `pages` to print, i.e. 2-4 or 1,3,5-", setPages)
+	CmdImage.Flag.Func("fit", fmt.Sprintf("`policy` for the pages wider than the printer, one of: %s", strings.Join(ippsrv.Fits(), ", ")), setFit)
+	CmdImage.Flag.BoolVar(&pageNumbers, "page-numbers", false, "print the page number headers between the pages of multi-page documents")
+	CmdImage.Flag.BoolVar(&pdfText, "pdf-text", false, "reprint the text of PDF documents with the built-in font instead of rasterising the pages")
+	CmdImage.Flag.Float64Var(&blank, "blank-threshold", ippsrv.DefaultBlankThreshold, "share of the dark pixels, below which the document page is blank and not printed, 0 prints all pages")
+}
+
+func setFit(s string) (err error) {
+	fit, err = ippsrv.ParseFit(s)
+	return err
+}
+
+func setPages(s string) error {
+	rr, err := ippsrv.ParsePageRanges(s)
+	if err != nil {
+		return err
+	}
+	pageRanges = rr
+	return nil
+}
+
 func runImage(ctx context.Context, cmd *base.Command, args []string) error {
 	if len(args) != 1 {
 		base.SetExitStatus(base.SInvalidParameters)
 		return errors.New("expected only one image")
 	}
+	if posterStrips < 0 {
+		base.SetExitStatus(base.SInvalidParameters)
+		return errors.New("number of poster strips must be positive")
+	}
+	if blank < 0 || blank >= 1 {
+		base.SetExitStatus(base.SInvalidParameters)
+		return fmt.Errorf("-blank-threshold: must be in the range [0, 1), got %g", blank)
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		base.SetExitStatus(base.SBadInput)
+		return err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		if posterStrips > 0 {
+			base.SetExitStatus(base.SBadInput)
+			return fmt.Errorf("poster requires an image: %w", err)
+		}
+		return printDocument(ctx, data)
+	}
+	if !pageRanges.Contains(1) {
+		base.SetExitStatus(base.SInvalidParameters)
+		return ippsrv.ErrNoPages
+	}
 
-	f, err := os.Open(args[0])
+	prn, err := bootstrap.Printer(ctx)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	img, _, err := image.Decode(f)
+	if posterStrips > 0 {
+		return printPoster(ctx, prn, img, posterStrips)
+	}
+	return printPages(ctx, prn, []image.Image{img})
+}
+
+func printPoster(ctx context.Context, prn thermoprint.Printer, img image.Image, n int) error {
+	strips, err := bitmap.Poster(img, n, prn.Width())
 	if err != nil {
 		return err
 	}
+	for i, strip := range strips {
+		slog.InfoContext(ctx, "printing poster strip", "strip", i+1, "of", n)
+		if err := prn.PrintImage(ctx, strip); err != nil {
+			return fmt.Errorf("strip %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
 
+// printDocument rasterises the selected pages of the document and prints
+// them one after another.
+func printDocument(ctx context.Context, data []byte) error {
 	prn, err := bootstrap.Printer(ctx)
 	if err != nil {
 		return err
 	}
+	filter := ippsrv.NewFilter()
+	if pdfText {
+		filter = ippsrv.NewTextFilter(prn.Width(), fontmgr.DefaultFont, filter)
+	}
+	pages, err := ippsrv.ToRasterPages(ctx, filter, int(prn.DPI()), data, pageRanges)
+	if err != nil {
+		base.SetExitStatus(base.SBadInput)
+		return fmt.Errorf("unable to rasterise the document: %w", err)
+	}
+	if len(pages) == 0 {
+		base.SetExitStatus(base.SBadInput)
+		return ippsrv.ErrNoPages
+	}
+	var skipped []int
+	if pages, skipped = ippsrv.SkipBlankPages(pages, blank); len(skipped) > 0 {
+		slog.InfoContext(ctx, "skipping blank pages", "pages", skipped)
+	}
+	if len(pages) == 0 {
+		base.SetExitStatus(base.SBadInput)
+		return ippsrv.ErrBlankDocument
+	}
+	if pageNumbers && len(pages) > 1 {
+		pages = numberPages(pages, prn.Width())
+	}
+	return printPages(ctx, prn, pages)
+}
+
+// numberPages adds the page headers to the pages.  The pages are fitted to
+// the printer width first, so that the headers are not scaled with them.
+func numberPages(pages []image.Image, width int) []image.Image {
+	policy := cmp.Or(fit, ippsrv.FitWidth)
+	if fit == "" && cfg.Crop {
+		policy = ippsrv.FitCrop
+	}
+	numbered := make([]image.Image, 0, len(pages))
+	for i, pg := range pages {
+		strips := ippsrv.FitPages([]image.Image{pg}, width, policy)
+		strips[0] = bitmap.AddPageHeader(strips[0], i+1, len(pages))
+		numbered = append(numbered, strips...)
+	}
+	return numbered
+}
 
-	return prn.PrintImage(ctx, img)
+// printPages applies the fit policy, if it is set, to the pages and prints
+// them one after another.
+func printPages(ctx context.Context, prn thermoprint.Printer, pages []image.Image) error {
+	if fit != "" {
+		pages = ippsrv.FitPages(pages, prn.Width(), fit)
+	}
+	if len(pages) == 1 {
+		return prn.PrintImage(ctx, pages[0])
+	}
+	for i, pg := range pages {
+		slog.InfoContext(ctx, "printing page", "page", i+1, "of", len(pages))
+		if err := prn.PrintImage(ctx, pg); err != nil {
+			return fmt.Errorf("page %d: %w", i+1, err)
+		}
+	}
+	return nil
 }
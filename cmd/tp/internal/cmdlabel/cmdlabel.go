@@ -0,0 +1,184 @@
+// Package cmdlabel provides the label printing subcommand.
+package cmdlabel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rusq/thermoprint/cmd/tp/internal/bootstrap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
+	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+	"github.com/rusq/thermoprint/label"
+)
+
+var CmdLabel = &base.Command{
+	Run:        runLabel,
+	UsageLine:  "tp label [flags] {-f <label.json or - for stdin> | -preset <name> field=value...}",
+	Short:      "prints labels described in JSON",
+	FlagMask:   cfg.OmitCommonImageFlags,
+	PrintFlags: true,
+	Long: `
+Prints the labels, i.e. the asset tags, described in the JSON file, without
+writing the document script.  The file holds one label, or an array of them:
+
+    {
+      "size": {"width_mm": 48, "height_mm": 30},
+      "title": "Laptop 17",
+      "subtitle": "IT department, room 4.12",
+      "icon": "warning",
+      "qr": "https://inventory.example.com/asset/17",
+      "barcode": {"type": "code128", "data": "ASSET-0017", "height_mm": 8}
+    }
+
+The title and the subtitle are printed between the icon on the left and the
+QR code on the right, the barcode is printed below them.  All keys are
+optional.  Without the size, the label is the printer wide and fits the
+content.  The barcode type is code128 (default), ean13 or code39.
+
+The built-in presets, i.e. the address label, the jar label, or the shelf
+price tag, take the values of their fields as arguments instead of the
+file:
+
+    tp label -preset jar contents="Strawberry jam" note="best before 06/27"
+    tp label -preset price product="Coffee 500g" price='$7.99' ean=400638133393
+
+"tp label -presets" lists the presets with their fields.  The optional
+fields may be omitted, "\n" in the value is the line break.
+
+-n prints each label several times, "tp label -icons" lists the icons.
+`,
+}
+
+var (
+	file        string
+	copies      int
+	listIcons   bool
+	preset      string
+	listPresets bool
+)
+
+func init() {
+	CmdLabel.Flag.StringVar(&file, "f", "", "label JSON `file`, - for stdin")
+	CmdLabel.Flag.IntVar(&copies, "n", 1, "`number` of copies of each label")
+	CmdLabel.Flag.BoolVar(&listIcons, "icons", false, "list the built-in icons")
+	CmdLabel.Flag.StringVar(&preset, "preset", "", "built-in label preset `name`")
+	CmdLabel.Flag.BoolVar(&listPresets, "presets", false, "list the built-in label presets")
+}
+
+func runLabel(ctx context.Context, cmd *base.Command, args []string) error {
+	if listIcons {
+		fmt.Println(strings.Join(label.Icons(), "\n"))
+		return nil
+	}
+	if listPresets {
+		return listLabelPresets(os.Stdout)
+	}
+	if copies < 1 {
+		base.SetExitStatus(base.SInvalidParameters)
+		return errors.New("number of copies must be positive")
+	}
+	var (
+		labels []label.Label
+		err    error
+	)
+	switch {
+	case file != "" && preset != "":
+		base.SetExitStatus(base.SInvalidParameters)
+		return errors.New("-f and -preset are mutually exclusive")
+	case preset != "":
+		labels, err = presetLabel(preset, args)
+	case len(args) > 0:
+		base.SetExitStatus(base.SInvalidParameters)
+		return fmt.Errorf("unexpected arguments: %v", args)
+	case file != "":
+		labels, err = loadLabels(file)
+	default:
+		base.SetExitStatus(base.SInvalidParameters)
+		return errors.New("expected the label file, -f, or the preset, -preset")
+	}
+	if err != nil {
+		base.SetExitStatus(base.SBadInput)
+		return err
+	}
+
+	prn, err := bootstrap.Printer(ctx)
+	if err != nil {
+		return err
+	}
+	for i, l := range labels {
+		img, err := l.Render(prn.Width(), prn.DPI())
+		if err != nil {
+			base.SetExitStatus(base.SBadInput)
+			return fmt.Errorf("label %d: %w", i+1, err)
+		}
+		for n := range copies {
+			slog.InfoContext(ctx, "printing label", "label", i+1, "of", len(labels), "copy", n+1)
+			if err := prn.PrintImage(ctx, img); err != nil {
+				return fmt.Errorf("label %d: %w", i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+func loadLabels(filename string) ([]label.Label, error) {
+	var r io.Reader = os.Stdin
+	if filename != "-" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return label.Load(r)
+}
+
+// presetLabel fills the preset with the field=value arguments.
+func presetLabel(name string, args []string) ([]label.Label, error) {
+	p, err := label.LoadPreset(name)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(args))
+	for _, arg := range args {
+		k, v, ok := strings.Cut(arg, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("expected field=value, got %q", arg)
+		}
+		values[k] = v
+	}
+	l, err := p.Fill(values, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return []label.Label{l}, nil
+}
+
+func listLabelPresets(w io.Writer) error {
+	for _, name := range label.Presets() {
+		p, err := label.LoadPreset(name)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s  %s\n", name, p.Description); err != nil {
+			return err
+		}
+		for _, f := range p.Fields {
+			desc := f.Description
+			if f.Default != nil {
+				desc += " (optional)"
+			}
+			if _, err := fmt.Fprintf(w, "    %-10s  %s\n", f.Name, desc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Package cmdnote provides the sticky note printing subcommand.
+package cmdnote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rusq/thermoprint/bitmap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/bootstrap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
+	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+)
+
+var CmdNote = &base.Command{
+	Run:        runNote,
+	UsageLine:  "tp note [flags] <text or - for stdin>",
+	Short:      "prints a sticky note",
+	FlagMask:   cfg.OmitCommonImageFlags,
+	PrintFlags: true,
+	Long: `
+Prints the text as a sticky note inside a decorative template with a border
+and the timestamp, i.e.:
+
+    tp note -title Shopping "milk, bread"
+
+The template is selected with -template, run "tp note -list" to see the
+available templates.
+`,
+}
+
+var (
+	template      string
+	title         string
+	noTime        bool
+	listTemplates bool
+)
+
+func init() {
+	CmdNote.Flag.StringVar(&template, "template", "plain", "note template `name`")
+	CmdNote.Flag.StringVar(&title, "title", "", "optional note `title`")
+	CmdNote.Flag.BoolVar(&noTime, "no-time", false, "omit the timestamp")
+	CmdNote.Flag.BoolVar(&listTemplates, "list", false, "list note templates")
+}
+
+func runNote(ctx context.Context, cmd *base.Command, args []string) error {
+	if listTemplates {
+		return listNoteTemplates(os.Stdout)
+	}
+	if len(args) == 0 {
+		base.SetExitStatus(base.SInvalidParameters)
+		return errors.New("expected the note text")
+	}
+	text := strings.Join(args, " ")
+	if text == "-" {
+		var buf bytes.Buffer
+		if _, err := buf.ReadFrom(os.Stdin); err != nil {
+			base.SetExitStatus(base.SBadInput)
+			return fmt.Errorf("failed to read text from stdin: %w", err)
+		}
+		text = strings.TrimRight(buf.String(), "\n")
+	}
+	tmpl, err := bitmap.LoadNoteTemplate(template)
+	if err != nil {
+		base.SetExitStatus(base.SInvalidParameters)
+		return err
+	}
+	if noTime {
+		tmpl.Timestamp = ""
+	}
+
+	prn, err := bootstrap.Printer(ctx)
+	if err != nil {
+		return err
+	}
+	img, err := tmpl.Render(prn.Width(), bitmap.Note{Title: title, Text: text, Time: time.Now()})
+	if err != nil {
+		return err
+	}
+	return prn.PrintImage(ctx, img)
+}
+
+func listNoteTemplates(w io.Writer) error {
+	for _, name := range bitmap.NoteTemplates() {
+		tmpl, err := bitmap.LoadNoteTemplate(name)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%10s  %s\n", name, tmpl.Description); err != nil {
+			return err
+		}
+	}
+	return nil
+}
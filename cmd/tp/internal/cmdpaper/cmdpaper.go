@@ -0,0 +1,79 @@
+// Package cmdpaper provides the notepaper printing subcommand.
+package cmdpaper
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/rusq/thermoprint"
+	"github.com/rusq/thermoprint/cmd/tp/internal/bootstrap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
+	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+)
+
+var CmdPaper = &base.Command{
+	Run:        runPaper,
+	UsageLine:  "tp paper [flags]",
+	Short:      "prints ruled, grid or dotted notepaper",
+	FlagMask:   cfg.OmitCommonImageFlags,
+	PrintFlags: true,
+	Long: `
+Prints a section of notepaper, i.e.:
+
+    tp paper -style grid -length 150mm
+
+Lengths are in millimetres, unless followed by the unit: mm, cm, in or px.
+`,
+}
+
+// maxLength is the longest section that can be printed at once.
+const maxLength = "1000mm"
+
+var (
+	style   string
+	length  string
+	spacing string
+)
+
+func init() {
+	CmdPaper.Flag.StringVar(&style, "style", "ruled", fmt.Sprintf("paper `style`, one of: %v", styles()))
+	CmdPaper.Flag.StringVar(&length, "length", "100mm", "section `length`")
+	CmdPaper.Flag.StringVar(&spacing, "spacing", "5mm", "distance between the lines or dots")
+}
+
+func styles() []string {
+	return slices.Sorted(maps.Keys(thermoprint.NotepaperStyles))
+}
+
+func runPaper(ctx context.Context, cmd *base.Command, args []string) error {
+	drawFn, ok := thermoprint.NotepaperStyles[style]
+	if !ok {
+		base.SetExitStatus(base.SInvalidParameters)
+		return fmt.Errorf("unknown paper style %q, expected one of: %v", style, styles())
+	}
+	// the lengths are validated before connecting to the printer, the
+	// resolution is the same for all supported printers.
+	dpi := float64(thermoprint.LXD02Rasteriser.DPI())
+	lengthDots, err := thermoprint.ParseLength(length, dpi)
+	if err != nil {
+		base.SetExitStatus(base.SInvalidParameters)
+		return fmt.Errorf("-length: %w", err)
+	}
+	if maxDots, _ := thermoprint.ParseLength(maxLength, dpi); lengthDots > maxDots {
+		base.SetExitStatus(base.SInvalidParameters)
+		return fmt.Errorf("-length: %s is longer than %s", length, maxLength)
+	}
+	spacingDots, err := thermoprint.ParseLength(spacing, dpi)
+	if err != nil {
+		base.SetExitStatus(base.SInvalidParameters)
+		return fmt.Errorf("-spacing: %w", err)
+	}
+
+	prn, err := bootstrap.Printer(ctx)
+	if err != nil {
+		return err
+	}
+	return prn.PrintImage(ctx, drawFn(prn.Width(), lengthDots, spacingDots))
+}
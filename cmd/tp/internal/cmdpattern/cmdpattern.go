@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math/rand/v2"
 	"os"
 	"slices"
 
@@ -21,13 +23,21 @@ var CmdPattern = &base.Command{
 	PrintFlags: true,
 	Long: `
 Prints a test pattern.
+
+Generative patterns (Maze, Life, Landscape) are produced from a seed, the
+same seed always gives the same picture.  If the seed is not set, a random
+one is used, and logged, so that the print can be repeated.
 `,
 }
 
-var ListPatterns bool
+var (
+	ListPatterns bool
+	Seed         uint64
+)
 
 func init() {
 	CmdPattern.Flag.BoolVar(&ListPatterns, "list", false, "list patterns")
+	CmdPattern.Flag.Uint64Var(&Seed, "seed", 0, "seed for generative patterns, 0 picks a random one")
 }
 
 func runPattern(ctx context.Context, cmd *base.Command, args []string) error {
@@ -41,14 +51,33 @@ func runPattern(ctx context.Context, cmd *base.Command, args []string) error {
 		}
 		return errors.New("expected pattern name")
 	}
+	if !isPattern(args[0]) {
+		base.SetExitStatus(base.SInvalidParameters)
+		return fmt.Errorf("unknown test pattern: %s", args[0])
+	}
 
 	prn, err := bootstrap.Printer(ctx)
 	if err != nil {
 		return err
 	}
+	if _, ok := thermoprint.GenerativePatterns[args[0]]; ok {
+		seed := Seed
+		if seed == 0 {
+			seed = rand.Uint64()
+		}
+		slog.Info("generating pattern", "pattern", args[0], "seed", seed)
+		return prn.PrintGenerativePattern(ctx, args[0], seed)
+	}
 	return prn.PrintPattern(ctx, args[0])
 }
 
+func isPattern(name string) bool {
+	_, isImage := thermoprint.TestImagePatterns[name]
+	_, isBuffer := thermoprint.TestBufferPatterns[name]
+	_, isGenerative := thermoprint.GenerativePatterns[name]
+	return isImage || isBuffer || isGenerative
+}
+
 func listPatterns(w io.Writer) error {
 	var names []string
 	for name := range thermoprint.TestImagePatterns {
@@ -57,6 +86,9 @@ func listPatterns(w io.Writer) error {
 	for bufname := range thermoprint.TestBufferPatterns {
 		names = append(names, bufname)
 	}
+	for genname := range thermoprint.GenerativePatterns {
+		names = append(names, genname)
+	}
 	slices.Sort(names)
 	_, err := fmt.Fprintf(w, "Available test patterns: %v\n", names)
 	return err
@@ -0,0 +1,73 @@
+// Package cmdqr provides the QR code printing subcommand.
+package cmdqr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rusq/thermoprint"
+	"github.com/rusq/thermoprint/bitmap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/bootstrap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
+	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+)
+
+var CmdQR = &base.Command{
+	Run:        runQR,
+	UsageLine:  "tp qr [flags] <text>",
+	Short:      "prints a QR code",
+	FlagMask:   cfg.OmitCommonImageFlags,
+	PrintFlags: true,
+	Long: `
+Prints the QR code of the text, scaled to the printer width, i.e.:
+
+    tp qr https://example.com
+
+Phones join the WiFi network by scanning the code of the network details:
+
+    tp qr "WIFI:T:WPA;S:MyNetwork;P:secret;;"
+
+-size makes the code smaller, the size is in millimetres, unless followed by
+the unit: mm, cm, in or px.
+`,
+}
+
+var size string
+
+func init() {
+	CmdQR.Flag.StringVar(&size, "size", "", "code `size`, the printer width if empty")
+}
+
+func runQR(ctx context.Context, cmd *base.Command, args []string) error {
+	text := strings.Join(args, " ")
+	if text == "" {
+		base.SetExitStatus(base.SInvalidParameters)
+		return errors.New("expected the text to encode")
+	}
+	// the size is validated before connecting to the printer, the
+	// resolution is the same for all supported printers.
+	var sizeDots int
+	if size != "" {
+		var err error
+		if sizeDots, err = thermoprint.ParseLength(size, float64(thermoprint.LXD02Rasteriser.DPI())); err != nil || sizeDots == 0 {
+			base.SetExitStatus(base.SInvalidParameters)
+			return fmt.Errorf("-size: invalid size %q", size)
+		}
+	}
+
+	prn, err := bootstrap.Printer(ctx)
+	if err != nil {
+		return err
+	}
+	if sizeDots == 0 || sizeDots > prn.Width() {
+		sizeDots = prn.Width()
+	}
+	c := bitmap.NewComposer(prn.Width())
+	if err := c.AppendQR(text, sizeDots); err != nil {
+		base.SetExitStatus(base.SBadInput)
+		return err
+	}
+	return prn.PrintImage(ctx, c.Image())
+}
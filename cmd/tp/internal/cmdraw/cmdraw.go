@@ -0,0 +1,97 @@
+// Package cmdraw provides the raw subcommand, that sends the pre-built
+// packets to the printer.
+package cmdraw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/rusq/thermoprint"
+	"github.com/rusq/thermoprint/cmd/tp/internal/bootstrap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
+	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+	"github.com/rusq/thermoprint/ippsrv"
+)
+
+var CmdRaw = &base.Command{
+	Run:        runRaw,
+	UsageLine:  "tp raw [flags] <file or ->",
+	Short:      "sends the raw printer packets",
+	FlagMask:   cfg.OmitCommonImageFlags,
+	PrintFlags: true,
+	Long: `
+Sends the pre-built printer packets, i.e. captured from the vendor app, to
+the printer as they are, without the conversion, for the protocol-level
+experiments:
+
+    tp raw capture.bin
+
+The file holds either the image packets, as they are sent to the printer,
+with the packet prefixes and terminators, that are checked, or the raster
+lines, that fill the packets, one bit per dot, the most significant bit is
+the leftmost dot.  The data, that are neither, are rejected before they are
+sent.  The file must not exceed 1 MiB.
+
+The print server accepts the same data on POST /api/v1/printers/{name}/raw.
+Only the LX-D02 printers support the raw data, the dry run is not supported.
+`,
+}
+
+func runRaw(ctx context.Context, cmd *base.Command, args []string) error {
+	if len(args) != 1 {
+		base.SetExitStatus(base.SInvalidParameters)
+		return errors.New("expected one file name, or - for the standard input")
+	}
+	data, err := readRaw(args[0], ippsrv.MaxRawSize)
+	if err != nil {
+		base.SetExitStatus(base.SInvalidParameters)
+		return err
+	}
+
+	prn, err := bootstrap.Printer(ctx)
+	if err != nil {
+		return err
+	}
+	rp, ok := prn.(thermoprint.RawPrinter)
+	if !ok {
+		base.SetExitStatus(base.SInvalidParameters)
+		return fmt.Errorf("printer %s does not support raw data", prn.Address())
+	}
+	packets, err := rp.SplitRAW(data)
+	if err != nil {
+		base.SetExitStatus(base.SInvalidParameters)
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	slog.Info("sending raw data", "bytes", len(data), "packets", len(packets))
+	if err := rp.PrintRAW(ctx, packets); err != nil {
+		base.SetExitStatus(base.SApplicationError)
+		return err
+	}
+	return nil
+}
+
+// readRaw reads the file, or the standard input, if it is "-", that must
+// not exceed the limit.
+func readRaw(name string, limit int64) ([]byte, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s: raw data exceeds %d bytes", name, limit)
+	}
+	return data, nil
+}
@@ -0,0 +1,241 @@
+// Package cmdremote provides the remote and print subcommands, that find the
+// tp servers on the network and print on them.
+package cmdremote
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
+	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+	"github.com/rusq/thermoprint/ippsrv"
+)
+
+// defaultBrowse is the time the network is browsed for the servers.
+const defaultBrowse = 3 * time.Second
+
+var CmdRemote = &base.Command{
+	Run:        runRemote,
+	UsageLine:  "tp remote [flags] [list]",
+	Short:      "lists the tp servers on the network",
+	FlagMask:   cfg.OmitAll,
+	PrintFlags: true,
+	Long: `
+Browses the local network with Bonjour/DNS-SD for the printers served by
+"tp server" on other machines, and lists them with the name, the address
+and whether they require the authentication, i.e.:
+
+    tp remote list -t 5s
+
+The servers are found, unless they are started with -no-mdns, or listen on
+the loopback address.  Print on them with "tp print -remote".
+`,
+}
+
+var CmdPrint = &base.Command{
+	Run:        runPrint,
+	UsageLine:  "tp print [flags] -remote <name> <file or -> ...",
+	Short:      "prints the files on the tp server on the network",
+	FlagMask:   cfg.OmitAll,
+	PrintFlags: true,
+	Long: `
+Submits the files to the printer of "tp server" on another machine, so that
+the machine without Bluetooth prints on the printer connected to the other
+one.  The documents are converted by the server, any format, that it
+prints, i.e. PDF, the images and the plain text, may be submitted:
+
+    tp print -remote kitchen receipt.pdf
+
+The -remote printer is found on the network with Bonjour/DNS-SD by the name,
+that "tp remote list" shows, by the host name of the server, or by
+host/printer, the case is ignored.  The host:port of the server, that is not
+advertised, may be given instead, its printer is "default".
+
+If the server requires the authentication, give the password with -user
+user:password, or in the TP_REMOTE_PASSWORD environment variable.  The
+password is sent over TLS only: to the server started with -tls, that is
+found on the network, or given with -tls.  The self-signed certificate of
+the server is accepted with -insecure.  -allow-http sends the password in
+the clear to the server without TLS.
+`,
+}
+
+var (
+	browse   time.Duration
+	asJSON   bool
+	remote   string
+	userFlag string
+	jobName  string
+
+	useTLS    bool
+	insecure  bool
+	allowHTTP bool
+)
+
+func init() {
+	CmdRemote.Flag.DurationVar(&browse, "t", defaultBrowse, "browse `duration`")
+	CmdRemote.Flag.BoolVar(&asJSON, "json", false, "print the printers as JSON")
+
+	CmdPrint.Flag.StringVar(&remote, "remote", "", "`name` of the remote printer, see tp remote list, or host:port of the server")
+	CmdPrint.Flag.DurationVar(&browse, "t", defaultBrowse, "time to look for the remote printer on the network")
+	CmdPrint.Flag.StringVar(&userFlag, "user", "", "`user` of the job, or user:password of the authentication (default the current user)")
+	CmdPrint.Flag.StringVar(&jobName, "name", "", "job `name`; if not specified, the file name is used")
+	CmdPrint.Flag.BoolVar(&useTLS, "tls", false, "connect to the server with TLS, if it is given as host:port")
+	CmdPrint.Flag.BoolVar(&insecure, "insecure", false, "accept the self-signed certificate of the server")
+	CmdPrint.Flag.BoolVar(&allowHTTP, "allow-http", false, "allow to send the password to the server without TLS, in the clear")
+}
+
+func runRemote(ctx context.Context, cmd *base.Command, args []string) error {
+	if len(args) > 0 && args[0] == "list" {
+		// the flags may follow list.
+		if err := cmd.Flag.Parse(args[1:]); err != nil {
+			base.SetExitStatus(base.SInvalidParameters)
+			return err
+		}
+		args = cmd.Flag.Args()
+	}
+	if len(args) > 0 {
+		base.SetExitStatus(base.SInvalidParameters)
+		return fmt.Errorf("unexpected arguments: %v", args)
+	}
+	if browse <= 0 {
+		base.SetExitStatus(base.SInvalidParameters)
+		return fmt.Errorf("-t: duration must be positive, got %s", browse)
+	}
+	ctx, cancel := context.WithTimeout(ctx, browse)
+	defer cancel()
+	var printers []ippsrv.RemotePrinter
+	if err := ippsrv.Discover(ctx, func(rp ippsrv.RemotePrinter) {
+		printers = append(printers, rp)
+	}); err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(os.Stdout, printers)
+	}
+	if len(printers) == 0 {
+		base.SetExitStatus(base.SDeviceNotFound)
+		return fmt.Errorf("no tp servers found on the network in %s", browse)
+	}
+	return printPrinters(os.Stdout, printers)
+}
+
+func printPrinters(w io.Writer, printers []ippsrv.RemotePrinter) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tHOST\tPRINTER\tURL\tAUTH")
+	for _, rp := range printers {
+		auth := "-"
+		if rp.Auth {
+			auth = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", rp.Name, strings.TrimSuffix(rp.Host, "."), rp.Printer, rp.URL(), auth)
+	}
+	return tw.Flush()
+}
+
+// jsonPrinter is the remote printer in the output with -json.
+type jsonPrinter struct {
+	Name         string `json:"name"`
+	Host         string `json:"host"`
+	Printer      string `json:"printer"`
+	MakeAndModel string `json:"make_and_model"`
+	URL          string `json:"url"`
+	UUID         string `json:"uuid"`
+	Auth         bool   `json:"auth"`
+	TLS          bool   `json:"tls"`
+}
+
+func printJSON(w io.Writer, printers []ippsrv.RemotePrinter) error {
+	out := make([]jsonPrinter, 0, len(printers))
+	for _, rp := range printers {
+		out = append(out, jsonPrinter{Name: rp.Name, Host: strings.TrimSuffix(rp.Host, "."), Printer: rp.Printer, MakeAndModel: rp.MakeAndModel, URL: rp.URL(), UUID: rp.UUID, Auth: rp.Auth, TLS: rp.TLS})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func runPrint(ctx context.Context, cmd *base.Command, args []string) error {
+	if remote == "" {
+		base.SetExitStatus(base.SInvalidParameters)
+		return errors.New("-remote is required, use tp image to print on the connected printer")
+	}
+	if len(args) == 0 {
+		base.SetExitStatus(base.SInvalidParameters)
+		return errors.New("no files to print")
+	}
+	rp, err := findRemote(ctx, remote)
+	if err != nil {
+		base.SetExitStatus(base.SDeviceNotFound)
+		return err
+	}
+	if useTLS {
+		rp.TLS = true
+	}
+	user, password := jobUser(userFlag)
+	for _, file := range args {
+		data, err := readFile(file)
+		if err != nil {
+			base.SetExitStatus(base.SInvalidParameters)
+			return err
+		}
+		filename := file
+		if file == "-" {
+			filename = "stdin"
+		}
+		job, err := rp.Submit(ctx, ippsrv.RemoteDocument{Filename: filename, Data: data, Name: jobName, User: user, Password: password, Insecure: insecure, AllowPlainHTTP: allowHTTP})
+		if err != nil {
+			base.SetExitStatus(base.SApplicationError)
+			return err
+		}
+		fmt.Printf("%s: job %d submitted to %s (%s)\n", file, job.ID, rp.Name, job.State)
+	}
+	return nil
+}
+
+// findRemote returns the remote printer by the name, or by the address of
+// the server, that is not looked up.
+func findRemote(ctx context.Context, name string) (ippsrv.RemotePrinter, error) {
+	if host, port, err := net.SplitHostPort(name); err == nil {
+		n, err := strconv.Atoi(port)
+		if err != nil || host == "" {
+			return ippsrv.RemotePrinter{}, fmt.Errorf("-remote: invalid address %q", name)
+		}
+		return ippsrv.RemotePrinter{Name: name, Host: host, Port: n, Printer: "default"}, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, cmp.Or(browse, defaultBrowse))
+	defer cancel()
+	return ippsrv.FindRemote(ctx, name)
+}
+
+// jobUser returns the user and the password of -user, the password may be
+// given in TP_REMOTE_PASSWORD too.
+func jobUser(s string) (name, password string) {
+	name, password, _ = strings.Cut(s, ":")
+	if name == "" {
+		if u, err := user.Current(); err == nil {
+			name = filepath.Base(u.Username) // DOMAIN\user on Windows
+		}
+	}
+	return name, cmp.Or(password, os.Getenv("TP_REMOTE_PASSWORD"))
+}
+
+// readFile reads the file, or the standard input, if it is "-".
+func readFile(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name)
+}
@@ -0,0 +1,129 @@
+// Package cmdscan provides the scan subcommand.
+package cmdscan
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rusq/thermoprint"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
+	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+)
+
+var CmdScan = &base.Command{
+	Run:        runScan,
+	UsageLine:  "tp scan [flags]",
+	Short:      "lists the printers nearby",
+	FlagMask:   cfg.OmitAll,
+	PrintFlags: true,
+	Long: `
+Scans for the thermal printers and lists them with the address, the signal
+strength and the guessed model, strongest signal first, i.e.:
+
+    tp scan -t 10s
+
+The printers are recognised by the name or by the advertised Bluetooth
+services.  To list all named devices, use -all, to list only the devices
+with the given name, use -p:
+
+    tp scan -p M02
+
+Pass the address of the chosen printer to other commands with -mac, or save
+it with -save, giving the number of the printer in the list or its address:
+
+    tp scan -save 1
+
+The saved printer is used by other commands, unless another printer is given
+with -p or -mac.
+`,
+}
+
+var (
+	duration time.Duration
+	name     string
+	all      bool
+	save     string
+)
+
+func init() {
+	CmdScan.Flag.DurationVar(&duration, "t", thermoprint.DefaultScanDuration, "scan `duration`")
+	CmdScan.Flag.StringVar(&name, "p", "", "list only the devices with this `name`")
+	CmdScan.Flag.BoolVar(&all, "all", false, "list all named devices, not only the printers")
+	CmdScan.Flag.StringVar(&save, "save", "", "save the printer with this `number` in the list, or address, for use by other commands")
+}
+
+func runScan(ctx context.Context, cmd *base.Command, args []string) error {
+	if duration <= 0 {
+		base.SetExitStatus(base.SInvalidParameters)
+		return fmt.Errorf("-t: duration must be positive, got %s", duration)
+	}
+	if err := cfg.Adapter().Enable(); err != nil {
+		return fmt.Errorf("failed to enable Bluetooth adapter: %w", err)
+	}
+	var (
+		candidates []thermoprint.Candidate
+		err        error
+	)
+	if name != "" || all {
+		candidates, err = thermoprint.Scan(ctx, cfg.Adapter(), thermoprint.SearchParameters{Name: name}, duration)
+	} else {
+		candidates, err = thermoprint.Discover(ctx, cfg.Adapter(), duration)
+	}
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		base.SetExitStatus(base.SDeviceNotFound)
+		return fmt.Errorf("%w: no devices found in %s", thermoprint.ErrDeviceNotFound, duration)
+	}
+	if err := printCandidates(os.Stdout, candidates); err != nil {
+		return err
+	}
+	if save == "" {
+		return nil
+	}
+	c, err := choose(candidates, save)
+	if err != nil {
+		base.SetExitStatus(base.SInvalidParameters)
+		return err
+	}
+	kd := cfg.KnownDevice{Name: c.Name, Address: c.MACAddress, LastSeen: time.Now(), Chosen: true}
+	if err := cfg.SaveKnownDevice(kd); err != nil {
+		return fmt.Errorf("failed to save the printer: %w", err)
+	}
+	fmt.Printf("saved %s (%s)\n", c.Name, c.MACAddress)
+	return nil
+}
+
+func printCandidates(w io.Writer, candidates []thermoprint.Candidate) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tNAME\tADDRESS\tRSSI\tMODEL")
+	for i, c := range candidates {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d dBm\t%s\n", i+1, c.Name, c.MACAddress, c.RSSI, cmp.Or(c.Model, "-"))
+	}
+	return tw.Flush()
+}
+
+// choose returns the candidate by the number in the list, starting from 1,
+// or by the address.
+func choose(candidates []thermoprint.Candidate, s string) (thermoprint.Candidate, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 || len(candidates) < n {
+			return thermoprint.Candidate{}, fmt.Errorf("-save: no printer number %d in the list", n)
+		}
+		return candidates[n-1], nil
+	}
+	for _, c := range candidates {
+		if strings.EqualFold(c.MACAddress, s) {
+			return c, nil
+		}
+	}
+	return thermoprint.Candidate{}, fmt.Errorf("-save: no printer with the address %s in the list", s)
+}
@@ -6,19 +6,26 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
+	"maps"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/rusq/thermoprint"
 	"github.com/rusq/thermoprint/cmd/tp/internal/bootstrap"
 	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
 	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+	"github.com/rusq/thermoprint/fontmgr"
 	"github.com/rusq/thermoprint/ippsrv"
 	"golang.org/x/term"
 )
 
 var CmdServer = &base.Command{
 	Run:        runServer,
-	UsageLine:  "tp server [flags]",
+	UsageLine:  "tp server [flags] | tp server install [-service manager] [-o file] [flags]",
 	Short:      "start the IPP server",
 	PrintFlags: true,
 	Long: `
@@ -28,6 +35,48 @@ By default the printer is advertised on the local network via Bonjour/DNS-SD,
 so it appears in e.g. macOS "Printers & Scanners" -> Add Printer.  For the
 advertisement to work, the server must listen on a non-loopback address:
 binding to a loopback address (e.g. -addr localhost:6310) disables it.
+
+With -virtual, the server does not connect to the printer, instead the print
+jobs are rasterised and saved as PNG files to the -outdir directory.  This
+allows to test the AirPrint/CUPS integration on a machine without Bluetooth.
+
+Phones resubmit the job, if the printer is slow to respond, and it is
+printed twice.  With -dedup-window, the job with the same document as the
+job submitted to the printer within the window is skipped, the client gets
+the original job, or, with -dedup warn, printed with the warning in the log:
+
+    tp server -dedup-window 30s
+
+If the printer is offline, the jobs wait for it, and are printed once it
+is back, which may be days later.  With -job-ttl, the jobs that wait longer
+than that are aborted instead:
+
+    tp server -job-ttl 1h
+
+On the shared printer, -job-footer prints the small footer with the user,
+the host the job came from, the job id and the time at the end of every
+job.  Set job_footer in the printer profile of the configuration file to
+enable it for that printer only.
+
+On the shared network, -htpasswd or -auth-user protect the admin page with
+the Basic authentication, and, with -auth all, the printing too; combine it
+with -tls, so that the passwords are not sent in the clear:
+
+    tp server -tls -htpasswd /etc/tp.htpasswd -auth all
+
+With -daemon, the server runs under the service manager: it has no
+dashboard, notifies systemd, that it is ready (Type=notify), and, on SIGTERM,
+stops accepting the new jobs and prints the queued ones for -drain-timeout
+before it shuts down.  "tp server install" writes the systemd unit, or the
+launchd plist on macOS, that runs "tp server -daemon" with the flags given
+after install:
+
+    tp server install -spool /var/spool/tp > ~/.config/systemd/user/tp.service
+
+GET /healthz responds with 200, while the printer is reachable, and with
+503 otherwise; the probe is cached for -health-ttl.  With -require-printer,
+the server does not start without the printer, and exits with the error,
+once the probe fails, so that the service manager restarts it.
 `,
 }
 
@@ -36,6 +85,30 @@ var (
 	protoDumpDir string
 	noMDNS       bool
 	noTUI        bool
+	virtual      bool
+	outDir       string
+	fit          = ippsrv.FitWidth
+	blank        float64
+	pageNumbers  bool
+	pdfText      bool
+	jobFooter    bool
+	jobTTL       time.Duration
+	spoolDir     string
+	spoolNaming  = ippsrv.DefaultSpoolNaming
+	maxQueue     int
+	useTLS       bool
+	tlsCert      string
+	tlsKey       string
+	htpasswd     string
+	authUsers    = make(ippsrv.Users)
+	authScope    = ippsrv.AuthAdmin
+	qualities    = make(ippsrv.QualityProfiles)
+	dedupWindow  time.Duration
+	dedupMode    = ippsrv.DedupSkip
+	daemon       bool
+	drainTimeout time.Duration
+	requirePrn   bool
+	healthTTL    time.Duration
 )
 
 func init() {
@@ -55,19 +128,197 @@ func init() {
 		"no-tui",
 		false,
 		"disable the interactive dashboard and keep plain log output")
+	CmdServer.Flag.BoolVar(&virtual,
+		"virtual",
+		false,
+		"use the virtual printer that saves printouts to files, no Bluetooth required")
+	CmdServer.Flag.StringVar(&outDir,
+		"outdir",
+		"printouts",
+		"output `directory` for the virtual printer printouts")
+	CmdServer.Flag.Func("fit",
+		fmt.Sprintf("default `policy` for the pages wider than the printer, one of: %s", strings.Join(ippsrv.Fits(), ", ")),
+		setFit)
+	CmdServer.Flag.Float64Var(&blank,
+		"blank-threshold",
+		ippsrv.DefaultBlankThreshold,
+		"share of the dark pixels, below which the document page is blank and not printed, 0 prints all pages")
+	CmdServer.Flag.BoolVar(&pageNumbers,
+		"page-numbers",
+		false,
+		"print the page number headers between the pages of multi-page documents")
+	CmdServer.Flag.BoolVar(&pdfText,
+		"pdf-text",
+		false,
+		"reprint the text of PDF documents with the built-in font instead of rasterising the pages")
+	CmdServer.Flag.BoolVar(&jobFooter,
+		"job-footer",
+		false,
+		"print the footer with the user, host, job id and time at the end of every job")
+	CmdServer.Flag.DurationVar(&jobTTL,
+		"job-ttl",
+		0,
+		"abort the jobs that wait for the printer longer than the `duration`, i.e. 1h; 0 keeps them until printed")
+	CmdServer.Flag.StringVar(&spoolDir,
+		"spool",
+		"",
+		"spool `directory`, that keeps the job ids and the jobs across the restarts; if not specified, a temporary directory is used")
+	CmdServer.Flag.Func("spool-naming",
+		fmt.Sprintf("file name `pattern` of the spooled documents with {id}, {printer}, {time}, {doc} and {ext} (default %q)", ippsrv.DefaultSpoolNaming),
+		setSpoolNaming)
+	CmdServer.Flag.IntVar(&maxQueue,
+		"max-queue",
+		0,
+		"refuse the new jobs with server-error-busy while the printer has this `number` of unfinished jobs; 0 is unlimited")
+	CmdServer.Flag.BoolVar(&useTLS,
+		"tls",
+		false,
+		"serve IPPS (IPP over TLS) next to the plain IPP on the same address, the self-signed certificate is generated on the first run")
+	CmdServer.Flag.StringVar(&tlsCert,
+		"tls-cert",
+		"",
+		"certificate `file` (PEM) for -tls; if not specified, ipps.crt in the configuration directory is used")
+	CmdServer.Flag.StringVar(&tlsKey,
+		"tls-key",
+		"",
+		"private key `file` (PEM) for -tls; if not specified, ipps.key in the configuration directory is used")
+	CmdServer.Flag.StringVar(&htpasswd,
+		"htpasswd",
+		"",
+		"htpasswd `file` with the users of the Basic authentication, the MD5 (htpasswd -m) and SHA-1 (-s) hashes are supported")
+	CmdServer.Flag.Func("auth-user",
+		"`user:password` of the Basic authentication, may be repeated",
+		authUsers.ParseUser)
+	CmdServer.Flag.Func("auth",
+		fmt.Sprintf("`scope` of the Basic authentication, one of: %s (default %s); admin protects the admin page and the API, all protects the printing too", strings.Join(ippsrv.AuthScopes(), ", "), ippsrv.AuthAdmin),
+		setAuthScope)
+	CmdServer.Flag.Func("quality",
+		"print-quality `profiles`, the settings of draft, normal and high quality jobs separated with semicolons, i.e. draft:energy=1,dither=threshold;high:energy=4,interval=12ms",
+		setQualities)
+	CmdServer.Flag.DurationVar(&dedupWindow,
+		"dedup-window",
+		0,
+		"skip the jobs identical to the job submitted within the `duration`, i.e. 30s; 0 disables")
+	CmdServer.Flag.Func("dedup",
+		fmt.Sprintf("`mode` of the duplicate jobs within -dedup-window, one of: %s (default %s)", strings.Join(ippsrv.DedupModes(), ", "), ippsrv.DedupSkip),
+		setDedup)
+	CmdServer.Flag.BoolVar(&daemon,
+		"daemon",
+		false,
+		"run under the service manager: no dashboard, sd_notify readiness, and the queued jobs are printed on SIGTERM before the shutdown")
+	CmdServer.Flag.DurationVar(&drainTimeout,
+		"drain-timeout",
+		time.Minute,
+		"with -daemon, wait for the queued jobs to print on SIGTERM for at most the `duration`; 0 shuts down at once")
+	CmdServer.Flag.BoolVar(&requirePrn,
+		"require-printer",
+		false,
+		"exit with the error, if the printer is not reachable at the start, or stops responding, see -health-ttl")
+	CmdServer.Flag.DurationVar(&healthTTL,
+		"health-ttl",
+		ippsrv.DefaultHealthTTL,
+		"probe the printer for /healthz and -require-printer at most once per `duration`")
+}
+
+func setDedup(s string) (err error) {
+	dedupMode, err = ippsrv.ParseDedupMode(s)
+	return err
+}
+
+func setAuthScope(s string) (err error) {
+	authScope, err = ippsrv.ParseAuthScope(s)
+	return err
+}
+
+func setQualities(s string) error {
+	pp, err := ippsrv.ParseQualityProfiles(s)
+	if err != nil {
+		return err
+	}
+	maps.Copy(qualities, pp)
+	return nil
+}
+
+func setSpoolNaming(s string) (err error) {
+	spoolNaming, err = ippsrv.ParseSpoolNaming(s)
+	return err
+}
+
+func setFit(s string) (err error) {
+	fit, err = ippsrv.ParseFit(s)
+	return err
+}
+
+// normalQuality returns the profiles with the packet interval of the normal
+// quality set to -d, so that it is restored after the jobs of the other
+// quality.
+func normalQuality(pp ippsrv.QualityProfiles) ippsrv.QualityProfiles {
+	pp = maps.Clone(pp)
+	normal := pp[ippsrv.QualityNormal]
+	if normal.Interval == 0 {
+		normal.Interval = cfg.PrintDelay
+		pp[ippsrv.QualityNormal] = normal
+	}
+	return pp
+}
+
+// serverPrinter is the printer driver served by the IPP server.
+type serverPrinter interface {
+	ippsrv.Driver
+	physicalSnapshotter
+}
+
+// newPrinter returns the virtual printer, if -virtual flag is set, or the
+// connected physical printer otherwise.
+func newPrinter(ctx context.Context) (serverPrinter, string, error) {
+	if virtual {
+		post, err := cfg.PostProcessors()
+		if err != nil {
+			base.SetExitStatus(base.SInvalidParameters)
+			return nil, "", err
+		}
+		vp, err := thermoprint.NewVirtualPrinter(outDir,
+			thermoprint.WithCrop(cfg.Crop),
+			thermoprint.WithDither(cfg.Dither),
+			thermoprint.WithGamma(cfg.Gamma),
+			thermoprint.WithAutoDither(cfg.AutoDither),
+			thermoprint.WithChannel(cfg.Channel),
+			thermoprint.WithPostProcessors(post...),
+		)
+		if err != nil {
+			return nil, "", err
+		}
+		slog.InfoContext(ctx, "using virtual printer", "outdir", outDir)
+		return vp, "LX-D02 Thermal Printer (virtual)", nil
+	}
+	p, err := bootstrap.Printer(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	if thermoprint.IsCatPrinter(cfg.SearchParams.Name) || thermoprint.IsPhomemo(cfg.SearchParams.Name) {
+		return p, strings.ToUpper(cfg.SearchParams.Name) + " Thermal Printer", nil
+	}
+	return p, "LX-D02 Thermal Printer", nil
 }
 
 func runServer(ctx context.Context, cmd *base.Command, args []string) error {
+	if len(args) > 0 && args[0] == "install" {
+		return runInstall(ctx, cmd, args[1:])
+	}
 	if len(args) > 0 {
 		base.SetExitStatus(base.SInvalidParameters)
 		return fmt.Errorf("unexpected arguments: %v", args)
 	}
-	p, err := bootstrap.Printer(ctx)
+	p, fullname, err := newPrinter(ctx)
 	if err != nil {
 		base.SetExitStatus(base.SApplicationError)
 		return fmt.Errorf("failed to get printer: %w", err)
 	}
-	ippPrn, err := ippsrv.WrapDriver(p, "default", "LX-D02 Thermal Printer")
+	prnOpts := []ippsrv.PrinterOption{ippsrv.WithFit(fit), ippsrv.WithBlankThreshold(blank), ippsrv.WithPageNumbers(pageNumbers), ippsrv.WithJobFooter(jobFooter), ippsrv.WithEnergy(uint8(cfg.PrintEnergy())), ippsrv.WithDither(cfg.Dither), ippsrv.WithChannel(cfg.Channel), ippsrv.WithQualityProfiles(normalQuality(qualities))}
+	if pdfText {
+		prnOpts = append(prnOpts, ippsrv.WithFilter(ippsrv.NewTextFilter(p.Width(), fontmgr.DefaultFont, ippsrv.NewFilter())))
+	}
+	ippPrn, err := ippsrv.WrapDriver(p, "default", fullname, prnOpts...)
 	if err != nil {
 		base.SetExitStatus(base.SApplicationError)
 		return fmt.Errorf("failed to wrap printer: %w", err)
@@ -75,21 +326,69 @@ func runServer(ctx context.Context, cmd *base.Command, args []string) error {
 	var opts = []ippsrv.Option{
 		ippsrv.WithDebug(cfg.Verbose),
 		ippsrv.WithDumpDir(protoDumpDir),
+		ippsrv.WithDedup(dedupWindow, dedupMode),
+		ippsrv.WithJobTTL(jobTTL),
+		ippsrv.WithSpoolDir(spoolDir),
+		ippsrv.WithSpoolNaming(spoolNaming),
+		ippsrv.WithMaxQueue(maxQueue),
+		ippsrv.WithHealthTTL(healthTTL),
 	}
 	if !noMDNS {
 		opts = append(opts, ippsrv.WithBonjour())
 	}
+	if daemon {
+		opts = append(opts, ippsrv.WithReady(notifyReady))
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, syscall.SIGTERM)
+		defer stop()
+	}
+	if htpasswd != "" {
+		users, err := ippsrv.ReadHtpasswd(htpasswd)
+		if err != nil {
+			base.SetExitStatus(base.SInvalidParameters)
+			return fmt.Errorf("failed to read the users: %w", err)
+		}
+		maps.Copy(authUsers, users)
+	}
+	if len(authUsers) > 0 {
+		opts = append(opts, ippsrv.WithBasicAuth(authUsers, authScope))
+	} else if authScope == ippsrv.AuthAll {
+		base.SetExitStatus(base.SInvalidParameters)
+		return errors.New("-auth all requires the users, see -htpasswd and -auth-user")
+	}
+	if useTLS {
+		cert, key, err := cfg.TLSFilenames(tlsCert, tlsKey)
+		if err != nil {
+			base.SetExitStatus(base.SApplicationError)
+			return err
+		}
+		opts = append(opts, ippsrv.WithTLS(cert, key))
+	}
 	s, err := ippsrv.New(ippPrn, opts...)
 	if err != nil {
 		base.SetExitStatus(base.SApplicationError)
 		return err
 	}
 	cfg.RegisterSigInfoReporter(s.Info)
-	runCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
+	if requirePrn {
+		if err := s.Healthy(ctx); err != nil {
+			base.SetExitStatus(base.SApplicationError)
+			return err
+		}
+	}
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	if requirePrn {
+		go watchPrinter(runCtx, s, healthTTL, cancel)
+	}
 
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
 		<-runCtx.Done()
+		if daemon && !errors.Is(context.Cause(runCtx), errPrinterGone) {
+			drain(s, drainTimeout)
+		}
 		if err := s.Shutdown(context.Background()); err != nil {
 			slog.Error("error shutting down server", "err", err)
 		} else {
@@ -97,7 +396,7 @@ func runServer(ctx context.Context, cmd *base.Command, args []string) error {
 		}
 	}()
 
-	if useTUI := shouldUseTUI(noTUI, os.Stdout.Fd(), os.Stderr.Fd()); useTUI {
+	if useTUI := shouldUseTUI(noTUI || daemon, os.Stdout.Fd(), os.Stderr.Fd()); useTUI {
 		logs := newLogBuffer(400)
 		installTUILogger(logs, cfg.LogFile != "")
 		if cfg.LogFile == "" {
@@ -112,12 +411,12 @@ func runServer(ctx context.Context, cmd *base.Command, args []string) error {
 			base.SetExitStatus(base.SApplicationError)
 			return err
 		}
-		cancel()
+		cancel(nil)
 		if err := serverResult.wait(); err != nil {
 			base.SetExitStatus(base.SApplicationError)
 			return err
 		}
-		return nil
+		return printerGone(runCtx)
 	}
 
 	slog.Info("starting server", "addr", addr)
@@ -125,7 +424,9 @@ func runServer(ctx context.Context, cmd *base.Command, args []string) error {
 		base.SetExitStatus(base.SApplicationError)
 		return err
 	}
-	return nil
+	// the server is closed by the shutdown, wait for the spool to close.
+	<-stopped
+	return printerGone(runCtx)
 }
 
 func listenAndServe(s *ippsrv.Server, addr string) error {
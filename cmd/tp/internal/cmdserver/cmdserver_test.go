@@ -1,8 +1,15 @@
 package cmdserver
 
 import (
+	"context"
 	"errors"
+	"flag"
+	"image"
+	"io"
 	"log/slog"
+	"net"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -11,6 +18,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/muesli/termenv"
 
+	"github.com/rusq/thermoprint"
 	"github.com/rusq/thermoprint/ippsrv"
 )
 
@@ -201,3 +209,150 @@ func TestServerResult(t *testing.T) {
 		}
 	})
 }
+
+func TestSdNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if err := sdNotify("READY=1"); err != nil {
+		t.Fatalf("sdNotify without the socket: %v", err)
+	}
+
+	socket := filepath.Join(t.TempDir(), "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram sockets are not supported: %v", err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", socket)
+	if err := sdNotify("READY=1"); err != nil {
+		t.Fatalf("sdNotify: %v", err)
+	}
+	buf := make([]byte, 64)
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if got := string(buf[:n]); got != "READY=1" {
+		t.Fatalf("notification = %q, want READY=1", got)
+	}
+}
+
+func TestSystemdQuote(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{arg: "-addr=:631", want: "-addr=:631"},
+		{arg: "-spool=/var/spool/my tp", want: `"-spool=/var/spool/my tp"`},
+		{arg: `-auth-user=a:"b\c`, want: `"-auth-user=a:\"b\\c"`},
+		{arg: "-spool-naming={id}%$HOME.{ext}", want: "-spool-naming={id}%%$$HOME.{ext}"},
+		{arg: "", want: `""`},
+	}
+	for _, tt := range tests {
+		if got := systemdQuote(tt.arg); got != tt.want {
+			t.Errorf("systemdQuote(%q) = %s, want %s", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestInstallFlags(t *testing.T) {
+	server := flag.NewFlagSet("server", flag.ContinueOnError)
+	var (
+		addr, daemon, tls = server.String("addr", ":6310", ""), server.Bool("daemon", false, ""), server.Bool("tls", false, "")
+		users             []string
+	)
+	server.Func("auth-user", "", func(s string) error { users = append(users, s); return nil })
+
+	service, output := serviceSystemd, ""
+	var args []string
+	fs := installFlags(server, &service, &output, &args)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse([]string{"-service", "launchd", "-addr", ":631", "-tls", "-auth-user", "a:1", "-auth-user", "b:2"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"-addr=:631", "-tls=true", "-auth-user=a:1", "-auth-user=b:2"}; !slices.Equal(args, want) {
+		t.Fatalf("recorded args = %q, want %q", args, want)
+	}
+	if service != serviceLaunchd || *addr != ":631" || !*tls || len(users) != 2 {
+		t.Fatalf("service = %s, addr = %s, tls = %t, users = %v, the flags are not set", service, *addr, *tls, users)
+	}
+	if err := fs.Parse([]string{"-daemon"}); err == nil || *daemon {
+		t.Fatalf("-daemon is accepted, want the error, the service always runs with it")
+	}
+}
+
+func TestServiceTemplate(t *testing.T) {
+	sc := serviceConfig{
+		Args:        []string{"/usr/bin/tp", "server", "-daemon", "-spool=/srv/my tp"},
+		StopTimeout: 90 * time.Second,
+		LogFile:     "/Users/me/Library/Logs/thermoprint.log",
+	}
+	tests := []struct {
+		service string
+		want    []string
+	}{
+		{service: serviceSystemd, want: []string{"Type=notify\n", `ExecStart=/usr/bin/tp server -daemon "-spool=/srv/my tp"`, "TimeoutStopSec=90\n"}},
+		{service: serviceLaunchd, want: []string{"<string>-spool=/srv/my tp</string>", "<key>ExitTimeOut</key>\n\t<integer>90</integer>", "<string>/Users/me/Library/Logs/thermoprint.log</string>"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			tmpl, err := serviceTemplate(tt.service)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sb strings.Builder
+			if err := tmpl.Execute(&sb, sc); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(sb.String(), want) {
+					t.Errorf("service does not contain %q:\n%s", want, sb.String())
+				}
+			}
+		})
+	}
+	if _, err := serviceTemplate("upstart"); err == nil {
+		t.Fatal("serviceTemplate(upstart) error = nil, want the unknown service manager")
+	}
+}
+
+// goneDriver is the printer driver, that is disconnected.
+type goneDriver struct{}
+
+func (goneDriver) SetOptions(...thermoprint.Option) error        { return nil }
+func (goneDriver) PrintImage(context.Context, image.Image) error { return nil }
+func (goneDriver) DPI() float64                                  { return 203 }
+func (goneDriver) Width() int                                    { return 384 }
+func (goneDriver) Snapshot() thermoprint.PrinterSnapshot         { return thermoprint.PrinterSnapshot{} }
+
+func TestWatchPrinter(t *testing.T) {
+	p, err := ippsrv.WrapDriver(goneDriver{}, "default", "Gone Printer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := ippsrv.New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	go watchPrinter(ctx, s, time.Millisecond, cancel)
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the gone printer is not detected")
+	}
+	if err := printerGone(ctx); !errors.Is(err, errPrinterGone) {
+		t.Fatalf("printerGone() = %v, want %v", err, errPrinterGone)
+	}
+
+	ctx, cancel = context.WithCancelCause(context.Background())
+	cancel(nil)
+	if err := printerGone(ctx); err != nil {
+		t.Fatalf("printerGone() of the shutdown = %v, want nil", err)
+	}
+}
@@ -0,0 +1,102 @@
+package cmdserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"time"
+
+	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+	"github.com/rusq/thermoprint/ippsrv"
+)
+
+// sdNotify sends the state to the service manager, i.e. "READY=1", see
+// sd_notify(3).  It does nothing, unless the server is started by systemd
+// with Type=notify, that sets $NOTIFY_SOCKET.
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:] // the abstract socket
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("sd_notify: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("sd_notify: %w", err)
+	}
+	return nil
+}
+
+// notifyService sends the state to the service manager, the errors are
+// logged, the server runs without the notifications.
+func notifyService(state string) {
+	if err := sdNotify(state); err != nil {
+		slog.Warn("failed to notify the service manager", "state", state, "error", err)
+	}
+}
+
+// notifyReady notifies the service manager, that the server listens on the
+// address.
+func notifyReady(addr net.Addr) {
+	slog.Info("server is ready", "addr", addr)
+	notifyService("READY=1\nSTATUS=listening on " + addr.String())
+}
+
+// drain stops accepting the new jobs, and waits for the queued ones to
+// print, the timeout at most.
+func drain(s *ippsrv.Server, timeout time.Duration) {
+	notifyService("STOPPING=1\nSTATUS=draining the job queue")
+	if timeout <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	slog.Info("draining the job queue", "jobs", s.ActiveJobs(), "timeout", timeout)
+	if err := s.Drain(ctx); err != nil {
+		slog.Warn("shutting down with the unfinished jobs", "jobs", s.ActiveJobs(), "error", err)
+	}
+}
+
+// errPrinterGone is the cause of the shutdown, once the printer stops
+// responding with -require-printer.
+var errPrinterGone = errors.New("printer is gone")
+
+// watchPrinter probes the printer every interval, and cancels the context
+// with errPrinterGone, once it is not reachable.
+func watchPrinter(ctx context.Context, s *ippsrv.Server, interval time.Duration, cancel context.CancelCauseFunc) {
+	if interval <= 0 {
+		interval = ippsrv.DefaultHealthTTL
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.Healthy(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "printer is not reachable, shutting down", "error", err)
+			cancel(fmt.Errorf("%w: %w", errPrinterGone, err))
+			return
+		}
+	}
+}
+
+// printerGone returns the error, if the server was shut down, because the
+// printer is gone.
+func printerGone(ctx context.Context) error {
+	if err := context.Cause(ctx); errors.Is(err, errPrinterGone) {
+		base.SetExitStatus(base.SApplicationError)
+		return err
+	}
+	return nil
+}
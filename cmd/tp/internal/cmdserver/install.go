@@ -0,0 +1,221 @@
+package cmdserver
+
+import (
+	"context"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
+	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+)
+
+// Service managers, that tp server install generates the service for.
+const (
+	serviceSystemd = "systemd"
+	serviceLaunchd = "launchd"
+)
+
+// stopMargin is the time the service manager waits for the server to shut
+// down after -drain-timeout, before it kills it.
+const stopMargin = 30 * time.Second
+
+// launchdLabel is the label of the launchd job.
+const launchdLabel = "com.github.rusq.thermoprint"
+
+// serviceConfig is the data of the service templates.
+type serviceConfig struct {
+	Args        []string      // the command line, the executable first
+	StopTimeout time.Duration // the time the server may take to shut down
+	LogFile     string        // the log file, launchd only, may be empty
+}
+
+var serviceFuncs = template.FuncMap{
+	"systemdArgs": func(args []string) string {
+		quoted := make([]string, len(args))
+		for i, a := range args {
+			quoted[i] = systemdQuote(a)
+		}
+		return strings.Join(quoted, " ")
+	},
+	"xml": func(s string) (string, error) {
+		var sb strings.Builder
+		err := xml.EscapeText(&sb, []byte(s))
+		return sb.String(), err
+	},
+	"seconds": func(d time.Duration) int { return int(d.Round(time.Second).Seconds()) },
+}
+
+var systemdUnit = template.Must(template.New(serviceSystemd).Funcs(serviceFuncs).Parse(`[Unit]
+Description=Thermoprint IPP server
+Documentation=https://github.com/rusq/thermoprint
+
+[Service]
+Type=notify
+ExecStart={{ systemdArgs .Args }}
+Restart=on-failure
+TimeoutStopSec={{ seconds .StopTimeout }}
+
+[Install]
+WantedBy=default.target
+`))
+
+var launchdPlist = template.Must(template.New(serviceLaunchd).Funcs(serviceFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + launchdLabel + `</string>
+	<key>ProgramArguments</key>
+	<array>
+{{- range .Args }}
+		<string>{{ xml . }}</string>
+{{- end }}
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>ExitTimeOut</key>
+	<integer>{{ seconds .StopTimeout }}</integer>
+{{- with .LogFile }}
+	<key>StandardOutPath</key>
+	<string>{{ xml . }}</string>
+	<key>StandardErrorPath</key>
+	<string>{{ xml . }}</string>
+{{- end }}
+</dict>
+</plist>
+`))
+
+// systemdQuote quotes the argument of ExecStart, see "Command lines" in
+// systemd.service(5).
+func systemdQuote(s string) string {
+	s = strings.NewReplacer("%", "%%", "$", "$$").Replace(s)
+	if s != "" && !strings.ContainsAny(s, " \t\n\"'\\;") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`).Replace(s) + `"`
+}
+
+// defaultService returns the service manager of the operating system.
+func defaultService() string {
+	if runtime.GOOS == "darwin" {
+		return serviceLaunchd
+	}
+	return serviceSystemd
+}
+
+// serviceTemplate returns the template of the service file of the service
+// manager.
+func serviceTemplate(service string) (*template.Template, error) {
+	switch service {
+	case serviceSystemd:
+		return systemdUnit, nil
+	case serviceLaunchd:
+		return launchdPlist, nil
+	}
+	return nil, fmt.Errorf("unknown service manager %q, must be one of: %s, %s", service, serviceSystemd, serviceLaunchd)
+}
+
+// installFlags returns the flags of tp server install: its own ones, and
+// the server flags, that are recorded to args in the order they are given,
+// so that the service starts the server with them.
+func installFlags(server *flag.FlagSet, service, output *string, args *[]string) *flag.FlagSet {
+	fs := flag.NewFlagSet("server install", flag.ContinueOnError)
+	fs.StringVar(service, "service", *service, fmt.Sprintf("service `manager`, one of: %s, %s", serviceSystemd, serviceLaunchd))
+	fs.StringVar(output, "o", "", "output `file`; if not specified, the service is written to stdout")
+	server.VisitAll(func(f *flag.Flag) {
+		if f.Name == "daemon" || f.Name == "no-tui" || fs.Lookup(f.Name) != nil {
+			return // the service always runs with -daemon, that has no dashboard.
+		}
+		record := func(s string) error {
+			if err := f.Value.Set(s); err != nil {
+				return err
+			}
+			*args = append(*args, "-"+f.Name+"="+s)
+			return nil
+		}
+		if bf, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && bf.IsBoolFlag() {
+			fs.BoolFunc(f.Name, f.Usage, record)
+		} else {
+			fs.Func(f.Name, f.Usage, record)
+		}
+	})
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: tp server install [-service manager] [-o file] [server flags]\n\n")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// runInstall generates the service, that runs tp server -daemon with the
+// server flags given after install.
+func runInstall(ctx context.Context, cmd *base.Command, args []string) error {
+	var (
+		service    = defaultService()
+		output     string
+		serverArgs []string
+	)
+	fs := installFlags(&cmd.Flag, &service, &output, &serverArgs)
+	if err := fs.Parse(args); err != nil {
+		base.SetExitStatus(base.SInvalidParameters)
+		return err
+	}
+	if fs.NArg() > 0 {
+		base.SetExitStatus(base.SInvalidParameters)
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	tmpl, err := serviceTemplate(service)
+	if err != nil {
+		base.SetExitStatus(base.SInvalidParameters)
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		base.SetExitStatus(base.SApplicationError)
+		return fmt.Errorf("failed to locate the executable: %w", err)
+	}
+	sc := serviceConfig{
+		Args:        []string{exe},
+		StopTimeout: drainTimeout + stopMargin,
+	}
+	if cfg.ProfileName != "" {
+		sc.Args = append(sc.Args, "-profile", cfg.ProfileName)
+	}
+	sc.Args = append(sc.Args, "server", "-daemon")
+	sc.Args = append(sc.Args, serverArgs...)
+	if home, err := os.UserHomeDir(); err == nil && service == serviceLaunchd {
+		sc.LogFile = filepath.Join(home, "Library", "Logs", "thermoprint.log")
+	}
+
+	if output == "" {
+		return tmpl.Execute(os.Stdout, sc)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		base.SetExitStatus(base.SApplicationError)
+		return err
+	}
+	if err := tmpl.Execute(f, sc); err != nil {
+		f.Close()
+		base.SetExitStatus(base.SApplicationError)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		base.SetExitStatus(base.SApplicationError)
+		return err
+	}
+	slog.InfoContext(ctx, "service written", "service", service, "file", output)
+	return nil
+}
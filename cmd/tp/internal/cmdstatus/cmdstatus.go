@@ -0,0 +1,195 @@
+// Package cmdstatus provides the status subcommand.
+package cmdstatus
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rusq/thermoprint"
+	"github.com/rusq/thermoprint/cmd/tp/internal/bootstrap"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
+	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
+)
+
+var CmdStatus = &base.Command{
+	Run:        runStatus,
+	UsageLine:  "tp status [flags]",
+	Short:      "shows the printer status",
+	FlagMask:   cfg.OmitCommonImageFlags,
+	PrintFlags: true,
+	Long: `
+Shows the printer status: connects to the printer and shows the battery
+level, paper and charging state it reports, the last connected printer and
+the estimated length of paper left on the roll.  With -offline, the printer
+is not connected to.  With -json, the status is printed as JSON.
+
+Paper tracking is enabled by resetting the roll counter, do this every time
+a new roll is loaded:
+
+    tp status -reset-roll
+
+The roll length can be set with -roll-length, and the threshold below which
+the paper is reported as low with -low-mark.  When the roll runs low, the
+printer reports "media-low-warning" in IPP printer-state-reasons.
+`,
+}
+
+var (
+	resetRoll  bool
+	rollLength float64
+	lowMark    float64
+	offline    bool
+	asJSON     bool
+)
+
+func init() {
+	CmdStatus.Flag.BoolVar(&resetRoll, "reset-roll", false, "reset the paper roll counter after loading a new roll")
+	CmdStatus.Flag.Float64Var(&rollLength, "roll-length", thermoprint.DefaultRollLength, "length of a new paper roll, `mm`")
+	CmdStatus.Flag.Float64Var(&lowMark, "low-mark", 0, "report the paper as low when less than `mm` left (default 500)")
+	CmdStatus.Flag.BoolVar(&offline, "offline", false, "do not connect to the printer")
+	CmdStatus.Flag.BoolVar(&asJSON, "json", false, "print the status as JSON")
+}
+
+func runStatus(ctx context.Context, cmd *base.Command, args []string) error {
+	filename, err := cfg.RollFilename()
+	if err != nil {
+		return err
+	}
+	var rc *thermoprint.RollCounter
+	if resetRoll {
+		rc, err = thermoprint.NewRollCounter(filename, rollLength, lowMark)
+		if err != nil {
+			base.SetExitStatus(base.SInvalidParameters)
+			return err
+		}
+	} else {
+		rc, err = thermoprint.LoadRollCounter(filename)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	var st *thermoprint.Status
+	if !offline && !cfg.DryRun {
+		if st, err = queryPrinter(ctx); err != nil {
+			return err
+		}
+	}
+	kd, err := cfg.LoadKnownDevice()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if asJSON {
+		return printJSON(os.Stdout, kd, rc, st)
+	}
+	if err := printDevice(os.Stdout, kd); err != nil {
+		return err
+	}
+	if err := printPrinterStatus(os.Stdout, st); err != nil {
+		return err
+	}
+	return printStatus(os.Stdout, rc)
+}
+
+// queryPrinter connects to the printer and requests the status.  Drivers
+// that can't request the status report the last status that the printer
+// sent.
+func queryPrinter(ctx context.Context) (*thermoprint.Status, error) {
+	prn, err := bootstrap.Printer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if q, ok := prn.(thermoprint.StatusQuerier); ok {
+		st, err := q.Status(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &st, nil
+	}
+	snap := prn.Snapshot()
+	return &thermoprint.Status{
+		BatteryLevel: snap.BatteryLevel,
+		NoPaper:      snap.NoPaper,
+		Charging:     snap.Charging,
+		Charged:      snap.Charged,
+	}, nil
+}
+
+// jsonStatus is the output of the status command with -json.
+type jsonStatus struct {
+	Printer *cfg.KnownDevice    `json:"printer,omitempty"`
+	Status  *thermoprint.Status `json:"status,omitempty"`
+	Roll    *jsonRoll           `json:"roll,omitempty"`
+}
+
+type jsonRoll struct {
+	Length    float64   `json:"length_mm"`
+	Remaining float64   `json:"remaining_mm"`
+	Low       bool      `json:"low"`
+	LoadedAt  time.Time `json:"loaded_at"`
+}
+
+func printJSON(w io.Writer, kd cfg.KnownDevice, rc *thermoprint.RollCounter, st *thermoprint.Status) error {
+	out := jsonStatus{Status: st}
+	if kd.Address != "" {
+		out.Printer = &kd
+	}
+	if rc != nil {
+		rs := rc.State()
+		out.Roll = &jsonRoll{Length: rs.Length, Remaining: rs.Remaining, Low: rs.Low(), LoadedAt: rs.LoadedAt}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func printDevice(w io.Writer, kd cfg.KnownDevice) error {
+	if kd.Address == "" {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "Printer: %s, address %s, last connected %s\n",
+		kd.Name, kd.Address, kd.LastSeen.Format("2006-01-02 15:04"))
+	return err
+}
+
+func printPrinterStatus(w io.Writer, st *thermoprint.Status) error {
+	if st == nil {
+		return nil
+	}
+	state := []string{fmt.Sprintf("battery %d%%", st.BatteryLevel)}
+	switch {
+	case st.Charging:
+		state = append(state, "charging")
+	case st.Charged:
+		state = append(state, "charged")
+	}
+	if st.NoPaper {
+		state = append(state, "NO PAPER")
+	} else {
+		state = append(state, "paper loaded")
+	}
+	state = append(state, "firmware "+cmp.Or(st.Firmware, "unknown"))
+	_, err := fmt.Fprintf(w, "Status: %s\n", strings.Join(state, ", "))
+	return err
+}
+
+func printStatus(w io.Writer, rc *thermoprint.RollCounter) error {
+	if rc == nil {
+		_, err := fmt.Fprintln(w, "Paper: not tracked, run \"tp status -reset-roll\" after loading a new roll")
+		return err
+	}
+	st := rc.State()
+	state := "ok"
+	if st.Low() {
+		state = "LOW"
+	}
+	_, err := fmt.Fprintf(w, "Paper: %s, ~%.0fmm of %.0fmm left (%.0f%%), roll loaded %s\n",
+		state, st.Remaining, st.Length, st.Remaining/st.Length*100, st.LoadedAt.Format("2006-01-02 15:04"))
+	return err
+}
@@ -12,6 +12,7 @@ import (
 	"golang.org/x/image/font"
 
 	"github.com/rusq/thermoprint"
+	"github.com/rusq/thermoprint/bitmap"
 	"github.com/rusq/thermoprint/cmd/tp/internal/bootstrap"
 	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
 	"github.com/rusq/thermoprint/fontmgr"
@@ -24,6 +25,17 @@ var CmdText = &base.Command{
 	PrintFlags: true,
 	Long: `
 Prints the text from the specified file or from stdin if '-' is used.
+
+The lines that don't fit the paper are wrapped on the word boundaries, unless
+-wrap=false, then they are cut off.  -hyphenate breaks the long words at the
+end of the line between the syllables, so that the lines are filled more
+evenly, the soft hyphens (U+00AD) in the text are used in any case.
+
+With -markdown, the text is interpreted as markdown: "#" headings are
+printed large and bold, "**bold**" text in bold, "-" and "1." lists are
+indented, and "---" is printed as the horizontal rule.  The bold text uses
+the bold variant of the built-in font, i.e. toshiba-bold for toshiba, if
+there is one, otherwise it is double-struck.
 `,
 }
 
@@ -33,6 +45,10 @@ var (
 	ListFonts   bool
 	TTFFontSize float64
 	TTFDPI      float64
+	Markdown    bool
+	Wrap        bool
+	Hyphenate   bool
+	LineSpacing int
 )
 
 func init() {
@@ -41,6 +57,10 @@ func init() {
 	CmdText.Flag.BoolVar(&ListFonts, "list-fonts", false, "lists built-in fonts")
 	CmdText.Flag.Float64Var(&TTFFontSize, "font-size", 5.0, "font size in `pt` for true-type fonts")
 	CmdText.Flag.Float64Var(&TTFDPI, "dpi", float64(thermoprint.LXD02Rasteriser.Dpi), "DPI for TrueType fonts")
+	CmdText.Flag.BoolVar(&Wrap, "wrap", true, "wrap the lines that don't fit the paper")
+	CmdText.Flag.BoolVar(&Hyphenate, "hyphenate", false, "hyphenate the long words when wrapping")
+	CmdText.Flag.IntVar(&LineSpacing, "line-spacing", 0, "extra `pixels` between the lines")
+	CmdText.Flag.BoolVar(&Markdown, "markdown", false, "interpret the text as markdown: headings, bold, lists and rules")
 }
 
 func runText(ctx context.Context, cmd *base.Command, args []string) error {
@@ -54,31 +74,43 @@ func runText(ctx context.Context, cmd *base.Command, args []string) error {
 
 	file := args[0]
 
-	var face font.Face
+	var face, bold font.Face
 	if FontFile != "" {
 		fc, err := fontmgr.LoadFromFile(FontFile, TTFFontSize, TTFDPI)
 		if err != nil {
+			base.SetExitStatus(base.SBadInput)
 			return err
 		}
 		face = fc
 	} else {
 		fc, err := fontmgr.LoadByName(FontName)
 		if err != nil {
+			base.SetExitStatus(base.SBadInput)
 			return err
 		}
 		face = fc
+		if Markdown {
+			bf, err := fontmgr.LoadByName(FontName + "-bold")
+			if err != nil && !errors.Is(err, fontmgr.ErrNotFound) {
+				base.SetExitStatus(base.SBadInput)
+				return err
+			}
+			bold = bf
+		}
 	}
 	var text string
 	if file == "-" {
 		// Read text from stdin if "-" is specified
 		var buf bytes.Buffer
 		if _, err := buf.ReadFrom(os.Stdin); err != nil {
+			base.SetExitStatus(base.SBadInput)
 			return fmt.Errorf("failed to read text from stdin: %w", err)
 		}
 		text = buf.String()
 	} else {
 		data, err := os.ReadFile(file)
 		if err != nil {
+			base.SetExitStatus(base.SBadInput)
 			return fmt.Errorf("error reading file: %w", err)
 		}
 		text = string(data)
@@ -89,7 +121,15 @@ func runText(ctx context.Context, cmd *base.Command, args []string) error {
 		return err
 	}
 
-	return prn.PrintTextTTF(ctx, text, face)
+	if Markdown {
+		img, err := bitmap.RenderMarkdown(text, face, bold, prn.Width())
+		if err != nil {
+			base.SetExitStatus(base.SBadInput)
+			return err
+		}
+		return prn.PrintImage(ctx, img)
+	}
+	return prn.PrintTextTTF(ctx, text, face, bitmap.WithWrap(Wrap), bitmap.WithHyphenation(Hyphenate), bitmap.WithLineSpacing(LineSpacing))
 }
 
 func listFonts(w io.Writer) error {
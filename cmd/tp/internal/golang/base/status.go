@@ -1,15 +1,52 @@
 package base
 
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/rusq/thermoprint"
+)
+
 // StatusCode is the code returned to the OS.
 //
 //go:generate go tool stringer -type StatusCode -linecomment
 type StatusCode uint8
 
-// Status codes returned by the main executable.
+// Status codes returned by the main executable.  The codes that describe the
+// cause of the failure have greater values than the generic ones, so that
+// they take precedence in [SetExitStatus].
 const (
 	SNoError           StatusCode = iota // No Error
 	SGenericError                        // Generic Error
 	SHelpRequested                       // Help Requested
 	SInvalidParameters                   // Invalid Parameters
 	SApplicationError                    // Application Error
+	SBadInput                            // Bad Input
+	SDeviceNotFound                      // Device Not Found
+	SNoPaper                             // No Paper
+	STimeout                             // Timeout
+	SCancelled                           // Cancelled
 )
+
+// StatusFromError returns the status code for the error returned by the
+// command.  Errors without a distinct status code are reported as
+// SGenericError, so that they don't override the status set by the command.
+func StatusFromError(err error) StatusCode {
+	switch {
+	case err == nil:
+		return SNoError
+	case errors.Is(err, context.Canceled):
+		return SCancelled
+	case errors.Is(err, thermoprint.ErrTimeout),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, os.ErrDeadlineExceeded):
+		return STimeout
+	case errors.Is(err, thermoprint.ErrNoPaper):
+		return SNoPaper
+	case errors.Is(err, thermoprint.ErrDeviceNotFound):
+		return SDeviceNotFound
+	default:
+		return SGenericError
+	}
+}
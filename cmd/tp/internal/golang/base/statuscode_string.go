@@ -13,11 +13,16 @@ func _() {
 	_ = x[SHelpRequested-2]
 	_ = x[SInvalidParameters-3]
 	_ = x[SApplicationError-4]
+	_ = x[SBadInput-5]
+	_ = x[SDeviceNotFound-6]
+	_ = x[SNoPaper-7]
+	_ = x[STimeout-8]
+	_ = x[SCancelled-9]
 }
 
-const _StatusCode_name = "No ErrorGeneric ErrorHelp RequestedInvalid ParametersApplication Error"
+const _StatusCode_name = "No ErrorGeneric ErrorHelp RequestedInvalid ParametersApplication ErrorBad InputDevice Not FoundNo PaperTimeoutCancelled"
 
-var _StatusCode_index = [...]uint8{0, 8, 21, 35, 53, 70}
+var _StatusCode_index = [...]uint8{0, 8, 21, 35, 53, 70, 79, 95, 103, 110, 119}
 
 func (i StatusCode) String() string {
 	idx := int(i) - 0
@@ -12,13 +12,27 @@ import (
 	"strings"
 
 	"github.com/rusq/thermoprint/cmd/tp/internal/cfg"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cmdbarcode"
 	"github.com/rusq/thermoprint/cmd/tp/internal/cmdcompose"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cmdcontact"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cmdcsv"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cmddither"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cmdgui"
 	"github.com/rusq/thermoprint/cmd/tp/internal/cmdimage"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cmdlabel"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cmdnote"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cmdpaper"
 	"github.com/rusq/thermoprint/cmd/tp/internal/cmdpattern"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cmdqr"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cmdraw"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cmdremote"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cmdscan"
 	"github.com/rusq/thermoprint/cmd/tp/internal/cmdserver"
+	"github.com/rusq/thermoprint/cmd/tp/internal/cmdstatus"
 	"github.com/rusq/thermoprint/cmd/tp/internal/cmdtext"
 	"github.com/rusq/thermoprint/cmd/tp/internal/golang/base"
 	"github.com/rusq/thermoprint/cmd/tp/internal/golang/help"
+	"github.com/rusq/thermoprint/locale"
 )
 
 func init() {
@@ -27,13 +41,29 @@ func init() {
 		cmdimage.CmdImage,
 		cmdtext.CmdText,
 		cmdcompose.CmdCompose,
+		cmdcompose.CmdTemplate,
+		cmdcsv.CmdCSV,
 		cmdpattern.CmdPattern,
+		cmdraw.CmdRaw,
+		cmdnote.CmdNote,
+		cmdpaper.CmdPaper,
+		cmdqr.CmdQR,
+		cmdcontact.CmdContact,
+		cmdbarcode.CmdBarcode,
+		cmdlabel.CmdLabel,
+		cmddither.CmdDitherCompare,
 		cmdserver.CmdServer,
+		cmdremote.CmdRemote,
+		cmdremote.CmdPrint,
+		cmdstatus.CmdStatus,
+		cmdscan.CmdScan,
+		cmdgui.CmdGUI,
 	}
 }
 
 func main() {
 	flag.Usage = base.Usage
+	flag.StringVar(&cfg.ProfileName, "profile", cfg.ProfileName, "printer `profile` from the configuration file")
 	flag.Parse()
 
 	args := flag.Args()
@@ -73,6 +103,7 @@ BigCmdLoop:
 				continue
 			}
 			if err := invoke(cmd, args); err != nil {
+				base.SetExitStatus(base.StatusFromError(err))
 				msg := fmt.Sprintf("%03[1]d (%[1]s): %[2]s.", base.ExitStatus(), err)
 				slog.Error(msg)
 			}
@@ -107,6 +138,10 @@ func invoke(cmd *base.Command, args []string) error {
 		if err != nil {
 			return err
 		}
+		if err := locale.SetDefault(cfg.Lang); err != nil {
+			base.SetExitStatus(base.SInvalidParameters)
+			return err
+		}
 	}
 
 	// maybe start trace
@@ -137,6 +172,10 @@ func invoke(cmd *base.Command, args []string) error {
 func parseFlags(cmd *base.Command, args []string) ([]string, error) {
 	cfg.SetBaseFlags(&cmd.Flag, cmd.FlagMask)
 	cmd.Flag.Usage = func() { cmd.Usage() }
+	if err := cfg.ApplyConfig(&cmd.Flag, cmd.Name()); err != nil {
+		base.SetExitStatus(base.SInvalidParameters)
+		return nil, err
+	}
 	if err := cmd.Flag.Parse(args[1:]); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,208 @@
+//go:build js && wasm
+
+// Command tpweb is the WebAssembly build of thermoprint for the browser.  It
+// prints directly from the web page using Web Bluetooth, nothing has to be
+// installed on the phone or computer.
+//
+// It exports the "thermoprint" object to JavaScript, all functions return
+// promises:
+//
+//	thermoprint.connect({energy, dither, gamma})  // shows the device chooser
+//	thermoprint.preview(bytes, {dither, gamma})   // resolves to PNG bytes
+//	thermoprint.printImage(bytes)                 // PNG, JPEG or GIF bytes
+//	thermoprint.printText(text)
+//	thermoprint.disconnect()
+//
+// connect must be called from the user action handler, i.e. a button click,
+// as the browser does not allow Bluetooth requests otherwise.
+//
+// Build it with "make wasm" and serve the static directory over HTTPS or
+// from localhost, see README.
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"sync"
+	"syscall/js"
+
+	"github.com/rusq/thermoprint"
+	"github.com/rusq/thermoprint/bitmap"
+	"github.com/rusq/thermoprint/fontmgr"
+)
+
+var (
+	mu  sync.Mutex
+	prn *thermoprint.LXD02
+)
+
+func main() {
+	tp := js.Global().Get("Object").New()
+	tp.Set("connect", promiseFunc(connect))
+	tp.Set("preview", promiseFunc(preview))
+	tp.Set("printImage", promiseFunc(printImage))
+	tp.Set("printText", promiseFunc(printText))
+	tp.Set("disconnect", promiseFunc(disconnect))
+	js.Global().Set("thermoprint", tp)
+	select {} // keep the functions alive
+}
+
+// promiseFunc wraps fn into the JavaScript function that returns a promise.
+// fn runs in its own goroutine, as it must not block the event loop.
+func promiseFunc(fn func(ctx context.Context, args []js.Value) (any, error)) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) any {
+		executor := js.FuncOf(func(this js.Value, pargs []js.Value) any {
+			resolve, reject := pargs[0], pargs[1]
+			go func() {
+				v, err := fn(context.Background(), args)
+				if err != nil {
+					reject.Invoke(js.Global().Get("Error").New(err.Error()))
+					return
+				}
+				resolve.Invoke(v)
+			}()
+			return nil
+		})
+		defer executor.Release()
+		return js.Global().Get("Promise").New(executor)
+	})
+}
+
+// options are the print options passed from JavaScript.
+type options struct {
+	energy uint8
+	dither string
+	gamma  float64
+}
+
+func parseOptions(args []js.Value, n int) options {
+	opts := options{energy: 2, gamma: bitmap.DefaultGamma}
+	if len(args) <= n || args[n].Type() != js.TypeObject {
+		return opts
+	}
+	o := args[n]
+	if v := o.Get("energy"); v.Type() == js.TypeNumber {
+		opts.energy = uint8(v.Int())
+	}
+	if v := o.Get("dither"); v.Type() == js.TypeString {
+		opts.dither = v.String()
+	}
+	if v := o.Get("gamma"); v.Type() == js.TypeNumber {
+		opts.gamma = v.Float()
+	}
+	return opts
+}
+
+func connect(ctx context.Context, args []js.Value) (any, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if prn != nil {
+		if err := prn.Disconnect(); err != nil {
+			return nil, err
+		}
+		prn = nil
+	}
+	opts := parseOptions(args, 0)
+	p, err := thermoprint.NewLXD02(ctx, nil, thermoprint.SearchParameters{Name: "LX-D02"},
+		thermoprint.WithBackend(thermoprint.BackendWebBluetooth),
+		thermoprint.WithEnergy(opts.energy),
+		thermoprint.WithDither(opts.dither),
+		thermoprint.WithGamma(opts.gamma),
+	)
+	if err != nil {
+		return nil, err
+	}
+	prn = p
+	return p.Address(), nil
+}
+
+func printer() (*thermoprint.LXD02, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if prn == nil {
+		return nil, errors.New("printer is not connected")
+	}
+	return prn, nil
+}
+
+func disconnect(ctx context.Context, args []js.Value) (any, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if prn == nil {
+		return nil, nil
+	}
+	err := prn.Disconnect()
+	prn = nil
+	return nil, err
+}
+
+// decodeImage decodes the image from the Uint8Array argument.
+func decodeImage(args []js.Value) (image.Image, error) {
+	if len(args) == 0 || args[0].Get("length").IsUndefined() {
+		return nil, errors.New("expected image bytes")
+	}
+	data := make([]byte, args[0].Get("length").Int())
+	js.CopyBytesToGo(data, args[0])
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	return img, nil
+}
+
+// preview returns the PNG image as it would be printed.
+func preview(ctx context.Context, args []js.Value) (any, error) {
+	img, err := decodeImage(args)
+	if err != nil {
+		return nil, err
+	}
+	opts := parseOptions(args, 1)
+	r := *thermoprint.LXD02Rasteriser
+	if opts.dither != "" {
+		fn, ok := bitmap.DitherFunction(opts.dither)
+		if !ok {
+			return nil, fmt.Errorf("unknown dither function: %s", opts.dither)
+		}
+		r.SetDitherFunc(fn)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, r.ResizeAndDither(img, opts.gamma, false)); err != nil {
+		return nil, err
+	}
+	out := js.Global().Get("Uint8Array").New(buf.Len())
+	js.CopyBytesToJS(out, buf.Bytes())
+	return out, nil
+}
+
+func printImage(ctx context.Context, args []js.Value) (any, error) {
+	p, err := printer()
+	if err != nil {
+		return nil, err
+	}
+	img, err := decodeImage(args)
+	if err != nil {
+		return nil, err
+	}
+	return nil, p.PrintImage(ctx, img)
+}
+
+func printText(ctx context.Context, args []js.Value) (any, error) {
+	p, err := printer()
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 || args[0].Type() != js.TypeString {
+		return nil, errors.New("expected text")
+	}
+	face, err := fontmgr.LoadByName("toshiba")
+	if err != nil {
+		return nil, err
+	}
+	return nil, p.PrintTextTTF(ctx, args[0].String(), face)
+}
@@ -0,0 +1,127 @@
+// Package contact renders the contact cards: the vCard QR code, that the
+// phones add to the contacts when scanned, and the same details in text.
+package contact
+
+import (
+	"errors"
+	"image"
+	"strings"
+
+	"golang.org/x/image/font"
+
+	"github.com/rusq/thermoprint/bitmap"
+)
+
+// gap is the gap between the QR code and the text, in pixels.
+const gap = 8
+
+// Card is the contact.  Only the name is required.
+type Card struct {
+	Name    string
+	Org     string
+	Title   string
+	Tel     []string
+	Email   []string
+	URL     string
+	Address string
+	Note    string
+}
+
+// Validate checks that the card has the name.
+func (c Card) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return errors.New("contact name is required")
+	}
+	return nil
+}
+
+// VCard returns the vCard 3.0 of the contact.  The last word of the name is
+// the family name, the rest are the given names.
+func (c Card) VCard() string {
+	var sb strings.Builder
+	line := func(prop, value string) {
+		if value != "" {
+			sb.WriteString(prop + ":" + value + "\r\n")
+		}
+	}
+	name := strings.Fields(c.Name)
+	var family, given string
+	if len(name) > 0 {
+		family, given = name[len(name)-1], strings.Join(name[:len(name)-1], " ")
+	}
+	line("BEGIN", "VCARD")
+	line("VERSION", "3.0")
+	line("N", escape(family)+";"+escape(given)+";;;")
+	line("FN", escape(strings.Join(name, " ")))
+	line("ORG", escape(c.Org))
+	line("TITLE", escape(c.Title))
+	for _, tel := range c.Tel {
+		line("TEL;TYPE=VOICE", escape(tel))
+	}
+	for _, email := range c.Email {
+		line("EMAIL;TYPE=INTERNET", escape(email))
+	}
+	line("URL", escape(c.URL))
+	if c.Address != "" {
+		// the address is not split into the parts, it is the street.
+		line("ADR", ";;"+escape(c.Address)+";;;;")
+	}
+	line("NOTE", escape(c.Note))
+	line("END", "VCARD")
+	return sb.String()
+}
+
+// escape escapes the vCard text value.
+var escape = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`).Replace
+
+// Lines returns the details of the card for the text block, except the
+// name: the title and the organisation, the phones, the emails, the URL, the
+// address and the note.
+func (c Card) Lines() []string {
+	var lines []string
+	add := func(s ...string) {
+		for _, v := range s {
+			if v = strings.TrimSpace(v); v != "" {
+				lines = append(lines, v)
+			}
+		}
+	}
+	switch {
+	case c.Title != "" && c.Org != "":
+		add(c.Title + ", " + c.Org)
+	default:
+		add(c.Title, c.Org)
+	}
+	add(c.Tel...)
+	add(c.Email...)
+	add(c.URL, c.Address, c.Note)
+	return lines
+}
+
+// Render renders the card of the width: the QR code of the vCard of the
+// given size, the name with the face of the name, i.e. the bold one, and
+// the rest of the details with the face under it, centred.  If the text is
+// false, only the QR code is rendered.
+func (c Card) Render(width, qrSize int, nameFace, face font.Face, text bool) (image.Image, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	comp := bitmap.NewComposer(width)
+	if err := comp.AppendQR(c.VCard(), qrSize); err != nil {
+		return nil, err
+	}
+	if !text {
+		return comp.Image(), nil
+	}
+	comp.Feed(gap)
+	opts := []bitmap.TextOption{bitmap.WithWrap(true), bitmap.WithAlignment(bitmap.AlignCenter)}
+	if err := comp.AppendText(nameFace, strings.TrimSpace(c.Name), opts...); err != nil {
+		return nil, err
+	}
+	if lines := c.Lines(); len(lines) > 0 {
+		if err := comp.AppendText(face, strings.Join(lines, "\n"), opts...); err != nil {
+			return nil, err
+		}
+	}
+	return comp.Image(), nil
+}
@@ -0,0 +1,84 @@
+package contact
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rusq/thermoprint/fontmgr"
+)
+
+func TestCard_VCard(t *testing.T) {
+	c := Card{
+		Name:    "Jane Q. Doe",
+		Org:     "Example; Ltd",
+		Tel:     []string{"+1 555 0100", "+1 555 0199"},
+		Email:   []string{"jane@example.com"},
+		Address: "1 Main St, Springfield",
+		Note:    "line one\nline two",
+	}
+	want := strings.Join([]string{
+		"BEGIN:VCARD",
+		"VERSION:3.0",
+		"N:Doe;Jane Q.;;;",
+		"FN:Jane Q. Doe",
+		`ORG:Example\; Ltd`,
+		"TEL;TYPE=VOICE:+1 555 0100",
+		"TEL;TYPE=VOICE:+1 555 0199",
+		"EMAIL;TYPE=INTERNET:jane@example.com",
+		`ADR:;;1 Main St\, Springfield;;;;`,
+		`NOTE:line one\nline two`,
+		"END:VCARD",
+		"",
+	}, "\r\n")
+	if got := c.VCard(); got != want {
+		t.Errorf("VCard() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCard_Lines(t *testing.T) {
+	tests := []struct {
+		name string
+		card Card
+		want []string
+	}{
+		{"name only", Card{Name: "Jane"}, nil},
+		{"title and org", Card{Name: "Jane", Title: "CTO", Org: "Example"}, []string{"CTO, Example"}},
+		{"org only", Card{Name: "Jane", Org: "Example"}, []string{"Example"}},
+		{
+			"all",
+			Card{Name: "Jane", Tel: []string{"1", " "}, Email: []string{"a@b"}, URL: "https://b", Address: "here", Note: "hi"},
+			[]string{"1", "a@b", "https://b", "here", "hi"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.card.Lines(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCard_Render(t *testing.T) {
+	face := fontmgr.DefaultFont
+	if _, err := (Card{Name: " "}).Render(384, 200, face, face, true); err == nil {
+		t.Error("no name: error = nil, want error")
+	}
+	c := Card{Name: "Jane Doe", Tel: []string{"+1 555 0100"}}
+	qrOnly, err := c.Render(384, 200, face, face, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := c.Render(384, 200, face, face, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if qrOnly.Bounds().Dx() != 384 || full.Bounds().Dx() != 384 {
+		t.Errorf("width = %d, %d, want 384", qrOnly.Bounds().Dx(), full.Bounds().Dx())
+	}
+	// the name and the phone are two lines under the code.
+	if min := qrOnly.Bounds().Dy() + gap + 2*face.Metrics().Height.Ceil(); full.Bounds().Dy() < min {
+		t.Errorf("height = %d, want at least %d", full.Bounds().Dy(), min)
+	}
+}
@@ -2,7 +2,8 @@
 // rasterisation in CUPS and macOS/iOS printing: PWG Raster (PWG 5102.4,
 // image/pwg-raster) and Apple Raster (URF, image/urf).  Both formats carry
 // one or more pre-rendered pages compressed with the same simple run-length
-// scheme; the decoder converts each page to an image.Image.
+// scheme; the decoder converts each page to an image.Image.  Pages can be
+// encoded back to PWG Raster with [EncodePWG].
 //
 // References:
 //   - https://ftp.pwg.org/pub/pwg/candidates/cs-ippraster10-20120420-5102.4.pdf
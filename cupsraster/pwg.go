@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"image"
+	"image/draw"
 	"io"
 )
 
@@ -24,6 +25,8 @@ const (
 const (
 	pwgOffHWResolutionX = 276
 	pwgOffHWResolutionY = 280
+	pwgOffPageSizeX     = 352
+	pwgOffPageSizeY     = 356
 	pwgOffWidth         = 372
 	pwgOffHeight        = 376
 	pwgOffBitsPerColor  = 384
@@ -31,8 +34,16 @@ const (
 	pwgOffBytesPerLine  = 392
 	pwgOffColorOrder    = 396
 	pwgOffColorSpace    = 400
+	pwgOffNumColors     = 420
+	pwgOffTotalPages    = 452
+	pwgOffCrossFeed     = 456
+	pwgOffFeed          = 460
 )
 
+// defaultDPI is the resolution of the encoded pages, that do not declare
+// their own.
+const defaultDPI = 203
+
 // PWG cupsColorSpace values (subset the decoder understands).
 const (
 	pwgCSBlack    = 3  // K, ink semantics: 1 = black
@@ -146,3 +157,66 @@ func decodePWGPage(br *bufio.Reader, h pwgHeader) (image.Image, error) {
 	}
 	panic("unreachable: bpp validated in parsePWGHeader")
 }
+
+// EncodePWG encodes the pages as a PWG Raster stream of 8-bit sGray pages,
+// at the resolution declared by each page, or 203 dpi if it is not set.
+// Colour and transparent pages are flattened on white.
+func EncodePWG(w io.Writer, pages []Page) error {
+	if len(pages) == 0 {
+		return errors.New("no pages to encode")
+	}
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(pwgSyncWord); err != nil {
+		return err
+	}
+	for i, pg := range pages {
+		b := pg.Bounds()
+		if err := checkDimensions(b.Dx(), b.Dy()); err != nil {
+			return fmt.Errorf("page %d: %w", i+1, err)
+		}
+		if _, err := bw.Write(pwgPageHeader(pg, len(pages))); err != nil {
+			return fmt.Errorf("page %d: %w", i+1, err)
+		}
+		gray := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(gray, gray.Bounds(), image.White, image.Point{}, draw.Src)
+		draw.Draw(gray, gray.Bounds(), pg.Image, b.Min, draw.Over)
+		rows := make([][]byte, b.Dy())
+		for y := range rows {
+			rows[y] = gray.Pix[y*gray.Stride : y*gray.Stride+b.Dx()]
+		}
+		encodePage(bw, rows, 1)
+	}
+	return bw.Flush()
+}
+
+// pwgPageHeader returns the header of the 8-bit sGray page of the stream
+// of total pages.
+func pwgPageHeader(pg Page, total int) []byte {
+	xdpi, ydpi := pg.XDPI, pg.YDPI
+	if xdpi <= 0 {
+		xdpi = defaultDPI
+	}
+	if ydpi <= 0 {
+		ydpi = xdpi
+	}
+	width, height := pg.Bounds().Dx(), pg.Bounds().Dy()
+	hdr := make([]byte, pwgHeaderSize)
+	copy(hdr, pwgMagic)
+	u32 := func(off, v int) { binary.BigEndian.PutUint32(hdr[off:off+4], uint32(v)) }
+	u32(pwgOffHWResolutionX, xdpi)
+	u32(pwgOffHWResolutionY, ydpi)
+	u32(pwgOffPageSizeX, width*72/xdpi) // in points
+	u32(pwgOffPageSizeY, height*72/ydpi)
+	u32(pwgOffWidth, width)
+	u32(pwgOffHeight, height)
+	u32(pwgOffBitsPerColor, 8)
+	u32(pwgOffBitsPerPixel, 8)
+	u32(pwgOffBytesPerLine, width)
+	u32(pwgOffColorOrder, 0) // chunky
+	u32(pwgOffColorSpace, pwgCSSGray)
+	u32(pwgOffNumColors, 1)
+	u32(pwgOffTotalPages, total)
+	u32(pwgOffCrossFeed, 1)
+	u32(pwgOffFeed, 1)
+	return hdr
+}
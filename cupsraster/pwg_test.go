@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/binary"
 	"image"
+	"image/color"
+	"io"
 	"strings"
 	"testing"
 )
@@ -156,6 +158,48 @@ func TestDecodePWG_MultiPage(t *testing.T) {
 	}
 }
 
+func TestEncodePWG_RoundTrip(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 300, 4))
+	for i := range gray.Pix {
+		gray.Pix[i] = byte(i / 7 * 13)
+	}
+	gray.Pix[5] = 0 // literal run
+	rgb := image.NewNRGBA(image.Rect(10, 10, 12, 11))
+	rgb.SetNRGBA(10, 10, color.NRGBA{A: 255})
+	rgb.SetNRGBA(11, 10, color.NRGBA{}) // transparent is white
+
+	var buf bytes.Buffer
+	if err := EncodePWG(&buf, []Page{{Image: gray, XDPI: 300}, {Image: rgb}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := Detect(buf.Bytes()); got != FormatPWG {
+		t.Fatalf("Detect: got %v, want PWG", got)
+	}
+	pages, err := DecodePages(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(pages))
+	}
+	if pages[0].XDPI != 300 || pages[0].YDPI != 300 || pages[1].XDPI != 203 {
+		t.Errorf("resolutions: got %d×%d and %d, want 300×300 and 203", pages[0].XDPI, pages[0].YDPI, pages[1].XDPI)
+	}
+	if got := pages[0].Image.(*image.Gray); !bytes.Equal(got.Pix, gray.Pix) {
+		t.Error("page 1 does not round-trip")
+	}
+	got := pages[1].Image.(*image.Gray)
+	if !bytes.Equal(got.Pix, []byte{0, 255}) {
+		t.Errorf("page 2: got %v, want [0 255]", got.Pix)
+	}
+}
+
+func TestEncodePWG_NoPages(t *testing.T) {
+	if err := EncodePWG(io.Discard, nil); err == nil {
+		t.Error("expected an error")
+	}
+}
+
 func TestDecodePWG_Errors(t *testing.T) {
 	valid := pwgPage{buildPWGHeader(8, 1, 1, pwgCSBlack), [][]byte{{0x00}}}
 	tests := []struct {
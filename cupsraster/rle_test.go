@@ -8,54 +8,6 @@ import (
 	"testing"
 )
 
-// encodeLine RLE-encodes a single row for tests.  It uses repeat runs for
-// consecutive equal groups and literal runs otherwise.
-func encodeLine(w *bytes.Buffer, row []byte, groupSize int) {
-	numGroups := len(row) / groupSize
-	group := func(i int) []byte { return row[i*groupSize : (i+1)*groupSize] }
-	for i := 0; i < numGroups; {
-		// count run of equal groups
-		run := 1
-		for i+run < numGroups && run < 128 && bytes.Equal(group(i), group(i+run)) {
-			run++
-		}
-		if run > 1 {
-			w.WriteByte(byte(run - 1)) // 0..127: group repeated c+1 times
-			w.Write(group(i))
-			i += run
-			continue
-		}
-		// count literal groups (no two consecutive equal)
-		lit := 1
-		for i+lit < numGroups && lit < 128 &&
-			!(i+lit+1 <= numGroups-1 && bytes.Equal(group(i+lit), group(i+lit+1))) {
-			lit++
-		}
-		if lit == 1 {
-			w.WriteByte(0) // single group as a repeat of 1
-			w.Write(group(i))
-		} else {
-			w.WriteByte(byte(257 - lit)) // 129..255: 257-c literal groups
-			w.Write(row[i*groupSize : (i+lit)*groupSize])
-		}
-		i += lit
-	}
-}
-
-// encodePage RLE-encodes rows, collapsing consecutive identical rows into
-// line-repeat counts.
-func encodePage(w *bytes.Buffer, rows [][]byte, groupSize int) {
-	for y := 0; y < len(rows); {
-		repeat := 0
-		for y+repeat+1 < len(rows) && repeat < 255 && bytes.Equal(rows[y], rows[y+repeat+1]) {
-			repeat++
-		}
-		w.WriteByte(byte(repeat))
-		encodeLine(w, rows[y], groupSize)
-		y += repeat + 1
-	}
-}
-
 func decodeToRows(t *testing.T, data []byte, height, bytesPerLine, groupSize int, fill byte) [][]byte {
 	t.Helper()
 	rows := make([][]byte, height)
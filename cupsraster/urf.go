@@ -19,10 +19,15 @@ const (
 	urfPageHeaderSize = 32
 )
 
-// URF color space values (subset the decoder understands).
+// URF color space values (subset the decoder understands).  The device and
+// AdobeRGB spaces are decoded as their sGray and sRGB counterparts: the
+// colour fidelity doesn't matter on the thermal paper.
 const (
-	urfCSSGray = 0 // sGray, luminance semantics: 0 = black
-	urfCSSRGB  = 1 // sRGB
+	urfCSSGray     = 0 // sGray, luminance semantics: 0 = black
+	urfCSSRGB      = 1 // sRGB
+	urfCSAdobeRGB  = 3 // AdobeRGB
+	urfCSGray      = 4 // device gray, same semantics as sGray
+	urfCSDeviceRGB = 5 // device RGB
 )
 
 type urfHeader struct {
@@ -45,13 +50,13 @@ func parseURFHeader(buf []byte) (urfHeader, error) {
 		return h, err
 	}
 	switch h.ColorSpace {
-	case urfCSSGray:
+	case urfCSSGray, urfCSGray:
 		if h.BitsPerPixel != 1 && h.BitsPerPixel != 8 {
 			return h, fmt.Errorf("unsupported bits per pixel %d for sGray", h.BitsPerPixel)
 		}
-	case urfCSSRGB:
+	case urfCSSRGB, urfCSAdobeRGB, urfCSDeviceRGB:
 		if h.BitsPerPixel != 24 {
-			return h, fmt.Errorf("unsupported bits per pixel %d for sRGB", h.BitsPerPixel)
+			return h, fmt.Errorf("unsupported bits per pixel %d for RGB", h.BitsPerPixel)
 		}
 	default:
 		return h, fmt.Errorf("unsupported color space %d", h.ColorSpace)
@@ -85,6 +85,40 @@ func TestDecodeURF_RGB24(t *testing.T) {
 	}
 }
 
+func TestDecodeURF_DeviceColorSpaces(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		bpp, cs    int
+		row        []byte
+		wantGray   uint8
+		wantColour bool
+	}{
+		{"device gray", 8, urfCSGray, []byte{42}, 42, false},
+		{"device rgb", 24, urfCSDeviceRGB, []byte{10, 20, 30}, 0, true},
+		{"adobe rgb", 24, urfCSAdobeRGB, []byte{10, 20, 30}, 0, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := buildURFStream(t, urfPage{buildURFPageHeader(1, 1, tt.bpp, tt.cs), [][]byte{tt.row}})
+			pages, err := DecodeURF(bytes.NewReader(stream))
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch img := pages[0].(type) {
+			case *image.Gray:
+				if tt.wantColour || img.GrayAt(0, 0).Y != tt.wantGray {
+					t.Errorf("gray pixel %v, want %d", img.GrayAt(0, 0), tt.wantGray)
+				}
+			case *image.NRGBA:
+				if c := img.NRGBAAt(0, 0); !tt.wantColour || c.R != 10 || c.G != 20 || c.B != 30 {
+					t.Errorf("rgb pixel %v", c)
+				}
+			default:
+				t.Fatalf("unexpected image type %T", img)
+			}
+		})
+	}
+}
+
 func TestDecodeURF_MultiPage(t *testing.T) {
 	p1 := urfPage{buildURFPageHeader(8, 1, 1, urfCSSGray), [][]byte{{0xff}}}
 	p2 := urfPage{buildURFPageHeader(8, 2, 8, urfCSSGray), [][]byte{{1, 2, 3, 4, 5, 6, 7, 8}, {9, 10, 11, 12, 13, 14, 15, 16}}}
@@ -0,0 +1,24 @@
+//go:build js && wasm
+
+package thermoprint
+
+import (
+	"fmt"
+
+	"github.com/rusq/thermoprint/internal/ble"
+)
+
+// Adapter is unused in the WebAssembly build, the browser manages the
+// Bluetooth adapter.  Pass nil to [NewLXD02].
+type Adapter struct{}
+
+// newBackend returns the Bluetooth backend by name.  Only the
+// [BackendWebBluetooth] is available in the browser.
+func newBackend(name string, _ *Adapter) (ble.Backend, error) {
+	switch name {
+	case "", BackendWebBluetooth:
+		return ble.NewWebBluetooth()
+	default:
+		return nil, fmt.Errorf("unsupported Bluetooth backend in the browser: %q", name)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !js
+
+package thermoprint
+
+import (
+	"fmt"
+
+	"tinygo.org/x/bluetooth"
+
+	"github.com/rusq/thermoprint/internal/ble"
+)
+
+// Adapter is the Bluetooth adapter used by the [BackendTinyGo].
+type Adapter = bluetooth.Adapter
+
+// newBackend returns the Bluetooth backend by name.  The adapter is used by
+// the [BackendTinyGo].
+func newBackend(name string, adapter *Adapter) (ble.Backend, error) {
+	switch name {
+	case "", BackendTinyGo:
+		return ble.NewTinyGo(adapter), nil
+	case BackendBlueZ:
+		return ble.NewBlueZ()
+	default:
+		return nil, fmt.Errorf("unknown Bluetooth backend: %q", name)
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !js
+
+package thermoprint
+
+import (
+	"testing"
+
+	"github.com/rusq/thermoprint/internal/ble"
+)
+
+func TestNewBackend(t *testing.T) {
+	for _, name := range []string{"", BackendTinyGo} {
+		b, err := newBackend(name, nil)
+		if err != nil {
+			t.Fatalf("newBackend(%q) error = %v", name, err)
+		}
+		if _, ok := b.(*ble.TinyGo); !ok {
+			t.Fatalf("newBackend(%q) = %T, want *ble.TinyGo", name, b)
+		}
+	}
+	if _, err := newBackend("carrier-pigeon", nil); err == nil {
+		t.Fatal("newBackend() succeeded for unknown backend")
+	}
+}
@@ -0,0 +1,26 @@
+package thermoprint
+
+import "testing"
+
+func TestSearchParametersTarget(t *testing.T) {
+	if _, err := (SearchParameters{}).target(lxd02GATT); err == nil {
+		t.Fatal("target() succeeded without name and MAC address")
+	}
+	tgt, err := SearchParameters{Name: "LX-D02"}.target(lxd02GATT)
+	if err != nil {
+		t.Fatalf("target() error = %v", err)
+	}
+	if tgt.Name != "LX-D02" || tgt.TX != txChar || tgt.RX != rxChar {
+		t.Fatalf("target() = %+v", tgt)
+	}
+	tgt, err = SearchParameters{MACAddress: "aa-bb-cc-dd-ee-ff"}.target(lxd02GATT)
+	if err != nil {
+		t.Fatalf("target() error = %v", err)
+	}
+	if tgt.MACAddress != "AABBCCDDEEFF" {
+		t.Fatalf("MACAddress = %q, want normalised address", tgt.MACAddress)
+	}
+	if _, err := (SearchParameters{MACAddress: "not-a-mac"}).target(lxd02GATT); err == nil {
+		t.Fatal("target() succeeded with invalid address")
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/looplab/fsm"
@@ -40,6 +42,14 @@ const (
 
 var errPrintFailed = errors.New("print job failed")
 
+// ErrRetransmitStorm is returned if the printer keeps requesting to
+// retransmit the same packet, which happens on weak Bluetooth links.
+var ErrRetransmitStorm = errors.New("too many retransmit requests")
+
+// maxRetransmits is the number of times the printer may request to
+// retransmit the same packet before the print is aborted.
+const maxRetransmits = 5
+
 type fsmEvent struct {
 	kind     printerEvent
 	data     []byte
@@ -47,24 +57,34 @@ type fsmEvent struct {
 	streamID uint64
 }
 
+// printJob is a single print.  It owns the channels used to drive its FSM
+// and holds the result of the print.  Once the job is finished, the done
+// channel is closed and the events for the job are ignored.
 type printJob struct {
 	fsm         *fsm.FSM
 	fsmMu       sync.Mutex
 	eventCh     chan fsmEvent
-	doneCh      chan error
+	done        chan struct{} // closed when the job is finished
 	doneOnce    sync.Once
+	err         error // result of the job, set before done is closed
 	ctx         context.Context
 	cancel      context.CancelFunc
 	printCancel context.CancelFunc
+	aborted     error    // set by the cancel transition, see abortPrint
+	packets     [][]byte // the packets of the job, read only
 	printStream uint64
 	printSeq    uint64
+	retransmits map[int]int  // retransmit requests per packet index
+	begun       atomic.Bool  // the print job command has been sent
+	reconnects  atomic.Int32 // reconnections after the link dropped
 }
 
-func (p *LXD02) newPrintJob(ctx context.Context) *printJob {
+func (p *LXD02) newPrintJob(ctx context.Context, packets [][]byte) *printJob {
 	jobCtx, cancel := context.WithCancel(ctx)
 	job := &printJob{
+		packets: slices.Clone(packets),
 		eventCh: make(chan fsmEvent, 10),
-		doneCh:  make(chan error, 1),
+		done:    make(chan struct{}),
 		ctx:     jobCtx,
 		cancel:  cancel,
 	}
@@ -122,13 +142,20 @@ func (p *LXD02) newPrintFSM(job *printJob, initial printerState) *fsm.FSM {
 				packet := extractRetryPacketIndex(eventData(e))
 				slog.Warn("Retransmit request", "packet", packet)
 				p.cancelPrintBuffer(job)
+				if err := job.countRetransmit(packet); err != nil {
+					go p.dispatchJobEvent(job, fsmEvent{kind: eventError, err: err})
+					return
+				}
 				go p.startPrintBuffer(job, packet)
 			},
 			"after_" + eventNotificationFinished.String(): func(_ context.Context, _ *fsm.Event) {
 				go p.finishPrint(job)
 			},
 			"after_" + eventCancel.String(): func(_ context.Context, e *fsm.Event) {
-				p.failPrint(job, eventErr(e, context.Canceled))
+				// the end command is sent by dispatchJobEvent, once fsmMu
+				// is released.
+				p.cancelPrintBuffer(job)
+				job.aborted = eventErr(e, context.Canceled)
 			},
 			"after_" + eventError.String(): func(_ context.Context, e *fsm.Event) {
 				p.failPrint(job, eventErr(e, errPrintFailed))
@@ -137,6 +164,40 @@ func (p *LXD02) newPrintFSM(job *printJob, initial printerState) *fsm.FSM {
 	)
 }
 
+// Done returns a channel that is closed when the job is finished.
+func (job *printJob) Done() <-chan struct{} {
+	return job.done
+}
+
+// Err returns the result of the job.  It must be called after the channel
+// returned by [printJob.Done] is closed.
+func (job *printJob) Err() error {
+	return job.err
+}
+
+// isDone returns true if the job is finished.
+func (job *printJob) isDone() bool {
+	select {
+	case <-job.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// countRetransmit counts the retransmit request for the packet, and returns
+// an error if the packet was requested more than maxRetransmits times.
+func (job *printJob) countRetransmit(packet int) error {
+	if job.retransmits == nil {
+		job.retransmits = make(map[int]int)
+	}
+	job.retransmits[packet]++
+	if n := job.retransmits[packet]; n > maxRetransmits {
+		return fmt.Errorf("%w: packet %d was requested %d times, the connection is unreliable, try increasing the delay between packets (-d flag)", ErrRetransmitStorm, packet, n)
+	}
+	return nil
+}
+
 func (p *LXD02) runFSM(job *printJob) {
 	for {
 		select {
@@ -159,6 +220,15 @@ func (p *LXD02) dispatchJobEvent(job *printJob, evt fsmEvent) bool {
 		return false
 	}
 
+	// The cancelled job is ended after fsmMu is released, as waiting for the
+	// printer response must not hold up the other events.
+	var aborted error
+	defer func() {
+		if aborted != nil {
+			p.abortPrint(job, aborted)
+		}
+	}()
+
 	// Keep stream validation and transition application in one ordered section.
 	// Packet-stream goroutines dispatch directly while printer notifications are
 	// handled by runFSM, so both paths must agree on the current stream/state.
@@ -169,6 +239,10 @@ func (p *LXD02) dispatchJobEvent(job *printJob, evt fsmEvent) bool {
 		slog.Warn("Ignoring stale FSM event", "event", evt.kind)
 		return false
 	}
+	if job.isDone() {
+		slog.Debug("Ignoring FSM event for finished print job", "event", evt.kind)
+		return false
+	}
 	if !p.isCurrentPrintStream(job, evt) {
 		slog.Warn("Ignoring stale packet stream event", "event", evt.kind, "stream", evt.streamID)
 		return false
@@ -190,6 +264,7 @@ func (p *LXD02) dispatchJobEvent(job *printJob, evt fsmEvent) bool {
 			log.Warn("FSM event returned error", "error", err)
 		}
 	}
+	aborted, job.aborted = job.aborted, nil
 	p.setStateForJob(job, fsmStateToPrinterState(job.fsm.Current()))
 	return true
 }
@@ -198,17 +273,15 @@ func (p *LXD02) beginPrint(job *printJob) {
 	if !p.isActiveJob(job) {
 		return
 	}
-	buflen := len(p.buffer)
-	if buflen == 0 {
+	if len(job.packets) == 0 {
 		slog.Error("Buffer is empty, cannot start printing")
 		p.dispatchJobEvent(job, fsmEvent{kind: eventError, err: errBufferEmpty})
 		return
 	}
 
-	m := byte((buflen >> 8) & 0xFF)
-	n := byte(buflen & 0xFF)
-	beginCmd := []byte{0x5a, 0x04, m, n, 0x00, 0x00}
-	resp, err := p.sendAndWaitForFSM(beginCmd, beginCmd[:2], 3*time.Second)
+	beginCmd := job.command(0x00)
+	job.begun.Store(true) // the printer may start the job before the ack
+	resp, err := p.sendAndWaitForFSM(beginCmd, beginCmd[:2], p.options.timeout())
 	if err != nil {
 		slog.Error("Failed to send initial print command", "error", err)
 		p.dispatchJobEvent(job, fsmEvent{kind: eventError, err: fmt.Errorf("send initial print command: %w", err)})
@@ -225,11 +298,8 @@ func (p *LXD02) finishPrint(job *printJob) {
 	if !p.isActiveJob(job) {
 		return
 	}
-	buflen := len(p.buffer)
-	m := byte((buflen >> 8) & 0xFF)
-	n := byte(buflen & 0xFF)
-	finalCmd := []byte{0x5a, 0x04, m, n, 0x01, 0x00}
-	resp, err := p.sendAndWaitForFSM(finalCmd, finalCmd[:2], 3*time.Second)
+	finalCmd := job.command(0x01)
+	resp, err := p.sendAndWaitForFSM(finalCmd, finalCmd[:2], p.options.timeout())
 	if err != nil {
 		slog.Error("Failed to send final end command", "error", err)
 		p.dispatchJobEvent(job, fsmEvent{kind: eventError, err: fmt.Errorf("send final end command: %w", err)})
@@ -242,19 +312,43 @@ func (p *LXD02) finishPrint(job *printJob) {
 	p.completePrint(job, nil)
 }
 
+// command returns the print job command for the packets of the job, flag
+// is 0x00 to begin the job, and 0x01 to end it.
+func (job *printJob) command(flag byte) []byte {
+	buflen := len(job.packets)
+	return []byte{0x5a, 0x04, byte(buflen >> 8), byte(buflen), flag, 0x00}
+}
+
+// abortPrint stops sending the packets of the cancelled job, and if the
+// printer has started the job, ends it, so that the printer returns to idle
+// instead of waiting for the rest of the data with the paper half-fed.  It
+// must not be called with fsmMu held.
+func (p *LXD02) abortPrint(job *printJob, err error) {
+	p.cancelPrintBuffer(job)
+	if job.begun.Load() && !job.isDone() {
+		endCmd := job.command(0x01)
+		if _, err := p.sendAndWaitForFSM(endCmd, endCmd[:2], p.options.timeout()); err != nil {
+			slog.Warn("Failed to end the cancelled print job", "error", err)
+		} else {
+			slog.Info("Cancelled print job ended")
+		}
+	}
+	p.failPrint(job, err)
+}
+
 func (p *LXD02) failPrint(job *printJob, err error) {
 	p.cancelPrintBuffer(job)
 	p.completePrint(job, err)
 }
 
+// completePrint records the result and finishes the job.  Only the first
+// result is recorded.
 func (p *LXD02) completePrint(job *printJob, err error) {
 	job.doneOnce.Do(func() {
+		job.err = err
 		job.cancel()
 		p.cancelPrintBuffer(job)
-		select {
-		case job.doneCh <- err:
-		default:
-		}
+		close(job.done)
 	})
 }
 
@@ -304,6 +398,13 @@ func (p *LXD02) nextPrintStream(job *printJob) (uint64, bool) {
 	return job.printStream, true
 }
 
+// currentState returns the state of the printer.
+func (p *LXD02) currentState() printerState {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+	return p.state
+}
+
 func (p *LXD02) setStateForJob(job *printJob, state printerState) {
 	p.stateMu.Lock()
 	if p.activeJob == job {
@@ -314,7 +415,7 @@ func (p *LXD02) setStateForJob(job *printJob, state printerState) {
 
 func (p *LXD02) routeNotificationEvent(evt fsmEvent) bool {
 	job := p.currentJob()
-	if job == nil {
+	if job == nil || job.isDone() {
 		slog.Warn("Ignoring printer notification with no active print", "event", evt.kind)
 		return false
 	}
@@ -25,19 +25,23 @@ type fsmStreamStart struct {
 	streamID uint64
 }
 
-func newFSMTestPrinter(packetCount int) *LXD02 {
-	buffer := make([][]byte, packetCount)
-	for i := range buffer {
-		buffer[i] = []byte{byte(i)}
+// testPackets returns the packets of the test job.
+func testPackets(packetCount int) [][]byte {
+	packets := make([][]byte, packetCount)
+	for i := range packets {
+		packets[i] = []byte{byte(i)}
 	}
+	return packets
+}
+
+func newFSMTestPrinter(packetCount int) *LXD02 {
 	p := &LXD02{
-		buffer: buffer,
-		state:  stateIdle,
+		state: stateIdle,
 		options: printOptions{
 			printInterval: time.Millisecond,
 		},
 	}
-	job := p.newPrintJob(context.Background())
+	job := p.newPrintJob(context.Background(), testPackets(packetCount))
 	p.activeJob = job
 	return p
 }
@@ -105,6 +109,28 @@ func requireNoDone(t *testing.T, doneCh <-chan error) {
 	}
 }
 
+func requireJobDone(t *testing.T, job *printJob) error {
+	t.Helper()
+
+	select {
+	case <-job.Done():
+		return job.Err()
+	case <-time.After(fsmWaitTimeout):
+		t.Fatal("timed out waiting for job to finish")
+		return nil
+	}
+}
+
+func requireJobNotDone(t *testing.T, job *printJob) {
+	t.Helper()
+
+	select {
+	case <-job.Done():
+		t.Fatalf("unexpected job finish: %v", job.Err())
+	case <-time.After(fsmBlockTimeout):
+	}
+}
+
 func requireEvent(t *testing.T, eventCh <-chan fsmEvent, want printerEvent) fsmEvent {
 	t.Helper()
 
@@ -208,7 +234,7 @@ func TestFSM(t *testing.T) {
 		callsMu.Unlock()
 
 		p.dispatchJobEvent(job, fsmEvent{kind: eventNotificationFinished})
-		if err := requireDone(t, job.doneCh); err != nil {
+		if err := requireJobDone(t, job); err != nil {
 			t.Fatalf("done error = %v, want nil", err)
 		}
 		waitForState(t, p, stateCompleted)
@@ -234,7 +260,7 @@ func TestFSM(t *testing.T) {
 		go p.runFSM(job)
 
 		p.dispatchJobEvent(job, fsmEvent{kind: eventStart})
-		if err := requireDone(t, job.doneCh); !errors.Is(err, wantErr) {
+		if err := requireJobDone(t, job); !errors.Is(err, wantErr) {
 			t.Fatalf("done error = %v, want %v", err, wantErr)
 		}
 		waitForState(t, p, stateFailed)
@@ -254,7 +280,7 @@ func TestFSM(t *testing.T) {
 		go p.runFSM(job)
 
 		p.dispatchJobEvent(job, fsmEvent{kind: eventStart})
-		err := requireDone(t, job.doneCh)
+		err := requireJobDone(t, job)
 		if !errors.Is(err, wantErr) {
 			t.Fatalf("done error = %v, want %v", err, wantErr)
 		}
@@ -273,7 +299,7 @@ func TestFSM(t *testing.T) {
 		go p.runFSM(job)
 
 		p.dispatchJobEvent(job, fsmEvent{kind: eventNotificationFinished})
-		err := requireDone(t, job.doneCh)
+		err := requireJobDone(t, job)
 		if !errors.Is(err, wantErr) {
 			t.Fatalf("done error = %v, want %v", err, wantErr)
 		}
@@ -289,7 +315,7 @@ func TestFSM(t *testing.T) {
 		}
 
 		p.startPrintBuffer(job, 0)
-		err := requireDone(t, job.doneCh)
+		err := requireJobDone(t, job)
 		if !errors.Is(err, wantErr) {
 			t.Fatalf("done error = %v, want %v", err, wantErr)
 		}
@@ -311,7 +337,7 @@ func TestFSM(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		errCh := make(chan error, 1)
 		go func() {
-			errCh <- p.printPackets(ctx, p.buffer)
+			errCh <- p.printPackets(ctx, testPackets(1), 0)
 		}()
 
 		select {
@@ -331,6 +357,47 @@ func TestFSM(t *testing.T) {
 		}
 	})
 
+	t.Run("overlapping print fails with ErrBusy and disconnect cancels print", func(t *testing.T) {
+		p := newFSMTestPrinter(1)
+		p.initSequenceHook = func(job *printJob) {
+			p.dispatchJobEvent(job, fsmEvent{kind: eventInitComplete})
+		}
+		p.sendAndWaitHook = func(data []byte, expectPrefix []byte, timeout time.Duration) ([]byte, error) {
+			return append([]byte(nil), expectPrefix...), nil
+		}
+		streamStarted := make(chan struct{})
+		p.printBufferHook = func(*printJob, int, uint64) {
+			close(streamStarted)
+		}
+
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- p.printPackets(t.Context(), testPackets(1), 0)
+		}()
+		select {
+		case <-streamStarted:
+		case <-time.After(fsmWaitTimeout):
+			t.Fatal("print stream was not started")
+		}
+
+		if err := p.printPackets(t.Context(), testPackets(1), 0); !errors.Is(err, ErrBusy) {
+			t.Fatalf("overlapping printPackets error = %v, want %v", err, ErrBusy)
+		}
+
+		var wg sync.WaitGroup
+		for range 2 {
+			wg.Go(func() {
+				if err := p.Disconnect(); err != nil {
+					t.Errorf("Disconnect: %v", err)
+				}
+			})
+		}
+		wg.Wait()
+		if err := requireDone(t, errCh); !errors.Is(err, ErrDisconnected) {
+			t.Fatalf("printPackets error = %v, want %v", err, ErrDisconnected)
+		}
+	})
+
 	t.Run("hold during printing does not stop stream", func(t *testing.T) {
 		p := newFSMTestPrinter(1)
 		job := activeTestJob(t, p)
@@ -505,7 +572,7 @@ func TestFSM(t *testing.T) {
 			t.Fatal("stale packet completion was accepted")
 		}
 		waitForState(t, p, statePrinting)
-		requireNoDone(t, job.doneCh)
+		requireJobNotDone(t, job)
 
 		if ok := p.dispatchJobEvent(job, fsmEvent{kind: eventPacketsSent, streamID: second.streamID}); !ok {
 			t.Fatal("current packet completion was ignored")
@@ -513,6 +580,29 @@ func TestFSM(t *testing.T) {
 		waitForState(t, p, stateWaitingRetry)
 	})
 
+	t.Run("retransmit storm aborts the print", func(t *testing.T) {
+		p := newFSMTestPrinter(10)
+		job := activeTestJob(t, p)
+		setFSMState(p, statePrinting)
+		starts := make(chan fsmStreamStart, maxRetransmits+1)
+		p.printBufferHook = func(_ *printJob, start int, streamID uint64) {
+			starts <- fsmStreamStart{start: start, streamID: streamID}
+		}
+
+		for range maxRetransmits {
+			p.dispatchJobEvent(job, fsmEvent{kind: eventNotificationRetransmit, data: []byte{0x5a, 0x05, 0x00, 0x03}})
+			requireStreamStart(t, starts)
+		}
+		requireJobNotDone(t, job)
+
+		p.dispatchJobEvent(job, fsmEvent{kind: eventNotificationRetransmit, data: []byte{0x5a, 0x05, 0x00, 0x03}})
+		err := requireJobDone(t, job)
+		if !errors.Is(err, ErrRetransmitStorm) {
+			t.Fatalf("done error = %v, want %v", err, ErrRetransmitStorm)
+		}
+		waitForState(t, p, stateFailed)
+	})
+
 	t.Run("duplicate error and cancel complete once without blocking", func(t *testing.T) {
 		p := newFSMTestPrinter(1)
 		job := activeTestJob(t, p)
@@ -521,11 +611,31 @@ func TestFSM(t *testing.T) {
 		p.dispatchJobEvent(job, fsmEvent{kind: eventError})
 		p.dispatchJobEvent(job, fsmEvent{kind: eventCancel})
 
-		if err := requireDone(t, job.doneCh); !errors.Is(err, errPrintFailed) {
+		if err := requireJobDone(t, job); !errors.Is(err, errPrintFailed) {
 			t.Fatalf("done error = %v, want %v", err, errPrintFailed)
 		}
-		requireNoDone(t, job.doneCh)
 		waitForState(t, p, stateFailed)
+		if err := job.Err(); !errors.Is(err, errPrintFailed) {
+			t.Fatalf("job error after cancel = %v, want %v", err, errPrintFailed)
+		}
+	})
+
+	t.Run("notification after completion is dropped", func(t *testing.T) {
+		p := newFSMTestPrinter(1)
+		job := activeTestJob(t, p)
+		setFSMState(p, statePrinting)
+
+		p.dispatchJobEvent(job, fsmEvent{kind: eventError})
+		requireJobDone(t, job)
+
+		for range cap(job.eventCh) + 1 {
+			if p.routeNotificationEvent(fsmEvent{kind: eventNotificationFinished}) {
+				t.Fatal("notification for a finished job was routed")
+			}
+		}
+		if n := len(job.eventCh); n != 0 {
+			t.Fatalf("queued events = %d, want 0", n)
+		}
 	})
 
 	t.Run("cancel after failed does not block without a done receiver", func(t *testing.T) {
@@ -559,7 +669,7 @@ func TestFSM(t *testing.T) {
 
 		p.dispatchJobEvent(job, fsmEvent{kind: eventNotificationFinished})
 
-		err := requireDone(t, job.doneCh)
+		err := requireJobDone(t, job)
 		if !errors.Is(err, wantErr) {
 			t.Fatalf("done error = %v, want %v", err, wantErr)
 		}
@@ -618,7 +728,7 @@ func TestFSM(t *testing.T) {
 		ctxA, cancelA := context.WithCancel(context.Background())
 		errA := make(chan error, 1)
 		go func() {
-			errA <- p.printPackets(ctxA, p.buffer)
+			errA <- p.printPackets(ctxA, testPackets(1), 0)
 		}()
 		select {
 		case <-firstInitStarted:
@@ -633,7 +743,7 @@ func TestFSM(t *testing.T) {
 		ctxB := t.Context()
 		errB := make(chan error, 1)
 		go func() {
-			errB <- p.printPackets(ctxB, p.buffer)
+			errB <- p.printPackets(ctxB, testPackets(1), 0)
 		}()
 		select {
 		case <-secondStreamStarted:
@@ -706,7 +816,7 @@ func TestFSM(t *testing.T) {
 		ctxA, cancelA := context.WithCancel(context.Background())
 		errA := make(chan error, 1)
 		go func() {
-			errA <- p.printPackets(ctxA, p.buffer)
+			errA <- p.printPackets(ctxA, testPackets(1), 0)
 		}()
 		waitUntil(t, func() bool {
 			mu.Lock()
@@ -721,7 +831,7 @@ func TestFSM(t *testing.T) {
 		ctxB := t.Context()
 		errB := make(chan error, 1)
 		go func() {
-			errB <- p.printPackets(ctxB, p.buffer)
+			errB <- p.printPackets(ctxB, testPackets(1), 0)
 		}()
 		waitUntil(t, func() bool {
 			mu.Lock()
@@ -746,16 +856,32 @@ func TestFSM(t *testing.T) {
 	})
 }
 
+func TestPrintJobOwnsPackets(t *testing.T) {
+	p := newFSMTestPrinter(0)
+	packets := testPackets(2)
+	job := p.newPrintJob(context.Background(), packets)
+	packets[0] = []byte{0xff}
+
+	if got := job.command(0x00); got[3] != 2 {
+		t.Fatalf("job command packet count = %d, want 2", got[3])
+	}
+	if got := job.packets[0]; len(got) != 1 || got[0] != 0 {
+		t.Fatalf("job packet 0 = % x, want 00", got)
+	}
+}
+
 func TestNotificationWorker(t *testing.T) {
 	for _, tc := range []struct {
-		name string
-		ntf  lxd02notification
-		want printerEvent
+		name    string
+		ntf     lxd02notification
+		want    printerEvent
+		wantErr error
 	}{
 		{
-			name: "no paper status emits error",
-			ntf:  lxd02notification{prefix: ntStatus, data: []byte{0x5a, 0x02, 50, 1, 0, 0}},
-			want: eventError,
+			name:    "no paper status emits error",
+			ntf:     lxd02notification{prefix: ntStatus, data: []byte{0x5a, 0x02, 50, 1, 0, 0}},
+			want:    eventError,
+			wantErr: ErrNoPaper,
 		},
 		{
 			name: "hold notification emits hold event",
@@ -785,6 +911,16 @@ func TestNotificationWorker(t *testing.T) {
 			if tc.want == eventNotificationRetransmit && !bytes.Equal(got.data, tc.ntf.data) {
 				t.Fatalf("retransmit data = % x, want % x", got.data, tc.ntf.data)
 			}
+			if !errors.Is(got.err, tc.wantErr) {
+				t.Fatalf("event error = %v, want %v", got.err, tc.wantErr)
+			}
+			if tc.wantErr == nil {
+				return
+			}
+			p.dispatchJobEvent(job, got)
+			if err := requireJobDone(t, job); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("done error = %v, want %v", err, tc.wantErr)
+			}
 		})
 	}
 
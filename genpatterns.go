@@ -0,0 +1,250 @@
+package thermoprint
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand/v2"
+
+	"golang.org/x/image/draw"
+)
+
+// GenerativePatterns are the patterns that are generated from a seed.  The
+// same seed always produces the same image, so that a print that came out
+// nicely can be repeated.
+var GenerativePatterns = map[string]func(width int, seed uint64) image.Image{
+	"Maze":      GenMaze,
+	"Life":      GenLife,
+	"Landscape": GenLandscape,
+}
+
+func newRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+}
+
+const (
+	mazeCell = 16 // maze cell size in pixels, including the wall
+	mazeWall = 3  // maze wall thickness in pixels
+)
+
+// GenMaze generates a square maze, with the entrance in the top left corner,
+// and the exit in the bottom right corner.  The maze is carved with the
+// randomised depth-first search, so there is exactly one path between any
+// two cells.
+func GenMaze(maxX int, seed uint64) image.Image {
+	rnd := newRand(seed)
+	cols := (maxX - mazeWall) / mazeCell
+	if cols < 2 {
+		return nil
+	}
+	rows := cols
+	// offset centres the maze on the paper.
+	off := (maxX - cols*mazeCell - mazeWall) / 2
+
+	img := image.NewGray(image.Rect(0, 0, maxX, rows*mazeCell+mazeWall))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	fill := func(x0, y0, x1, y1 int) {
+		draw.Draw(img, image.Rect(off+x0, y0, off+x1, y1), image.Black, image.Point{}, draw.Src)
+	}
+
+	// right and down hold the walls to the right and below each cell.
+	right := make([]bool, cols*rows)
+	down := make([]bool, cols*rows)
+	for i := range right {
+		right[i], down[i] = true, true
+	}
+	visited := make([]bool, cols*rows)
+	stack := []int{0}
+	visited[0] = true
+	for len(stack) > 0 {
+		cur := stack[len(stack)-1]
+		x, y := cur%cols, cur/cols
+		var next []int
+		if x > 0 && !visited[cur-1] {
+			next = append(next, cur-1)
+		}
+		if x < cols-1 && !visited[cur+1] {
+			next = append(next, cur+1)
+		}
+		if y > 0 && !visited[cur-cols] {
+			next = append(next, cur-cols)
+		}
+		if y < rows-1 && !visited[cur+cols] {
+			next = append(next, cur+cols)
+		}
+		if len(next) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		n := next[rnd.IntN(len(next))]
+		switch n {
+		case cur - 1:
+			right[n] = false
+		case cur + 1:
+			right[cur] = false
+		case cur - cols:
+			down[n] = false
+		case cur + cols:
+			down[cur] = false
+		}
+		visited[n] = true
+		stack = append(stack, n)
+	}
+
+	// the walls to the right and below the last column and row make the
+	// border, open the exit in it.
+	down[len(down)-1] = false
+	w := cols * mazeCell
+	fill(mazeCell, 0, w+mazeWall, mazeWall) // top, leaving the entrance
+	fill(0, 0, mazeWall, rows*mazeCell+mazeWall)
+	for y := range rows {
+		for x := range cols {
+			i := y*cols + x
+			x0, y0 := x*mazeCell, y*mazeCell
+			if right[i] {
+				fill(x0+mazeCell, y0, x0+mazeCell+mazeWall, y0+mazeCell+mazeWall)
+			}
+			if down[i] {
+				fill(x0, y0+mazeCell, x0+mazeCell+mazeWall, y0+mazeCell+mazeWall)
+			}
+		}
+	}
+	return img
+}
+
+const (
+	lifeCell        = 4   // cell size in pixels
+	lifeGenerations = 60  // generations to run before taking the snapshot
+	lifeDensity     = 0.3 // share of the cells alive in the first generation
+)
+
+// GenLife runs Conway's Game of Life on a random soup for a number of
+// generations and draws the resulting snapshot.  The field wraps around at
+// the edges.
+func GenLife(maxX int, seed uint64) image.Image {
+	rnd := newRand(seed)
+	cols := maxX / lifeCell
+	if cols < 1 {
+		return nil
+	}
+	rows := cols
+	cur := make([]bool, cols*rows)
+	for i := range cur {
+		cur[i] = rnd.Float64() < lifeDensity
+	}
+	next := make([]bool, len(cur))
+	for range lifeGenerations {
+		for y := range rows {
+			for x := range cols {
+				var n int
+				for dy := -1; dy <= 1; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						if (dx != 0 || dy != 0) && cur[(y+dy+rows)%rows*cols+(x+dx+cols)%cols] {
+							n++
+						}
+					}
+				}
+				alive := cur[y*cols+x]
+				next[y*cols+x] = n == 3 || (alive && n == 2)
+			}
+		}
+		cur, next = next, cur
+	}
+
+	img := image.NewGray(image.Rect(0, 0, maxX, rows*lifeCell))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	off := (maxX - cols*lifeCell) / 2
+	for i, alive := range cur {
+		if !alive {
+			continue
+		}
+		x, y := off+i%cols*lifeCell, i/cols*lifeCell
+		// one pixel gap between the cells.
+		draw.Draw(img, image.Rect(x, y, x+lifeCell-1, y+lifeCell-1), image.Black, image.Point{}, draw.Src)
+	}
+	return img
+}
+
+const landscapeLayers = 5
+
+// GenLandscape draws a mountain landscape: a few ridges made of Perlin noise,
+// fading into the sky with the distance.  The image is in shades of grey,
+// which makes it a good showcase for the dithering functions.
+func GenLandscape(maxX int, seed uint64) image.Image {
+	if maxX < 1 {
+		return nil
+	}
+	rnd := newRand(seed)
+	height := maxX * 2 / 3
+	img := image.NewGray(image.Rect(0, 0, maxX, height))
+	// the sky gets lighter towards the horizon.
+	for y := range height {
+		c := color.Gray{Y: uint8(170 + 85*y/height)}
+		for x := range maxX {
+			img.SetGray(x, y, c)
+		}
+	}
+	for layer := range landscapeLayers {
+		noise := newPerlin(rnd)
+		// the ridges are drawn from the back to the front, the further the
+		// ridge is, the lighter, higher and smoother it is.
+		depth := 1 - float64(layer)/(landscapeLayers-1)
+		base := float64(height) * (0.8 - 0.45*depth)
+		amp := float64(height) * (0.25 - 0.1*depth)
+		scale := 4 - 2.5*depth
+		top := uint8(10 + 190*depth)
+		bottom := uint8(max(0, int(top)-60))
+		for x := range maxX {
+			h := noise.fractal(float64(x)/float64(maxX)*scale, 4)
+			ridge := int(base - amp*h)
+			for y := max(0, ridge); y < height; y++ {
+				// the slopes get darker towards the foot of the ridge.
+				t := float64(y-ridge) / float64(height-ridge+1)
+				img.SetGray(x, y, color.Gray{Y: uint8(float64(top) + (float64(bottom)-float64(top))*t)})
+			}
+		}
+	}
+	return img
+}
+
+// perlin is a one-dimensional Perlin gradient noise.
+type perlin struct {
+	perm [512]uint8
+	grad [256]float64
+}
+
+func newPerlin(rnd *rand.Rand) *perlin {
+	var p perlin
+	for i, v := range rnd.Perm(256) {
+		p.perm[i] = uint8(v)
+		p.perm[i+256] = uint8(v)
+	}
+	for i := range p.grad {
+		p.grad[i] = rnd.Float64()*2 - 1
+	}
+	return &p
+}
+
+// noise returns the noise value at x, in the range of about [-0.5, 0.5].
+func (p *perlin) noise(x float64) float64 {
+	x0 := math.Floor(x)
+	i := int(x0) & 255
+	t := x - x0
+	g0 := p.grad[p.perm[i]] * t
+	g1 := p.grad[p.perm[i+1]] * (t - 1)
+	fade := t * t * t * (t*(t*6-15) + 10)
+	return g0 + fade*(g1-g0)
+}
+
+// fractal sums the octaves of the noise, each having the double frequency
+// and a half of the amplitude of the previous one.
+func (p *perlin) fractal(x float64, octaves int) float64 {
+	var sum float64
+	freq, amp := 1.0, 1.0
+	for range octaves {
+		sum += amp * p.noise(x*freq)
+		freq *= 2
+		amp /= 2
+	}
+	return sum
+}
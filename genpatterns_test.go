@@ -0,0 +1,77 @@
+package thermoprint
+
+import (
+	"bytes"
+	"image"
+	"testing"
+)
+
+func pixels(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	g, ok := img.(*image.Gray)
+	if !ok {
+		t.Fatalf("expected *image.Gray, got %T", img)
+	}
+	return g.Pix
+}
+
+func TestGenerativePatterns(t *testing.T) {
+	for name, fn := range GenerativePatterns {
+		t.Run(name, func(t *testing.T) {
+			img := fn(384, 42)
+			if img == nil {
+				t.Fatal("nil image")
+			}
+			if got := img.Bounds().Dx(); got != 384 {
+				t.Errorf("width = %d, want 384", got)
+			}
+			if !bytes.Equal(pixels(t, img), pixels(t, fn(384, 42))) {
+				t.Error("same seed produced different images")
+			}
+			if bytes.Equal(pixels(t, img), pixels(t, fn(384, 43))) {
+				t.Error("different seeds produced the same image")
+			}
+			if img := fn(1, 42); img != nil && img.Bounds().Dx() != 1 {
+				t.Errorf("narrow image width = %d, want 1", img.Bounds().Dx())
+			}
+		})
+	}
+}
+
+func TestGenMaze_Solvable(t *testing.T) {
+	img := GenMaze(384, 1).(*image.Gray)
+	// the maze is centred, the margins must not count as passages.
+	cols := (384 - mazeWall) / mazeCell
+	off := (384 - cols*mazeCell - mazeWall) / 2
+	b := image.Rect(off, 0, off+cols*mazeCell+mazeWall, img.Bounds().Dy())
+	white := func(p image.Point) bool { return p.In(b) && img.GrayAt(p.X, p.Y).Y != 0 }
+
+	// flood fill from the entrance must reach the exit.
+	start := image.Pt(-1, -1)
+	for x := b.Min.X; x < b.Max.X; x++ {
+		if white(image.Pt(x, 0)) {
+			start = image.Pt(x, 0)
+			break
+		}
+	}
+	if !white(start) {
+		t.Fatal("no entrance")
+	}
+	seen := map[image.Point]bool{start: true}
+	queue := []image.Point{start}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if p.Y == b.Max.Y-1 {
+			return
+		}
+		for _, d := range []image.Point{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			n := p.Add(d)
+			if white(n) && !seen[n] {
+				seen[n] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	t.Error("exit is not reachable from the entrance")
+}
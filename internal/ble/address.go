@@ -0,0 +1,46 @@
+package ble
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeAddress returns the device address in the canonical form: upper
+// case hex digits without separators.  It accepts MAC addresses in any case,
+// with or without separators, i.e. "aa:bb:cc:dd:ee:ff", "AA-BB-CC-DD-EE-FF"
+// or "aabbccddeeff", and the CoreBluetooth UUIDs that macOS uses instead of
+// MAC addresses.
+func NormalizeAddress(s string) (string, error) {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == ':' || r == '-' || r == '.':
+			continue
+		case '0' <= r && r <= '9', 'A' <= r && r <= 'F':
+			b.WriteRune(r)
+		case 'a' <= r && r <= 'f':
+			b.WriteRune(r - 'a' + 'A')
+		default:
+			return "", fmt.Errorf("invalid device address %q", s)
+		}
+	}
+	switch b.Len() {
+	case 12, 32: // MAC address, UUID
+		return b.String(), nil
+	default:
+		return "", fmt.Errorf("invalid device address %q: expected MAC address or UUID", s)
+	}
+}
+
+// sameAddress returns true if both addresses identify the same device.
+func sameAddress(a, b string) bool {
+	na, err := NormalizeAddress(a)
+	if err != nil {
+		return false
+	}
+	nb, err := NormalizeAddress(b)
+	if err != nil {
+		return false
+	}
+	return na == nb
+}
@@ -0,0 +1,57 @@
+package ble
+
+import "testing"
+
+func TestNormalizeAddress(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"AA:BB:CC:DD:EE:FF", "AABBCCDDEEFF", false},
+		{"aa:bb:cc:dd:ee:ff", "AABBCCDDEEFF", false},
+		{"aa-bb-cc-dd-ee-ff", "AABBCCDDEEFF", false},
+		{"aabb.ccdd.eeff", "AABBCCDDEEFF", false},
+		{"aabbccddeeff", "AABBCCDDEEFF", false},
+		{"3f2504e0-4f89-11d3-9a0c-0305e82c3301", "3F2504E04F8911D39A0C0305E82C3301", false},
+		{"", "", true},
+		{"aa:bb:cc:dd:ee", "", true},
+		{"gg:bb:cc:dd:ee:ff", "", true},
+		{"LX-D02", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := NormalizeAddress(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeAddress(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("NormalizeAddress(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  Target
+		devName string
+		address string
+		want    bool
+	}{
+		{"name", Target{Name: "LX-D02"}, "LX-D02", "11:22:33:44:55:66", true},
+		{"other name", Target{Name: "LX-D02"}, "Headphones", "11:22:33:44:55:66", false},
+		{"MAC without separators", Target{MACAddress: "112233445566"}, "", "11:22:33:44:55:66", true},
+		{"lowercase MAC", Target{MACAddress: "aa:bb:cc:dd:ee:ff"}, "", "AA:BB:CC:DD:EE:FF", true},
+		{"macOS UUID", Target{MACAddress: "3F2504E0-4F89-11D3-9A0C-0305E82C3301"}, "", "3f2504e0-4f89-11d3-9a0c-0305e82c3301", true},
+		{"empty name does not match unnamed device", Target{MACAddress: "aa:bb:cc:dd:ee:ff"}, "", "11:22:33:44:55:66", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.target.matches(tt.devName, tt.address); got != tt.want {
+				t.Fatalf("matches(%q, %q) = %v, want %v", tt.devName, tt.address, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Package ble abstracts the Bluetooth LE stack used to talk to the printer,
+// so that the printer driver does not depend on a particular implementation.
+package ble
+
+import "context"
+
+// Target describes the device to connect to.  The device is matched either
+// by the local name or by the address.
+type Target struct {
+	Name       string // local name of the device
+	MACAddress string // MAC address of the device, or UUID on macOS
+	Service    string // UUID of the service with TX and RX characteristics
+	TX         string // UUID of the characteristic that receives the data
+	RX         string // UUID of the characteristic that sends notifications
+}
+
+// matches returns true if the device with the given name and address is the
+// target.
+func (t Target) matches(name, address string) bool {
+	return (t.Name != "" && name == t.Name) ||
+		(t.MACAddress != "" && sameAddress(address, t.MACAddress))
+}
+
+// Backend connects to the Bluetooth LE devices.
+type Backend interface {
+	// Connect locates the target device and connects to it.  Connection
+	// is retried according to the retry policy.
+	Connect(ctx context.Context, t Target, r Retry) (Conn, error)
+}
+
+// Conn is a connection to the device.
+type Conn interface {
+	// Address returns the address of the device.
+	Address() string
+	// Write writes data to the TX characteristic without response.
+	Write(data []byte) error
+	// Notify sets the function that receives the notifications from the RX
+	// characteristic.  Calling it again replaces the function.
+	Notify(fn func([]byte)) error
+	// Disconnect disconnects from the device.
+	Disconnect() error
+}
@@ -0,0 +1,350 @@
+//go:build linux
+
+package ble
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	bluezService   = "org.bluez"
+	adapterIface   = "org.bluez.Adapter1"
+	deviceIface    = "org.bluez.Device1"
+	gattCharIface  = "org.bluez.GattCharacteristic1"
+	propertiesIntf = "org.freedesktop.DBus.Properties"
+
+	getManagedObjects = "org.freedesktop.DBus.ObjectManager.GetManagedObjects"
+	propertiesChanged = propertiesIntf + ".PropertiesChanged"
+)
+
+const (
+	// pollInterval is the interval between the checks for the discovered
+	// devices and resolved services.
+	pollInterval = 500 * time.Millisecond
+	// resolveTimeout is the maximum time to wait for BlueZ to resolve the
+	// services of the connected device.
+	resolveTimeout = 10 * time.Second
+)
+
+// managedObjects is the reply of the ObjectManager.GetManagedObjects: object
+// path -> interface -> property -> value.
+type managedObjects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+
+// BlueZ is the backend that talks to the BlueZ daemon over D-Bus directly.
+// Unlike [TinyGo], it connects to the devices already known to BlueZ, i.e.
+// paired ones, without scanning, and powers on the adapter if it was reset.
+type BlueZ struct{}
+
+// NewBlueZ returns the BlueZ backend.
+func NewBlueZ() (Backend, error) {
+	return &BlueZ{}, nil
+}
+
+func (b *BlueZ) Connect(ctx context.Context, t Target, r Retry) (Conn, error) {
+	bus, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the system bus: %w", err)
+	}
+	c, err := connectBlueZ(ctx, bus, t, r)
+	if err != nil {
+		bus.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func connectBlueZ(ctx context.Context, bus *dbus.Conn, t Target, r Retry) (*bluezConn, error) {
+	objs, err := managedObjectsOf(bus)
+	if err != nil {
+		return nil, err
+	}
+	adapterPath, ok := findAdapter(objs)
+	if !ok {
+		return nil, errors.New("no Bluetooth adapter found")
+	}
+	adapter := bus.Object(bluezService, adapterPath)
+	if powered, _ := objs[adapterPath][adapterIface]["Powered"].Value().(bool); !powered {
+		if err := adapter.SetProperty(adapterIface+".Powered", true); err != nil {
+			return nil, fmt.Errorf("failed to power on the adapter %s: %w", adapterPath, err)
+		}
+		slog.Info("Powered on Bluetooth adapter", "adapter", adapterPath)
+	}
+
+	devPath, err := discover(ctx, bus, adapter, t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate device: %w", err)
+	}
+	dev := bus.Object(bluezService, devPath)
+	if err := r.do(ctx, func() error {
+		return dev.CallWithContext(ctx, deviceIface+".Connect", 0).Err
+	}); err != nil {
+		return nil, fmt.Errorf("failed to connect to device: %w", err)
+	}
+	if err := waitServicesResolved(ctx, dev); err != nil {
+		dev.Call(deviceIface+".Disconnect", 0)
+		return nil, err
+	}
+
+	objs, err = managedObjectsOf(bus)
+	if err != nil {
+		return nil, err
+	}
+	tx, rx, err := findCharacteristics(objs, devPath, t.TX, t.RX)
+	if err != nil {
+		dev.Call(deviceIface+".Disconnect", 0)
+		return nil, fmt.Errorf("failed to locate services: %w", err)
+	}
+	address, _ := objs[devPath][deviceIface]["Address"].Value().(string)
+	c := &bluezConn{
+		bus:     bus,
+		dev:     dev,
+		address: address,
+		tx:      bus.Object(bluezService, tx),
+		rx:      bus.Object(bluezService, rx),
+		signals: make(chan *dbus.Signal, 16),
+	}
+	bus.Signal(c.signals)
+	go c.dispatch()
+	return c, nil
+}
+
+func managedObjectsOf(bus *dbus.Conn) (managedObjects, error) {
+	var objs managedObjects
+	if err := bus.Object(bluezService, "/").Call(getManagedObjects, 0).Store(&objs); err != nil {
+		return nil, fmt.Errorf("failed to list BlueZ objects: %w", err)
+	}
+	return objs, nil
+}
+
+// discover returns the object path of the target device.  If BlueZ does not
+// know the device yet, it runs the discovery until the device is found or
+// ctx is cancelled.
+func discover(ctx context.Context, bus *dbus.Conn, adapter dbus.BusObject, t Target) (dbus.ObjectPath, error) {
+	objs, err := managedObjectsOf(bus)
+	if err != nil {
+		return "", err
+	}
+	if path, ok := findDevice(objs, t); ok {
+		slog.Info("Found known printer", "path", path)
+		return path, nil
+	}
+	if err := adapter.CallWithContext(ctx, adapterIface+".StartDiscovery", 0).Err; err != nil {
+		return "", fmt.Errorf("failed to start scanning: %w", err)
+	}
+	defer func() {
+		if err := adapter.Call(adapterIface+".StopDiscovery", 0).Err; err != nil {
+			slog.Debug("Failed to stop scanning", "error", err)
+		}
+	}()
+
+	tick := time.NewTicker(pollInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("scanning was cancelled: %w", ctx.Err())
+		case <-tick.C:
+		}
+		objs, err := managedObjectsOf(bus)
+		if err != nil {
+			return "", err
+		}
+		if path, ok := findDevice(objs, t); ok {
+			slog.Info("Found printer", "path", path)
+			return path, nil
+		}
+	}
+}
+
+// waitServicesResolved waits until BlueZ resolves the GATT services of the
+// connected device.
+func waitServicesResolved(ctx context.Context, dev dbus.BusObject) error {
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+	tick := time.NewTicker(pollInterval)
+	defer tick.Stop()
+	for {
+		v, err := dev.GetProperty(deviceIface + ".ServicesResolved")
+		if err != nil {
+			return fmt.Errorf("failed to get device properties: %w", err)
+		}
+		if resolved, _ := v.Value().(bool); resolved {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("services were not resolved: %w", ctx.Err())
+		case <-tick.C:
+		}
+	}
+}
+
+// sortedPaths returns the object paths in a stable order.
+func sortedPaths(objs managedObjects) []dbus.ObjectPath {
+	return slices.Sorted(maps.Keys(objs))
+}
+
+// findAdapter returns the path of the first Bluetooth adapter.
+func findAdapter(objs managedObjects) (dbus.ObjectPath, bool) {
+	for _, path := range sortedPaths(objs) {
+		if _, ok := objs[path][adapterIface]; ok {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// findDevice returns the path of the device matching the target.
+func findDevice(objs managedObjects, t Target) (dbus.ObjectPath, bool) {
+	for _, path := range sortedPaths(objs) {
+		props, ok := objs[path][deviceIface]
+		if !ok {
+			continue
+		}
+		name, _ := props["Name"].Value().(string)
+		address, _ := props["Address"].Value().(string)
+		if t.matches(name, address) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// findCharacteristics returns the paths of the TX and RX characteristics of
+// the device.
+func findCharacteristics(objs managedObjects, devPath dbus.ObjectPath, txUUID, rxUUID string) (tx, rx dbus.ObjectPath, err error) {
+	prefix := string(devPath) + "/"
+	for _, path := range sortedPaths(objs) {
+		if !strings.HasPrefix(string(path), prefix) {
+			continue
+		}
+		props, ok := objs[path][gattCharIface]
+		if !ok {
+			continue
+		}
+		uuid, _ := props["UUID"].Value().(string)
+		switch {
+		case strings.EqualFold(uuid, txUUID):
+			tx = path
+		case strings.EqualFold(uuid, rxUUID):
+			rx = path
+		}
+	}
+	if tx == "" || rx == "" {
+		return "", "", fmt.Errorf("required characteristics not found: TX (%s) or RX (%s)", txUUID, rxUUID)
+	}
+	return tx, rx, nil
+}
+
+// bluezConn is the connection to the device established by the [BlueZ]
+// backend.
+type bluezConn struct {
+	bus     *dbus.Conn
+	dev     dbus.BusObject
+	address string
+	tx      dbus.BusObject
+	rx      dbus.BusObject
+	signals chan *dbus.Signal
+
+	mu        sync.Mutex
+	notify    func([]byte)
+	notifying bool
+}
+
+func (c *bluezConn) Address() string {
+	return c.address
+}
+
+func (c *bluezConn) Write(data []byte) error {
+	opts := map[string]dbus.Variant{"type": dbus.MakeVariant("command")} // without response
+	return c.tx.Call(gattCharIface+".WriteValue", 0, data, opts).Err
+}
+
+func (c *bluezConn) Notify(fn func([]byte)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notify = fn
+	if c.notifying {
+		return nil
+	}
+	if err := c.bus.AddMatchSignal(
+		dbus.WithMatchObjectPath(c.rx.Path()),
+		dbus.WithMatchInterface(propertiesIntf),
+		dbus.WithMatchMember("PropertiesChanged"),
+	); err != nil {
+		return fmt.Errorf("failed to subscribe to notifications: %w", err)
+	}
+	if err := c.rx.Call(gattCharIface+".StartNotify", 0).Err; err != nil {
+		return fmt.Errorf("failed to start notifications: %w", err)
+	}
+	c.notifying = true
+	return nil
+}
+
+// dispatch passes the values of the RX characteristic to the notification
+// function.  It exits when the bus connection is closed.
+func (c *bluezConn) dispatch() {
+	for sig := range c.signals {
+		if sig.Path != c.rx.Path() {
+			continue
+		}
+		data, ok := notificationValue(sig)
+		if !ok {
+			continue
+		}
+		c.mu.Lock()
+		fn := c.notify
+		c.mu.Unlock()
+		if fn != nil {
+			fn(data)
+		}
+	}
+}
+
+// notificationValue returns the new value of the characteristic from the
+// PropertiesChanged signal.
+func notificationValue(sig *dbus.Signal) ([]byte, bool) {
+	if sig.Name != propertiesChanged || len(sig.Body) < 2 {
+		return nil, false
+	}
+	if iface, _ := sig.Body[0].(string); iface != gattCharIface {
+		return nil, false
+	}
+	changed, ok := sig.Body[1].(map[string]dbus.Variant)
+	if !ok {
+		return nil, false
+	}
+	v, ok := changed["Value"]
+	if !ok {
+		return nil, false
+	}
+	data, ok := v.Value().([]byte)
+	return data, ok
+}
+
+func (c *bluezConn) Disconnect() error {
+	c.mu.Lock()
+	notifying := c.notifying
+	c.notifying = false
+	c.mu.Unlock()
+	if notifying {
+		if err := c.rx.Call(gattCharIface+".StopNotify", 0).Err; err != nil {
+			slog.Debug("Failed to stop notifications", "error", err)
+		}
+	}
+	err := c.dev.Call(deviceIface+".Disconnect", 0).Err
+	if err != nil {
+		err = fmt.Errorf("failed to disconnect: %w", err)
+	}
+	return errors.Join(err, c.bus.Close())
+}
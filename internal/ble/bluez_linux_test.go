@@ -0,0 +1,118 @@
+//go:build linux
+
+package ble
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	testTX = "0000ffe1-0000-1000-8000-00805f9b34fb"
+	testRX = "0000ffe2-0000-1000-8000-00805f9b34fb"
+)
+
+func testObjects() managedObjects {
+	v := dbus.MakeVariant
+	return managedObjects{
+		"/org/bluez/hci0": {
+			adapterIface: {"Powered": v(true)},
+		},
+		"/org/bluez/hci0/dev_11_22_33_44_55_66": {
+			deviceIface: {"Name": v("Headphones"), "Address": v("11:22:33:44:55:66")},
+		},
+		"/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF": {
+			deviceIface: {"Name": v("LX-D02"), "Address": v("AA:BB:CC:DD:EE:FF")},
+		},
+		"/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF/service0010": {
+			"org.bluez.GattService1": {"UUID": v("0000ffe0-0000-1000-8000-00805f9b34fb")},
+		},
+		"/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF/service0010/char0011": {
+			gattCharIface: {"UUID": v(testTX)},
+		},
+		"/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF/service0010/char0014": {
+			gattCharIface: {"UUID": v(testRX)},
+		},
+		"/org/bluez/hci0/dev_11_22_33_44_55_66/service0010/char0011": {
+			gattCharIface: {"UUID": v(testTX)},
+		},
+	}
+}
+
+func TestFindAdapter(t *testing.T) {
+	path, ok := findAdapter(testObjects())
+	if !ok || path != "/org/bluez/hci0" {
+		t.Fatalf("findAdapter() = %q, %v", path, ok)
+	}
+	if _, ok := findAdapter(managedObjects{}); ok {
+		t.Fatal("findAdapter() found an adapter in empty objects")
+	}
+}
+
+func TestFindDevice(t *testing.T) {
+	tests := []struct {
+		name   string
+		target Target
+		want   dbus.ObjectPath
+		wantOK bool
+	}{
+		{"by name", Target{Name: "LX-D02"}, "/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF", true},
+		{"by MAC", Target{MACAddress: "11:22:33:44:55:66"}, "/org/bluez/hci0/dev_11_22_33_44_55_66", true},
+		{"by lowercase MAC", Target{MACAddress: "aa:bb:cc:dd:ee:ff"}, "/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF", true},
+		{"unknown", Target{Name: "LX-D03"}, "", false},
+		{"empty target", Target{}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := findDevice(testObjects(), tt.target)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("findDevice() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFindCharacteristics(t *testing.T) {
+	tx, rx, err := findCharacteristics(testObjects(), "/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF", testTX, testRX)
+	if err != nil {
+		t.Fatalf("findCharacteristics() error = %v", err)
+	}
+	if tx != "/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF/service0010/char0011" {
+		t.Errorf("tx = %q", tx)
+	}
+	if rx != "/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF/service0010/char0014" {
+		t.Errorf("rx = %q", rx)
+	}
+
+	// the other device has only the TX characteristic.
+	if _, _, err := findCharacteristics(testObjects(), "/org/bluez/hci0/dev_11_22_33_44_55_66", testTX, testRX); err == nil {
+		t.Fatal("findCharacteristics() succeeded without RX characteristic")
+	}
+}
+
+func TestNotificationValue(t *testing.T) {
+	changed := func(iface string, props map[string]dbus.Variant) *dbus.Signal {
+		return &dbus.Signal{Name: propertiesChanged, Body: []any{iface, props, []string{}}}
+	}
+	tests := []struct {
+		name   string
+		sig    *dbus.Signal
+		want   []byte
+		wantOK bool
+	}{
+		{"value", changed(gattCharIface, map[string]dbus.Variant{"Value": dbus.MakeVariant([]byte{0x5a, 0x02})}), []byte{0x5a, 0x02}, true},
+		{"other property", changed(gattCharIface, map[string]dbus.Variant{"Notifying": dbus.MakeVariant(true)}), nil, false},
+		{"other interface", changed(deviceIface, map[string]dbus.Variant{"Value": dbus.MakeVariant([]byte{1})}), nil, false},
+		{"other signal", &dbus.Signal{Name: "org.bluez.Foo.Bar", Body: []any{gattCharIface}}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := notificationValue(tt.sig)
+			if !bytes.Equal(got, tt.want) || ok != tt.wantOK {
+				t.Fatalf("notificationValue() = %x, %v, want %x, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
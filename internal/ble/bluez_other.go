@@ -0,0 +1,10 @@
+//go:build !linux
+
+package ble
+
+import "errors"
+
+// NewBlueZ returns an error, as BlueZ is only available on Linux.
+func NewBlueZ() (Backend, error) {
+	return nil, errors.New("BlueZ backend is only available on Linux")
+}
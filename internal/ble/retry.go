@@ -0,0 +1,88 @@
+package ble
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+// Retry is the policy of the connection retries.  The wait between the
+// attempts grows exponentially from Wait up to MaxWait, with a random
+// jitter, so that several clients don't retry in lockstep.
+type Retry struct {
+	Attempts int           // number of connection attempts, at least one is made
+	Wait     time.Duration // wait before the second attempt
+	MaxWait  time.Duration // maximum wait between the attempts, zero means no limit
+}
+
+func (r Retry) attempts() int {
+	return max(1, r.Attempts)
+}
+
+// backoff returns the wait after the failed attempt n, starting from 0.  The
+// wait is a random duration between half and the full exponential delay.
+func (r Retry) backoff(n int) time.Duration {
+	if r.Wait <= 0 {
+		return 0
+	}
+	d := r.Wait
+	for range n {
+		if r.MaxWait > 0 && d >= r.MaxWait {
+			break
+		}
+		d *= 2
+	}
+	if r.MaxWait > 0 {
+		d = min(d, r.MaxWait)
+	}
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+// stopError stops the retries, see [stop].
+type stopError struct{ err error }
+
+func (e stopError) Error() string { return e.err.Error() }
+func (e stopError) Unwrap() error { return e.err }
+
+// stop marks the error as permanent, so that [Retry.do] does not retry.
+func stop(err error) error {
+	return stopError{err}
+}
+
+// do calls fn until it succeeds, the attempts are exhausted, fn returns the
+// error marked with [stop], or the context is done.  The returned error
+// holds the errors of all attempts.
+func (r Retry) do(ctx context.Context, fn func() error) error {
+	var errs []error
+	for attempt := range r.attempts() {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		var se stopError
+		if errors.As(err, &se) {
+			return se.err
+		}
+		errs = append(errs, fmt.Errorf("attempt %d: %w", attempt+1, err))
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		if attempt == r.attempts()-1 {
+			break
+		}
+		wait := r.backoff(attempt)
+		slog.Warn("Failed to connect to device, retrying", "attempt", attempt+1, "wait", wait, "error", err)
+		select {
+		case <-ctx.Done():
+			errs = append(errs, ctx.Err())
+			return errors.Join(errs...)
+		case <-time.After(wait):
+		}
+	}
+	return errors.Join(errs...)
+}
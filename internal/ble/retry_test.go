@@ -0,0 +1,85 @@
+package ble
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetry_backoff(t *testing.T) {
+	r := Retry{Wait: 100 * time.Millisecond, MaxWait: time.Second}
+	tests := []struct {
+		n        int
+		min, max time.Duration
+	}{
+		{0, 50 * time.Millisecond, 100 * time.Millisecond},
+		{1, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond, 800 * time.Millisecond},
+		{4, 500 * time.Millisecond, time.Second}, // capped
+		{100, 500 * time.Millisecond, time.Second},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			if got := r.backoff(tt.n); got < tt.min || tt.max < got {
+				t.Fatalf("backoff(%d) = %v, want in [%v, %v]", tt.n, got, tt.min, tt.max)
+			}
+		}
+	}
+	if got := (Retry{}).backoff(3); got != 0 {
+		t.Errorf("zero policy backoff = %v, want 0", got)
+	}
+}
+
+func TestRetry_do(t *testing.T) {
+	ctx := context.Background()
+	r := Retry{Attempts: 3, Wait: time.Millisecond}
+
+	t.Run("succeeds", func(t *testing.T) {
+		var calls int
+		err := r.do(ctx, func() error {
+			calls++
+			if calls < 2 {
+				return errors.New("busy")
+			}
+			return nil
+		})
+		if err != nil || calls != 2 {
+			t.Errorf("do = %v after %d calls", err, calls)
+		}
+	})
+	t.Run("reports all attempts", func(t *testing.T) {
+		errBusy := errors.New("busy")
+		var calls int
+		err := r.do(ctx, func() error { calls++; return errBusy })
+		if calls != 3 {
+			t.Errorf("calls = %d, want 3", calls)
+		}
+		if !errors.Is(err, errBusy) {
+			t.Errorf("do error = %v, want %v", err, errBusy)
+		}
+		for _, want := range []string{"attempt 1: busy", "attempt 3: busy"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("do error %q does not contain %q", err, want)
+			}
+		}
+	})
+	t.Run("stops", func(t *testing.T) {
+		errFatal := errors.New("fatal")
+		var calls int
+		err := r.do(ctx, func() error { calls++; return stop(errFatal) })
+		if calls != 1 || err != errFatal {
+			t.Errorf("do = %v after %d calls", err, calls)
+		}
+	})
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		r := Retry{Attempts: 3, Wait: time.Hour}
+		var calls int
+		err := r.do(ctx, func() error { calls++; cancel(); return errors.New("busy") })
+		if calls != 1 || !errors.Is(err, context.Canceled) {
+			t.Errorf("do = %v after %d calls", err, calls)
+		}
+	})
+}
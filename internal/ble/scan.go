@@ -0,0 +1,78 @@
+package ble
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Candidate is the device found by [Scanner.Scan].
+type Candidate struct {
+	Name    string // local name of the device, may be empty
+	Address string // MAC address of the device, or UUID on macOS
+	RSSI    int16  // signal strength, dBm
+	// Services are the UUIDs of the services that the device advertises,
+	// lower case, may be empty.
+	Services []string
+}
+
+// Scanner is implemented by the backends that can scan for the devices.
+type Scanner interface {
+	// Scan scans for the devices for the duration d, or until ctx is
+	// done, and returns the devices that match the target, strongest
+	// signal first.  The target without the name and address matches all
+	// devices that advertise a name.
+	Scan(ctx context.Context, t Target, d time.Duration) ([]Candidate, error)
+}
+
+// accepts returns true if the scanned device with the given name and
+// address is the candidate for the target.
+func (t Target) accepts(name, address string) bool {
+	if t.Name == "" && t.MACAddress == "" {
+		return name != ""
+	}
+	return t.matches(name, address)
+}
+
+// candidateSet collects the scan results, the device advertises many times
+// during the scan, but is listed once.  It is safe for concurrent use.
+type candidateSet struct {
+	mu     sync.Mutex
+	byAddr map[string]Candidate
+}
+
+// add adds the candidate, or updates the signal strength of the known one.
+func (s *candidateSet) add(c Candidate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.byAddr == nil {
+		s.byAddr = make(map[string]Candidate)
+	}
+	if prev, ok := s.byAddr[c.Address]; ok {
+		if c.Name == "" {
+			c.Name = prev.Name // name is not in every advertisement
+		}
+		for _, svc := range prev.Services {
+			if !slices.Contains(c.Services, svc) {
+				c.Services = append(c.Services, svc)
+			}
+		}
+	}
+	s.byAddr[c.Address] = c
+}
+
+// list returns the candidates, strongest signal first.
+func (s *candidateSet) list() []Candidate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Candidate, 0, len(s.byAddr))
+	for _, c := range s.byAddr {
+		out = append(out, c)
+	}
+	slices.SortFunc(out, func(a, b Candidate) int {
+		return cmp.Or(cmp.Compare(b.RSSI, a.RSSI), cmp.Compare(a.Address, b.Address))
+	})
+	return out
+}
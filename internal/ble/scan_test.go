@@ -0,0 +1,45 @@
+package ble
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTarget_accepts(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       Target
+		device, addr string
+		want         bool
+	}{
+		{"any named", Target{}, "LX-D02", "AA:BB:CC:DD:EE:FF", true},
+		{"any unnamed", Target{}, "", "AA:BB:CC:DD:EE:FF", false},
+		{"by name", Target{Name: "LX-D02"}, "LX-D02", "AA:BB:CC:DD:EE:FF", true},
+		{"other name", Target{Name: "LX-D02"}, "GB01", "AA:BB:CC:DD:EE:FF", false},
+		{"by address", Target{MACAddress: "AABBCCDDEEFF"}, "", "aa:bb:cc:dd:ee:ff", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.target.accepts(tt.device, tt.addr); got != tt.want {
+				t.Errorf("accepts(%q, %q) = %t, want %t", tt.device, tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCandidateSet(t *testing.T) {
+	var s candidateSet
+	s.add(Candidate{Name: "LX-D02", Address: "A", RSSI: -80, Services: []string{"ffe0"}})
+	s.add(Candidate{Name: "GB01", Address: "B", RSSI: -60})
+	s.add(Candidate{Address: "A", RSSI: -50, Services: []string{"180a"}}) // scan response without the name
+	s.add(Candidate{Name: "M02", Address: "C", RSSI: -60})
+
+	want := []Candidate{
+		{Name: "LX-D02", Address: "A", RSSI: -50, Services: []string{"180a", "ffe0"}},
+		{Name: "GB01", Address: "B", RSSI: -60},
+		{Name: "M02", Address: "C", RSSI: -60},
+	}
+	if got := s.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("list() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,210 @@
+//go:build !js
+
+package ble
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// TinyGo is the backend that uses the tinygo.org/x/bluetooth package.  It
+// works on Linux, macOS and Windows.
+type TinyGo struct {
+	adapter *bluetooth.Adapter
+}
+
+// NewTinyGo returns the backend that uses the adapter.  The adapter must be
+// enabled.
+func NewTinyGo(adapter *bluetooth.Adapter) *TinyGo {
+	return &TinyGo{adapter: adapter}
+}
+
+func (b *TinyGo) Connect(ctx context.Context, t Target, r Retry) (Conn, error) {
+	device, err := b.connectWithRetries(ctx, t, r)
+	if err != nil {
+		return nil, err
+	}
+	txrx, err := locateCharacteristics(device, t.TX, t.RX)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate services: %w", err)
+	}
+	return &tinyGoConn{dev: device, txrx: txrx}, nil
+}
+
+func (b *TinyGo) connectWithRetries(ctx context.Context, t Target, r Retry) (bluetooth.Device, error) {
+	var device bluetooth.Device
+	err := r.do(ctx, func() error {
+		foundDevice, err := b.locateDevice(ctx, t)
+		if err != nil {
+			return stop(fmt.Errorf("failed to locate device: %w", err))
+		}
+		device, err = b.adapter.Connect(foundDevice.Address, bluetooth.ConnectionParams{})
+		return err
+	})
+	if err != nil {
+		return bluetooth.Device{}, fmt.Errorf("failed to connect to device: %w", err)
+	}
+	return device, nil
+}
+
+func (b *TinyGo) locateDevice(ctx context.Context, t Target) (bluetooth.ScanResult, error) {
+	var (
+		d        bluetooth.ScanResult
+		canceled bool
+	)
+	err := b.adapter.Scan(func(a *bluetooth.Adapter, sr bluetooth.ScanResult) {
+		if ctx.Err() != nil {
+			slog.WarnContext(ctx, "Scan cancelled", "error", ctx.Err())
+			canceled = true
+			if err := a.StopScan(); err != nil {
+				slog.ErrorContext(ctx, "Failed to stop scanning", "error", err)
+			}
+			return
+		}
+		if t.matches(sr.LocalName(), sr.Address.String()) {
+			slog.Info("Found printer", "name", sr.LocalName(), "address", sr.Address)
+			d = sr
+			if err := a.StopScan(); err != nil {
+				slog.ErrorContext(ctx, "Failed to stop scanning", "error", err)
+			}
+			return
+		}
+	})
+	if err != nil {
+		return d, fmt.Errorf("failed to start scanning: %w", err)
+	} else if canceled {
+		return d, fmt.Errorf("scanning was cancelled: %w", ctx.Err())
+	}
+	slog.DebugContext(ctx, "Scanning complete", "device", d.Address, "name", d.LocalName())
+	return d, nil
+}
+
+// Scan scans for the devices that match the target for the duration d.  If
+// ctx is cancelled, the devices found so far are returned with the error.
+func (b *TinyGo) Scan(ctx context.Context, t Target, d time.Duration) ([]Candidate, error) {
+	scanCtx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	stopScan := sync.OnceFunc(func() {
+		if err := b.adapter.StopScan(); err != nil {
+			slog.ErrorContext(ctx, "Failed to stop scanning", "error", err)
+		}
+	})
+	defer context.AfterFunc(scanCtx, stopScan)()
+
+	var found candidateSet
+	err := b.adapter.Scan(func(a *bluetooth.Adapter, sr bluetooth.ScanResult) {
+		if scanCtx.Err() != nil {
+			// expired before the scan started
+			if err := a.StopScan(); err != nil {
+				slog.ErrorContext(ctx, "Failed to stop scanning", "error", err)
+			}
+			return
+		}
+		if name, address := sr.LocalName(), sr.Address.String(); t.accepts(name, address) {
+			slog.DebugContext(ctx, "Found device", "name", name, "address", address, "rssi", sr.RSSI)
+			found.add(Candidate{Name: name, Address: address, RSSI: sr.RSSI, Services: serviceUUIDs(sr)})
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start scanning: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return found.list(), fmt.Errorf("scanning was cancelled: %w", err)
+	}
+	return found.list(), nil
+}
+
+var _ Scanner = (*TinyGo)(nil)
+
+// serviceUUIDs returns the service UUIDs from the advertisement.
+func serviceUUIDs(sr bluetooth.ScanResult) []string {
+	uuids := sr.ServiceUUIDs()
+	if len(uuids) == 0 {
+		return nil
+	}
+	out := make([]string, len(uuids))
+	for i, u := range uuids {
+		out[i] = strings.ToLower(u.String())
+	}
+	return out
+}
+
+type txrx struct {
+	tx bluetooth.DeviceCharacteristic
+	rx bluetooth.DeviceCharacteristic
+}
+
+// locateCharacteristics discovers the TX and RX characteristics of the device.
+func locateCharacteristics(device bluetooth.Device, tx string, rx string) (txrx, error) {
+	var zero txrx
+	services, err := device.DiscoverServices(nil) // all
+	if err != nil {
+		return zero, fmt.Errorf("failed to discover services: %w", err)
+	}
+	if len(services) == 0 {
+		return zero, fmt.Errorf("no services found on device %s", device.Address)
+	}
+	slog.Debug("Discovered services", "services", services)
+	var txrx txrx
+	rxOK, txOK := false, false
+	for _, service := range services {
+		chars, err := service.DiscoverCharacteristics(nil) // all
+		if err != nil {
+			return zero, fmt.Errorf("failed to discover characteristics for service %s: %w", service.UUID().String(), err)
+		}
+		if len(chars) == 0 {
+			continue
+		}
+		for _, char := range chars {
+			slog.Debug("Discovered characteristic", "uuid", char.UUID().String())
+			if char.UUID().String() == tx {
+				slog.Debug("Found TX characteristic", "uuid", char.UUID().String())
+				txrx.tx = char
+				txOK = true
+			} else if char.UUID().String() == rx {
+				slog.Debug("Found RX characteristic", "uuid", char.UUID().String())
+				txrx.rx = char
+				rxOK = true
+			}
+			if txOK && rxOK {
+				break
+			}
+		}
+	}
+	if !txOK || !rxOK {
+		return txrx, fmt.Errorf("required characteristics not found: TX (%s) or RX (%s)", tx, rx)
+	}
+	slog.Debug("Required characteristics found", "txChar", tx, "rxChar", rx)
+
+	return txrx, nil
+}
+
+// tinyGoConn is the connection to the device established by the [TinyGo]
+// backend.
+type tinyGoConn struct {
+	dev  bluetooth.Device
+	txrx txrx
+}
+
+func (c *tinyGoConn) Address() string {
+	return c.dev.Address.String()
+}
+
+func (c *tinyGoConn) Write(data []byte) error {
+	_, err := c.txrx.tx.WriteWithoutResponse(data)
+	return err
+}
+
+func (c *tinyGoConn) Notify(fn func([]byte)) error {
+	return c.txrx.rx.EnableNotifications(fn)
+}
+
+func (c *tinyGoConn) Disconnect() error {
+	return c.dev.Disconnect()
+}
@@ -0,0 +1,215 @@
+//go:build js && wasm
+
+package ble
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"syscall/js"
+)
+
+// WebBluetooth is the backend that uses the Web Bluetooth API of the
+// browser.  The browser shows the device chooser on Connect, so it must be
+// called in response to the user action, i.e. a button click.
+type WebBluetooth struct {
+	bluetooth js.Value
+}
+
+// NewWebBluetooth returns the Web Bluetooth backend.  It fails if the
+// browser does not support Web Bluetooth.
+func NewWebBluetooth() (Backend, error) {
+	bt := js.Global().Get("navigator").Get("bluetooth")
+	if bt.IsUndefined() {
+		return nil, errors.New("this browser does not support Web Bluetooth")
+	}
+	return &WebBluetooth{bluetooth: bt}, nil
+}
+
+func (b *WebBluetooth) Connect(ctx context.Context, t Target, r Retry) (Conn, error) {
+	filter := map[string]any{}
+	if t.Name != "" {
+		filter["name"] = t.Name
+	} else {
+		filter["services"] = []any{t.Service}
+	}
+	opts := map[string]any{
+		"filters":          []any{filter},
+		"optionalServices": []any{t.Service},
+	}
+	device, err := await(ctx, b.bluetooth.Call("requestDevice", js.ValueOf(opts)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate device: %w", err)
+	}
+	slog.Info("Found printer", "name", device.Get("name").String(), "id", device.Get("id").String())
+
+	var server js.Value
+	if err := r.do(ctx, func() error {
+		var err error
+		server, err = await(ctx, device.Get("gatt").Call("connect"))
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("failed to connect to device: %w", err)
+	}
+
+	c, err := locateWebCharacteristics(ctx, server, t)
+	if err != nil {
+		device.Get("gatt").Call("disconnect")
+		return nil, fmt.Errorf("failed to locate services: %w", err)
+	}
+	c.device = device
+	return c, nil
+}
+
+func locateWebCharacteristics(ctx context.Context, server js.Value, t Target) (*webConn, error) {
+	service, err := await(ctx, server.Call("getPrimaryService", t.Service))
+	if err != nil {
+		return nil, fmt.Errorf("service %s: %w", t.Service, err)
+	}
+	tx, err := await(ctx, service.Call("getCharacteristic", t.TX))
+	if err != nil {
+		return nil, fmt.Errorf("required characteristic not found: TX (%s): %w", t.TX, err)
+	}
+	rx, err := await(ctx, service.Call("getCharacteristic", t.RX))
+	if err != nil {
+		return nil, fmt.Errorf("required characteristic not found: RX (%s): %w", t.RX, err)
+	}
+	return &webConn{tx: tx, rx: rx}, nil
+}
+
+// notifyQueueSize is the number of notifications that can be queued for the
+// notification function.
+const notifyQueueSize = 64
+
+// webConn is the connection to the device established by the
+// [WebBluetooth] backend.
+type webConn struct {
+	device js.Value
+	tx     js.Value
+	rx     js.Value
+
+	mu       sync.Mutex
+	notify   func([]byte)
+	listener js.Func
+	queue    chan []byte
+}
+
+// Address returns the identifier of the device.  Web Bluetooth hides the
+// MAC addresses, the identifier is assigned by the browser.
+func (c *webConn) Address() string {
+	return c.device.Get("id").String()
+}
+
+func (c *webConn) Write(data []byte) error {
+	buf := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(buf, data)
+	_, err := await(context.Background(), c.tx.Call("writeValueWithoutResponse", buf))
+	return err
+}
+
+func (c *webConn) Notify(fn func([]byte)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notify = fn
+	if c.listener.Truthy() {
+		return nil
+	}
+	c.listener = js.FuncOf(func(this js.Value, args []js.Value) any {
+		view := args[0].Get("target").Get("value") // DataView
+		data := make([]byte, view.Get("byteLength").Int())
+		js.CopyBytesToGo(data, js.Global().Get("Uint8Array").New(view.Get("buffer"), view.Get("byteOffset"), view.Get("byteLength")))
+		// the listener must not block the browser event loop, the
+		// notifications are delivered in order by the goroutine.
+		select {
+		case c.queue <- data:
+		default:
+			slog.Warn("Notification queue is full, dropping notification", "data", fmt.Sprintf("% X", data))
+		}
+		return nil
+	})
+	c.queue = make(chan []byte, notifyQueueSize)
+	go c.deliver(c.queue)
+	c.rx.Call("addEventListener", "characteristicvaluechanged", c.listener)
+	if _, err := await(context.Background(), c.rx.Call("startNotifications")); err != nil {
+		return fmt.Errorf("failed to start notifications: %w", err)
+	}
+	return nil
+}
+
+// deliver passes the queued notifications to the notification function.
+func (c *webConn) deliver(queue <-chan []byte) {
+	for data := range queue {
+		c.mu.Lock()
+		fn := c.notify
+		c.mu.Unlock()
+		if fn != nil {
+			fn(data)
+		}
+	}
+}
+
+func (c *webConn) Disconnect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.listener.Truthy() {
+		c.rx.Call("removeEventListener", "characteristicvaluechanged", c.listener)
+		c.listener.Release()
+		c.listener = js.Func{}
+		close(c.queue)
+	}
+	c.device.Get("gatt").Call("disconnect")
+	return nil
+}
+
+// await waits for the JavaScript promise to settle, and returns its value
+// or the error it was rejected with.
+func await(ctx context.Context, promise js.Value) (js.Value, error) {
+	type result struct {
+		v   js.Value
+		err error
+	}
+	ch := make(chan result, 1)
+	then := js.FuncOf(func(this js.Value, args []js.Value) any {
+		ch <- result{v: arg0(args)}
+		return nil
+	})
+	catch := js.FuncOf(func(this js.Value, args []js.Value) any {
+		ch <- result{err: jsError(arg0(args))}
+		return nil
+	})
+	release := func() {
+		then.Release()
+		catch.Release()
+	}
+	promise.Call("then", then, catch)
+
+	select {
+	case r := <-ch:
+		release()
+		return r.v, r.err
+	case <-ctx.Done():
+		// the functions can only be released after the promise settles.
+		go func() {
+			<-ch
+			release()
+		}()
+		return js.Undefined(), ctx.Err()
+	}
+}
+
+func arg0(args []js.Value) js.Value {
+	if len(args) == 0 {
+		return js.Undefined()
+	}
+	return args[0]
+}
+
+// jsError converts the rejection reason to the Go error.
+func jsError(v js.Value) error {
+	if v.Type() == js.TypeObject && v.Get("message").Type() == js.TypeString {
+		return errors.New(v.Get("message").String())
+	}
+	return errors.New(v.String())
+}
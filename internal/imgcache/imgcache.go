@@ -0,0 +1,253 @@
+// Package imgcache implements the disk cache for the images fetched over
+// HTTP, i.e. the logos referenced by URL in the document scripts.  Cached
+// images are revalidated with the server using ETag and Last-Modified, and
+// the least recently used images are evicted once the cache grows over its
+// size limit.
+package imgcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxSize is the default size limit of the cache.
+	DefaultMaxSize = 64 << 20
+	// MaxImageSize is the maximum size of a single image.
+	MaxImageSize = 16 << 20
+
+	defaultTimeout = 30 * time.Second
+
+	dataExt = ".data"
+	metaExt = ".json"
+)
+
+// ErrTooLarge is returned if the image exceeds [MaxImageSize].
+var ErrTooLarge = fmt.Errorf("image exceeds %d bytes", MaxImageSize)
+
+// Cache is the LRU disk cache of the images.  It is safe for concurrent
+// use.
+type Cache struct {
+	dir     string
+	maxSize int64
+	client  *http.Client
+
+	mu sync.Mutex
+}
+
+// meta is the metadata of the cached image, stored next to it.
+type meta struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Fetched      time.Time `json:"fetched"`
+}
+
+// New returns the cache in the directory dir, that is created if it does
+// not exist.  If maxSize is zero, [DefaultMaxSize] is used.  If client is
+// nil, the client with the 30s timeout is used.
+func New(dir string, maxSize int64, client *http.Client) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create image cache directory: %w", err)
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	return &Cache{dir: dir, maxSize: maxSize, client: client}, nil
+}
+
+// Get returns the image at the url.  The cached image is revalidated with
+// the server, and returned as is, if the server reports that it is not
+// modified, or if the server can't be reached.
+func (c *Cache) Get(ctx context.Context, url string) ([]byte, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("not an http url: %q", url)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cacheKey(url)
+	m, data, cached := c.load(key)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cached {
+		if m.ETag != "" {
+			req.Header.Set("If-None-Match", m.ETag)
+		}
+		if m.LastModified != "" {
+			req.Header.Set("If-Modified-Since", m.LastModified)
+		}
+	}
+	lg := slog.With("url", url)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if cached {
+			lg.WarnContext(ctx, "using the cached image, server is not available", "error", err)
+			c.touch(key)
+			return data, nil
+		}
+		return nil, fmt.Errorf("fetch image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+		lg.DebugContext(ctx, "cached image is up to date")
+		c.touch(key)
+		return data, nil
+	case resp.StatusCode == http.StatusOK:
+		data, err := io.ReadAll(io.LimitReader(resp.Body, MaxImageSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("fetch image: %w", err)
+		}
+		if len(data) > MaxImageSize {
+			return nil, fmt.Errorf("fetch image %s: %w", url, ErrTooLarge)
+		}
+		m := meta{
+			URL:          url,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			Fetched:      time.Now(),
+		}
+		if err := c.store(key, m, data); err != nil {
+			lg.WarnContext(ctx, "failed to cache the image", "error", err)
+		} else {
+			c.evict(key)
+		}
+		return data, nil
+	case resp.StatusCode >= http.StatusInternalServerError && cached:
+		lg.WarnContext(ctx, "using the cached image, server error", "status", resp.Status)
+		c.touch(key)
+		return data, nil
+	default:
+		return nil, fmt.Errorf("fetch image %s: %s", url, resp.Status)
+	}
+}
+
+// cacheKey returns the file name of the cached url, without extension.
+func cacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Cache) path(key, ext string) string {
+	return filepath.Join(c.dir, key+ext)
+}
+
+// load returns the cached image and its metadata.
+func (c *Cache) load(key string) (meta, []byte, bool) {
+	mb, err := os.ReadFile(c.path(key, metaExt))
+	if err != nil {
+		return meta{}, nil, false
+	}
+	var m meta
+	if err := json.Unmarshal(mb, &m); err != nil {
+		return meta{}, nil, false
+	}
+	data, err := os.ReadFile(c.path(key, dataExt))
+	if err != nil {
+		return meta{}, nil, false
+	}
+	return m, data, true
+}
+
+// store writes the image and its metadata.
+func (c *Cache) store(key string, m meta, data []byte) error {
+	mb, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(c.path(key, dataExt), data); err != nil {
+		return err
+	}
+	return writeFile(c.path(key, metaExt), mb)
+}
+
+// writeFile writes the file atomically.
+func writeFile(name string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(name), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, bytes.NewReader(data)); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// touch marks the image as recently used.
+func (c *Cache) touch(key string) {
+	now := time.Now()
+	if err := os.Chtimes(c.path(key, dataExt), now, now); err != nil {
+		slog.Debug("failed to update the image access time", "error", err)
+	}
+}
+
+// evict removes the least recently used images until the cache fits the
+// size limit.  The image keep is never removed.
+func (c *Cache) evict(keep string) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	type file struct {
+		key  string
+		size int64
+		used time.Time
+	}
+	var (
+		files []file
+		total int64
+	)
+	for _, e := range entries {
+		key, ok := strings.CutSuffix(e.Name(), dataExt)
+		if !ok {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{key: key, size: fi.Size(), used: fi.ModTime()})
+		total += fi.Size()
+	}
+	slices.SortFunc(files, func(a, b file) int { return a.used.Compare(b.used) })
+	for _, f := range files {
+		if total <= c.maxSize {
+			break
+		}
+		if f.key == keep {
+			continue
+		}
+		if err := errors.Join(os.Remove(c.path(f.key, dataExt)), os.Remove(c.path(f.key, metaExt))); err != nil {
+			slog.Debug("failed to evict the cached image", "error", err)
+		}
+		total -= f.size
+	}
+}
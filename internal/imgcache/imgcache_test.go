@@ -0,0 +1,140 @@
+package imgcache
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testServer serves the body with the ETag, and counts the full responses.
+type testServer struct {
+	body    atomic.Pointer[[]byte]
+	full    atomic.Int32 // 200 responses
+	revalid atomic.Int32 // 304 responses
+	fail    atomic.Bool
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.fail.Load() {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+		return
+	}
+	body := *s.body.Load()
+	etag := `"` + cacheKey(string(body))[:8] + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		s.revalid.Add(1)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.full.Add(1)
+	w.Header().Set("ETag", etag)
+	w.Write(body)
+}
+
+func newTestServer(t *testing.T, body string) (*testServer, string) {
+	t.Helper()
+	s := new(testServer)
+	b := []byte(body)
+	s.body.Store(&b)
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv.URL + "/logo.png"
+}
+
+func TestCache_Get(t *testing.T) {
+	s, url := newTestServer(t, "logo v1")
+	c, err := New(t.TempDir(), 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(want string) {
+		t.Helper()
+		got, err := c.Get(t.Context(), url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Fatalf("Get = %q, want %q", got, want)
+		}
+	}
+
+	get("logo v1")
+	get("logo v1")
+	if full, revalid := s.full.Load(), s.revalid.Load(); full != 1 || revalid != 1 {
+		t.Errorf("full = %d, revalidated = %d, want 1 and 1", full, revalid)
+	}
+
+	// modified on the server
+	b := []byte("logo v2")
+	s.body.Store(&b)
+	get("logo v2")
+	if full := s.full.Load(); full != 2 {
+		t.Errorf("full = %d, want 2", full)
+	}
+
+	// server is down, the cached image is used
+	s.fail.Store(true)
+	get("logo v2")
+}
+
+func TestCache_GetErrors(t *testing.T) {
+	c, err := New(t.TempDir(), 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(t.Context(), "file:///etc/passwd"); err == nil {
+		t.Error("non-http url is accepted")
+	}
+
+	s, url := newTestServer(t, "logo")
+	s.fail.Store(true)
+	if _, err := c.Get(t.Context(), url); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("error = %v, want 503", err)
+	}
+
+	big := bytes.Repeat([]byte{'x'}, MaxImageSize+1)
+	s.body.Store(&big)
+	s.fail.Store(false)
+	if _, err := c.Get(t.Context(), url); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("error = %v, want %v", err, ErrTooLarge)
+	}
+}
+
+func TestCache_evict(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New(dir, 25, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var urls []string
+	for _, body := range []string{"first image", "second image", "third image"} {
+		_, url := newTestServer(t, body)
+		if _, err := c.Get(t.Context(), url); err != nil {
+			t.Fatal(err)
+		}
+		// make the access times distinct
+		old := time.Now().Add(-time.Duration(10-len(urls)) * time.Minute)
+		os.Chtimes(c.path(cacheKey(url), dataExt), old, old)
+		urls = append(urls, url)
+	}
+	cached := func(url string) bool {
+		_, err := os.Stat(c.path(cacheKey(url), dataExt))
+		return err == nil
+	}
+	if cached(urls[0]) {
+		t.Error("least recently used image is not evicted")
+	}
+	if !cached(urls[1]) || !cached(urls[2]) {
+		t.Error("recently used images are evicted")
+	}
+	if m, _ := filepath.Glob(filepath.Join(dir, "*"+metaExt)); len(m) != 2 {
+		t.Errorf("got %d metadata files, want 2", len(m))
+	}
+}
@@ -0,0 +1,64 @@
+package ippsrv
+
+import (
+	"html/template"
+	"slices"
+
+	"github.com/rusq/thermoprint"
+)
+
+// adminTemplate is the server status page, served at /admin/.
+var adminTemplate = template.Must(template.New("admin").Funcs(template.FuncMap{
+	"newestFirst": func(alerts []thermoprint.Alert) []thermoprint.Alert {
+		alerts = slices.Clone(alerts)
+		slices.Reverse(alerts)
+		return alerts
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Thermoprint server</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+.critical { color: #b00; }
+.warning { color: #a60; }
+</style>
+</head>
+<body>
+<h1>Thermoprint server</h1>
+<p>Up {{.Uptime.Round 1e9}}, listening on {{.ListenAddr}}.</p>
+{{range .Printers}}
+<h2>{{.MakeAndModel}} ({{.Name}})</h2>
+<p>State: {{.State}}, reasons: {{range $i, $r := .StateReasons}}{{if $i}}, {{end}}{{$r}}{{end}}</p>
+<h3>Alerts</h3>
+{{with .Alerts}}
+<table>
+<tr><th>Time</th><th>Severity</th><th>Alert</th></tr>
+{{range newestFirst .}}<tr class="{{.Severity}}"><td>{{.Time.Format "2006-01-02 15:04:05"}}</td><td>{{.Severity}}</td><td>{{.Message}}</td></tr>
+{{end}}</table>
+{{else}}
+<p>No alerts.</p>
+{{end}}
+{{with .UnknownNotifications}}
+<h3>Unknown notifications</h3>
+<table>
+<tr><th>Time</th><th>Data</th></tr>
+{{range .}}<tr><td>{{.Time.Format "2006-01-02 15:04:05"}}</td><td><code>{{printf "% x" .Data}}</code></td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+<h2>Jobs</h2>
+{{with .Jobs}}
+<table>
+<tr><th>ID</th><th>Name</th><th>User</th><th>State</th></tr>
+{{range .}}<tr><td>{{.ID}}</td><td>{{.Name}}</td><td>{{.Username}}</td><td>{{.State}}</td></tr>
+{{end}}</table>
+{{else}}
+<p>No jobs.</p>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,70 @@
+package ippsrv
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rusq/thermoprint"
+)
+
+func TestHandleAdminShowsAlerts(t *testing.T) {
+	drv := alertDriver{alerts: []thermoprint.Alert{
+		{Time: time.Now(), Code: thermoprint.AlertNoPaper, Severity: thermoprint.SeverityCritical, Message: "Printer is out of paper"},
+	}}
+	server, err := New(mustWrapDriver(t, drv, "test-printer", "Test Printer"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := server.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Shutdown: %v", err)
+		}
+	})
+
+	rec := httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"Test Printer", "Printer is out of paper", `class="critical"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("admin page does not contain %q:\n%s", want, body)
+		}
+	}
+}
+
+type notifyDriver struct {
+	testDriver
+	unknown []thermoprint.UnknownNotification
+}
+
+func (d notifyDriver) UnknownNotifications() []thermoprint.UnknownNotification { return d.unknown }
+
+func TestHandleAdminShowsUnknownNotifications(t *testing.T) {
+	drv := notifyDriver{unknown: []thermoprint.UnknownNotification{
+		{Time: time.Now(), Data: []byte{0x5a, 0xee, 0x01}},
+	}}
+	server, err := New(mustWrapDriver(t, drv, "test-printer", "Test Printer"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := server.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Shutdown: %v", err)
+		}
+	})
+
+	rec := httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/", nil))
+	body := rec.Body.String()
+	for _, want := range []string{"Unknown notifications", "5a ee 01"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("admin page does not contain %q:\n%s", want, body)
+		}
+	}
+}
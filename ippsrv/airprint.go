@@ -41,25 +41,33 @@ func escapeLabel(label string) string {
 	return strings.NewReplacer(`\`, `\\`, `.`, `\.`).Replace(label)
 }
 
-// subtypeResponder answers PTR queries for one DNS-SD service subtype with
-// the parent-type instance names.
+// subtypeResponder answers PTR queries for the DNS-SD service subtype of
+// the parent types with the parent-type instance names.
 type subtypeResponder struct {
-	subtype   string   // fully qualified, e.g. "_universal._sub._ipp._tcp.local."
-	instances []string // fully qualified parent instances, e.g. "Printer\ Name._ipp._tcp.local."
+	subtypes []subtypeRecords
 
 	pc4 *ipv4.PacketConn
 	pc6 *ipv6.PacketConn
 }
 
-// newSubtypeResponder prepares a responder that maps the subtype to the
-// given instance names of parentType (e.g. "_ipp._tcp").  IPv6 is best
-// effort; IPv4 is required.
-func newSubtypeResponder(subtype, parentType string, names []string) (*subtypeResponder, error) {
-	r := &subtypeResponder{
-		subtype: subtype + ".local.",
-	}
-	for _, name := range names {
-		r.instances = append(r.instances, escapeLabel(name)+"."+parentType+".local.")
+// subtypeRecords is the subtype of one parent type and its instances.
+type subtypeRecords struct {
+	subtype   string   // fully qualified, e.g. "_universal._sub._ipp._tcp.local."
+	instances []string // fully qualified parent instances, e.g. "Printer\ Name._ipp._tcp.local."
+}
+
+// newSubtypeResponder prepares a responder that maps the subtype prefix
+// (e.g. "_universal._sub.") of each of the parentTypes (e.g. "_ipp._tcp")
+// to the given instance names of that type.  IPv6 is best effort; IPv4 is
+// required.
+func newSubtypeResponder(subtype string, parentTypes, names []string) (*subtypeResponder, error) {
+	r := &subtypeResponder{}
+	for _, typ := range parentTypes {
+		rec := subtypeRecords{subtype: subtype + typ + ".local."}
+		for _, name := range names {
+			rec.instances = append(rec.instances, escapeLabel(name)+"."+typ+".local.")
+		}
+		r.subtypes = append(r.subtypes, rec)
 	}
 
 	// Binding to the multicast group address (not the wildcard) is what
@@ -104,19 +112,21 @@ func multicastInterfaces() []net.Interface {
 	return out
 }
 
-// records returns the PTR record set, with the given TTL.
-func (r *subtypeResponder) records(ttl uint32) []dns.RR {
-	rrs := make([]dns.RR, 0, len(r.instances))
-	for _, inst := range r.instances {
-		rrs = append(rrs, &dns.PTR{
-			Hdr: dns.RR_Header{
-				Name:   r.subtype,
-				Rrtype: dns.TypePTR,
-				Class:  dns.ClassINET,
-				Ttl:    ttl,
-			},
-			Ptr: inst,
-		})
+// records returns the PTR record set of the subtypes, with the given TTL.
+func records(subtypes []subtypeRecords, ttl uint32) []dns.RR {
+	var rrs []dns.RR
+	for _, st := range subtypes {
+		for _, inst := range st.instances {
+			rrs = append(rrs, &dns.PTR{
+				Hdr: dns.RR_Header{
+					Name:   st.subtype,
+					Rrtype: dns.TypePTR,
+					Class:  dns.ClassINET,
+					Ttl:    ttl,
+				},
+				Ptr: inst,
+			})
+		}
 	}
 	return rrs
 }
@@ -131,7 +141,7 @@ func (r *subtypeResponder) respond(ctx context.Context) {
 
 	// unsolicited announcements (RFC 6762 §8.3)
 	for range 2 {
-		r.multicast(r.records(subtypeTTL))
+		r.multicast(records(r.subtypes, subtypeTTL))
 		select {
 		case <-time.After(time.Second):
 		case <-ctx.Done():
@@ -139,7 +149,7 @@ func (r *subtypeResponder) respond(ctx context.Context) {
 	}
 
 	<-ctx.Done()
-	r.multicast(r.records(0)) // goodbye
+	r.multicast(records(r.subtypes, 0)) // goodbye
 	r.pc4.Close()
 	if r.pc6 != nil {
 		r.pc6.Close()
@@ -189,22 +199,24 @@ func (r *subtypeResponder) handle(pkt []byte, src net.Addr, write func(msg []byt
 	if err := q.Unpack(pkt); err != nil || q.Response {
 		return
 	}
-	asked := false
-	for _, question := range q.Question {
-		if (question.Qtype == dns.TypePTR || question.Qtype == dns.TypeANY) &&
-			strings.EqualFold(question.Name, r.subtype) {
-			asked = true
-			break
+	var asked []subtypeRecords
+	for _, st := range r.subtypes {
+		for _, question := range q.Question {
+			if (question.Qtype == dns.TypePTR || question.Qtype == dns.TypeANY) &&
+				strings.EqualFold(question.Name, st.subtype) {
+				asked = append(asked, st)
+				break
+			}
 		}
 	}
-	if !asked {
+	if len(asked) == 0 {
 		return
 	}
 
 	var resp dns.Msg
 	resp.Response = true
 	resp.Authoritative = true
-	resp.Answer = r.records(subtypeTTL)
+	resp.Answer = records(asked, subtypeTTL)
 
 	udp, _ := src.(*net.UDPAddr)
 	if udp != nil && udp.Port != mdnsPort {
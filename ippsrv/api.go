@@ -0,0 +1,401 @@
+package ippsrv
+
+// The JSON REST API for the clients, that do not speak IPP: the scripts and
+// the home automation.
+//
+//	GET    /api/v1/printers                       the printers
+//	GET    /api/v1/printers/{name}/jobs           the jobs of the printer
+//	POST   /api/v1/printers/{name}/jobs           submits the job
+//	GET    /api/v1/printers/{name}/jobs/{id}      the job status
+//	DELETE /api/v1/printers/{name}/jobs/{id}      cancels the job
+//	GET    /api/v1/printers/{name}/jobs/{id}/data the spooled document
+//	POST   /api/v1/printers/{name}/raw            prints the raw packets
+//	GET    /api/v1/events                         the WebSocket event stream
+//
+// The job is submitted as the multipart form with the "file" (the image, the
+// PDF, or the other document the server accepts), or the "text" field, and
+// the optional "name", "user", "dither" and "quality" fields.  The raw
+// packets are the request body, see raw.go.
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rusq/thermoprint"
+	"github.com/rusq/thermoprint/bitmap"
+)
+
+const (
+	apiPrefix     = "/api/v1"
+	apiEventsPath = apiPrefix + "/events"
+)
+
+// apiMaxMemory is the part of the multipart form kept in memory, the rest
+// is stored in the temporary files.
+const apiMaxMemory = 8 << 20
+
+// apiPrinter is the printer of the REST API.
+type apiPrinter struct {
+	Name         string   `json:"name"`
+	MakeAndModel string   `json:"make_and_model"`
+	State        string   `json:"state"`
+	StateReasons []string `json:"state_reasons"`
+	QueuedJobs   int      `json:"queued_jobs"`
+	// Status is the status, that the driver reported last: the battery
+	// level, the paper, if the driver reports it.
+	Status *thermoprint.Status `json:"status,omitempty"`
+}
+
+// apiJob is the job of the REST API.
+type apiJob struct {
+	ID           JobID     `json:"id"`
+	Printer      string    `json:"printer"`
+	Name         string    `json:"name"`
+	User         string    `json:"user"`
+	State        string    `json:"state"`
+	StateReasons []string  `json:"state_reasons"`
+	Created      time.Time `json:"created"`
+	Processing   time.Time `json:"processing,omitzero"`
+	Completed    time.Time `json:"completed,omitzero"`
+}
+
+// apiError is the error response of the REST API.
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// jobStateKeywords are the job states as the IPP keywords, RFC 8011,
+// section 5.3.7.
+var jobStateKeywords = map[JobState]string{
+	JobPending:           "pending",
+	JobPendingHeld:       "pending-held",
+	JobProcessing:        "processing",
+	JobProcessingStopped: "processing-stopped",
+	JobCancelled:         "canceled",
+	JobAborted:           "aborted",
+	JobCompleted:         "completed",
+}
+
+// printerStateKeywords are the printer states as the IPP keywords.
+var printerStateKeywords = map[PrinterState]string{
+	PSIdle:       "idle",
+	PSProcessing: "processing",
+	PSStopped:    "stopped",
+}
+
+// registerAPI registers the REST API handlers, wrap wraps the handlers,
+// i.e. with the authentication.
+func (s *Server) registerAPI(m *http.ServeMux, wrap func(http.HandlerFunc) http.HandlerFunc) {
+	m.HandleFunc("GET "+apiPrefix+"/printers", wrap(s.apiPrinters))
+	m.HandleFunc("GET "+apiPrefix+"/printers/{name}/jobs", wrap(s.apiJobs))
+	m.HandleFunc("POST "+apiPrefix+"/printers/{name}/jobs", wrap(sameOriginOnly(s.apiSubmitJob)))
+	m.HandleFunc("GET "+apiPrefix+"/printers/{name}/jobs/{id}", wrap(s.apiJob))
+	m.HandleFunc("DELETE "+apiPrefix+"/printers/{name}/jobs/{id}", wrap(sameOriginOnly(s.apiCancelJob)))
+	m.HandleFunc("GET "+apiPrefix+"/printers/{name}/jobs/{id}/data", wrap(s.apiJobData))
+	m.HandleFunc("POST "+apiPrefix+"/printers/{name}/raw", wrap(sameOriginOnly(s.apiPrintRaw)))
+}
+
+// crossOrigin reports whether the request is sent by the other web page.
+// The requests without the Origin, i.e. from curl or tp print -remote, are
+// not cross-site.
+func crossOrigin(r *http.Request) bool {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || !strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+	}
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" && site != "none" {
+		return true
+	}
+	return false
+}
+
+// sameOriginOnly rejects the cross-origin requests, so that the web pages,
+// that the users on the network open, can not print or cancel the jobs with
+// the form posts, that the browser sends without asking.
+func sameOriginOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if crossOrigin(r) {
+			slog.WarnContext(r.Context(), "rejected the cross-origin request", "origin", r.Header.Get("Origin"), "path", r.URL.Path)
+			apiErrorf(w, http.StatusForbidden, "cross-origin request rejected")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set(hdrContentType, "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("failed to encode the API response", "error", err)
+	}
+}
+
+func apiErrorf(w http.ResponseWriter, code int, format string, args ...any) {
+	writeJSON(w, code, apiError{Error: fmt.Sprintf(format, args...)})
+}
+
+func (s *Server) apiPrinters(w http.ResponseWriter, r *http.Request) {
+	printers := make([]apiPrinter, 0, len(s.pp))
+	for _, p := range s.pp {
+		printers = append(printers, s.apiPrinter(p))
+	}
+	writeJSON(w, http.StatusOK, printers)
+}
+
+func (s *Server) apiPrinter(p Printer) apiPrinter {
+	reasons := stateReasons(p)
+	ap := apiPrinter{
+		Name:         p.Name(),
+		MakeAndModel: p.MakeAndModel(),
+		State:        printerStateKeywords[p.State()],
+		StateReasons: make([]string, len(reasons)),
+		QueuedJobs:   s.is.spool.GetJobCount(p.Name()),
+	}
+	for i, r := range reasons {
+		ap.StateReasons[i] = string(r)
+	}
+	if sr, ok := p.(statusReporter); ok {
+		if st, ok := sr.lastStatus(); ok {
+			ap.Status = &st
+		}
+	}
+	return ap
+}
+
+func newAPIJob(j *Job) apiJob {
+	snap := j.Snapshot()
+	aj := apiJob{
+		ID:           snap.ID,
+		Printer:      snap.PrinterName,
+		Name:         snap.Name,
+		User:         snap.Username,
+		State:        jobStateKeywords[snap.State],
+		StateReasons: make([]string, len(snap.StateReasons)),
+		Created:      snap.Created,
+		Processing:   snap.Processing,
+		Completed:    snap.Completed,
+	}
+	for i, r := range snap.StateReasons {
+		aj.StateReasons[i] = string(r)
+	}
+	return aj
+}
+
+// apiPrinterFromRequest returns the printer of the request path, or
+// answers 404.
+func (s *Server) apiPrinterFromRequest(w http.ResponseWriter, r *http.Request) (Printer, bool) {
+	p, ok := s.is.Printer[r.PathValue("name")]
+	if !ok {
+		apiErrorf(w, http.StatusNotFound, "printer %q not found", r.PathValue("name"))
+		return nil, false
+	}
+	return p, true
+}
+
+// apiJobFromRequest returns the job of the request path, or answers 404.
+func (s *Server) apiJobFromRequest(w http.ResponseWriter, r *http.Request) (*Job, bool) {
+	p, ok := s.apiPrinterFromRequest(w, r)
+	if !ok {
+		return nil, false
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		apiErrorf(w, http.StatusNotFound, "invalid job id %q", r.PathValue("id"))
+		return nil, false
+	}
+	job, err := s.is.spool.GetJob(JobID(id))
+	if err != nil || job.Printer.Name() != p.Name() {
+		apiErrorf(w, http.StatusNotFound, "job %d not found", id)
+		return nil, false
+	}
+	return job, true
+}
+
+func (s *Server) apiJobs(w http.ResponseWriter, r *http.Request) {
+	p, ok := s.apiPrinterFromRequest(w, r)
+	if !ok {
+		return
+	}
+	jobs, err := s.is.spool.GetJobs(p.Name())
+	if err != nil && !errors.Is(err, errJobNotFound) {
+		apiErrorf(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	aj := make([]apiJob, 0, len(jobs))
+	for _, job := range jobs {
+		aj = append(aj, newAPIJob(job))
+	}
+	writeJSON(w, http.StatusOK, aj)
+}
+
+func (s *Server) apiJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.apiJobFromRequest(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, newAPIJob(job))
+}
+
+func (s *Server) apiCancelJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.apiJobFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := job.cancel(r.Context(), JSRJobCancelledByUser); err != nil {
+		apiErrorf(w, http.StatusConflict, "%v", err)
+		return
+	}
+	slog.InfoContext(r.Context(), "job cancelled", "job_id", job.ID, "endpoint", "api")
+	writeJSON(w, http.StatusOK, newAPIJob(job))
+}
+
+// apiJobData sends the spooled document of the job, as it was received, to
+// debug the failed conversions.  The documents of the job, that has several,
+// are concatenated.
+func (s *Server) apiJobData(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.apiJobFromRequest(w, r)
+	if !ok {
+		return
+	}
+	data, err := s.is.spool.GetJobData(job.ID)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			apiErrorf(w, http.StatusNotFound, "job %d has no data", job.ID)
+			return
+		}
+		apiErrorf(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	job.mu.RLock()
+	files := slices.Clone(job.files)
+	job.mu.RUnlock()
+	format, filename := ippApplicationOctetStream.String(), fmt.Sprintf("job_%d.bin", job.ID)
+	if len(files) == 1 {
+		format, filename = detectFormat(job.Format, data), files[0]
+	}
+	w.Header().Set(hdrContentType, format)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if _, err := w.Write(data); err != nil {
+		slog.WarnContext(r.Context(), "failed to send the job data", "job_id", job.ID, "error", err)
+	}
+}
+
+// apiSubmitJob spools the job of the multipart form, see the package API
+// description above.
+func (s *Server) apiSubmitJob(w http.ResponseWriter, r *http.Request) {
+	p, ok := s.apiPrinterFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if s.is.draining.Load() {
+		apiErrorf(w, http.StatusServiceUnavailable, "printer %s: %v", p.Name(), errDraining)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, MaxDocumentSize)
+	if err := r.ParseMultipartForm(apiMaxMemory); err != nil {
+		apiErrorf(w, http.StatusBadRequest, "invalid form: %v", err)
+		return
+	}
+	data, format, filename, err := apiDocument(r)
+	if err != nil {
+		apiErrorf(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	dither := r.FormValue("dither")
+	if dither != "" {
+		if _, err := bitmap.ParseDither(dither); err != nil {
+			apiErrorf(w, http.StatusBadRequest, "%v", err)
+			return
+		}
+		dither = bitmap.DitherName(dither)
+	}
+	var quality Quality
+	if v := r.FormValue("quality"); v != "" {
+		if quality, err = ParseQuality(v); err != nil {
+			apiErrorf(w, http.StatusBadRequest, "%v", err)
+			return
+		}
+	}
+
+	id, err := s.is.spool.NextJobID()
+	if err != nil {
+		apiErrorf(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	name := r.FormValue("name")
+	if name == "" {
+		name = cmp.Or(filename, fmt.Sprintf("Job-%d", id))
+	}
+	user := authUser(r.Context())
+	if user == "" {
+		user = cmp.Or(r.FormValue("user"), "api")
+	}
+	printerURI := "ipp://" + r.Host + s.is.baseURL + p.Name()
+	jobURL := path.Join(s.is.baseURL, p.Name(), strconv.Itoa(int(id)))
+	job, err := createJob(p, id, printerURI, jobURL, name, user, format)
+	if err != nil {
+		apiErrorf(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	job.printOptions.format = format
+	job.printOptions.dither = dither
+	job.printOptions.quality = quality
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	job.printOptions.origin = JobOrigin{User: user, Host: host, JobID: id, Time: job.Created}
+	if err := s.is.spool.AddJob(job, data); err != nil {
+		if errors.Is(err, errQueueFull) {
+			apiErrorf(w, http.StatusServiceUnavailable, "printer %s: %v", p.Name(), err)
+			return
+		}
+		apiErrorf(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	slog.InfoContext(r.Context(), "job submitted", "job_id", id, "printer", p.Name(), "endpoint", "api")
+	w.Header().Set("Location", path.Join(apiPrefix, "printers", p.Name(), "jobs", strconv.Itoa(int(id))))
+	writeJSON(w, http.StatusCreated, newAPIJob(job))
+}
+
+// apiDocument returns the document of the form: the "file" part, or the
+// "text" field, with its format and the file name.
+func apiDocument(r *http.Request) (data []byte, format, filename string, err error) {
+	if text := r.FormValue("text"); text != "" {
+		return []byte(text), ippTextPlain.String(), "", nil
+	}
+	f, hdr, err := r.FormFile("file")
+	if err != nil {
+		return nil, "", "", errors.New(`the form must have the "file" or the "text" field`)
+	}
+	defer f.Close()
+	if data, err = io.ReadAll(f); err != nil {
+		return nil, "", "", err
+	}
+	if len(data) == 0 {
+		return nil, "", "", errors.New("the file is empty")
+	}
+	// the format is sniffed at print time, except for the plain text.
+	if ct := hdr.Header.Get(hdrContentType); strings.HasPrefix(ct, ippTextPlain.String()) {
+		format = ippTextPlain.String()
+	}
+	return data, format, hdr.Filename, nil
+}
@@ -0,0 +1,211 @@
+package ippsrv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAPIServer(t *testing.T, d Driver, opts ...Option) *Server {
+	t.Helper()
+	s, err := New(mustWrapDriver(t, d, "test-printer", "Test Printer"), opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, s.Shutdown(context.Background())) })
+	return s
+}
+
+// serveAPI sends the API request to the server, and decodes the JSON
+// response into v, if it is not nil.
+func serveAPI(t *testing.T, s *Server, r *http.Request, v any) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.srv.Handler.ServeHTTP(rec, r)
+	assert.Equal(t, "application/json", rec.Header().Get(hdrContentType))
+	if v != nil {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), v), rec.Body.String())
+	}
+	return rec
+}
+
+// newJobForm returns the POST request of the job with the form fields, and
+// the file, if it is not nil.
+func newJobForm(t *testing.T, printer string, fields map[string]string, file []byte) *http.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		require.NoError(t, mw.WriteField(k, v))
+	}
+	if file != nil {
+		fw, err := mw.CreateFormFile("file", "receipt.png")
+		require.NoError(t, err)
+		_, err = fw.Write(file)
+		require.NoError(t, err)
+	}
+	require.NoError(t, mw.Close())
+	r := httptest.NewRequest(http.MethodPost, apiPrefix+"/printers/"+printer+"/jobs", &buf)
+	r.Header.Set(hdrContentType, mw.FormDataContentType())
+	return r
+}
+
+func TestAPIPrinters(t *testing.T) {
+	s := newAPIServer(t, testDriver{})
+	var printers []apiPrinter
+	rec := serveAPI(t, s, httptest.NewRequest(http.MethodGet, apiPrefix+"/printers", nil), &printers)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, printers, 1)
+	assert.Equal(t, "test-printer", printers[0].Name)
+	assert.Equal(t, "idle", printers[0].State)
+}
+
+func TestAPISubmitJob(t *testing.T) {
+	d := &captureDriver{}
+	s := newAPIServer(t, d)
+
+	var job apiJob
+	r := newJobForm(t, "test-printer", map[string]string{"user": "script", "dither": "Floyd_Steinberg", "quality": "draft"}, tinyPNG(t))
+	rec := serveAPI(t, s, r, &job)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "receipt.png", job.Name)
+	assert.Equal(t, "script", job.User)
+	assert.Equal(t, "test-printer", job.Printer)
+	assert.Equal(t, apiPrefix+"/printers/test-printer/jobs/1", rec.Header().Get("Location"))
+
+	j, err := s.is.spool.GetJob(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "floyd-steinberg", j.printOptions.dither)
+	assert.Equal(t, QualityDraft, j.printOptions.quality)
+
+	waitJob(t, j)
+	var got apiJob
+	serveAPI(t, s, httptest.NewRequest(http.MethodGet, rec.Header().Get("Location"), nil), &got)
+	assert.Equal(t, "completed", got.State)
+	assert.False(t, d.printedBounds().Empty())
+
+	var jobs []apiJob
+	serveAPI(t, s, httptest.NewRequest(http.MethodGet, apiPrefix+"/printers/test-printer/jobs", nil), &jobs)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, job.ID, jobs[0].ID)
+}
+
+func TestAPISubmitText(t *testing.T) {
+	s := newAPIServer(t, testDriver{})
+	var job apiJob
+	rec := serveAPI(t, s, newJobForm(t, "test-printer", map[string]string{"text": "Hello", "name": "note"}, nil), &job)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "note", job.Name)
+	assert.Equal(t, "api", job.User)
+	j, err := s.is.spool.GetJob(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ippTextPlain.String(), j.Format)
+}
+
+func TestAPISubmitJobErrors(t *testing.T) {
+	s := newAPIServer(t, testDriver{})
+	tests := []struct {
+		name string
+		r    *http.Request
+		code int
+	}{
+		{"unknown printer", newJobForm(t, "nope", map[string]string{"text": "x"}, nil), http.StatusNotFound},
+		{"no document", newJobForm(t, "test-printer", map[string]string{"name": "x"}, nil), http.StatusBadRequest},
+		{"bad dither", newJobForm(t, "test-printer", map[string]string{"text": "x", "dither": "crayon"}, nil), http.StatusBadRequest},
+		{"bad quality", newJobForm(t, "test-printer", map[string]string{"text": "x", "quality": "ultra"}, nil), http.StatusBadRequest},
+		{"not a form", httptest.NewRequest(http.MethodPost, apiPrefix+"/printers/test-printer/jobs", nil), http.StatusBadRequest},
+		{"unknown job", httptest.NewRequest(http.MethodGet, apiPrefix+"/printers/test-printer/jobs/42", nil), http.StatusNotFound},
+		{"invalid job", httptest.NewRequest(http.MethodDelete, apiPrefix+"/printers/test-printer/jobs/x", nil), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e apiError
+			rec := serveAPI(t, s, tt.r, &e)
+			assert.Equal(t, tt.code, rec.Code)
+			assert.NotEmpty(t, e.Error)
+		})
+	}
+}
+
+func TestAPICancelJob(t *testing.T) {
+	d := newBlockingDriver(2)
+	s := newAPIServer(t, d)
+	t.Cleanup(func() { close(d.release) })
+
+	var first, second apiJob
+	serveAPI(t, s, newJobForm(t, "test-printer", nil, tinyPNG(t)), &first)
+	<-d.entered // the first job is printing, the second one is pending.
+	serveAPI(t, s, newJobForm(t, "test-printer", nil, tinyPNG(t)), &second)
+
+	url := apiPrefix + "/printers/test-printer/jobs/" + strconv.Itoa(int(second.ID))
+	var cancelled apiJob
+	rec := serveAPI(t, s, httptest.NewRequest(http.MethodDelete, url, nil), &cancelled)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "canceled", cancelled.State)
+
+	rec = serveAPI(t, s, httptest.NewRequest(http.MethodDelete, url, nil), nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestAPIAuth(t *testing.T) {
+	users := make(Users)
+	users.Add("alice", "secret")
+	s := newAPIServer(t, testDriver{}, WithBasicAuth(users, AuthAll))
+
+	rec := httptest.NewRecorder()
+	s.srv.Handler.ServeHTTP(rec, newJobForm(t, "test-printer", map[string]string{"text": "x"}, nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// the authenticated user owns the job, whatever the form says.
+	r := newJobForm(t, "test-printer", map[string]string{"text": "x", "user": "mallory"}, nil)
+	r.SetBasicAuth("alice", "secret")
+	var job apiJob
+	rec = serveAPI(t, s, r, &job)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "alice", job.User)
+}
+
+func TestAPICrossOrigin(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   map[string]string
+		wantCode int
+	}{
+		{"no origin", nil, http.StatusCreated},
+		{"same origin", map[string]string{"Origin": "http://example.com", "Sec-Fetch-Site": "same-origin"}, http.StatusCreated},
+		{"other origin", map[string]string{"Origin": "http://evil.example"}, http.StatusForbidden},
+		{"cross site", map[string]string{"Sec-Fetch-Site": "cross-site"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newAPIServer(t, testDriver{})
+			r := newJobForm(t, "test-printer", map[string]string{"text": "x"}, nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			rec := serveAPI(t, s, r, nil)
+			assert.Equal(t, tt.wantCode, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestAPIJobData(t *testing.T) {
+	s := newAPIServer(t, testDriver{})
+	var job apiJob
+	rec := serveAPI(t, s, newJobForm(t, "test-printer", nil, tinyPNG(t)), &job)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	data := rec.Header().Get("Location") + "/data"
+	rec = httptest.NewRecorder()
+	s.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, data, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/png", rec.Header().Get(hdrContentType))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), ".png")
+	assert.Equal(t, tinyPNG(t), rec.Body.Bytes())
+}
@@ -0,0 +1,254 @@
+package ippsrv
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/OpenPrinting/goipp"
+)
+
+// authRealm is the realm of the Basic authentication.
+const authRealm = "Thermoprint"
+
+// ippBasic is the uri-authentication-supported value of the printer, that
+// requires the Basic authentication.
+const ippBasic goipp.String = "basic"
+
+// AuthScope is the part of the server, that requires the authentication,
+// see [WithBasicAuth].
+type AuthScope string
+
+const (
+	// AuthAdmin protects the admin page and the REST API, the IPP printing
+	// is open.
+	AuthAdmin AuthScope = "admin"
+	// AuthAll protects the admin page, the REST API and the IPP operations.
+	AuthAll AuthScope = "all"
+)
+
+// AuthScopes returns the names of the authentication scopes.
+func AuthScopes() []string {
+	return []string{string(AuthAdmin), string(AuthAll)}
+}
+
+// ParseAuthScope parses the authentication scope.
+func ParseAuthScope(s string) (AuthScope, error) {
+	switch sc := AuthScope(strings.ToLower(s)); sc {
+	case AuthAdmin, AuthAll:
+		return sc, nil
+	}
+	return "", fmt.Errorf("unknown authentication scope %q, must be one of: %s", s, strings.Join(AuthScopes(), ", "))
+}
+
+// WithBasicAuth requires the HTTP Basic authentication with the users for
+// the scope.  With [AuthAll], the printers report uri-authentication-supported
+// basic, and the authenticated user is the owner of the jobs.  No users
+// disable the authentication.
+func WithBasicAuth(users Users, scope AuthScope) Option {
+	return func(s *Server) {
+		s.auth.users = users
+		s.auth.scope = scope
+	}
+}
+
+// Users are the password hashes of the users, in the htpasswd format:
+// "{SHA}" and the base64 SHA-1 digest, or "$apr1$" and "$1$" MD5 crypt.
+type Users map[string]string
+
+// Add adds the user with the password.
+func (u Users) Add(user, password string) {
+	u[user] = shaHash(password)
+}
+
+// ParseUser parses the "user:password" pair and adds the user.
+func (u Users) ParseUser(s string) error {
+	user, password, ok := strings.Cut(s, ":")
+	if !ok || user == "" {
+		return fmt.Errorf("invalid user %q, expected user:password", s)
+	}
+	u.Add(user, password)
+	return nil
+}
+
+// Check reports whether the password of the user is valid.
+func (u Users) Check(user, password string) bool {
+	hash, ok := u[user]
+	if !ok {
+		return false
+	}
+	var got string
+	switch {
+	case strings.HasPrefix(hash, "{SHA}"):
+		got = shaHash(password)
+	case strings.HasPrefix(hash, "$apr1$"):
+		got = md5Crypt(password, hashSalt(hash, "$apr1$"), "$apr1$")
+	case strings.HasPrefix(hash, "$1$"):
+		got = md5Crypt(password, hashSalt(hash, "$1$"), "$1$")
+	default:
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(hash)) == 1
+}
+
+// ReadHtpasswd reads the users from the htpasswd file, see [ParseHtpasswd].
+func ReadHtpasswd(name string) (Users, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	u, err := ParseHtpasswd(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return u, nil
+}
+
+// ParseHtpasswd parses the htpasswd file, the lines "user:hash".  The
+// hashes, that htpasswd makes by default (-m, MD5) and with -s (SHA-1), are
+// supported; bcrypt (-B) is not.
+func ParseHtpasswd(r io.Reader) (Users, error) {
+	u := make(Users)
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user, hash, ok := strings.Cut(line, ":")
+		if !ok || user == "" {
+			return nil, fmt.Errorf("line %d: expected user:hash", n)
+		}
+		switch {
+		case strings.HasPrefix(hash, "{SHA}"), strings.HasPrefix(hash, "$apr1$"), strings.HasPrefix(hash, "$1$"):
+		case strings.HasPrefix(hash, "$2"):
+			return nil, fmt.Errorf("line %d: bcrypt hash of user %q is not supported, use htpasswd -m", n, user)
+		default:
+			return nil, fmt.Errorf("line %d: unsupported hash of user %q", n, user)
+		}
+		u[user] = hash
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// shaHash returns the "{SHA}" hash of the password.
+func shaHash(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// hashSalt returns the salt of the MD5 crypt hash.
+func hashSalt(hash, magic string) string {
+	salt, _, _ := strings.Cut(strings.TrimPrefix(hash, magic), "$")
+	return salt
+}
+
+// cryptAlphabet is the base64 alphabet of crypt(3).
+const cryptAlphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// md5Crypt returns the MD5 crypt hash of the password, the magic is "$1$"
+// for crypt(3) or "$apr1$" for the Apache variant.
+func md5Crypt(password, salt, magic string) string {
+	pw := []byte(password)
+	salt = salt[:min(len(salt), 8)]
+
+	alt := md5.New()
+	alt.Write(pw)
+	alt.Write([]byte(salt))
+	alt.Write(pw)
+	altSum := alt.Sum(nil)
+
+	d := md5.New()
+	d.Write(pw)
+	d.Write([]byte(magic + salt))
+	for i := len(pw); i > 0; i -= md5.Size {
+		d.Write(altSum[:min(i, md5.Size)])
+	}
+	for i := len(pw); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			d.Write([]byte{0})
+		} else {
+			d.Write(pw[:1])
+		}
+	}
+	sum := d.Sum(nil)
+
+	for i := range 1000 {
+		d := md5.New()
+		if i&1 != 0 {
+			d.Write(pw)
+		} else {
+			d.Write(sum)
+		}
+		if i%3 != 0 {
+			d.Write([]byte(salt))
+		}
+		if i%7 != 0 {
+			d.Write(pw)
+		}
+		if i&1 != 0 {
+			d.Write(sum)
+		} else {
+			d.Write(pw)
+		}
+		sum = d.Sum(nil)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(magic + salt + "$")
+	encode := func(v uint32, n int) {
+		for range n {
+			sb.WriteByte(cryptAlphabet[v&0x3f])
+			v >>= 6
+		}
+	}
+	for _, g := range [][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}} {
+		encode(uint32(sum[g[0]])<<16|uint32(sum[g[1]])<<8|uint32(sum[g[2]]), 4)
+	}
+	encode(uint32(sum[11]), 2)
+	return sb.String()
+}
+
+// authIPP reports whether the IPP operations require the authentication.
+func (s *Server) authIPP() bool {
+	return len(s.auth.users) > 0 && s.auth.scope == AuthAll
+}
+
+type authUserKey struct{}
+
+// authUser returns the authenticated user of the request, or an empty
+// string.
+func authUser(ctx context.Context) string {
+	user, _ := ctx.Value(authUserKey{}).(string)
+	return user
+}
+
+// requireAuth returns the handler, that serves the requests of the users
+// with the valid credentials, and answers 401 Unauthorized to the others.
+func (s *Server) requireAuth(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || !s.auth.users.Check(user, password) {
+			if ok {
+				slog.WarnContext(r.Context(), "authentication failed", "user", user, "remote_addr", r.RemoteAddr)
+			}
+			w.Header().Set("WWW-Authenticate", `Basic realm="`+authRealm+`", charset="UTF-8"`)
+			httpError(w, http.StatusUnauthorized)
+			return
+		}
+		h(w, r.WithContext(context.WithValue(r.Context(), authUserKey{}, user)))
+	}
+}
@@ -0,0 +1,143 @@
+package ippsrv
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OpenPrinting/goipp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMD5Crypt(t *testing.T) {
+	// openssl passwd -apr1 -salt abcdefgh secret, and -1.
+	assert.Equal(t, "$apr1$abcdefgh$h9FWgUz3n9YxylKLlR5SQ/", md5Crypt("secret", "abcdefgh", "$apr1$"))
+	assert.Equal(t, "$1$abcdefgh$cHJi5PXp/ki/ktXzqlk6I1", md5Crypt("secret", "abcdefgh", "$1$"))
+}
+
+func TestParseHtpasswd(t *testing.T) {
+	users, err := ParseHtpasswd(strings.NewReader(`# users
+alice:$apr1$abcdefgh$h9FWgUz3n9YxylKLlR5SQ/
+bob:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=
+
+carol:$1$abcdefgh$cHJi5PXp/ki/ktXzqlk6I1
+`))
+	require.NoError(t, err)
+	for _, user := range []string{"alice", "bob", "carol"} {
+		assert.True(t, users.Check(user, "secret"), user)
+		assert.False(t, users.Check(user, "wrong"), user)
+	}
+	assert.False(t, users.Check("dave", "secret"))
+
+	_, err = ParseHtpasswd(strings.NewReader("alice:$2y$05$abcdefghijklmnopqrstuv\n"))
+	assert.ErrorContains(t, err, "bcrypt")
+	_, err = ParseHtpasswd(strings.NewReader("alice\n"))
+	assert.Error(t, err)
+}
+
+func TestUsersParseUser(t *testing.T) {
+	users := make(Users)
+	require.NoError(t, users.ParseUser("alice:se:cret"))
+	assert.True(t, users.Check("alice", "se:cret"))
+	assert.Error(t, users.ParseUser("alice"))
+	assert.Error(t, users.ParseUser(":secret"))
+}
+
+func TestParseAuthScope(t *testing.T) {
+	sc, err := ParseAuthScope("ALL")
+	require.NoError(t, err)
+	assert.Equal(t, AuthAll, sc)
+	_, err = ParseAuthScope("print")
+	assert.Error(t, err)
+}
+
+func newAuthServer(t *testing.T, scope AuthScope) *Server {
+	t.Helper()
+	users := make(Users)
+	users.Add("alice", "secret")
+	s, err := New(mustWrapDriver(t, testDriver{}, "test-printer", "Test Printer"), WithBasicAuth(users, scope))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, s.Shutdown(context.Background())) })
+	return s
+}
+
+// serveIPP sends the IPP request to the server, with the credentials, if
+// the user is not empty.
+func serveIPP(t *testing.T, s *Server, req *goipp.Message, user, password string) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, req.Encode(&buf))
+	r := httptest.NewRequest(http.MethodPost, "/printers/test-printer", &buf)
+	r.Header.Set(hdrContentType, ippMIMEType)
+	if user != "" {
+		r.SetBasicAuth(user, password)
+	}
+	rec := httptest.NewRecorder()
+	s.srv.Handler.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestBasicAuthAdmin(t *testing.T) {
+	s := newAuthServer(t, AuthAdmin)
+
+	rec := httptest.NewRecorder()
+	s.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "Basic")
+
+	r := httptest.NewRequest(http.MethodGet, "/admin/", nil)
+	r.SetBasicAuth("alice", "wrong")
+	rec = httptest.NewRecorder()
+	s.srv.Handler.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	r = httptest.NewRequest(http.MethodGet, "/admin/", nil)
+	r.SetBasicAuth("alice", "secret")
+	rec = httptest.NewRecorder()
+	s.srv.Handler.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// the API is protected as the admin page.
+	rec = httptest.NewRecorder()
+	s.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, apiPrefix+"/printers", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	r = httptest.NewRequest(http.MethodGet, apiPrefix+"/printers", nil)
+	r.SetBasicAuth("alice", "secret")
+	rec = httptest.NewRecorder()
+	s.srv.Handler.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// the printing is open.
+	rec = serveIPP(t, s, newIPPRequest(goipp.OpGetPrinterAttributes, testRequestID), "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, s.is.auth)
+}
+
+func TestBasicAuthAll(t *testing.T) {
+	s := newAuthServer(t, AuthAll)
+
+	rec := serveIPP(t, s, newIPPRequest(goipp.OpGetPrinterAttributes, testRequestID), "", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = serveIPP(t, s, newIPPRequest(goipp.OpGetPrinterAttributes, testRequestID), "alice", "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp goipp.Message
+	require.NoError(t, resp.Decode(rec.Body))
+	assert.Equal(t, []string{"basic"}, attrStrings(t, resp.Printer, "uri-authentication-supported"))
+
+	// the authenticated user owns the job, whatever the client says.
+	req := newIPPRequest(goipp.OpPrintJob, testRequestID)
+	req.Operation.Add(goipp.MakeAttribute("requesting-user-name", goipp.TagName, goipp.String("mallory")))
+	ctx := context.WithValue(context.Background(), authUserKey{}, "alice")
+	resp2, err := s.is.handlePrintJob(ctx, req, tinyPNG(t))
+	require.NoError(t, err)
+	id, err := extractValue[goipp.Integer](resp2.Job, "job-id")
+	require.NoError(t, err)
+	job, err := s.is.spool.GetJob(JobID(id))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", job.Username)
+}
@@ -0,0 +1,67 @@
+package ippsrv
+
+import (
+	"errors"
+	"fmt"
+	"image"
+)
+
+// DefaultBlankThreshold is the default share of the dark pixels, below which
+// the page is considered blank: scanner noise or a stray dot, but not a page
+// number.
+const DefaultBlankThreshold = 0.0001
+
+// ErrBlankDocument is returned when all pages of the document are blank.
+var ErrBlankDocument = errors.New("all pages of the document are blank")
+
+// WithBlankThreshold sets the share of the dark pixels, below which the page
+// of the document is considered blank and is not printed.  Zero disables
+// skipping the blank pages.
+func WithBlankThreshold(threshold float64) PrinterOption {
+	return func(p *basePrinter) error {
+		if threshold < 0 || threshold >= 1 {
+			return fmt.Errorf("blank page threshold must be in the range [0, 1), got %g", threshold)
+		}
+		p.BlankThreshold = threshold
+		return nil
+	}
+}
+
+// IsBlank reports whether the share of the dark pixels in the image is below
+// the threshold.
+func IsBlank(img image.Image, threshold float64) bool {
+	b := img.Bounds()
+	limit := threshold * float64(b.Dx()*b.Dy())
+	dark := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if colorToWhiteBackgroundGray(img.At(x, y)) < 128 {
+				dark++
+				if float64(dark) >= limit {
+					return false
+				}
+			}
+		}
+	}
+	return float64(dark) < limit
+}
+
+// SkipBlankPages returns the pages that are not blank, and the numbers of
+// the skipped pages, starting from 1.
+func SkipBlankPages(pages []image.Image, threshold float64) ([]image.Image, []int) {
+	if threshold <= 0 {
+		return pages, nil
+	}
+	var (
+		kept    = make([]image.Image, 0, len(pages))
+		skipped []int
+	)
+	for i, pg := range pages {
+		if IsBlank(pg, threshold) {
+			skipped = append(skipped, i+1)
+			continue
+		}
+		kept = append(kept, pg)
+	}
+	return kept, skipped
+}
@@ -0,0 +1,76 @@
+package ippsrv
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func whitePage(w, h int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	return img
+}
+
+func TestIsBlank(t *testing.T) {
+	page := whitePage(100, 100)
+	assert.True(t, IsBlank(page, DefaultBlankThreshold))
+
+	page.SetGray(10, 10, color.Gray{}) // a dot is 0.01% of the page
+	assert.False(t, IsBlank(page, DefaultBlankThreshold))
+	assert.True(t, IsBlank(page, 0.001), "dot is below the higher threshold")
+	assert.False(t, IsBlank(whitePage(100, 100), 0), "zero threshold considers no page blank")
+
+	transparent := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	assert.True(t, IsBlank(transparent, DefaultBlankThreshold), "transparent is white")
+}
+
+func TestSkipBlankPages(t *testing.T) {
+	text := whitePage(100, 100)
+	draw.Draw(text, image.Rect(10, 10, 50, 20), image.Black, image.Point{}, draw.Src)
+	pages := []image.Image{text, whitePage(100, 100), text, whitePage(100, 100)}
+
+	kept, skipped := SkipBlankPages(pages, DefaultBlankThreshold)
+	assert.Len(t, kept, 2)
+	assert.Equal(t, []int{2, 4}, skipped)
+
+	kept, skipped = SkipBlankPages(pages, 0)
+	assert.Len(t, kept, 4, "skipping is disabled")
+	assert.Empty(t, skipped)
+}
+
+// blankFilter returns a page with text and a number of blank pages.
+type blankFilter int
+
+func (f blankFilter) ToRaster(context.Context, int, []byte) ([]image.Image, error) {
+	text := whitePage(8, 10)
+	draw.Draw(text, image.Rect(0, 0, 8, 2), image.Black, image.Point{}, draw.Src)
+	images := []image.Image{text}
+	for range int(f) {
+		images = append(images, whitePage(8, 10))
+	}
+	return images, nil
+}
+
+func (f blankFilter) Type() string { return "blank" }
+
+func TestPrintSkipsBlankPages(t *testing.T) {
+	driver := &captureDriver{}
+	p, err := WrapDriver(driver, "test-printer", "Test Printer", WithFilter(blankFilter(2)))
+	require.NoError(t, err)
+	require.NoError(t, p.Print(context.Background(), []byte("%PDF-1.7")))
+	assert.Equal(t, 10, driver.printedBounds().Dy(), "trailing blank pages must be skipped")
+
+	p, err = WrapDriver(driver, "test-printer", "Test Printer", WithFilter(blankFilter(2)), WithBlankThreshold(0))
+	require.NoError(t, err)
+	require.NoError(t, p.Print(context.Background(), []byte("%PDF-1.7")))
+	assert.Equal(t, 30, driver.printedBounds().Dy(), "skipping is disabled")
+
+	_, err = WrapDriver(driver, "test-printer", "Test Printer", WithBlankThreshold(1))
+	assert.Error(t, err)
+}
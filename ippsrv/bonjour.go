@@ -35,12 +35,13 @@ func WithBonjour() Option {
 }
 
 const (
-	svcTypeIPP = "_ipp._tcp"
-	// svcTypeUniversal is the AirPrint service subtype.  Apple clients
-	// browse it to decide whether a printer is driverless-capable: without
-	// it, macOS "Add Printer" falls back to asking for a driver and iOS does
-	// not see the printer at all.
-	svcTypeUniversal = "_universal._sub._ipp._tcp"
+	svcTypeIPP  = "_ipp._tcp"
+	svcTypeIPPS = "_ipps._tcp" // IPP over TLS, see WithTLS
+	// svcSubUniversal is the prefix of the AirPrint service subtype.  Apple
+	// clients browse it to decide whether a printer is driverless-capable:
+	// without it, macOS "Add Printer" falls back to asking for a driver and
+	// iOS does not see the printer at all.
+	svcSubUniversal = "_universal._sub."
 )
 
 // urfSupported lists the Apple Raster capabilities: URF version, 8-bit
@@ -69,11 +70,11 @@ func txtRecord(p PrinterInformer, baseURL, hostname string, port, dpi int) map[s
 		"ty":       p.MakeAndModel(),
 		"note":     p.Info(),
 		"product":  "(" + p.MakeAndModel() + ")",
-		"usb_MFG":  "Thermoprint",
+		"usb_MFG":  thermoprintMFG,
 		"usb_MDL":  p.MakeAndModel(),
 		"pdl":      ippImageURF.String() + "," + ippImagePWGRaster.String(),
 		"URF":      strings.Join(urfSupported(dpi), ","),
-		"adminurl": fmt.Sprintf("http://%s.local.:%d/admin/", hostname, port),
+		"adminurl": fmt.Sprintf("http://%s.local.:%d%s", hostname, port, adminPath),
 		"UUID":     p.UUID(),
 		"kind":     "label",
 		"PaperMax": ">isoC-A2",
@@ -134,22 +135,36 @@ func (s *Server) startBonjour(ta *net.TCPAddr) error {
 	if err != nil {
 		return fmt.Errorf("failed to create DNS-SD responder: %w", err)
 	}
+	// the IPPS clients use the same port, see sniffListener.
+	types := []string{svcTypeIPP}
+	if s.tls.config != nil {
+		types = append(types, svcTypeIPPS)
+	}
 	names := make([]string, 0, len(s.pp))
 	for _, p := range s.pp {
 		name := instanceName(p, len(s.pp))
-		sv, err := dnssd.NewService(dnssd.Config{
-			Name:   name,
-			Type:   svcTypeIPP,
-			Domain: "local",
-			Host:   host,
-			Port:   ta.Port,
-			Text:   txtRecord(p, s.is.baseURL, host, ta.Port, int(p.Driver().DPI())),
-		})
-		if err != nil {
-			return fmt.Errorf("failed to create DNS-SD service for printer %q: %w", p.Name(), err)
-		}
-		if _, err := rsp.Add(sv); err != nil {
-			return fmt.Errorf("failed to register DNS-SD service for printer %q: %w", p.Name(), err)
+		for _, typ := range types {
+			text := txtRecord(p, s.is.baseURL, host, ta.Port, int(p.Driver().DPI()))
+			if typ == svcTypeIPPS {
+				text["TLS"] = "1.2"
+			}
+			if s.authIPP() {
+				text["air"] = "username,password"
+			}
+			sv, err := dnssd.NewService(dnssd.Config{
+				Name:   name,
+				Type:   typ,
+				Domain: "local",
+				Host:   host,
+				Port:   ta.Port,
+				Text:   text,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create DNS-SD service for printer %q: %w", p.Name(), err)
+			}
+			if _, err := rsp.Add(sv); err != nil {
+				return fmt.Errorf("failed to register DNS-SD service for printer %q: %w", p.Name(), err)
+			}
 		}
 		names = append(names, name)
 	}
@@ -157,7 +172,7 @@ func (s *Server) startBonjour(ta *net.TCPAddr) error {
 	// is answered by a supplementary responder (see airprint.go).  Failure
 	// is not fatal: the printer stays discoverable, only driverless
 	// detection by Apple clients degrades.
-	sub, err := newSubtypeResponder(svcTypeUniversal, svcTypeIPP, names)
+	sub, err := newSubtypeResponder(svcSubUniversal, types, names)
 	if err != nil {
 		slog.Warn("AirPrint subtype announcement disabled", "error", err)
 	}
@@ -181,7 +196,7 @@ func (s *Server) startBonjour(ta *net.TCPAddr) error {
 		}
 		<-subDone
 	}()
-	slog.Info("bonjour advertisement started", "type", svcTypeIPP, "host", host+".local.", "port", ta.Port)
+	slog.Info("bonjour advertisement started", "types", types, "host", host+".local.", "port", ta.Port)
 	return nil
 }
 
@@ -0,0 +1,303 @@
+package ippsrv
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/OpenPrinting/goipp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rusq/thermoprint"
+)
+
+// The conformance tests run the complete server over HTTP with the virtual
+// printer, and check the responses against RFC 8011 the way a real client
+// would see them, i.e. after encoding and decoding.
+
+// ippTestClient is a minimal IPP client for the in-process server.
+type ippTestClient struct {
+	t       *testing.T
+	srv     *Server
+	httpURL string // http URL of the printer
+	uri     string // printer-uri
+	reqID   uint32
+}
+
+// newConformanceClient starts the server with the virtual printer and
+// returns the client and the printout directory.
+func newConformanceClient(t *testing.T) (*ippTestClient, string) {
+	t.Helper()
+
+	outdir := t.TempDir()
+	vp, err := thermoprint.NewVirtualPrinter(outdir)
+	require.NoError(t, err)
+	srv, err := New(mustWrapDriver(t, vp, "default", "Virtual Printer"))
+	require.NoError(t, err)
+	ts := httptest.NewServer(srv.srv.Handler)
+	t.Cleanup(func() {
+		ts.Close()
+		require.NoError(t, srv.Shutdown(context.Background()))
+	})
+
+	c := &ippTestClient{
+		t:       t,
+		srv:     srv,
+		httpURL: ts.URL + "/printers/default",
+		uri:     "ipp" + strings.TrimPrefix(ts.URL, "http") + "/printers/default",
+	}
+	return c, outdir
+}
+
+// request returns a new request with the operation attributes required by
+// RFC 8011, section 4.1.4.
+func (c *ippTestClient) request(op goipp.Op) *goipp.Message {
+	c.reqID++
+	req := goipp.NewRequest(goipp.DefaultVersion, op, c.reqID)
+	a := adder(&req.Operation)
+	a("attributes-charset", goipp.TagCharset, ippUTF8)
+	a("attributes-natural-language", goipp.TagLanguage, ippENUS)
+	a("printer-uri", goipp.TagURI, goipp.String(c.uri))
+	a("requesting-user-name", goipp.TagName, goipp.String("tester"))
+	return req
+}
+
+// do sends the request with the optional document and returns the decoded
+// response.  It checks the properties common to all responses.
+func (c *ippTestClient) do(req *goipp.Message, doc []byte) *goipp.Message {
+	c.t.Helper()
+
+	var body bytes.Buffer
+	require.NoError(c.t, req.Encode(&body))
+	body.Write(doc)
+	hr, err := http.Post(c.httpURL, ippMIMEType, &body)
+	require.NoError(c.t, err)
+	defer hr.Body.Close()
+	require.Equal(c.t, http.StatusOK, hr.StatusCode)
+	require.Equal(c.t, ippMIMEType, hr.Header.Get(hdrContentType))
+
+	var resp goipp.Message
+	require.NoError(c.t, resp.Decode(hr.Body), "response must decode")
+
+	// RFC 8011, section 4.1.3 and 4.1.4
+	assert.Equal(c.t, req.RequestID, resp.RequestID, "request-id must be echoed")
+	require.GreaterOrEqual(c.t, len(resp.Operation), 2, "operation attributes")
+	assert.Equal(c.t, "attributes-charset", resp.Operation[0].Name, "first operation attribute")
+	assert.Equal(c.t, "attributes-natural-language", resp.Operation[1].Name, "second operation attribute")
+	return &resp
+}
+
+// waitJob waits for the job, that is printed in the background, to finish,
+// and returns its attributes.
+func (c *ippTestClient) waitJob(id goipp.Integer) *goipp.Message {
+	c.t.Helper()
+
+	job, err := c.srv.is.spool.GetJob(JobID(id))
+	require.NoError(c.t, err)
+	<-job.finished()
+	resp := c.do(c.withJobID(c.request(goipp.OpGetJobAttributes), id), nil)
+	require.Equal(c.t, goipp.StatusOk, statusOf(resp))
+	return resp
+}
+
+func (c *ippTestClient) withJobID(req *goipp.Message, id goipp.Integer) *goipp.Message {
+	req.Operation.Add(goipp.MakeAttribute("job-id", goipp.TagInteger, id))
+	return req
+}
+
+func statusOf(resp *goipp.Message) goipp.Status {
+	return goipp.Status(resp.Code)
+}
+
+func jobIDOf(t *testing.T, attrs goipp.Attributes) goipp.Integer {
+	t.Helper()
+	vv, ok := findAttr(attrs, "job-id")
+	require.True(t, ok, "job-id missing")
+	id, ok := vv[0].V.(goipp.Integer)
+	require.True(t, ok, "job-id must be an integer")
+	return id
+}
+
+func TestConformanceGetPrinterAttributes(t *testing.T) {
+	c, _ := newConformanceClient(t)
+
+	resp := c.do(c.request(goipp.OpGetPrinterAttributes), nil)
+	require.Equal(t, goipp.StatusOk, statusOf(resp))
+
+	// RFC 8011, section 5.4: REQUIRED printer attributes, returned in the
+	// printer attributes group.
+	for _, name := range []string{
+		"printer-uri-supported",
+		"uri-security-supported",
+		"uri-authentication-supported",
+		"printer-name",
+		"printer-state",
+		"printer-state-reasons",
+		"ipp-versions-supported",
+		"operations-supported",
+		"charset-configured",
+		"charset-supported",
+		"natural-language-configured",
+		"generated-natural-language-supported",
+		"document-format-default",
+		"document-format-supported",
+		"printer-is-accepting-jobs",
+		"queued-job-count",
+		"pdl-override-supported",
+		"printer-up-time",
+		"compression-supported",
+	} {
+		_, ok := findAttr(resp.Printer, name)
+		assert.True(t, ok, "printer attribute %q missing", name)
+	}
+	assert.Equal(t, []string{c.uri}, attrStrings(t, resp.Printer, "printer-uri-supported"),
+		"printer-uri-supported must echo the requested printer-uri")
+	assert.Equal(t, []string{"3"}, attrStrings(t, resp.Printer, "printer-state"), "idle")
+}
+
+func TestConformanceAdvertisedOperationsAreSupported(t *testing.T) {
+	c, _ := newConformanceClient(t)
+
+	resp := c.do(c.request(goipp.OpGetPrinterAttributes), nil)
+	ops, ok := findAttr(resp.Printer, "operations-supported")
+	require.True(t, ok, "operations-supported missing")
+	for _, v := range ops {
+		op := goipp.Op(v.V.(goipp.Integer))
+		req := c.withJobID(c.request(op), 1)
+		resp := c.do(req, nil)
+		assert.NotEqual(t, goipp.StatusErrorOperationNotSupported, statusOf(resp),
+			"operation %s is advertised, but not supported", op)
+	}
+}
+
+func TestConformanceJobLifecycle(t *testing.T) {
+	c, outdir := newConformanceClient(t)
+	pwg, err := os.ReadFile("../cupsraster/testdata/doc.pwg")
+	require.NoError(t, err)
+
+	// Validate-Job
+	resp := c.do(c.request(goipp.OpValidateJob), nil)
+	require.Equal(t, goipp.StatusOk, statusOf(resp))
+	assert.Empty(t, resp.Job, "Validate-Job must not create a job")
+
+	// Print-Job, RFC 8011, section 4.2.1.2
+	var ids []goipp.Integer
+	for range 2 {
+		req := c.request(goipp.OpPrintJob)
+		req.Operation.Add(goipp.MakeAttribute("job-name", goipp.TagName, goipp.String("conformance")))
+		req.Operation.Add(goipp.MakeAttribute("document-format", goipp.TagMimeType, ippImagePWGRaster))
+		resp := c.do(req, pwg)
+		require.Equal(t, goipp.StatusOk, statusOf(resp))
+		for _, name := range []string{"job-uri", "job-id", "job-state", "job-state-reasons"} {
+			_, ok := findAttr(resp.Job, name)
+			assert.True(t, ok, "Print-Job response attribute %q missing", name)
+		}
+		assert.Contains(t, []string{"3", "5"}, attrStrings(t, resp.Job, "job-state")[0], "pending or processing, it is printed in the background")
+		ids = append(ids, jobIDOf(t, resp.Job))
+	}
+	assert.NotEqual(t, ids[0], ids[1], "job-id must be unique")
+	for _, id := range ids {
+		c.waitJob(id)
+	}
+	printouts, err := filepath.Glob(filepath.Join(outdir, "*.png"))
+	require.NoError(t, err)
+	assert.Len(t, printouts, 2, "virtual printer printouts")
+
+	// Get-Job-Attributes, RFC 8011, section 4.3.4
+	resp = c.do(c.withJobID(c.request(goipp.OpGetJobAttributes), ids[0]), nil)
+	require.Equal(t, goipp.StatusOk, statusOf(resp))
+	assert.Equal(t, ids[0], jobIDOf(t, resp.Job))
+	assert.Equal(t, []string{"9"}, attrStrings(t, resp.Job, "job-state"), "completed")
+	assert.Equal(t, []string{"conformance"}, attrStrings(t, resp.Job, "job-name"))
+
+	// Get-Jobs, RFC 8011, section 4.2.6: one group per job.
+	resp = c.do(c.request(goipp.OpGetJobs), nil)
+	require.Equal(t, goipp.StatusOk, statusOf(resp))
+	var got []goipp.Integer
+	for _, g := range resp.Groups {
+		if g.Tag == goipp.TagJobGroup {
+			got = append(got, jobIDOf(t, g.Attrs))
+		}
+	}
+	assert.ElementsMatch(t, ids, got)
+
+	// Cancel-Job, RFC 8011, section 4.3.3: completed jobs can not be
+	// cancelled.
+	resp = c.do(c.withJobID(c.request(goipp.OpCancelJob), ids[0]), nil)
+	assert.Equal(t, goipp.StatusErrorNotPossible, statusOf(resp))
+	resp = c.do(c.withJobID(c.request(goipp.OpCancelJob), ids[len(ids)-1]+100), nil)
+	assert.Equal(t, goipp.StatusErrorNotFound, statusOf(resp))
+}
+
+func TestConformanceMultiDocumentJob(t *testing.T) {
+	c, outdir := newConformanceClient(t)
+	pwg, err := os.ReadFile("../cupsraster/testdata/doc.pwg")
+	require.NoError(t, err)
+	sendDocument := func(id goipp.Integer, doc []byte, last bool) *goipp.Message {
+		req := c.withJobID(c.request(goipp.OpSendDocument), id)
+		req.Operation.Add(goipp.MakeAttribute("last-document", goipp.TagBoolean, goipp.Boolean(last)))
+		return c.do(req, doc)
+	}
+	printouts := func() int {
+		pp, err := filepath.Glob(filepath.Join(outdir, "*.png"))
+		require.NoError(t, err)
+		return len(pp)
+	}
+
+	// Create-Job, RFC 8011, section 4.2.4
+	resp := c.do(c.request(goipp.OpCreateJob), nil)
+	require.Equal(t, goipp.StatusOk, statusOf(resp))
+	id := jobIDOf(t, resp.Job)
+	assert.Equal(t, []string{"3"}, attrStrings(t, resp.Job, "job-state"), "pending")
+
+	// Send-Document, RFC 8011, section 4.3.1
+	resp = sendDocument(id, pwg, false)
+	require.Equal(t, goipp.StatusOk, statusOf(resp))
+	assert.Zero(t, printouts(), "the job waits for the last document")
+	resp = sendDocument(id, pwg, false)
+	require.Equal(t, goipp.StatusOk, statusOf(resp))
+	resp = sendDocument(id, nil, true)
+	require.Equal(t, goipp.StatusOk, statusOf(resp))
+	resp = c.waitJob(id)
+	assert.Equal(t, []string{"9"}, attrStrings(t, resp.Job, "job-state"), "completed")
+	assert.Equal(t, []string{"2"}, attrStrings(t, resp.Job, "number-of-documents"))
+	assert.Equal(t, 2, printouts(), "both documents are printed")
+
+	assert.Equal(t, goipp.StatusErrorNotPossible, statusOf(sendDocument(id, pwg, true)), "the job is complete")
+	assert.Equal(t, goipp.StatusErrorNotFound, statusOf(sendDocument(id+100, pwg, true)))
+	resp = c.do(c.withJobID(c.request(goipp.OpSendDocument), id), pwg)
+	assert.Equal(t, goipp.StatusErrorBadRequest, statusOf(resp), "last-document is required")
+
+	// the job without the documents is aborted.
+	resp = c.do(c.request(goipp.OpCreateJob), nil)
+	require.Equal(t, goipp.StatusOk, statusOf(resp))
+	id = jobIDOf(t, resp.Job)
+	resp = sendDocument(id, nil, true)
+	require.Equal(t, goipp.StatusOk, statusOf(resp))
+	resp = c.waitJob(id)
+	assert.Equal(t, []string{"8"}, attrStrings(t, resp.Job, "job-state"), "aborted")
+}
+
+func TestConformanceErrors(t *testing.T) {
+	c, _ := newConformanceClient(t)
+
+	t.Run("unknown printer", func(t *testing.T) {
+		req := c.request(goipp.OpGetPrinterAttributes)
+		req.Operation[2] = goipp.MakeAttribute("printer-uri", goipp.TagURI, goipp.String(c.uri+"-missing"))
+		assert.Equal(t, goipp.StatusErrorNotFound, statusOf(c.do(req, nil)))
+	})
+	t.Run("unknown job", func(t *testing.T) {
+		resp := c.do(c.withJobID(c.request(goipp.OpGetJobAttributes), 1), nil)
+		assert.Equal(t, goipp.StatusErrorNotFound, statusOf(resp))
+	})
+	t.Run("unsupported operation", func(t *testing.T) {
+		resp := c.do(c.request(goipp.OpPausePrinter), nil)
+		assert.Equal(t, goipp.StatusErrorOperationNotSupported, statusOf(resp))
+	})
+}
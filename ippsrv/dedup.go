@@ -0,0 +1,122 @@
+package ippsrv
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DedupMode is what the server does with the job, that is identical to the
+// job submitted to the same printer within the deduplication window, i.e.
+// when the phone resubmits the job on timeout.
+type DedupMode string
+
+const (
+	// DedupSkip doesn't print the duplicate job, the client gets the
+	// original job in the response.
+	DedupSkip DedupMode = "skip"
+	// DedupWarn prints the duplicate job, but logs the warning.
+	DedupWarn DedupMode = "warn"
+)
+
+// DedupModes returns the names of the deduplication modes.
+func DedupModes() []string {
+	return []string{string(DedupSkip), string(DedupWarn)}
+}
+
+// ParseDedupMode parses the deduplication mode.
+func ParseDedupMode(s string) (DedupMode, error) {
+	switch m := DedupMode(strings.ToLower(s)); m {
+	case DedupSkip, DedupWarn:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown deduplication mode %q, must be one of: %s", s, strings.Join(DedupModes(), ", "))
+}
+
+// WithDedup enables the deduplication of the jobs: the job with the same
+// document, that is submitted to the same printer within the window after
+// the previous one, is skipped or logged, depending on the mode.  Zero
+// window disables the deduplication.
+func WithDedup(window time.Duration, mode DedupMode) Option {
+	return func(s *Server) {
+		s.dedup = newDedup(window, mode)
+	}
+}
+
+// dedup remembers the hashes of the recent jobs.
+type dedup struct {
+	window time.Duration
+	mode   DedupMode
+	now    func() time.Time
+
+	mu   sync.Mutex
+	seen map[[sha256.Size]byte]dedupEntry
+}
+
+type dedupEntry struct {
+	at  time.Time
+	job JobID
+}
+
+// newDedup returns the deduplicator, or nil, if the window is not positive.
+func newDedup(window time.Duration, mode DedupMode) *dedup {
+	if window <= 0 {
+		return nil
+	}
+	return &dedup{
+		window: window,
+		mode:   mode,
+		now:    time.Now,
+		seen:   make(map[[sha256.Size]byte]dedupEntry),
+	}
+}
+
+func dedupKey(printer string, data []byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write([]byte(printer))
+	h.Write([]byte{0})
+	h.Write(data)
+	var key [sha256.Size]byte
+	h.Sum(key[:0])
+	return key
+}
+
+// duplicate returns the job, that had the same document and was submitted to
+// the printer within the window, and true, if there is one.  The nil dedup
+// reports no duplicates.
+func (d *dedup) duplicate(printer string, data []byte) (JobID, bool) {
+	if d == nil {
+		return 0, false
+	}
+	key := dedupKey(printer, data)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.expire()
+	e, ok := d.seen[key]
+	return e.job, ok
+}
+
+// record remembers the document of the job, that was submitted to the
+// printer.
+func (d *dedup) record(printer string, data []byte, job JobID) {
+	if d == nil {
+		return
+	}
+	key := dedupKey(printer, data)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.seen[key] = dedupEntry{at: d.now(), job: job}
+}
+
+// expire forgets the jobs older than the window, the caller must hold the
+// lock.
+func (d *dedup) expire() {
+	cutoff := d.now().Add(-d.window)
+	for k, e := range d.seen {
+		if e.at.Before(cutoff) {
+			delete(d.seen, k)
+		}
+	}
+}
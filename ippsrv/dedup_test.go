@@ -0,0 +1,86 @@
+package ippsrv
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OpenPrinting/goipp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDedupMode(t *testing.T) {
+	m, err := ParseDedupMode("Skip")
+	require.NoError(t, err)
+	assert.Equal(t, DedupSkip, m)
+	_, err = ParseDedupMode("drop")
+	assert.Error(t, err)
+}
+
+func TestDedup(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	d := newDedup(time.Minute, DedupSkip)
+	d.now = func() time.Time { return now }
+
+	_, ok := d.duplicate("p", []byte("doc"))
+	assert.False(t, ok, "first job")
+	d.record("p", []byte("doc"), 7)
+
+	id, ok := d.duplicate("p", []byte("doc"))
+	assert.True(t, ok, "same document")
+	assert.Equal(t, JobID(7), id)
+	_, ok = d.duplicate("q", []byte("doc"))
+	assert.False(t, ok, "other printer")
+	_, ok = d.duplicate("p", []byte("other"))
+	assert.False(t, ok, "other document")
+
+	now = now.Add(time.Minute + time.Second)
+	_, ok = d.duplicate("p", []byte("doc"))
+	assert.False(t, ok, "outside the window")
+	assert.Empty(t, d.seen, "expired jobs are forgotten")
+}
+
+func TestDedup_Disabled(t *testing.T) {
+	d := newDedup(0, DedupSkip)
+	assert.Nil(t, d)
+	d.record("p", []byte("doc"), 1)
+	_, ok := d.duplicate("p", []byte("doc"))
+	assert.False(t, ok)
+}
+
+func TestHandlePrintJob_Dedup(t *testing.T) {
+	printJob := func(t *testing.T, s *basicIPPServer, data []byte) JobID {
+		t.Helper()
+		req := newIPPRequest(goipp.OpPrintJob, testRequestID)
+		resp, err := s.handlePrintJob(context.Background(), req, data)
+		require.NoError(t, err)
+		assertResponse(t, resp, req.RequestID, goipp.StatusOk)
+		id, err := extractValue[goipp.Integer](resp.Job, "job-id")
+		require.NoError(t, err)
+		return JobID(id)
+	}
+	t.Run("skip", func(t *testing.T) {
+		s := newTestIPPServer(t)
+		s.dedup = newDedup(time.Minute, DedupSkip)
+		doc := tinyPNG(t)
+		first := printJob(t, s, doc)
+		assert.Equal(t, first, printJob(t, s, doc), "duplicate gets the original job")
+		jobs, err := s.spool.GetJobs("test-printer")
+		require.NoError(t, err)
+		assert.Len(t, jobs, 1, "duplicate is not spooled")
+	})
+	t.Run("warn", func(t *testing.T) {
+		s := newTestIPPServer(t)
+		s.dedup = newDedup(time.Minute, DedupWarn)
+		doc := tinyPNG(t)
+		first := printJob(t, s, doc)
+		assert.NotEqual(t, first, printJob(t, s, doc), "duplicate is printed")
+	})
+	t.Run("disabled", func(t *testing.T) {
+		s := newTestIPPServer(t)
+		doc := tinyPNG(t)
+		first := printJob(t, s, doc)
+		assert.NotEqual(t, first, printJob(t, s, doc))
+	})
+}
@@ -0,0 +1,79 @@
+package ippsrv
+
+import (
+	"fmt"
+
+	"github.com/OpenPrinting/goipp"
+
+	"github.com/rusq/thermoprint/bitmap"
+)
+
+// ditherAttr is the job template attribute, that selects the dither function
+// of the job, i.e. "lp -o thermoprint-dither=atkinson".  It is the vendor
+// extension, IPP has no attribute for the dithering.
+const ditherAttr = "thermoprint-dither"
+
+// WithDither sets the dither function of the document pages, the name of the
+// function registered in the bitmap package, see
+// [bitmap.RegisterDitherFunction].  The empty name is the default function.
+// The jobs may select another one with the thermoprint-dither attribute.
+func WithDither(name string) PrinterOption {
+	return func(p *basePrinter) error {
+		if _, err := bitmap.ParseDither(name); err != nil {
+			return err
+		}
+		p.Dither = bitmap.DitherName(name)
+		return nil
+	}
+}
+
+// WithChannel sets the colour channel, with which the document pages are
+// converted to grey before dithering, i.e. [bitmap.ChannelRed] hides the red
+// stamps of the scanned documents.
+func WithChannel(ch bitmap.Channel) PrinterOption {
+	return func(p *basePrinter) error {
+		p.Channel = ch
+		return nil
+	}
+}
+
+// requestDither returns the canonical name of the dither function of the
+// request, or the empty string, if the client did not send it.
+func requestDither(req *goipp.Message) (string, error) {
+	v, ok := jobTemplateValue(req, ditherAttr)
+	if !ok {
+		return "", nil
+	}
+	s, ok := v.(goipp.String)
+	if !ok {
+		return "", fmt.Errorf("%s: unexpected value %q", ditherAttr, v.String())
+	}
+	if _, err := bitmap.ParseDither(string(s)); err != nil {
+		return "", fmt.Errorf("%s: unsupported value %q: %w", ditherAttr, s, err)
+	}
+	return bitmap.DitherName(string(s)), nil
+}
+
+// ditherValues returns the names of the dither functions, the values of
+// thermoprint-dither-supported.
+func ditherValues() []goipp.Value {
+	names := bitmap.AllDitherFunctions()
+	vv := make([]goipp.Value, len(names))
+	for i, name := range names {
+		vv[i] = goipp.String(name)
+	}
+	return vv
+}
+
+// printerDither returns the name of the dither function the printer is
+// configured with, or the empty string for the default one.  The group
+// reports the dither function of its first member.
+func printerDither(p Printer) string {
+	if g, ok := p.(*Group); ok {
+		p = g.members[0]
+	}
+	if bp, ok := p.(*basePrinter); ok {
+		return bp.Dither
+	}
+	return ""
+}
@@ -0,0 +1,84 @@
+package ippsrv
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/OpenPrinting/goipp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rusq/thermoprint/bitmap"
+)
+
+func TestRequestDither(t *testing.T) {
+	req := newIPPRequest(goipp.OpPrintJob, testRequestID)
+	got, err := requestDither(req)
+	require.NoError(t, err)
+	assert.Empty(t, got, "the printer dither is used")
+
+	req.Job.Add(goipp.MakeAttribute(ditherAttr, goipp.TagKeyword, goipp.String("Floyd_Steinberg")))
+	got, err = requestDither(req)
+	require.NoError(t, err)
+	assert.Equal(t, "floyd-steinberg", got)
+
+	req = newIPPRequest(goipp.OpPrintJob, testRequestID)
+	req.Job.Add(goipp.MakeAttribute(ditherAttr, goipp.TagKeyword, goipp.String("Bayer:4X4:0.8")))
+	got, err = requestDither(req)
+	require.NoError(t, err)
+	assert.Equal(t, "bayer:4x4:0.8", got)
+
+	req = newIPPRequest(goipp.OpPrintJob, testRequestID)
+	req.Job.Add(goipp.MakeAttribute(ditherAttr, goipp.TagKeyword, goipp.String("smudge")))
+	_, err = requestDither(req)
+	assert.Error(t, err)
+}
+
+func TestWithDither(t *testing.T) {
+	p, err := WrapDriver(&captureDriver{}, "test-printer", "Test Printer", WithDither("ATKINSON"))
+	require.NoError(t, err)
+	assert.Equal(t, "atkinson", printerDither(p))
+
+	_, err = WrapDriver(&captureDriver{}, "test-printer", "Test Printer", WithDither("smudge"))
+	assert.Error(t, err)
+}
+
+func TestDitherValues(t *testing.T) {
+	var got []string
+	for _, v := range ditherValues() {
+		got = append(got, v.String())
+	}
+	assert.Equal(t, bitmap.AllDitherFunctions(), got)
+}
+
+func TestWithChannel(t *testing.T) {
+	// the red stamp above the black text.
+	img := image.NewRGBA(image.Rect(0, 0, 384, 64))
+	draw.Draw(img, image.Rect(0, 0, 384, 32), image.NewUniform(color.RGBA{R: 0xe0, G: 0x20, B: 0x20, A: 0xff}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 32, 384, 64), image.Black, image.Point{}, draw.Src)
+
+	dark := func(opts ...PrinterOption) int {
+		driver := &captureDriver{}
+		p, err := WrapDriver(driver, "test-printer", "Test Printer", opts...)
+		require.NoError(t, err)
+		require.NoError(t, p.Print(context.Background(), mustPNG(t, img)))
+		driver.mu.Lock()
+		defer driver.mu.Unlock()
+		n := 0
+		b := driver.img.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if bitmap.PixelBit(driver.img, x, y, bitmap.DefaultThreshold) {
+					n++
+				}
+			}
+		}
+		return n
+	}
+	assert.Greater(t, dark(), 384*32, "the stamp is printed")
+	assert.Equal(t, 384*32, dark(WithChannel(bitmap.ChannelRed)), "only the text is printed")
+	assert.Equal(t, 384*32, dark(WithChannel(bitmap.ChannelRed), WithFit(FitCrop)), "only the text is printed by the composer")
+}
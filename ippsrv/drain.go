@@ -0,0 +1,76 @@
+package ippsrv
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+)
+
+// errDraining is returned for the new jobs, while the server drains.
+var errDraining = errors.New("the server is shutting down, not accepting the new jobs")
+
+// WithReady sets the function, that is called once the server listens on
+// the address, i.e. to notify the service manager, that the server is
+// ready.
+func WithReady(fn func(addr net.Addr)) Option {
+	return func(s *Server) {
+		s.ready = fn
+	}
+}
+
+// Drain stops accepting the new jobs, the printers report
+// printer-is-accepting-jobs false, and waits for the queued jobs and the
+// job in progress to finish, or for the context to be done.  The held jobs
+// and the jobs, that wait for the documents, are not waited for.  Call
+// [Server.Shutdown] after it.
+func (s *Server) Drain(ctx context.Context) error {
+	s.is.draining.Store(true)
+	for {
+		jobs := s.is.activeJobs()
+		if len(jobs) == 0 {
+			slog.InfoContext(ctx, "the job queue is drained")
+			return nil
+		}
+		slog.DebugContext(ctx, "draining the job queue", "jobs", len(jobs))
+		if s.drainWait != nil {
+			s.drainWait(len(jobs))
+		}
+		// the released held job may join the queue meanwhile, the jobs are
+		// listed again, once these are finished.
+		for _, job := range jobs {
+			select {
+			case <-ctx.Done():
+				slog.WarnContext(ctx, "the job queue is not drained", "jobs", len(s.is.activeJobs()), "error", ctx.Err())
+				return ctx.Err()
+			case <-job.finished():
+			}
+		}
+	}
+}
+
+// ActiveJobs returns the number of the jobs, that are queued for printing
+// or printing.
+func (s *Server) ActiveJobs() int {
+	return len(s.is.activeJobs())
+}
+
+// activeJobs returns the jobs, that are queued for printing or printing.
+func (ih *basicIPPServer) activeJobs() []*Job {
+	jobs, err := ih.spool.ListJobs()
+	if err != nil {
+		return nil
+	}
+	var active []*Job
+	for _, job := range jobs {
+		if job.queued() || job.state() == JobProcessing {
+			active = append(active, job)
+		}
+	}
+	return active
+}
+
+// acceptingJobs reports whether the printer accepts the new jobs.
+func (ih *basicIPPServer) acceptingJobs(p Printer) bool {
+	return p.Ready() && !ih.draining.Load()
+}
@@ -0,0 +1,92 @@
+package ippsrv
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/OpenPrinting/goipp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrain(t *testing.T) {
+	driver := newBlockingDriver(2)
+	s := newAPIServer(t, driver)
+	ctx := context.Background()
+
+	first := mustPrintJob(t, s.is)
+	waitStarted(t, driver.entered, nil)
+	second := mustPrintJob(t, s.is)
+	assert.Equal(t, 2, s.ActiveJobs())
+
+	waiting := make(chan int, 1)
+	s.drainWait = func(jobs int) { waiting <- jobs }
+	drained := make(chan error, 1)
+	go func() { drained <- s.Drain(ctx) }()
+	select {
+	case n := <-waiting:
+		assert.Equal(t, 2, n, "Drain waits for the jobs")
+	case err := <-drained:
+		t.Fatalf("Drain returned %v with the jobs in the queue", err)
+	}
+
+	_, err := s.is.handlePrintJob(ctx, newIPPRequest(goipp.OpPrintJob, testRequestID), tinyPNG(t))
+	assert.Equal(t, goipp.StatusErrorNotAcceptingJobs, ippStatusFromError(err))
+	_, err = s.is.handleCreateJob(ctx, newIPPRequest(goipp.OpCreateJob, testRequestID), nil)
+	assert.Equal(t, goipp.StatusErrorNotAcceptingJobs, ippStatusFromError(err))
+	rec := serveAPI(t, s, newJobForm(t, "test-printer", map[string]string{"text": "hello"}, nil), nil)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	resp := s.is.printerAttributes(s.pp[0], testRequestID, "")
+	accepting, ok := findAttr(resp.Printer, "printer-is-accepting-jobs")
+	require.True(t, ok)
+	assert.Equal(t, goipp.Boolean(false), accepting[0].V)
+
+	close(driver.release)
+	select {
+	case err := <-drained:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Drain did not return after the queue was drained")
+	}
+	assert.Equal(t, JobCompleted, first.state())
+	assert.Equal(t, JobCompleted, second.state())
+}
+
+func TestDrainCancel(t *testing.T) {
+	driver := newBlockingDriver(1)
+	s := newAPIServer(t, driver)
+	mustPrintJob(t, s.is)
+	waitStarted(t, driver.entered, nil)
+	defer close(driver.release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.drainWait = func(int) { cancel() } // the job is still printing
+	err := s.Drain(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, s.ActiveJobs())
+}
+
+func TestWithReady(t *testing.T) {
+	ready := make(chan net.Addr, 1)
+	s, err := New(mustWrapDriver(t, testDriver{}, "test-printer", "Test Printer"), WithReady(func(addr net.Addr) { ready <- addr }))
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() { served <- s.ListenAndServe("127.0.0.1:0") }()
+
+	select {
+	case addr := <-ready:
+		assert.NotZero(t, addr.(*net.TCPAddr).Port)
+	case err := <-served:
+		t.Fatalf("ListenAndServe: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the ready function was not called")
+	}
+	require.NoError(t, s.Shutdown(context.Background()))
+	assert.True(t, errors.Is(<-served, http.ErrServerClosed))
+}
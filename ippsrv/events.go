@@ -0,0 +1,169 @@
+package ippsrv
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Event types of the event stream.
+const (
+	EventJob     = "job"     // the job was created or changed its state
+	EventPrinter = "printer" // the printer changed its state or status
+)
+
+// eventBuffer is the number of the events kept for the slow subscriber,
+// the events are dropped, once it is full.
+const eventBuffer = 64
+
+// apiEvent is the event of the /api/v1/events stream.
+type apiEvent struct {
+	Type    string      `json:"type"`
+	Time    time.Time   `json:"time"`
+	Job     *apiJob     `json:"job,omitempty"`
+	Printer *apiPrinter `json:"printer,omitempty"`
+
+	// printer is the printer of the printer event, it is described by the
+	// subscriber, not to take the spool and the driver locks, that the
+	// publisher may hold.
+	printer Printer
+}
+
+// eventHub fans out the events to the subscribers.  It is safe for
+// concurrent use.
+type eventHub struct {
+	mu     sync.Mutex
+	subs   map[chan apiEvent]struct{}
+	closed bool
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subs: make(map[chan apiEvent]struct{})}
+}
+
+// subscribe returns the channel of the events, and the function, that
+// unsubscribes.  The channel is closed, once the hub is closed.
+func (h *eventHub) subscribe() (<-chan apiEvent, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c := make(chan apiEvent, eventBuffer)
+	if h.closed {
+		close(c)
+		return c, func() {}
+	}
+	h.subs[c] = struct{}{}
+	return c, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subs[c]; ok {
+			delete(h.subs, c)
+			close(c)
+		}
+	}
+}
+
+// publish sends the event to the subscribers, it never blocks.
+func (h *eventHub) publish(ev apiEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.subs {
+		select {
+		case c <- ev:
+		default:
+			slog.Debug("event subscriber is too slow, dropping the event", "type", ev.Type)
+		}
+	}
+}
+
+// close closes the channels of the subscribers.
+func (h *eventHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.subs {
+		close(c)
+	}
+	clear(h.subs)
+	h.closed = true
+}
+
+// publishJob publishes the state of the job.
+func (h *eventHub) publishJob(j *Job) {
+	aj := newAPIJob(j)
+	h.publish(apiEvent{Type: EventJob, Time: time.Now(), Job: &aj})
+}
+
+// publishPrinter publishes the change of the printer.
+func (h *eventHub) publishPrinter(p Printer) {
+	h.publish(apiEvent{Type: EventPrinter, Time: time.Now(), printer: p})
+}
+
+// changeNotifier is implemented by the printers, that report the changes
+// of their state and of the driver status.
+type changeNotifier interface {
+	notifyChanges(fn func())
+}
+
+// sameOrigin is the WebSocket handshake of the event stream, it rejects the
+// connections, that the other web pages open, so that they can not read the
+// jobs and the printers of the server.  The connections without the Origin,
+// i.e. from the command line clients, are not cross-site.
+func sameOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if crossOrigin(r) {
+		slog.WarnContext(r.Context(), "rejected the cross-origin event stream", "origin", origin, "host", r.Host)
+		return fmt.Errorf("cross-origin request rejected: %s", origin)
+	}
+	if origin != "" {
+		config.Origin, _ = url.Parse(origin) // checked by crossOrigin
+	}
+	return nil
+}
+
+// apiEvents streams the events as the JSON messages over the WebSocket.
+// The current state of the printers is sent first.
+func (s *Server) apiEvents(ws *websocket.Conn) {
+	ctx := ws.Request().Context()
+	events, unsubscribe := s.events.subscribe()
+	defer unsubscribe()
+
+	// the client does not send anything, the read fails, once it is gone.
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		var msg []byte
+		for websocket.Message.Receive(ws, &msg) == nil {
+		}
+	}()
+
+	send := func(ev apiEvent) bool {
+		if ev.printer != nil {
+			ap := s.apiPrinter(ev.printer)
+			ev.Printer = &ap
+		}
+		if err := websocket.JSON.Send(ws, ev); err != nil {
+			slog.DebugContext(ctx, "event subscriber is gone", "error", err)
+			return false
+		}
+		return true
+	}
+	for _, p := range s.pp {
+		if !send(apiEvent{Type: EventPrinter, Time: time.Now(), printer: p}) {
+			return
+		}
+	}
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok || !send(ev) {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
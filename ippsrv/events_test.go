@@ -0,0 +1,115 @@
+package ippsrv
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func TestEventHub(t *testing.T) {
+	h := newEventHub()
+	events, unsubscribe := h.subscribe()
+
+	for range eventBuffer + 1 {
+		h.publish(apiEvent{Type: EventJob}) // never blocks
+	}
+	assert.Len(t, events, eventBuffer)
+
+	unsubscribe()
+	unsubscribe()
+	h.publish(apiEvent{Type: EventJob})
+
+	other, _ := h.subscribe()
+	h.close()
+	_, ok := <-other
+	assert.False(t, ok, "the channel is closed with the hub")
+	closed, _ := h.subscribe()
+	_, ok = <-closed
+	assert.False(t, ok, "subscribing to the closed hub")
+}
+
+func TestPrinterNotifyChanges(t *testing.T) {
+	p := mustWrapDriver(t, testDriver{}, "test-printer", "Test Printer")
+	var n int
+	p.(changeNotifier).notifyChanges(func() { n++ })
+	p.SetState(PSProcessing)
+	p.SetState(PSProcessing)
+	p.SetState(PSIdle)
+	assert.Equal(t, 2, n, "only the changes are notified")
+}
+
+// receiveEvent receives the next event of the stream.
+func receiveEvent(t *testing.T, ws *websocket.Conn) apiEvent {
+	t.Helper()
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var ev apiEvent
+	require.NoError(t, websocket.JSON.Receive(ws, &ev))
+	return ev
+}
+
+func TestAPIEvents(t *testing.T) {
+	s := newAPIServer(t, testDriver{})
+	ts := httptest.NewServer(s.srv.Handler)
+	defer ts.Close()
+
+	url := strings.Replace(ts.URL, "http", "ws", 1) + apiEventsPath
+	ws, err := websocket.Dial(url, "", ts.URL)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	ev := receiveEvent(t, ws)
+	require.Equal(t, EventPrinter, ev.Type)
+	require.NotNil(t, ev.Printer)
+	assert.Equal(t, "test-printer", ev.Printer.Name)
+
+	r := newJobForm(t, "test-printer", map[string]string{"text": "hello"}, nil)
+	resp, err := http.Post(ts.URL+r.URL.Path, r.Header.Get(hdrContentType), r.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var states []string
+	for len(states) == 0 || states[len(states)-1] != "completed" {
+		ev := receiveEvent(t, ws)
+		if ev.Type == EventJob {
+			require.NotNil(t, ev.Job)
+			states = append(states, ev.Job.State)
+		}
+	}
+	assert.Equal(t, "pending", states[0])
+	assert.Contains(t, states, "processing")
+}
+
+func TestAPIEventsCrossOrigin(t *testing.T) {
+	s := newAPIServer(t, testDriver{})
+	ts := httptest.NewServer(s.srv.Handler)
+	defer ts.Close()
+
+	url := strings.Replace(ts.URL, "http", "ws", 1) + apiEventsPath
+	_, err := websocket.Dial(url, "", "http://evil.example")
+	assert.Error(t, err, "the other web page can not open the stream")
+}
+
+func TestAPIEventsShutdown(t *testing.T) {
+	s, err := New(mustWrapDriver(t, testDriver{}, "test-printer", "Test Printer"))
+	require.NoError(t, err)
+	ts := httptest.NewServer(s.srv.Handler)
+	defer ts.Close()
+
+	ws, err := websocket.Dial(strings.Replace(ts.URL, "http", "ws", 1)+apiEventsPath, "", ts.URL)
+	require.NoError(t, err)
+	defer ws.Close()
+	receiveEvent(t, ws) // the printer
+
+	require.NoError(t, s.Shutdown(context.Background()))
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var ev apiEvent
+	assert.Error(t, websocket.JSON.Receive(ws, &ev), "the stream ends with the server")
+}
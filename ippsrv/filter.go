@@ -11,6 +11,7 @@ import (
 	"log/slog"
 	"os/exec"
 	"strconv"
+	"strings"
 
 	"golang.org/x/image/draw"
 
@@ -36,22 +37,37 @@ type rasterSniffFilter struct {
 	fallback Filter
 }
 
-var _ Filter = &rasterSniffFilter{}
+var _ PageFilter = &rasterSniffFilter{}
+
+// NewFilter returns the default filter: PWG/URF raster streams are decoded
+// natively, anything else is converted with ImageMagick.  If ImageMagick is
+// not installed, the PDF documents are rasterised natively, see
+// [NewPDFFilter].
+func NewFilter() Filter {
+	return &rasterSniffFilter{fallback: documentFilter()}
+}
 
 func (f *rasterSniffFilter) ToRaster(ctx context.Context, dpi int, data []byte) ([]image.Image, error) {
+	return f.ToRasterPages(ctx, dpi, data, nil)
+}
+
+func (f *rasterSniffFilter) ToRasterPages(ctx context.Context, dpi int, data []byte, sel PageRanges) ([]image.Image, error) {
 	if format := cupsraster.Detect(data); format != cupsraster.FormatUnknown {
 		slog.InfoContext(ctx, "decoding client-rasterised document", "format", format)
 		pages, err := cupsraster.DecodePages(bytes.NewReader(data))
 		if err != nil {
 			return nil, err
 		}
-		imgs := make([]image.Image, len(pages))
+		var imgs []image.Image
 		for i, pg := range pages {
-			imgs[i] = scaleToDPI(ctx, pg, dpi)
+			// skip the pages before scaling, it is the costly part
+			if sel.Contains(i + 1) {
+				imgs = append(imgs, scaleToDPI(ctx, pg, dpi))
+			}
 		}
 		return imgs, nil
 	}
-	return f.fallback.ToRaster(ctx, dpi, data)
+	return ToRasterPages(ctx, f.fallback, dpi, data, sel)
 }
 
 // scaleToDPI resizes a decoded page whose declared resolution differs from
@@ -81,10 +97,50 @@ func (f *rasterSniffFilter) Type() string {
 
 type imageMagickFilter struct{}
 
-var _ Filter = &imageMagickFilter{}
+var _ PageFilter = &imageMagickFilter{}
 
 func (f *imageMagickFilter) ToRaster(ctx context.Context, dpi int, data []byte) ([]image.Image, error) {
-	cmd := exec.CommandContext(ctx, "magick", "-density", strconv.Itoa(dpi), "-", "-background", "white", "-alpha", "remove", "png:-")
+	return f.convert(ctx, dpi, data, "")
+}
+
+// ToRasterPages converts only the selected pages, ImageMagick skips the
+// rest of the document without rendering it.
+func (f *imageMagickFilter) ToRasterPages(ctx context.Context, dpi int, data []byte, pages PageRanges) ([]image.Image, error) {
+	frames, ok := magickFrames(pages)
+	if !ok {
+		images, err := f.convert(ctx, dpi, data, "")
+		if err != nil {
+			return images, err
+		}
+		return pages.Select(images), nil
+	}
+	return f.convert(ctx, dpi, data, frames)
+}
+
+// magickFrames returns the ImageMagick frame selection for the page ranges,
+// i.e. "[1-3,5]" for pages 2-4 and 6.  It returns false, if the selection
+// can't be expressed, that is the case for the open ranges.
+func magickFrames(pages PageRanges) (string, bool) {
+	if len(pages) == 0 {
+		return "", true
+	}
+	parts := make([]string, len(pages))
+	for i, r := range pages {
+		if r.Last == 0 {
+			return "", false
+		}
+		parts[i] = strconv.Itoa(r.First - 1)
+		if r.Last != r.First {
+			parts[i] += "-" + strconv.Itoa(r.Last-1)
+		}
+	}
+	return "[" + strings.Join(parts, ",") + "]", true
+}
+
+// convert runs ImageMagick on the data, frames is the optional frame
+// selection, see [magickFrames].
+func (f *imageMagickFilter) convert(ctx context.Context, dpi int, data []byte, frames string) ([]image.Image, error) {
+	cmd := exec.CommandContext(ctx, "magick", "-density", strconv.Itoa(dpi), "-"+frames, "-background", "white", "-alpha", "remove", "png:-")
 	cmd.Stdin = bytes.NewReader(data)
 	out, err := cmd.Output()
 	if err != nil {
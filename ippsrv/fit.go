@@ -0,0 +1,111 @@
+package ippsrv
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+
+	"github.com/OpenPrinting/goipp"
+
+	"github.com/rusq/thermoprint/bitmap"
+)
+
+// Fit is the policy for the pages that are wider than the printer.
+type Fit string
+
+const (
+	// FitWidth scales the page down to the printer width.
+	FitWidth Fit = "fit-width"
+	// FitCrop prints the page in actual size, cropped on the right margin.
+	FitCrop Fit = "crop"
+	// FitActual prints the page in actual size, in the strips of the printer
+	// width from left to right, that can be taped together.
+	FitActual Fit = "actual-size"
+	// FitReflow re-stacks the words of the page in the lines of the printer
+	// width, so that the text remains legible, see [bitmap.Reflow].
+	FitReflow Fit = "reflow"
+)
+
+// Fits returns the names of the fit policies.
+func Fits() []string {
+	return []string{string(FitWidth), string(FitCrop), string(FitActual), string(FitReflow)}
+}
+
+// ParseFit parses the name of the fit policy.
+func ParseFit(s string) (Fit, error) {
+	switch f := Fit(s); f {
+	case FitWidth, FitCrop, FitActual, FitReflow:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown fit policy %q, must be one of %v", s, Fits())
+}
+
+// FitPages applies the fit policy to the pages for the printer of the given
+// width.  Pages that are not wider than the printer are returned as is.
+// With [FitActual], each wide page is replaced by its strips, with
+// [FitReflow], by its reflowed text.
+func FitPages(pages []image.Image, width int, fit Fit) []image.Image {
+	fitted := make([]image.Image, 0, len(pages))
+	for _, pg := range pages {
+		b := pg.Bounds()
+		if b.Dx() <= width {
+			fitted = append(fitted, pg)
+			continue
+		}
+		switch fit {
+		case FitCrop:
+			fitted = append(fitted, cropColumns(pg, b.Min.X, width))
+		case FitActual:
+			for x := b.Min.X; x < b.Max.X; x += width {
+				fitted = append(fitted, cropColumns(pg, x, width))
+			}
+		case FitReflow:
+			fitted = append(fitted, bitmap.Reflow(pg, width, 0))
+		default:
+			fitted = append(fitted, bitmap.ResizeToFit(pg, width))
+		}
+	}
+	return fitted
+}
+
+// cropColumns returns the width columns of the image starting at x, on the
+// white canvas, if the image is narrower.
+func cropColumns(img image.Image, x, width int) image.Image {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, image.Pt(x, b.Min.Y), draw.Over)
+	return dst
+}
+
+// printScalingFits maps the print-scaling keywords, see PWG 5100.13, section
+// 6.2.3, to the fit policies.  On the roll, there's no difference between
+// fitting and filling the page.  "actual-size" and "reflow" are the
+// extension keywords for printing the page in strips and reflowing its text.
+var printScalingFits = map[string]Fit{
+	"auto":        "", // printer default
+	"auto-fit":    FitWidth,
+	"fill":        FitWidth,
+	"fit":         FitWidth,
+	"none":        FitCrop,
+	"actual-size": FitActual,
+	"reflow":      FitReflow,
+}
+
+// printScalingSupported are the print-scaling keywords in the order they are
+// advertised.
+var printScalingSupported = []string{"auto", "auto-fit", "fill", "fit", "none", "actual-size", "reflow"}
+
+// requestFit returns the fit policy of the print-scaling job template
+// attribute of the request, or an empty Fit for the printer default.
+func requestFit(req *goipp.Message) (Fit, error) {
+	v, ok := jobTemplateValue(req, "print-scaling")
+	if !ok {
+		return "", nil
+	}
+	fit, ok := printScalingFits[v.String()]
+	if !ok {
+		return "", fmt.Errorf("print-scaling: unsupported value %q", v.String())
+	}
+	return fit, nil
+}
@@ -0,0 +1,95 @@
+package ippsrv
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/OpenPrinting/goipp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFitPages(t *testing.T) {
+	wide := image.NewGray(image.Rect(0, 0, 250, 40))
+	wide.SetGray(120, 0, color.Gray{}) // black dot in the second strip
+	narrow := image.NewGray(image.Rect(0, 0, 50, 10))
+
+	fitted := FitPages([]image.Image{wide, narrow}, 100, FitWidth)
+	require.Len(t, fitted, 2)
+	assert.Equal(t, image.Rect(0, 0, 100, 16), fitted[0].Bounds())
+	assert.Same(t, narrow, fitted[1].(*image.Gray), "narrow pages are not changed")
+
+	fitted = FitPages([]image.Image{wide}, 100, FitCrop)
+	require.Len(t, fitted, 1)
+	assert.Equal(t, image.Rect(0, 0, 100, 40), fitted[0].Bounds())
+
+	fitted = FitPages([]image.Image{wide, narrow}, 100, FitActual)
+	require.Len(t, fitted, 4, "three strips and the narrow page")
+	for _, strip := range fitted[:3] {
+		assert.Equal(t, image.Rect(0, 0, 100, 40), strip.Bounds())
+	}
+	r, _, _, _ := fitted[1].At(20, 0).RGBA()
+	assert.Zero(t, r, "dot must be in the second strip")
+	r, _, _, _ = fitted[2].At(60, 0).RGBA()
+	assert.Equal(t, uint32(0xffff), r, "last strip is padded with white")
+}
+
+func TestParseFit(t *testing.T) {
+	for _, s := range Fits() {
+		fit, err := ParseFit(s)
+		require.NoError(t, err)
+		assert.Equal(t, s, string(fit))
+	}
+	_, err := ParseFit("stretch")
+	assert.Error(t, err)
+}
+
+func TestRequestFit(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Fit
+		wantErr bool
+	}{
+		{value: "auto", want: ""},
+		{value: "fit", want: FitWidth},
+		{value: "none", want: FitCrop},
+		{value: "actual-size", want: FitActual},
+		{value: "reflow", want: FitReflow},
+		{value: "stretch", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			req := newIPPRequest(goipp.OpPrintJob, testRequestID)
+			req.Job.Add(goipp.MakeAttribute("print-scaling", goipp.TagKeyword, goipp.String(tt.value)))
+			got, err := requestFit(req)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPrintWithOptionsFit(t *testing.T) {
+	driver := &captureDriver{}
+	p, err := WrapDriver(driver, "test-printer", "Test Printer", WithFit(FitCrop))
+	require.NoError(t, err)
+	op := p.(OptionPrinter)
+	wide := mustPNG(t, image.NewGray(image.Rect(0, 0, 1000, 10)))
+
+	require.NoError(t, op.PrintWithOptions(context.Background(), wide, PrintOptions{}))
+	assert.Equal(t, image.Rect(0, 0, 384, 10), driver.printedBounds(), "printer default is cropping")
+
+	require.NoError(t, op.PrintWithOptions(context.Background(), wide, PrintOptions{Fit: FitActual}))
+	assert.Equal(t, image.Rect(0, 0, 384, 30), driver.printedBounds(), "three strips")
+
+	require.NoError(t, op.PrintWithOptions(context.Background(), wide, PrintOptions{Fit: FitWidth}))
+	assert.Equal(t, image.Rect(0, 0, 1000, 10), driver.printedBounds(), "driver scales the image")
+
+	_, err = WrapDriver(driver, "test-printer", "Test Printer", WithFit("stretch"))
+	assert.Error(t, err)
+}
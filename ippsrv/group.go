@@ -0,0 +1,213 @@
+package ippsrv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// GroupPolicy is the policy, with which the [Group] chooses the member
+// printer for the job.
+type GroupPolicy string
+
+const (
+	// GroupFailover prints on the first available member, the others are
+	// the spares.
+	GroupFailover GroupPolicy = "failover"
+	// GroupRoundRobin prints on the available members in turn.
+	GroupRoundRobin GroupPolicy = "round-robin"
+)
+
+// GroupPolicies returns the names of the group policies.
+func GroupPolicies() []string {
+	return []string{string(GroupFailover), string(GroupRoundRobin)}
+}
+
+// ParseGroupPolicy parses the group policy.
+func ParseGroupPolicy(s string) (GroupPolicy, error) {
+	switch gp := GroupPolicy(strings.ToLower(s)); gp {
+	case GroupFailover, GroupRoundRobin:
+		return gp, nil
+	}
+	return "", fmt.Errorf("unknown group policy %q, must be one of: %s", s, strings.Join(GroupPolicies(), ", "))
+}
+
+// Group is the logical printer, the queue, that is backed by several
+// printers.  The job is printed on the member, that the policy chooses;
+// the members, that are stopped, out of paper or disconnected, are tried
+// last.  If the member fails, the job is printed on the next one, so the
+// jobs keep printing, while one of the printers is out of paper or offline.
+// The job, that failed in the middle, may be printed partially twice.
+//
+// The members are expected to be the same model: the group reports the
+// media and the driver of the first one.
+type Group struct {
+	name     string
+	fullname string
+	policy   GroupPolicy
+	members  []Printer
+
+	mu   sync.Mutex
+	next int // the member, that prints next with GroupRoundRobin
+
+	stateMu sync.RWMutex
+	state   PrinterState
+}
+
+var (
+	_ Printer       = (*Group)(nil)
+	_ OptionPrinter = (*Group)(nil)
+	_ StateReasoner = (*Group)(nil)
+)
+
+// NewGroup returns the group of the printers with the policy.  The members
+// are not served on their own, unless they are added to the server too, see
+// [WithAdditionalPrinters].
+func NewGroup(name, fullname string, policy GroupPolicy, members ...Printer) (*Group, error) {
+	if name == "" {
+		return nil, errors.New("group name cannot be empty")
+	}
+	if fullname == "" {
+		return nil, errors.New("group fullname cannot be empty")
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("group %s: no member printers", name)
+	}
+	if _, err := ParseGroupPolicy(string(policy)); err != nil {
+		return nil, fmt.Errorf("group %s: %w", name, err)
+	}
+	return &Group{
+		name:     name,
+		fullname: fullname,
+		policy:   policy,
+		members:  members,
+		state:    PSIdle,
+	}, nil
+}
+
+func (g *Group) Name() string         { return g.name }
+func (g *Group) MakeAndModel() string { return g.fullname }
+func (g *Group) Info() string         { return g.fullname }
+func (g *Group) UpTime() int          { return g.members[0].UpTime() }
+func (g *Group) Driver() Driver       { return g.members[0].Driver() }
+
+func (g *Group) MediaSupported() []string { return g.members[0].MediaSupported() }
+func (g *Group) MediaDefault() string     { return g.members[0].MediaDefault() }
+
+func (g *Group) UUID() string {
+	return uuid.NewSHA1(uuid.UUID{}, []byte("group:"+g.name)).String()
+}
+
+// Members returns the member printers of the group.
+func (g *Group) Members() []Printer {
+	return slices.Clone(g.members)
+}
+
+// Ready reports whether any of the members is ready.
+func (g *Group) Ready() bool {
+	return slices.ContainsFunc(g.members, Printer.Ready)
+}
+
+func (g *Group) State() PrinterState {
+	g.stateMu.RLock()
+	defer g.stateMu.RUnlock()
+	return g.state
+}
+
+func (g *Group) SetState(state PrinterState) {
+	g.stateMu.Lock()
+	defer g.stateMu.Unlock()
+	g.state = state
+}
+
+// StateReasons returns the reasons of the members, when none of them is
+// available, the group prints, while any of them is.
+func (g *Group) StateReasons() []PrinterStateReason {
+	var reasons []PrinterStateReason
+	for _, m := range g.members {
+		if memberAvailable(m) {
+			return nil
+		}
+		for _, r := range stateReasons(m) {
+			if r != PSRNone && !slices.Contains(reasons, r) {
+				reasons = append(reasons, r)
+			}
+		}
+	}
+	return reasons
+}
+
+func (g *Group) Print(ctx context.Context, data []byte) error {
+	return g.PrintWithOptions(ctx, data, PrintOptions{})
+}
+
+// PrintWithOptions prints the data on the member, that the policy chooses,
+// or on the next one, if it fails.
+func (g *Group) PrintWithOptions(ctx context.Context, data []byte, opts PrintOptions) error {
+	var errs error
+	for _, m := range g.order() {
+		err := printMember(ctx, m, data, opts)
+		if err == nil {
+			slog.InfoContext(ctx, "group job printed", "group", g.name, "printer", m.Name())
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		slog.WarnContext(ctx, "group member failed, trying the next one", "group", g.name, "printer", m.Name(), "error", err)
+		errs = errors.Join(errs, fmt.Errorf("%s: %w", m.Name(), err))
+	}
+	return fmt.Errorf("group %s: all printers failed: %w", g.name, errs)
+}
+
+// order returns the members in the order they are tried: the available
+// ones first, starting from the one, that the policy chooses, then the
+// unavailable ones, their status may be outdated.
+func (g *Group) order() []Printer {
+	members := slices.Clone(g.members)
+	if g.policy == GroupRoundRobin {
+		g.mu.Lock()
+		start := g.next
+		g.next = (g.next + 1) % len(members)
+		g.mu.Unlock()
+		members = slices.Concat(members[start:], members[:start])
+	}
+	slices.SortStableFunc(members, func(a, b Printer) int {
+		switch aa, ba := memberAvailable(a), memberAvailable(b); {
+		case aa == ba:
+			return 0
+		case aa:
+			return -1
+		}
+		return 1
+	})
+	return members
+}
+
+// memberAvailable reports whether the member printer can print: it is not
+// stopped, has the paper, and its driver is connected.
+func memberAvailable(p Printer) bool {
+	if p.State() == PSStopped || !p.Ready() {
+		return false
+	}
+	if slices.Contains(stateReasons(p), PSRMediaEmpty) {
+		return false
+	}
+	if s, ok := p.Driver().(snapshotter); ok {
+		if snap := s.Snapshot(); !snap.Connected && !snap.DryRun {
+			return false
+		}
+	}
+	return true
+}
+
+// printMember prints the data on the member printer with the options.
+func printMember(ctx context.Context, p Printer, data []byte, opts PrintOptions) error {
+	return printWithOptions(ctx, p, data, printJobOptions{trimTrailingBlank: opts.TrimTrailingBlank, progress: opts.Progress, pages: opts.Pages, fit: opts.Fit, format: opts.Format, origin: opts.Origin, orientation: opts.Orientation, quality: opts.Quality, lang: opts.Lang, dither: opts.Dither})
+}
@@ -0,0 +1,137 @@
+package ippsrv
+
+import (
+	"context"
+	"errors"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rusq/thermoprint"
+)
+
+// countingDriver counts the printed images, or fails with err.
+type countingDriver struct {
+	n   atomic.Int32
+	err error
+}
+
+func (*countingDriver) SetOptions(opt ...thermoprint.Option) error { return nil }
+func (d *countingDriver) PrintImage(ctx context.Context, img image.Image) error {
+	if d.err != nil {
+		return d.err
+	}
+	d.n.Add(1)
+	return nil
+}
+func (*countingDriver) DPI() float64 { return 203 }
+func (*countingDriver) Width() int   { return 384 }
+
+func newTestGroup(t *testing.T, policy GroupPolicy, drivers ...*countingDriver) *Group {
+	t.Helper()
+	var members []Printer
+	for i, d := range drivers {
+		members = append(members, mustWrapDriver(t, d, "member-"+string(rune('a'+i)), "Member"))
+	}
+	g, err := NewGroup("group", "Test Group", policy, members...)
+	require.NoError(t, err)
+	return g
+}
+
+func TestParseGroupPolicy(t *testing.T) {
+	gp, err := ParseGroupPolicy("Round-Robin")
+	require.NoError(t, err)
+	assert.Equal(t, GroupRoundRobin, gp)
+	_, err = ParseGroupPolicy("random")
+	assert.Error(t, err)
+}
+
+func TestNewGroup(t *testing.T) {
+	p := mustWrapDriver(t, testDriver{}, "test-printer", "Test Printer")
+	_, err := NewGroup("group", "Test Group", GroupFailover)
+	assert.Error(t, err, "no members")
+	_, err = NewGroup("", "Test Group", GroupFailover, p)
+	assert.Error(t, err, "no name")
+	_, err = NewGroup("group", "Test Group", "random", p)
+	assert.Error(t, err, "unknown policy")
+}
+
+func TestGroupFailover(t *testing.T) {
+	a, b := &countingDriver{}, &countingDriver{}
+	g := newTestGroup(t, GroupFailover, a, b)
+
+	for range 2 {
+		require.NoError(t, g.Print(context.Background(), tinyPNG(t)))
+	}
+	assert.Equal(t, int32(2), a.n.Load(), "the first member prints")
+	assert.Equal(t, int32(0), b.n.Load())
+
+	// out of paper: the spare prints.
+	g.members[0].(*basePrinter).setStatus(thermoprint.Status{BatteryLevel: 100, NoPaper: true})
+	require.NoError(t, g.Print(context.Background(), tinyPNG(t)))
+	assert.Equal(t, int32(1), b.n.Load())
+	assert.Empty(t, g.StateReasons(), "the group prints, while any member does")
+
+	// the paper is back, but the printer fails.
+	g.members[0].(*basePrinter).setStatus(thermoprint.Status{BatteryLevel: 100})
+	a.err = errors.New("disconnected")
+	require.NoError(t, g.Print(context.Background(), tinyPNG(t)))
+	assert.Equal(t, int32(2), b.n.Load())
+
+	b.err = errors.New("disconnected")
+	err := g.Print(context.Background(), tinyPNG(t))
+	assert.ErrorContains(t, err, "member-a")
+	assert.ErrorContains(t, err, "member-b")
+}
+
+func TestGroupRoundRobin(t *testing.T) {
+	a, b, c := &countingDriver{}, &countingDriver{}, &countingDriver{}
+	g := newTestGroup(t, GroupRoundRobin, a, b, c)
+	for range 6 {
+		require.NoError(t, g.Print(context.Background(), tinyPNG(t)))
+	}
+	for _, d := range []*countingDriver{a, b, c} {
+		assert.Equal(t, int32(2), d.n.Load())
+	}
+
+	// the member without the paper is skipped.
+	g.members[1].(*basePrinter).setStatus(thermoprint.Status{BatteryLevel: 100, NoPaper: true})
+	for range 4 {
+		require.NoError(t, g.Print(context.Background(), tinyPNG(t)))
+	}
+	assert.Equal(t, int32(2), b.n.Load())
+	assert.Equal(t, int32(8), a.n.Load()+c.n.Load())
+}
+
+func TestGroupStateReasons(t *testing.T) {
+	g := newTestGroup(t, GroupFailover, &countingDriver{}, &countingDriver{})
+	for _, m := range g.members {
+		m.(*basePrinter).setStatus(thermoprint.Status{BatteryLevel: 100, NoPaper: true})
+	}
+	assert.Equal(t, []PrinterStateReason{PSRMediaEmpty}, g.StateReasons())
+}
+
+func TestGroupServer(t *testing.T) {
+	a := &countingDriver{err: errors.New("offline")}
+	b := &countingDriver{}
+	g := newTestGroup(t, GroupFailover, a, b)
+	s, err := New(g)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, s.Shutdown(context.Background())) })
+
+	var job apiJob
+	rec := serveAPI(t, s, newJobForm(t, "group", nil, tinyPNG(t)), &job)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	j, err := s.is.spool.GetJob(job.ID)
+	require.NoError(t, err)
+	waitJob(t, j)
+	var got apiJob
+	serveAPI(t, s, httptest.NewRequest(http.MethodGet, rec.Header().Get("Location"), nil), &got)
+	assert.Equal(t, "completed", got.State)
+	assert.Equal(t, int32(1), b.n.Load())
+}
@@ -0,0 +1,207 @@
+package ippsrv
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rusq/thermoprint"
+)
+
+const (
+	// healthPath is the path of the health check, it is not logged and does
+	// not require the authentication, so that the orchestration can poll it.
+	healthPath = "/healthz"
+	// DefaultHealthTTL is the time the result of the printer probe is kept.
+	DefaultHealthTTL = 15 * time.Second
+	// probeTimeout is the time the printer has to respond to the probe.
+	probeTimeout = 5 * time.Second
+)
+
+// errUnreachable is returned by the probe of the printer, that is not
+// connected.
+var errUnreachable = errors.New("printer is not reachable")
+
+// WithHealthTTL sets the time the result of the printer probe of the health
+// check is kept, see [Server.Healthy].  Zero is [DefaultHealthTTL].
+func WithHealthTTL(d time.Duration) Option {
+	return func(s *Server) {
+		s.health.ttl = d
+	}
+}
+
+// prober is implemented by the printers, that check, whether the device is
+// reachable.
+type prober interface {
+	probe(ctx context.Context) error
+}
+
+// probe checks, that the printer is connected and responds to the status
+// request.  The printer, that prints, is reachable, the probe does not wait
+// for the job, and the job waits for the probe to finish.
+func (p *basePrinter) probe(ctx context.Context) error {
+	if !p.printMu.TryLock() {
+		return nil
+	}
+	defer p.printMu.Unlock()
+
+	if s, ok := p.Drv.(snapshotter); ok {
+		snap := s.Snapshot()
+		if snap.DryRun {
+			return nil
+		}
+		if !snap.Connected {
+			return errUnreachable
+		}
+	}
+	q, ok := p.Drv.(thermoprint.StatusQuerier)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	if _, err := q.Status(ctx); err != nil && !errors.Is(err, thermoprint.ErrBusy) {
+		return fmt.Errorf("%w: %w", errUnreachable, err)
+	}
+	return nil
+}
+
+// probe checks the members, the group is reachable, while any of them is.
+func (g *Group) probe(ctx context.Context) error {
+	var errs error
+	for _, m := range g.members {
+		err := probePrinter(ctx, m)
+		if err == nil {
+			return nil
+		}
+		errs = errors.Join(errs, fmt.Errorf("%s: %w", m.Name(), err))
+	}
+	return errs
+}
+
+// probePrinter probes the printer, the printers, that can't be probed, are
+// reachable.
+func probePrinter(ctx context.Context, p Printer) error {
+	if pr, ok := p.(prober); ok {
+		return pr.probe(ctx)
+	}
+	return nil
+}
+
+// probeResult is the cached result of the printer probe.
+type probeResult struct {
+	err     error
+	checked time.Time
+}
+
+// probeCall is the probe in progress, the concurrent health checks wait for
+// it, rather than probe the printer again.
+type probeCall struct {
+	done   chan struct{} // closed, once the result is set
+	result probeResult
+}
+
+// healthCache keeps the results of the printer probes for the TTL.
+type healthCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	results map[string]probeResult
+	calls   map[string]*probeCall
+}
+
+// probe returns the cached result of the printer probe, or probes it, if
+// the result is older than the TTL.  The probe is not bound to the context,
+// the caller, that gives up, gets the context error, and the probe finishes
+// for the next one.
+func (hc *healthCache) probe(ctx context.Context, p Printer) probeResult {
+	hc.mu.Lock()
+	if r, ok := hc.results[p.Name()]; ok && time.Since(r.checked) < cmp.Or(hc.ttl, DefaultHealthTTL) {
+		hc.mu.Unlock()
+		return r
+	}
+	call, ok := hc.calls[p.Name()]
+	if !ok {
+		call = &probeCall{done: make(chan struct{})}
+		if hc.calls == nil {
+			hc.calls = make(map[string]*probeCall)
+		}
+		hc.calls[p.Name()] = call
+		go hc.run(context.WithoutCancel(ctx), p, call)
+	}
+	hc.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.result
+	case <-ctx.Done():
+		return probeResult{err: ctx.Err(), checked: time.Now()}
+	}
+}
+
+// run probes the printer, and caches the result, unless the probe was
+// cancelled.  Every device has probeTimeout to respond, see
+// basePrinter.probe, the group members are probed one after another.
+func (hc *healthCache) run(ctx context.Context, p Printer, call *probeCall) {
+	r := probeResult{err: probePrinter(ctx, p), checked: time.Now()}
+
+	hc.mu.Lock()
+	delete(hc.calls, p.Name())
+	if !errors.Is(r.err, context.Canceled) {
+		if hc.results == nil {
+			hc.results = make(map[string]probeResult)
+		}
+		hc.results[p.Name()] = r
+	}
+	hc.mu.Unlock()
+	call.result = r
+	close(call.done)
+}
+
+// Healthy returns nil, if all printers are reachable, or the error, that
+// names the unreachable ones.  The results of the probes are kept for the
+// TTL, see [WithHealthTTL].
+func (s *Server) Healthy(ctx context.Context) error {
+	var errs error
+	for _, p := range s.pp {
+		if r := s.health.probe(ctx, p); r.err != nil {
+			errs = errors.Join(errs, fmt.Errorf("printer %s: %w", p.Name(), r.err))
+		}
+	}
+	return errs
+}
+
+// healthPrinter is the printer of the health check response.
+type healthPrinter struct {
+	Name      string    `json:"name"`
+	Reachable bool      `json:"reachable"`
+	Error     string    `json:"error,omitempty"`
+	Checked   time.Time `json:"checked"`
+}
+
+// healthResponse is the health check response.
+type healthResponse struct {
+	Status   string          `json:"status"` // "ok" or "unavailable"
+	Printers []healthPrinter `json:"printers"`
+}
+
+// handleHealth responds with 200, if all printers are reachable, and with
+// 503 otherwise.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	resp := healthResponse{Status: "ok", Printers: make([]healthPrinter, 0, len(s.pp))}
+	code := http.StatusOK
+	for _, p := range s.pp {
+		pr := s.health.probe(r.Context(), p)
+		hp := healthPrinter{Name: p.Name(), Reachable: pr.err == nil, Checked: pr.checked}
+		if pr.err != nil {
+			hp.Error = pr.err.Error()
+			resp.Status, code = "unavailable", http.StatusServiceUnavailable
+		}
+		resp.Printers = append(resp.Printers, hp)
+	}
+	writeJSON(w, code, resp)
+}
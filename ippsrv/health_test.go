@@ -0,0 +1,140 @@
+package ippsrv
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rusq/thermoprint"
+)
+
+// probeDriver is a test driver that responds to the status request with
+// the error, once the release channel, if set, is closed.
+type probeDriver struct {
+	snapshotDriver
+	queries atomic.Int32
+	err     error
+	release chan struct{}
+}
+
+func (d *probeDriver) Status(ctx context.Context) (thermoprint.Status, error) {
+	d.queries.Add(1)
+	if d.release != nil {
+		select {
+		case <-d.release:
+		case <-ctx.Done():
+			return thermoprint.Status{}, ctx.Err()
+		}
+	}
+	return thermoprint.Status{}, d.err
+}
+
+func TestPrinterProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		drv     Driver
+		wantErr bool
+	}{
+		{name: "no status", drv: testDriver{}},
+		{name: "dry run", drv: snapshotDriver{snap: thermoprint.PrinterSnapshot{DryRun: true}}},
+		{name: "disconnected", drv: snapshotDriver{}, wantErr: true},
+		{name: "connected", drv: snapshotDriver{snap: thermoprint.PrinterSnapshot{Connected: true}}},
+		{name: "status", drv: &probeDriver{snapshotDriver: snapshotDriver{snap: thermoprint.PrinterSnapshot{Connected: true}}}},
+		{name: "busy", drv: &probeDriver{snapshotDriver: snapshotDriver{snap: thermoprint.PrinterSnapshot{Connected: true}}, err: thermoprint.ErrBusy}},
+		{name: "no response", drv: &probeDriver{snapshotDriver: snapshotDriver{snap: thermoprint.PrinterSnapshot{Connected: true}}, err: errors.New("timeout")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := mustWrapDriver(t, tt.drv, "test-printer", "Test Printer")
+			err := probePrinter(context.Background(), p)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, errUnreachable)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPrinterProbeWhilePrinting(t *testing.T) {
+	drv := &probeDriver{snapshotDriver: snapshotDriver{snap: thermoprint.PrinterSnapshot{Connected: true}}, err: errors.New("timeout")}
+	p := mustWrapDriver(t, drv, "test-printer", "Test Printer")
+	p.(*basePrinter).printMu.Lock()
+	defer p.(*basePrinter).printMu.Unlock()
+
+	assert.NoError(t, probePrinter(context.Background(), p), "the printer, that prints, is reachable")
+	assert.Zero(t, drv.queries.Load(), "the printer is not queried while printing")
+}
+
+func TestGroupProbe(t *testing.T) {
+	up := mustWrapDriver(t, snapshotDriver{snap: thermoprint.PrinterSnapshot{Connected: true}}, "up", "Up")
+	down := mustWrapDriver(t, snapshotDriver{}, "down", "Down")
+
+	g, err := NewGroup("group", "Group", GroupFailover, down, up)
+	require.NoError(t, err)
+	assert.NoError(t, probePrinter(context.Background(), g))
+
+	g, err = NewGroup("group", "Group", GroupFailover, down)
+	require.NoError(t, err)
+	assert.ErrorIs(t, probePrinter(context.Background(), g), errUnreachable)
+}
+
+func TestHealthz(t *testing.T) {
+	drv := &probeDriver{snapshotDriver: snapshotDriver{snap: thermoprint.PrinterSnapshot{Connected: true}}}
+	s := newAPIServer(t, drv, WithHealthTTL(time.Hour), WithBasicAuth(Users{"alice": "secret"}, AuthAll))
+
+	var resp healthResponse
+	rec := serveAPI(t, s, httptest.NewRequest(http.MethodGet, healthPath, nil), &resp)
+	assert.Equal(t, http.StatusOK, rec.Code, "the health check does not require the authentication")
+	assert.Equal(t, "ok", resp.Status)
+	require.Len(t, resp.Printers, 1)
+	assert.True(t, resp.Printers[0].Reachable)
+
+	drv.err = errors.New("timeout")
+	serveAPI(t, s, httptest.NewRequest(http.MethodGet, healthPath, nil), nil)
+	assert.Equal(t, int32(1), drv.queries.Load(), "the probe is cached")
+	assert.NoError(t, s.Healthy(context.Background()))
+
+	s.health.ttl = time.Nanosecond
+	rec = serveAPI(t, s, httptest.NewRequest(http.MethodGet, healthPath, nil), &resp)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "unavailable", resp.Status)
+	assert.False(t, resp.Printers[0].Reachable)
+	assert.Contains(t, resp.Printers[0].Error, "timeout")
+	assert.ErrorIs(t, s.Healthy(context.Background()), errUnreachable)
+}
+
+func TestHealthProbeOutlivesRequest(t *testing.T) {
+	drv := &probeDriver{snapshotDriver: snapshotDriver{snap: thermoprint.PrinterSnapshot{Connected: true}}, release: make(chan struct{})}
+	s := newAPIServer(t, drv, WithHealthTTL(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // the client gave up
+	var resp healthResponse
+	rec := serveAPI(t, s, httptest.NewRequest(http.MethodGet, healthPath, nil).WithContext(ctx), &resp)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	healthy := make(chan error, 1)
+	go func() { healthy <- s.Healthy(context.Background()) }()
+	close(drv.release)
+	assert.NoError(t, <-healthy, "the cancelled request is not cached")
+	assert.NoError(t, s.Healthy(context.Background()))
+	assert.Equal(t, int32(1), drv.queries.Load(), "the probe in progress is shared")
+}
+
+func TestHealthCancelledProbeNotCached(t *testing.T) {
+	drv := &probeDriver{snapshotDriver: snapshotDriver{snap: thermoprint.PrinterSnapshot{Connected: true}}, err: context.Canceled}
+	s := newAPIServer(t, drv, WithHealthTTL(time.Hour))
+
+	assert.ErrorIs(t, s.Healthy(context.Background()), context.Canceled)
+	drv.err = nil
+	assert.NoError(t, s.Healthy(context.Background()))
+	assert.Equal(t, int32(2), drv.queries.Load())
+}
@@ -3,6 +3,7 @@ package ippsrv
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -18,6 +19,7 @@ import (
 
 	"github.com/OpenPrinting/goipp"
 	"github.com/rusq/httpex"
+	"golang.org/x/net/websocket"
 )
 
 var MaxDocumentSize int64 = 104857600
@@ -32,8 +34,28 @@ type Server struct {
 	srv *http.Server    // HTTP server instance
 	is  *basicIPPServer // IPP server instance
 
-	debug   bool
-	dumpdir string
+	debug    bool
+	dumpdir  string
+	dedup    *dedup        // nil, unless the deduplication is enabled
+	jobTTL   time.Duration // time the job may stay pending, zero is forever
+	spoolDir string        // persistent spool directory, empty for the temporary spool
+	maxQueue int           // maximum queued jobs per printer, zero is unlimited
+
+	spoolNaming SpoolNaming         // see WithSpoolNaming, empty for the default
+	events      *eventHub           // the job and the printer events, see apiEvents
+	ready       func(addr net.Addr) // see WithReady, may be nil
+	drainWait   func(jobs int)      // called, when Drain waits for the jobs, set in tests
+	health      healthCache         // the printer probes, see Healthy
+
+	tls struct {
+		certFile, keyFile string      // see WithTLS
+		config            *tls.Config // nil, unless IPPS is enabled
+	}
+
+	auth struct {
+		users Users     // see WithBasicAuth, nil if disabled
+		scope AuthScope // what requires the authentication
+	}
 
 	bonjour struct {
 		enabled bool
@@ -94,19 +116,60 @@ func New(p Printer, opts ...Option) (*Server, error) {
 		slog.Info("protocol dump", "directory", s.dumpdir)
 	}
 
-	ippsrv, err := newBasicIPPServer("/printers/", s.pp...)
+	if s.tls.certFile != "" || s.tls.keyFile != "" {
+		config, err := tlsConfig(s.tls.certFile, s.tls.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		s.tls.config = config
+	}
+
+	ippsrv, err := newBasicIPPServer("/printers/", s.spoolDir, s.pp...)
 	if err != nil {
 		return nil, err
 	}
+	s.events = newEventHub()
+	for _, p := range s.pp {
+		if cn, ok := p.(changeNotifier); ok {
+			cn.notifyChanges(func() { s.events.publishPrinter(p) })
+		}
+	}
+	ippsrv.dedup = s.dedup
+	ippsrv.tls = s.tls.config != nil
+	ippsrv.auth = s.authIPP()
+	if sp, ok := ippsrv.spool.(*spool); ok {
+		sp.setJobTTL(s.jobTTL)
+		sp.setMaxQueue(s.maxQueue)
+		sp.setNaming(s.spoolNaming)
+		sp.setNotify(s.events.publishJob)
+	}
 	s.is = ippsrv
 
+	admin, ipp, job := s.handleAdmin, s.handlePrint, s.handleJob
+	api := func(h http.HandlerFunc) http.HandlerFunc { return h }
+	if len(s.auth.users) > 0 {
+		// the API lists, cancels and prints the jobs, as the admin page
+		// does, the scope governs the IPP endpoints only.
+		admin, api = s.requireAuth(admin), s.requireAuth
+	}
+	if s.authIPP() {
+		ipp, job = s.requireAuth(ipp), s.requireAuth(job)
+	}
 	m := http.NewServeMux()
-	m.HandleFunc("/admin/", s.handleAdmin)
-	m.HandleFunc("POST /printers/{name}", s.handlePrint)
-	m.HandleFunc("POST /printers/{name}/{job}", s.handleJob)
-	m.HandleFunc("/", s.handlePrint)
+	s.registerAPI(m, api)
+	m.HandleFunc(adminPath, admin)
+	m.HandleFunc("GET "+iconPath, s.handleIcon)
+	m.HandleFunc("POST /printers/{name}", ipp)
+	m.HandleFunc("POST /printers/{name}/{job}", job)
+	m.HandleFunc("/", ipp)
+	// the WebSocket needs the connection, that the log middleware does
+	// not let to hijack, and the health check is polled too often to log.
+	root := http.NewServeMux()
+	root.Handle("GET "+apiEventsPath, api(websocket.Server{Handler: s.apiEvents, Handshake: sameOrigin}.ServeHTTP))
+	root.HandleFunc("GET "+healthPath, s.handleHealth)
+	root.Handle("/", httpex.LogMiddleware(m, log.Default()))
 	srv := &http.Server{
-		Handler: httpex.LogMiddleware(m, log.Default()),
+		Handler: root,
 	}
 	s.srv = srv
 
@@ -168,6 +231,14 @@ func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 	slog.InfoContext(r.Context(), "admin requested", "endpoint", "admin", "method", r.Method)
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		httpError(w, http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set(hdrContentType, "text/html; charset=utf-8")
+	if err := adminTemplate.Execute(w, s.Snapshot()); err != nil {
+		slog.ErrorContext(r.Context(), "failed to render admin page", "error", err)
+	}
 }
 
 func httpError(w http.ResponseWriter, code int) {
@@ -214,7 +285,7 @@ func (s *Server) handlePrint(w http.ResponseWriter, r *http.Request) {
 	}
 	// Pass the control to the IPP server handler
 	w.Header().Set(hdrContentType, ippMIMEType)
-	resp, err := s.is.ServeIPP(r.Context(), &msg, payload)
+	resp, err := s.is.ServeIPP(withRemoteAddr(r.Context(), r.RemoteAddr), &msg, payload)
 	if err != nil {
 		if err := baseResponse(goipp.StatusErrorInternal, msg.RequestID).Encode(w); err != nil {
 			slog.Error("failed to encode response", "error", err)
@@ -235,11 +306,17 @@ func (s *Server) ListenAndServe(addr string) error {
 		return err
 	}
 	s.setListenAddr(addr)
+	if s.tls.config != nil {
+		l = &sniffListener{Listener: l, config: s.tls.config}
+	}
 	if s.bonjour.enabled {
 		if err := s.startBonjour(l.Addr().(*net.TCPAddr)); err != nil {
 			slog.Warn("bonjour advertisement disabled", "error", err)
 		}
 	}
+	if s.ready != nil {
+		s.ready(l.Addr())
+	}
 	return s.srv.Serve(l)
 }
 
@@ -270,6 +347,7 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	s.stopBonjour(bonjourCtx)
 	stopBonjour()
 
+	s.events.close()
 	var errs error
 	for _, fn := range []func(ctx context.Context) error{
 		s.is.Shutdown,
@@ -0,0 +1,131 @@
+package ippsrv
+
+// Printer icons for the driverless clients: iOS, macOS and CUPS show the
+// printer-icons next to the printer, instead of the generic placeholder.
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// iconSizes are the sizes of the printer-icons, PWG 5100.13, section
+// 6.5.4: small, normal and large.
+var iconSizes = []int{48, 128, 512}
+
+const (
+	iconPath  = "/icons/"
+	adminPath = "/admin/"
+)
+
+var (
+	iconBody  = color.RGBA{0x3c, 0x40, 0x46, 0xff}
+	iconPaper = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	iconLine  = color.RGBA{0x9a, 0xa0, 0xa6, 0xff}
+	iconLED   = color.RGBA{0x34, 0xa8, 0x53, 0xff}
+)
+
+// drawIcon draws the thermal printer icon of the given size: the box of
+// the printer with the receipt coming out of the slot on the top.
+func drawIcon(size int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	var (
+		u      = func(n int) int { return n * size / 16 } // 16x16 grid
+		stroke = max(1, size/32)
+	)
+	fill := func(r image.Rectangle, c color.Color) {
+		draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+	rect := func(x0, y0, x1, y1 int) image.Rectangle {
+		return image.Rect(u(x0), u(y0), u(x1), u(y1))
+	}
+	// receipt with the outline, so that it is seen on the white background,
+	// and the lines of text.
+	fill(rect(4, 1, 12, 9), iconLine)
+	fill(rect(4, 1, 12, 9).Inset(stroke), iconPaper)
+	for y := 2; y < 8; y += 2 {
+		fill(image.Rect(u(5), u(y), u(11), u(y)+stroke), iconLine)
+	}
+	// printer body and the slot.
+	fill(rect(1, 7, 15, 15), iconBody)
+	fill(rect(3, 8, 13, 9), color.Black)
+	fill(rect(12, 12, 13, 13), iconLED)
+	return img
+}
+
+// icons caches the encoded PNG icons by size.
+var icons = struct {
+	once sync.Once
+	png  map[int][]byte
+}{}
+
+// iconPNG returns the PNG icon of the given size, or false, if the size is
+// not one of the iconSizes.
+func iconPNG(size int) ([]byte, bool) {
+	icons.once.Do(func() {
+		icons.png = make(map[int][]byte, len(iconSizes))
+		for _, sz := range iconSizes {
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, drawIcon(sz)); err != nil {
+				slog.Error("failed to encode the printer icon", "size", sz, "error", err)
+				continue
+			}
+			icons.png[sz] = buf.Bytes()
+		}
+	})
+	data, ok := icons.png[size]
+	return data, ok
+}
+
+// iconName returns the file name of the icon of the given size.
+func iconName(size int) string {
+	return fmt.Sprintf("printer-%d.png", size)
+}
+
+// handleIcon serves the printer icons at /icons/printer-<size>.png.
+func (s *Server) handleIcon(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, iconPath)
+	sz, ok := strings.CutPrefix(strings.TrimSuffix(name, ".png"), "printer-")
+	size, err := strconv.Atoi(sz)
+	if !ok || err != nil || !slices.Contains(iconSizes, size) || name != iconName(size) {
+		http.NotFound(w, r)
+		return
+	}
+	data, ok := iconPNG(size)
+	if !ok {
+		httpError(w, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(hdrContentType, "image/png")
+	w.Header().Set("Cache-Control", "max-age=86400")
+	w.Write(data)
+}
+
+// webURL returns the http(s) URL of the path on the host of the printer
+// URI, or false, if the printer URI has no host, i.e. the client did not
+// send it.
+func webURL(printerURI, path string) (string, bool) {
+	u, err := url.Parse(printerURI)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	scheme := "http"
+	if u.Scheme == "ipps" {
+		scheme = "https"
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = u.Host + ":631" // default IPP port
+	}
+	return (&url.URL{Scheme: scheme, Host: host, Path: path}).String(), true
+}
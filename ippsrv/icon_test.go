@@ -0,0 +1,66 @@
+package ippsrv
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OpenPrinting/goipp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleIcon(t *testing.T) {
+	server, err := New(mustWrapDriver(t, testDriver{}, "test-printer", "Test Printer"))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, server.Shutdown(context.Background())) })
+
+	for _, size := range iconSizes {
+		rec := httptest.NewRecorder()
+		server.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, iconPath+iconName(size), nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "image/png", rec.Header().Get(hdrContentType))
+		cfg, err := png.DecodeConfig(bytes.NewReader(rec.Body.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, []int{size, size}, []int{cfg.Width, cfg.Height})
+	}
+	for _, path := range []string{"/icons/printer-64.png", "/icons/printer-48.gif", "/icons/"} {
+		rec := httptest.NewRecorder()
+		server.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code, path)
+	}
+}
+
+func TestWebURL(t *testing.T) {
+	tests := []struct {
+		uri    string
+		want   string
+		wantOK bool
+	}{
+		{"ipp://printhost:6310/printers/default", "http://printhost:6310/admin/", true},
+		{"ipps://printhost/printers/default", "https://printhost:631/admin/", true},
+		{"ipp://[fe80::1]:631/printers/default", "http://[fe80::1]:631/admin/", true},
+		{"/printers/default", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := webURL(tt.uri, adminPath)
+		assert.Equal(t, tt.wantOK, ok, tt.uri)
+		assert.Equal(t, tt.want, got, tt.uri)
+	}
+}
+
+func TestPrinterAttributes_Icons(t *testing.T) {
+	s := newTestIPPServer(t)
+	resp, err := s.handleGetPrinterAttributes(context.Background(), newIPPRequest(goipp.OpGetPrinterAttributes, 14), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"http://localhost:631/icons/printer-48.png",
+		"http://localhost:631/icons/printer-128.png",
+		"http://localhost:631/icons/printer-512.png",
+	}, attrStrings(t, resp.Printer, "printer-icons"))
+	assert.Equal(t, []string{"http://localhost:631/admin/"}, attrStrings(t, resp.Printer, "printer-more-info"))
+}
@@ -7,21 +7,31 @@
 package ippsrv
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/url"
+	"slices"
 	"strings"
-	"time"
+	"sync/atomic"
 
 	"github.com/OpenPrinting/goipp"
+
+	"github.com/rusq/thermoprint/bitmap"
+	"github.com/rusq/thermoprint/locale"
 )
 
 type basicIPPServer struct {
 	baseURL string
 	Printer map[string]Printer
 	spool   spooler // Spooler for managing print jobs
+	dedup   *dedup  // recent jobs, nil if the deduplication is disabled
+	tls     bool    // IPPS is enabled, see WithTLS
+	auth    bool    // IPP requires the Basic authentication, see WithBasicAuth
+
+	draining atomic.Bool // the new jobs are refused, see Server.Drain
 }
 
 type IPPHandler interface {
@@ -65,14 +75,13 @@ func ippStatusFromError(err error) goipp.Status {
 	return goipp.StatusErrorInternal
 }
 
-func newBasicIPPServer(baseURL string, pp ...Printer) (*basicIPPServer, error) {
+// newBasicIPPServer returns the IPP server of the printers.  The spool in
+// the spoolDir is persistent, see [WithSpoolDir], if spoolDir is empty, the
+// temporary spool is used.
+func newBasicIPPServer(baseURL, spoolDir string, pp ...Printer) (*basicIPPServer, error) {
 	if len(pp) == 0 {
 		return nil, fmt.Errorf("at least one printer must be provided")
 	}
-	spool, err := newSpool("spool")
-	if err != nil {
-		return nil, err
-	}
 	var printers = make(map[string]Printer, len(pp))
 	for _, p := range pp {
 		if p == nil {
@@ -87,6 +96,18 @@ func newBasicIPPServer(baseURL string, pp ...Printer) (*basicIPPServer, error) {
 		p.SetState(PSIdle) // Set initial state to idle
 		printers[p.Name()] = p
 	}
+	var (
+		spool *spool
+		err   error
+	)
+	if spoolDir != "" {
+		spool, err = openSpool(spoolDir, printers)
+	} else {
+		spool, err = newSpool("")
+	}
+	if err != nil {
+		return nil, err
+	}
 
 	return &basicIPPServer{
 		baseURL: baseURL,
@@ -110,13 +131,20 @@ func (ih *basicIPPServer) ServeIPP(ctx context.Context, req *goipp.Message, body
 	lg := slog.With("code", req.Code, "request_id", req.RequestID)
 	lg.Info("ipp request received")
 	var handlers = map[goipp.Op]IPPHandlerFunc{
-		goipp.OpPrintJob:             ih.handlePrintJob,
-		goipp.OpValidateJob:          ih.handleWithBaseResponse,
-		goipp.OpGetJobAttributes:     ih.handleGetJobAttributes,
-		goipp.OpGetJobs:              ih.handleGetJobs,
-		goipp.OpGetPrinterAttributes: ih.handleGetPrinterAttributes,
-		goipp.OpCupsGetPrinters:      ih.handleGetPrinterAttributes,
-		goipp.OpCupsGetDefault:       ih.handleGetPrinterAttributes,
+		goipp.OpPrintJob:                  ih.handlePrintJob,
+		goipp.OpCreateJob:                 ih.handleCreateJob,
+		goipp.OpSendDocument:              ih.handleSendDocument,
+		goipp.OpCancelJob:                 ih.handleCancelJob,
+		goipp.OpHoldJob:                   ih.handleHoldJob,
+		goipp.OpReleaseJob:                ih.handleReleaseJob,
+		goipp.OpRestartJob:                ih.handleRestartJob,
+		goipp.OpValidateJob:               ih.handleWithBaseResponse,
+		goipp.OpGetJobAttributes:          ih.handleGetJobAttributes,
+		goipp.OpGetJobs:                   ih.handleGetJobs,
+		goipp.OpGetPrinterAttributes:      ih.handleGetPrinterAttributes,
+		goipp.OpGetPrinterSupportedValues: ih.handleGetPrinterSupportedValues,
+		goipp.OpCupsGetPrinters:           ih.handleGetPrinterAttributes,
+		goipp.OpCupsGetDefault:            ih.handleGetPrinterAttributes,
 	}
 	next, ok := handlers[goipp.Op(req.Code)]
 	if !ok || next == nil {
@@ -139,35 +167,54 @@ func (ih *basicIPPServer) printerAttributes(p Printer, requestID uint32, printer
 	}
 	dpi := int(p.Driver().DPI())
 	m := baseResponse(goipp.StatusOk, requestID)
-	a := adder(&m.Operation)
-	a("printer-uri-supported", goipp.TagURI, goipp.String(printerURI))
-	a("uri-authentication-supported", goipp.TagKeyword, ippNone)
-	a("uri-security-supported", goipp.TagKeyword, ippNone)
+	a := adder(&m.Printer)
+	uris, security := printerURIs(printerURI, ih.tls)
+	a("printer-uri-supported", goipp.TagURI, uris...)
+	authentication := goipp.Value(ippNone)
+	if ih.auth {
+		authentication = ippBasic
+	}
+	a("uri-authentication-supported", goipp.TagKeyword, slices.Repeat([]goipp.Value{authentication}, len(uris))...)
+	a("uri-security-supported", goipp.TagKeyword, security...)
 	a("printer-name", goipp.TagName, goipp.String(p.Name()))
 	a("printer-info", goipp.TagText, goipp.String(p.Info()))
 	a("printer-make-and-model", goipp.TagText, goipp.String(p.MakeAndModel()))
 	a("printer-state", goipp.TagEnum, goipp.Integer(p.State()))
-	a("printer-state-reasons", goipp.TagKeyword, ippNone)
+	reasons := stateReasons(p)
+	a("printer-state-reasons", goipp.TagKeyword, stringsToValues(reasons)...)
+	alerts := printerAlerts(p)
+	if len(alerts) > 0 {
+		a("printer-alert", goipp.TagString, alertValues(alerts)...)
+		a("printer-alert-description", goipp.TagText, alertDescriptions(alerts)...)
+	}
+	a("printer-state-message", goipp.TagText, goipp.String(stateMessage(alerts, reasons)))
 	a("ipp-versions-supported", goipp.TagKeyword, goipp.String("1.1"), goipp.String("2.0"))
 	a("operations-supported", goipp.TagEnum,
 		goipp.Integer(goipp.OpPrintJob),
+		goipp.Integer(goipp.OpCreateJob),
+		goipp.Integer(goipp.OpSendDocument),
 		goipp.Integer(goipp.OpValidateJob),
 		goipp.Integer(goipp.OpCancelJob),
+		goipp.Integer(goipp.OpHoldJob),
+		goipp.Integer(goipp.OpReleaseJob),
+		goipp.Integer(goipp.OpRestartJob),
 		goipp.Integer(goipp.OpGetJobs),
 		goipp.Integer(goipp.OpGetJobAttributes),
 		goipp.Integer(goipp.OpGetPrinterAttributes),
+		goipp.Integer(goipp.OpGetPrinterSupportedValues),
 	)
-	a("multiple-document-jobs-supported", goipp.TagBoolean, goipp.Boolean(false))
+	a("multiple-document-jobs-supported", goipp.TagBoolean, goipp.Boolean(true))
 	a("charset-configured", goipp.TagCharset, ippUTF8)
 	a("charset-supported", goipp.TagCharset, ippUTF8)
-	a("natural-language-configured", goipp.TagLanguage, ippENUS)
-	a("generated-natural-language-supported", goipp.TagLanguage, ippENUS)
+	a("natural-language-configured", goipp.TagLanguage, ippLanguage(locale.Default().Lang()))
+	a("generated-natural-language-supported", goipp.TagLanguage, languageValues()...)
 	// Only raster formats are advertised: listing application/pdf would make
 	// CUPS driverless clients pass PDFs through instead of rasterising them
 	// client-side.  PDF is still accepted — the print filter sniffs the data
 	// format and falls back to ImageMagick for anything that is not raster.
+	// Plain text is printed with the built-in font.
 	a("document-format-default", goipp.TagMimeType, ippImagePWGRaster)
-	a("document-format-supported", goipp.TagMimeType, ippImagePWGRaster, ippImageURF)
+	a("document-format-supported", goipp.TagMimeType, ippImagePWGRaster, ippImageURF, ippTextPlain)
 	// PWG 5102.4 raster attributes; type keywords are bits-per-COLOR
 	// (24-bit RGB would be srgb_8), mono/grayscale only for this printer.
 	a("pwg-raster-document-resolution-supported", goipp.TagResolution,
@@ -188,18 +235,27 @@ func (ih *basicIPPServer) printerAttributes(p Printer, requestID uint32, printer
 	a("sides-default", goipp.TagKeyword, goipp.String("one-sided"))
 	a("copies-supported", goipp.TagRange, goipp.Range{Lower: 1, Upper: 1})
 	a("copies-default", goipp.TagInteger, goipp.Integer(1))
+	a("page-ranges-supported", goipp.TagBoolean, goipp.Boolean(true))
+	a("print-scaling-supported", goipp.TagKeyword, stringsToValues(printScalingSupported)...)
+	a("print-scaling-default", goipp.TagKeyword, goipp.String("auto"))
 	// print-quality drives the resolution entries in Apple's ipp2ppd
 	// AirPrint PPD generator: without it no *DefaultResolution is emitted
 	// and cgpdftoraster rasterises at 100dpi, printing at half size.
-	a("print-quality-supported", goipp.TagEnum, goipp.Integer(4)) // normal
-	a("print-quality-default", goipp.TagEnum, goipp.Integer(4))
-	a("printer-is-accepting-jobs", goipp.TagBoolean, goipp.Boolean(p.Ready()))
-	a("queued-job-count", goipp.TagInteger, goipp.Integer(ih.spool.GetJobCount(p.Name()))) // TODO: interrogate spooler for queued jobs for this printer
+	a("print-quality-supported", goipp.TagEnum, intsToValues(qualitiesSupported)...)
+	a("print-quality-default", goipp.TagEnum, goipp.Integer(QualityNormal))
+	a(ditherAttr+"-supported", goipp.TagKeyword, ditherValues()...)
+	a(ditherAttr+"-default", goipp.TagKeyword, goipp.String(cmp.Or(printerDither(p), bitmap.DefaultDither)))
+	a("orientation-requested-supported", goipp.TagEnum, intsToValues(orientationsSupported)...)
+	a("orientation-requested-default", goipp.TagEnum, goipp.Integer(OrientationPortrait))
+	a("printer-is-accepting-jobs", goipp.TagBoolean, goipp.Boolean(ih.acceptingJobs(p)))
+	a("queued-job-count", goipp.TagInteger, goipp.Integer(ih.spool.GetJobCount(p.Name())))
 	a("pdl-override-supported", goipp.TagKeyword, goipp.String("not-attempted"))
 	a("printer-up-time", goipp.TagInteger, goipp.Integer(p.UpTime()))
 	a("compression-supported", goipp.TagKeyword, ippNone)
 	a("media-supported", goipp.TagKeyword, stringsToValues(p.MediaSupported())...)
 	a("media-default", goipp.TagKeyword, goipp.String(p.MediaDefault()))
+	// the roll is always loaded, so the default media is the ready one.
+	a("media-ready", goipp.TagKeyword, goipp.String(p.MediaDefault()))
 	if sizes, cols := mediaCollections(p.MediaSupported()); len(sizes) > 0 {
 		a("media-size-supported", goipp.TagBeginCollection, sizes...)
 		a("media-col-database", goipp.TagBeginCollection, cols...)
@@ -213,8 +269,24 @@ func (ih *basicIPPServer) printerAttributes(p Printer, requestID uint32, printer
 	)
 	if x, y, err := mediaSizeDimensions(p.MediaDefault()); err == nil {
 		a("media-col-default", goipp.TagBeginCollection, mediaCol(x, y))
+		a("media-col-ready", goipp.TagBeginCollection, mediaCol(x, y))
+	}
+	// borderless only, see mediaCol.
+	for _, margin := range []string{"media-top-margin", "media-bottom-margin", "media-left-margin", "media-right-margin"} {
+		a(margin+"-supported", goipp.TagInteger, goipp.Integer(0))
 	}
 	a("printer-uuid", goipp.TagURI, goipp.String("urn:uuid:"+p.UUID()))
+	// the icons and the status page are served by the same server, on the
+	// host the client reached it on.
+	if adminURL, ok := webURL(printerURI, adminPath); ok {
+		a("printer-more-info", goipp.TagURI, goipp.String(adminURL))
+		var iconURLs []goipp.Value
+		for _, size := range iconSizes {
+			u, _ := webURL(printerURI, iconPath+iconName(size))
+			iconURLs = append(iconURLs, goipp.String(u))
+		}
+		a("printer-icons", goipp.TagURI, iconURLs...)
+	}
 
 	return m
 }
@@ -224,18 +296,38 @@ func (ih *basicIPPServer) handleGetPrinterAttributes(ctx context.Context, req *g
 	if err != nil {
 		return nil, fmt.Errorf("failed to get printer: %w", err)
 	}
-	lg := slog.With("printer", p.Name(), "code", req.Code, "request_id", req.RequestID)
-	attrs, ok := findAttr(req.Operation, "requested-attributes")
-	lg.Debug("requested attributes", "ok", ok, "attrs", attrs)
+	requested := requestedAttributes(req.Operation)
+	slog.Debug("requested attributes", "printer", p.Name(), "request_id", req.RequestID, "attrs", requested)
 
 	// echo the printer-uri the client used, if any, as
 	// printer-uri-supported.
 	uri, _ := extractValue[goipp.String](req.Operation, "printer-uri")
 
 	resp = ih.printerAttributes(p, req.RequestID, uri.String())
+	resp.Printer = filterAttributes(resp.Printer, requested, groupPrinterDescription)
 	return
 }
 
+// handleGetPrinterSupportedValues handles the Get-Printer-Supported-Values
+// operation (PWG 5100.18), it returns the "-supported" printer attributes,
+// the client asks for it to find out the values it may set, i.e. the custom
+// media size range.
+func (ih *basicIPPServer) handleGetPrinterSupportedValues(ctx context.Context, req *goipp.Message, _ []byte) (resp *goipp.Message, err error) {
+	p, err := ih.printerFromRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get printer: %w", err)
+	}
+	resp = ih.printerAttributes(p, req.RequestID, "")
+	var supported goipp.Attributes
+	for _, attr := range resp.Printer {
+		if strings.HasSuffix(attr.Name, "-supported") {
+			supported = append(supported, attr)
+		}
+	}
+	resp.Printer = filterAttributes(supported, requestedAttributes(req.Operation), groupPrinterDescription)
+	return resp, nil
+}
+
 func (ih *basicIPPServer) printerFromRequest(req *goipp.Message) (Printer, error) {
 	strName, err := extractValue[goipp.String](req.Operation, "printer-uri")
 	if err != nil {
@@ -285,28 +377,197 @@ func (ih *basicIPPServer) handleGetJobAttributes(ctx context.Context, req *goipp
 	}
 
 	resp = baseResponse(goipp.StatusOk, req.RequestID)
-	resp.Job = job.attributes()
+	resp.Job = filterAttributes(job.attributes(), requestedAttributes(req.Operation), groupJobDescription)
 	return resp, nil
 }
 
+// newJob creates the job of the request with the next job id of the spool.
+func (ih *basicIPPServer) newJob(p Printer, req *goipp.Message) (*Job, error) {
+	id, err := ih.spool.NextJobID()
+	if err != nil {
+		return nil, err
+	}
+	return createJobFromRequest(p, ih.baseURL, id, req)
+}
+
 // ref: https://datatracker.ietf.org/doc/html/rfc8011#section-4.2.1.1
 func (ih *basicIPPServer) handlePrintJob(ctx context.Context, req *goipp.Message, body []byte) (resp *goipp.Message, err error) {
 	p, err := ih.printerFromRequest(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get printer: %w", err)
 	}
-	j, err := createJobFromRequest(p, ih.baseURL, JobID(time.Now().Unix()), req)
+	if ih.draining.Load() {
+		return nil, ippError(goipp.StatusErrorNotAcceptingJobs, "printer %s: %w", p.Name(), errDraining)
+	}
+	if id, ok := ih.dedup.duplicate(p.Name(), body); ok {
+		lg := slog.With("printer", p.Name(), "job_id", id)
+		if ih.dedup.mode == DedupSkip {
+			if orig, err := ih.spool.GetJob(id); err == nil {
+				lg.WarnContext(ctx, "skipping the duplicate of the recent job")
+				resp = baseResponse(goipp.StatusOk, req.RequestID)
+				resp.Job = orig.attributes()
+				return resp, nil
+			}
+		} else {
+			lg.WarnContext(ctx, "job is the duplicate of the recent job, printing it anyway")
+		}
+	}
+	j, err := ih.newJob(p, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create job: %w", err)
 	}
-	if err := ih.spool.AddJob(ctx, j, body); err != nil {
+	if user := authUser(ctx); user != "" {
+		j.Username = user
+	}
+	j.printOptions.origin = JobOrigin{User: j.Username, Host: originHost(ctx, req), JobID: j.ID, Time: j.Created}
+	if err := ih.spool.AddJob(j, body); err != nil {
+		if errors.Is(err, errQueueFull) {
+			return nil, ippError(goipp.StatusErrorBusy, "printer %s: %w", p.Name(), err)
+		}
 		return nil, fmt.Errorf("failed to add job to spool: %w", err)
 	}
+	ih.dedup.record(p.Name(), body, j.ID)
 	resp = baseResponse(goipp.StatusOk, req.RequestID)
 	resp.Job = j.attributes()
 	return resp, nil
 }
 
+// handleCreateJob creates the job without the documents, the client sends
+// them with Send-Document.  The duplicate jobs are not detected for such
+// jobs.
+// ref: https://datatracker.ietf.org/doc/html/rfc8011#section-4.2.4
+func (ih *basicIPPServer) handleCreateJob(ctx context.Context, req *goipp.Message, _ []byte) (*goipp.Message, error) {
+	p, err := ih.printerFromRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get printer: %w", err)
+	}
+	if ih.draining.Load() {
+		return nil, ippError(goipp.StatusErrorNotAcceptingJobs, "printer %s: %w", p.Name(), errDraining)
+	}
+	j, err := ih.newJob(p, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+	if user := authUser(ctx); user != "" {
+		j.Username = user
+	}
+	j.printOptions.origin = JobOrigin{User: j.Username, Host: originHost(ctx, req), JobID: j.ID, Time: j.Created}
+	if err := ih.spool.CreateJob(j); err != nil {
+		if errors.Is(err, errQueueFull) {
+			return nil, ippError(goipp.StatusErrorBusy, "printer %s: %w", p.Name(), err)
+		}
+		return nil, fmt.Errorf("failed to add job to spool: %w", err)
+	}
+	resp := baseResponse(goipp.StatusOk, req.RequestID)
+	resp.Job = j.attributes()
+	return resp, nil
+}
+
+// handleSendDocument adds the document to the job created with Create-Job,
+// the job is printed, once the client sends the last document.
+// ref: https://datatracker.ietf.org/doc/html/rfc8011#section-4.3.1
+func (ih *basicIPPServer) handleSendDocument(ctx context.Context, req *goipp.Message, body []byte) (*goipp.Message, error) {
+	v, err := extractValue[goipp.Integer](req.Operation, "job-id")
+	if err != nil {
+		return nil, ippError(goipp.StatusErrorBadRequest, "failed to extract job-id: %w", err)
+	}
+	last, err := extractValue[goipp.Boolean](req.Operation, "last-document")
+	if err != nil {
+		return nil, ippError(goipp.StatusErrorBadRequest, "failed to extract last-document: %w", err)
+	}
+	format, _ := extractValue[goipp.String](req.Operation, "document-format")
+	doc := document{data: body, format: format.String()}
+	if err := ih.spool.AddDocument(JobID(v), doc, bool(last)); err != nil {
+		if errors.Is(err, errJobNotIncoming) {
+			return nil, ippError(goipp.StatusErrorNotPossible, "job %d: %w", v, err)
+		}
+		return nil, fmt.Errorf("failed to add document to job %d: %w", v, err)
+	}
+	job, err := ih.spool.GetJob(JobID(v))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job with ID %d: %w", v, err)
+	}
+	resp := baseResponse(goipp.StatusOk, req.RequestID)
+	resp.Job = job.attributes()
+	return resp, nil
+}
+
+// ref: https://datatracker.ietf.org/doc/html/rfc8011#section-4.3.3
+func (ih *basicIPPServer) handleCancelJob(ctx context.Context, req *goipp.Message, _ []byte) (*goipp.Message, error) {
+	job, err := ih.jobFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := job.cancel(ctx, JSRJobCancelledByUser); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "job cancelled", "job_id", job.ID)
+	return baseResponse(goipp.StatusOk, req.RequestID), nil
+}
+
+// handleHoldJob holds the pending job, until it is released with
+// Release-Job.
+// ref: https://datatracker.ietf.org/doc/html/rfc8011#section-4.3.5
+func (ih *basicIPPServer) handleHoldJob(ctx context.Context, req *goipp.Message, _ []byte) (*goipp.Message, error) {
+	job, err := ih.jobFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := job.hold(ctx); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "job held", "job_id", job.ID)
+	return baseResponse(goipp.StatusOk, req.RequestID), nil
+}
+
+// handleReleaseJob releases the held job for printing.
+// ref: https://datatracker.ietf.org/doc/html/rfc8011#section-4.3.6
+func (ih *basicIPPServer) handleReleaseJob(ctx context.Context, req *goipp.Message, _ []byte) (*goipp.Message, error) {
+	job, err := ih.jobFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := job.release(ctx); err != nil {
+		return nil, err
+	}
+	if err := ih.spool.Dispatch(job.ID); err != nil {
+		return nil, fmt.Errorf("failed to dispatch job %d: %w", job.ID, err)
+	}
+	slog.InfoContext(ctx, "job released", "job_id", job.ID)
+	return baseResponse(goipp.StatusOk, req.RequestID), nil
+}
+
+// handleRestartJob prints the finished job again, i.e. the one that failed,
+// while its documents are in the spool.
+// ref: https://datatracker.ietf.org/doc/html/rfc8011#section-4.3.7
+func (ih *basicIPPServer) handleRestartJob(ctx context.Context, req *goipp.Message, _ []byte) (*goipp.Message, error) {
+	job, err := ih.jobFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := job.restart(ctx); err != nil {
+		return nil, err
+	}
+	if err := ih.spool.Dispatch(job.ID); err != nil {
+		return nil, fmt.Errorf("failed to dispatch job %d: %w", job.ID, err)
+	}
+	slog.InfoContext(ctx, "job restarted", "job_id", job.ID)
+	return baseResponse(goipp.StatusOk, req.RequestID), nil
+}
+
+// jobFromRequest returns the job of the job-id operation attribute.
+func (ih *basicIPPServer) jobFromRequest(req *goipp.Message) (*Job, error) {
+	v, err := extractValue[goipp.Integer](req.Operation, "job-id")
+	if err != nil {
+		return nil, ippError(goipp.StatusErrorBadRequest, "failed to extract job-id: %w", err)
+	}
+	job, err := ih.spool.GetJob(JobID(v))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job with ID %d: %w", v, err)
+	}
+	return job, nil
+}
+
 func asString(vv goipp.Values, ok bool) (string, bool) {
 	if !ok {
 		return "", false
@@ -345,9 +606,8 @@ func (ih *basicIPPServer) handleGetJobs(ctx context.Context, req *goipp.Message,
 		lg = lg.With("username", username)
 	}
 
-	// Get the requested attributes
-	attrs, ok := findAttr(req.Operation, "requested-attributes")
-	lg.Debug("requested attributes", "ok", ok, "attrs", attrs)
+	requested := requestedAttributes(req.Operation)
+	lg.Debug("requested attributes", "attrs", requested)
 
 	jobs, err := ih.spool.GetJobs(p.Name())
 	if err != nil {
@@ -368,7 +628,7 @@ func (ih *basicIPPServer) handleGetJobs(ctx context.Context, req *goipp.Message,
 		}
 		resp.Groups.Add(goipp.Group{
 			Tag:   goipp.TagJobGroup,
-			Attrs: job.attributes(),
+			Attrs: filterAttributes(job.attributes(), requested, groupJobDescription),
 		})
 	}
 
@@ -3,9 +3,11 @@ package ippsrv
 import (
 	"bytes"
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/OpenPrinting/goipp"
+	"github.com/rusq/thermoprint"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -31,36 +33,37 @@ func TestPrinterAttributes_RasterAdvertisement(t *testing.T) {
 	resp, err := s.handleGetPrinterAttributes(context.Background(), req, nil)
 	require.NoError(t, err)
 
-	formats := attrStrings(t, resp.Operation, "document-format-supported")
-	assert.ElementsMatch(t, []string{"image/pwg-raster", "image/urf"}, formats,
-		"only raster formats may be advertised; PDF would make clients skip client-side rasterisation")
-	assert.Equal(t, []string{"image/pwg-raster"}, attrStrings(t, resp.Operation, "document-format-default"))
+	formats := attrStrings(t, resp.Printer, "document-format-supported")
+	assert.ElementsMatch(t, []string{"image/pwg-raster", "image/urf", "text/plain"}, formats,
+		"only raster formats and plain text may be advertised; PDF would make clients skip client-side rasterisation")
+	assert.Equal(t, []string{"image/pwg-raster"}, attrStrings(t, resp.Printer, "document-format-default"))
 
-	assert.Equal(t, []string{"black_1", "sgray_8"}, attrStrings(t, resp.Operation, "pwg-raster-document-type-supported"))
-	assert.Equal(t, []string{"normal"}, attrStrings(t, resp.Operation, "pwg-raster-document-sheet-back"))
-	assert.Equal(t, urfSupported(203), attrStrings(t, resp.Operation, "urf-supported"),
+	assert.Equal(t, []string{"black_1", "sgray_8"}, attrStrings(t, resp.Printer, "pwg-raster-document-type-supported"))
+	assert.Equal(t, []string{"en-us", "de", "es", "fr"}, attrStrings(t, resp.Printer, "generated-natural-language-supported"))
+	assert.Equal(t, []string{"normal"}, attrStrings(t, resp.Printer, "pwg-raster-document-sheet-back"))
+	assert.Equal(t, urfSupported(203), attrStrings(t, resp.Printer, "urf-supported"),
 		"urf-supported must match the URF TXT record key")
 
-	res, ok := findAttr(resp.Operation, "pwg-raster-document-resolution-supported")
+	res, ok := findAttr(resp.Printer, "pwg-raster-document-resolution-supported")
 	require.True(t, ok, "pwg-raster-document-resolution-supported missing")
 	require.IsType(t, goipp.Resolution{}, res[0].V)
 	assert.Equal(t, goipp.Resolution{Xres: 203, Yres: 203, Units: goipp.UnitsDpi}, res[0].V)
 
-	media := attrStrings(t, resp.Operation, "media-supported")
+	media := attrStrings(t, resp.Printer, "media-supported")
 	assert.Contains(t, media, "om_label-48x100mm_48x100mm")
 	assert.Contains(t, media, rollCustomMinMedia)
 	assert.Contains(t, media, rollCustomMaxMedia)
-	assert.Equal(t, []string{"om_label-48x100mm_48x100mm"}, attrStrings(t, resp.Operation, "media-default"))
+	assert.Equal(t, []string{"om_label-48x100mm_48x100mm"}, attrStrings(t, resp.Printer, "media-default"))
 
-	cols, ok := findAttr(resp.Operation, "media-col-database")
+	cols, ok := findAttr(resp.Printer, "media-col-database")
 	require.True(t, ok, "media-col-database missing")
 	assert.Len(t, cols, 5, "one media-col per fixed label size plus custom roll range")
-	_, ok = findAttr(resp.Operation, "media-size-supported")
+	_, ok = findAttr(resp.Printer, "media-size-supported")
 	assert.True(t, ok, "media-size-supported missing")
 	assert.ElementsMatch(t,
 		[]string{"media-size", "media-top-margin", "media-bottom-margin", "media-left-margin", "media-right-margin"},
-		attrStrings(t, resp.Operation, "media-col-supported"))
-	_, ok = findAttr(resp.Operation, "media-col-default")
+		attrStrings(t, resp.Printer, "media-col-supported"))
+	_, ok = findAttr(resp.Printer, "media-col-default")
 	assert.True(t, ok, "media-col-default missing")
 }
 
@@ -83,3 +86,219 @@ func TestPrinterAttributes_Encodes(t *testing.T) {
 	var m goipp.Message
 	require.NoError(t, m.Decode(bytes.NewReader(buf.Bytes())))
 }
+
+// snapshotDriver is a test driver that reports the device status.
+type snapshotDriver struct {
+	testDriver
+	snap thermoprint.PrinterSnapshot
+}
+
+func (d snapshotDriver) Snapshot() thermoprint.PrinterSnapshot { return d.snap }
+
+func TestPrinterAttributes_StateReasons(t *testing.T) {
+	tests := []struct {
+		name string
+		drv  Driver
+		want []string
+	}{
+		{"driver without status", testDriver{}, []string{"none"}},
+		{"paper ok", snapshotDriver{snap: thermoprint.PrinterSnapshot{RollTracked: true}}, []string{"none"}},
+		{"paper low", snapshotDriver{snap: thermoprint.PrinterSnapshot{RollTracked: true, MediaLow: true}}, []string{"media-low-warning"}},
+		{"no paper", snapshotDriver{snap: thermoprint.PrinterSnapshot{NoPaper: true, MediaLow: true}}, []string{"media-empty-error"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := WrapDriver(tt.drv, "test-printer", "Test Printer")
+			require.NoError(t, err)
+			s, err := newBasicIPPServer("/printers/", "", p)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+
+			resp, err := s.handleGetPrinterAttributes(context.Background(), newIPPRequest(goipp.OpGetPrinterAttributes, 9), nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, attrStrings(t, resp.Printer, "printer-state-reasons"))
+		})
+	}
+}
+
+// alertDriver is a test driver that keeps the alert history.
+type alertDriver struct {
+	testDriver
+	alerts []thermoprint.Alert
+	snap   thermoprint.PrinterSnapshot
+}
+
+func (d alertDriver) Alerts() []thermoprint.Alert { return d.alerts }
+
+func (d alertDriver) Snapshot() thermoprint.PrinterSnapshot { return d.snap }
+
+func TestPrinterAttributes_Alerts(t *testing.T) {
+	drv := alertDriver{alerts: []thermoprint.Alert{
+		{Time: startTime, Code: thermoprint.AlertBatteryLow, Severity: thermoprint.SeverityWarning, Message: "Battery level is low: 15%"},
+		{Time: startTime, Code: thermoprint.AlertNoPaper, Severity: thermoprint.SeverityCritical, Message: "Printer is out of paper"},
+	}, snap: thermoprint.PrinterSnapshot{NoPaper: true}}
+	p, err := WrapDriver(drv, "test-printer", "Test Printer")
+	require.NoError(t, err)
+	s, err := newBasicIPPServer("/printers/", "", p)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+
+	resp, err := s.handleGetPrinterAttributes(context.Background(), newIPPRequest(goipp.OpGetPrinterAttributes, 10), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"index=1;severity=warning;group=generalPrinter;code=other;time=0",
+		"index=2;severity=critical;group=mediaInput;code=mediaEmpty;time=0",
+	}, attrStrings(t, resp.Printer, "printer-alert"))
+	assert.Equal(t, []string{"Battery level is low: 15%", "Printer is out of paper"},
+		attrStrings(t, resp.Printer, "printer-alert-description"))
+	assert.Equal(t, []string{"Printer is out of paper"}, attrStrings(t, resp.Printer, "printer-state-message"))
+}
+
+func TestPrinterAttributes_ClearedAlert(t *testing.T) {
+	drv := alertDriver{alerts: []thermoprint.Alert{
+		{Time: startTime, Code: thermoprint.AlertNoPaper, Severity: thermoprint.SeverityCritical, Message: "Printer is out of paper"},
+		{Time: startTime, Code: thermoprint.AlertCooldown, Severity: thermoprint.SeverityWarning, Message: "Printer is cooling down"},
+	}}
+	p, err := WrapDriver(drv, "test-printer", "Test Printer")
+	require.NoError(t, err)
+	s, err := newBasicIPPServer("/printers/", "", p)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+
+	resp, err := s.handleGetPrinterAttributes(context.Background(), newIPPRequest(goipp.OpGetPrinterAttributes, 10), nil)
+	require.NoError(t, err)
+	assert.Len(t, attrStrings(t, resp.Printer, "printer-alert"), 2, "the cleared alerts stay in the history")
+	assert.Equal(t, []string{""}, attrStrings(t, resp.Printer, "printer-state-message"), "the cleared alert is not the state message")
+}
+
+func TestPrinterAttributes_NoAlerts(t *testing.T) {
+	s := newTestIPPServer(t)
+
+	resp, err := s.handleGetPrinterAttributes(context.Background(), newIPPRequest(goipp.OpGetPrinterAttributes, 11), nil)
+	require.NoError(t, err)
+	_, ok := findAttr(resp.Printer, "printer-alert")
+	assert.False(t, ok, "printer-alert must be omitted when there are no alerts")
+	assert.Equal(t, []string{""}, attrStrings(t, resp.Printer, "printer-state-message"))
+}
+
+// subscribeDriver is a test driver that reports the status as it changes.
+type subscribeDriver struct {
+	testDriver
+	fn func(thermoprint.Status)
+}
+
+func (d *subscribeDriver) Subscribe(fn func(thermoprint.Status)) func() {
+	d.fn = fn
+	return func() {}
+}
+
+func TestPrinterAttributes_StateReasonsSubscribed(t *testing.T) {
+	drv := &subscribeDriver{}
+	p, err := WrapDriver(drv, "test-printer", "Test Printer")
+	require.NoError(t, err)
+	require.NotNil(t, drv.fn, "driver is not subscribed")
+	s, err := newBasicIPPServer("/printers/", "", p)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+
+	reasons := func() []string {
+		resp, err := s.handleGetPrinterAttributes(context.Background(), newIPPRequest(goipp.OpGetPrinterAttributes, 9), nil)
+		require.NoError(t, err)
+		return attrStrings(t, resp.Printer, "printer-state-reasons")
+	}
+	assert.Equal(t, []string{"none"}, reasons())
+
+	drv.fn(thermoprint.Status{BatteryLevel: 10, NoPaper: true})
+	assert.Equal(t, []string{"media-empty-error", "battery-low-warning"}, reasons())
+
+	drv.fn(thermoprint.Status{BatteryLevel: 90})
+	assert.Equal(t, []string{"none"}, reasons())
+}
+
+func TestPrinterAttributes_Requested(t *testing.T) {
+	s := newTestIPPServer(t)
+	names := func(t *testing.T, requested ...string) []string {
+		t.Helper()
+		req := newIPPRequest(goipp.OpGetPrinterAttributes, 9)
+		if len(requested) > 0 {
+			attr := goipp.MakeAttribute("requested-attributes", goipp.TagKeyword, goipp.String(requested[0]))
+			for _, r := range requested[1:] {
+				attr.Values.Add(goipp.TagKeyword, goipp.String(r))
+			}
+			req.Operation.Add(attr)
+		}
+		resp, err := s.handleGetPrinterAttributes(context.Background(), req, nil)
+		require.NoError(t, err)
+		var out []string
+		for _, attr := range resp.Printer {
+			out = append(out, attr.Name)
+		}
+		return out
+	}
+
+	all := names(t)
+	assert.Equal(t, all, names(t, "all"))
+	assert.Equal(t, all, names(t, "printer-state", "all"))
+	assert.ElementsMatch(t, []string{"printer-state", "printer-name"}, names(t, "printer-state", "printer-name", "no-such-attribute"))
+
+	tmpl := names(t, "job-template")
+	assert.Contains(t, tmpl, "media-supported")
+	assert.Contains(t, tmpl, "media-col-default")
+	assert.Contains(t, tmpl, "copies-default")
+	assert.NotContains(t, tmpl, "printer-state")
+	assert.NotContains(t, tmpl, "document-format-supported")
+
+	descr := names(t, "printer-description")
+	assert.Contains(t, descr, "printer-state")
+	assert.Contains(t, descr, "document-format-supported")
+	assert.NotContains(t, descr, "media-supported")
+	assert.ElementsMatch(t, all, append(descr, tmpl...), "the groups cover all attributes")
+
+	assert.ElementsMatch(t, append([]string{"printer-name"}, tmpl...), names(t, "job-template", "printer-name"))
+}
+
+func TestIsJobTemplate(t *testing.T) {
+	assert.True(t, isJobTemplate("media-col-supported"))
+	assert.True(t, isJobTemplate("printer-resolution-default"))
+	assert.True(t, isJobTemplate("copies"))
+	assert.False(t, isJobTemplate("media-col-database"), "printer description attribute")
+	assert.False(t, isJobTemplate("printer-state"))
+	assert.False(t, isJobTemplate("document-format-supported"))
+}
+
+func TestPrinterAttributes_MediaReady(t *testing.T) {
+	s := newTestIPPServer(t)
+	resp, err := s.handleGetPrinterAttributes(context.Background(), newIPPRequest(goipp.OpGetPrinterAttributes, 11), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"om_label-48x100mm_48x100mm"}, attrStrings(t, resp.Printer, "media-ready"))
+	ready, ok := findAttr(resp.Printer, "media-col-ready")
+	require.True(t, ok, "media-col-ready missing")
+	x, y, ok := mediaColDimensions(ready[0].V.(goipp.Collection))
+	require.True(t, ok)
+	assert.Equal(t, []int{4800, 10000}, []int{x, y})
+	for _, name := range []string{"media-top-margin-supported", "media-bottom-margin-supported", "media-left-margin-supported", "media-right-margin-supported"} {
+		assert.Equal(t, []string{"0"}, attrStrings(t, resp.Printer, name), name)
+	}
+}
+
+func TestGetPrinterSupportedValues(t *testing.T) {
+	s := newTestIPPServer(t)
+	resp, err := s.handleGetPrinterSupportedValues(context.Background(), newIPPRequest(goipp.OpGetPrinterSupportedValues, 12), nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Printer)
+	for _, attr := range resp.Printer {
+		assert.True(t, strings.HasSuffix(attr.Name, "-supported"), attr.Name)
+	}
+
+	req := newIPPRequest(goipp.OpGetPrinterSupportedValues, 13)
+	req.Operation.Add(goipp.MakeAttribute("requested-attributes", goipp.TagKeyword, goipp.String("media-size-supported")))
+	resp, err = s.handleGetPrinterSupportedValues(context.Background(), req, nil)
+	require.NoError(t, err)
+	require.Len(t, resp.Printer, 1)
+	sizes := resp.Printer[0].Values
+	roll, ok := sizes[len(sizes)-1].V.(goipp.Collection)
+	require.True(t, ok)
+	assert.Equal(t, goipp.Range{Lower: rollCustomMinHeight, Upper: rollCustomMaxHeight}, mustCollectionValue(t, roll, "y-dimension"),
+		"the variable length roll is advertised as the y-dimension range")
+}
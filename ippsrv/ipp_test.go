@@ -29,7 +29,7 @@ func newTestIPPServer(t *testing.T) *basicIPPServer {
 	if err != nil {
 		t.Fatalf("WrapDriver: %v", err)
 	}
-	s, err := newBasicIPPServer("/printers/", p)
+	s, err := newBasicIPPServer("/printers/", "", p)
 	if err != nil {
 		t.Fatalf("newBasicIPPServer: %v", err)
 	}
@@ -42,6 +42,22 @@ func newTestIPPServer(t *testing.T) *basicIPPServer {
 	return s
 }
 
+// waitJobs waits for the jobs of the server, that are printed in the
+// background, to finish.
+func waitJobs(t *testing.T, s *basicIPPServer) {
+	t.Helper()
+
+	jobs, err := s.spool.ListJobs()
+	if errors.Is(err, errJobNotFound) {
+		return
+	} else if err != nil {
+		t.Fatalf("ListJobs: %v", err)
+	}
+	for _, job := range jobs {
+		waitJob(t, job)
+	}
+}
+
 func newIPPRequest(op goipp.Op, requestID uint32) *goipp.Message {
 	req := goipp.NewRequest(goipp.DefaultVersion, op, requestID)
 	a := adder(&req.Operation)
@@ -423,6 +439,26 @@ func TestJobAttributesTimeSyntax(t *testing.T) {
 	}
 }
 
+func TestJobAttributesMediaSheets(t *testing.T) {
+	s := newTestIPPServer(t)
+	job := addTestJob(t, s, 42, "test-job", "tester")
+
+	if vv, ok := findAttr(job.attributes(), "job-media-sheets-completed"); !ok || vv[0].V != goipp.Integer(0) {
+		t.Fatalf("job-media-sheets-completed = %v, want 0", vv)
+	}
+	if _, ok := findAttr(job.attributes(), "job-media-sheets"); ok {
+		t.Fatal("job-media-sheets is reported before printing")
+	}
+	job.setSheets(1, 2)
+	attrs := job.attributes()
+	if vv, ok := findAttr(attrs, "job-media-sheets-completed"); !ok || vv[0].V != goipp.Integer(1) {
+		t.Errorf("job-media-sheets-completed = %v, want 1", vv)
+	}
+	if vv, ok := findAttr(attrs, "job-media-sheets"); !ok || vv[0].V != goipp.Integer(2) {
+		t.Errorf("job-media-sheets = %v, want 2", vv)
+	}
+}
+
 func TestJobAttributesZeroTimeIsNoValue(t *testing.T) {
 	s := newTestIPPServer(t)
 	p := s.Printer["test-printer"]
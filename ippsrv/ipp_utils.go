@@ -4,8 +4,12 @@ package ippsrv
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/OpenPrinting/goipp"
+
+	"github.com/rusq/thermoprint"
+	"github.com/rusq/thermoprint/locale"
 )
 
 const (
@@ -15,6 +19,9 @@ const (
 	ippApplicationPDF goipp.String = "application/pdf"
 	ippImagePWGRaster goipp.String = "image/pwg-raster"
 	ippImageURF       goipp.String = "image/urf"
+	ippTextPlain      goipp.String = "text/plain"
+
+	ippApplicationOctetStream goipp.String = "application/octet-stream"
 )
 
 // adder is a helper function to add attributes to an operation.
@@ -40,6 +47,32 @@ func stringsToValues[S ~[]E, E ~string](strs S) []goipp.Value {
 	return values
 }
 
+func intsToValues[S ~[]E, E ~int](ints S) []goipp.Value {
+	values := make([]goipp.Value, len(ints))
+	for i, n := range ints {
+		values[i] = goipp.Integer(n)
+	}
+	return values
+}
+
+// ippLanguage returns the natural language tag of the catalog language.
+func ippLanguage(lang string) goipp.String {
+	if lang == locale.English {
+		return ippENUS
+	}
+	return goipp.String(lang)
+}
+
+// languageValues returns the natural languages, that the job may be printed
+// in, see [locale.Languages].
+func languageValues() []goipp.Value {
+	var values []goipp.Value
+	for _, lang := range locale.Languages() {
+		values = append(values, ippLanguage(lang))
+	}
+	return values
+}
+
 func baseResponse(status goipp.Status, requestID uint32) *goipp.Message {
 	m := goipp.NewResponse(goipp.DefaultVersion, status, requestID)
 	a := adder(&m.Operation)
@@ -72,3 +105,64 @@ func extractValue[T any](attrs goipp.Attributes, name string) (T, error) {
 	}
 	return zero, fmt.Errorf("attribute %q is not of type %T: %T", name, zero, v)
 }
+
+// alertValues converts alerts to printer-alert values, see PWG 5100.9,
+// section 7.1.
+func alertValues(alerts []thermoprint.Alert) []goipp.Value {
+	values := make([]goipp.Value, len(alerts))
+	for i, a := range alerts {
+		code, group := pwgAlert(a.Code)
+		values[i] = goipp.String(fmt.Sprintf("index=%d;severity=%s;group=%s;code=%s;time=%d",
+			i+1, a.Severity, group, code, int(a.Time.Sub(startTime).Seconds())))
+	}
+	return values
+}
+
+// alertDescriptions returns printer-alert-description values for alerts.
+func alertDescriptions(alerts []thermoprint.Alert) []goipp.Value {
+	values := make([]goipp.Value, len(alerts))
+	for i, a := range alerts {
+		values[i] = goipp.String(a.Message)
+	}
+	return values
+}
+
+// stateMessage returns the message of the newest alert, that is still
+// active, i.e. its condition is among the current state reasons, or an
+// empty string.  The alerts that have cleared stay in the history only.
+func stateMessage(alerts []thermoprint.Alert, reasons []PrinterStateReason) string {
+	for _, a := range slices.Backward(alerts) {
+		if r, ok := alertReason(a.Code); ok && slices.Contains(reasons, r) {
+			return a.Message
+		}
+	}
+	return ""
+}
+
+// alertReason maps the alert code to the printer state reason of the
+// condition, ok is false, if the condition has no state reason.
+func alertReason(code thermoprint.AlertCode) (reason PrinterStateReason, ok bool) {
+	switch code {
+	case thermoprint.AlertNoPaper:
+		return PSRMediaEmpty, true
+	case thermoprint.AlertMediaLow:
+		return PSRMediaLow, true
+	case thermoprint.AlertBatteryLow, thermoprint.AlertBatteryCritical:
+		return PSRBatteryLow, true
+	}
+	return "", false
+}
+
+// pwgAlert maps the alert code to the PWG 5100.9 alert code and group.
+func pwgAlert(code thermoprint.AlertCode) (pwgCode, group string) {
+	switch code {
+	case thermoprint.AlertNoPaper:
+		return "mediaEmpty", "mediaInput"
+	case thermoprint.AlertMediaLow:
+		return "mediaLow", "mediaInput"
+	case thermoprint.AlertCooldown:
+		return "other", "marker"
+	default:
+		return "other", "generalPrinter"
+	}
+}
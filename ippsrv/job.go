@@ -1,7 +1,9 @@
 package ippsrv
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"path"
@@ -10,6 +12,8 @@ import (
 
 	"github.com/OpenPrinting/goipp"
 	"github.com/looplab/fsm"
+
+	"github.com/rusq/thermoprint"
 )
 
 type Job struct {
@@ -27,10 +31,32 @@ type Job struct {
 	JobURI       string // URL to access the job, e.g., "/printers/default/123"
 	PrinterURI   string // URI of the printer, e.g., "/printers/default"
 	Format       string // document-format of the job data, if provided by the client
+	// Sheets is the number of pages of the job, known once printing starts,
+	// and SheetsCompleted is the number of pages printed so far.
+	Sheets          int
+	SheetsCompleted int
 
 	sm           *fsm.FSM
-	buffer       []byte // Buffer for job data, if needed
+	buffer       []document // Buffer for job data, if needed
 	printOptions printJobOptions
+	stopPrint    context.CancelFunc // cancels the print in progress, if any
+	changed      func()             // called after the state changes, if set, see spool.addJobLocked
+	done         chan struct{}      // closed in the terminal state, see finished
+	printing     bool               // the process callback runs, done waits for it to return
+
+	// documents are the formats of the documents received so far, files
+	// are their file names in the spool, and lastDocument is set, once the
+	// client has sent the last one, see Send-Document.
+	documents    []string
+	files        []string
+	lastDocument bool
+}
+
+// document is the document of the job: the job of Print-Job has one, the
+// job of Create-Job has as many, as the client sent with Send-Document.
+type document struct {
+	data   []byte
+	format string // document-format, if the client provided it
 }
 
 type JobID int32
@@ -60,6 +86,7 @@ const (
 	jobEvtAbort    = "abort"
 	jobEvtComplete = "complete"
 	jobEvtCancel   = "cancel"
+	jobEvtRestart  = "restart"
 )
 
 /*
@@ -72,6 +99,9 @@ https://datatracker.ietf.org/doc/html/rfc8011#page-128
    --->+         |                   |                +----> aborted
        |         v                   v               /
        +----> pending-held    processing-stopped ---+
+
+The finished job, that is still in the spool, is queued again as pending
+with Restart-Job.
 */
 
 var jobFsmEvts = []fsm.EventDesc{
@@ -86,7 +116,7 @@ var jobFsmEvts = []fsm.EventDesc{
 		Dst:  JobPending.String(),
 	},
 	{
-		Name: jobEvtProcess, // event args: []byte{data to print}
+		Name: jobEvtProcess, // event args: []byte{data to print} or []document
 		Src:  []string{JobPending.String()},
 		Dst:  JobProcessing.String(),
 	},
@@ -102,8 +132,13 @@ var jobFsmEvts = []fsm.EventDesc{
 	},
 	{
 		Name: jobEvtCancel, // event args: JobStateReason...
-		Src:  []string{JobProcessing.String()},
-		Dst:  JobCancelled.String(),
+		Src: []string{
+			JobPending.String(),
+			JobPendingHeld.String(),
+			JobProcessing.String(),
+			JobProcessingStopped.String(),
+		},
+		Dst: JobCancelled.String(),
 	},
 	{
 		Name: jobEvtComplete,
@@ -113,11 +148,22 @@ var jobFsmEvts = []fsm.EventDesc{
 	{
 		Name: jobEvtAbort, // event args: JobStateReason...
 		Src: []string{
+			JobPending.String(), // stale jobs, see spool.expireLocked
+			JobPendingHeld.String(),
 			JobProcessing.String(),
 			JobProcessingStopped.String(),
 		},
 		Dst: JobAborted.String(),
 	},
+	{
+		Name: jobEvtRestart,
+		Src: []string{
+			JobCompleted.String(),
+			JobCancelled.String(),
+			JobAborted.String(),
+		},
+		Dst: JobPending.String(),
+	},
 }
 
 // JobStateReason represents the reason for the current job state.
@@ -137,9 +183,9 @@ const (
 	JSRJobQueued                 JobStateReason = "job-queued"
 	JSRJobTransforming           JobStateReason = "job-transforming"
 	JSRJobPrinting               JobStateReason = "job-printing"
-	JSRJobCancelledByUser        JobStateReason = "job-cancelled-by-user"
-	JSRJobCancelledByOperator    JobStateReason = "job-cancelled-by-operator"
-	JSRJobCancelledAtDevice      JobStateReason = "job-cancelled-at-device"
+	JSRJobCancelledByUser        JobStateReason = "job-canceled-by-user"
+	JSRJobCancelledByOperator    JobStateReason = "job-canceled-by-operator"
+	JSRJobCancelledAtDevice      JobStateReason = "job-canceled-at-device"
 	JSRAbortedBySystem           JobStateReason = "aborted-by-system"
 	JSRUnsupportedCompression    JobStateReason = "unsupported-compression"
 	JSRUnsupportedDocumentFormat JobStateReason = "unsupported-document-format"
@@ -171,8 +217,8 @@ func createJobFromRequest(p Printer, baseURL string, id JobID, req *goipp.Messag
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract printer-uri: %w", err)
 	}
-	// document-format is optional; used for logging only, the data format is
-	// sniffed at print time.
+	// document-format is optional; the data format is sniffed at print time,
+	// except for the plain text, that can't be told from the data reliably.
 	format, err := extractValue[goipp.String](req.Operation, "document-format")
 	if err != nil {
 		format = ""
@@ -187,6 +233,35 @@ func createJobFromRequest(p Printer, baseURL string, id JobID, req *goipp.Messag
 		return nil, err
 	}
 	job.printOptions.trimTrailingBlank = requestAllowsTrailingBlankTrim(req, p)
+	job.printOptions.format = format.String()
+	if pages, err := requestPageRanges(req); err != nil {
+		slog.Warn("ignoring page-ranges, printing all pages", "job_id", id, "error", err)
+	} else {
+		job.printOptions.pages = pages
+	}
+	if fit, err := requestFit(req); err != nil {
+		slog.Warn("ignoring print-scaling, using the printer default", "job_id", id, "error", err)
+	} else {
+		job.printOptions.fit = fit
+	}
+	if orientation, err := requestOrientation(req); err != nil {
+		slog.Warn("ignoring orientation-requested, printing as is", "job_id", id, "error", err)
+	} else {
+		job.printOptions.orientation = orientation
+	}
+	if lang, err := extractValue[goipp.String](req.Operation, "attributes-natural-language"); err == nil {
+		job.printOptions.lang = lang.String()
+	}
+	if dither, err := requestDither(req); err != nil {
+		slog.Warn("ignoring "+ditherAttr+", using the printer dither function", "job_id", id, "error", err)
+	} else {
+		job.printOptions.dither = dither
+	}
+	if quality, err := requestQuality(req); err != nil {
+		slog.Warn("ignoring print-quality, using the printer energy", "job_id", id, "error", err)
+	} else {
+		job.printOptions.quality = quality
+	}
 	return job, nil
 }
 
@@ -224,11 +299,19 @@ func makeJobFSM(j *Job) *fsm.FSM {
 			},
 			jobEvtResume: func(ctx context.Context, e *fsm.Event) {
 				lg.InfoContext(ctx, "Job resumed")
-				j.setState(JobPending, nil)
+				j.setState(JobPending, nil, JSRNone)
 			},
 			jobEvtProcess: func(ctx context.Context, e *fsm.Event) {
 				lg.InfoContext(ctx, "Job processing started")
 
+				// The job cancelled while printing reaches the terminal
+				// state before the print returns, done is closed only once
+				// the printer is back to idle.
+				j.mu.Lock()
+				j.printing = true
+				j.mu.Unlock()
+				defer j.donePrinting()
+
 				j.setState(JobProcessing, nil, JSRJobPrinting, JSRJobTransforming)
 
 				// args should contain the data to print
@@ -243,30 +326,62 @@ func makeJobFSM(j *Job) *fsm.FSM {
 					lg.WarnContext(ctx, "Too many arguments provided for job processing, using only first arg", "args_count", len(e.Args))
 				}
 
-				var data []byte
-				if b, ok := e.Args[0].([]byte); !ok {
-					lg.WarnContext(ctx, "Invalid argument type for job processing, expected []byte", "arg_type", fmt.Sprintf("%T", e.Args[0]))
-					// send the abort event if the argument is not of type []byte
+				var docs []document
+				switch arg := e.Args[0].(type) {
+				case []byte:
+					docs = []document{{data: arg, format: j.Format}}
+				case []document:
+					docs = arg
+				default:
+					lg.WarnContext(ctx, "Invalid argument type for job processing, expected []byte or []document", "arg_type", fmt.Sprintf("%T", e.Args[0]))
+				}
+				if len(docs) == 0 {
+					// send the abort event if there is nothing to print
 					if err := e.FSM.Event(ctx, jobEvtAbort, JSRJobDataInsufficient, JSRAbortedBySystem); err != nil {
 						lg.ErrorContext(ctx, "Failed to send abort event for job processing", "error", err)
 					}
 					return
-				} else {
-					data = b
-					j.mu.Lock()
-					j.buffer = data // Store the data in the job buffer, for potential reprocessing
-					j.mu.Unlock()
-
 				}
+				j.mu.Lock()
+				j.buffer = docs // Store the data in the job buffer, for potential reprocessing
+				j.mu.Unlock()
 
 				// Concurrent jobs for the same printer are serialised by the
-				// spool (see spool.lockPrinter).
+				// spool (see spool.dispatch).
 				j.Printer.SetState(PSProcessing) // Set the printer state to processing
+				printCtx, stop := context.WithCancel(ctx)
+				defer stop()
 				j.mu.Lock()
 				j.Processing = time.Now() // Set the processing time to now
+				j.stopPrint = stop
 				j.mu.Unlock()
 				// Call the printer's Print method with the job data
-				if err := printWithOptions(ctx, j.Printer, data, j.printOptions); err != nil {
+				err := j.printDocuments(printCtx, docs)
+				j.mu.Lock()
+				j.stopPrint = nil
+				j.mu.Unlock()
+				if j.state() == JobCancelled {
+					lg.InfoContext(ctx, "Job was cancelled while printing", "error", err)
+					j.Printer.SetState(PSIdle)
+					return
+				}
+				if ctx.Err() != nil {
+					lg.WarnContext(ctx, "Job was interrupted by the spool shutdown", "error", err)
+					if err := e.FSM.Event(context.WithoutCancel(ctx), jobEvtAbort, JSRAbortedBySystem); err != nil {
+						lg.ErrorContext(ctx, "Failed to send abort event for job processing", "error", err)
+					}
+					j.Printer.SetState(PSIdle)
+					return
+				}
+				if errors.Is(err, thermoprint.ErrNoPaper) {
+					lg.ErrorContext(ctx, "Printer ran out of paper", "error", err)
+					if err := e.FSM.Event(ctx, jobEvtCancel, JSRJobCancelledAtDevice); err != nil {
+						lg.ErrorContext(ctx, "Failed to send cancel event for job processing", "error", err)
+					}
+					j.Printer.SetState(PSIdle)
+					return
+				}
+				if err != nil {
 					lg.ErrorContext(ctx, "Failed to print job data", "error", err)
 					// If printing fails, we can abort the job
 					if err := e.FSM.Event(ctx, jobEvtAbort, JSRDocumentFormatError, JSRAbortedBySystem); err != nil {
@@ -302,18 +417,113 @@ func makeJobFSM(j *Job) *fsm.FSM {
 				lg.InfoContext(ctx, "Job cancelled")
 				j.setState(JobCancelled, e.Args, JSRJobCancelledByUser)
 			},
+			jobEvtRestart: func(ctx context.Context, e *fsm.Event) {
+				lg.InfoContext(ctx, "Job restarted")
+				j.mu.Lock()
+				j.Processing = time.Time{}
+				j.Completed = time.Time{}
+				j.Sheets = 0
+				j.SheetsCompleted = 0
+				j.mu.Unlock()
+				j.setState(JobPending, nil, JSRNone)
+			},
 		},
 	)
 }
 
+// cancel cancels the job with the given reason, stopping the print if it is
+// in progress.  It fails with client-error-not-possible if the job is
+// already in a terminal state.
+func (j *Job) cancel(ctx context.Context, reason JobStateReason) error {
+	if state := j.state(); isCompletedState(state) {
+		return ippError(goipp.StatusErrorNotPossible, "job %d is already %s", j.ID, state)
+	}
+	if err := j.sm.Event(ctx, jobEvtCancel, reason); err != nil {
+		return ippError(goipp.StatusErrorNotPossible, "job %d cannot be cancelled: %w", j.ID, err)
+	}
+	j.mu.RLock()
+	stop := j.stopPrint
+	j.mu.RUnlock()
+	if stop != nil {
+		stop()
+	}
+	return nil
+}
+
+// hold holds the pending job, it is not printed until it is released.  It
+// fails with client-error-not-possible if the job is not pending.
+func (j *Job) hold(ctx context.Context) error {
+	switch state := j.state(); state {
+	case JobPendingHeld:
+		return nil
+	case JobPending:
+	default:
+		return ippError(goipp.StatusErrorNotPossible, "job %d is %s, only the pending job can be held", j.ID, state)
+	}
+	if err := j.sm.Event(ctx, jobEvtHeld); err != nil {
+		return ippError(goipp.StatusErrorNotPossible, "job %d cannot be held: %w", j.ID, err)
+	}
+	return nil
+}
+
+// release releases the held job, it is queued for printing again.  It fails
+// with client-error-not-possible if the job is not held.
+func (j *Job) release(ctx context.Context) error {
+	if state := j.state(); state != JobPendingHeld {
+		return ippError(goipp.StatusErrorNotPossible, "job %d is %s, not held", j.ID, state)
+	}
+	if err := j.sm.Event(ctx, jobEvtResume); err != nil {
+		return ippError(goipp.StatusErrorNotPossible, "job %d cannot be released: %w", j.ID, err)
+	}
+	return nil
+}
+
+// restart queues the finished job for printing again with its spooled
+// documents.  It fails with client-error-not-possible if the job is not
+// finished yet, or it has no documents.
+func (j *Job) restart(ctx context.Context) error {
+	if state := j.state(); !isCompletedState(state) {
+		return ippError(goipp.StatusErrorNotPossible, "job %d is %s, only the finished job can be restarted", j.ID, state)
+	}
+	j.mu.RLock()
+	complete := j.lastDocument && len(j.documents) > 0
+	j.mu.RUnlock()
+	if !complete {
+		return ippError(goipp.StatusErrorNotPossible, "job %d has no documents to print", j.ID)
+	}
+	if err := j.sm.Event(ctx, jobEvtRestart); err != nil {
+		return ippError(goipp.StatusErrorNotPossible, "job %d cannot be restarted: %w", j.ID, err)
+	}
+	return nil
+}
+
 // setState transitions the job into state under the job lock. State reasons
 // are taken from args (the fsm event arguments), falling back to fallback
 // when args carry none; when both are empty the current reasons are kept.
-// Reaching a terminal state records the completion time.
+// Reaching a terminal state records the completion time.  The changed
+// callback is called once the lock is released.
 func (j *Job) setState(state JobState, args []any, fallback ...JobStateReason) {
 	j.mu.Lock()
-	defer j.mu.Unlock()
+	changed := j.changed
+	defer func() {
+		j.mu.Unlock()
+		if changed != nil {
+			changed()
+		}
+	}()
 	j.State = state
+	if j.done != nil {
+		select {
+		case <-j.done:
+			if !isCompletedState(state) {
+				j.done = nil // restarted, finished makes the new one
+			}
+		default:
+			if isCompletedState(state) && !j.printing {
+				close(j.done)
+			}
+		}
+	}
 	if reasons := reasonsFromArgs(args...); len(reasons) > 0 {
 		j.StateReasons = reasons
 	} else if len(fallback) > 0 {
@@ -324,6 +534,69 @@ func (j *Job) setState(state JobState, args []any, fallback ...JobStateReason) {
 	}
 }
 
+// printDocuments prints the documents of the job one after another.  The
+// pages are counted across the documents, and the footer, if enabled, is
+// printed after the last one.
+func (j *Job) printDocuments(ctx context.Context, docs []document) error {
+	var done int // pages of the printed documents
+	for i, doc := range docs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		opts := j.printOptions
+		opts.format = cmp.Or(doc.format, opts.format)
+		opts.progress = func(completed, total int) { j.setSheets(done+completed, done+total) }
+		if i < len(docs)-1 {
+			opts.origin = JobOrigin{}
+		}
+		if err := printWithOptions(ctx, j.Printer, doc.data, opts); err != nil {
+			if len(docs) > 1 {
+				return fmt.Errorf("document %d: %w", i+1, err)
+			}
+			return err
+		}
+		j.mu.RLock()
+		done = j.SheetsCompleted
+		j.mu.RUnlock()
+	}
+	return nil
+}
+
+// acceptsDocuments reports whether the job waits for the documents of
+// Send-Document.
+func (j *Job) acceptsDocuments() bool {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.State == JobPending && !j.lastDocument
+}
+
+// queued reports whether the job has all its documents and waits for the
+// printer.
+func (j *Job) queued() bool {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.State == JobPending && j.lastDocument
+}
+
+// addDocument records the document of the given format and returns its
+// number, starting from 1.
+func (j *Job) addDocument(format, file string) int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.documents = append(j.documents, format)
+	j.files = append(j.files, file)
+	return len(j.documents)
+}
+
+// setSheets records the print progress, it is the [PrintOptions] Progress
+// callback.
+func (j *Job) setSheets(completed, total int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Sheets = total
+	j.SheetsCompleted = completed
+}
+
 func reasonsFromArgs(args ...any) []JobStateReason {
 	reasons := make([]JobStateReason, 0, len(args))
 	for _, arg := range args {
@@ -368,6 +641,11 @@ func (j *Job) attributes() goipp.Attributes {
 	a("job-state-reasons", goipp.TagKeyword, stringsToValues(j.StateReasons)...)
 	a("job-printer-uri", goipp.TagURI, goipp.String(j.PrinterURI))
 	a("job-originating-user-name", goipp.TagName, goipp.String(j.Username))
+	a("number-of-documents", goipp.TagInteger, goipp.Integer(len(j.documents)))
+	if j.Sheets > 0 {
+		a("job-media-sheets", goipp.TagInteger, goipp.Integer(j.Sheets))
+	}
+	a("job-media-sheets-completed", goipp.TagInteger, goipp.Integer(j.SheetsCompleted))
 	addTime("creation", j.Created)
 	addTime("processing", j.Processing)
 	addTime("completed", j.Completed)                                 // https://datatracker.ietf.org/doc/html/rfc2911#section-4.3.14.3
@@ -375,6 +653,36 @@ func (j *Job) attributes() goipp.Attributes {
 	return attrs
 }
 
+// finished returns the channel, that is closed, once the job is completed,
+// cancelled or aborted.  The restarted job returns the new channel.
+func (j *Job) finished() <-chan struct{} {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.done == nil {
+		j.done = make(chan struct{})
+		if isCompletedState(j.State) && !j.printing {
+			close(j.done)
+		}
+	}
+	return j.done
+}
+
+// donePrinting is called, when the process callback returns, it closes done,
+// if the job has reached the terminal state meanwhile.
+func (j *Job) donePrinting() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.printing = false
+	if j.done == nil || !isCompletedState(j.State) {
+		return
+	}
+	select {
+	case <-j.done:
+	default:
+		close(j.done)
+	}
+}
+
 func (j *Job) IsCompleted() bool {
 	return isCompletedState(j.state())
 }
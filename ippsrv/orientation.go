@@ -0,0 +1,87 @@
+package ippsrv
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/OpenPrinting/goipp"
+)
+
+// Orientation is the orientation-requested job template attribute value,
+// RFC 8011, section 5.2.10.
+type Orientation int
+
+const (
+	OrientationPortrait         Orientation = 3
+	OrientationLandscape        Orientation = 4
+	OrientationReverseLandscape Orientation = 5
+	OrientationReversePortrait  Orientation = 6
+)
+
+// orientationsSupported are the orientation-requested values in the order
+// they are advertised.
+var orientationsSupported = []Orientation{OrientationPortrait, OrientationLandscape, OrientationReverseLandscape, OrientationReversePortrait}
+
+// requestOrientation returns the orientation-requested job template
+// attribute of the request, or zero, if the client did not send it.
+func requestOrientation(req *goipp.Message) (Orientation, error) {
+	v, ok := jobTemplateValue(req, "orientation-requested")
+	if !ok {
+		return 0, nil
+	}
+	n, ok := v.(goipp.Integer)
+	if !ok {
+		return 0, fmt.Errorf("orientation-requested: unexpected value %q", v.String())
+	}
+	o := Orientation(n)
+	switch o {
+	case OrientationPortrait, OrientationLandscape, OrientationReverseLandscape, OrientationReversePortrait:
+		return o, nil
+	}
+	return 0, fmt.Errorf("orientation-requested: unsupported value %d", n)
+}
+
+// jobTemplateValue returns the first value of the job template attribute,
+// that clients send either in the job or, less often, in the operation
+// attributes.
+func jobTemplateValue(req *goipp.Message, name string) (goipp.Value, bool) {
+	vv, ok := findAttr(req.Job, name)
+	if !ok {
+		if vv, ok = findAttr(req.Operation, name); !ok {
+			return nil, false
+		}
+	}
+	return vv[0].V, true
+}
+
+// Rotate returns the page rotated for the orientation: the landscape page
+// is turned 90 degrees counter-clockwise, so that its long edge runs along
+// the roll, the reverse landscape — clockwise, and the reverse portrait
+// page is turned upside down.  The portrait page is returned as is.
+func (o Orientation) Rotate(img image.Image) image.Image {
+	b := img.Bounds()
+	var (
+		dst *image.RGBA
+		at  func(x, y int) image.Point // destination point of the source x, y
+	)
+	switch o {
+	case OrientationLandscape:
+		dst = image.NewRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
+		at = func(x, y int) image.Point { return image.Pt(y, b.Dx()-1-x) }
+	case OrientationReverseLandscape:
+		dst = image.NewRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
+		at = func(x, y int) image.Point { return image.Pt(b.Dy()-1-y, x) }
+	case OrientationReversePortrait:
+		dst = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		at = func(x, y int) image.Point { return image.Pt(b.Dx()-1-x, b.Dy()-1-y) }
+	default:
+		return img
+	}
+	for y := range b.Dy() {
+		for x := range b.Dx() {
+			p := at(x, y)
+			dst.Set(p.X, p.Y, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
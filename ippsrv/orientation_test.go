@@ -0,0 +1,80 @@
+package ippsrv
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/OpenPrinting/goipp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestOrientation(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   goipp.Value
+		want    Orientation
+		wantErr bool
+	}{
+		{name: "portrait", value: goipp.Integer(3), want: OrientationPortrait},
+		{name: "landscape", value: goipp.Integer(4), want: OrientationLandscape},
+		{name: "reverse-portrait", value: goipp.Integer(6), want: OrientationReversePortrait},
+		{name: "none", value: goipp.Integer(7), wantErr: true},
+		{name: "keyword", value: goipp.String("landscape"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newIPPRequest(goipp.OpPrintJob, testRequestID)
+			req.Job.Add(goipp.MakeAttribute("orientation-requested", goipp.TagEnum, tt.value))
+			got, err := requestOrientation(req)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	got, err := requestOrientation(newIPPRequest(goipp.OpPrintJob, testRequestID))
+	require.NoError(t, err)
+	assert.Zero(t, got)
+}
+
+func TestOrientationRotate(t *testing.T) {
+	// 3x2 image with the black top left pixel.
+	img := testPrintImage(t, 3, 2, map[image.Point]color.Color{image.Pt(0, 0): color.Black})
+	isBlack := func(img image.Image, x, y int) bool {
+		r, _, _, _ := img.At(x, y).RGBA()
+		return r == 0
+	}
+	tests := []struct {
+		o     Orientation
+		size  image.Point
+		black image.Point
+	}{
+		{OrientationPortrait, image.Pt(3, 2), image.Pt(0, 0)},
+		{OrientationLandscape, image.Pt(2, 3), image.Pt(0, 2)},
+		{OrientationReverseLandscape, image.Pt(2, 3), image.Pt(1, 0)},
+		{OrientationReversePortrait, image.Pt(3, 2), image.Pt(2, 1)},
+	}
+	for _, tt := range tests {
+		got := tt.o.Rotate(img)
+		assert.Equal(t, tt.size, got.Bounds().Size(), "orientation %d", tt.o)
+		assert.True(t, isBlack(got, tt.black.X, tt.black.Y), "orientation %d", tt.o)
+	}
+}
+
+func TestPrintWithOptionsOrientation(t *testing.T) {
+	driver := &captureDriver{}
+	p, err := WrapDriver(driver, "test-printer", "Test Printer")
+	require.NoError(t, err)
+	op := p.(OptionPrinter)
+	page := mustPNG(t, testPrintImage(t, 200, 50, nil))
+
+	require.NoError(t, op.PrintWithOptions(context.Background(), page, PrintOptions{}))
+	assert.Equal(t, image.Rect(0, 0, 200, 50), driver.printedBounds())
+	require.NoError(t, op.PrintWithOptions(context.Background(), page, PrintOptions{Orientation: OrientationLandscape}))
+	assert.Equal(t, image.Rect(0, 0, 50, 200), driver.printedBounds(), "the long edge runs along the roll")
+}
@@ -0,0 +1,67 @@
+package ippsrv
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/OpenPrinting/goipp"
+
+	"github.com/rusq/thermoprint/locale"
+)
+
+// JobOrigin identifies the print job and where it came from, it is printed
+// in the footer of the job, if the printer has it enabled, see
+// [WithJobFooter].
+type JobOrigin struct {
+	User  string    // requesting user name
+	Host  string    // host the job was submitted from
+	JobID JobID     // job id
+	Time  time.Time // time the job was submitted
+}
+
+// footerTimeFormat is the format of the time in the job footer.
+const footerTimeFormat = "2006-01-02 15:04"
+
+// String returns the footer text, i.e. "user@host, job 12, 2026-10-16 14:02",
+// in the default language.
+func (o JobOrigin) String() string {
+	return o.Format(locale.Default())
+}
+
+// Format returns the footer text in the language of the catalog.
+func (o JobOrigin) Format(c *locale.Catalog) string {
+	who := o.User
+	if o.Host != "" {
+		who += "@" + o.Host
+	}
+	return c.Sprintf("%s, job %d, %s", who, o.JobID, o.Time.Format(footerTimeFormat))
+}
+
+// IsZero reports whether the origin is not set, i.e. the data is printed
+// directly, not as the IPP job.
+func (o JobOrigin) IsZero() bool {
+	return o.JobID == 0
+}
+
+type remoteAddrKey struct{}
+
+// withRemoteAddr returns the context with the address of the client, that
+// sent the request.
+func withRemoteAddr(ctx context.Context, addr string) context.Context {
+	return context.WithValue(ctx, remoteAddrKey{}, addr)
+}
+
+// originHost returns the host the job was submitted from: the
+// job-originating-host-name, that CUPS sends when it forwards the jobs, or
+// the address of the client.
+func originHost(ctx context.Context, req *goipp.Message) string {
+	if host, err := extractValue[goipp.String](req.Operation, "job-originating-host-name"); err == nil && host != "" {
+		return host.String()
+	}
+	addr, _ := ctx.Value(remoteAddrKey{}).(string)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,71 @@
+package ippsrv
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OpenPrinting/goipp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rusq/thermoprint/locale"
+)
+
+func TestJobOrigin_String(t *testing.T) {
+	at := time.Date(2026, 10, 16, 14, 2, 0, 0, time.UTC)
+	assert.Equal(t, "alice@laptop, job 12, 2026-10-16 14:02", JobOrigin{User: "alice", Host: "laptop", JobID: 12, Time: at}.String())
+	assert.Equal(t, "alice, job 12, 2026-10-16 14:02", JobOrigin{User: "alice", JobID: 12, Time: at}.String())
+	assert.True(t, JobOrigin{}.IsZero())
+
+	de, err := locale.Lookup("de")
+	require.NoError(t, err)
+	assert.Equal(t, "alice@laptop, Auftrag 12, 2026-10-16 14:02", JobOrigin{User: "alice", Host: "laptop", JobID: 12, Time: at}.Format(de))
+}
+
+func TestOriginHost(t *testing.T) {
+	ctx := withRemoteAddr(context.Background(), "192.0.2.7:51234")
+	req := newIPPRequest(goipp.OpPrintJob, testRequestID)
+	assert.Equal(t, "192.0.2.7", originHost(ctx, req))
+	assert.Empty(t, originHost(context.Background(), req))
+
+	req.Operation.Add(goipp.MakeAttribute("job-originating-host-name", goipp.TagName, goipp.String("workstation")))
+	assert.Equal(t, "workstation", originHost(ctx, req), "the host forwarded by CUPS takes precedence")
+}
+
+func TestPrintJobFooter(t *testing.T) {
+	origin := JobOrigin{User: "alice", Host: "laptop", JobID: 3, Time: time.Now()}
+	page := mustPNG(t, testPrintImage(t, 384, 20, nil))
+	printHeight := func(t *testing.T, enable bool, origin JobOrigin) int {
+		t.Helper()
+		driver := &captureDriver{}
+		p, err := WrapDriver(driver, "test-printer", "Test Printer", WithJobFooter(enable))
+		require.NoError(t, err)
+		require.NoError(t, p.(OptionPrinter).PrintWithOptions(context.Background(), page, PrintOptions{Origin: origin}))
+		return driver.printedBounds().Dy()
+	}
+	assert.Equal(t, 20, printHeight(t, false, origin), "footer disabled")
+	assert.Equal(t, 20, printHeight(t, true, JobOrigin{}), "not an IPP job")
+	assert.Greater(t, printHeight(t, true, origin), 20, "footer added")
+}
+
+func TestHandlePrintJob_Origin(t *testing.T) {
+	s := newTestIPPServer(t)
+	req := newIPPRequest(goipp.OpPrintJob, testRequestID)
+	req.Operation.Add(goipp.MakeAttribute("requesting-user-name", goipp.TagName, goipp.String("alice")))
+	ctx := withRemoteAddr(context.Background(), "[2001:db8::1]:631")
+	resp, err := s.handlePrintJob(ctx, req, tinyPNG(t))
+	require.NoError(t, err)
+	id, err := extractValue[goipp.Integer](resp.Job, "job-id")
+	require.NoError(t, err)
+	j, err := s.spool.GetJob(JobID(id))
+	require.NoError(t, err)
+	assert.Equal(t, JobOrigin{User: "alice", Host: "2001:db8::1", JobID: JobID(id), Time: j.Created}, j.printOptions.origin)
+	assert.Equal(t, "en-us", j.printOptions.lang, "attributes-natural-language of the request")
+}
+
+func TestJobCatalog(t *testing.T) {
+	assert.Equal(t, "de", jobCatalog("de-de").Lang())
+	assert.Equal(t, locale.Default(), jobCatalog("xx"), "unsupported language")
+	assert.Equal(t, locale.Default(), jobCatalog(""))
+}
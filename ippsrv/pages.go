@@ -0,0 +1,166 @@
+package ippsrv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/OpenPrinting/goipp"
+)
+
+// ErrNoPages is returned when none of the document pages is in the selected
+// page ranges.
+var ErrNoPages = errors.New("no pages in the selected page ranges")
+
+// PageRange is the inclusive range of pages, numbered from 1.  Last is zero
+// for the range that extends to the end of the document.
+type PageRange struct {
+	First int
+	Last  int
+}
+
+// PageRanges is the selection of the pages of the document.  The empty
+// selection selects all pages.
+type PageRanges []PageRange
+
+// ParsePageRanges parses the comma separated list of pages and ranges,
+// i.e. "2-4", "1,3,5-" or "7".
+func ParsePageRanges(s string) (PageRanges, error) {
+	var rr PageRanges
+	for part := range strings.SplitSeq(s, ",") {
+		part = strings.TrimSpace(part)
+		first, last, isRange := strings.Cut(part, "-")
+		var (
+			r   PageRange
+			err error
+		)
+		if r.First, err = parsePageNumber(first); err != nil {
+			return nil, fmt.Errorf("page range %q: %w", part, err)
+		}
+		switch {
+		case !isRange:
+			r.Last = r.First
+		case strings.TrimSpace(last) == "":
+			// open range, up to the last page
+		default:
+			if r.Last, err = parsePageNumber(last); err != nil {
+				return nil, fmt.Errorf("page range %q: %w", part, err)
+			}
+			if r.Last < r.First {
+				return nil, fmt.Errorf("page range %q: last page is before the first", part)
+			}
+		}
+		rr = append(rr, r)
+	}
+	return rr, nil
+}
+
+func parsePageNumber(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, errors.New("invalid page number")
+	}
+	if n < 1 {
+		return 0, errors.New("pages are numbered from 1")
+	}
+	return n, nil
+}
+
+// Contains reports whether the page, numbered from 1, is selected.
+func (rr PageRanges) Contains(page int) bool {
+	if len(rr) == 0 {
+		return true
+	}
+	for _, r := range rr {
+		if r.First <= page && (r.Last == 0 || page <= r.Last) {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns the selected pages.
+func (rr PageRanges) Select(pages []image.Image) []image.Image {
+	if len(rr) == 0 {
+		return pages
+	}
+	var sel []image.Image
+	for i, pg := range pages {
+		if rr.Contains(i + 1) {
+			sel = append(sel, pg)
+		}
+	}
+	return sel
+}
+
+func (rr PageRanges) String() string {
+	parts := make([]string, len(rr))
+	for i, r := range rr {
+		switch {
+		case r.Last == r.First:
+			parts[i] = strconv.Itoa(r.First)
+		case r.Last == 0:
+			parts[i] = strconv.Itoa(r.First) + "-"
+		default:
+			parts[i] = strconv.Itoa(r.First) + "-" + strconv.Itoa(r.Last)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// PageFilter is implemented by the filters that can rasterise only the
+// selected pages of the document, which is much faster for a few pages of
+// a long document.
+type PageFilter interface {
+	Filter
+	// ToRasterPages converts the selected pages of the data.
+	ToRasterPages(ctx context.Context, dpi int, data []byte, pages PageRanges) ([]image.Image, error)
+}
+
+// ToRasterPages converts the selected pages of the data with the filter.
+// Filters that don't implement [PageFilter] convert the whole document, and
+// the pages are selected afterwards.
+func ToRasterPages(ctx context.Context, f Filter, dpi int, data []byte, pages PageRanges) ([]image.Image, error) {
+	if len(pages) == 0 {
+		return f.ToRaster(ctx, dpi, data)
+	}
+	if pf, ok := f.(PageFilter); ok {
+		return pf.ToRasterPages(ctx, dpi, data, pages)
+	}
+	images, err := f.ToRaster(ctx, dpi, data)
+	if err != nil {
+		return nil, err
+	}
+	return pages.Select(images), nil
+}
+
+// requestPageRanges returns the page-ranges job template attribute of the
+// request, see RFC 8011, section 5.2.7.
+func requestPageRanges(req *goipp.Message) (PageRanges, error) {
+	vv, ok := findAttr(req.Job, "page-ranges")
+	if !ok {
+		if vv, ok = findAttr(req.Operation, "page-ranges"); !ok {
+			return nil, nil
+		}
+	}
+	rr := make(PageRanges, 0, len(vv))
+	for _, v := range vv {
+		rng, ok := v.V.(goipp.Range)
+		if !ok {
+			return nil, fmt.Errorf("page-ranges: unexpected value type %T", v.V)
+		}
+		if rng.Lower < 1 || rng.Upper < rng.Lower {
+			return nil, fmt.Errorf("page-ranges: invalid range %d-%d", rng.Lower, rng.Upper)
+		}
+		r := PageRange{First: rng.Lower, Last: rng.Upper}
+		if rng.Upper == math.MaxInt32 {
+			r.Last = 0 // clients send MAX for "to the end"
+		}
+		rr = append(rr, r)
+	}
+	return rr, nil
+}
@@ -0,0 +1,115 @@
+package ippsrv
+
+import (
+	"context"
+	"image"
+	"math"
+	"testing"
+
+	"github.com/OpenPrinting/goipp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePageRanges(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    PageRanges
+		wantErr bool
+	}{
+		{in: "3", want: PageRanges{{3, 3}}},
+		{in: "2-4", want: PageRanges{{2, 4}}},
+		{in: "1, 3,5-", want: PageRanges{{1, 1}, {3, 3}, {5, 0}}},
+		{in: "", wantErr: true},
+		{in: "0-2", wantErr: true},
+		{in: "4-2", wantErr: true},
+		{in: "a-b", wantErr: true},
+		{in: "1,,2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParsePageRanges(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPageRanges_Contains(t *testing.T) {
+	rr := PageRanges{{2, 4}, {7, 0}}
+	for page, want := range map[int]bool{1: false, 2: true, 4: true, 5: false, 7: true, 100: true} {
+		assert.Equal(t, want, rr.Contains(page), "page %d", page)
+	}
+	assert.True(t, PageRanges(nil).Contains(1), "empty selection selects all pages")
+	assert.Equal(t, "2-4,7-", rr.String())
+}
+
+func TestMagickFrames(t *testing.T) {
+	frames, ok := magickFrames(PageRanges{{2, 4}, {6, 6}})
+	assert.True(t, ok)
+	assert.Equal(t, "[1-3,5]", frames)
+
+	_, ok = magickFrames(PageRanges{{2, 0}})
+	assert.False(t, ok, "open range can't be expressed")
+}
+
+func TestRequestPageRanges(t *testing.T) {
+	req := newIPPRequest(goipp.OpPrintJob, testRequestID)
+	rr, err := requestPageRanges(req)
+	require.NoError(t, err)
+	assert.Empty(t, rr)
+
+	req.Job.Add(goipp.MakeAttribute("page-ranges", goipp.TagRange, goipp.Range{Lower: 2, Upper: 3}))
+	req.Job[len(req.Job)-1].Values.Add(goipp.TagRange, goipp.Range{Lower: 5, Upper: math.MaxInt32})
+	rr, err = requestPageRanges(req)
+	require.NoError(t, err)
+	assert.Equal(t, PageRanges{{2, 3}, {5, 0}}, rr)
+
+	bad := newIPPRequest(goipp.OpPrintJob, testRequestID)
+	bad.Job.Add(goipp.MakeAttribute("page-ranges", goipp.TagRange, goipp.Range{Lower: 3, Upper: 2}))
+	_, err = requestPageRanges(bad)
+	assert.Error(t, err)
+}
+
+// pagesFilter returns the pages of the given heights, it doesn't implement
+// PageFilter.
+type pagesFilter []int
+
+func (f pagesFilter) ToRaster(context.Context, int, []byte) ([]image.Image, error) {
+	images := make([]image.Image, len(f))
+	for i, h := range f {
+		images[i] = image.NewGray(image.Rect(0, 0, 8, h))
+	}
+	return images, nil
+}
+
+func (f pagesFilter) Type() string { return "pages" }
+
+func TestToRasterPages(t *testing.T) {
+	f := &rasterSniffFilter{fallback: pagesFilter{1, 2, 3, 4}}
+	pages, err := ToRasterPages(context.Background(), f, 203, []byte("%PDF-1.7"), PageRanges{{2, 3}})
+	require.NoError(t, err)
+	require.Len(t, pages, 2)
+	assert.Equal(t, 2, pages[0].Bounds().Dy())
+	assert.Equal(t, 3, pages[1].Bounds().Dy())
+}
+
+func TestPrintWithOptionsPages(t *testing.T) {
+	driver := &captureDriver{}
+	p, err := WrapDriver(driver, "test-printer", "Test Printer", WithFilter(pagesFilter{10, 20, 30}))
+	require.NoError(t, err)
+	op := p.(OptionPrinter)
+
+	require.NoError(t, op.PrintWithOptions(context.Background(), []byte("%PDF-1.7"), PrintOptions{Pages: PageRanges{{2, 0}}}))
+	assert.Equal(t, 50, driver.printedBounds().Dy(), "pages 2 and 3 must be printed")
+
+	err = op.PrintWithOptions(context.Background(), []byte("%PDF-1.7"), PrintOptions{Pages: PageRanges{{5, 6}}})
+	assert.ErrorIs(t, err, ErrNoPages)
+
+	err = op.PrintWithOptions(context.Background(), mustPNG(t, image.NewGray(image.Rect(0, 0, 4, 4))), PrintOptions{Pages: PageRanges{{2, 2}}})
+	assert.ErrorIs(t, err, ErrNoPages, "image is a single page document")
+}
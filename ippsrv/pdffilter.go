@@ -0,0 +1,229 @@
+package ippsrv
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log/slog"
+	"math"
+	"os/exec"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// pointsPerInch is the PDF unit.
+	pointsPerInch = 72
+	// maxRuleWidth is the thickest rectangle, in points, that is drawn by
+	// the PDF filter, see [pdfFilter].
+	maxRuleWidth = 2
+	// maxTreeDepth is the deepest page tree, that is searched for the
+	// inherited page attributes.
+	maxTreeDepth = 32
+)
+
+// pdfFilter rasterises the PDF documents natively, without ImageMagick.  It
+// draws the text of the pages at its place with the Go fonts of the same
+// size, and the thin rectangles, i.e. the rules and the table borders.  The
+// images and the other vector graphics are not drawn, the colour of the
+// rectangles is unknown, so the larger ones, usually the background fills,
+// are skipped too.  Documents that are not PDF are passed to the fallback
+// filter.
+type pdfFilter struct {
+	fallback Filter
+}
+
+var _ PageFilter = &pdfFilter{}
+
+// NewPDFFilter returns the filter that rasterises the PDF documents natively,
+// everything else is converted with the fallback filter.
+func NewPDFFilter(fallback Filter) Filter {
+	return &pdfFilter{fallback: fallback}
+}
+
+// lookPath is [exec.LookPath], replaced in tests.
+var lookPath = exec.LookPath
+
+// documentFilter returns the filter of the documents, that are not
+// pre-rasterised: ImageMagick, if it is installed, otherwise the native PDF
+// filter.
+func documentFilter() Filter {
+	magick := &imageMagickFilter{}
+	if _, err := lookPath("magick"); err != nil {
+		slog.Debug("ImageMagick is not found, PDF documents are rasterised natively", "error", err)
+		return NewPDFFilter(magick)
+	}
+	return magick
+}
+
+func (f *pdfFilter) ToRaster(ctx context.Context, dpi int, data []byte) ([]image.Image, error) {
+	return f.ToRasterPages(ctx, dpi, data, nil)
+}
+
+func (f *pdfFilter) ToRasterPages(ctx context.Context, dpi int, data []byte, sel PageRanges) ([]image.Image, error) {
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return ToRasterPages(ctx, f.fallback, dpi, data, sel)
+	}
+	if dpi <= 0 {
+		return nil, fmt.Errorf("invalid resolution: %d", dpi)
+	}
+	slog.InfoContext(ctx, "rasterising the PDF document natively, the images are not printed", "dpi", dpi)
+	return rasterisePDF(data, float64(dpi), sel)
+}
+
+func (f *pdfFilter) Type() string {
+	return "pdf+" + f.fallback.Type()
+}
+
+// rasterisePDF renders the selected pages of the PDF document at the
+// resolution.
+func rasterisePDF(data []byte, dpi float64, sel PageRanges) (pages []image.Image, err error) {
+	// the pdf package panics on some malformed documents.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	faces := make(faceCache)
+	for i := 1; i <= r.NumPage(); i++ {
+		if !sel.Contains(i) {
+			continue
+		}
+		p := r.Page(i)
+		if p.V.IsNull() {
+			continue
+		}
+		img, err := renderPage(p, dpi, faces)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i, err)
+		}
+		pages = append(pages, img)
+	}
+	return pages, nil
+}
+
+// renderPage renders the text and the thin rectangles of the page.
+func renderPage(p pdf.Page, dpi float64, faces faceCache) (image.Image, error) {
+	box := inherited(p.V, "CropBox")
+	if box.Len() != 4 {
+		box = inherited(p.V, "MediaBox")
+	}
+	if box.Len() != 4 {
+		return nil, errors.New("page has no media box")
+	}
+	x0, y0 := min(box.Index(0).Float64(), box.Index(2).Float64()), min(box.Index(1).Float64(), box.Index(3).Float64())
+	x1, y1 := max(box.Index(0).Float64(), box.Index(2).Float64()), max(box.Index(1).Float64(), box.Index(3).Float64())
+	scale := dpi / pointsPerInch
+	// toPixel converts the PDF coordinates, with Y increasing bottom to top,
+	// to the pixel coordinates.
+	toPixel := func(x, y float64) (int, int) {
+		return int(math.Round((x - x0) * scale)), int(math.Round((y1 - y) * scale))
+	}
+	w, h := toPixel(x1, y0)
+	if w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("invalid page size: %gx%g points", x1-x0, y1-y0)
+	}
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	content := p.Content()
+	for _, r := range content.Rect {
+		if min(math.Abs(r.Max.X-r.Min.X), math.Abs(r.Max.Y-r.Min.Y)) > maxRuleWidth {
+			continue
+		}
+		ax, ay := toPixel(r.Min.X, r.Min.Y)
+		bx, by := toPixel(r.Max.X, r.Max.Y)
+		rect := image.Rect(ax, ay, bx, by).Canon()
+		// the hairlines are at least a pixel thick.
+		rect.Max.X, rect.Max.Y = max(rect.Max.X, rect.Min.X+1), max(rect.Max.Y, rect.Min.Y+1)
+		draw.Draw(img, rect, image.Black, image.Point{}, draw.Src)
+	}
+	d := font.Drawer{Dst: img, Src: image.Black}
+	var prev *pdf.Text
+	for i := range content.Text {
+		t := &content.Text[i]
+		if t.S == "" || t.FontSize <= 0 {
+			continue
+		}
+		face, err := faces.face(t.Font, t.FontSize, dpi)
+		if err != nil {
+			return nil, err
+		}
+		d.Face = face
+		// the glyphs of the standard fonts without the widths in the
+		// document are all at the same position, they are set one after
+		// another with the widths of the Go font.
+		if prev == nil || prev.X != t.X || prev.Y != t.Y || prev.W != 0 {
+			x, y := toPixel(t.X, t.Y)
+			d.Dot = fixed.P(x, y)
+		}
+		d.DrawString(t.S)
+		prev = t
+	}
+	return img, nil
+}
+
+// inherited returns the value of the page attribute, that may be inherited
+// from the page tree.
+func inherited(v pdf.Value, key string) pdf.Value {
+	// the depth is limited, in case the malformed tree has a cycle.
+	for depth := 0; !v.IsNull() && depth < maxTreeDepth; v, depth = v.Key("Parent"), depth+1 {
+		if a := v.Key(key); !a.IsNull() {
+			return a
+		}
+	}
+	return pdf.Value{}
+}
+
+// faceCache caches the faces of the fonts by the style and the size.
+type faceCache map[faceKey]font.Face
+
+type faceKey struct {
+	ttf  *[]byte
+	size float64
+}
+
+// face returns the Go font face that resembles the PDF font of the size in
+// points: the monospaced for Courier, the bold for the bold fonts, the
+// regular otherwise.
+func (c faceCache) face(name string, size, dpi float64) (font.Face, error) {
+	ttf := &goregular.TTF
+	switch lower := strings.ToLower(name); {
+	case strings.Contains(lower, "courier") || strings.Contains(lower, "mono"):
+		ttf = &gomono.TTF
+	case strings.Contains(lower, "bold") || strings.Contains(lower, "black") || strings.Contains(lower, "heavy"):
+		ttf = &gobold.TTF
+	}
+	key := faceKey{ttf: ttf, size: math.Round(size*2) / 2}
+	if f, ok := c[key]; ok {
+		return f, nil
+	}
+	fnt, err := opentype.Parse(*ttf)
+	if err != nil {
+		return nil, err
+	}
+	f, err := opentype.NewFace(fnt, &opentype.FaceOptions{
+		Size:    key.size,
+		DPI:     dpi,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c[key] = f
+	return f, nil
+}
@@ -0,0 +1,76 @@
+package ippsrv
+
+import (
+	"context"
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPDFFilter_ToRasterPages(t *testing.T) {
+	data := minimalPDF(t, "first page", "", "third page\nsecond line")
+	f := &pdfFilter{fallback: &recordingFilter{}}
+
+	t.Run("all pages", func(t *testing.T) {
+		pages, err := f.ToRaster(context.Background(), 72, data)
+		require.NoError(t, err)
+		require.Len(t, pages, 3)
+		for _, pg := range pages {
+			assert.Equal(t, image.Rect(0, 0, 595, 842), pg.Bounds(), "A4 at 72dpi")
+		}
+		assert.True(t, hasInk(pages[0], image.Rect(72, 100, 300, 125)), "text at its place")
+		assert.True(t, hasInk(pages[0], image.Rect(110, 100, 130, 125)), "glyphs set one after another")
+		assert.False(t, hasInk(pages[1], pages[1].Bounds()), "empty page")
+		assert.True(t, hasInk(pages[2], image.Rect(72, 125, 300, 140)), "second line")
+	})
+	t.Run("printer resolution", func(t *testing.T) {
+		pages, err := f.ToRaster(context.Background(), 203, data)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 1678, 2374), pages[0].Bounds())
+	})
+	t.Run("selected pages", func(t *testing.T) {
+		pages, err := f.ToRasterPages(context.Background(), 72, data, PageRanges{{First: 3, Last: 3}})
+		require.NoError(t, err)
+		require.Len(t, pages, 1)
+		assert.True(t, hasInk(pages[0], image.Rect(72, 125, 300, 140)))
+	})
+	t.Run("malformed", func(t *testing.T) {
+		_, err := f.ToRaster(context.Background(), 72, []byte("%PDF-1.4\ngarbage"))
+		assert.Error(t, err)
+	})
+}
+
+func TestPDFFilter_Fallback(t *testing.T) {
+	rec := &recordingFilter{}
+	f := NewPDFFilter(rec)
+	_, err := f.ToRaster(context.Background(), 203, []byte("%!PS-Adobe-3.0\n"))
+	require.NoError(t, err)
+	assert.True(t, rec.called, "not PDF goes to the fallback")
+	assert.Equal(t, "pdf+recording", f.Type())
+}
+
+func TestDocumentFilter(t *testing.T) {
+	old := lookPath
+	t.Cleanup(func() { lookPath = old })
+
+	lookPath = func(string) (string, error) { return "/usr/bin/magick", nil }
+	assert.Equal(t, "ImageMagick", documentFilter().Type())
+
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+	assert.Equal(t, "pdf+ImageMagick", documentFilter().Type())
+}
+
+// hasInk reports whether there is a dark pixel in the rectangle.
+func hasInk(img image.Image, r image.Rectangle) bool {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if r, g, b, _ := img.At(x, y).RGBA(); r+g+b < 3*0x8000 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package ippsrv
+
+import (
+	"errors"
+	"image"
+	"mime"
+	"net/http"
+	"strings"
+
+	"golang.org/x/image/font"
+
+	"github.com/rusq/thermoprint/bitmap"
+)
+
+// formFeed starts the new page of the plain text.
+const formFeed = "\f"
+
+// isPlainText reports whether the job data is the plain text: either the
+// client says so in the document-format, or it does not name the format,
+// i.e. "lp -o raw", and the data looks like the text.
+func isPlainText(format string, data []byte) bool {
+	mediaType, _, err := mime.ParseMediaType(format)
+	switch {
+	case err == nil && mediaType == ippTextPlain.String():
+		return true
+	case format == "" || mediaType == ippApplicationOctetStream.String():
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+		return mediaType == ippTextPlain.String()
+	}
+	return false
+}
+
+// plainTextPages renders the selected pages of the plain text with the face
+// on the pages of the given width.  The form feed starts the new page, the
+// long lines are wrapped on the words.  The text is expected in UTF-8,
+// unless the document-format names the ISO-8859-1 charset, the invalid
+// characters are replaced.  The pages that have no text are not printed.
+func plainTextPages(data []byte, format string, width int, face font.Face, sel PageRanges) ([]image.Image, error) {
+	if face == nil {
+		return nil, errors.New("no font for the plain text")
+	}
+	text := decodeText(data, format)
+	text = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+	var pages []image.Image
+	for i, page := range strings.Split(text, formFeed) {
+		if !sel.Contains(i+1) || strings.TrimSpace(page) == "" {
+			continue
+		}
+		c := bitmap.NewComposer(width)
+		if err := c.AppendText(face, strings.Trim(page, "\n"), bitmap.WithWrap(true)); err != nil {
+			return nil, err
+		}
+		pages = append(pages, c.Image())
+	}
+	return pages, nil
+}
+
+// decodeText returns the data as the UTF-8 string, according to the charset
+// parameter of the document-format.
+func decodeText(data []byte, format string) string {
+	_, params, _ := mime.ParseMediaType(format)
+	switch strings.ToLower(params["charset"]) {
+	case "iso-8859-1", "latin1":
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+	return strings.ToValidUTF8(string(data), "�")
+}
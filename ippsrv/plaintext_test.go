@@ -0,0 +1,71 @@
+package ippsrv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rusq/thermoprint/fontmgr"
+)
+
+func TestIsPlainText(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		want   bool
+	}{
+		{"text/plain", "text/plain", "%PDF-1.7", true},
+		{"charset", "text/plain; charset=utf-8", "hello", true},
+		{"raw text", "", "hello, world\n", true},
+		{"octet stream text", "application/octet-stream", "hello, world\n", true},
+		{"raw pdf", "", "%PDF-1.7\n", false},
+		{"raw postscript", "", "%!PS-Adobe-3.0\n", false},
+		{"raw pwg", "", "RaS2PwgRaster\x00", false},
+		{"pwg format", "image/pwg-raster", "hello", false},
+		{"html", "", "<html><body>hi</body></html>", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isPlainText(tt.format, []byte(tt.data)))
+		})
+	}
+}
+
+func TestPlainTextPages(t *testing.T) {
+	data := []byte("page one\r\n\fpage two\f\n\n\fpage four, which is long enough to be wrapped on the narrow page\n")
+	pages, err := plainTextPages(data, "text/plain", 384, fontmgr.DefaultFont, nil)
+	require.NoError(t, err)
+	require.Len(t, pages, 3, "the blank page is not printed")
+	assert.Equal(t, 384, pages[0].Bounds().Dx())
+	assert.Greater(t, pages[2].Bounds().Dy(), pages[0].Bounds().Dy(), "the long line is wrapped")
+
+	pages, err = plainTextPages(data, "text/plain", 384, fontmgr.DefaultFont, PageRanges{{2, 3}})
+	require.NoError(t, err)
+	assert.Len(t, pages, 1, "page 3 is blank")
+
+	_, err = plainTextPages(data, "text/plain", 384, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestDecodeText(t *testing.T) {
+	assert.Equal(t, "café", decodeText([]byte("caf\xe9"), "text/plain; charset=ISO-8859-1"))
+	assert.Equal(t, "café", decodeText([]byte("café"), "text/plain"))
+	assert.Equal(t, "caf�", decodeText([]byte("caf\xe9"), ""))
+}
+
+func TestPrintPlainText(t *testing.T) {
+	driver := &captureDriver{}
+	p, err := WrapDriver(driver, "test-printer", "Test Printer", WithFilter(pagesFilter{1000}))
+	require.NoError(t, err)
+	op := p.(OptionPrinter)
+
+	require.NoError(t, op.PrintWithOptions(context.Background(), []byte("hello\fworld"), PrintOptions{Format: "text/plain"}))
+	assert.Less(t, driver.printedBounds().Dy(), 1000, "text is not passed to the filter")
+	assert.Positive(t, driver.printedBounds().Dy())
+
+	assert.ErrorIs(t, op.PrintWithOptions(context.Background(), []byte("\n\f \n"), PrintOptions{Format: "text/plain"}), ErrBlankDocument)
+	assert.ErrorIs(t, op.PrintWithOptions(context.Background(), []byte("hello"), PrintOptions{Format: "text/plain", Pages: PageRanges{{2, 2}}}), ErrNoPages)
+}
@@ -2,6 +2,7 @@ package ippsrv
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
@@ -9,13 +10,17 @@ import (
 	"image/color"
 	"image/draw"
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/image/font"
 
 	"github.com/rusq/thermoprint"
 	"github.com/rusq/thermoprint/bitmap"
+	"github.com/rusq/thermoprint/fontmgr"
+	"github.com/rusq/thermoprint/locale"
 )
 
 var startTime = time.Now()
@@ -28,6 +33,37 @@ type basePrinter struct {
 	printMu  sync.Mutex
 	Drv      Driver
 	Filter   Filter
+	Fit      Fit // default fit policy for the wide pages
+
+	// BlankThreshold is the share of the dark pixels, below which the
+	// document page is not printed, zero disables.
+	BlankThreshold float64
+	// PageNumbers enables the page headers with the page numbers between
+	// the pages of multi-page documents.
+	PageNumbers bool
+	// TextFont is the font of the plain text jobs.
+	TextFont font.Face
+	// JobFooter enables the footer with the origin of the job, see
+	// [JobOrigin], at the end of every job.
+	JobFooter bool
+	// Energy is the thermal energy level of the normal quality jobs, the
+	// draft and high quality jobs are printed lighter and darker, see
+	// [Quality.Energy].
+	Energy uint8
+	// Dither is the name of the dither function of the document pages, the
+	// default function is used, if it is empty, see [WithDither].
+	Dither string
+	// Channel is the colour channel, with which the pages are converted to
+	// grey before dithering, see [WithChannel].
+	Channel bitmap.Channel
+	// Qualities are the settings of the print-quality values, see
+	// [WithQualityProfiles].
+	Qualities QualityProfiles
+
+	statusMu sync.RWMutex
+	status   *thermoprint.Status // last status reported by the driver, if it reports
+
+	changed func() // called after the state or the status changes, see notifyChanges
 }
 
 type PrinterInformer interface {
@@ -107,6 +143,61 @@ func WithFilter(f Filter) PrinterOption {
 	}
 }
 
+// WithFit sets the default fit policy for the pages wider than the printer,
+// that is used, unless the job requests another one.
+func WithFit(fit Fit) PrinterOption {
+	return func(p *basePrinter) error {
+		if _, err := ParseFit(string(fit)); err != nil {
+			return err
+		}
+		p.Fit = fit
+		return nil
+	}
+}
+
+// WithTextFont sets the font of the plain text jobs, [fontmgr.DefaultFont]
+// by default.
+func WithTextFont(face font.Face) PrinterOption {
+	return func(p *basePrinter) error {
+		if face == nil {
+			return errors.New("text font cannot be nil")
+		}
+		p.TextFont = face
+		return nil
+	}
+}
+
+// WithJobFooter enables the footer with the user, host, job id and time at
+// the end of every job, so that it is known who printed what on the shared
+// printer.
+func WithJobFooter(enable bool) PrinterOption {
+	return func(p *basePrinter) error {
+		p.JobFooter = enable
+		return nil
+	}
+}
+
+// WithEnergy sets the thermal energy level (0-6) the driver is configured
+// with, it is restored after the jobs that request another print-quality.
+func WithEnergy(level uint8) PrinterOption {
+	return func(p *basePrinter) error {
+		if level > maxEnergy {
+			return fmt.Errorf("energy level %d is out of range 0-%d", level, maxEnergy)
+		}
+		p.Energy = level
+		return nil
+	}
+}
+
+// WithPageNumbers enables the "page n/N" headers above the pages of
+// multi-page documents, so that the long printout remains navigable.
+func WithPageNumbers(enable bool) PrinterOption {
+	return func(p *basePrinter) error {
+		p.PageNumbers = enable
+		return nil
+	}
+}
+
 func WrapDriver(drv Driver, id, fullname string, opt ...PrinterOption) (Printer, error) {
 	if drv == nil {
 		return nil, errors.New("driver cannot be nil")
@@ -124,16 +215,59 @@ func WrapDriver(drv Driver, id, fullname string, opt ...PrinterOption) (Printer,
 		Drv:      drv,
 		// Default filter: PWG/URF raster streams are decoded natively,
 		// anything else falls back to ImageMagick. Can be overridden.
-		Filter: &rasterSniffFilter{fallback: &imageMagickFilter{}},
+		Filter:   NewFilter(),
+		Fit:      FitWidth,
+		TextFont: fontmgr.DefaultFont,
+		Energy:   DefaultEnergy,
+
+		BlankThreshold: DefaultBlankThreshold,
 	}
 	for _, o := range opt {
 		if err := o(p); err != nil {
 			return nil, fmt.Errorf("failed to apply printer option: %w", err)
 		}
 	}
+	if s, ok := drv.(thermoprint.StatusSubscriber); ok {
+		s.Subscribe(p.setStatus)
+	}
 	return p, nil
 }
 
+// setStatus records the status reported by the driver, and logs the
+// changes that need attention.
+func (p *basePrinter) setStatus(st thermoprint.Status) {
+	p.statusMu.Lock()
+	prev := p.status
+	p.status = &st
+	p.statusMu.Unlock()
+	if prev == nil || *prev != st {
+		p.notify()
+	}
+
+	if st.NoPaper && (prev == nil || !prev.NoPaper) {
+		slog.Warn("printer is out of paper", "printer", p.ID)
+	}
+	if st.BatteryLow() && (prev == nil || !prev.BatteryLow()) {
+		slog.Warn("printer battery is low", "printer", p.ID, "level", st.BatteryLevel)
+	}
+}
+
+// statusReporter is implemented by the printers, that keep the status
+// reported by the driver.
+type statusReporter interface {
+	lastStatus() (thermoprint.Status, bool)
+}
+
+// lastStatus returns the last status reported by the driver.
+func (p *basePrinter) lastStatus() (thermoprint.Status, bool) {
+	p.statusMu.RLock()
+	defer p.statusMu.RUnlock()
+	if p.status == nil {
+		return thermoprint.Status{}, false
+	}
+	return *p.status, true
+}
+
 func (p *basePrinter) Name() string {
 	return p.ID
 }
@@ -161,6 +295,124 @@ func (p *basePrinter) State() PrinterState {
 	return p.state
 }
 
+// PrinterStateReason is a keyword value of the printer-state-reasons
+// attribute, see RFC 8011, section 5.4.12.
+type PrinterStateReason string
+
+const (
+	PSRNone       PrinterStateReason = "none"
+	PSRMediaLow   PrinterStateReason = "media-low-warning"
+	PSRMediaEmpty PrinterStateReason = "media-empty-error"
+	// PSRBatteryLow is not registered with IANA, clients that don't know
+	// it treat it as a warning.
+	PSRBatteryLow PrinterStateReason = "battery-low-warning"
+)
+
+// StateReasoner is implemented by printers that can explain their current
+// state (printer-state-reasons attribute).
+type StateReasoner interface {
+	StateReasons() []PrinterStateReason
+}
+
+// snapshotter is implemented by drivers that report the device status, i.e.
+// [thermoprint.LXD02].
+type snapshotter interface {
+	Snapshot() thermoprint.PrinterSnapshot
+}
+
+// StateReasons returns the reasons from the status that the driver reported
+// last, if it reports the status as it changes, see
+// [thermoprint.StatusSubscriber], and from the driver snapshot.
+func (p *basePrinter) StateReasons() []PrinterStateReason {
+	var (
+		reasons           []PrinterStateReason
+		noPaper, mediaLow bool
+	)
+	if s, ok := p.Drv.(snapshotter); ok {
+		snap := s.Snapshot()
+		noPaper, mediaLow = snap.NoPaper, snap.MediaLow
+	}
+	st, ok := p.lastStatus()
+	if ok {
+		noPaper = noPaper || st.NoPaper
+	}
+	if noPaper {
+		reasons = append(reasons, PSRMediaEmpty)
+	} else if mediaLow {
+		reasons = append(reasons, PSRMediaLow)
+	}
+	if ok && st.BatteryLow() {
+		reasons = append(reasons, PSRBatteryLow)
+	}
+	return reasons
+}
+
+// intervalReporter is implemented by drivers that report the interval
+// between the data packets, i.e. [thermoprint.LXD02].
+type intervalReporter interface {
+	PrintInterval() time.Duration
+}
+
+// printInterval returns the print interval, that the driver is configured
+// with, or the interval of the normal quality profile, if the driver does not
+// report it.
+func (p *basePrinter) printInterval() time.Duration {
+	if ir, ok := p.Drv.(intervalReporter); ok {
+		return ir.PrintInterval()
+	}
+	return cmp.Or(p.Qualities[QualityNormal].Interval, thermoprint.DefaultPrintDelay)
+}
+
+// AlertReporter is implemented by printers that keep the history of device
+// alerts (printer-alert attribute), and by the drivers, that the printer
+// takes them from, i.e. [thermoprint.LXD02].
+type AlertReporter interface {
+	Alerts() []thermoprint.Alert
+}
+
+func (p *basePrinter) Alerts() []thermoprint.Alert {
+	if a, ok := p.Drv.(AlertReporter); ok {
+		return a.Alerts()
+	}
+	return nil
+}
+
+func (p *basePrinter) UnknownNotifications() []thermoprint.UnknownNotification {
+	if c, ok := p.Drv.(thermoprint.NotificationCapturer); ok {
+		return c.UnknownNotifications()
+	}
+	return nil
+}
+
+// printerNotifications returns the unknown notifications captured by the
+// printer driver, oldest first, or nil, if the driver does not capture them.
+func printerNotifications(p Printer) []thermoprint.UnknownNotification {
+	if c, ok := p.(thermoprint.NotificationCapturer); ok {
+		return c.UnknownNotifications()
+	}
+	return nil
+}
+
+// printerAlerts returns the printer alerts, oldest first, or nil, if the
+// printer does not report any.
+func printerAlerts(p Printer) []thermoprint.Alert {
+	if ar, ok := p.(AlertReporter); ok {
+		return ar.Alerts()
+	}
+	return nil
+}
+
+// stateReasons returns the printer state reasons, or [PSRNone], if the
+// printer does not report any.
+func stateReasons(p Printer) []PrinterStateReason {
+	if sr, ok := p.(StateReasoner); ok {
+		if reasons := sr.StateReasons(); len(reasons) > 0 {
+			return reasons
+		}
+	}
+	return []PrinterStateReason{PSRNone}
+}
+
 func (p *basePrinter) Ready() bool {
 	return true
 }
@@ -215,6 +467,35 @@ type PrintOptions struct {
 	// TrimTrailingBlank removes trailing blank rows for variable-height roll
 	// jobs so the printer stops after the visible content.
 	TrimTrailingBlank bool
+	// Progress, if set, is called with the number of pages (sheets) of the
+	// job printed so far and the total number of pages, as the data is
+	// sent to the printer.
+	Progress func(completed, total int)
+	// Pages selects the pages of the document to print, all pages are
+	// printed if it is empty.
+	Pages PageRanges
+	// Fit is the policy for the pages wider than the printer, the printer
+	// default is used if it is empty.
+	Fit Fit
+	// Format is the document-format of the job data, if the client provided
+	// it.  Plain text is printed with the text font of the printer.
+	Format string
+	// Origin is the origin of the job, that is printed in the footer, if the
+	// printer has it enabled, see [WithJobFooter].
+	Origin JobOrigin
+	// Orientation is the orientation the pages are rotated to, the pages
+	// are printed as is, if it is zero.
+	Orientation Orientation
+	// Quality selects the thermal energy of the job, the printer energy is
+	// used, if it is zero.
+	Quality Quality
+	// Lang is the natural language of the job, the page headers and the
+	// footer are printed in it, if there is the catalog for it, see
+	// [locale.Lookup], or in the default language otherwise.
+	Lang string
+	// Dither is the name of the dither function of the job, the printer
+	// dither function is used, if it is empty, see [WithDither].
+	Dither string
 }
 
 // OptionPrinter is implemented by printers that can honor per-job print
@@ -225,6 +506,15 @@ type OptionPrinter interface {
 
 type printJobOptions struct {
 	trimTrailingBlank bool
+	progress          func(completed, total int)
+	pages             PageRanges
+	fit               Fit
+	format            string
+	origin            JobOrigin
+	orientation       Orientation
+	quality           Quality
+	lang              string
+	dither            string
 }
 
 func (p *basePrinter) Print(ctx context.Context, data []byte) error {
@@ -232,7 +522,7 @@ func (p *basePrinter) Print(ctx context.Context, data []byte) error {
 }
 
 func (p *basePrinter) PrintWithOptions(ctx context.Context, data []byte, opts PrintOptions) error {
-	return p.print(ctx, data, printJobOptions{trimTrailingBlank: opts.TrimTrailingBlank})
+	return p.print(ctx, data, printJobOptions{trimTrailingBlank: opts.TrimTrailingBlank, progress: opts.Progress, pages: opts.Pages, fit: opts.Fit, format: opts.Format, origin: opts.Origin, orientation: opts.Orientation, quality: opts.Quality, lang: opts.Lang, dither: opts.Dither})
 }
 
 func (p *basePrinter) print(ctx context.Context, data []byte, opts printJobOptions) error {
@@ -246,58 +536,179 @@ func (p *basePrinter) print(ctx context.Context, data []byte, opts printJobOptio
 		return ErrEmptyData
 	}
 
-	// try decoding the data as an image
-	if img, _, err := image.Decode(bytes.NewReader(data)); err == nil {
-		// fast path for images
-		return p.printImage(ctx, img, opts)
-	}
+	fit := cmp.Or(opts.fit, p.Fit, FitWidth)
+	footer := p.JobFooter && !opts.origin.IsZero()
+	cat := jobCatalog(opts.lang)
+	dither := cmp.Or(opts.dither, p.qualityProfile(opts.quality).Dither)
 
-	// slow path for other data formats
-	// multiple formats can be supported, such as PostScript, PDF, etc.
-	images, err := p.Filter.ToRaster(ctx, int(p.Drv.DPI()), data)
-	if err != nil {
-		slog.Error("images", "len", len(images), "err", err)
-		return fmt.Errorf("failed to convert data: %w", err)
-	}
-	if len(images) == 0 {
-		return ErrNoImages
+	var images []image.Image
+	if isPlainText(opts.format, data) {
+		pages, err := plainTextPages(data, opts.format, p.Drv.Width(), p.TextFont, opts.pages)
+		if err != nil {
+			return fmt.Errorf("failed to render text: %w", err)
+		}
+		if len(pages) == 0 {
+			if len(opts.pages) > 0 {
+				return ErrNoPages
+			}
+			return ErrBlankDocument
+		}
+		slog.Debug("rendered plain text", "pages", len(pages), "page_ranges", opts.pages)
+		images = pages
+	} else if img, _, err := image.Decode(bytes.NewReader(data)); err == nil {
+		// the data is an image
+		if !opts.pages.Contains(1) {
+			return ErrNoPages
+		}
+		img = opts.orientation.Rotate(img)
+		if fit == FitWidth && !footer && dither == p.Dither {
+			// fast path for images, the driver scales and dithers them.
+			return p.printImage(ctx, p.Channel.Gray(img), opts, []int{img.Bounds().Dy()})
+		}
+		images = []image.Image{img}
+	} else {
+		// slow path for other data formats
+		// multiple formats can be supported, such as PostScript, PDF, etc.
+		images, err = ToRasterPages(ctx, p.Filter, int(p.Drv.DPI()), data, opts.pages)
+		if err != nil {
+			slog.Error("images", "len", len(images), "err", err)
+			return fmt.Errorf("failed to convert data: %w", err)
+		}
+		if len(images) == 0 {
+			if len(opts.pages) > 0 {
+				return ErrNoPages
+			}
+			return ErrNoImages
+		}
+		slog.Debug("converted source document", "pages", len(images), "dpi", p.Drv.DPI(), "page_ranges", opts.pages)
+		var skipped []int
+		if images, skipped = SkipBlankPages(images, p.BlankThreshold); len(skipped) > 0 {
+			slog.InfoContext(ctx, "skipping blank pages", "pages", skipped)
+		}
+		if len(images) == 0 {
+			return ErrBlankDocument
+		}
 	}
-	slog.Debug("converted source document", "pages", len(images), "dpi", p.Drv.DPI())
 
 	// combine all pages into a long image.
-	c := bitmap.NewComposer(p.Drv.Width(), bitmap.WithComposerDitherFunc(bitmap.DitherDefault))
-	for _, img := range images {
-		if bitmap.IsDocument(img, 50, 200) {
-			c.AppendImageDither(img, bitmap.DitherThresholdFn(128))
-		} else {
-			c.AppendImage(img)
+	dfn, ok := bitmap.DitherFunction(dither)
+	if !ok {
+		return fmt.Errorf("unknown dither function: %s", dither)
+	}
+	c := bitmap.NewComposer(p.Drv.Width(), bitmap.WithComposerDitherFunc(dfn))
+	// the text of the mixed pages is binarised, only the photos are dithered.
+	rdfn := p.Channel.Dither(bitmap.RegionDither(dfn, bitmap.DefaultThreshold))
+	bottoms := make([]int, 0, len(images))
+	for i, page := range images {
+		page = opts.orientation.Rotate(page)
+		if p.PageNumbers && len(images) > 1 {
+			c.AppendLabelHeader(cat.Sprintf("page %d/%d", i+1, len(images)))
 		}
+		for _, img := range FitPages([]image.Image{page}, p.Drv.Width(), fit) {
+			c.AppendImageDither(img, rdfn)
+		}
+		bottoms = append(bottoms, c.Image().Bounds().Dy())
+	}
+	if footer {
+		if err := c.AppendFooter(opts.origin.Format(cat)); err != nil {
+			return fmt.Errorf("failed to add the job footer: %w", err)
+		}
+		bottoms[len(bottoms)-1] = c.Image().Bounds().Dy()
 	}
 	// print the image.
 	img := c.Image()
-	return p.printImage(ctx, img, opts)
+	return p.printImage(ctx, img, opts, bottoms)
 }
 
-func (p *basePrinter) printImage(ctx context.Context, img image.Image, opts printJobOptions) error {
+// printImage prints the image, that consists of pages ending at the bottoms
+// rows.
+func (p *basePrinter) printImage(ctx context.Context, img image.Image, opts printJobOptions, bottoms []int) error {
 	if opts.trimTrailingBlank {
 		img = trimTrailingBlankRows(img)
 	}
+	if opts.progress != nil {
+		sc := &sheetCounter{bottoms: bottoms, report: opts.progress}
+		if err := p.Drv.SetOptions(thermoprint.WithProgress(sc.update)); err != nil {
+			slog.WarnContext(ctx, "print progress is not reported", "error", err)
+		} else {
+			defer p.Drv.SetOptions(thermoprint.WithProgress(nil))
+		}
+	}
+	qp := p.qualityProfile(opts.quality)
+	if qp.Energy != p.Energy {
+		if err := p.Drv.SetOptions(thermoprint.WithEnergy(qp.Energy)); err != nil {
+			slog.WarnContext(ctx, "print-quality is not applied", "error", err)
+		} else {
+			defer p.Drv.SetOptions(thermoprint.WithEnergy(p.Energy))
+		}
+	}
+	if interval := p.printInterval(); qp.Interval != 0 && qp.Interval != interval {
+		if err := p.Drv.SetOptions(thermoprint.WithPrintInterval(qp.Interval)); err != nil {
+			slog.WarnContext(ctx, "print-quality interval is not applied", "error", err)
+		} else {
+			defer p.Drv.SetOptions(thermoprint.WithPrintInterval(interval))
+		}
+	}
 	if err := p.Drv.PrintImage(ctx, img); err != nil {
 		return fmt.Errorf("failed to print image: %w", err)
 	}
 	return nil
 }
 
+// jobCatalog returns the message catalog of the job language, or the
+// default one, if the language is not set or not supported.
+func jobCatalog(lang string) *locale.Catalog {
+	if lang == "" {
+		return locale.Default()
+	}
+	c, err := locale.Lookup(lang)
+	if err != nil {
+		slog.Debug("job language is not supported, using the default", "lang", lang)
+		return locale.Default()
+	}
+	return c
+}
+
 func printWithOptions(ctx context.Context, p Printer, data []byte, opts printJobOptions) error {
 	if p, ok := p.(OptionPrinter); ok {
-		return p.PrintWithOptions(ctx, data, PrintOptions{TrimTrailingBlank: opts.trimTrailingBlank})
+		return p.PrintWithOptions(ctx, data, PrintOptions{TrimTrailingBlank: opts.trimTrailingBlank, Progress: opts.progress, Pages: opts.pages, Fit: opts.fit, Format: opts.format, Origin: opts.origin, Orientation: opts.orientation, Quality: opts.quality, Lang: opts.lang, Dither: opts.dither})
 	}
-	if opts.trimTrailingBlank {
+	if opts.trimTrailingBlank || len(opts.pages) > 0 || opts.fit != "" || opts.orientation != 0 || opts.quality != 0 || opts.dither != "" {
 		return ErrPrintOptionsUnsupported
 	}
 	return p.Print(ctx, data)
 }
 
+// sheetCounter converts the print progress in packets to the number of
+// printed pages.
+type sheetCounter struct {
+	bottoms []int // bottom row of each page
+	report  func(completed, total int)
+
+	mu        sync.Mutex
+	completed int
+}
+
+// update is the [thermoprint.WithProgress] callback, it reports the number
+// of pages, that are sent completely, when it changes.
+func (sc *sheetCounter) update(sent, total int) {
+	if total <= 0 || len(sc.bottoms) == 0 {
+		return
+	}
+	completed := len(sc.bottoms)
+	if sent < total {
+		row := sc.bottoms[len(sc.bottoms)-1] * sent / total
+		completed, _ = slices.BinarySearch(sc.bottoms, row+1)
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if completed == sc.completed {
+		return
+	}
+	sc.completed = completed
+	sc.report(completed, len(sc.bottoms))
+}
+
 func trimTrailingBlankRows(img image.Image) image.Image {
 	b := img.Bounds()
 	if b.Empty() || b.Dy() <= 1 {
@@ -349,6 +760,27 @@ func colorToWhiteBackgroundGray(c color.Color) uint8 {
 
 func (p *basePrinter) SetState(state PrinterState) {
 	p.stateMu.Lock()
-	defer p.stateMu.Unlock()
+	prev := p.state
 	p.state = state
+	p.stateMu.Unlock()
+	if prev != state {
+		p.notify()
+	}
+}
+
+// notifyChanges sets the function, that is called after the state or the
+// status of the printer changes.
+func (p *basePrinter) notifyChanges(fn func()) {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+	p.changed = fn
+}
+
+func (p *basePrinter) notify() {
+	p.stateMu.RLock()
+	changed := p.changed
+	p.stateMu.RUnlock()
+	if changed != nil {
+		changed()
+	}
 }
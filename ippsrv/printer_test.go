@@ -8,9 +8,12 @@ import (
 	"image/png"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/OpenPrinting/goipp"
 	"github.com/rusq/thermoprint"
+	"github.com/rusq/thermoprint/bitmap"
+	"github.com/rusq/thermoprint/printers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -151,7 +154,7 @@ func TestPrintJobMediaTrimGating(t *testing.T) {
 			driver := &captureDriver{}
 			p, err := WrapDriver(driver, "test-printer", "Test Printer")
 			require.NoError(t, err)
-			s, err := newBasicIPPServer("/printers/", p)
+			s, err := newBasicIPPServer("/printers/", "", p)
 			require.NoError(t, err)
 			t.Cleanup(func() {
 				require.NoError(t, s.Shutdown(context.Background()))
@@ -163,6 +166,7 @@ func TestPrintJobMediaTrimGating(t *testing.T) {
 			}
 			_, err = s.handlePrintJob(context.Background(), req, mustPNG(t, tt.img))
 			require.NoError(t, err)
+			waitJobs(t, s)
 
 			assert.Equal(t, tt.wantDY, driver.printedBounds().Dy())
 		})
@@ -252,3 +256,52 @@ func (p optionlessPrinter) Print(ctx context.Context, data []byte) error {
 	}
 	return p.driver.PrintImage(ctx, img)
 }
+
+func TestSheetCounter(t *testing.T) {
+	var got [][2]int
+	sc := &sheetCounter{bottoms: []int{100, 150, 300}, report: func(completed, total int) {
+		got = append(got, [2]int{completed, total})
+	}}
+	for _, sent := range []int{1, 10, 33, 34, 50, 60, 99, 100} {
+		sc.update(sent, 100)
+	}
+	// rows: 3, 30, 99, 102, 150, 180, 297, done
+	assert.Equal(t, [][2]int{{1, 3}, {2, 3}, {3, 3}}, got)
+}
+
+func TestPrintWithOptionsReportsProgress(t *testing.T) {
+	drv, err := printers.NewMock(t.Context(), printers.MockOptions{}, thermoprint.WithPrintInterval(time.Millisecond))
+	require.NoError(t, err)
+	t.Cleanup(func() { drv.Disconnect() })
+	p, err := WrapDriver(drv, "mock-printer", "Mock Printer")
+	require.NoError(t, err)
+
+	var (
+		mu   sync.Mutex
+		last [2]int
+	)
+	progress := func(completed, total int) {
+		mu.Lock()
+		defer mu.Unlock()
+		last = [2]int{completed, total}
+	}
+	img := testPrintImage(t, 384, 40, map[image.Point]color.Color{image.Pt(0, 1): color.Black})
+	require.NoError(t, printWithOptions(t.Context(), p, mustPNG(t, img), printJobOptions{progress: progress}))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, [2]int{1, 1}, last)
+}
+
+func TestPrintPageNumbers(t *testing.T) {
+	driver := &captureDriver{}
+	p, err := WrapDriver(driver, "test-printer", "Test Printer", WithFilter(pagesFilter{10, 20, 30}), WithPageNumbers(true))
+	require.NoError(t, err)
+	require.NoError(t, p.Print(context.Background(), []byte("%PDF-1.7")))
+	assert.Equal(t, 60+3*bitmap.PageHeader(384, 1, 3).Bounds().Dy(), driver.printedBounds().Dy(), "each page has a header")
+
+	p, err = WrapDriver(driver, "test-printer", "Test Printer", WithFilter(pagesFilter{10}), WithPageNumbers(true))
+	require.NoError(t, err)
+	require.NoError(t, p.Print(context.Background(), []byte("%PDF-1.7")))
+	assert.Equal(t, 10, driver.printedBounds().Dy(), "single page has no header")
+}
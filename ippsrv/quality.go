@@ -0,0 +1,218 @@
+package ippsrv
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenPrinting/goipp"
+
+	"github.com/rusq/thermoprint/bitmap"
+)
+
+// Quality is the print-quality job template attribute value, RFC 8011,
+// section 5.2.13.  It selects the thermal energy of the job, and the other
+// settings of the quality profile, see [WithQualityProfiles].
+type Quality int
+
+const (
+	QualityDraft  Quality = 3
+	QualityNormal Quality = 4
+	QualityHigh   Quality = 5
+)
+
+// DefaultEnergy is the thermal energy level of the normal quality jobs, if
+// the printer is not configured with [WithEnergy].
+const DefaultEnergy = 2
+
+// energy levels of the driver.
+const (
+	minEnergy = 1
+	maxEnergy = 6
+)
+
+var qualityNames = map[string]Quality{
+	"draft":  QualityDraft,
+	"normal": QualityNormal,
+	"high":   QualityHigh,
+}
+
+// ParseQuality returns the quality of the name: "draft", "normal" or "high".
+func ParseQuality(s string) (Quality, error) {
+	q, ok := qualityNames[strings.ToLower(strings.TrimSpace(s))]
+	if !ok {
+		return 0, fmt.Errorf("unknown print quality %q, must be one of: draft, normal, high", s)
+	}
+	return q, nil
+}
+
+// String returns the name of the quality.
+func (q Quality) String() string {
+	for name, v := range qualityNames {
+		if v == q {
+			return name
+		}
+	}
+	return "Quality(" + strconv.Itoa(int(q)) + ")"
+}
+
+// QualityProfile is the settings of the jobs of the print-quality.  The
+// zero fields are the printer settings.
+type QualityProfile struct {
+	// Energy is the thermal energy level, zero is relative to the normal
+	// quality, see [Quality.Energy].
+	Energy uint8
+	// Dither is the name of the dither function, the job may select another
+	// one with thermoprint-dither.
+	Dither string
+	// Interval is the interval between the data packets: the longer one
+	// gives the print head more time to heat and prints darker.
+	Interval time.Duration
+}
+
+// QualityProfiles are the settings of the print-quality values.
+type QualityProfiles map[Quality]QualityProfile
+
+// ParseQualityProfiles parses the profiles, separated with semicolons: the
+// quality, the colon, and the comma separated settings, i.e.
+//
+//	draft:energy=1,dither=threshold;high:energy=4,dither=atkinson,interval=12ms
+func ParseQualityProfiles(s string) (QualityProfiles, error) {
+	pp := make(QualityProfiles)
+	for spec := range strings.SplitSeq(s, ";") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		name, settings, _ := strings.Cut(spec, ":")
+		q, err := ParseQuality(name)
+		if err != nil {
+			return nil, err
+		}
+		var qp QualityProfile
+		for kv := range strings.SplitSeq(settings, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(kv), "=")
+			if !ok {
+				return nil, fmt.Errorf("%s: invalid setting %q, expected key=value", q, kv)
+			}
+			switch strings.TrimSpace(key) {
+			case "energy":
+				n, err := strconv.ParseUint(value, 10, 8)
+				if err != nil || n < minEnergy || n > maxEnergy {
+					return nil, fmt.Errorf("%s: invalid energy %q, want %d-%d", q, value, minEnergy, maxEnergy)
+				}
+				qp.Energy = uint8(n)
+			case "dither":
+				if _, err := bitmap.ParseDither(value); err != nil {
+					return nil, fmt.Errorf("%s: %w", q, err)
+				}
+				qp.Dither = bitmap.DitherName(value)
+			case "interval":
+				d, err := time.ParseDuration(value)
+				if err != nil || d <= 0 {
+					return nil, fmt.Errorf("%s: invalid interval %q", q, value)
+				}
+				qp.Interval = d
+			default:
+				return nil, fmt.Errorf("%s: unknown setting %q, must be one of: energy, dither, interval", q, key)
+			}
+		}
+		pp[q] = qp
+	}
+	return pp, nil
+}
+
+// String returns the profiles in the format of [ParseQualityProfiles].
+func (pp QualityProfiles) String() string {
+	var specs []string
+	for _, q := range slices.Sorted(maps.Keys(pp)) {
+		qp := pp[q]
+		var settings []string
+		if qp.Energy != 0 {
+			settings = append(settings, "energy="+strconv.Itoa(int(qp.Energy)))
+		}
+		if qp.Dither != "" {
+			settings = append(settings, "dither="+qp.Dither)
+		}
+		if qp.Interval != 0 {
+			settings = append(settings, "interval="+qp.Interval.String())
+		}
+		specs = append(specs, q.String()+":"+strings.Join(settings, ","))
+	}
+	return strings.Join(specs, ";")
+}
+
+// WithQualityProfiles sets the settings of the print-quality values, so
+// that the client's choice of the quality changes not only the energy, but
+// the dither function and the speed of the printing.  The normal quality
+// profile applies to the jobs without print-quality.
+func WithQualityProfiles(pp QualityProfiles) PrinterOption {
+	return func(p *basePrinter) error {
+		for q, qp := range pp {
+			if !slices.Contains(qualitiesSupported, q) {
+				return fmt.Errorf("unsupported print quality %d", q)
+			}
+			if qp.Energy > maxEnergy {
+				return fmt.Errorf("%s: energy level %d is out of range 0-%d", q, qp.Energy, maxEnergy)
+			}
+			if _, err := bitmap.ParseDither(qp.Dither); err != nil {
+				return fmt.Errorf("%s: %w", q, err)
+			}
+		}
+		p.Qualities = maps.Clone(pp)
+		return nil
+	}
+}
+
+// qualityProfile returns the settings of the quality, the missing ones are
+// filled in: the energy is relative to the normal quality, the dither
+// function is the printer's.  The zero quality is normal.
+func (p *basePrinter) qualityProfile(q Quality) QualityProfile {
+	q = cmp.Or(q, QualityNormal)
+	normal := p.Qualities[QualityNormal]
+	qp := p.Qualities[q]
+	qp.Energy = cmp.Or(qp.Energy, q.Energy(cmp.Or(normal.Energy, p.Energy)))
+	qp.Dither = cmp.Or(qp.Dither, p.Dither)
+	return qp
+}
+
+// qualitiesSupported are the print-quality values in the order they are
+// advertised.
+var qualitiesSupported = []Quality{QualityDraft, QualityNormal, QualityHigh}
+
+// requestQuality returns the print-quality job template attribute of the
+// request, or zero, if the client did not send it.
+func requestQuality(req *goipp.Message) (Quality, error) {
+	v, ok := jobTemplateValue(req, "print-quality")
+	if !ok {
+		return 0, nil
+	}
+	n, ok := v.(goipp.Integer)
+	if !ok {
+		return 0, fmt.Errorf("print-quality: unexpected value %q", v.String())
+	}
+	q := Quality(n)
+	switch q {
+	case QualityDraft, QualityNormal, QualityHigh:
+		return q, nil
+	}
+	return 0, fmt.Errorf("print-quality: unsupported value %d", n)
+}
+
+// Energy returns the thermal energy level for the quality, given the level
+// of the normal quality: the draft is printed one level lighter, the high
+// quality — one level darker.
+func (q Quality) Energy(normal uint8) uint8 {
+	level := int(normal)
+	switch q {
+	case QualityDraft:
+		level--
+	case QualityHigh:
+		level++
+	}
+	return uint8(max(min(level, maxEnergy), minEnergy))
+}
@@ -0,0 +1,139 @@
+package ippsrv
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+	"time"
+
+	"github.com/OpenPrinting/goipp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rusq/thermoprint"
+	"github.com/rusq/thermoprint/bitmap"
+)
+
+func TestQualityEnergy(t *testing.T) {
+	assert.Equal(t, uint8(1), QualityDraft.Energy(2))
+	assert.Equal(t, uint8(2), QualityNormal.Energy(2))
+	assert.Equal(t, uint8(3), QualityHigh.Energy(2))
+	assert.Equal(t, uint8(1), QualityDraft.Energy(0), "the lowest level is 1")
+	assert.Equal(t, uint8(6), QualityHigh.Energy(6), "the highest level is 6")
+}
+
+func TestRequestQuality(t *testing.T) {
+	req := newIPPRequest(goipp.OpPrintJob, testRequestID)
+	got, err := requestQuality(req)
+	require.NoError(t, err)
+	assert.Zero(t, got)
+
+	req.Job.Add(goipp.MakeAttribute("print-quality", goipp.TagEnum, goipp.Integer(5)))
+	got, err = requestQuality(req)
+	require.NoError(t, err)
+	assert.Equal(t, QualityHigh, got)
+
+	req = newIPPRequest(goipp.OpPrintJob, testRequestID)
+	req.Job.Add(goipp.MakeAttribute("print-quality", goipp.TagEnum, goipp.Integer(9)))
+	_, err = requestQuality(req)
+	assert.Error(t, err)
+}
+
+// optionsDriver counts the options set on the driver.
+type optionsDriver struct {
+	captureDriver
+	options int
+}
+
+func (d *optionsDriver) SetOptions(opt ...thermoprint.Option) error {
+	d.options += len(opt)
+	return nil
+}
+
+func TestPrintWithOptionsQuality(t *testing.T) {
+	driver := &optionsDriver{}
+	p, err := WrapDriver(driver, "test-printer", "Test Printer", WithEnergy(3))
+	require.NoError(t, err)
+	op := p.(OptionPrinter)
+	page := mustPNG(t, image.NewGray(image.Rect(0, 0, 10, 10)))
+
+	require.NoError(t, op.PrintWithOptions(context.Background(), page, PrintOptions{Quality: QualityNormal}))
+	assert.Zero(t, driver.options, "normal quality prints with the printer energy")
+	require.NoError(t, op.PrintWithOptions(context.Background(), page, PrintOptions{Quality: QualityHigh}))
+	assert.Equal(t, 2, driver.options, "the energy is set and restored")
+
+	_, err = WrapDriver(driver, "test-printer", "Test Printer", WithEnergy(7))
+	assert.Error(t, err)
+}
+
+func TestParseQualityProfiles(t *testing.T) {
+	pp, err := ParseQualityProfiles("draft:energy=1,dither=Threshold:160; high:energy=4,dither=atkinson,interval=12ms")
+	require.NoError(t, err)
+	assert.Equal(t, QualityProfiles{
+		QualityDraft: {Energy: 1, Dither: "threshold:160"},
+		QualityHigh:  {Energy: 4, Dither: "atkinson", Interval: 12 * time.Millisecond},
+	}, pp)
+	assert.Equal(t, "draft:energy=1,dither=threshold:160;high:energy=4,dither=atkinson,interval=12ms", pp.String())
+
+	for _, s := range []string{
+		"best:energy=1",
+		"draft:energy=9",
+		"draft:dither=smudge",
+		"draft:interval=-1ms",
+		"draft:speed=1",
+		"draft:energy",
+	} {
+		_, err := ParseQualityProfiles(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestWithQualityProfiles(t *testing.T) {
+	_, err := WrapDriver(&captureDriver{}, "test-printer", "Test Printer", WithQualityProfiles(QualityProfiles{7: {}}))
+	assert.Error(t, err)
+	_, err = WrapDriver(&captureDriver{}, "test-printer", "Test Printer", WithQualityProfiles(QualityProfiles{QualityDraft: {Dither: "smudge"}}))
+	assert.Error(t, err)
+}
+
+func TestPrintWithQualityProfile(t *testing.T) {
+	driver := &optionsDriver{}
+	p, err := WrapDriver(driver, "test-printer", "Test Printer", WithEnergy(3), WithQualityProfiles(QualityProfiles{
+		QualityDraft: {Dither: "threshold:160"},
+		QualityHigh:  {Energy: 5, Interval: 12 * time.Millisecond},
+	}))
+	require.NoError(t, err)
+	op := p.(OptionPrinter)
+	// the mid grey is black with the threshold, dithered otherwise.
+	gray := image.NewGray(image.Rect(0, 0, 384, 10))
+	draw.Draw(gray, gray.Bounds(), image.NewUniform(color.Gray{Y: 0x90}), image.Point{}, draw.Src)
+	page := mustPNG(t, gray)
+
+	require.NoError(t, op.PrintWithOptions(context.Background(), page, PrintOptions{Quality: QualityHigh}))
+	assert.Equal(t, 4, driver.options, "the energy and the interval are set and restored")
+
+	driver.options = 0
+	require.NoError(t, op.PrintWithOptions(context.Background(), page, PrintOptions{Quality: QualityDraft}))
+	assert.Equal(t, 2, driver.options, "the energy is set and restored")
+	img := driver.img
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			require.True(t, bitmap.PixelBit(img, x, y, bitmap.DefaultThreshold), "the draft is printed with the threshold at %d,%d", x, y)
+		}
+	}
+}
+
+func TestPrintWithQualityRestoresInterval(t *testing.T) {
+	driver, err := thermoprint.NewVirtualPrinter(t.TempDir(), thermoprint.WithPrintInterval(20*time.Millisecond))
+	require.NoError(t, err)
+	p, err := WrapDriver(driver, "test-printer", "Test Printer", WithQualityProfiles(QualityProfiles{
+		QualityHigh: {Interval: 12 * time.Millisecond},
+	}))
+	require.NoError(t, err)
+	page := mustPNG(t, image.NewGray(image.Rect(0, 0, 10, 10)))
+
+	require.NoError(t, p.(OptionPrinter).PrintWithOptions(context.Background(), page, PrintOptions{Quality: QualityHigh}))
+	assert.Equal(t, 20*time.Millisecond, driver.PrintInterval(), "the driver interval is restored")
+}
@@ -0,0 +1,130 @@
+package ippsrv
+
+// The raw passthrough: the pre-built printer packets, i.e. captured from the
+// vendor app, are sent to the driver as they are, bypassing the conversion
+// and the job queue.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/rusq/thermoprint"
+)
+
+// MaxRawSize is the maximum size of the raw data, 1 MiB is about 2.7 metres
+// of the paper on the 58 mm printer.
+var MaxRawSize int64 = 1 << 20
+
+// errRawUnsupported is returned, if the driver of the printer does not print
+// the raw data, see [thermoprint.RawPrinter].
+var errRawUnsupported = errors.New("printer does not support raw data")
+
+// rawPrinter is implemented by the printers, that send the raw data to the
+// driver.
+type rawPrinter interface {
+	// printRaw validates and prints the raw data, it returns the number of
+	// packets printed.
+	printRaw(ctx context.Context, data []byte) (int, error)
+}
+
+// printRaw prints the raw data with the driver, the jobs wait for it to
+// finish.
+func (p *basePrinter) printRaw(ctx context.Context, data []byte) (int, error) {
+	rp, ok := p.Drv.(thermoprint.RawPrinter)
+	if !ok {
+		return 0, errRawUnsupported
+	}
+	packets, err := rp.SplitRAW(data)
+	if err != nil {
+		return 0, err
+	}
+	p.printMu.Lock()
+	defer p.printMu.Unlock()
+	if err := rp.PrintRAW(ctx, packets); err != nil {
+		return 0, err
+	}
+	return len(packets), nil
+}
+
+// printRaw prints the raw data on the member, that the policy chooses, or on
+// the next one, if it fails.  The members must be of the same model, the
+// packets of one are garbage to the other.
+func (g *Group) printRaw(ctx context.Context, data []byte) (int, error) {
+	var errs error
+	for _, m := range g.order() {
+		rp, ok := m.(rawPrinter)
+		if !ok {
+			continue
+		}
+		n, err := rp.printRaw(ctx, data)
+		if err == nil {
+			slog.InfoContext(ctx, "group raw data printed", "group", g.name, "printer", m.Name())
+			return n, nil
+		}
+		if ctx.Err() != nil || errors.Is(err, thermoprint.ErrInvalidRAW) {
+			return 0, err
+		}
+		if !errors.Is(err, errRawUnsupported) {
+			slog.WarnContext(ctx, "group member failed, trying the next one", "group", g.name, "printer", m.Name(), "error", err)
+		}
+		errs = errors.Join(errs, fmt.Errorf("%s: %w", m.Name(), err))
+	}
+	if errs == nil {
+		return 0, errRawUnsupported
+	}
+	return 0, fmt.Errorf("group %s: all printers failed: %w", g.name, errs)
+}
+
+// apiRawResult is the response of the raw data endpoint.
+type apiRawResult struct {
+	Printer string `json:"printer"`
+	Bytes   int    `json:"bytes"`
+	Packets int    `json:"packets"`
+}
+
+// apiPrintRaw prints the request body, the raw packets, or the raster lines,
+// that fill them, on the printer, and responds, once they are printed.
+func (s *Server) apiPrintRaw(w http.ResponseWriter, r *http.Request) {
+	p, ok := s.apiPrinterFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if s.is.draining.Load() {
+		apiErrorf(w, http.StatusServiceUnavailable, "printer %s: %v", p.Name(), errDraining)
+		return
+	}
+	rp, ok := p.(rawPrinter)
+	if !ok {
+		apiErrorf(w, http.StatusNotImplemented, "printer %s: %v", p.Name(), errRawUnsupported)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxRawSize))
+	if err != nil {
+		if mbe := new(http.MaxBytesError); errors.As(err, &mbe) {
+			apiErrorf(w, http.StatusRequestEntityTooLarge, "raw data exceeds %d bytes", mbe.Limit)
+			return
+		}
+		apiErrorf(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	n, err := rp.printRaw(r.Context(), data)
+	if err != nil {
+		switch {
+		case errors.Is(err, errRawUnsupported):
+			apiErrorf(w, http.StatusNotImplemented, "printer %s: %v", p.Name(), err)
+		case errors.Is(err, thermoprint.ErrInvalidRAW):
+			apiErrorf(w, http.StatusBadRequest, "%v", err)
+		case errors.Is(err, thermoprint.ErrBusy):
+			apiErrorf(w, http.StatusConflict, "printer %s: %v", p.Name(), err)
+		default:
+			apiErrorf(w, http.StatusInternalServerError, "printer %s: %v", p.Name(), err)
+		}
+		return
+	}
+	slog.InfoContext(r.Context(), "raw data printed", "printer", p.Name(), "bytes", len(data), "packets", n, "user", authUser(r.Context()))
+	writeJSON(w, http.StatusOK, apiRawResult{Printer: p.Name(), Bytes: len(data), Packets: n})
+}
@@ -0,0 +1,148 @@
+package ippsrv
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rusq/thermoprint"
+)
+
+// rawDriver is a test driver, that prints the raw data of the LX-D02 packet
+// size.
+type rawDriver struct {
+	testDriver
+	err error
+
+	mu      sync.Mutex
+	packets [][]byte
+}
+
+func (d *rawDriver) SplitRAW(data []byte) ([][]byte, error) {
+	return thermoprint.LXD02Rasteriser.Split(data)
+}
+
+func (d *rawDriver) PrintRAW(ctx context.Context, data [][]byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.packets = append(d.packets, data...)
+	return d.err
+}
+
+// rawPacket returns the LX-D02 packet with the index and the data byte.
+func rawPacket(i int, b byte) []byte {
+	return slices.Concat([]byte{0x55, byte(i >> 8), byte(i)}, bytes.Repeat([]byte{b}, 96), []byte{0x00})
+}
+
+func newRawRequest(printer string, data []byte) *http.Request {
+	return httptest.NewRequest(http.MethodPost, apiPrefix+"/printers/"+printer+"/raw", bytes.NewReader(data))
+}
+
+func TestAPIPrintRaw(t *testing.T) {
+	drv := &rawDriver{}
+	s := newAPIServer(t, drv)
+
+	var res apiRawResult
+	data := slices.Concat(rawPacket(0, 0xff), rawPacket(1, 0x0f))
+	rec := serveAPI(t, s, newRawRequest("test-printer", data), &res)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, apiRawResult{Printer: "test-printer", Bytes: 200, Packets: 2}, res)
+	assert.Equal(t, [][]byte{bytes.Repeat([]byte{0xff}, 96), bytes.Repeat([]byte{0x0f}, 96)}, drv.packets, "the packets are unframed")
+
+	drv.packets = nil
+	rec = serveAPI(t, s, newRawRequest("test-printer", make([]byte, 48*4)), &res)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, 2, res.Packets, "the raster lines fill the packets")
+	assert.Len(t, drv.packets, 2)
+}
+
+func TestAPIPrintRawErrors(t *testing.T) {
+	t.Run("invalid", func(t *testing.T) {
+		s := newAPIServer(t, &rawDriver{})
+		for _, data := range [][]byte{nil, make([]byte, 95), slices.Concat(rawPacket(0, 0xff), rawPacket(0, 0xff)[:99])} {
+			var e apiError
+			rec := serveAPI(t, s, newRawRequest("test-printer", data), &e)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, e.Error, thermoprint.ErrInvalidRAW.Error())
+		}
+	})
+	t.Run("too large", func(t *testing.T) {
+		s := newAPIServer(t, &rawDriver{})
+		rec := serveAPI(t, s, newRawRequest("test-printer", make([]byte, MaxRawSize+96)), nil)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+	t.Run("unsupported", func(t *testing.T) {
+		s := newAPIServer(t, testDriver{})
+		rec := serveAPI(t, s, newRawRequest("test-printer", make([]byte, 96)), nil)
+		assert.Equal(t, http.StatusNotImplemented, rec.Code)
+	})
+	t.Run("not found", func(t *testing.T) {
+		s := newAPIServer(t, &rawDriver{})
+		rec := serveAPI(t, s, newRawRequest("nonexistent", make([]byte, 96)), nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+	t.Run("draining", func(t *testing.T) {
+		drv := &rawDriver{}
+		s := newAPIServer(t, drv)
+		s.is.draining.Store(true)
+		rec := serveAPI(t, s, newRawRequest("test-printer", make([]byte, 96)), nil)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Empty(t, drv.packets)
+	})
+	t.Run("busy", func(t *testing.T) {
+		s := newAPIServer(t, &rawDriver{err: thermoprint.ErrBusy})
+		rec := serveAPI(t, s, newRawRequest("test-printer", make([]byte, 96)), nil)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+	t.Run("cross-origin", func(t *testing.T) {
+		drv := &rawDriver{}
+		s := newAPIServer(t, drv)
+		r := newRawRequest("test-printer", make([]byte, 96))
+		r.Header.Set("Origin", "http://evil.example")
+		rec := serveAPI(t, s, r, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Empty(t, drv.packets)
+	})
+	t.Run("auth", func(t *testing.T) {
+		users := make(Users)
+		users.Add("alice", "secret")
+		s := newAPIServer(t, &rawDriver{}, WithBasicAuth(users, AuthAll))
+		rec := httptest.NewRecorder()
+		s.srv.Handler.ServeHTTP(rec, newRawRequest("test-printer", make([]byte, 96)))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestGroupPrintRaw(t *testing.T) {
+	down := &rawDriver{err: errors.New("disconnected")}
+	up := &rawDriver{}
+	g, err := NewGroup("group", "Group", GroupFailover,
+		mustWrapDriver(t, testDriver{}, "plain", "Plain"),
+		mustWrapDriver(t, down, "down", "Down"),
+		mustWrapDriver(t, up, "up", "Up"),
+	)
+	require.NoError(t, err)
+
+	n, err := g.printRaw(context.Background(), make([]byte, 96))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Len(t, down.packets, 1, "the failed member is tried first")
+	assert.Len(t, up.packets, 1)
+
+	_, err = g.printRaw(context.Background(), make([]byte, 95))
+	assert.ErrorIs(t, err, thermoprint.ErrInvalidRAW)
+	assert.Len(t, up.packets, 1, "the invalid data are not printed")
+
+	g, err = NewGroup("group", "Group", GroupFailover, mustWrapDriver(t, testDriver{}, "plain", "Plain"))
+	require.NoError(t, err)
+	_, err = g.printRaw(context.Background(), make([]byte, 96))
+	assert.ErrorIs(t, err, errRawUnsupported)
+}
@@ -0,0 +1,250 @@
+package ippsrv
+
+// The client side of the thermoprint servers on the network: the printers
+// are discovered with DNS-SD, and the jobs are submitted with the REST API.
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/textproto"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/brutella/dnssd"
+)
+
+// thermoprintMFG is the manufacturer in the TXT record of the printers, by
+// which the thermoprint servers are told from the other IPP printers.
+const thermoprintMFG = "Thermoprint"
+
+// RemotePrinter is the printer of the thermoprint server on the network.
+type RemotePrinter struct {
+	Name         string   // the DNS-SD instance name, i.e. "LX-D02 Thermal Printer"
+	Printer      string   // the printer name on the server, i.e. "default"
+	MakeAndModel string   // the make and model of the printer
+	Host         string   // the mDNS host name, i.e. "kitchen.local."
+	IPs          []net.IP // the addresses of the host
+	Port         int      // the port of the server
+	UUID         string   // the printer UUID
+	Auth         bool     // the server requires the Basic authentication
+	TLS          bool     // the server serves IPPS too
+}
+
+// ErrRemoteNotFound is returned by [FindRemote], if there is no printer
+// with the name on the network.
+var ErrRemoteNotFound = errors.New("remote printer not found")
+
+// remotePrinter returns the printer of the DNS-SD entry, it is false, if the
+// entry is not the thermoprint server.
+func remotePrinter(e dnssd.BrowseEntry) (RemotePrinter, bool) {
+	if e.Text["usb_MFG"] != thermoprintMFG {
+		return RemotePrinter{}, false
+	}
+	return RemotePrinter{
+		Name:         e.Name,
+		Printer:      path.Base(cmp.Or(e.Text["rp"], "printers/default")),
+		MakeAndModel: e.Text["ty"],
+		Host:         e.Host,
+		IPs:          e.IPs,
+		Port:         e.Port,
+		UUID:         e.Text["UUID"],
+		Auth:         e.Text["air"] == "username,password",
+		TLS:          e.Text["TLS"] != "",
+	}, true
+}
+
+// Discover browses the network for the thermoprint servers, and calls fn
+// for every printer found, until the context is done.
+func Discover(ctx context.Context, fn func(RemotePrinter)) error {
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]bool)
+	)
+	add := func(e dnssd.BrowseEntry) {
+		rp, ok := remotePrinter(e)
+		if !ok {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		// the entry is reported for every interface.
+		if key := rp.Name + "\x00" + rp.Host; !seen[key] {
+			seen[key] = true
+			fn(rp)
+		}
+	}
+	err := dnssd.LookupType(ctx, svcTypeIPP+".local.", add, func(dnssd.BrowseEntry) {})
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("discovery: %w", err)
+	}
+	return nil
+}
+
+// FindRemote browses the network for the printer, that matches the name,
+// see [RemotePrinter.Matches], until it is found, or the context is done.
+func FindRemote(ctx context.Context, name string) (RemotePrinter, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		found RemotePrinter
+		once  sync.Once
+	)
+	err := Discover(ctx, func(rp RemotePrinter) {
+		if rp.Matches(name) {
+			once.Do(func() {
+				found = rp
+				cancel()
+			})
+		}
+	})
+	if err != nil {
+		return RemotePrinter{}, err
+	}
+	if found.Name == "" {
+		return RemotePrinter{}, fmt.Errorf("%w: %s", ErrRemoteNotFound, name)
+	}
+	return found, nil
+}
+
+// Matches reports whether the printer has the name: the instance name, the
+// host name without ".local", or "host/printer", the case is ignored.
+func (rp RemotePrinter) Matches(name string) bool {
+	host := rp.hostLabel()
+	return strings.EqualFold(name, rp.Name) ||
+		strings.EqualFold(name, host) ||
+		strings.EqualFold(name, host+"/"+rp.Printer)
+}
+
+// hostLabel returns the host name without the ".local." domain.
+func (rp RemotePrinter) hostLabel() string {
+	return strings.TrimSuffix(strings.TrimSuffix(rp.Host, "."), ".local")
+}
+
+// URL returns the base URL of the server, the first IPv4 address is
+// preferred to the mDNS host name, that does not resolve everywhere.  It is
+// https, if the server serves TLS, on the same port.
+func (rp RemotePrinter) URL() string {
+	host := strings.TrimSuffix(rp.Host, ".")
+	for _, ip := range rp.IPs {
+		if ip.To4() != nil {
+			host = ip.String()
+			break
+		}
+	}
+	scheme := "http://"
+	if rp.TLS {
+		scheme = "https://"
+	}
+	return scheme + net.JoinHostPort(host, strconv.Itoa(rp.Port))
+}
+
+// RemoteDocument is the document submitted to the remote printer.
+type RemoteDocument struct {
+	Filename string // the file name, the job is named after it
+	Data     []byte
+	// Name is the job name, the file name is used, if it is empty.
+	Name string
+	// User is the job user, and, with Password, the user of the Basic
+	// authentication.
+	User     string
+	Password string
+	// Insecure skips the verification of the server certificate, i.e. the
+	// self-signed one, that tp server -tls generates.
+	Insecure bool
+	// AllowPlainHTTP allows to send the password to the server without
+	// TLS, in the clear.
+	AllowPlainHTTP bool
+}
+
+// ErrPlainHTTPPassword is returned by [RemotePrinter.Submit], if the
+// password would be sent to the server without TLS, and it is not allowed
+// with [RemoteDocument.AllowPlainHTTP].
+var ErrPlainHTTPPassword = errors.New("the password is not sent over the plain HTTP, the server does not serve TLS")
+
+// RemoteJob is the job submitted to the remote printer.
+type RemoteJob struct {
+	ID      JobID  `json:"id"`
+	Printer string `json:"printer"`
+	Name    string `json:"name"`
+	State   string `json:"state"`
+}
+
+// Submit submits the document to the printer with the REST API.
+func (rp RemotePrinter) Submit(ctx context.Context, doc RemoteDocument) (RemoteJob, error) {
+	if doc.Password != "" && !rp.TLS && !doc.AllowPlainHTTP {
+		return RemoteJob{}, fmt.Errorf("remote printer %s: %w", rp.Name, ErrPlainHTTPPassword)
+	}
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range map[string]string{"name": doc.Name, "user": doc.User} {
+		if v == "" {
+			continue
+		}
+		if err := mw.WriteField(k, v); err != nil {
+			return RemoteJob{}, err
+		}
+	}
+	hdr := make(textproto.MIMEHeader)
+	hdr.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": "file", "filename": filepath.Base(doc.Filename)}))
+	hdr.Set(hdrContentType, cmp.Or(mime.TypeByExtension(filepath.Ext(doc.Filename)), ippApplicationOctetStream.String()))
+	fw, err := mw.CreatePart(hdr)
+	if err != nil {
+		return RemoteJob{}, err
+	}
+	if _, err := fw.Write(doc.Data); err != nil {
+		return RemoteJob{}, err
+	}
+	if err := mw.Close(); err != nil {
+		return RemoteJob{}, err
+	}
+
+	url := rp.URL() + path.Join(apiPrefix, "printers", rp.Printer, "jobs")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+	if err != nil {
+		return RemoteJob{}, err
+	}
+	req.Header.Set(hdrContentType, mw.FormDataContentType())
+	if doc.Password != "" {
+		req.SetBasicAuth(doc.User, doc.Password)
+	}
+	client := http.DefaultClient
+	if rp.TLS && doc.Insecure {
+		tr := http.DefaultTransport.(*http.Transport).Clone()
+		tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		client = &http.Client{Transport: tr}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return RemoteJob{}, fmt.Errorf("remote printer %s: %w", rp.Name, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return RemoteJob{}, fmt.Errorf("remote printer %s: %w", rp.Name, err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		var ae apiError
+		if json.Unmarshal(data, &ae) != nil || ae.Error == "" {
+			ae.Error = http.StatusText(resp.StatusCode)
+		}
+		return RemoteJob{}, fmt.Errorf("remote printer %s: %s (%d)", rp.Name, ae.Error, resp.StatusCode)
+	}
+	var job RemoteJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return RemoteJob{}, fmt.Errorf("remote printer %s: invalid response: %w", rp.Name, err)
+	}
+	return job, nil
+}
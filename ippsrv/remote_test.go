@@ -0,0 +1,133 @@
+package ippsrv
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/brutella/dnssd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemotePrinter(t *testing.T) {
+	p := mustWrapDriver(t, testDriver{}, "kitchen", "LX-D02 Thermal Printer")
+	text := txtRecord(p, "/printers/", "bar", 6310, 203)
+	text["air"] = "username,password"
+	e := dnssd.BrowseEntry{
+		Name: "LX-D02 Thermal Printer",
+		Host: "bar.local.",
+		IPs:  []net.IP{net.ParseIP("fe80::1"), net.ParseIP("192.0.2.10")},
+		Port: 6310,
+		Text: text,
+	}
+
+	rp, ok := remotePrinter(e)
+	require.True(t, ok)
+	assert.Equal(t, "kitchen", rp.Printer)
+	assert.Equal(t, p.UUID(), rp.UUID)
+	assert.True(t, rp.Auth)
+	assert.False(t, rp.TLS)
+	assert.Equal(t, "http://192.0.2.10:6310", rp.URL(), "IPv4 is preferred")
+
+	for _, name := range []string{"LX-D02 Thermal Printer", "bar", "BAR", "bar/kitchen"} {
+		assert.True(t, rp.Matches(name), name)
+	}
+	for _, name := range []string{"kitchen", "bar.local", "bar/default"} {
+		assert.False(t, rp.Matches(name), name)
+	}
+
+	e.Text = map[string]string{"usb_MFG": "HP", "rp": "ipp/print"}
+	_, ok = remotePrinter(e)
+	assert.False(t, ok, "the other printers are skipped")
+}
+
+// remoteTestPrinter returns the remote printer of the test server.
+func remoteTestPrinter(t *testing.T, s *Server) RemotePrinter {
+	t.Helper()
+	ts := httptest.NewServer(s.srv.Handler)
+	t.Cleanup(ts.Close)
+	return remoteServerPrinter(t, ts)
+}
+
+// remoteServerPrinter returns the remote printer of the test HTTP server.
+func remoteServerPrinter(t *testing.T, ts *httptest.Server) RemotePrinter {
+	t.Helper()
+	host, port, err := net.SplitHostPort(ts.Listener.Addr().String())
+	require.NoError(t, err)
+	n, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	return RemotePrinter{Name: "Test Printer", Printer: "test-printer", Host: "test.local.", IPs: []net.IP{net.ParseIP(host)}, Port: n}
+}
+
+func TestRemotePrinterSubmit(t *testing.T) {
+	s := newAPIServer(t, testDriver{})
+	rp := remoteTestPrinter(t, s)
+
+	job, err := rp.Submit(context.Background(), RemoteDocument{Filename: "/tmp/receipt.png", Data: tinyPNG(t), User: "bob"})
+	require.NoError(t, err)
+	assert.Equal(t, "test-printer", job.Printer)
+	assert.Equal(t, "receipt.png", job.Name)
+	assert.NotZero(t, job.ID)
+	j, err := s.is.spool.GetJob(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", j.Username)
+
+	job, err = rp.Submit(context.Background(), RemoteDocument{Filename: "notes.txt", Data: []byte("hello"), Name: "Notes"})
+	require.NoError(t, err)
+	assert.Equal(t, "Notes", job.Name)
+	j, err = s.is.spool.GetJob(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ippTextPlain.String(), j.printOptions.format, "the text is sent as text/plain")
+
+	rp.Printer = "nonexistent"
+	_, err = rp.Submit(context.Background(), RemoteDocument{Filename: "notes.txt", Data: []byte("hello")})
+	assert.ErrorContains(t, err, "(404)")
+}
+
+func TestRemotePrinterSubmitAuth(t *testing.T) {
+	users := make(Users)
+	users.Add("alice", "secret")
+	s := newAPIServer(t, testDriver{}, WithBasicAuth(users, AuthAll))
+	rp := remoteTestPrinter(t, s)
+	doc := RemoteDocument{Filename: "notes.txt", Data: []byte("hello"), User: "alice"}
+
+	_, err := rp.Submit(context.Background(), doc)
+	assert.ErrorContains(t, err, "(401)")
+
+	doc.Password = "secret"
+	_, err = rp.Submit(context.Background(), doc)
+	assert.ErrorIs(t, err, ErrPlainHTTPPassword)
+
+	doc.AllowPlainHTTP = true
+	job, err := rp.Submit(context.Background(), doc)
+	require.NoError(t, err)
+	j, err := s.is.spool.GetJob(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", j.Username)
+}
+
+func TestRemotePrinterSubmitTLS(t *testing.T) {
+	users := make(Users)
+	users.Add("alice", "secret")
+	s := newAPIServer(t, testDriver{}, WithBasicAuth(users, AuthAll))
+	ts := httptest.NewTLSServer(s.srv.Handler)
+	t.Cleanup(ts.Close)
+	rp := remoteServerPrinter(t, ts)
+	rp.TLS = true
+	assert.True(t, strings.HasPrefix(rp.URL(), "https://"), rp.URL())
+	doc := RemoteDocument{Filename: "notes.txt", Data: []byte("hello"), User: "alice", Password: "secret"}
+
+	_, err := rp.Submit(context.Background(), doc)
+	assert.Error(t, err, "the self-signed certificate is not trusted")
+
+	doc.Insecure = true
+	job, err := rp.Submit(context.Background(), doc)
+	require.NoError(t, err)
+	j, err := s.is.spool.GetJob(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", j.Username)
+}
@@ -0,0 +1,88 @@
+package ippsrv
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/OpenPrinting/goipp"
+)
+
+// Attribute group keywords of the requested-attributes operation attribute,
+// RFC 8011 4.2.5.1 and 4.3.4.1.
+const (
+	groupAll                = "all"
+	groupJobTemplate        = "job-template"
+	groupJobDescription     = "job-description"
+	groupPrinterDescription = "printer-description"
+)
+
+// jobTemplateAttrs lists the job template attributes, that the printer
+// advertises the defaults and supported values of.  The "-default",
+// "-supported" and "-ready" printer attributes of these belong to the
+// job-template group.
+var jobTemplateAttrs = []string{
+	"copies",
+	"finishings",
+	"media",
+	"media-col",
+	"multiple-document-handling",
+	"orientation-requested",
+	"output-bin",
+	"page-ranges",
+	"print-color-mode",
+	"print-quality",
+	"print-scaling",
+	"printer-resolution",
+	"sides",
+	ditherAttr,
+}
+
+// isJobTemplate reports whether the attribute belongs to the job-template
+// group.
+func isJobTemplate(name string) bool {
+	for _, suffix := range []string{"-default", "-supported", "-ready"} {
+		if base, ok := strings.CutSuffix(name, suffix); ok {
+			name = base
+			break
+		}
+	}
+	return slices.Contains(jobTemplateAttrs, name)
+}
+
+// requestedAttributes returns the keywords of the requested-attributes
+// operation attribute, or nil, if the client did not send it, which means
+// "all".
+func requestedAttributes(op goipp.Attributes) []string {
+	vv, ok := findAttr(op, "requested-attributes")
+	if !ok {
+		return nil
+	}
+	names := make([]string, 0, len(vv))
+	for _, v := range vv {
+		names = append(names, v.V.String())
+	}
+	return names
+}
+
+// filterAttributes returns the attributes the client requested, either by
+// name, or by group: "all", "job-template" or the description group, which
+// is "printer-description" for printer and "job-description" for job
+// attributes, and includes everything but the job template attributes.
+// Requested names that the server does not have are ignored.
+func filterAttributes(attrs goipp.Attributes, requested []string, description string) goipp.Attributes {
+	if len(requested) == 0 || slices.Contains(requested, groupAll) {
+		return attrs
+	}
+	var (
+		template = slices.Contains(requested, groupJobTemplate)
+		descr    = slices.Contains(requested, description)
+	)
+	var out goipp.Attributes
+	for _, attr := range attrs {
+		isTemplate := isJobTemplate(attr.Name)
+		if (template && isTemplate) || (descr && !isTemplate) || slices.Contains(requested, attr.Name) {
+			out = append(out, attr)
+		}
+	}
+	return out
+}
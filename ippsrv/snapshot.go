@@ -4,6 +4,8 @@ import (
 	"errors"
 	"sort"
 	"time"
+
+	"github.com/rusq/thermoprint"
 )
 
 // ServerSnapshot is a stable, read-only copy of server, printer, and spool
@@ -30,6 +32,11 @@ type PrinterSnapshot struct {
 	UpTime       int
 	MediaDefault string
 	UUID         string
+	StateReasons []PrinterStateReason
+	Alerts       []thermoprint.Alert // alert history, oldest first
+	// UnknownNotifications are the notifications captured by the driver,
+	// oldest first.
+	UnknownNotifications []thermoprint.UnknownNotification
 }
 
 // JobSnapshot is a stable copy of a spooled job.
@@ -92,6 +99,10 @@ func snapshotPrinter(p Printer) PrinterSnapshot {
 		UpTime:       p.UpTime(),
 		MediaDefault: p.MediaDefault(),
 		UUID:         p.UUID(),
+		StateReasons: stateReasons(p),
+		Alerts:       printerAlerts(p),
+
+		UnknownNotifications: printerNotifications(p),
 	}
 }
 
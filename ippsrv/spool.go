@@ -10,31 +10,79 @@ import (
 	"path/filepath"
 	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const jobRetention = 24 * time.Hour // Duration to retain job files in the spool
 
+// WithMaxQueue sets the maximum number of the queued jobs per printer, the
+// jobs, that are not finished yet.  The new jobs are refused with
+// server-error-busy while the queue is full, the client retries them later.
+// Zero is unlimited.
+func WithMaxQueue(n int) Option {
+	return func(s *Server) {
+		s.maxQueue = n
+	}
+}
+
+// WithJobTTL sets the time the job may stay pending or held, i.e. while the
+// printer is offline, after which it is aborted with resources-are-not-ready,
+// instead of printing unexpectedly days later.  Zero keeps the jobs until
+// they are printed.
+func WithJobTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.jobTTL = ttl
+	}
+}
+
 type spooler interface {
-	AddJob(ctx context.Context, job *Job, data []byte) error
+	// AddJob spools the job with the data, the job is printed in the
+	// background, once the printer is free (Print-Job).
+	AddJob(job *Job, data []byte) error
+	// CreateJob registers the job without the documents, that are added
+	// with AddDocument (Create-Job).
+	CreateJob(job *Job) error
+	// AddDocument adds the document to the job, that was registered with
+	// CreateJob, the job is queued for printing once the last document is
+	// added (Send-Document).
+	AddDocument(jobID JobID, doc document, last bool) error
+	// Dispatch wakes up the dispatcher of the job printer, once the job is
+	// queued for printing again (Release-Job, Restart-Job).
+	Dispatch(jobID JobID) error
 	RemoveJob(jobID JobID) error
 	GetJob(jobID JobID) (*Job, error)
 	// GetJobs returns all jobs for a specific printer by its ID.
 	GetJobs(prnID string) ([]*Job, error) // code 10
 	GetJobData(jobID JobID) ([]byte, error)
+	// GetJobCount returns the number of the unfinished jobs of the printer
+	// (queued-job-count).
 	GetJobCount(prnID string) int
 	ListJobs() ([]*Job, error)
+	// NextJobID returns the new job id.
+	NextJobID() (JobID, error)
 	io.Closer
 }
 
 type spool struct {
-	dir  string        // Directory where jobs are spooled
-	msgC chan struct{} // Channel for spool messages
-
-	mu           sync.Mutex             // Mutex to protect concurrent access
-	jobs         map[JobID]*Job         // In-memory cache of jobs, keyed by JobID
-	printerJobs  map[string][]JobID     // Jobs per printer, keyed by printer ID
-	printerLocks map[string]*sync.Mutex // Job processing locks per printer, keyed by printer ID
+	dir        string        // Directory where jobs are spooled
+	msgC       chan struct{} // Channel for spool messages
+	persistent bool          // the spool survives the restart, see [WithSpoolDir]
+	dirty      atomic.Bool   // the jobs have changed since the job index was saved
+
+	mu          sync.Mutex                 // Mutex to protect concurrent access
+	ttl         time.Duration              // Time the job may stay pending, zero is forever
+	maxQueue    int                        // Maximum queued jobs per printer, zero is unlimited
+	naming      SpoolNaming                // Naming of the document files
+	notify      atomic.Pointer[func(*Job)] // called after the job is added or changes the state, see setNotify
+	jobs        map[JobID]*Job             // In-memory cache of jobs, keyed by JobID
+	printerJobs map[string][]JobID         // Jobs per printer, keyed by printer ID
+	queues      map[string]chan struct{}   // Dispatcher wake-ups per printer, keyed by printer ID
+	lastID      JobID                      // the last issued job id
+
+	ctx        context.Context    // context of the job processing
+	stop       context.CancelFunc // stops the dispatchers and the prints in progress
+	dispatcher sync.WaitGroup     // running dispatchers
 }
 
 func newSpool(spoolDir string) (*spool, error) {
@@ -52,21 +100,32 @@ func newSpool(spoolDir string) (*spool, error) {
 		}
 	}
 	sp := &spool{
-		dir:          spoolDir,
-		jobs:         make(map[JobID]*Job),
-		printerJobs:  make(map[string][]JobID),
-		printerLocks: make(map[string]*sync.Mutex),
-		msgC:         make(chan struct{}, 100), // Buffered channel for spool messages
+		dir:         spoolDir,
+		jobs:        make(map[JobID]*Job),
+		printerJobs: make(map[string][]JobID),
+		queues:      make(map[string]chan struct{}),
+		naming:      DefaultSpoolNaming,
+		msgC:        make(chan struct{}, 100), // Buffered channel for spool messages
 	}
+	sp.ctx, sp.stop = context.WithCancel(context.Background())
 	go sp.worker()
 	return sp, nil
 }
 
+// Close stops the dispatchers, the print in progress is aborted, and
+// removes the temporary spool.
 func (s *spool) Close() error {
+	slog.Debug("closing spool", "dir", s.dir)
+	s.stop()
+	s.dispatcher.Wait()
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	slog.Debug("closing spool", "dir", s.dir)
 	close(s.msgC)
+	if s.persistent {
+		s.syncLocked()
+		slog.Info("spool closed, the jobs are kept", "dir", s.dir)
+		return nil
+	}
 	if err := os.RemoveAll(s.dir); err != nil {
 		return fmt.Errorf("failed to remove spool directory %s: %w", s.dir, err)
 	}
@@ -97,7 +156,11 @@ func (s *spool) worker() {
 			if activeJobCount > 0 {
 				slog.Info("spool worker running", "job_count", activeJobCount)
 			}
+			s.expireLocked(time.Now())
 			s.pruneLocked()
+			if s.dirty.Load() {
+				s.syncLocked()
+			}
 			s.mu.Unlock()
 		}
 	}
@@ -106,6 +169,8 @@ func (s *spool) worker() {
 var (
 	errJobAlreadyExists = errors.New("job already exists")
 	errJobNotFound      = errors.New("job not found")
+	errJobNotIncoming   = errors.New("job does not accept documents")
+	errQueueFull        = errors.New("printer queue is full")
 )
 
 func (s *spool) pruneLocked() {
@@ -119,12 +184,99 @@ func (s *spool) pruneLocked() {
 	}
 }
 
+// setJobTTL sets the time the job may stay pending, see [WithJobTTL].
+func (s *spool) setJobTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ttl = ttl
+}
+
+// setMaxQueue sets the maximum queued jobs per printer, see [WithMaxQueue].
+func (s *spool) setMaxQueue(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxQueue = n
+}
+
+// setNaming sets the naming of the document files, see [WithSpoolNaming],
+// empty keeps the default.
+func (s *spool) setNaming(n SpoolNaming) {
+	if n == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.naming = n
+}
+
+// setNotify sets the function, that is called after the job is added or
+// changes its state.
+func (s *spool) setNotify(fn func(*Job)) {
+	s.notify.Store(&fn)
+}
+
+// notifyJob calls the notify function, if it is set.
+func (s *spool) notifyJob(job *Job) {
+	if fn := s.notify.Load(); fn != nil {
+		(*fn)(job)
+	}
+}
+
+// queuedLocked returns the number of the queued jobs of the printer, the
+// jobs, that are not finished: pending, held, or printing.
+func (s *spool) queuedLocked(prnID string) int {
+	n := 0
+	for _, jobID := range s.printerJobs[prnID] {
+		if job, ok := s.jobs[jobID]; ok && !isCompletedState(job.state()) {
+			n++
+		}
+	}
+	return n
+}
+
+// checkQueueLocked returns errQueueFull, if the printer queue has no room
+// for the new job.
+func (s *spool) checkQueueLocked(prnID string) error {
+	if s.maxQueue > 0 && s.queuedLocked(prnID) >= s.maxQueue {
+		return errQueueFull
+	}
+	return nil
+}
+
+// expireLocked aborts the jobs, that have been pending or held for longer
+// than the job TTL.
+func (s *spool) expireLocked(now time.Time) {
+	if s.ttl <= 0 {
+		return
+	}
+	for jobID, job := range s.jobs {
+		if state := job.state(); state != JobPending && state != JobPendingHeld {
+			continue
+		}
+		if now.Sub(job.Created) <= s.ttl {
+			continue
+		}
+		slog.Warn("aborting the stale job", "job_id", jobID, "created_at", job.Created, "ttl", s.ttl)
+		if err := job.sm.Event(context.Background(), jobEvtAbort, JSRResourcesAreNotReady); err != nil {
+			slog.Error("failed to abort the stale job", "job_id", jobID, "error", err)
+		}
+	}
+}
+
 func (s *spool) addJobLocked(job *Job) error {
 	if _, ok := s.jobs[job.ID]; ok {
 		return errJobAlreadyExists
 	}
 
 	s.jobs[job.ID] = job
+	job.mu.Lock()
+	job.changed = func() {
+		s.dirty.Store(true)
+		s.notifyJob(job)
+	}
+	job.mu.Unlock()
+	s.dirty.Store(true)
+	s.notifyJob(job)
 	pjobs := s.printerJobs[job.Printer.Name()]
 	if slices.Contains(pjobs, job.ID) {
 		return fmt.Errorf("job %d already exists for printer %s", job.ID, job.Printer.Name())
@@ -144,6 +296,7 @@ func (s *spool) removeJobLocked(jobID JobID) error {
 	}
 
 	delete(s.jobs, jobID)
+	s.dirty.Store(true)
 
 	// Remove the job ID from the printer's job list
 	printerJobs := s.printerJobs[job.Printer.Name()]
@@ -155,16 +308,21 @@ func (s *spool) removeJobLocked(jobID JobID) error {
 		}
 	}
 
-	// The file is removed last so that a failure cannot leave a job that is
-	// registered but has no file; a missing file is not an error.
-	filePath := s.jobFilePath(jobID)
-	if err := os.Remove(filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
-		return fmt.Errorf("failed to remove job file %s: %w", filePath, err)
+	// The files are removed last so that a failure cannot leave a job that
+	// is registered but has no file; a missing file is not an error.
+	job.mu.RLock()
+	n := max(len(job.documents), 1)
+	job.mu.RUnlock()
+	for i := 1; i <= n; i++ {
+		filePath := s.documentFilePath(job, i)
+		if err := os.Remove(filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove job file %s: %w", filePath, err)
+		}
 	}
 	return nil
 }
 
-func (s *spool) AddJob(ctx context.Context, job *Job, data []byte) error {
+func (s *spool) AddJob(job *Job, data []byte) error {
 	if job == nil {
 		return errors.New("job cannot be nil")
 	}
@@ -172,52 +330,207 @@ func (s *spool) AddJob(ctx context.Context, job *Job, data []byte) error {
 		return errors.New("job printer cannot be nil")
 	}
 
-	if err := func() error {
-		s.mu.Lock()
-		defer s.mu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkQueueLocked(job.Printer.Name()); err != nil {
+		return err
+	}
+	if err := s.addJobLocked(job); err != nil {
+		return fmt.Errorf("failed to add job %d: %w", job.ID, err)
+	}
 
-		if err := s.addJobLocked(job); err != nil {
-			return fmt.Errorf("failed to add job %d: %w", job.ID, err)
+	file := s.naming.filename(job, 1, detectFormat(job.Format, data))
+	jobFile := filepath.Join(s.dir, file)
+	if err := os.WriteFile(jobFile, data, 0644); err != nil {
+		// Roll back the registration so the job does not linger in the
+		// spool without a file.
+		if rerr := s.removeJobLocked(job.ID); rerr != nil {
+			slog.Error("failed to roll back job registration", "job_id", job.ID, "error", rerr)
 		}
+		return fmt.Errorf("failed to write job data to file %s: %w", jobFile, err)
+	}
+	job.addDocument(job.Format, file)
+	job.mu.Lock()
+	job.lastDocument = true
+	job.mu.Unlock()
+	s.syncLocked()
+	slog.Info("job added", "job_id", job.ID, "printer", job.Printer.Name(), "file", jobFile)
+	s.wakeLocked(job.Printer.Name())
+	return nil
+}
 
-		jobFile := s.jobFilePath(job.ID)
-		if err := os.WriteFile(jobFile, data, 0644); err != nil {
-			// Roll back the registration so the job does not linger in the
-			// spool without a file.
-			if rerr := s.removeJobLocked(job.ID); rerr != nil {
-				slog.Error("failed to roll back job registration", "job_id", job.ID, "error", rerr)
-			}
-			return fmt.Errorf("failed to write job data to file %s: %w", jobFile, err)
-		}
-		slog.Info("job added", "job_id", job.ID, "printer", job.Printer.Name(), "file", jobFile)
-		return nil
-	}(); err != nil {
+func (s *spool) CreateJob(job *Job) error {
+	if job == nil {
+		return errors.New("job cannot be nil")
+	}
+	if job.Printer == nil {
+		return errors.New("job printer cannot be nil")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkQueueLocked(job.Printer.Name()); err != nil {
 		return err
 	}
+	if err := s.addJobLocked(job); err != nil {
+		return fmt.Errorf("failed to add job %d: %w", job.ID, err)
+	}
+	s.syncLocked()
+	slog.Info("job created, waiting for the documents", "job_id", job.ID, "printer", job.Printer.Name())
+	return nil
+}
+
+func (s *spool) AddDocument(jobID JobID, doc document, last bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	unlock := s.lockPrinter(job.Printer.Name())
-	defer unlock()
-	return job.sm.Event(ctx, jobEvtProcess, data)
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return errJobNotFound
+	}
+	if !job.acceptsDocuments() {
+		return errJobNotIncoming
+	}
+	// the last Send-Document may come without the data.
+	if len(doc.data) > 0 {
+		job.mu.RLock()
+		n := len(job.documents) + 1
+		job.mu.RUnlock()
+		file := s.naming.filename(job, n, detectFormat(doc.format, doc.data))
+		docFile := filepath.Join(s.dir, file)
+		if err := os.WriteFile(docFile, doc.data, 0644); err != nil {
+			return fmt.Errorf("failed to write document %d to file %s: %w", n, docFile, err)
+		}
+		job.addDocument(doc.format, file)
+		slog.Info("document added", "job_id", jobID, "document", n, "file", docFile)
+	}
+	if last {
+		job.mu.Lock()
+		job.lastDocument = true
+		job.mu.Unlock()
+		s.syncLocked()
+		s.wakeLocked(job.Printer.Name())
+	}
+	return nil
+}
+
+// documents reads the documents of the job from the spool.
+func (s *spool) documents(job *Job) ([]document, error) {
+	job.mu.RLock()
+	formats := slices.Clone(job.documents)
+	job.mu.RUnlock()
+	docs := make([]document, len(formats))
+	for i, format := range formats {
+		docFile := s.documentFilePath(job, i+1)
+		data, err := os.ReadFile(docFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read document %d from file %s: %w", i+1, docFile, err)
+		}
+		docs[i] = document{data: data, format: format}
+	}
+	return docs, nil
 }
 
-// lockPrinter serialises job processing per printer, so that concurrent jobs
-// for the same printer do not interleave printing and printer state changes.
-// The spool identifies printers by name, as in printerJobs; locks are per
-// spool, so same-named printers served by different spools stay independent.
-func (s *spool) lockPrinter(prnID string) (unlock func()) {
+func (s *spool) Dispatch(jobID JobID) error {
 	s.mu.Lock()
-	mu, ok := s.printerLocks[prnID]
+	defer s.mu.Unlock()
+	job, ok := s.jobs[jobID]
 	if !ok {
-		mu = &sync.Mutex{}
-		s.printerLocks[prnID] = mu
+		return errJobNotFound
 	}
-	s.mu.Unlock()
-	mu.Lock()
-	return mu.Unlock
+	s.wakeLocked(job.Printer.Name())
+	return nil
 }
 
-func (s *spool) jobFilePath(jobID JobID) string {
-	return filepath.Join(s.dir, fmt.Sprintf("job_%d.ps", jobID))
+// wakeLocked wakes up the dispatcher of the printer, starting it, if it is
+// not running yet.
+func (s *spool) wakeLocked(prnID string) {
+	wake, ok := s.queues[prnID]
+	if !ok {
+		if s.ctx.Err() != nil {
+			return // closed
+		}
+		wake = make(chan struct{}, 1)
+		s.queues[prnID] = wake
+		s.dispatcher.Add(1)
+		go s.dispatch(prnID, wake)
+	}
+	select {
+	case wake <- struct{}{}:
+	default: // the dispatcher is already woken up
+	}
+}
+
+// dispatch prints the queued jobs of the printer one after another, in the
+// order they were submitted, so that the jobs for the same printer do not
+// interleave printing and printer state changes.  The spool identifies
+// printers by name, as in printerJobs; dispatchers are per spool, so
+// same-named printers served by different spools stay independent.
+func (s *spool) dispatch(prnID string, wake <-chan struct{}) {
+	defer s.dispatcher.Done()
+	for {
+		job := s.nextQueued(prnID)
+		if job == nil {
+			select {
+			case <-wake:
+				continue
+			case <-s.ctx.Done():
+				return
+			}
+		}
+		s.process(job)
+	}
+}
+
+// nextQueued returns the first job of the printer, that is queued for
+// printing, or nil, if there is none.
+func (s *spool) nextQueued(prnID string) *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx.Err() != nil {
+		return nil
+	}
+	for _, jobID := range s.printerJobs[prnID] {
+		if job, ok := s.jobs[jobID]; ok && job.queued() {
+			return job
+		}
+	}
+	return nil
+}
+
+// process prints the documents of the job.
+func (s *spool) process(job *Job) {
+	docs, err := s.documents(job)
+	if err != nil {
+		slog.Error("failed to read the job documents", "job_id", job.ID, "error", err)
+		if err := job.sm.Event(s.ctx, jobEvtAbort, JSRDocumentAccessError, JSRAbortedBySystem); err != nil {
+			slog.Error("failed to abort the job", "job_id", job.ID, "error", err)
+		}
+		return
+	}
+	if err := job.sm.Event(s.ctx, jobEvtProcess, docs); err != nil {
+		// cancelled after it was taken from the queue.
+		slog.Info("job is not processed", "job_id", job.ID, "state", job.state(), "error", err)
+	}
+}
+
+func (s *spool) jobFilePath(job *Job) string {
+	return s.documentFilePath(job, 1)
+}
+
+// documentFilePath returns the file of the nth document of the job, the
+// first one is the job file.  The jobs of the older spools have no file
+// names in the job index, their files are job_<id>.ps and job_<id>_<n>.ps.
+func (s *spool) documentFilePath(job *Job, n int) string {
+	job.mu.RLock()
+	defer job.mu.RUnlock()
+	if n >= 1 && n <= len(job.files) {
+		return filepath.Join(s.dir, job.files[n-1])
+	}
+	if n <= 1 {
+		return filepath.Join(s.dir, fmt.Sprintf("job_%d.ps", job.ID))
+	}
+	return filepath.Join(s.dir, fmt.Sprintf("job_%d_%d.ps", job.ID, n))
 }
 
 func (s *spool) RemoveJob(jobID JobID) error {
@@ -227,6 +540,7 @@ func (s *spool) RemoveJob(jobID JobID) error {
 	if err := s.removeJobLocked(jobID); err != nil {
 		return fmt.Errorf("failed to remove job %d: %w", jobID, err)
 	}
+	s.syncLocked()
 	return nil
 }
 
@@ -248,10 +562,25 @@ func (s *spool) GetJobData(jobID JobID) ([]byte, error) {
 	if !ok {
 		return nil, errJobNotFound
 	}
-	jobFile := s.jobFilePath(job.ID)
-	data, err := os.ReadFile(jobFile)
+	job.mu.RLock()
+	multi := len(job.documents) > 1
+	job.mu.RUnlock()
+	if !multi {
+		jobFile := s.jobFilePath(job)
+		data, err := os.ReadFile(jobFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read job file %s: %w", jobFile, err)
+		}
+		return data, nil
+	}
+	// the documents of Create-Job, concatenated.
+	docs, err := s.documents(job)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read job file %s: %w", jobFile, err)
+		return nil, err
+	}
+	var data []byte
+	for _, doc := range docs {
+		data = append(data, doc.data...)
 	}
 	return data, nil
 }
@@ -269,14 +598,12 @@ func (s *spool) ListJobs() ([]*Job, error) {
 	return jobList, nil
 }
 
+// GetJobCount returns the number of the queued jobs of the printer, the
+// finished jobs, that are kept in the spool, are not counted.
 func (s *spool) GetJobCount(prnID string) int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-
-	if jobs, ok := s.printerJobs[prnID]; ok {
-		return len(jobs)
-	}
-	return 0
+	return s.queuedLocked(prnID)
 }
 
 func (s *spool) GetJobs(prnID string) ([]*Job, error) {
@@ -9,9 +9,12 @@ import (
 	"image/color"
 	"image/png"
 	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
+	"github.com/OpenPrinting/goipp"
 	"github.com/rusq/thermoprint"
 )
 
@@ -76,15 +79,27 @@ func assertJobGone(t *testing.T, sp *spool, jobID JobID, prnName string) {
 }
 
 // startAddJob runs sp.AddJob in a goroutine and returns the channel carrying
-// its result.
+// its error, or nil, once the job is finished.
 func startAddJob(sp *spool, job *Job, data []byte) <-chan error {
 	addErr := make(chan error, 1)
 	go func() {
-		addErr <- sp.AddJob(context.Background(), job, data)
+		if err := sp.AddJob(job, data); err != nil {
+			addErr <- err
+			return
+		}
+		<-job.finished()
+		addErr <- nil
 	}()
 	return addErr
 }
 
+// waitJob waits for the job, processed by the spool, to finish.
+func waitJob(t *testing.T, job *Job) {
+	t.Helper()
+
+	<-job.finished()
+}
+
 // waitStarted waits until the driver reports that printing has started.
 func waitStarted(t *testing.T, entered <-chan struct{}, addErr <-chan error) {
 	t.Helper()
@@ -124,13 +139,37 @@ func waitDone(t *testing.T, addErr <-chan error) {
 	}
 }
 
+func TestJobFinished(t *testing.T) {
+	job := mustCreateJob(t, mustWrapDriver(t, testDriver{}, "test-printer", "Test Printer"), 1, "job")
+	done := job.finished()
+	select {
+	case <-done:
+		t.Fatal("pending job is finished")
+	default:
+	}
+
+	job.setState(JobCompleted, nil)
+	select {
+	case <-done:
+	default:
+		t.Fatal("completed job is not finished")
+	}
+
+	job.setState(JobPending, nil) // restarted
+	select {
+	case <-job.finished():
+		t.Fatal("restarted job is finished")
+	default:
+	}
+}
+
 func TestSpoolRemoveJobRemovesFileAndIndexes(t *testing.T) {
 	sp := newTestSpool(t)
 	printer := mustWrapDriver(t, testDriver{}, "test-printer", "Test Printer")
 	job := mustCreateJob(t, printer, 42, "test-job")
 	registerJob(t, sp, job)
 
-	jobFile := sp.jobFilePath(job.ID)
+	jobFile := sp.jobFilePath(job)
 	if err := os.WriteFile(jobFile, []byte("print data"), 0600); err != nil {
 		t.Fatalf("WriteFile: %v", err)
 	}
@@ -174,7 +213,7 @@ func TestSpoolAddJobRollsBackOnWriteFailure(t *testing.T) {
 	if err := os.RemoveAll(sp.dir); err != nil {
 		t.Fatalf("RemoveAll: %v", err)
 	}
-	if err := sp.AddJob(context.Background(), job, tinyPNG(t)); err == nil {
+	if err := sp.AddJob(job, tinyPNG(t)); err == nil {
 		t.Fatal("AddJob succeeded, want write failure")
 	}
 	assertJobGone(t, sp, job.ID, printer.Name())
@@ -183,7 +222,7 @@ func TestSpoolAddJobRollsBackOnWriteFailure(t *testing.T) {
 	if err := os.MkdirAll(sp.dir, 0755); err != nil {
 		t.Fatalf("MkdirAll: %v", err)
 	}
-	if err := sp.AddJob(context.Background(), job, tinyPNG(t)); err != nil {
+	if err := sp.AddJob(job, tinyPNG(t)); err != nil {
 		t.Fatalf("AddJob after rollback: %v", err)
 	}
 }
@@ -292,6 +331,80 @@ func TestValuePrinterSerializesConcurrentJobs(t *testing.T) {
 	waitDone(t, addErr2)
 }
 
+func TestCancelJobStopsPrinting(t *testing.T) {
+	sp := newTestSpool(t)
+	driver := newBlockingDriver(1)
+	printer := mustWrapDriver(t, driver, "test-printer", "Test Printer")
+	job := mustCreateJob(t, printer, 42, "test-job")
+
+	addErr := startAddJob(sp, job, tinyPNG(t))
+	waitStarted(t, driver.entered, addErr)
+
+	if err := job.cancel(context.Background(), JSRJobCancelledByUser); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	waitDone(t, addErr)
+	if got := job.state(); got != JobCancelled {
+		t.Fatalf("job state = %v, want %v", got, JobCancelled)
+	}
+	if got := printer.State(); got != PSIdle {
+		t.Fatalf("printer state = %v, want %v", got, PSIdle)
+	}
+	if err := job.cancel(context.Background(), JSRJobCancelledByUser); ippStatusFromError(err) != goipp.StatusErrorNotPossible {
+		t.Fatalf("second cancel error = %v, want client-error-not-possible", err)
+	}
+}
+
+func TestCancelQueuedJobIsNotPrinted(t *testing.T) {
+	sp := newTestSpool(t)
+	driver := newBlockingDriver(2)
+	printer := mustWrapDriver(t, driver, "test-printer", "Test Printer")
+	job1 := mustCreateJob(t, printer, 42, "test-job-1")
+	job2 := mustCreateJob(t, printer, 43, "test-job-2")
+	data := tinyPNG(t)
+
+	addErr1 := startAddJob(sp, job1, data)
+	waitStarted(t, driver.entered, addErr1)
+	addErr2 := startAddJob(sp, job2, data)
+
+	if err := job2.cancel(context.Background(), JSRJobCancelledByUser); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	close(driver.release)
+	waitDone(t, addErr1)
+	waitDone(t, addErr2)
+	assertNotStarted(t, driver.entered, "cancelled job was printed")
+	if got := job2.state(); got != JobCancelled {
+		t.Fatalf("job state = %v, want %v", got, JobCancelled)
+	}
+}
+
+// errDriver fails every print with err.
+type errDriver struct {
+	testDriver
+	err error
+}
+
+func (d errDriver) PrintImage(ctx context.Context, img image.Image) error { return d.err }
+
+func TestNoPaperCancelsJobAtDevice(t *testing.T) {
+	sp := newTestSpool(t)
+	printer := mustWrapDriver(t, errDriver{err: thermoprint.ErrNoPaper}, "test-printer", "Test Printer")
+	job := mustCreateJob(t, printer, 42, "test-job")
+
+	if err := sp.AddJob(job, tinyPNG(t)); err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	waitJob(t, job)
+	snap := job.Snapshot()
+	if snap.State != JobCancelled {
+		t.Fatalf("job state = %v, want %v", snap.State, JobCancelled)
+	}
+	if len(snap.StateReasons) != 1 || snap.StateReasons[0] != JSRJobCancelledAtDevice {
+		t.Fatalf("job state reasons = %v, want [%s]", snap.StateReasons, JSRJobCancelledAtDevice)
+	}
+}
+
 // blockingDriver signals on entered when PrintImage is called and blocks
 // until release is closed.
 type blockingDriver struct {
@@ -353,3 +466,432 @@ func tinyPNG(t *testing.T) []byte {
 	}
 	return buf.Bytes()
 }
+
+func TestSpoolAddDocument(t *testing.T) {
+	sp := newTestSpool(t)
+	driver := &captureDriver{}
+	printer := mustWrapDriver(t, driver, "test-printer", "Test Printer")
+	job := mustCreateJob(t, printer, 42, "test-job")
+	if err := sp.CreateJob(job); err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	for _, doc := range []document{{data: tinyPNG(t)}, {data: []byte("hello"), format: "text/plain"}} {
+		if err := sp.AddDocument(job.ID, doc, false); err != nil {
+			t.Fatalf("AddDocument: %v", err)
+		}
+	}
+	if job.state() != JobPending {
+		t.Fatalf("state = %v, want pending until the last document", job.state())
+	}
+	data, err := sp.GetJobData(job.ID)
+	if err != nil {
+		t.Fatalf("GetJobData: %v", err)
+	}
+	if !bytes.HasSuffix(data, []byte("hello")) || len(data) != len(tinyPNG(t))+5 {
+		t.Errorf("GetJobData returned %d bytes, want the documents concatenated", len(data))
+	}
+	if err := sp.AddDocument(job.ID, document{}, true); err != nil {
+		t.Fatalf("AddDocument: %v", err)
+	}
+	waitJob(t, job)
+	if job.state() != JobCompleted {
+		t.Fatalf("state = %v, want completed", job.state())
+	}
+	if err := sp.AddDocument(job.ID, document{data: []byte("late")}, true); !errors.Is(err, errJobNotIncoming) {
+		t.Fatalf("AddDocument error = %v, want %v", err, errJobNotIncoming)
+	}
+
+	second := sp.documentFilePath(job, 2)
+	if _, err := os.Stat(second); err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if err := sp.RemoveJob(job.ID); err != nil {
+		t.Fatalf("RemoveJob: %v", err)
+	}
+	if _, err := os.Stat(second); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Stat error = %v, want the document file removed", err)
+	}
+}
+
+func TestSpoolNaming(t *testing.T) {
+	sp := newTestSpool(t)
+	sp.setNaming("{printer}-{id}.{ext}")
+	printer := mustWrapDriver(t, &captureDriver{}, "test-printer", "Test Printer")
+	job := mustCreateJob(t, printer, 42, "test-job")
+	if err := sp.CreateJob(job); err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	for _, doc := range []document{{data: tinyPNG(t)}, {data: []byte("hello"), format: "text/plain"}} {
+		if err := sp.AddDocument(job.ID, doc, false); err != nil {
+			t.Fatalf("AddDocument: %v", err)
+		}
+	}
+	for n, want := range []string{"test-printer-42.png", "test-printer-42_2.txt"} {
+		if got := sp.documentFilePath(job, n+1); got != filepath.Join(sp.dir, want) {
+			t.Errorf("document %d file = %s, want %s", n+1, got, want)
+		}
+		if _, err := os.Stat(filepath.Join(sp.dir, want)); err != nil {
+			t.Errorf("Stat: %v", err)
+		}
+	}
+
+	// the jobs of the older spools have no file names recorded.
+	old := mustCreateJob(t, printer, 7, "old")
+	if got, want := sp.documentFilePath(old, 2), filepath.Join(sp.dir, "job_7_2.ps"); got != want {
+		t.Errorf("legacy document file = %s, want %s", got, want)
+	}
+}
+
+func TestSpoolExpireAbortsStaleJobs(t *testing.T) {
+	sp := newTestSpool(t)
+	printer := mustWrapDriver(t, testDriver{}, "test-printer", "Test Printer")
+	now := time.Now()
+
+	stale := mustCreateJob(t, printer, 1, "stale")
+	stale.Created = now.Add(-2 * time.Hour)
+	held := mustCreateJob(t, printer, 2, "held")
+	held.Created = now.Add(-2 * time.Hour)
+	if err := held.sm.Event(context.Background(), jobEvtHeld); err != nil {
+		t.Fatalf("held: %v", err)
+	}
+	fresh := mustCreateJob(t, printer, 3, "fresh")
+	for _, job := range []*Job{stale, held, fresh} {
+		registerJob(t, sp, job)
+	}
+
+	sp.mu.Lock()
+	sp.expireLocked(now) // no TTL
+	sp.mu.Unlock()
+	if stale.state() != JobPending {
+		t.Fatalf("state = %v, want pending without the TTL", stale.state())
+	}
+
+	sp.setJobTTL(time.Hour)
+	sp.mu.Lock()
+	sp.expireLocked(now)
+	sp.mu.Unlock()
+	for _, job := range []*Job{stale, held} {
+		if job.state() != JobAborted {
+			t.Errorf("%s: state = %v, want aborted", job.Name, job.state())
+		}
+		if want := []JobStateReason{JSRResourcesAreNotReady}; !slices.Equal(job.StateReasons, want) {
+			t.Errorf("%s: reasons = %v, want %v", job.Name, job.StateReasons, want)
+		}
+	}
+	if fresh.state() != JobPending {
+		t.Errorf("fresh: state = %v, want pending", fresh.state())
+	}
+}
+
+func mustOpenSpool(t *testing.T, dir string, pp ...Printer) *spool {
+	t.Helper()
+
+	printers := make(map[string]Printer, len(pp))
+	for _, p := range pp {
+		printers[p.Name()] = p
+	}
+	sp, err := openSpool(dir, printers)
+	if err != nil {
+		t.Fatalf("openSpool: %v", err)
+	}
+	return sp
+}
+
+func TestSpoolNextJobIDPersists(t *testing.T) {
+	dir := t.TempDir()
+	sp := mustOpenSpool(t, dir)
+	var last JobID
+	for range 3 {
+		id, err := sp.NextJobID()
+		if err != nil {
+			t.Fatalf("NextJobID: %v", err)
+		}
+		if id <= last {
+			t.Fatalf("NextJobID = %d, want greater than %d", id, last)
+		}
+		last = id
+	}
+	if err := sp.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	sp = mustOpenSpool(t, dir)
+	defer sp.Close()
+	id, err := sp.NextJobID()
+	if err != nil {
+		t.Fatalf("NextJobID: %v", err)
+	}
+	if id != last+1 {
+		t.Fatalf("NextJobID after reopen = %d, want %d", id, last+1)
+	}
+}
+
+func TestSpoolRestoresJobs(t *testing.T) {
+	dir := t.TempDir()
+	printer := mustWrapDriver(t, testDriver{}, "test-printer", "Test Printer")
+	other := mustWrapDriver(t, testDriver{}, "other-printer", "Other Printer")
+
+	sp := mustOpenSpool(t, dir, printer, other)
+	done := mustCreateJob(t, printer, 1, "done")
+	if err := sp.AddJob(done, tinyPNG(t)); err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	waitJob(t, done)
+	waiting := mustCreateJob(t, printer, 2, "waiting")
+	if err := sp.CreateJob(waiting); err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	gone := mustCreateJob(t, other, 3, "gone")
+	if err := sp.CreateJob(gone); err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	if err := sp.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(sp.jobFilePath(done)); err != nil {
+		t.Fatalf("Stat: %v, want the job file kept", err)
+	}
+
+	sp = mustOpenSpool(t, dir, printer) // other-printer is no longer served
+	defer sp.Close()
+	jobs, err := sp.GetJobs(printer.Name())
+	if err != nil {
+		t.Fatalf("GetJobs: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("GetJobs returned %d jobs, want 2", len(jobs))
+	}
+	restored, err := sp.GetJob(done.ID)
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if restored.state() != JobCompleted || restored.Name != "done" || !restored.Created.Equal(done.Created) {
+		t.Errorf("restored job = %s %q created %v, want completed %q created %v", restored.state(), restored.Name, restored.Created, done.Name, done.Created)
+	}
+	if data, err := sp.GetJobData(done.ID); err != nil || !bytes.Equal(data, tinyPNG(t)) {
+		t.Errorf("GetJobData = %d bytes, %v, want the job data", len(data), err)
+	}
+	restored, err = sp.GetJob(waiting.ID)
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if restored.state() != JobAborted || !slices.Equal(restored.StateReasons, []JobStateReason{JSRAbortedBySystem}) {
+		t.Errorf("unfinished job = %s %v, want aborted by the system", restored.state(), restored.StateReasons)
+	}
+	if restored.acceptsDocuments() {
+		t.Error("the aborted job accepts the documents")
+	}
+	if _, err := sp.GetJob(gone.ID); !errors.Is(err, errJobNotFound) {
+		t.Errorf("GetJob error = %v, want %v for the unknown printer", err, errJobNotFound)
+	}
+	if id, err := sp.NextJobID(); err != nil || id != gone.ID+1 {
+		t.Errorf("NextJobID = %d, %v, want %d, after the restored jobs", id, err, gone.ID+1)
+	}
+}
+
+func TestSpoolRestoresPrintOptions(t *testing.T) {
+	dir := t.TempDir()
+	printer := mustWrapDriver(t, testDriver{}, "test-printer", "Test Printer")
+
+	sp := mustOpenSpool(t, dir, printer)
+	held := mustCreateJob(t, printer, 1, "held")
+	held.printOptions.pages = PageRanges{{First: 2, Last: 2}}
+	held.printOptions.dither = "atkinson"
+	if err := held.hold(context.Background()); err != nil {
+		t.Fatalf("hold: %v", err)
+	}
+	if err := sp.AddJob(held, tinyPNG(t)); err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	if err := sp.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	sp = mustOpenSpool(t, dir, printer)
+	defer sp.Close()
+	restored, err := sp.GetJob(held.ID)
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if got := restored.state(); got != JobPendingHeld {
+		t.Fatalf("restored job state = %v, want %v", got, JobPendingHeld)
+	}
+	if got := restored.printOptions.pages; !slices.Equal(got, held.printOptions.pages) {
+		t.Errorf("restored page ranges = %v, want %v", got, held.printOptions.pages)
+	}
+	if got := restored.printOptions.dither; got != "atkinson" {
+		t.Errorf("restored dither = %q, want %q", got, "atkinson")
+	}
+}
+
+func TestSpoolTemporaryIsRemoved(t *testing.T) {
+	sp, err := newSpool("")
+	if err != nil {
+		t.Fatalf("newSpool: %v", err)
+	}
+	if err := sp.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(sp.dir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Stat error = %v, want the temporary spool removed", err)
+	}
+}
+
+// newDriverIPPServer returns the IPP server of the test printer with the
+// driver.
+func newDriverIPPServer(t *testing.T, d Driver) *basicIPPServer {
+	t.Helper()
+
+	s, err := newBasicIPPServer("/printers/", "", mustWrapDriver(t, d, "test-printer", "Test Printer"))
+	if err != nil {
+		t.Fatalf("newBasicIPPServer: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := s.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Shutdown: %v", err)
+		}
+	})
+	return s
+}
+
+// mustPrintJob submits the Print-Job request and returns the spooled job.
+func mustPrintJob(t *testing.T, s *basicIPPServer) *Job {
+	t.Helper()
+
+	resp, err := s.handlePrintJob(context.Background(), newIPPRequest(goipp.OpPrintJob, testRequestID), tinyPNG(t))
+	if err != nil {
+		t.Fatalf("handlePrintJob: %v", err)
+	}
+	id, err := extractValue[goipp.Integer](resp.Job, "job-id")
+	if err != nil {
+		t.Fatalf("job-id: %v", err)
+	}
+	job, err := s.spool.GetJob(JobID(id))
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	return job
+}
+
+// jobRequest returns the request of the job operation.
+func jobRequest(op goipp.Op, id JobID) *goipp.Message {
+	req := newIPPRequest(op, testRequestID)
+	req.Operation.Add(goipp.MakeAttribute("job-id", goipp.TagInteger, goipp.Integer(id)))
+	return req
+}
+
+func TestHandlePrintJobReturnsBeforePrinting(t *testing.T) {
+	driver := newBlockingDriver(2)
+	s := newDriverIPPServer(t, driver)
+
+	first := mustPrintJob(t, s)
+	waitStarted(t, driver.entered, nil)
+	second := mustPrintJob(t, s) // returns while the first one is printing
+	if got := second.state(); got != JobPending {
+		t.Fatalf("queued job state = %v, want %v", got, JobPending)
+	}
+	close(driver.release)
+	waitJob(t, first)
+	waitJob(t, second)
+	if got := second.state(); got != JobCompleted {
+		t.Fatalf("job state = %v, want %v", got, JobCompleted)
+	}
+}
+
+func TestSpoolCloseAbortsPrinting(t *testing.T) {
+	sp, err := newSpool(t.TempDir())
+	if err != nil {
+		t.Fatalf("newSpool: %v", err)
+	}
+	driver := newBlockingDriver(1)
+	job := mustCreateJob(t, mustWrapDriver(t, driver, "test-printer", "Test Printer"), 42, "test-job")
+	if err := sp.AddJob(job, tinyPNG(t)); err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	waitStarted(t, driver.entered, nil)
+	if err := sp.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := job.state(); got != JobAborted {
+		t.Fatalf("job state = %v, want %v", got, JobAborted)
+	}
+	if want := []JobStateReason{JSRAbortedBySystem}; !slices.Equal(job.StateReasons, want) {
+		t.Fatalf("reasons = %v, want %v", job.StateReasons, want)
+	}
+}
+
+func TestHoldReleaseRestartJob(t *testing.T) {
+	driver := newBlockingDriver(3)
+	s := newDriverIPPServer(t, driver)
+	ctx := context.Background()
+
+	first := mustPrintJob(t, s)
+	waitStarted(t, driver.entered, nil)
+	second := mustPrintJob(t, s)
+	if _, err := s.handleHoldJob(ctx, jobRequest(goipp.OpHoldJob, second.ID), nil); err != nil {
+		t.Fatalf("Hold-Job: %v", err)
+	}
+	if _, err := s.handleHoldJob(ctx, jobRequest(goipp.OpHoldJob, first.ID), nil); ippStatusFromError(err) != goipp.StatusErrorNotPossible {
+		t.Fatalf("Hold-Job of the printing job error = %v, want client-error-not-possible", err)
+	}
+	close(driver.release)
+	waitJob(t, first)
+	assertNotStarted(t, driver.entered, "held job was printed")
+	if got := second.state(); got != JobPendingHeld {
+		t.Fatalf("job state = %v, want %v", got, JobPendingHeld)
+	}
+
+	if _, err := s.handleReleaseJob(ctx, jobRequest(goipp.OpReleaseJob, second.ID), nil); err != nil {
+		t.Fatalf("Release-Job: %v", err)
+	}
+	waitJob(t, second)
+	if got := second.state(); got != JobCompleted {
+		t.Fatalf("released job state = %v, want %v", got, JobCompleted)
+	}
+	if _, err := s.handleReleaseJob(ctx, jobRequest(goipp.OpReleaseJob, second.ID), nil); ippStatusFromError(err) != goipp.StatusErrorNotPossible {
+		t.Fatalf("Release-Job of the completed job error = %v, want client-error-not-possible", err)
+	}
+
+	if _, err := s.handleRestartJob(ctx, jobRequest(goipp.OpRestartJob, first.ID), nil); err != nil {
+		t.Fatalf("Restart-Job: %v", err)
+	}
+	waitJob(t, first)
+	// the first print was taken by waitStarted.
+	if got := len(driver.entered); got != 2 {
+		t.Fatalf("printed %d more times, want 2, the restarted job is printed again", got)
+	}
+	if got := first.state(); got != JobCompleted {
+		t.Fatalf("restarted job state = %v, want %v", got, JobCompleted)
+	}
+	if _, err := s.handleRestartJob(ctx, jobRequest(goipp.OpRestartJob, first.ID+100), nil); ippStatusFromError(err) != goipp.StatusErrorNotFound {
+		t.Fatalf("Restart-Job of the unknown job error = %v, want client-error-not-found", err)
+	}
+}
+
+func TestMaxQueueRefusesJobs(t *testing.T) {
+	driver := newBlockingDriver(3)
+	s := newDriverIPPServer(t, driver)
+	s.spool.(*spool).setMaxQueue(2)
+	ctx := context.Background()
+
+	first := mustPrintJob(t, s)
+	waitStarted(t, driver.entered, nil)
+	second := mustPrintJob(t, s)
+	if got := s.spool.GetJobCount("test-printer"); got != 2 {
+		t.Fatalf("GetJobCount = %d, want 2", got)
+	}
+	if _, err := s.handlePrintJob(ctx, newIPPRequest(goipp.OpPrintJob, testRequestID), tinyPNG(t)); ippStatusFromError(err) != goipp.StatusErrorBusy {
+		t.Fatalf("Print-Job error = %v, want server-error-busy", err)
+	}
+	if _, err := s.handleCreateJob(ctx, newIPPRequest(goipp.OpCreateJob, testRequestID), nil); ippStatusFromError(err) != goipp.StatusErrorBusy {
+		t.Fatalf("Create-Job error = %v, want server-error-busy", err)
+	}
+
+	close(driver.release)
+	waitJob(t, first)
+	waitJob(t, second)
+	if got := s.spool.GetJobCount("test-printer"); got != 0 {
+		t.Fatalf("GetJobCount = %d, want 0, the finished jobs are not queued", got)
+	}
+	waitJob(t, mustPrintJob(t, s))
+}
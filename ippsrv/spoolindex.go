@@ -0,0 +1,297 @@
+package ippsrv
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Files of the persistent spool, see [WithSpoolDir].
+const (
+	jobIDFile    = "job_id"    // the last issued job id
+	jobIndexFile = "jobs.json" // the jobs and their states
+)
+
+// WithSpoolDir sets the spool directory.  The spool in the given directory
+// is persistent: the job ids continue from the last one, and the jobs, with
+// their states, are restored on the server restart, so that Get-Jobs lists
+// them.  By default, the spool is temporary and is removed on shutdown.
+func WithSpoolDir(dir string) Option {
+	return func(s *Server) {
+		s.spoolDir = dir
+	}
+}
+
+// jobRecord is the job in the job index of the persistent spool.
+type jobRecord struct {
+	ID              JobID            `json:"id"`
+	Printer         string           `json:"printer"`
+	State           JobState         `json:"state"`
+	StateReasons    []JobStateReason `json:"state_reasons,omitempty"`
+	Name            string           `json:"name"`
+	Created         time.Time        `json:"created"`
+	Processing      time.Time        `json:"processing,omitzero"`
+	Completed       time.Time        `json:"completed,omitzero"`
+	Username        string           `json:"username"`
+	JobURI          string           `json:"job_uri"`
+	PrinterURI      string           `json:"printer_uri"`
+	Format          string           `json:"format,omitempty"`
+	Sheets          int              `json:"sheets,omitempty"`
+	SheetsCompleted int              `json:"sheets_completed,omitempty"`
+	Documents       []string         `json:"documents,omitempty"`
+	Files           []string         `json:"files,omitempty"`
+	LastDocument    bool             `json:"last_document,omitempty"`
+	Options         optionsRecord    `json:"options,omitzero"`
+}
+
+// optionsRecord is the print options of the job in the job index, the
+// restored job is printed as it was requested.  The progress callback is
+// not saved, it is set by the spool, when the job is printed.
+type optionsRecord struct {
+	TrimTrailingBlank bool        `json:"trim_trailing_blank,omitempty"`
+	Pages             string      `json:"pages,omitempty"`
+	Fit               Fit         `json:"fit,omitempty"`
+	Format            string      `json:"format,omitempty"`
+	OriginUser        string      `json:"origin_user,omitempty"`
+	OriginHost        string      `json:"origin_host,omitempty"`
+	OriginJobID       JobID       `json:"origin_job_id,omitempty"`
+	OriginTime        time.Time   `json:"origin_time,omitzero"`
+	Orientation       Orientation `json:"orientation,omitempty"`
+	Quality           Quality     `json:"quality,omitempty"`
+	Lang              string      `json:"lang,omitempty"`
+	Dither            string      `json:"dither,omitempty"`
+}
+
+// record returns the job index record of the print options.
+func (o printJobOptions) record() optionsRecord {
+	return optionsRecord{
+		TrimTrailingBlank: o.trimTrailingBlank,
+		Pages:             o.pages.String(),
+		Fit:               o.fit,
+		Format:            o.format,
+		OriginUser:        o.origin.User,
+		OriginHost:        o.origin.Host,
+		OriginJobID:       o.origin.JobID,
+		OriginTime:        o.origin.Time,
+		Orientation:       o.orientation,
+		Quality:           o.quality,
+		Lang:              o.lang,
+		Dither:            o.dither,
+	}
+}
+
+// printOptions returns the print options of the job index record.
+func (r optionsRecord) printOptions() (printJobOptions, error) {
+	var pages PageRanges
+	if r.Pages != "" {
+		var err error
+		if pages, err = ParsePageRanges(r.Pages); err != nil {
+			return printJobOptions{}, fmt.Errorf("invalid page ranges: %w", err)
+		}
+	}
+	return printJobOptions{
+		trimTrailingBlank: r.TrimTrailingBlank,
+		pages:             pages,
+		fit:               r.Fit,
+		format:            r.Format,
+		origin:            JobOrigin{User: r.OriginUser, Host: r.OriginHost, JobID: r.OriginJobID, Time: r.OriginTime},
+		orientation:       r.Orientation,
+		quality:           r.Quality,
+		lang:              r.Lang,
+		dither:            r.Dither,
+	}, nil
+}
+
+// record returns the job index record of the job.
+func (j *Job) record() jobRecord {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return jobRecord{
+		ID:              j.ID,
+		Printer:         j.Printer.Name(),
+		State:           j.State,
+		StateReasons:    slices.Clone(j.StateReasons),
+		Name:            j.Name,
+		Created:         j.Created,
+		Processing:      j.Processing,
+		Completed:       j.Completed,
+		Username:        j.Username,
+		JobURI:          j.JobURI,
+		PrinterURI:      j.PrinterURI,
+		Format:          j.Format,
+		Sheets:          j.Sheets,
+		SheetsCompleted: j.SheetsCompleted,
+		Documents:       slices.Clone(j.documents),
+		Files:           slices.Clone(j.files),
+		LastDocument:    j.lastDocument,
+		Options:         j.printOptions.record(),
+	}
+}
+
+// restoreJob returns the job of the job index record.  The job, that was
+// queued for printing or held, stays so.  The job, that was printing or waiting
+// for the documents when the server stopped, is restored as aborted by the
+// system: it is not printed twice or incomplete after the restart.
+func restoreJob(p Printer, r jobRecord) (*Job, error) {
+	job, err := createJob(p, r.ID, r.PrinterURI, r.JobURI, r.Name, r.Username, r.Format)
+	if err != nil {
+		return nil, err
+	}
+	if job.printOptions, err = r.Options.printOptions(); err != nil {
+		return nil, err
+	}
+	job.State = r.State
+	job.StateReasons = r.StateReasons
+	job.Created = r.Created
+	job.Processing = r.Processing
+	job.Completed = r.Completed
+	job.Sheets = r.Sheets
+	job.SheetsCompleted = r.SheetsCompleted
+	job.documents = r.Documents
+	job.files = r.Files
+	job.lastDocument = true
+	if (r.State == JobPending || r.State == JobPendingHeld) && r.LastDocument {
+		job.sm.SetState(job.State.String())
+		return job, nil
+	}
+	if !isCompletedState(r.State) {
+		job.State = JobAborted
+		job.StateReasons = []JobStateReason{JSRAbortedBySystem}
+		job.Completed = time.Now()
+	}
+	job.sm.SetState(job.State.String())
+	return job, nil
+}
+
+// openSpool opens the persistent spool in the directory, restoring the last
+// job id and the jobs of the printers.  The jobs of the printers, that are
+// no longer served, are skipped.
+func openSpool(dir string, printers map[string]Printer) (*spool, error) {
+	sp, err := newSpool(dir)
+	if err != nil {
+		return nil, err
+	}
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.persistent = true
+	if err := sp.loadLocked(printers); err != nil {
+		sp.stop()
+		close(sp.msgC)
+		return nil, err
+	}
+	return sp, nil
+}
+
+// loadLocked loads the last job id and the job index.
+func (s *spool) loadLocked(printers map[string]Printer) error {
+	data, err := os.ReadFile(filepath.Join(s.dir, jobIDFile))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read the job id: %w", err)
+	default:
+		id, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid job id in %s: %w", jobIDFile, err)
+		}
+		s.lastID = JobID(id)
+	}
+
+	data, err = os.ReadFile(filepath.Join(s.dir, jobIndexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read the job index: %w", err)
+	}
+	var records []jobRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("invalid job index %s: %w", jobIndexFile, err)
+	}
+	for _, r := range records {
+		// the job id file may lag behind the index, if it was not written.
+		s.lastID = max(s.lastID, r.ID)
+		p, ok := printers[r.Printer]
+		if !ok {
+			slog.Warn("skipping the job of the unknown printer", "job_id", r.ID, "printer", r.Printer)
+			continue
+		}
+		job, err := restoreJob(p, r)
+		if err != nil {
+			return fmt.Errorf("failed to restore job %d: %w", r.ID, err)
+		}
+		if err := s.addJobLocked(job); err != nil {
+			return fmt.Errorf("failed to restore job %d: %w", r.ID, err)
+		}
+		if job.queued() {
+			s.wakeLocked(p.Name())
+		}
+	}
+	s.syncLocked() // the unfinished jobs are aborted now
+	slog.Info("spool restored", "dir", s.dir, "jobs", len(s.jobs), "last_job_id", s.lastID)
+	return nil
+}
+
+// NextJobID returns the new job id, one greater than the last one.  The id
+// of the persistent spool is saved before it is returned, so that it is not
+// issued again after the restart.
+func (s *spool) NextJobID() (JobID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.lastID + 1
+	if s.persistent {
+		if err := writeFileAtomic(filepath.Join(s.dir, jobIDFile), []byte(strconv.Itoa(int(id))+"\n")); err != nil {
+			return 0, fmt.Errorf("failed to save the job id: %w", err)
+		}
+	}
+	s.lastID = id
+	return id, nil
+}
+
+// syncLocked saves the job index of the persistent spool.  It is saved when
+// the jobs are added or removed, and the state changes are saved by the
+// spool worker and on close.
+func (s *spool) syncLocked() {
+	s.dirty.Store(false)
+	if !s.persistent {
+		return
+	}
+	records := make([]jobRecord, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		records = append(records, job.record())
+	}
+	slices.SortFunc(records, func(a, b jobRecord) int { return cmp.Compare(a.ID, b.ID) })
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		slog.Error("failed to encode the job index", "error", err)
+		return
+	}
+	if err := writeFileAtomic(filepath.Join(s.dir, jobIndexFile), data); err != nil {
+		slog.Error("failed to save the job index", "error", err)
+	}
+}
+
+// writeFileAtomic writes the file through the temporary file in the same
+// directory, so that the file is never left half-written.
+func writeFileAtomic(name string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
@@ -0,0 +1,132 @@
+package ippsrv
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rusq/thermoprint/cupsraster"
+)
+
+// SpoolNaming is the pattern of the file names of the spooled documents,
+// with the placeholders:
+//
+//	{id}       the job id, required
+//	{printer}  the printer name
+//	{time}     the time the job was created, 20060102-150405
+//	{doc}      the document number of the job, "_2" for the second one,
+//	           empty for the first one
+//	{ext}      the extension of the detected document format, i.e. "pdf"
+//
+// Without {doc}, the number of the second and the following documents is
+// added before the extension.
+type SpoolNaming string
+
+// DefaultSpoolNaming is the default naming of the spooled documents.
+const DefaultSpoolNaming SpoolNaming = "job_{id}_{printer}_{time}{doc}.{ext}"
+
+var spoolPlaceholders = regexp.MustCompile(`\{[^}]*\}`)
+
+// ParseSpoolNaming parses the naming pattern, see [SpoolNaming].
+func ParseSpoolNaming(s string) (SpoolNaming, error) {
+	if !strings.Contains(s, "{id}") {
+		return "", fmt.Errorf("spool naming %q: {id} is required", s)
+	}
+	if strings.ContainsAny(s, `/\`) {
+		return "", fmt.Errorf("spool naming %q: must be the file name, not the path", s)
+	}
+	for _, ph := range spoolPlaceholders.FindAllString(s, -1) {
+		switch ph {
+		case "{id}", "{printer}", "{time}", "{doc}", "{ext}":
+		default:
+			return "", fmt.Errorf("spool naming %q: unknown placeholder %s", s, ph)
+		}
+	}
+	return SpoolNaming(s), nil
+}
+
+// WithSpoolNaming sets the naming of the spooled documents, see
+// [SpoolNaming].
+func WithSpoolNaming(n SpoolNaming) Option {
+	return func(s *Server) {
+		s.spoolNaming = n
+	}
+}
+
+// unsafeFilename are the characters, that are replaced in the printer
+// name.
+var unsafeFilename = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// filename returns the file name of the nth document of the job in the
+// detected format.
+func (sn SpoolNaming) filename(job *Job, n int, format string) string {
+	var doc string
+	if n > 1 {
+		doc = "_" + strconv.Itoa(n)
+	}
+	name := string(sn)
+	if !strings.Contains(name, "{doc}") && doc != "" {
+		if i := strings.LastIndex(name, "."); i > 0 {
+			name = name[:i] + doc + name[i:]
+		} else {
+			name += doc
+		}
+	}
+	return strings.NewReplacer(
+		"{id}", strconv.Itoa(int(job.ID)),
+		"{printer}", unsafeFilename.ReplaceAllString(job.Printer.Name(), "_"),
+		"{time}", job.Created.Format("20060102-150405"),
+		"{doc}", doc,
+		"{ext}", formatExtension(format),
+	).Replace(name)
+}
+
+// formatExtensions are the file extensions of the document formats.
+var formatExtensions = map[string]string{
+	ippApplicationPDF.String(): "pdf",
+	"application/postscript":   "ps",
+	ippImagePWGRaster.String(): "pwg",
+	ippImageURF.String():       "urf",
+	ippTextPlain.String():      "txt",
+	"image/png":                "png",
+	"image/jpeg":               "jpg",
+	"image/gif":                "gif",
+	"image/bmp":                "bmp",
+	"image/webp":               "webp",
+}
+
+// formatExtension returns the file extension of the media type, "bin" for
+// the unknown one.
+func formatExtension(format string) string {
+	if ext, ok := formatExtensions[format]; ok {
+		return ext
+	}
+	return "bin"
+}
+
+// detectFormat returns the media type of the document: the format, that
+// the client named, or the one sniffed from the data, if the client did
+// not name it, or sent application/octet-stream.
+func detectFormat(format string, data []byte) string {
+	if mediaType, _, err := mime.ParseMediaType(format); err == nil && mediaType != ippApplicationOctetStream.String() {
+		return mediaType
+	}
+	switch {
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return ippApplicationPDF.String()
+	case bytes.HasPrefix(data, []byte("%!")):
+		return "application/postscript"
+	}
+	switch cupsraster.Detect(data) {
+	case cupsraster.FormatPWG:
+		return ippImagePWGRaster.String()
+	case cupsraster.FormatURF:
+		return ippImageURF.String()
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	return mediaType
+}
@@ -0,0 +1,55 @@
+package ippsrv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSpoolNaming(t *testing.T) {
+	n, err := ParseSpoolNaming("{time}-{id}.{ext}")
+	require.NoError(t, err)
+	assert.Equal(t, SpoolNaming("{time}-{id}.{ext}"), n)
+
+	for _, s := range []string{"job.{ext}", "../{id}.{ext}", `{id}\x`, "{id}-{user}.{ext}"} {
+		_, err := ParseSpoolNaming(s)
+		assert.Error(t, err, s)
+	}
+	_, err = ParseSpoolNaming(string(DefaultSpoolNaming))
+	assert.NoError(t, err)
+}
+
+func TestSpoolNamingFilename(t *testing.T) {
+	job, err := createJob(mustWrapDriver(t, testDriver{}, "lx d02/kitchen", "Test Printer"), 7, "", "", "job", "alice", "")
+	require.NoError(t, err)
+	job.Created = time.Date(2026, 10, 16, 14, 2, 3, 0, time.UTC)
+
+	assert.Equal(t, "job_7_lx_d02_kitchen_20261016-140203.png", DefaultSpoolNaming.filename(job, 1, "image/png"))
+	assert.Equal(t, "job_7_lx_d02_kitchen_20261016-140203_2.pdf", DefaultSpoolNaming.filename(job, 2, "application/pdf"))
+	// without {doc}, the number goes before the extension.
+	assert.Equal(t, "7_3.bin", SpoolNaming("{id}.{ext}").filename(job, 3, "application/x-unknown"))
+	assert.Equal(t, "7_2", SpoolNaming("{id}").filename(job, 2, ""))
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		data   []byte
+		want   string
+	}{
+		{"application/pdf", []byte("anything"), "application/pdf"},
+		{"text/plain; charset=utf-8", []byte("hello"), "text/plain"},
+		{"", []byte("%PDF-1.7\n"), "application/pdf"},
+		{"application/octet-stream", []byte("%!PS-Adobe-3.0\n"), "application/postscript"},
+		{"", []byte("UNIRAST\x00"), "image/urf"},
+		{"", tinyPNG(t), "image/png"},
+		{"", []byte("Milk, eggs\n"), "text/plain"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, detectFormat(tt.format, tt.data), tt.format)
+	}
+	assert.Equal(t, "urf", formatExtension(detectFormat("", []byte("UNIRAST\x00"))))
+	assert.Equal(t, "bin", formatExtension("application/x-unknown"))
+}
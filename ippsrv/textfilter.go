@@ -0,0 +1,128 @@
+package ippsrv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"log/slog"
+	"math"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+	"golang.org/x/image/font"
+
+	"github.com/rusq/thermoprint/bitmap"
+)
+
+// pdfTextFilter extracts the text from the PDF documents and renders it with
+// the font on the pages of the printer width, instead of rasterising the
+// pages.  The text of the documents designed for A4 remains legible at 384
+// pixels, although the layout, images and the font styles are lost.
+// Documents that are not PDF, or that have no text, i.e. scans, are passed
+// to the fallback filter.
+type pdfTextFilter struct {
+	width    int
+	face     font.Face
+	fallback Filter
+}
+
+var _ PageFilter = &pdfTextFilter{}
+
+// NewTextFilter returns the filter that reprints the text of the PDF
+// documents with the face on the pages of the given width, everything else
+// is converted with the fallback filter.
+func NewTextFilter(width int, face font.Face, fallback Filter) Filter {
+	return &pdfTextFilter{width: width, face: face, fallback: fallback}
+}
+
+func (f *pdfTextFilter) ToRaster(ctx context.Context, dpi int, data []byte) ([]image.Image, error) {
+	return f.ToRasterPages(ctx, dpi, data, nil)
+}
+
+func (f *pdfTextFilter) ToRasterPages(ctx context.Context, dpi int, data []byte, sel PageRanges) ([]image.Image, error) {
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return ToRasterPages(ctx, f.fallback, dpi, data, sel)
+	}
+	texts, err := pdfPageTexts(data, sel)
+	if err != nil {
+		slog.WarnContext(ctx, "unable to extract the text, rasterising the document", "error", err)
+		return ToRasterPages(ctx, f.fallback, dpi, data, sel)
+	}
+	if strings.TrimSpace(strings.Join(texts, "")) == "" {
+		slog.InfoContext(ctx, "document has no text, rasterising")
+		return ToRasterPages(ctx, f.fallback, dpi, data, sel)
+	}
+	slog.InfoContext(ctx, "printing the text of the document", "pages", len(texts))
+	images := make([]image.Image, 0, len(texts))
+	for i, text := range texts {
+		img, err := bitmap.RenderTTF(strings.TrimSpace(text), f.face, f.width, bitmap.WithWrap(true))
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+// pdfPageTexts returns the plain text of the selected pages of the PDF
+// document.
+func pdfPageTexts(data []byte, sel PageRanges) (texts []string, err error) {
+	// the pdf package panics on some malformed documents.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i <= r.NumPage(); i++ {
+		if !sel.Contains(i) {
+			continue
+		}
+		p := r.Page(i)
+		if p.V.IsNull() {
+			continue
+		}
+		texts = append(texts, pageText(p.Content().Text))
+	}
+	return texts, nil
+}
+
+// pageText joins the glyphs of the page into lines.  A glyph starts the new
+// line, when it is lower or higher than the line by more than half of the
+// font size, and a larger vertical gap starts the new paragraph.  The space
+// is inserted between the glyphs, that are set apart horizontally, because
+// the PDF documents often position the words instead of using the spaces.
+func pageText(glyphs []pdf.Text) string {
+	var (
+		sb   strings.Builder
+		prev *pdf.Text
+	)
+	for i := range glyphs {
+		g := &glyphs[i]
+		if g.S == "" || g.S == "\n" {
+			continue
+		}
+		if prev != nil {
+			size := max(prev.FontSize, g.FontSize, 1)
+			switch dy := prev.Y - g.Y; {
+			case dy > 1.8*size:
+				sb.WriteString("\n\n")
+			case math.Abs(dy) > size/2:
+				sb.WriteString("\n")
+			case g.X-(prev.X+prev.W) > size/4 && prev.S != " " && g.S != " ":
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString(g.S)
+		prev = g
+	}
+	return sb.String()
+}
+
+func (f *pdfTextFilter) Type() string {
+	return "text+" + f.fallback.Type()
+}
@@ -0,0 +1,125 @@
+package ippsrv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rusq/thermoprint/fontmgr"
+)
+
+// minimalPDF builds the PDF document with a page for each of the texts,
+// the lines of the text are set one under another, empty texts produce the
+// pages without the text.
+func minimalPDF(t *testing.T, texts ...string) []byte {
+	t.Helper()
+	// objects: 1 catalog, 2 pages, 3 font, then the page and its contents
+	// for each text.
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // pages, filled in below
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	var kids []string
+	for _, text := range texts {
+		page := len(objs) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+		objs = append(objs, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", page+1))
+		var content string
+		if text != "" {
+			lines := strings.Split(text, "\n")
+			for i := range lines {
+				lines[i] = "(" + lines[i] + ") Tj"
+			}
+			content = "BT /F1 12 Tf 72 720 Td " + strings.Join(lines, " 0 -14 Td ") + " ET"
+		}
+		objs = append(objs, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+	objs[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(texts))
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, obj := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	return buf.Bytes()
+}
+
+func TestPDFPageTexts(t *testing.T) {
+	data := minimalPDF(t, "Hello thermal world\nsecond line", "", "Third page")
+	texts, err := pdfPageTexts(data, nil)
+	require.NoError(t, err)
+	require.Len(t, texts, 3)
+	assert.Equal(t, "Hello thermal world\nsecond line", texts[0])
+	assert.Empty(t, texts[1])
+	assert.Contains(t, texts[2], "Third page")
+
+	texts, err = pdfPageTexts(data, PageRanges{{First: 3, Last: 3}})
+	require.NoError(t, err)
+	require.Len(t, texts, 1)
+	assert.Contains(t, texts[0], "Third page")
+
+	_, err = pdfPageTexts([]byte("%PDF-1.4\ngarbage"), nil)
+	assert.Error(t, err)
+}
+
+func TestPageText(t *testing.T) {
+	word := func(s string, x, y float64) []pdf.Text {
+		var glyphs []pdf.Text
+		for i, r := range s {
+			glyphs = append(glyphs, pdf.Text{FontSize: 10, X: x + float64(i)*5, Y: y, W: 5, S: string(r)})
+		}
+		return glyphs
+	}
+	var glyphs []pdf.Text
+	glyphs = append(glyphs, word("two", 0, 100)...)
+	glyphs = append(glyphs, word("words", 20, 100)...) // 5pt apart
+	glyphs = append(glyphs, pdf.Text{S: "\n"})
+	glyphs = append(glyphs, word("next", 0, 88)...)
+	glyphs = append(glyphs, word("paragraph", 0, 60)...)
+	assert.Equal(t, "two words\nnext\n\nparagraph", pageText(glyphs))
+}
+
+func TestTextFilter(t *testing.T) {
+	t.Run("renders the text", func(t *testing.T) {
+		rec := &recordingFilter{}
+		f := NewTextFilter(384, fontmgr.DefaultFont, rec)
+		pages, err := f.ToRaster(context.Background(), 203, minimalPDF(t, "Hello thermal world", "Second page"))
+		require.NoError(t, err)
+		assert.False(t, rec.called)
+		require.Len(t, pages, 2)
+		for _, pg := range pages {
+			assert.Equal(t, 384, pg.Bounds().Dx())
+			assert.False(t, IsBlank(pg, DefaultBlankThreshold))
+		}
+	})
+	t.Run("no text falls back", func(t *testing.T) {
+		rec := &recordingFilter{}
+		f := NewTextFilter(384, fontmgr.DefaultFont, rec)
+		_, err := f.ToRaster(context.Background(), 203, minimalPDF(t, "", ""))
+		require.NoError(t, err)
+		assert.True(t, rec.called)
+	})
+	t.Run("not a PDF falls back", func(t *testing.T) {
+		rec := &recordingFilter{}
+		f := NewTextFilter(384, fontmgr.DefaultFont, rec)
+		_, err := f.ToRaster(context.Background(), 203, []byte("%!PS-Adobe-3.0"))
+		require.NoError(t, err)
+		assert.True(t, rec.called)
+		assert.Equal(t, "text+recording", f.Type())
+	})
+}
@@ -0,0 +1,220 @@
+package ippsrv
+
+// IPPS, IPP over TLS (RFC 8011 §5.1.2, RFC 7472).  The TLS and the plain
+// IPP clients are served on the same port, like CUPS does: the connection,
+// that starts with the TLS handshake record, is TLS.
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/OpenPrinting/goipp"
+)
+
+// certValidity is the validity of the self-signed certificate.
+const certValidity = 10 * 365 * 24 * time.Hour
+
+// recordTypeHandshake is the first byte of the TLS connection, the content
+// type of the ClientHello record.
+const recordTypeHandshake = 0x16
+
+// WithTLS enables IPPS with the certificate and the key in the PEM files.
+// If the files do not exist, the self-signed certificate for the host name
+// is generated and saved to them on the first run.  The printers are
+// advertised with both ipp:// and ipps:// URIs, and the plain IPP is served
+// on the same port.
+func WithTLS(certFile, keyFile string) Option {
+	return func(s *Server) {
+		s.tls.certFile = certFile
+		s.tls.keyFile = keyFile
+	}
+}
+
+// ippTLS is the uri-security-supported value of the ipps:// URI.
+const ippTLS goipp.String = "tls"
+
+// printerURIs returns the values of printer-uri-supported and the parallel
+// uri-security-supported: the printer URI, and, if IPPS is enabled, the same
+// URI with the other scheme, so that the client may switch to TLS.
+func printerURIs(uri string, tlsEnabled bool) (uris, security []goipp.Value) {
+	uris, security = []goipp.Value{goipp.String(uri)}, []goipp.Value{ippNone}
+	if !tlsEnabled {
+		return uris, security
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uris, security
+	}
+	switch u.Scheme {
+	case "ipp":
+		u.Scheme = "ipps"
+		return append(uris, goipp.String(u.String())), append(security, ippTLS)
+	case "ipps":
+		u.Scheme = "ipp"
+		return append(uris, goipp.String(u.String())), []goipp.Value{ippTLS, ippNone}
+	default:
+		return uris, security
+	}
+}
+
+// tlsConfig returns the TLS configuration with the certificate, that is
+// generated, if the files do not exist.
+func tlsConfig(certFile, keyFile string) (*tls.Config, error) {
+	_, errCert := os.Stat(certFile)
+	_, errKey := os.Stat(keyFile)
+	if errors.Is(errCert, os.ErrNotExist) && errors.Is(errKey, os.ErrNotExist) {
+		hosts := []string{"localhost"}
+		if h, err := localHostname(); err == nil {
+			hosts = append(hosts, h, h+".local")
+		}
+		if err := generateCertificate(certFile, keyFile, hosts); err != nil {
+			return nil, fmt.Errorf("failed to generate the certificate: %w", err)
+		}
+		slog.Info("generated the self-signed certificate", "cert", certFile, "key", keyFile, "hosts", hosts)
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the certificate: %w", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// generateCertificate writes the self-signed certificate for the hosts and
+// its key to the PEM files.
+func generateCertificate(certFile, keyFile string, hosts []string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: hosts[len(hosts)-1], Organization: []string{"Thermoprint"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(certValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	tmpl.DNSNames = append(tmpl.DNSNames, hosts...)
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	for _, f := range []struct {
+		name  string
+		typ   string
+		bytes []byte
+		perm  os.FileMode
+	}{
+		{keyFile, "PRIVATE KEY", keyDER, 0600},
+		{certFile, "CERTIFICATE", der, 0644},
+	} {
+		if err := os.MkdirAll(filepath.Dir(f.name), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(f.name, pem.EncodeToMemory(&pem.Block{Type: f.typ, Bytes: f.bytes}), f.perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sniffListener accepts the TLS and the plain connections on the same
+// listener.
+type sniffListener struct {
+	net.Listener
+	config *tls.Config
+}
+
+func (l *sniffListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &sniffConn{Conn: c, config: l.config}, nil
+}
+
+// sniffConn is the connection, that turns into the TLS one on the first
+// read, if the client starts with the TLS handshake.  It is not decided in
+// Accept, so that the slow client does not block the others.
+type sniffConn struct {
+	net.Conn // the raw connection
+	config   *tls.Config
+
+	once sync.Once
+	r    io.Reader // the plain or the TLS stream
+	w    io.Writer
+	err  error
+}
+
+func (c *sniffConn) sniff() {
+	br := bufio.NewReader(c.Conn)
+	b, err := br.Peek(1)
+	if err != nil {
+		c.err = err
+		return
+	}
+	if b[0] == recordTypeHandshake {
+		tc := tls.Server(&peekedConn{Conn: c.Conn, r: br}, c.config)
+		c.r, c.w = tc, tc
+		return
+	}
+	c.r, c.w = br, c.Conn
+}
+
+func (c *sniffConn) Read(p []byte) (int, error) {
+	c.once.Do(c.sniff)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.r.Read(p)
+}
+
+func (c *sniffConn) Write(p []byte) (int, error) {
+	c.once.Do(c.sniff)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.w.Write(p)
+}
+
+// peekedConn is the connection, that reads the bytes peeked by the reader
+// first.
+type peekedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *peekedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
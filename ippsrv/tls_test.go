@@ -0,0 +1,94 @@
+package ippsrv
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenPrinting/goipp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrinterURIs(t *testing.T) {
+	uris, security := printerURIs("ipp://host:6310/printers/default", false)
+	assert.Equal(t, []goipp.Value{goipp.String("ipp://host:6310/printers/default")}, uris)
+	assert.Equal(t, []goipp.Value{ippNone}, security)
+
+	uris, security = printerURIs("ipp://host:6310/printers/default", true)
+	assert.Equal(t, []goipp.Value{
+		goipp.String("ipp://host:6310/printers/default"),
+		goipp.String("ipps://host:6310/printers/default"),
+	}, uris)
+	assert.Equal(t, []goipp.Value{ippNone, ippTLS}, security)
+
+	uris, security = printerURIs("ipps://host:6310/printers/default", true)
+	assert.Equal(t, []goipp.Value{
+		goipp.String("ipps://host:6310/printers/default"),
+		goipp.String("ipp://host:6310/printers/default"),
+	}, uris)
+	assert.Equal(t, []goipp.Value{ippTLS, ippNone}, security)
+}
+
+func TestTLSConfigGeneratesCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls", "ipps.crt"), filepath.Join(dir, "tls", "ipps.key")
+
+	config, err := tlsConfig(certFile, keyFile)
+	require.NoError(t, err)
+	require.Len(t, config.Certificates, 1)
+	leaf := config.Certificates[0].Leaf
+	require.NotNil(t, leaf)
+	assert.Contains(t, leaf.DNSNames, "localhost")
+
+	// the second run loads the same certificate.
+	again, err := tlsConfig(certFile, keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, leaf.Raw, again.Certificates[0].Leaf.Raw)
+}
+
+func TestTLSConfigMissingKey(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "ipps.crt"), filepath.Join(dir, "ipps.key")
+	require.NoError(t, generateCertificate(certFile, filepath.Join(dir, "other.key"), []string{"localhost"}))
+
+	_, err := tlsConfig(certFile, keyFile)
+	assert.Error(t, err, "the certificate without the key is not replaced")
+}
+
+func TestSniffListener(t *testing.T) {
+	dir := t.TempDir()
+	config, err := tlsConfig(filepath.Join(dir, "ipps.crt"), filepath.Join(dir, "ipps.key"))
+	require.NoError(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	})}
+	go srv.Serve(&sniffListener{Listener: l, config: config})
+	t.Cleanup(func() { srv.Close() })
+
+	get := func(t *testing.T, c *http.Client, url string) string {
+		t.Helper()
+		resp, err := c.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+	addr := l.Addr().String()
+	t.Run("plain", func(t *testing.T) {
+		assert.Equal(t, "ok", get(t, &http.Client{}, "http://"+addr+"/"))
+	})
+	t.Run("tls", func(t *testing.T) {
+		c := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}}
+		assert.Equal(t, "ok", get(t, c, "https://"+addr+"/"))
+	})
+}
@@ -0,0 +1,169 @@
+package label
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"maps"
+	"slices"
+)
+
+// iconSize is the width and height of the built-in icons, in pixels before
+// scaling.
+const iconSize = 16
+
+// icons are the built-in icons, "#" is black, anything else is white.
+var icons = map[string][iconSize]string{
+	"arrow-up": {
+		"................",
+		".......##.......",
+		"......####......",
+		".....######.....",
+		"....########....",
+		"...##########...",
+		"..############..",
+		".##############.",
+		".....######.....",
+		".....######.....",
+		".....######.....",
+		".....######.....",
+		".....######.....",
+		".....######.....",
+		".....######.....",
+		"................",
+	},
+	"bolt": {
+		"................",
+		"..........####..",
+		".........####...",
+		"........####....",
+		".......####.....",
+		"......####......",
+		".....##########.",
+		"....##########..",
+		".........####...",
+		"........####....",
+		".......####.....",
+		"......###.......",
+		".....##.........",
+		"....#...........",
+		"................",
+		"................",
+	},
+	"box": {
+		"................",
+		".##############.",
+		".#............#.",
+		".#............#.",
+		".##############.",
+		"..#..........#..",
+		"..#...####...#..",
+		"..#..........#..",
+		"..#..........#..",
+		"..#..........#..",
+		"..#..........#..",
+		"..#..........#..",
+		"..#..........#..",
+		"..############..",
+		"................",
+		"................",
+	},
+	"check": {
+		"................",
+		"................",
+		".............##.",
+		"............####",
+		"...........####.",
+		"..........####..",
+		".........####...",
+		".##.....#####...",
+		"####...#####....",
+		".####..####.....",
+		"..########......",
+		"...######.......",
+		"....####........",
+		".....##.........",
+		"................",
+		"................",
+	},
+	"cross": {
+		"................",
+		"................",
+		"..###......###..",
+		"..####....####..",
+		"..#####..#####..",
+		"...##########...",
+		"....########....",
+		".....######.....",
+		".....######.....",
+		"....########....",
+		"...##########...",
+		"..#####..#####..",
+		"..####....####..",
+		"..###......###..",
+		"................",
+		"................",
+	},
+	"heart": {
+		"................",
+		"................",
+		"...####..####...",
+		"..############..",
+		"..############..",
+		"..############..",
+		"..############..",
+		"..############..",
+		"...##########...",
+		"...##########...",
+		"....########....",
+		".....######.....",
+		".......##.......",
+		"................",
+		"................",
+		"................",
+	},
+	"warning": {
+		".......##.......",
+		"......####......",
+		"......#..#......",
+		".....##..##.....",
+		".....#.##.#.....",
+		"....##.##.##....",
+		"....#..##..#....",
+		"...##..##..##...",
+		"...#...##...#...",
+		"..##........##..",
+		"..#....##....#..",
+		".##....##....##.",
+		".#............#.",
+		"################",
+		"################",
+		"................",
+	},
+}
+
+// Icons returns the names of the built-in icons.
+func Icons() []string {
+	return slices.Sorted(maps.Keys(icons))
+}
+
+// Icon returns the built-in icon, each pixel of which is scaled to the
+// square of scale pixels.
+func Icon(name string, scale int) (*image.Gray, error) {
+	rows, ok := icons[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown icon %q, available: %v", name, Icons())
+	}
+	scale = max(1, scale)
+	img := image.NewGray(image.Rect(0, 0, iconSize*scale, iconSize*scale))
+	for y := range img.Bounds().Dy() {
+		for x := range img.Bounds().Dx() {
+			c := color.Gray{Y: 0xff}
+			if rows[y/scale][x/scale] == '#' {
+				c.Y = 0
+			}
+			img.SetGray(x, y, c)
+		}
+	}
+	return img, nil
+}
@@ -0,0 +1,266 @@
+// Package label renders the labels, i.e. asset tags and inventory labels,
+// described by the structured data instead of the document script.
+package label
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+
+	"github.com/rusq/thermoprint/bitmap"
+	"github.com/rusq/thermoprint/fontmgr"
+)
+
+const (
+	// gap is the gap between the parts of the label, in pixels.
+	gap = 8
+	// titleScale is the magnification of the title.
+	titleScale = 2
+	// iconScale is the magnification of the icon.
+	iconScale = 4
+	// defaultBarcodeHeight is the default height of the bars, in millimetres.
+	defaultBarcodeHeight = 8.0
+	// minTextWidth is the narrowest text column, in pixels.
+	minTextWidth = 64
+)
+
+const mmPerInch = 25.4
+
+// Size is the size of the label in millimetres.  Zero width is the printer
+// width, zero height fits the content.
+type Size struct {
+	Width  float64 `json:"width_mm"`
+	Height float64 `json:"height_mm"`
+}
+
+// Barcode is the linear barcode of the label.
+type Barcode struct {
+	Type   string  `json:"type"`      // see [bitmap.BarcodeTypes], code128 if empty
+	Data   string  `json:"data"`      // encoded data
+	Height float64 `json:"height_mm"` // height of the bars, 8mm if zero
+}
+
+// Label is the label.  The title, in large bold letters, and the subtitle
+// are printed between the icon on the left and the QR code on the right,
+// the barcode is printed below them.  All parts are optional, but the label
+// can't be empty.
+type Label struct {
+	Size     Size     `json:"size"`
+	Title    string   `json:"title"`
+	Subtitle string   `json:"subtitle"`
+	Icon     string   `json:"icon"` // name of the built-in icon, see [Icons]
+	QR       string   `json:"qr"`   // data of the QR code
+	Barcode  *Barcode `json:"barcode"`
+}
+
+// Load decodes the JSON label, or the array of labels, from r.  Unknown keys
+// are reported as an error, so that the typos don't go unnoticed.
+func Load(r io.Reader) ([]Label, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var labels []Label
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = dec.Decode(&labels)
+	} else {
+		var l Label
+		err = dec.Decode(&l)
+		labels = append(labels, l)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode label: %w", err)
+	}
+	if len(labels) == 0 {
+		return nil, errors.New("no labels")
+	}
+	return labels, nil
+}
+
+// Render renders the label in the middle of the canvas of the printer
+// width.  The content of the label with the height must fit it.
+func (l Label) Render(width int, dpi float64) (image.Image, error) {
+	if l.Title == "" && l.Subtitle == "" && l.Icon == "" && l.QR == "" && l.Barcode == nil {
+		return nil, errors.New("label is empty")
+	}
+	if l.Size.Width < 0 || l.Size.Height < 0 {
+		return nil, errors.New("label size can't be negative")
+	}
+	inner := width
+	if l.Size.Width > 0 {
+		inner = min(width, bitmap.MMToDots(l.Size.Width, dpi))
+	}
+
+	var parts []image.Image
+	header, err := l.header(inner)
+	if err != nil {
+		return nil, err
+	}
+	if header != nil {
+		parts = append(parts, header)
+	}
+	if l.Barcode != nil {
+		img, err := l.Barcode.render(inner, dpi)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, img)
+	}
+
+	height := gap * (len(parts) - 1)
+	for _, p := range parts {
+		height += p.Bounds().Dy()
+	}
+	y := 0
+	if l.Size.Height > 0 {
+		labelHeight := bitmap.MMToDots(l.Size.Height, dpi)
+		if height > labelHeight {
+			return nil, fmt.Errorf("label content is %.1fmm high, it doesn't fit the label of %gmm", float64(height)*mmPerInch/dpi, l.Size.Height)
+		}
+		y, height = (labelHeight-height)/2, labelHeight
+	}
+	dst := newCanvas(width, height)
+	for _, p := range parts {
+		b := p.Bounds()
+		x := (width - b.Dx()) / 2
+		draw.Draw(dst, image.Rect(x, y, x+b.Dx(), y+b.Dy()), p, b.Min, draw.Src)
+		y += b.Dy() + gap
+	}
+	return dst, nil
+}
+
+// header renders the icon, the title with the subtitle, and the QR code
+// side by side, or returns nil, if there are none.
+func (l Label) header(width int) (image.Image, error) {
+	var icon, qr image.Image
+	textWidth := width
+	if l.Icon != "" {
+		img, err := Icon(l.Icon, iconScale)
+		if err != nil {
+			return nil, err
+		}
+		icon, textWidth = img, textWidth-img.Bounds().Dx()-gap
+	}
+	if l.QR != "" {
+		img, err := bitmap.QRCode(l.QR, width/3)
+		if err != nil {
+			return nil, err
+		}
+		qr, textWidth = img, textWidth-img.Bounds().Dx()-gap
+	}
+	var text image.Image
+	if l.Title != "" || l.Subtitle != "" {
+		if textWidth < minTextWidth {
+			return nil, fmt.Errorf("no room for the title on the label %d pixels wide", width)
+		}
+		img, err := l.text(textWidth)
+		if err != nil {
+			return nil, err
+		}
+		text = img
+	}
+
+	var row []image.Image
+	height := 0
+	for _, img := range []image.Image{icon, text, qr} {
+		if img != nil {
+			row = append(row, img)
+			height = max(height, img.Bounds().Dy())
+		}
+	}
+	if len(row) == 0 {
+		return nil, nil
+	}
+	dst := newCanvas(width, height)
+	x := 0
+	for _, img := range row {
+		b := img.Bounds()
+		if img == qr {
+			x = width - b.Dx() // the QR code is on the right
+		}
+		y := (height - b.Dy()) / 2
+		draw.Draw(dst, image.Rect(x, y, x+b.Dx(), y+b.Dy()), img, b.Min, draw.Src)
+		x += b.Dx() + gap
+	}
+	return dst, nil
+}
+
+// text renders the title and the subtitle one under another, wrapped to the
+// width.
+func (l Label) text(width int) (image.Image, error) {
+	var lines []image.Image
+	if l.Title != "" {
+		bold, err := fontmgr.LoadByName("toshiba-bold")
+		if err != nil {
+			return nil, err
+		}
+		img, err := largeText(l.Title, bold, width)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, img)
+	}
+	if l.Subtitle != "" {
+		img, err := bitmap.RenderTTF(l.Subtitle, fontmgr.DefaultFont, width, bitmap.WithWrap(true))
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, img)
+	}
+	height := 0
+	for _, img := range lines {
+		height += img.Bounds().Dy()
+	}
+	dst := newCanvas(width, height)
+	y := 0
+	for _, img := range lines {
+		b := img.Bounds()
+		draw.Draw(dst, image.Rect(0, y, b.Dx(), y+b.Dy()), img, b.Min, draw.Src)
+		y += b.Dy()
+	}
+	return dst, nil
+}
+
+// largeText renders the text with the face magnified [titleScale] times,
+// wrapped to the width.
+func largeText(text string, face font.Face, width int) (image.Image, error) {
+	img, err := bitmap.RenderTTF(text, face, width/titleScale, bitmap.WithWrap(true))
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx()*titleScale, b.Dy()*titleScale))
+	draw.NearestNeighbor.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst, nil
+}
+
+// render renders the barcode, that is not wider than width.
+func (bc Barcode) render(width int, dpi float64) (image.Image, error) {
+	kind := bitmap.Code128
+	if bc.Type != "" {
+		var err error
+		if kind, err = bitmap.ParseBarcodeType(bc.Type); err != nil {
+			return nil, err
+		}
+	}
+	height := defaultBarcodeHeight
+	if bc.Height > 0 {
+		height = bc.Height
+	}
+	return bitmap.Barcode(kind, bc.Data, width, bitmap.MMToDots(height, dpi))
+}
+
+// newCanvas returns the white canvas.
+func newCanvas(width, height int) *image.Gray {
+	dst := image.NewGray(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	return dst
+}
@@ -0,0 +1,158 @@
+package label
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/rusq/thermoprint/bitmap"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{name: "object", input: `{"title": "Laptop", "qr": "17"}`, want: 1},
+		{name: "array", input: ` [{"title": "one"}, {"title": "two"}]`, want: 2},
+		{name: "empty array", input: `[]`, wantErr: true},
+		{name: "unknown key", input: `{"titel": "Laptop"}`, wantErr: true},
+		{name: "invalid", input: `{"title": `, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("Load() = %d labels, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFields(t *testing.T) {
+	got, err := Load(strings.NewReader(`{
+		"size": {"width_mm": 48, "height_mm": 30},
+		"title": "Laptop",
+		"subtitle": "room 4.12",
+		"icon": "box",
+		"qr": "https://example.com/17",
+		"barcode": {"type": "code39", "data": "A17", "height_mm": 5}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Label{
+		Size:     Size{Width: 48, Height: 30},
+		Title:    "Laptop",
+		Subtitle: "room 4.12",
+		Icon:     "box",
+		QR:       "https://example.com/17",
+		Barcode:  &Barcode{Type: "code39", Data: "A17", Height: 5},
+	}
+	l := got[0]
+	if l.Size != want.Size || l.Title != want.Title || l.Subtitle != want.Subtitle || l.Icon != want.Icon || l.QR != want.QR {
+		t.Errorf("Load() = %+v, want %+v", l, want)
+	}
+	if l.Barcode == nil || *l.Barcode != *want.Barcode {
+		t.Errorf("Load() barcode = %+v, want %+v", l.Barcode, want.Barcode)
+	}
+}
+
+func TestLabelRender(t *testing.T) {
+	const (
+		width = 384
+		dpi   = 203
+	)
+	full := Label{
+		Title:    "Laptop 17",
+		Subtitle: "IT department",
+		Icon:     "warning",
+		QR:       "https://example.com/17",
+		Barcode:  &Barcode{Data: "ASSET-0017"},
+	}
+	tests := []struct {
+		name       string
+		label      Label
+		wantHeight int // zero to skip the check
+		wantErr    bool
+	}{
+		{name: "full", label: full},
+		{name: "title only", label: Label{Title: "Fragile"}},
+		{name: "fixed height", label: Label{Size: Size{Height: 30}, Title: "Fragile"}, wantHeight: bitmap.MMToDots(30, dpi)},
+		{name: "too high", label: func() Label { l := full; l.Size.Height = 5; return l }(), wantErr: true},
+		{name: "empty", label: Label{}, wantErr: true},
+		{name: "negative size", label: Label{Size: Size{Width: -1}, Title: "x"}, wantErr: true},
+		{name: "unknown icon", label: Label{Icon: "nope"}, wantErr: true},
+		{name: "unknown barcode", label: Label{Barcode: &Barcode{Type: "qr", Data: "1"}}, wantErr: true},
+		{name: "no room for title", label: Label{Size: Size{Width: 10}, Title: "x", Icon: "box"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := tt.label.Render(width, dpi)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := img.Bounds().Dx(); got != width {
+				t.Errorf("Render() width = %d, want %d", got, width)
+			}
+			if tt.wantHeight > 0 && img.Bounds().Dy() != tt.wantHeight {
+				t.Errorf("Render() height = %d, want %d", img.Bounds().Dy(), tt.wantHeight)
+			}
+			if !hasInk(img) {
+				t.Error("Render() is blank")
+			}
+		})
+	}
+}
+
+func TestIcon(t *testing.T) {
+	img, err := Icon("check", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.Bounds().Dx(); got != iconSize*3 {
+		t.Errorf("Icon() width = %d, want %d", got, iconSize*3)
+	}
+	if _, err := Icon("nope", 1); err == nil {
+		t.Error("Icon() expected an error for the unknown icon")
+	}
+}
+
+func TestIcons(t *testing.T) {
+	names := Icons()
+	if len(names) != len(icons) {
+		t.Fatalf("Icons() = %d names, want %d", len(names), len(icons))
+	}
+	for i := 1; i < len(names); i++ {
+		if names[i-1] >= names[i] {
+			t.Errorf("Icons() not sorted: %v", names)
+		}
+	}
+	for name, rows := range icons {
+		for i, row := range rows {
+			if len(row) != iconSize {
+				t.Errorf("icon %q row %d is %d pixels wide, want %d", name, i, len(row), iconSize)
+			}
+		}
+	}
+}
+
+func hasInk(img image.Image) bool {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if r, _, _, _ := img.At(x, y).RGBA(); r < 0x8000 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package label
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rusq/thermoprint/bitmap"
+)
+
+//go:embed presets/*.json
+var presetFS embed.FS
+
+// Field is the field of the [Preset], its value replaces ${name} in the
+// label.
+type Field struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Default     *string `json:"default"` // nil if the field is required
+}
+
+// Preset is the built-in label layout with the fields, i.e. the address
+// label, embedded, see [Presets].
+type Preset struct {
+	Name        string  `json:"-"`
+	Description string  `json:"description"`
+	Fields      []Field `json:"fields"`
+	Label       Label   `json:"label"`
+}
+
+// Presets returns the names of the built-in label presets.
+func Presets() []string {
+	entries, err := fs.ReadDir(presetFS, "presets")
+	if err != nil {
+		panic(err) // embedded
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), path.Ext(e.Name())))
+	}
+	slices.Sort(names)
+	return names
+}
+
+// LoadPreset returns the built-in label preset by name.
+func LoadPreset(name string) (Preset, error) {
+	data, err := presetFS.ReadFile(path.Join("presets", name+".json"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Preset{}, fmt.Errorf("unknown label preset %q, available: %v", name, Presets())
+		}
+		return Preset{}, err
+	}
+	var p Preset
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Preset{}, fmt.Errorf("label preset %q: %w", name, err)
+	}
+	p.Name = name
+	return p, nil
+}
+
+// Fill returns the label of the preset with the values of the fields
+// substituted.  The required fields must have values, the optional ones
+// default to the value of the preset, that may contain ${date:format}, see
+// [bitmap.Interpolate].  "\n" in the value is the line break.  The barcode
+// and the QR code, that have no data after the substitution, are omitted.
+func (p Preset) Fill(values map[string]string, now time.Time) (Label, error) {
+	vars := make(map[string]string, len(p.Fields))
+	for _, f := range p.Fields {
+		v, ok := values[f.Name]
+		switch {
+		case ok:
+			v = strings.ReplaceAll(v, `\n`, "\n")
+		case f.Default != nil:
+			var err error
+			if v, err = bitmap.Interpolate(*f.Default, noVars, now); err != nil {
+				return Label{}, fmt.Errorf("field %q: %w", f.Name, err)
+			}
+		default:
+			return Label{}, fmt.Errorf("preset %q: field %q is required", p.Name, f.Name)
+		}
+		vars[f.Name] = v
+	}
+	for name := range values {
+		if _, ok := vars[name]; !ok {
+			return Label{}, fmt.Errorf("preset %q has no field %q, available: %v", p.Name, name, p.fieldNames())
+		}
+	}
+
+	lookup := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+	l := p.Label
+	if l.Barcode != nil {
+		bc := *l.Barcode // don't modify the preset
+		l.Barcode = &bc
+	}
+	for _, s := range []*string{&l.Title, &l.Subtitle, &l.Icon, &l.QR} {
+		if err := fill(s, lookup, now); err != nil {
+			return Label{}, err
+		}
+	}
+	if l.Barcode != nil {
+		if err := fill(&l.Barcode.Data, lookup, now); err != nil {
+			return Label{}, err
+		}
+		if l.Barcode.Data == "" {
+			l.Barcode = nil
+		}
+	}
+	return l, nil
+}
+
+// fieldNames returns the names of the fields of the preset.
+func (p Preset) fieldNames() []string {
+	names := make([]string, len(p.Fields))
+	for i, f := range p.Fields {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// fill interpolates the string in place, the surrounding space, i.e. the
+// line break before the empty field, is removed.
+func fill(s *string, lookup func(string) (string, bool), now time.Time) error {
+	v, err := bitmap.Interpolate(*s, lookup, now)
+	if err != nil {
+		return err
+	}
+	*s = strings.TrimSpace(v)
+	return nil
+}
+
+// noVars is the lookup function without variables.
+func noVars(string) (string, bool) { return "", false }
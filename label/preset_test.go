@@ -0,0 +1,116 @@
+package label
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPresets(t *testing.T) {
+	names := Presets()
+	if len(names) == 0 {
+		t.Fatal("Presets() is empty")
+	}
+	now := time.Date(2024, time.March, 5, 14, 7, 9, 0, time.UTC)
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			p, err := LoadPreset(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// every preset renders with the required fields only.
+			values := make(map[string]string)
+			for _, f := range p.Fields {
+				if f.Default == nil {
+					values[f.Name] = "x"
+				}
+			}
+			l, err := p.Fill(values, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := l.Render(384, 203); err != nil {
+				t.Errorf("Render() error = %v", err)
+			}
+		})
+	}
+	if _, err := LoadPreset("nope"); err == nil {
+		t.Error("LoadPreset() expected an error for the unknown preset")
+	}
+}
+
+func TestPresetFill(t *testing.T) {
+	now := time.Date(2024, time.March, 5, 14, 7, 9, 0, time.UTC)
+	p, err := LoadPreset("jar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name         string
+		values       map[string]string
+		wantTitle    string
+		wantSubtitle string
+		wantErr      bool
+	}{
+		{
+			name:         "defaults",
+			values:       map[string]string{"contents": "Jam"},
+			wantTitle:    "Jam",
+			wantSubtitle: "05 Mar 2024",
+		},
+		{
+			name:         "all fields",
+			values:       map[string]string{"contents": "Jam", "date": "May", "note": `best\nbefore`},
+			wantTitle:    "Jam",
+			wantSubtitle: "May\nbest\nbefore",
+		},
+		{
+			name:         "not interpolated twice",
+			values:       map[string]string{"contents": "${date:%Y}"},
+			wantTitle:    "${date:%Y}",
+			wantSubtitle: "05 Mar 2024",
+		},
+		{name: "required missing", values: map[string]string{"note": "x"}, wantErr: true},
+		{name: "unknown field", values: map[string]string{"contents": "Jam", "colour": "red"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := p.Fill(tt.values, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Fill() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if l.Title != tt.wantTitle {
+				t.Errorf("Fill() title = %q, want %q", l.Title, tt.wantTitle)
+			}
+			if l.Subtitle != tt.wantSubtitle {
+				t.Errorf("Fill() subtitle = %q, want %q", l.Subtitle, tt.wantSubtitle)
+			}
+		})
+	}
+}
+
+func TestPresetFillBarcode(t *testing.T) {
+	p, err := LoadPreset("price")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := p.Fill(map[string]string{"product": "Tea", "price": "$2"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Barcode != nil {
+		t.Errorf("Fill() barcode = %+v, want nil without the code", l.Barcode)
+	}
+	l, err = p.Fill(map[string]string{"product": "Tea", "price": "$2", "ean": "400638133393"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Barcode == nil || l.Barcode.Data != "400638133393" {
+		t.Errorf("Fill() barcode = %+v, want the code", l.Barcode)
+	}
+	if p.Label.Barcode.Data != "${ean}" {
+		t.Errorf("Fill() modified the preset: %q", p.Label.Barcode.Data)
+	}
+}
@@ -0,0 +1,178 @@
+// Package locale translates the built-in strings, that are printed or
+// reported to the clients: the page headers, the job footer, the names of
+// the months and the days of the week in the dates, the receipt labels and
+// the printer alerts.  The messages are identified by their English text,
+// the English catalog is empty, and the message, that a catalog does not
+// have, is returned as is.
+package locale
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//go:embed catalogs/*.json
+var catalogFS embed.FS
+
+// English is the language of the messages.
+const English = "en"
+
+// Catalog is the message catalog of the language.
+type Catalog struct {
+	lang string
+	msgs map[string]string
+}
+
+// Lang returns the language of the catalog, i.e. "de".
+func (c *Catalog) Lang() string {
+	if c == nil {
+		return English
+	}
+	return c.lang
+}
+
+// T returns the translation of the message, or the message, if the catalog
+// does not have it.  The nil catalog is English.
+func (c *Catalog) T(msg string) string {
+	if c == nil {
+		return msg
+	}
+	if s, ok := c.msgs[msg]; ok {
+		return s
+	}
+	return msg
+}
+
+// Sprintf formats the translation of the format.
+func (c *Catalog) Sprintf(format string, a ...any) string {
+	return fmt.Sprintf(c.T(format), a...)
+}
+
+// FormatTime formats the time t according to the layout, see
+// [time.Time.Format], with the names of the months and of the days of the
+// week translated.
+func (c *Catalog) FormatTime(t time.Time, layout string) string {
+	if c.Lang() == English {
+		return t.Format(layout)
+	}
+	var (
+		b     strings.Builder
+		start int
+	)
+	for i := 0; i < len(layout); {
+		name := nameToken(layout[i:])
+		if name == "" {
+			i++
+			continue
+		}
+		b.WriteString(t.Format(layout[start:i]))
+		b.WriteString(c.T(t.Format(name)))
+		i += len(name)
+		start = i
+	}
+	b.WriteString(t.Format(layout[start:]))
+	return b.String()
+}
+
+// nameTokens are the layout elements, that are the names, longest first.
+var nameTokens = []string{"January", "Monday", "Jan", "Mon"}
+
+// nameToken returns the name layout element at the start of s, or an empty
+// string.
+func nameToken(s string) string {
+	for _, tok := range nameTokens {
+		if strings.HasPrefix(s, tok) {
+			return tok
+		}
+	}
+	return ""
+}
+
+// Languages returns the languages, that have the catalogs, English first.
+func Languages() []string {
+	entries, err := fs.ReadDir(catalogFS, "catalogs")
+	if err != nil {
+		panic(err) // embedded
+	}
+	var langs []string
+	for _, e := range entries {
+		langs = append(langs, strings.TrimSuffix(e.Name(), path.Ext(e.Name())))
+	}
+	slices.Sort(langs)
+	return append([]string{English}, langs...)
+}
+
+var (
+	catalogsMu sync.Mutex
+	catalogs   = map[string]*Catalog{English: {lang: English}}
+)
+
+// Lookup returns the catalog of the language.  The language may be the
+// language tag, i.e. "de-CH", or the locale name, i.e. "de_DE.UTF-8", the
+// catalog of the language without the region is returned.  The empty
+// language, "C" and "POSIX" are English.
+func Lookup(lang string) (*Catalog, error) {
+	base := strings.ToLower(lang)
+	if i := strings.IndexAny(base, "-_.@"); i >= 0 {
+		base = base[:i]
+	}
+	if base == "" || base == "c" || base == "posix" {
+		base = English
+	}
+
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+	if c, ok := catalogs[base]; ok {
+		return c, nil
+	}
+	data, err := catalogFS.ReadFile(path.Join("catalogs", base+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported language %q, must be one of %v", lang, Languages())
+	}
+	c := &Catalog{lang: base}
+	if err := json.Unmarshal(data, &c.msgs); err != nil {
+		return nil, fmt.Errorf("catalog %q: %w", base, err)
+	}
+	catalogs[base] = c
+	return c, nil
+}
+
+var defaultCatalog atomic.Pointer[Catalog]
+
+func init() {
+	defaultCatalog.Store(catalogs[English])
+}
+
+// Default returns the catalog of the default language, English, unless it
+// is changed with [SetDefault].
+func Default() *Catalog {
+	return defaultCatalog.Load()
+}
+
+// SetDefault sets the default language.
+func SetDefault(lang string) error {
+	c, err := Lookup(lang)
+	if err != nil {
+		return err
+	}
+	defaultCatalog.Store(c)
+	return nil
+}
+
+// T returns the translation of the message in the default language.
+func T(msg string) string {
+	return Default().T(msg)
+}
+
+// Sprintf formats the translation of the format in the default language.
+func Sprintf(format string, a ...any) string {
+	return Default().Sprintf(format, a...)
+}
@@ -0,0 +1,91 @@
+package locale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		lang    string
+		want    string
+		wantErr bool
+	}{
+		{"", "en", false},
+		{"C", "en", false},
+		{"en-us", "en", false},
+		{"de", "de", false},
+		{"de-CH", "de", false},
+		{"fr_FR.UTF-8", "fr", false},
+		{"es", "es", false},
+		{"xx", "", true},
+	}
+	for _, tt := range tests {
+		c, err := Lookup(tt.lang)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("Lookup(%q) error = %v, wantErr %v", tt.lang, err, tt.wantErr)
+		}
+		if err == nil && c.Lang() != tt.want {
+			t.Errorf("Lookup(%q) = %q, want %q", tt.lang, c.Lang(), tt.want)
+		}
+	}
+}
+
+func TestCatalogSprintf(t *testing.T) {
+	de, err := Lookup("de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := de.Sprintf("page %d/%d", 2, 3); got != "Seite 2/3" {
+		t.Errorf("Sprintf = %q", got)
+	}
+	if got := de.T("no such message"); got != "no such message" {
+		t.Errorf("T = %q, want the message as is", got)
+	}
+	var nilCatalog *Catalog
+	if got := nilCatalog.Sprintf("page %d/%d", 2, 3); got != "page 2/3" {
+		t.Errorf("nil catalog Sprintf = %q", got)
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	at := time.Date(2026, time.March, 2, 14, 5, 0, 0, time.UTC) // Monday
+	de, err := Lookup("de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		c      *Catalog
+		layout string
+		want   string
+	}{
+		{nil, "Mon, 02 Jan 2006 15:04", "Mon, 02 Mar 2026 14:05"},
+		{de, "Mon, 02 Jan 2006 15:04", "Mo, 02 Mär 2026 14:05"},
+		{de, "Monday, 2 January 2006", "Montag, 2 März 2026"},
+		{de, "2006-01-02", "2026-03-02"},
+	}
+	for _, tt := range tests {
+		if got := tt.c.FormatTime(at, tt.layout); got != tt.want {
+			t.Errorf("FormatTime(%q) = %q, want %q", tt.layout, got, tt.want)
+		}
+	}
+}
+
+// TestCatalogsASCII checks the messages, that are printed with the ASCII
+// only font, see bitmap.PageHeader.
+func TestCatalogsASCII(t *testing.T) {
+	for _, lang := range Languages() {
+		c, err := Lookup(lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, msg := range []string{"page %d/%d", "%s, job %d, %s"} {
+			for _, r := range c.T(msg) {
+				if r > 0x7e {
+					t.Errorf("%s: %q is not ASCII", lang, c.T(msg))
+					break
+				}
+			}
+		}
+	}
+}
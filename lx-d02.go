@@ -4,6 +4,7 @@ package thermoprint
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	_ "embed"
 	"errors"
@@ -12,14 +13,16 @@ import (
 	"image/png"
 	"log/slog"
 	"os"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"golang.org/x/image/font"
-	"tinygo.org/x/bluetooth"
 
 	"github.com/rusq/thermoprint/bitmap"
+	"github.com/rusq/thermoprint/internal/ble"
+	"github.com/rusq/thermoprint/locale"
 )
 
 const (
@@ -31,15 +34,20 @@ const (
 )
 
 const (
-	txChar = "0000ffe1-0000-1000-8000-00805f9b34fb" // TX Characteristic UUID
-	rxChar = "0000ffe2-0000-1000-8000-00805f9b34fb" // RX Characteristic UUID
+	service = "0000ffe0-0000-1000-8000-00805f9b34fb" // Service UUID
+	txChar  = "0000ffe1-0000-1000-8000-00805f9b34fb" // TX Characteristic UUID
+	rxChar  = "0000ffe2-0000-1000-8000-00805f9b34fb" // RX Characteristic UUID
 )
 
+var lxd02GATT = gattProfile{service: service, tx: txChar, rx: rxChar}
+
 const (
 	sendRetryDelay  = 10 * time.Millisecond  // Delay between sends to avoid overwhelming the printer
 	maxRetries      = 3                      // Maximum retries for sending data
 	cooldownDelay   = 100 * time.Millisecond // Cooldown period after certain notifications
 	responseTimeout = 3 * time.Second        // Timeout for sending data and waiting for response
+
+	defaultReconnects = 3 // Reconnections per print job if the link drops
 )
 
 const (
@@ -47,23 +55,47 @@ const (
 	gBatCritical = 10.0
 )
 
-// LXD02 represents a LX-D02 printer.  Instance is not safe for concurrent use.
-// Zero value is unusable, initialise with [NewLXD02]
+var (
+	// ErrNoPaper is returned by the print functions if the printer reports
+	// that it ran out of paper during the print.
+	ErrNoPaper = errors.New("printer is out of paper")
+	// ErrBusy is returned by the print functions if another print is in
+	// progress.
+	ErrBusy = errors.New("printer is busy")
+	// ErrDisconnected is returned by the print functions if the printer was
+	// disconnected during the print.
+	ErrDisconnected = errors.New("printer disconnected")
+	// ErrDeviceNotFound is returned by [NewLXD02] and [LXD02.Connect] if the
+	// printer could not be found or connected to.
+	ErrDeviceNotFound = errors.New("printer not found")
+	// ErrTimeout is returned if the printer did not respond in time.
+	ErrTimeout = errors.New("timeout")
+	// ErrCancelled is returned by the print functions if the print was
+	// cancelled with [LXD02.Cancel].  It wraps [context.Canceled].
+	ErrCancelled = fmt.Errorf("print job %w", context.Canceled)
+)
+
+// LXD02 represents a LX-D02 printer.  The printer prints one job at a time:
+// print functions called while another print is in progress fail with
+// [ErrBusy].  Zero value is unusable, initialise with [NewLXD02]
 type LXD02 struct {
-	dev       bluetooth.Device
-	tx        bluetooth.DeviceCharacteristic
-	rx        bluetooth.DeviceCharacteristic
-	connected atomic.Bool // Indicates if the printer is connected
+	conn         Transport
+	connMu       sync.Mutex  // guards conn, that is replaced on reconnect
+	connected    atomic.Bool // Indicates if the printer is connected
+	printing     atomic.Bool // Set while the print is in progress
+	disconnectMu sync.Mutex
 
-	buffer     [][]byte
 	rasteriser Rasteriser // Interface for rasterizing images
 
-	stateMu    sync.Mutex
-	state      printerState
-	activeJob  *printJob
-	lastStatus lxd02status
-	statusSeen bool
-	statusAt   time.Time
+	stateMu     sync.Mutex
+	state       printerState
+	activeJob   *printJob
+	lastStatus  lxd02status
+	statusSeen  bool
+	statusAt    time.Time
+	alerts      alertLog
+	unknown     notificationRing // unrecognised notifications
+	subscribers statusSubscribers
 
 	responseMu    sync.Mutex
 	waitingPrefix []byte
@@ -71,6 +103,12 @@ type LXD02 struct {
 
 	options printOptions
 
+	// connection parameters, kept to reconnect if the link drops.
+	adapter    *Adapter
+	search     SearchParameters
+	connCtx    context.Context
+	stopWorker context.CancelFunc // stops the notification worker of conn
+
 	initSequenceHook func(job *printJob)
 	sendAndWaitHook  func(data []byte, expectPrefix []byte, timeout time.Duration) ([]byte, error)
 	printBufferHook  func(job *printJob, start int, streamID uint64)
@@ -87,20 +125,47 @@ type PrinterSnapshot struct {
 	Charging       bool
 	Charged        bool
 	LastStatusTime time.Time
+	// RollTracked is true if the paper roll length is being tracked, in which
+	// case RollLength and RollRemaining hold the estimate in millimetres.
+	RollTracked   bool
+	RollLength    float64
+	RollRemaining float64
+	MediaLow      bool // estimated paper left is below the low mark
 }
 
-var LXD02Rasteriser = &GenericRasteriser{
-	Width:          384, // 48 bytes
-	Dpi:            203, // 203 DPI
-	LinesPerPacket: 2,   // 2 lines per packet
-	PrefixFunc: func(packetIndex int) []byte {
-		m := byte((packetIndex >> 8) & 0xFF)
-		n := byte(packetIndex & 0xFF)
-		return []byte{0x55, m, n} // 55 m n
-	},
-	Terminator: 0x00,                    // 00
-	Threshold:  bitmap.DefaultThreshold, // default threshold for dark pixels
-	DitherFunc: bitmap.DitherDefault,    // default dither function
+// setRoll fills in the paper roll estimate from the counter rc, if it is not
+// nil.
+func (s *PrinterSnapshot) setRoll(rc *RollCounter) {
+	if rc == nil {
+		return
+	}
+	st := rc.State()
+	s.RollTracked = true
+	s.RollLength = st.Length
+	s.RollRemaining = st.Remaining
+	s.MediaLow = st.Low()
+}
+
+// LXD02Rasteriser is the rasteriser of LX-D02 with the default settings.
+// The drivers configure their own copy, see [NewLXD02Rasteriser].
+var LXD02Rasteriser = NewLXD02Rasteriser()
+
+// NewLXD02Rasteriser returns the new rasteriser of LX-D02 with the default
+// settings, that may be configured without affecting the others.
+func NewLXD02Rasteriser() *GenericRasteriser {
+	return &GenericRasteriser{
+		Width:          384, // 48 bytes
+		Dpi:            203, // 203 DPI
+		LinesPerPacket: 2,   // 2 lines per packet
+		PrefixFunc: func(packetIndex int) []byte {
+			m := byte((packetIndex >> 8) & 0xFF)
+			n := byte(packetIndex & 0xFF)
+			return []byte{0x55, m, n} // 55 m n
+		},
+		Terminator: 0x00,                    // 00
+		Threshold:  bitmap.DefaultThreshold, // default threshold for dark pixels
+		DitherFunc: bitmap.DitherDefault,    // default dither function
+	}
 }
 
 type printOptions struct {
@@ -111,6 +176,54 @@ type printOptions struct {
 	dryrun        bool          // If true, don't actually send data to the printer, output raster images
 	gamma         float64       // gamma
 	autoDither    bool
+	channel       bitmap.Channel // grey conversion before dithering, optional
+	roll          *RollCounter   // paper roll tracking, optional
+	backend       string         // Bluetooth backend name
+	initSeq       InitSequence   // handshake sent before each print job
+
+	// timeouts and retries, zero values mean defaults.
+	responseTimeout time.Duration // timeout waiting for the response
+	sendRetries     int           // attempts to write data
+	sendRetryDelay  time.Duration // delay between write attempts
+	connectRetries  int           // attempts to connect
+	connectWait     time.Duration // initial wait between connection attempts
+	connectMaxWait  time.Duration // maximum wait between connection attempts
+
+	transport Transport                                    // if set, used instead of Bluetooth
+	dialer    func(ctx context.Context) (Transport, error) // if set, used instead of Bluetooth
+
+	progress func(sent, total int) // print progress callback, optional
+
+	reconnects int // reconnections allowed per print job
+
+	postProcessors []PostProcessor // applied to the dithered image
+}
+
+func (o printOptions) timeout() time.Duration {
+	return cmp.Or(o.responseTimeout, responseTimeout)
+}
+
+func (o printOptions) writeRetries() int {
+	return cmp.Or(o.sendRetries, maxRetries)
+}
+
+func (o printOptions) writeRetryDelay() time.Duration {
+	return cmp.Or(o.sendRetryDelay, sendRetryDelay)
+}
+
+func (o printOptions) connectRetry() ble.Retry {
+	return ble.Retry{
+		Attempts: cmp.Or(o.connectRetries, connectRetries),
+		Wait:     cmp.Or(o.connectWait, connectRetryWait),
+		MaxWait:  cmp.Or(o.connectMaxWait, connectRetryMaxWait),
+	}
+}
+
+// reportProgress calls the progress callback, if set.
+func (o printOptions) reportProgress(sent, total int) {
+	if o.progress != nil {
+		o.progress(sent, total)
+	}
 }
 
 type Option func(*printOptions)
@@ -169,17 +282,116 @@ func WithAutoDither(isEnabled bool) Option {
 	}
 }
 
-func NewLXD02(ctx context.Context, adapter *bluetooth.Adapter, sp SearchParameters, opt ...Option) (*LXD02, error) {
+// WithChannel sets the channel, with which the images are converted to grey
+// before dithering, i.e. [bitmap.ChannelRed] hides the red stamps.  The zero
+// channel is the default conversion of the dither function.
+func WithChannel(ch bitmap.Channel) Option {
+	return func(o *printOptions) {
+		o.channel = ch
+	}
+}
+
+// WithRollCounter enables tracking of the paper left on the roll: the length
+// of every completed printout is subtracted from the counter.
+func WithRollCounter(rc *RollCounter) Option {
+	return func(o *printOptions) {
+		o.roll = rc
+	}
+}
+
+// WithBackend selects the Bluetooth backend, one of [BackendTinyGo] or
+// [BackendBlueZ].  The default is [BackendTinyGo].
+func WithBackend(name string) Option {
+	return func(o *printOptions) {
+		o.backend = name
+	}
+}
+
+// WithResponseTimeout sets the time to wait for the printer to respond to a
+// command.  Slow Bluetooth stacks, i.e. on Raspberry Pi Zero, may need longer
+// timeouts.  Zero or negative value means the default of 3s.
+func WithResponseTimeout(d time.Duration) Option {
+	return func(o *printOptions) {
+		o.responseTimeout = max(0, d)
+	}
+}
+
+// WithSendRetries sets the number of attempts to write the data to the
+// printer, and the delay between attempts.  Zero values mean the defaults.
+func WithSendRetries(n int, delay time.Duration) Option {
+	return func(o *printOptions) {
+		o.sendRetries = max(0, n)
+		o.sendRetryDelay = max(0, delay)
+	}
+}
+
+// WithConnectRetries sets the number of attempts to connect to the printer,
+// and the wait after the first failed attempt.  The wait doubles after every
+// attempt, up to the limit set with [WithConnectMaxWait].  Zero values mean
+// the defaults.
+func WithConnectRetries(n int, wait time.Duration) Option {
+	return func(o *printOptions) {
+		o.connectRetries = max(0, n)
+		o.connectWait = max(0, wait)
+	}
+}
+
+// WithConnectMaxWait limits the wait between the connection attempts.  Zero
+// value means the default of 10s.
+func WithConnectMaxWait(d time.Duration) Option {
+	return func(o *printOptions) {
+		o.connectMaxWait = max(0, d)
+	}
+}
+
+// WithProgress sets the callback that is called after each packet of the
+// print job is sent to the printer, with the number of packets sent and the
+// total number of packets in the job.  When the printer asks to retransmit,
+// sent goes back to the requested packet.  The callback is called from the
+// print goroutine and must not block.  nil disables the reporting.
+func WithProgress(fn func(sent, total int)) Option {
+	return func(o *printOptions) {
+		o.progress = fn
+	}
+}
+
+// WithReconnects sets the number of times the driver reconnects to the
+// printer, if the Bluetooth link drops in the middle of the print job.  After
+// reconnecting, the handshake is repeated, and the job resumes from the
+// packet that failed to send.  Zero disables reconnecting, and the job fails
+// on the first write error, as it does with [WithTransport], where the
+// transport can't be dialled again.
+func WithReconnects(n int) Option {
+	return func(o *printOptions) {
+		o.reconnects = max(0, n)
+	}
+}
+
+// WithInitSequence overrides the handshake, that is sent to the printer
+// before each print job.  Some clone firmwares expect handshake bytes that
+// are different from [LXD02InitSequence].
+func WithInitSequence(seq InitSequence) Option {
+	return func(o *printOptions) {
+		o.initSeq = seq
+	}
+}
+
+func NewLXD02(ctx context.Context, adapter *Adapter, sp SearchParameters, opt ...Option) (*LXD02, error) {
 	var opts = printOptions{
 		energy:        2, // Default energy level
 		printInterval: DefaultPrintDelay,
+		initSeq:       LXD02InitSequence,
+		reconnects:    defaultReconnects,
 	}
 	for _, o := range opt {
 		o(&opts)
 	}
+	if err := opts.initSeq.validate(); err != nil {
+		return nil, fmt.Errorf("invalid init sequence: %w", err)
+	}
 	prn := &LXD02{
 		options:    opts,
-		rasteriser: LXD02Rasteriser, // Default rasteriser for LXD02
+		rasteriser: NewLXD02Rasteriser(), // Default rasteriser for LXD02
 	}
 	if !opts.dryrun {
 		if err := prn.Connect(ctx, adapter, sp); err != nil {
@@ -199,37 +411,75 @@ func NewLXD02(ctx context.Context, adapter *bluetooth.Adapter, sp SearchParamete
 }
 
 // Connect connects to the LX-D02 printer using the provided adapter and search parameters.
-func (p *LXD02) Connect(ctx context.Context, adapter *bluetooth.Adapter, sp SearchParameters) error {
+func (p *LXD02) Connect(ctx context.Context, adapter *Adapter, sp SearchParameters) error {
 	if p.connected.Load() {
-		slog.Debug("Already connected to printer", "address", p.dev.Address)
+		slog.Debug("Already connected to printer", "address", p.transport().Address())
 		return nil
 	}
 
-	device, err := connectWithRetries(ctx, adapter, sp, 5)
-	if err != nil {
+	p.adapter, p.search, p.connCtx = adapter, sp, ctx
+	if err := p.connect(ctx); err != nil {
 		return err
 	}
-	p.dev = device
+	p.connected.Store(true)
+	slog.Debug("Connected to printer", "address", p.transport().Address())
+
+	return nil
+}
 
-	txrx, err := locateCharacteristics(device, txChar, rxChar)
+// connect dials the printer and starts the notification worker, that runs
+// until the connection is replaced or the context passed to [LXD02.Connect]
+// is cancelled.  ctx limits the dialling only.
+func (p *LXD02) connect(ctx context.Context) error {
+	conn, err := p.options.dial(ctx, p.adapter, p.search, lxd02GATT)
 	if err != nil {
-		return fmt.Errorf("failed to locate services: %w", err)
+		return err
 	}
-	p.tx = txrx.tx
-	p.rx = txrx.rx
-	slog.Info("Connected to printer", "address", device.Address, "mac", device.Address)
+	slog.Info("Connected to printer", "address", conn.Address(), "mac", conn.Address())
 
 	notifyCh := make(chan lxd02notification, 10)
-	if err := p.rx.EnableNotifications(p.notificationCallback(notifyCh)); err != nil {
+	if err := conn.Notify(p.notificationCallback(notifyCh)); err != nil {
 		return fmt.Errorf("failed to enable notifications on TX characteristic: %w", err)
 	}
 	slog.Debug("enabled notifications, starting worker")
-	go p.worker(ctx, notifyCh)
+	workerCtx, stop := context.WithCancel(p.connCtx)
+	p.connMu.Lock()
+	p.conn, p.stopWorker = conn, stop
+	p.connMu.Unlock()
+	go p.worker(workerCtx, notifyCh)
+	return nil
+}
 
-	p.connected.Store(true)
-	slog.Debug("Connected to printer", "address", p.dev.Address, "mac", p.dev.Address)
+// reconnect drops the connection to the printer, and connects again.
+func (p *LXD02) reconnect(ctx context.Context) error {
+	p.connMu.Lock()
+	old, stop := p.conn, p.stopWorker
+	p.connMu.Unlock()
+	if stop != nil {
+		stop()
+	}
+	if old != nil {
+		// the link is most likely gone already, the errors are expected.
+		if err := old.Notify(func([]byte) {}); err != nil {
+			slog.Debug("failed to disable notifications on the dropped connection", "error", err)
+		}
+		if err := old.Disconnect(); err != nil {
+			slog.Debug("failed to close the dropped connection", "error", err)
+		}
+	}
+	return p.connect(ctx)
+}
 
-	return nil
+// transport returns the current connection to the printer.
+func (p *LXD02) transport() Transport {
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+	return p.conn
+}
+
+// canReconnect returns true, if the connection can be dialled again.
+func (p *LXD02) canReconnect() bool {
+	return p.options.transport == nil && !p.options.dryrun && p.connected.Load()
 }
 
 func (p *LXD02) notificationCallback(notifyCh chan<- lxd02notification) func(value []byte) {
@@ -266,11 +516,13 @@ func (p *LXD02) notificationCallback(notifyCh chan<- lxd02notification) func(val
 		case ntRetransmit:
 			notifyCh <- lxd02notification{prefix: ntRetransmit, data: value}
 		case ntCooldown:
+			p.alerts.add(AlertCooldown, SeverityWarning, locale.T("Print head is cooling down"))
 			time.Sleep(cooldownDelay) // Cooldown period
 		case ntHold:
 			notifyCh <- lxd02notification{prefix: ntHold, data: value}
 		default:
 			slog.Warn("Received unknown notification", "value", fmt.Sprintf("% x", value))
+			p.unknown.add(value)
 		}
 	}
 	// Handle the received notification value here
@@ -345,7 +597,7 @@ func (p *LXD02) worker(ctx context.Context, notifyCh <-chan lxd02notification) {
 					slog.Error("Failed to parse status", "error", err)
 					continue
 				}
-				p.storeStatus(st)
+				p.updateStatus(st)
 				slog.DebugContext(ctx, "status", "status", st)
 				if st.BatteryLevel < gBatCritical {
 					slog.ErrorContext(ctx, "BATTERY LEVEL CRITICAL", "level", st.BatteryLevel)
@@ -354,7 +606,7 @@ func (p *LXD02) worker(ctx context.Context, notifyCh <-chan lxd02notification) {
 				}
 				if st.NoPaper {
 					slog.ErrorContext(ctx, "no paper")
-					p.routeNotificationEvent(fsmEvent{kind: eventError, err: errors.New("printer reported no paper")})
+					p.routeNotificationEvent(fsmEvent{kind: eventError, err: ErrNoPaper})
 				}
 			case ntHold:
 				p.routeNotificationEvent(fsmEvent{kind: eventNotificationHold})
@@ -369,12 +621,33 @@ func (p *LXD02) worker(ctx context.Context, notifyCh <-chan lxd02notification) {
 	}
 }
 
-func (p *LXD02) storeStatus(st lxd02status) {
+// storeStatus stores the status and returns the previous one.  seen is false,
+// if there was no previous status.
+func (p *LXD02) storeStatus(st lxd02status) (prev lxd02status, seen bool) {
 	p.stateMu.Lock()
 	defer p.stateMu.Unlock()
+	prev, seen = p.lastStatus, p.statusSeen
 	p.lastStatus = st
 	p.statusSeen = true
 	p.statusAt = time.Now()
+	return prev, seen
+}
+
+// PrintInterval returns the interval between the data packets, see
+// [WithPrintInterval].
+func (p *LXD02) PrintInterval() time.Duration {
+	return p.options.printInterval
+}
+
+// Alerts returns the history of device alerts, oldest first.
+func (p *LXD02) Alerts() []Alert {
+	return p.alerts.list()
+}
+
+// UnknownNotifications returns the notifications that the driver did not
+// recognise, oldest first.
+func (p *LXD02) UnknownNotifications() []UnknownNotification {
+	return p.unknown.list()
 }
 
 // Snapshot returns the current connection, print FSM, and last decoded status.
@@ -394,28 +667,118 @@ func (p *LXD02) Snapshot() PrinterSnapshot {
 		snap.Charged = p.lastStatus.Charged
 		snap.LastStatusTime = p.statusAt
 	}
+	snap.setRoll(p.options.roll)
 	return snap
 }
 
+// Status is the status reported by the printer, see [LXD02.Status].
+type Status struct {
+	BatteryLevel uint8 `json:"battery_level"` // percent
+	NoPaper      bool  `json:"no_paper"`
+	Charging     bool  `json:"charging"`
+	Charged      bool  `json:"charged"`
+	// Firmware is the firmware version, empty if the printer does not
+	// report it, which is the case for the LX-D02.
+	Firmware string `json:"firmware,omitempty"`
+}
+
+// StatusQuerier is implemented by the drivers that can request the status
+// from the printer.
+type StatusQuerier interface {
+	// Status requests the status from the printer.
+	Status(ctx context.Context) (Status, error)
+}
+
+var _ StatusQuerier = (*LXD02)(nil)
+
+// statusRequest asks the printer to report the status (5a 02).
+var statusRequest = []byte{0x5a, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+
+// Status requests the status from the printer and waits for the response.
+// The status is stored, as if the printer reported it on its own, so the
+// [LXD02.Snapshot] and the alerts are updated.  It returns [ErrBusy] while
+// printing, use [LXD02.Snapshot] to get the last reported status instead.
+func (p *LXD02) Status(ctx context.Context) (Status, error) {
+	if p.options.dryrun || !p.connected.Load() {
+		return Status{}, ErrDisconnected
+	}
+	if !p.printing.CompareAndSwap(false, true) {
+		return Status{}, ErrBusy
+	}
+	defer p.printing.Store(false)
+
+	timeout := p.options.timeout()
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = min(timeout, time.Until(deadline))
+	}
+	resp, err := p.sendAndWait(statusRequest, prefixStatus, timeout)
+	if err != nil {
+		return Status{}, fmt.Errorf("status request: %w", err)
+	}
+	st, err := parseStatus(resp)
+	if err != nil {
+		return Status{}, err
+	}
+	p.updateStatus(st)
+	return st.public(), nil
+}
+
+// Subscribe registers fn to be called with every status that the printer
+// reports, see [StatusSubscriber].
+func (p *LXD02) Subscribe(fn func(Status)) (unsubscribe func()) {
+	return p.subscribers.add(fn)
+}
+
+// updateStatus stores the status reported by the printer, records the
+// alerts and notifies the subscribers.
+func (p *LXD02) updateStatus(st lxd02status) {
+	prev, seen := p.storeStatus(st)
+	p.alerts.statusAlerts(prev, seen, st)
+	p.subscribers.publish(st.public())
+}
+
+// Cancel cancels the print in progress, if any: the remaining packets are
+// dropped and the printer is told to end the job, so that it returns to
+// idle.  The print function returns [ErrCancelled].  Cancelling the context
+// of the print function has the same effect.
+func (p *LXD02) Cancel() error {
+	job := p.currentJob()
+	if job == nil || job.isDone() {
+		return nil
+	}
+	slog.Info("Cancelling the print job")
+	p.dispatchJobEvent(job, fsmEvent{kind: eventCancel, err: ErrCancelled})
+	return nil
+}
+
+// Disconnect cancels the print in progress, if any, and disconnects from
+// the printer.  It is safe to call concurrently and more than once.
 func (p *LXD02) Disconnect() error {
-	if p.options.dryrun {
+	p.disconnectMu.Lock()
+	defer p.disconnectMu.Unlock()
+
+	if job := p.currentJob(); job != nil {
+		p.dispatchJobEvent(job, fsmEvent{kind: eventCancel, err: ErrDisconnected})
+	}
+	if p.options.dryrun || !p.connected.Swap(false) {
 		return nil
 	}
-	if err := p.rx.EnableNotifications(func([]byte) {}); err != nil { // noop callback
+	p.connMu.Lock()
+	conn, stop := p.conn, p.stopWorker
+	p.connMu.Unlock()
+	if stop != nil {
+		stop()
+	}
+	if err := conn.Notify(func([]byte) {}); err != nil { // noop callback
 		slog.Warn("failed to disable notifications, never mind, let's continue", "error", err)
 	}
-	if err := p.dev.Disconnect(); err != nil {
+	if err := conn.Disconnect(); err != nil {
 		return fmt.Errorf("failed to disconnect from printer: %w", err)
 	}
-	slog.Info("Disconnected from printer", "address", p.dev.Address)
+	slog.Info("Disconnected from printer", "address", conn.Address())
 	return nil
 }
 
-func (p *LXD02) loadBuffer(data [][]byte) {
-	p.buffer = make([][]byte, len(data))
-	copy(p.buffer, data)
-}
-
 // dry run file names
 const (
 	drRasteriseFile = "preview_rasterised.png"
@@ -426,7 +789,7 @@ const (
 // PrintImage prints an image on the printer.  If dry run is enabled, it saves
 // the preview file to disk and exits.
 func (p *LXD02) PrintImage(ctx context.Context, img image.Image) error {
-	bmp := p.rasteriser.ResizeAndDither(img, p.options.gamma, p.options.autoDither)
+	bmp := p.options.postProcess(p.rasteriser.ResizeAndDither(p.options.channel.Gray(img), p.options.gamma, p.options.autoDither))
 	if p.options.dryrun {
 		// DRY RUN terminates here.
 		debugSaveImage(bmp, drRasteriseFile)
@@ -438,13 +801,25 @@ func (p *LXD02) PrintImage(ctx context.Context, img image.Image) error {
 		return err
 	}
 
-	return p.printPackets(ctx, packets)
+	return p.printPackets(ctx, packets, bmp.Bounds().Dy())
 }
 
+// SplitRAW splits the raw data into the packets for [LXD02.PrintRAW], see
+// [GenericRasteriser.Split].
+func (p *LXD02) SplitRAW(data []byte) ([][]byte, error) {
+	return p.rasteriser.Split(data)
+}
+
+// PrintRAW prints the packet data, that are sent to the printer with the
+// packet prefixes and terminators added, see [GenericRasteriser.Enumerate].
+// Dry run is not supported.
 func (p *LXD02) PrintRAW(ctx context.Context, data [][]byte) error {
 	if len(data) == 0 {
 		return errors.New("empty raw data")
 	}
+	if p.options.dryrun {
+		return errors.New("raw data does not support dry run")
+	}
 
 	packets, err := p.rasteriser.Enumerate(data)
 	if err != nil {
@@ -452,15 +827,24 @@ func (p *LXD02) PrintRAW(ctx context.Context, data [][]byte) error {
 	}
 	slog.DebugContext(ctx, "packet stat", "len", len(packets))
 
-	return p.printPackets(ctx, packets)
+	var size int
+	for _, chunk := range data {
+		size += len(chunk)
+	}
+	lines := size / (p.rasteriser.LineWidth() / 8)
+	return p.printPackets(ctx, packets, lines)
 }
 
 // printPackets is the low level routine that starts the FSM and sends the
-// encoded image data to the printer.
-func (p *LXD02) printPackets(ctx context.Context, packets [][]byte) error {
-	p.loadBuffer(packets)
+// encoded image data to the printer.  lines is the number of raster lines
+// in the packets, it is used to account for the paper used.
+func (p *LXD02) printPackets(ctx context.Context, packets [][]byte, lines int) error {
+	if !p.printing.CompareAndSwap(false, true) {
+		return ErrBusy
+	}
+	defer p.printing.Store(false)
 
-	job := p.newPrintJob(context.Background())
+	job := p.newPrintJob(context.Background(), packets)
 	p.stateMu.Lock()
 	p.activeJob = job
 	p.state = stateIdle
@@ -481,36 +865,56 @@ func (p *LXD02) printPackets(ctx context.Context, packets [][]byte) error {
 	p.dispatchJobEvent(job, fsmEvent{kind: eventStart})
 
 	select {
-	case err := <-job.doneCh:
-		if err != nil {
-			return err
-		}
-		slog.Info("print completed successfully")
-		return nil
+	case <-job.Done():
 	case <-ctx.Done():
-		select {
-		case err := <-job.doneCh:
-			if err != nil {
-				return err
-			}
-			slog.Info("print completed successfully")
-			return nil
-		default:
+		if !job.isDone() {
+			p.dispatchJobEvent(job, fsmEvent{kind: eventCancel, err: ctx.Err()})
 		}
+		if !job.isDone() {
+			return ctx.Err()
+		}
+	}
+	if err := job.Err(); err != nil {
+		return err
+	}
+	slog.Info("print completed successfully")
+	p.consumePaper(lines)
+	return nil
+}
 
-		p.dispatchJobEvent(job, fsmEvent{kind: eventCancel, err: ctx.Err()})
-		select {
-		case err := <-job.doneCh:
-			return err
-		default:
+// consumePaper subtracts the length of printed lines from the paper roll
+// counter, if roll tracking is enabled.
+func (p *LXD02) consumePaper(lines int) {
+	if p.options.roll == nil {
+		return
+	}
+	consumePaper(p.options.roll, &p.alerts, lines, p.rasteriser.DPI())
+}
+
+// consumePaper subtracts the length of lines printed at dpi from the paper
+// roll counter rc, and records the alert once the roll is running low.  rc
+// may be nil, if the roll is not tracked.
+func consumePaper(rc *RollCounter, alerts *alertLog, lines int, dpi int) {
+	if rc == nil {
+		return
+	}
+	wasLow := rc.State().Low()
+	if err := rc.Consume(linesToMM(lines, dpi)); err != nil {
+		slog.Warn("failed to update paper roll counter", "error", err)
+		return
+	}
+	if st := rc.State(); st.Low() {
+		slog.Warn("paper roll is running low", "remaining_mm", int(st.Remaining))
+		if !wasLow {
+			alerts.add(AlertMediaLow, SeverityWarning, locale.Sprintf("Paper roll is running low: ~%.0fmm left", st.Remaining))
 		}
-		return ctx.Err()
 	}
 }
 
-func (p *LXD02) PrintTextTTF(ctx context.Context, text string, face font.Face) error {
+// PrintTextTTF renders the text with the font face and prints it.
+func (p *LXD02) PrintTextTTF(ctx context.Context, text string, face font.Face, opts ...bitmap.TextOption) error {
 	// rasterizeText
-	img, err := bitmap.RenderTTF(text, face, p.rasteriser.LineWidth())
+	img, err := bitmap.RenderTTF(text, face, p.rasteriser.LineWidth(), opts...)
 	if err != nil {
 		return fmt.Errorf("failed to render TTF text: %w", err)
 	}
@@ -537,10 +941,10 @@ func debugSaveImage(img image.Image, filename string) {
 
 var errBufferEmpty = errors.New("buffer is empty")
 
-// printBuffer sends the buffer to printer starting from
+// printBuffer sends the packets of the job to printer starting from
 // packet n.
 func (p *LXD02) printBuffer(job *printJob, start int, streamID uint64) {
-	if len(p.buffer) == 0 || start >= len(p.buffer) {
+	if len(job.packets) == 0 || start >= len(job.packets) {
 		p.dispatchJobEvent(job, fsmEvent{kind: eventError, err: errBufferEmpty, streamID: streamID})
 		return
 	}
@@ -550,24 +954,36 @@ func (p *LXD02) printBuffer(job *printJob, start int, streamID uint64) {
 	job.printCancel = cancel
 	p.stateMu.Unlock()
 
+	opts := p.options
 	go func() {
 		defer cancel()
 
-		t := time.NewTicker(p.options.printInterval)
+		t := time.NewTicker(opts.printInterval)
 		defer t.Stop()
 
-		for i := start; i < len(p.buffer); i++ {
+		for i := start; i < len(job.packets); i++ {
 			select {
 			case <-ctx.Done():
 				slog.Debug("Print buffer cancelled at packet", "packet", i)
 				return
 			case <-t.C:
-				err := p.sendPacket(p.buffer[i])
+				err := p.sendPacket(job.packets[i])
+				if err != nil && int(job.reconnects.Load()) < opts.reconnects && p.canReconnect() {
+					n := job.reconnects.Add(1)
+					slog.Warn("Failed to send packet, reconnecting to the printer", "packet", i, "attempt", n, "error", err)
+					if rerr := p.resumePrint(ctx, job); rerr != nil {
+						err = fmt.Errorf("%w, reconnect failed: %w", err, rerr)
+					} else {
+						slog.Info("Reconnected, resuming the print", "packet", i)
+						err = p.sendPacket(job.packets[i])
+					}
+				}
 				if err != nil {
 					slog.Error("Failed to send packet", "packet", i, "error", err)
 					p.dispatchJobEvent(job, fsmEvent{kind: eventError, err: fmt.Errorf("send packet %d: %w", i, err), streamID: streamID})
 					return
 				}
+				opts.reportProgress(i+1, len(job.packets))
 			}
 		}
 
@@ -586,23 +1002,96 @@ const (
 	minEnergy, maxEnergy = 1, 6
 )
 
-func (p *LXD02) sendInitSequence(job *printJob) {
-	initSeq := [][]byte{
+// InitSequence is the handshake, that is sent to the printer before each
+// print job.  The printer acknowledges every command with the response that
+// starts with the same two bytes as the command.
+type InitSequence struct {
+	// Commands are sent in order.
+	Commands [][]byte
+	// Energy encodes the energy command for the level in range 1-6.  It is
+	// sent after the Commands.  If nil, the energy command is not sent.
+	Energy func(level uint8) []byte
+}
+
+// LXD02InitSequence is the handshake of the original LX-D02 firmware.
+var LXD02InitSequence = InitSequence{
+	Commands: [][]byte{
 		{0x5a, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
 		{0x5a, 0x0a, 0xB5, 0x7C, 0x4C, 0xB8, 0xAE, 0x70, 0x51, 0xE6, 0xD3, 0x06},
 		{0x5a, 0x0b, 0x66, 0x3B, 0x62, 0x8C, 0x1A, 0x69, 0xBF, 0x54, 0x74, 0x4C},
-		{0x5a, 0x0c, max(min(p.options.energy, maxEnergy), minEnergy)},
+	},
+	Energy: func(level uint8) []byte {
+		return []byte{0x5a, 0x0c, level}
+	},
+}
+
+func (s InitSequence) validate() error {
+	for i, cmd := range s.Commands {
+		if len(cmd) < 2 {
+			return fmt.Errorf("command %d is too short: % x", i, cmd)
+		}
+	}
+	return nil
+}
+
+// commands returns the commands of the sequence with the energy command for
+// the energy level.
+func (s InitSequence) commands(energy uint8) ([][]byte, error) {
+	cmds := s.Commands
+	if s.Energy != nil {
+		cmd := s.Energy(max(min(energy, maxEnergy), minEnergy))
+		if len(cmd) < 2 {
+			return nil, fmt.Errorf("energy command is too short: % x", cmd)
+		}
+		cmds = append(slices.Clip(cmds), cmd)
+	}
+	return cmds, nil
+}
+
+func (p *LXD02) sendInitSequence(job *printJob) {
+	if err := p.handshake(); err != nil {
+		p.dispatchJobEvent(job, fsmEvent{kind: eventError, err: err})
+		return
+	}
+	p.dispatchJobEvent(job, fsmEvent{kind: eventInitComplete})
+}
+
+// handshake sends the init sequence and waits for the acknowledgements.
+func (p *LXD02) handshake() error {
+	initSeq, err := p.options.initSeq.commands(p.options.energy)
+	if err != nil {
+		return err
 	}
 	for _, cmd := range initSeq {
 		expectPrefix := cmd[:2]
-		resp, err := p.sendAndWait(cmd, expectPrefix, responseTimeout)
+		resp, err := p.sendAndWaitForFSM(cmd, expectPrefix, p.options.timeout())
 		if err != nil {
-			p.dispatchJobEvent(job, fsmEvent{kind: eventError, err: fmt.Errorf("send init command % x: %w", expectPrefix, err)})
-			return
+			return fmt.Errorf("send init command % x: %w", expectPrefix, err)
 		}
 		slog.Debug("init ack", "prefix", fmt.Sprintf("% x", expectPrefix), "response", fmt.Sprintf("% x", resp))
 	}
-	p.dispatchJobEvent(job, fsmEvent{kind: eventInitComplete})
+	return nil
+}
+
+// resumePrint reconnects to the printer after the link dropped in the
+// middle of the job, repeats the handshake and the print job command, so
+// that the packets can be sent from where they stopped.  If the printer
+// lost some of them, it asks to retransmit, as usual.
+func (p *LXD02) resumePrint(ctx context.Context, job *printJob) error {
+	if err := p.reconnect(ctx); err != nil {
+		return err
+	}
+	if err := p.handshake(); err != nil {
+		return err
+	}
+	if !p.isActiveJob(job) || ctx.Err() != nil {
+		return context.Canceled
+	}
+	beginCmd := job.command(0x00)
+	if _, err := p.sendAndWaitForFSM(beginCmd, beginCmd[:2], p.options.timeout()); err != nil {
+		return fmt.Errorf("send print command: %w", err)
+	}
+	return nil
 }
 
 func extractRetryPacketIndex(data []byte) int {
@@ -613,14 +1102,14 @@ func extractRetryPacketIndex(data []byte) int {
 }
 
 func (p *LXD02) send(data []byte) error {
-	for i := range maxRetries {
-		slog.Debug("Sending data", "state", p.state, "attempt", i+1, "data", fmt.Sprintf("% X", data))
-		_, err := p.tx.WriteWithoutResponse(data)
+	for i := range p.options.writeRetries() {
+		slog.Debug("Sending data", "state", p.currentState(), "attempt", i+1, "data", fmt.Sprintf("% X", data))
+		err := p.transport().Write(data)
 		if err == nil {
 			return nil
 		}
 		slog.Warn("send failed, retrying", "attempt", i+1, "error", err)
-		time.Sleep(sendRetryDelay)
+		time.Sleep(p.options.writeRetryDelay())
 	}
 	return errors.New("BLE write failed after retries")
 }
@@ -631,13 +1120,14 @@ func (p *LXD02) sendAndWait(data []byte, expectPrefix []byte, timeout time.Durat
 		p.responseMu.Unlock()
 		return nil, errors.New("sendAndWait already in progress")
 	}
-	p.responseCh = make(chan []byte, 1)
+	respCh := make(chan []byte, 1)
+	p.responseCh = respCh
 	p.waitingPrefix = expectPrefix
 	p.responseMu.Unlock()
 
-	slog.Debug("Sending data", "state", p.state, "data", fmt.Sprintf("% X", data), "expectPrefix", fmt.Sprintf("% X", expectPrefix))
+	slog.Debug("Sending data", "state", p.currentState(), "data", fmt.Sprintf("% X", data), "expectPrefix", fmt.Sprintf("% X", expectPrefix))
 
-	if _, err := p.tx.WriteWithoutResponse(data); err != nil {
+	if err := p.transport().Write(data); err != nil {
 		p.responseMu.Lock()
 		p.responseCh = nil
 		p.waitingPrefix = nil
@@ -646,15 +1136,25 @@ func (p *LXD02) sendAndWait(data []byte, expectPrefix []byte, timeout time.Durat
 	}
 
 	select {
-	case resp := <-p.responseCh:
+	case resp := <-respCh:
 		return resp, nil
 	case <-time.After(timeout):
 		p.responseMu.Lock()
 		p.responseCh = nil
 		p.waitingPrefix = nil
 		p.responseMu.Unlock()
-		return nil, fmt.Errorf("timeout waiting for response to % X", expectPrefix)
+		return nil, fmt.Errorf("%w waiting for response to % X", ErrTimeout, expectPrefix)
+	}
+}
+
+// Address returns the address of the connected printer: MAC address, or the
+// CoreBluetooth UUID on macOS.  It returns an empty string if the printer is
+// not connected.
+func (p *LXD02) Address() string {
+	if !p.connected.Load() {
+		return ""
 	}
+	return p.transport().Address()
 }
 
 // Width returns the maximum width of the print output in pixels.
@@ -673,9 +1173,24 @@ func (p *LXD02) PrintPattern(ctx context.Context, pattern string) error {
 	if bufPatFn, ok := TestBufferPatterns[pattern]; ok {
 		return p.printBufferPattern(ctx, bufPatFn)
 	}
+	if _, ok := GenerativePatterns[pattern]; ok {
+		return p.PrintGenerativePattern(ctx, pattern, 0)
+	}
 	return fmt.Errorf("unknown test pattern: %s", pattern)
 }
 
+// PrintGenerativePattern prints one of the [GenerativePatterns] generated
+// from the seed.
+func (p *LXD02) PrintGenerativePattern(ctx context.Context, pattern string, seed uint64) error {
+	genFn, ok := GenerativePatterns[pattern]
+	if !ok {
+		return fmt.Errorf("unknown generative pattern: %s", pattern)
+	}
+	return p.printImagePattern(ctx, func(width int) image.Image {
+		return genFn(width, seed)
+	})
+}
+
 func (p *LXD02) printImagePattern(ctx context.Context, imgFn func(int) image.Image) error {
 	img := imgFn(p.rasteriser.LineWidth())
 	if img == nil {
@@ -707,8 +1222,7 @@ func (p *LXD02) SetOptions(opts ...Option) error {
 	if p.options.dithername != "" {
 		ditherFunc, ok := bitmap.DitherFunction(p.options.dithername)
 		if !ok {
-			slog.Warn("unknown dither function, using default", "name", p.options.dithername)
-			return nil
+			return fmt.Errorf("unknown dither function: %s", p.options.dithername)
 		}
 		p.rasteriser.SetDitherFunc(ditherFunc)
 		slog.Debug("Using dither function", "name", p.options.dithername)
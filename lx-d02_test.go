@@ -1,6 +1,15 @@
 package thermoprint
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
 
 func TestParseStatus(t *testing.T) {
 	st, err := parseStatus([]byte{0x5a, 0x02, 87, 1, 2, 0})
@@ -50,3 +59,259 @@ func TestLXD02SnapshotStoresLastStatus(t *testing.T) {
 		t.Fatal("LastStatusTime is zero")
 	}
 }
+
+func TestInitSequence_commands(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		for _, tt := range []struct{ energy, want uint8 }{{0, 1}, {3, 3}, {9, 6}} {
+			cmds, err := LXD02InitSequence.commands(tt.energy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cmds) != 4 {
+				t.Fatalf("got %d commands, want 4", len(cmds))
+			}
+			if got, want := cmds[3], []byte{0x5a, 0x0c, tt.want}; !bytes.Equal(got, want) {
+				t.Errorf("energy %d: got % x, want % x", tt.energy, got, want)
+			}
+		}
+	})
+	t.Run("without energy", func(t *testing.T) {
+		seq := InitSequence{Commands: [][]byte{{0x5a, 0x01}}}
+		cmds, err := seq.commands(2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cmds) != 1 {
+			t.Errorf("got %d commands, want 1", len(cmds))
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		if err := (InitSequence{Commands: [][]byte{{0x5a}}}).validate(); err == nil {
+			t.Error("validate accepted the short command")
+		}
+		seq := InitSequence{Energy: func(uint8) []byte { return nil }}
+		if _, err := seq.commands(2); err == nil {
+			t.Error("commands accepted the empty energy command")
+		}
+	})
+}
+
+func TestNewLXD02_initSequence(t *testing.T) {
+	seq := InitSequence{
+		Commands: [][]byte{{0x5a, 0x01, 0xff}},
+		Energy:   func(level uint8) []byte { return []byte{0x5a, 0x0d, level * 2} },
+	}
+	p, err := NewLXD02(context.Background(), nil, SearchParameters{}, WithDryRun(true), WithInitSequence(seq), WithEnergy(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.activeJob = p.newPrintJob(context.Background(), nil)
+	setFSMState(p, stateInitializing)
+
+	var sent [][]byte
+	p.sendAndWaitHook = func(data []byte, expectPrefix []byte, _ time.Duration) ([]byte, error) {
+		sent = append(sent, bytes.Clone(data))
+		return expectPrefix, nil
+	}
+	p.sendInitSequence(p.activeJob)
+
+	want := [][]byte{{0x5a, 0x01, 0xff}, {0x5a, 0x0d, 6}}
+	if !slices.EqualFunc(sent, want, bytes.Equal) {
+		t.Errorf("sent % x, want % x", sent, want)
+	}
+
+	if _, err := NewLXD02(context.Background(), nil, SearchParameters{}, WithDryRun(true), WithInitSequence(InitSequence{Commands: [][]byte{{}}})); err == nil {
+		t.Error("NewLXD02 accepted the invalid init sequence")
+	}
+}
+
+func TestPrintOptions_timeoutsAndRetries(t *testing.T) {
+	var o printOptions
+	if o.timeout() != responseTimeout || o.writeRetries() != maxRetries || o.writeRetryDelay() != sendRetryDelay {
+		t.Errorf("zero options do not use the defaults: %v %d %v", o.timeout(), o.writeRetries(), o.writeRetryDelay())
+	}
+	if r := o.connectRetry(); r.Attempts != connectRetries || r.Wait != connectRetryWait {
+		t.Errorf("connectRetry = %+v", r)
+	}
+
+	for _, opt := range []Option{
+		WithResponseTimeout(10 * time.Second),
+		WithSendRetries(7, time.Second),
+		WithConnectRetries(2, 5*time.Second),
+	} {
+		opt(&o)
+	}
+	if o.timeout() != 10*time.Second || o.writeRetries() != 7 || o.writeRetryDelay() != time.Second {
+		t.Errorf("options are not applied: %v %d %v", o.timeout(), o.writeRetries(), o.writeRetryDelay())
+	}
+	if r := o.connectRetry(); r.Attempts != 2 || r.Wait != 5*time.Second {
+		t.Errorf("connectRetry = %+v", r)
+	}
+
+	WithResponseTimeout(-time.Second)(&o)
+	if o.timeout() != responseTimeout {
+		t.Errorf("negative timeout is not reset to the default: %v", o.timeout())
+	}
+}
+
+// statusConn responds to the status request with the status.
+type statusConn struct {
+	fakeCatConn
+	status []byte
+	notify func([]byte)
+}
+
+func (c *statusConn) Notify(fn func([]byte)) error {
+	c.notify = fn
+	return nil
+}
+
+func (c *statusConn) Write(data []byte) error {
+	if bytes.HasPrefix(data, prefixStatus) && c.status != nil {
+		go c.notify(c.status)
+	}
+	return nil
+}
+
+func TestLXD02_Status(t *testing.T) {
+	conn := &statusConn{status: []byte{0x5a, 0x02, 15, 0x00, 0x01, 0x00}}
+	p, err := NewLXD02(t.Context(), nil, SearchParameters{}, WithTransport(conn), WithResponseTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := p.Status(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Status{BatteryLevel: 15, Charging: true}); st != want {
+		t.Errorf("Status() = %+v, want %+v", st, want)
+	}
+	if snap := p.Snapshot(); snap.BatteryLevel != 15 || snap.LastStatusTime.IsZero() {
+		t.Errorf("snapshot is not updated: %+v", snap)
+	}
+	if alerts := p.Alerts(); len(alerts) != 1 || alerts[0].Code != AlertBatteryLow {
+		t.Errorf("alerts = %v", alerts)
+	}
+
+	conn.status = nil
+	if _, err := p.Status(t.Context()); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Status error = %v, want %v", err, ErrTimeout)
+	}
+
+	dry, err := NewLXD02(t.Context(), nil, SearchParameters{}, WithDryRun(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dry.Status(t.Context()); !errors.Is(err, ErrDisconnected) {
+		t.Errorf("Status error = %v, want %v", err, ErrDisconnected)
+	}
+}
+
+// dropConn is the LX-D02, that acknowledges the commands, reports the end
+// of the print after the last packet, and drops the link after dropAfter
+// packets, if it is positive.
+type dropConn struct {
+	fakeCatConn
+	mu        sync.Mutex
+	notify    func([]byte)
+	dropAfter int
+	packets   int
+	dropped   bool
+	total     int
+	received  *[]int // indices of the received packets, shared by the connections
+}
+
+func (c *dropConn) Notify(fn func([]byte)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notify = fn
+	return nil
+}
+
+func (c *dropConn) Write(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dropped {
+		return errors.New("link dropped")
+	}
+	notify := c.notify
+	switch data[0] {
+	case 0x5a:
+		go notify(slices.Clone(data)) // acknowledge the command
+	case 0x55:
+		if c.dropAfter > 0 && c.packets == c.dropAfter {
+			c.dropped = true
+			return errors.New("link dropped")
+		}
+		c.packets++
+		idx := int(data[1])<<8 | int(data[2])
+		*c.received = append(*c.received, idx)
+		if idx == c.total-1 {
+			// the printer reports once the paper is printed
+			time.AfterFunc(50*time.Millisecond, func() { notify([]byte{0x5a, 0x06, 0x00}) })
+		}
+	}
+	return nil
+}
+
+func TestLXD02_reconnect(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 384, 20))
+	packets, err := LXD02Rasteriser.Serialise(LXD02Rasteriser.ResizeAndDither(img, 0, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := len(packets)
+
+	newPrinter := func(t *testing.T, opt ...Option) (*LXD02, *[]int, *int) {
+		t.Helper()
+		var (
+			received []int
+			dials    int
+		)
+		dial := func(context.Context) (Transport, error) {
+			dials++
+			c := &dropConn{total: total, received: &received}
+			if dials == 1 {
+				c.dropAfter = 4
+			}
+			return c, nil
+		}
+		opt = append([]Option{WithDialer(dial), WithResponseTimeout(time.Second), WithSendRetries(1, time.Millisecond)}, opt...)
+		p, err := NewLXD02(t.Context(), nil, SearchParameters{}, opt...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { p.Disconnect() })
+		return p, &received, &dials
+	}
+
+	t.Run("resumes after the link drops", func(t *testing.T) {
+		p, received, dials := newPrinter(t)
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+		if err := p.PrintImage(ctx, img); err != nil {
+			t.Fatalf("PrintImage: %v", err)
+		}
+		if *dials != 2 {
+			t.Errorf("dialled %d times, want 2", *dials)
+		}
+		want := make([]int, total) // each packet once, in order
+		for i := range want {
+			want[i] = i
+		}
+		if !slices.Equal(*received, want) {
+			t.Errorf("received packets %v, want %v", *received, want)
+		}
+	})
+	t.Run("disabled", func(t *testing.T) {
+		p, _, dials := newPrinter(t, WithReconnects(0))
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+		if err := p.PrintImage(ctx, img); err == nil {
+			t.Fatal("PrintImage succeeded on the dropped link")
+		}
+		if *dials != 1 {
+			t.Errorf("dialled %d times, want 1", *dials)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package thermoprint
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"golang.org/x/image/draw"
+
+	"github.com/rusq/thermoprint/bitmap"
+)
+
+// NotepaperStyles are the notepaper patterns.  Each function draws a sheet
+// of the given width and length, with the lines or dots spaced by spacing,
+// all in dots.
+var NotepaperStyles = map[string]func(width, length, spacing int) image.Image{
+	"ruled": PaperRuled,
+	"grid":  PaperGrid,
+	"dot":   PaperDots,
+}
+
+func newSheet(width, length int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, length))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	return img
+}
+
+// offset returns the offset of the first line, so that the lines are
+// centred on the sheet of size n.
+func offset(n, spacing int) int {
+	return (n - 1) % spacing / 2
+}
+
+// PaperRuled draws horizontal lines.
+func PaperRuled(width, length, spacing int) image.Image {
+	img := newSheet(width, length)
+	if spacing <= 0 {
+		return img
+	}
+	for y := offset(length, spacing); y < length; y += spacing {
+		for x := range width {
+			img.SetGray(x, y, color.Gray{})
+		}
+	}
+	return img
+}
+
+// PaperGrid draws a square grid.
+func PaperGrid(width, length, spacing int) image.Image {
+	img := PaperRuled(width, length, spacing).(*image.Gray)
+	if spacing <= 0 {
+		return img
+	}
+	for x := offset(width, spacing); x < width; x += spacing {
+		for y := range length {
+			img.SetGray(x, y, color.Gray{})
+		}
+	}
+	return img
+}
+
+// PaperDots draws dots in the nodes of a square grid.  The dots are 2x2
+// dots in size, as single dots are too faint on thermal paper.
+func PaperDots(width, length, spacing int) image.Image {
+	img := newSheet(width, length)
+	if spacing <= 0 {
+		return img
+	}
+	for y := offset(length, spacing); y < length; y += spacing {
+		for x := offset(width, spacing); x < width; x += spacing {
+			draw.Draw(img, image.Rect(x, y, x+2, y+2).Intersect(img.Bounds()), image.Black, image.Point{}, draw.Src)
+		}
+	}
+	return img
+}
+
+// ParseLength parses the length with the unit suffix, i.e. "150mm", "15cm",
+// "2.5in" or "600px", and returns it in dots at the given resolution.  The
+// number without the suffix is in millimetres.  The length must be at least
+// one dot, see [bitmap.ParseLength] for the lengths that may be zero.
+func ParseLength(s string, dpi float64) (int, error) {
+	n, err := bitmap.ParseLength(s, dpi)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("invalid length %q: must be positive", s)
+	}
+	return n, nil
+}
@@ -0,0 +1,95 @@
+package thermoprint
+
+import (
+	"image"
+	"testing"
+)
+
+func TestParseLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    int
+		wantErr bool
+	}{
+		{"millimetres", "150mm", 1199, false},
+		{"no unit is millimetres", "150", 1199, false},
+		{"centimetres", "15cm", 1199, false},
+		{"inches", "2in", 406, false},
+		{"pixels", "600px", 600, false},
+		{"fraction and spaces", " 2.5 MM ", 20, false},
+		{"zero", "0mm", 0, true},
+		{"negative", "-5mm", 0, true},
+		{"not a number", "longmm", 0, true},
+		{"infinity", "inf", 0, true},
+		{"empty", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLength(tt.s, 203)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLength(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLength(%q) = %d, want %d", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+// rowBlack returns the number of black dots in the row y.
+func rowBlack(img *image.Gray, y int) int {
+	var n int
+	for x := range img.Bounds().Dx() {
+		if img.GrayAt(x, y).Y == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// colBlack returns the number of black dots in the column x.
+func colBlack(img *image.Gray, x int) int {
+	var n int
+	for y := range img.Bounds().Dy() {
+		if img.GrayAt(x, y).Y == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func TestNotepaperStyles(t *testing.T) {
+	const (
+		width   = 384
+		length  = 400
+		spacing = 40
+	)
+	for name, fn := range NotepaperStyles {
+		t.Run(name, func(t *testing.T) {
+			img := fn(width, length, spacing).(*image.Gray)
+			if got := img.Bounds(); got != image.Rect(0, 0, width, length) {
+				t.Fatalf("bounds = %v", got)
+			}
+			// the first line is at (400-1)%40/2 = 19.
+			line, between := rowBlack(img, 19), rowBlack(img, 19+spacing/2)
+			switch name {
+			case "ruled":
+				if line != width || between != 0 {
+					t.Errorf("ruled line has %d black dots, between lines %d", line, between)
+				}
+				if n := colBlack(img, 0); n != length/spacing {
+					t.Errorf("column has %d black dots, want %d", n, length/spacing)
+				}
+			case "grid":
+				if line != width || between == 0 {
+					t.Errorf("grid line has %d black dots, between lines %d", line, between)
+				}
+			case "dot":
+				if line == 0 || line == width || between != 0 {
+					t.Errorf("dot row has %d black dots, between rows %d", line, between)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package thermoprint
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxUnknownNotifications is the number of unknown notifications kept by the
+// driver.
+const maxUnknownNotifications = 64
+
+// UnknownNotification is the notification that the driver did not
+// recognise.  Clone firmwares send notifications that the original printers
+// don't, the captured data helps to support them.
+type UnknownNotification struct {
+	Time time.Time
+	Data []byte
+}
+
+func (n UnknownNotification) String() string {
+	return fmt.Sprintf("%s % x", n.Time.Format(time.TimeOnly), n.Data)
+}
+
+// NotificationCapturer is implemented by the drivers that capture the
+// unknown notifications.
+type NotificationCapturer interface {
+	// UnknownNotifications returns the captured unknown notifications,
+	// oldest first.
+	UnknownNotifications() []UnknownNotification
+}
+
+var (
+	_ NotificationCapturer = (*LXD02)(nil)
+	_ NotificationCapturer = (*CatPrinter)(nil)
+	_ NotificationCapturer = (*Phomemo)(nil)
+)
+
+// notificationRing is a ring buffer, that holds up to
+// maxUnknownNotifications most recent unknown notifications.  It is safe for
+// concurrent use.
+type notificationRing struct {
+	mu    sync.Mutex
+	items [maxUnknownNotifications]UnknownNotification
+	next  int // index of the next item
+	count int // number of items stored
+}
+
+// add stores the copy of data.
+func (r *notificationRing) add(data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items[r.next] = UnknownNotification{Time: time.Now(), Data: bytes.Clone(data)}
+	r.next = (r.next + 1) % len(r.items)
+	r.count = min(r.count+1, len(r.items))
+}
+
+func (r *notificationRing) list() []UnknownNotification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]UnknownNotification, 0, r.count)
+	for i := range r.count {
+		out = append(out, r.items[(r.next-r.count+i+len(r.items))%len(r.items)])
+	}
+	return out
+}
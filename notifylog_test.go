@@ -0,0 +1,42 @@
+package thermoprint
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNotificationRing(t *testing.T) {
+	var r notificationRing
+	if got := r.list(); len(got) != 0 {
+		t.Fatalf("empty ring returned %v", got)
+	}
+	data := []byte{0x5a, 0x00}
+	r.add(data)
+	data[1] = 0xff // the ring keeps a copy
+	if got := r.list(); len(got) != 1 || !bytes.Equal(got[0].Data, []byte{0x5a, 0x00}) {
+		t.Fatalf("list = %v", got)
+	}
+
+	for i := range maxUnknownNotifications + 3 {
+		r.add([]byte{byte(i)})
+	}
+	got := r.list()
+	if len(got) != maxUnknownNotifications {
+		t.Fatalf("got %d notifications, want %d", len(got), maxUnknownNotifications)
+	}
+	for i, n := range got {
+		if want := byte(i + 3); n.Data[0] != want {
+			t.Fatalf("notification %d = % x, want %02x", i, n.Data, want)
+		}
+	}
+}
+
+func TestLXD02_capturesUnknownNotifications(t *testing.T) {
+	p := &LXD02{}
+	cb := p.notificationCallback(make(chan lxd02notification, 1))
+	cb([]byte{0x5a, 0xee, 0x01})
+	got := p.UnknownNotifications()
+	if len(got) != 1 || !bytes.Equal(got[0].Data, []byte{0x5a, 0xee, 0x01}) {
+		t.Errorf("UnknownNotifications = %v", got)
+	}
+}
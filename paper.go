@@ -0,0 +1,139 @@
+package thermoprint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRollLength is the length of a fresh paper roll in millimetres,
+	// typical for the 57x30mm rolls sold for pocket thermal printers.
+	DefaultRollLength = 5000.0
+	// DefaultRollLowMark is the remaining length, in millimetres, below
+	// which the roll is reported as low.
+	DefaultRollLowMark = 500.0
+)
+
+const mmPerInch = 25.4
+
+// RollState is the persisted state of the [RollCounter].  All lengths are in
+// millimetres.
+type RollState struct {
+	Length    float64   `json:"length_mm"`    // length of a fresh roll
+	Remaining float64   `json:"remaining_mm"` // estimated paper left on the roll
+	LowMark   float64   `json:"low_mark_mm"`  // threshold for the media-low condition
+	LoadedAt  time.Time `json:"loaded_at"`    // time when the roll was reset
+}
+
+// Low returns true if the remaining length is at or below the low mark.
+func (s RollState) Low() bool {
+	return s.Remaining <= s.LowMark
+}
+
+// RollCounter estimates the paper left on the roll by subtracting the
+// length of every printout from the length of a fresh roll.  The estimate is
+// kept in a JSON file, so that it survives between program runs.  It is safe
+// for concurrent use.
+type RollCounter struct {
+	mu    sync.Mutex
+	path  string
+	state RollState
+}
+
+// NewRollCounter starts tracking a fresh roll of the given length and saves
+// the state to the file at path.  lowMark sets the media-low threshold, if it
+// is zero, [DefaultRollLowMark] is used.
+func NewRollCounter(path string, length, lowMark float64) (*RollCounter, error) {
+	rc := &RollCounter{path: path}
+	if err := rc.Reset(length, lowMark); err != nil {
+		return nil, err
+	}
+	return rc, nil
+}
+
+// LoadRollCounter loads the counter state from the file at path.  If the
+// roll is not tracked yet, the returned error wraps [os.ErrNotExist].
+func LoadRollCounter(path string) (*RollCounter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load roll counter: %w", err)
+	}
+	var st RollState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("decode roll counter %s: %w", path, err)
+	}
+	if st.Length <= 0 {
+		return nil, fmt.Errorf("invalid roll length in %s: %.0fmm", path, st.Length)
+	}
+	return &RollCounter{path: path, state: st}, nil
+}
+
+// Reset starts tracking a fresh roll of the given length, i.e. after a new
+// roll was loaded.  Zero lowMark keeps the current threshold.
+func (rc *RollCounter) Reset(length, lowMark float64) error {
+	if length <= 0 {
+		return errors.New("roll length must be positive")
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if lowMark <= 0 {
+		lowMark = rc.state.LowMark
+	}
+	if lowMark <= 0 {
+		lowMark = DefaultRollLowMark
+	}
+	rc.state = RollState{
+		Length:    length,
+		Remaining: length,
+		LowMark:   lowMark,
+		LoadedAt:  time.Now(),
+	}
+	return rc.saveLocked()
+}
+
+// Consume subtracts mm millimetres from the remaining length and saves the
+// new estimate.
+func (rc *RollCounter) Consume(mm float64) error {
+	if mm <= 0 {
+		return nil
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.state.Remaining = max(0, rc.state.Remaining-mm)
+	return rc.saveLocked()
+}
+
+// State returns the current estimate.
+func (rc *RollCounter) State() RollState {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.state
+}
+
+func (rc *RollCounter) saveLocked() error {
+	data, err := json.MarshalIndent(rc.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(rc.path), 0755); err != nil {
+		return fmt.Errorf("create roll counter directory: %w", err)
+	}
+	if err := os.WriteFile(rc.path, data, 0644); err != nil {
+		return fmt.Errorf("save roll counter: %w", err)
+	}
+	return nil
+}
+
+// linesToMM converts the number of printed raster lines at dpi to
+// millimetres.
+func linesToMM(lines int, dpi int) float64 {
+	if dpi <= 0 {
+		return 0
+	}
+	return float64(lines) / float64(dpi) * mmPerInch
+}
@@ -0,0 +1,89 @@
+package thermoprint
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRollCounterPersistsConsumption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "roll.json")
+	rc, err := NewRollCounter(path, 1000, 100)
+	if err != nil {
+		t.Fatalf("NewRollCounter: %v", err)
+	}
+	if err := rc.Consume(250); err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+
+	loaded, err := LoadRollCounter(path)
+	if err != nil {
+		t.Fatalf("LoadRollCounter: %v", err)
+	}
+	st := loaded.State()
+	if st.Length != 1000 || st.Remaining != 750 || st.LowMark != 100 {
+		t.Fatalf("state = %+v", st)
+	}
+	if st.Low() {
+		t.Fatal("Low() = true, want false")
+	}
+}
+
+func TestRollCounterLow(t *testing.T) {
+	rc, err := NewRollCounter(filepath.Join(t.TempDir(), "roll.json"), 1000, 0)
+	if err != nil {
+		t.Fatalf("NewRollCounter: %v", err)
+	}
+	if got := rc.State().LowMark; got != DefaultRollLowMark {
+		t.Fatalf("LowMark = %v, want %v", got, DefaultRollLowMark)
+	}
+	if err := rc.Consume(2000); err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	st := rc.State()
+	if st.Remaining != 0 {
+		t.Fatalf("Remaining = %v, want 0", st.Remaining)
+	}
+	if !st.Low() {
+		t.Fatal("Low() = false, want true")
+	}
+
+	// reset keeps the low mark
+	if err := rc.Reset(3000, 0); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if st := rc.State(); st.Remaining != 3000 || st.LowMark != DefaultRollLowMark || st.Low() {
+		t.Fatalf("state after reset = %+v", st)
+	}
+}
+
+func TestRollCounterRejectsInvalidLength(t *testing.T) {
+	if _, err := NewRollCounter(filepath.Join(t.TempDir(), "roll.json"), 0, 0); err == nil {
+		t.Fatal("NewRollCounter error = nil, want error")
+	}
+}
+
+func TestLoadRollCounterNotTracked(t *testing.T) {
+	_, err := LoadRollCounter(filepath.Join(t.TempDir(), "roll.json"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("LoadRollCounter error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestLinesToMM(t *testing.T) {
+	tests := []struct {
+		lines, dpi int
+		want       float64
+	}{
+		{203, 203, 25.4},
+		{0, 203, 0},
+		{100, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := linesToMM(tt.lines, tt.dpi); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("linesToMM(%d, %d) = %v, want %v", tt.lines, tt.dpi, got, tt.want)
+		}
+	}
+}
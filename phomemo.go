@@ -0,0 +1,464 @@
+package thermoprint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/image/font"
+
+	"github.com/rusq/thermoprint/bitmap"
+)
+
+// PhomemoModels are the name prefixes advertised by the Phomemo printers,
+// that are driven by [Phomemo].  The printers advertise the model name with
+// a suffix, i.e. "M02S".
+var PhomemoModels = []string{"M02", "T02"}
+
+// IsPhomemo returns true if the name starts with one of the
+// [PhomemoModels].
+func IsPhomemo(name string) bool {
+	name = strings.ToUpper(name)
+	for _, model := range PhomemoModels {
+		if strings.HasPrefix(name, model) {
+			return true
+		}
+	}
+	return false
+}
+
+var phomemoGATT = gattProfile{
+	service: "0000ff00-0000-1000-8000-00805f9b34fb",
+	tx:      "0000ff02-0000-1000-8000-00805f9b34fb",
+	rx:      "0000ff03-0000-1000-8000-00805f9b34fb",
+}
+
+// Commands of the Phomemo protocol, the ESC/POS commands and the vendor
+// extensions prefixed with 1f 11.
+var (
+	phomemoReset     = []byte{0x1b, 0x40}             // ESC @, initialise the printer
+	phomemoJustify   = []byte{0x1b, 0x61, 0x01}       // ESC a 1, centre the raster
+	phomemoDensity   = []byte{0x1f, 0x11, 0x02}       // set the print density, followed by 1-4
+	phomemoRaster    = []byte{0x1d, 0x76, 0x30, 0x00} // GS v 0, raster bit image
+	phomemoFeed      = []byte{0x1b, 0x64, 0x02}       // ESC d 2, feed two lines
+	phomemoBattery   = []byte{0x1f, 0x11, 0x08}       // query the battery level
+	phomemoFirmware  = []byte{0x1f, 0x11, 0x07}       // query the firmware version
+	phomemoPaper     = []byte{0x1f, 0x11, 0x09}       // query the paper state
+	phomemoEndOfJob  = []byte{0x1f, 0x11, 0x0e}       // end of the job
+	phomemoNotifyTag = byte(0x1a)                     // notifications start with 1a
+)
+
+// Notifications, sent by the printer as 1a <kind> <value>.
+const (
+	phomemoNotifyBattery = 0x04 // value is the battery level, percent
+	phomemoNotifyCover   = 0x05 // value is phomemoCoverOpen or phomemoCoverClosed
+	phomemoNotifyPaper   = 0x06 // value is phomemoPaperOut or phomemoPaperIn
+
+	phomemoCoverOpen   = 0x99
+	phomemoCoverClosed = 0x98
+	phomemoPaperOut    = 0x88
+	phomemoPaperIn     = 0x89
+)
+
+const (
+	// phomemoMaxLines is the maximum number of lines in one raster block.
+	phomemoMaxLines = 255
+	// phomemoChunk is the size of a single BLE write.
+	phomemoChunk = 128
+	// phomemoFeedLines is the paper fed after the print, in dots: two
+	// feed commands of two text lines of 1/6".
+	phomemoFeedLines = 2 * 2 * 34
+)
+
+// phomemoDensityLevel maps the energy level 1-6 to the print density 1-4.
+func phomemoDensityLevel(energy uint8) byte {
+	energy = max(min(energy, maxEnergy), minEnergy)
+	return byte((int(energy)*4 + 5) / 6)
+}
+
+// phomemoRasterBlocks returns the raster commands for the bitmap, split into
+// the blocks of at most [phomemoMaxLines] lines.  The first pixel of the row
+// is in the highest bit of the first byte, black pixels are set.
+func phomemoRasterBlocks(img image.Image, width int, threshold uint8) [][]byte {
+	b := img.Bounds()
+	bpl := width / 8
+	var blocks [][]byte
+	for y0 := 0; y0 < b.Dy(); y0 += phomemoMaxLines {
+		lines := min(phomemoMaxLines, b.Dy()-y0)
+		block := make([]byte, 0, len(phomemoRaster)+4+bpl*lines)
+		block = append(block, phomemoRaster...)
+		block = append(block, byte(bpl), byte(bpl>>8), byte(lines), byte(lines>>8))
+		for y := y0; y < y0+lines; y++ {
+			row := make([]byte, bpl)
+			for x := range min(width, b.Dx()) {
+				if bitmap.PixelBit(img, b.Min.X+x, b.Min.Y+y, threshold) {
+					row[x/8] |= 0x80 >> (x % 8)
+				}
+			}
+			block = append(block, row...)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// Phomemo is a driver for the Phomemo printers, see [PhomemoModels].  It has
+// the same API as [LXD02], and prints one job at a time.  Zero value is
+// unusable, initialise with [NewPhomemo].
+type Phomemo struct {
+	conn         Transport
+	connected    atomic.Bool
+	printing     atomic.Bool
+	disconnectMu sync.Mutex
+
+	rasteriser  *GenericRasteriser
+	options     printOptions
+	alerts      alertLog
+	unknown     notificationRing // unrecognised notifications
+	subscribers statusSubscribers
+
+	mu          sync.Mutex
+	status      lxd02status
+	batterySeen bool // battery level was reported
+	coverOpen   bool
+	seen        bool // device state was reported
+	statusAt    time.Time
+}
+
+// NewPhomemo connects to the Phomemo printer.
+func NewPhomemo(ctx context.Context, adapter *Adapter, sp SearchParameters, opt ...Option) (*Phomemo, error) {
+	p := &Phomemo{
+		rasteriser: &GenericRasteriser{
+			Width:      384,
+			Dpi:        203,
+			Threshold:  bitmap.DefaultThreshold,
+			DitherFunc: bitmap.DitherDefault,
+		},
+		options: printOptions{
+			energy:        2,
+			printInterval: DefaultPrintDelay,
+		},
+	}
+	for _, o := range opt {
+		o(&p.options)
+	}
+	if p.options.dithername != "" {
+		ditherFunc, ok := bitmap.DitherFunction(p.options.dithername)
+		if !ok {
+			return nil, fmt.Errorf("unknown dither function: %s", p.options.dithername)
+		}
+		p.rasteriser.SetDitherFunc(ditherFunc)
+	}
+	if !p.options.dryrun {
+		if err := p.Connect(ctx, adapter, sp); err != nil {
+			return nil, fmt.Errorf("failed to connect to printer: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// Connect connects to the Phomemo printer using the provided adapter and
+// search parameters, and requests the device state.
+func (p *Phomemo) Connect(ctx context.Context, adapter *Adapter, sp SearchParameters) error {
+	if p.connected.Load() {
+		return nil
+	}
+	conn, err := p.options.dial(ctx, adapter, sp, phomemoGATT)
+	if err != nil {
+		return err
+	}
+	p.conn = conn
+	if err := p.conn.Notify(p.notificationCallback); err != nil {
+		return fmt.Errorf("failed to enable notifications: %w", err)
+	}
+	p.connected.Store(true)
+	slog.Info("Connected to printer", "address", conn.Address())
+	for _, cmd := range [][]byte{phomemoBattery, phomemoPaper} {
+		if err := p.send(cmd); err != nil {
+			slog.Warn("failed to request the device state", "error", err)
+		}
+	}
+	return nil
+}
+
+func (p *Phomemo) notificationCallback(value []byte) {
+	if len(value) < 3 || value[0] != phomemoNotifyTag {
+		slog.Debug("Received unknown notification", "data", fmt.Sprintf("% x", value))
+		p.unknown.add(value)
+		return
+	}
+	p.mu.Lock()
+	prev, prevBattery, seen := p.status, p.batterySeen, p.seen
+	switch value[1] {
+	case phomemoNotifyBattery:
+		p.status.BatteryLevel, p.batterySeen = value[2], true
+	case phomemoNotifyCover:
+		p.coverOpen = value[2] == phomemoCoverOpen
+	case phomemoNotifyPaper:
+		p.status.NoPaper = value[2] == phomemoPaperOut
+	default:
+		p.mu.Unlock()
+		slog.Debug("Received unknown notification", "data", fmt.Sprintf("% x", value))
+		p.unknown.add(value)
+		return
+	}
+	cur := p.status
+	p.seen, p.statusAt = true, time.Now()
+	batterySeen := p.batterySeen
+	p.mu.Unlock()
+
+	// the battery level is not known until reported.
+	if !prevBattery {
+		prev.BatteryLevel = 100
+	}
+	if !batterySeen {
+		cur.BatteryLevel = 100
+	}
+	p.alerts.statusAlerts(prev, seen, cur)
+	p.subscribers.publish(cur.public())
+}
+
+// Subscribe registers fn to be called with every status that the printer
+// reports, see [StatusSubscriber].
+func (p *Phomemo) Subscribe(fn func(Status)) (unsubscribe func()) {
+	return p.subscribers.add(fn)
+}
+
+// checkState returns an error if the last reported device state does not
+// allow printing.
+func (p *Phomemo) checkState() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case p.status.NoPaper:
+		return ErrNoPaper
+	case p.coverOpen:
+		return errors.New("printer cover is open")
+	}
+	return nil
+}
+
+// send writes the data in chunks that fit a single BLE write.
+func (p *Phomemo) send(data []byte) error {
+	for chunk := range slices.Chunk(data, phomemoChunk) {
+		if err := p.write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Phomemo) write(data []byte) error {
+	for i := range p.options.writeRetries() {
+		err := p.conn.Write(data)
+		if err == nil {
+			return nil
+		}
+		slog.Warn("send failed, retrying", "attempt", i+1, "error", err)
+		time.Sleep(p.options.writeRetryDelay())
+	}
+	return errors.New("BLE write failed after retries")
+}
+
+// PrintImage prints an image on the printer.  If dry run is enabled, it saves
+// the preview file to disk and exits.
+func (p *Phomemo) PrintImage(ctx context.Context, img image.Image) error {
+	bmp := p.options.postProcess(p.rasteriser.ResizeAndDither(p.options.channel.Gray(img), p.options.gamma, p.options.autoDither))
+	if p.options.dryrun {
+		debugSaveImage(bmp, drRasteriseFile)
+		return nil
+	}
+	if !p.connected.Load() {
+		return ErrDisconnected
+	}
+	if !p.printing.CompareAndSwap(false, true) {
+		return ErrBusy
+	}
+	defer p.printing.Store(false)
+
+	if err := p.checkState(); err != nil {
+		return err
+	}
+
+	commands := [][]byte{
+		phomemoReset,
+		phomemoJustify,
+		slices.Concat(phomemoDensity, []byte{phomemoDensityLevel(p.options.energy)}),
+	}
+	commands = append(commands, phomemoRasterBlocks(bmp, p.rasteriser.LineWidth(), p.rasteriser.Threshold)...)
+	commands = append(commands,
+		phomemoFeed,
+		phomemoFeed,
+		phomemoBattery,
+		phomemoEndOfJob,
+		phomemoFirmware,
+		phomemoPaper,
+	)
+
+	t := time.NewTicker(p.options.printInterval)
+	defer t.Stop()
+	for i, cmd := range commands {
+		for chunk := range slices.Chunk(cmd, phomemoChunk) {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-t.C:
+			}
+			if err := p.write(chunk); err != nil {
+				return fmt.Errorf("send command %d: %w", i, err)
+			}
+		}
+		p.options.reportProgress(i+1, len(commands))
+	}
+	slog.Info("print completed successfully")
+	consumePaper(p.options.roll, &p.alerts, bmp.Bounds().Dy()+phomemoFeedLines, p.rasteriser.DPI())
+	return nil
+}
+
+// PrintTextTTF renders the text with the font face and prints it.
+func (p *Phomemo) PrintTextTTF(ctx context.Context, text string, face font.Face, opts ...bitmap.TextOption) error {
+	img, err := bitmap.RenderTTF(text, face, p.rasteriser.LineWidth(), opts...)
+	if err != nil {
+		return fmt.Errorf("failed to render TTF text: %w", err)
+	}
+	if p.options.dryrun {
+		debugSaveImage(img, drTextFile)
+	}
+	return p.PrintImage(ctx, img)
+}
+
+// PrintPattern prints the test pattern.  Buffer patterns are specific to
+// the LX-D02 protocol and are not supported.
+func (p *Phomemo) PrintPattern(ctx context.Context, pattern string) error {
+	if imgFn, ok := TestImagePatterns[pattern]; ok {
+		return p.printImagePattern(ctx, imgFn)
+	}
+	if _, ok := GenerativePatterns[pattern]; ok {
+		return p.PrintGenerativePattern(ctx, pattern, 0)
+	}
+	if _, ok := TestBufferPatterns[pattern]; ok {
+		return fmt.Errorf("buffer pattern %s is not supported by this printer", pattern)
+	}
+	return fmt.Errorf("unknown test pattern: %s", pattern)
+}
+
+// PrintGenerativePattern prints one of the [GenerativePatterns] generated
+// from the seed.
+func (p *Phomemo) PrintGenerativePattern(ctx context.Context, pattern string, seed uint64) error {
+	genFn, ok := GenerativePatterns[pattern]
+	if !ok {
+		return fmt.Errorf("unknown generative pattern: %s", pattern)
+	}
+	return p.printImagePattern(ctx, func(width int) image.Image {
+		return genFn(width, seed)
+	})
+}
+
+func (p *Phomemo) printImagePattern(ctx context.Context, imgFn func(int) image.Image) error {
+	img := imgFn(p.rasteriser.LineWidth())
+	if img == nil {
+		return errors.New("test image pattern returned nil image")
+	}
+	if p.options.dryrun {
+		debugSaveImage(img, drPatternFile)
+	}
+	return p.PrintImage(ctx, img)
+}
+
+// SetOptions sets the print options.
+func (p *Phomemo) SetOptions(opts ...Option) error {
+	for _, o := range opts {
+		o(&p.options)
+	}
+	if p.options.dithername != "" {
+		ditherFunc, ok := bitmap.DitherFunction(p.options.dithername)
+		if !ok {
+			return fmt.Errorf("unknown dither function: %s", p.options.dithername)
+		}
+		p.rasteriser.SetDitherFunc(ditherFunc)
+	}
+	return nil
+}
+
+// Width returns the maximum width of the print output in pixels.
+func (p *Phomemo) Width() int {
+	return p.rasteriser.LineWidth()
+}
+
+func (p *Phomemo) DPI() float64 {
+	return float64(p.rasteriser.DPI())
+}
+
+// Address returns the address of the connected printer, or an empty string,
+// if the printer is not connected.
+func (p *Phomemo) Address() string {
+	if !p.connected.Load() {
+		return ""
+	}
+	return p.conn.Address()
+}
+
+// PrintInterval returns the interval between the data packets, see
+// [WithPrintInterval].
+func (p *Phomemo) PrintInterval() time.Duration {
+	return p.options.printInterval
+}
+
+// Alerts returns the history of device alerts, oldest first.
+func (p *Phomemo) Alerts() []Alert {
+	return p.alerts.list()
+}
+
+// UnknownNotifications returns the notifications that the driver did not
+// recognise, oldest first.
+func (p *Phomemo) UnknownNotifications() []UnknownNotification {
+	return p.unknown.list()
+}
+
+// Snapshot returns the connection state and the last reported device state.
+func (p *Phomemo) Snapshot() PrinterSnapshot {
+	state := stateIdle
+	if p.printing.Load() {
+		state = statePrinting
+	}
+	snap := PrinterSnapshot{
+		Connected: p.connected.Load(),
+		DryRun:    p.options.dryrun,
+		State:     state.String(),
+	}
+	p.mu.Lock()
+	if p.seen {
+		snap.BatteryLevel = p.status.BatteryLevel
+		snap.NoPaper = p.status.NoPaper
+		snap.LastStatusTime = p.statusAt
+	}
+	p.mu.Unlock()
+	snap.setRoll(p.options.roll)
+	return snap
+}
+
+// Disconnect disconnects from the printer.  It is safe to call concurrently
+// and more than once.
+func (p *Phomemo) Disconnect() error {
+	p.disconnectMu.Lock()
+	defer p.disconnectMu.Unlock()
+
+	if p.options.dryrun || !p.connected.Swap(false) {
+		return nil
+	}
+	if err := p.conn.Notify(func([]byte) {}); err != nil {
+		slog.Warn("failed to disable notifications, never mind, let's continue", "error", err)
+	}
+	if err := p.conn.Disconnect(); err != nil {
+		return fmt.Errorf("failed to disconnect from printer: %w", err)
+	}
+	slog.Info("Disconnected from printer", "address", p.conn.Address())
+	return nil
+}
@@ -0,0 +1,149 @@
+package thermoprint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIsPhomemo(t *testing.T) {
+	for name, want := range map[string]bool{"M02": true, "m02s": true, "T02": true, "GB01": false, "": false} {
+		if got := IsPhomemo(name); got != want {
+			t.Errorf("IsPhomemo(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
+
+func TestPhomemoDensityLevel(t *testing.T) {
+	for energy, want := range map[uint8]byte{0: 1, 1: 1, 2: 2, 4: 3, 6: 4, 9: 4} {
+		if got := phomemoDensityLevel(energy); got != want {
+			t.Errorf("phomemoDensityLevel(%d) = %d, want %d", energy, got, want)
+		}
+	}
+}
+
+func TestPhomemoRasterBlocks(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 16, phomemoMaxLines+1))
+	for y := range img.Bounds().Dy() {
+		for x := range 16 {
+			img.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	img.SetGray(0, 0, color.Gray{}) // first pixel
+	img.SetGray(9, 0, color.Gray{}) // second byte, second pixel
+
+	blocks := phomemoRasterBlocks(img, 16, 128)
+	if len(blocks) != 2 {
+		t.Fatalf("got %d blocks, want 2", len(blocks))
+	}
+	header := []byte{0x1d, 0x76, 0x30, 0x00, 0x02, 0x00, phomemoMaxLines, 0x00}
+	if got := blocks[0][:len(header)]; !bytes.Equal(got, header) {
+		t.Errorf("header = % x, want % x", got, header)
+	}
+	if got, want := blocks[0][len(header):len(header)+2], []byte{0x80, 0x40}; !bytes.Equal(got, want) {
+		t.Errorf("first row = %08b, want %08b", got, want)
+	}
+	if got, want := len(blocks[0]), len(header)+2*phomemoMaxLines; got != want {
+		t.Errorf("first block length = %d, want %d", got, want)
+	}
+	if got, want := blocks[1][:len(header)], []byte{0x1d, 0x76, 0x30, 0x00, 0x02, 0x00, 0x01, 0x00}; !bytes.Equal(got, want) {
+		t.Errorf("second header = % x, want % x", got, want)
+	}
+}
+
+// fakePhomemoConn records the data written to the printer.
+type fakePhomemoConn struct {
+	mu      sync.Mutex
+	written []byte
+	writes  int
+}
+
+func (c *fakePhomemoConn) Address() string { return "AABBCCDDEEFF" }
+
+func (c *fakePhomemoConn) Write(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.written = append(c.written, data...)
+	c.writes++
+	return nil
+}
+
+func (c *fakePhomemoConn) Notify(func([]byte)) error { return nil }
+
+func (c *fakePhomemoConn) Disconnect() error { return nil }
+
+func newTestPhomemo(t *testing.T, conn *fakePhomemoConn) *Phomemo {
+	t.Helper()
+	p, err := NewPhomemo(context.Background(), nil, SearchParameters{Name: "M02"},
+		WithDryRun(true), WithPrintInterval(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.options.dryrun = false
+	p.conn = conn
+	p.connected.Store(true)
+	return p
+}
+
+func TestPhomemo_PrintImage(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 384, 3))
+
+	t.Run("prints", func(t *testing.T) {
+		conn := &fakePhomemoConn{}
+		p := newTestPhomemo(t, conn)
+		if err := p.PrintImage(context.Background(), img); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(conn.written, []byte{0x1b, 0x40, 0x1b, 0x61, 0x01, 0x1f, 0x11, 0x02, 0x02, 0x1d, 0x76, 0x30, 0x00, 48, 0x00, 0x03, 0x00}) {
+			t.Errorf("unexpected job header: % x", conn.written[:min(len(conn.written), 20)])
+		}
+		if !bytes.Contains(conn.written, phomemoEndOfJob) {
+			t.Error("end of job is not sent")
+		}
+		if conn.writes < 2 {
+			t.Errorf("data was sent in %d writes, want chunks", conn.writes)
+		}
+	})
+	t.Run("no paper", func(t *testing.T) {
+		conn := &fakePhomemoConn{}
+		p := newTestPhomemo(t, conn)
+		p.notificationCallback([]byte{0x1a, phomemoNotifyPaper, phomemoPaperOut})
+		if err := p.PrintImage(context.Background(), img); !errors.Is(err, ErrNoPaper) {
+			t.Fatalf("PrintImage error = %v, want %v", err, ErrNoPaper)
+		}
+		if len(conn.written) != 0 {
+			t.Errorf("sent % x, want nothing", conn.written)
+		}
+		if !p.Snapshot().NoPaper {
+			t.Error("snapshot does not report no paper")
+		}
+		if alerts := p.Alerts(); len(alerts) != 1 || alerts[0].Code != AlertNoPaper {
+			t.Errorf("alerts = %v", alerts)
+		}
+	})
+}
+
+func TestPhomemo_notificationCallback(t *testing.T) {
+	p := newTestPhomemo(t, &fakePhomemoConn{})
+	p.notificationCallback([]byte{0x1a, phomemoNotifyBattery, 80})
+	p.notificationCallback([]byte{0x1a, phomemoNotifyCover, phomemoCoverOpen})
+	if got := p.Snapshot().BatteryLevel; got != 80 {
+		t.Errorf("battery level = %d, want 80", got)
+	}
+	if err := p.checkState(); err == nil {
+		t.Error("printing allowed with the cover open")
+	}
+	p.notificationCallback([]byte{0x1a, phomemoNotifyCover, phomemoCoverClosed})
+	if err := p.checkState(); err != nil {
+		t.Errorf("checkState: %v", err)
+	}
+	p.notificationCallback([]byte{0x1a, phomemoNotifyBattery, 5})
+	if alerts := p.Alerts(); len(alerts) != 1 || alerts[0].Code != AlertBatteryCritical {
+		t.Errorf("alerts = %v", alerts)
+	}
+}
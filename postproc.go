@@ -0,0 +1,104 @@
+package thermoprint
+
+import (
+	"fmt"
+	"image"
+	"maps"
+	"slices"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// quietZoneLines is the number of blank lines, that [QuietZone] adds above
+// and below the image, it is 3mm at 203 DPI.
+const quietZoneLines = 24
+
+// PostProcessor transforms the image after it is resized to the printer
+// width and dithered, and before it is sent to the printer, i.e. mirrors it
+// for the transfer paper.  It must keep the width of the image.
+type PostProcessor func(img image.Image) image.Image
+
+// PostProcessors are the built-in post-processors by name, see
+// [WithPostProcessors].
+var PostProcessors = map[string]PostProcessor{
+	"mirror":     Mirror,
+	"flip":       Flip,
+	"quiet-zone": QuietZone,
+}
+
+// PostProcessorNames returns the sorted names of the [PostProcessors].
+func PostProcessorNames() []string {
+	return slices.Sorted(maps.Keys(PostProcessors))
+}
+
+// ParsePostProcessors returns the chain of the [PostProcessors], named in the
+// comma separated list, i.e. "mirror,quiet-zone".
+func ParsePostProcessors(names string) ([]PostProcessor, error) {
+	var chain []PostProcessor
+	for name := range strings.SplitSeq(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		pp, ok := PostProcessors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown post-processor %q, must be one of: %s", name, strings.Join(PostProcessorNames(), ", "))
+		}
+		chain = append(chain, pp)
+	}
+	return chain, nil
+}
+
+// WithPostProcessors sets the chain of the post-processors, that are
+// applied in order to every printed image, including the dry run preview.
+// The raw data is not post-processed.
+func WithPostProcessors(pp ...PostProcessor) Option {
+	return func(o *printOptions) {
+		o.postProcessors = slices.Clone(pp)
+	}
+}
+
+// postProcess applies the post-processors to the image.
+func (o printOptions) postProcess(img image.Image) image.Image {
+	for _, pp := range o.postProcessors {
+		img = pp(img)
+	}
+	return img
+}
+
+// Mirror mirrors the image left to right, so that the printout, i.e. on the
+// iron-on transfer paper, reads correctly when it is transferred.
+func Mirror(img image.Image) image.Image {
+	b := img.Bounds()
+	dst := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := range b.Dy() {
+		for x := range b.Dx() {
+			dst.Set(b.Dx()-1-x, y, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// Flip turns the image upside down, so that the printout reads from the end
+// that comes out of the printer first.
+func Flip(img image.Image) image.Image {
+	b := img.Bounds()
+	dst := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := range b.Dy() {
+		for x := range b.Dx() {
+			dst.Set(b.Dx()-1-x, b.Dy()-1-y, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// QuietZone adds the blank paper above and below the image, i.e. so that
+// the barcode at the edge of the printout can be scanned.
+func QuietZone(img image.Image) image.Image {
+	b := img.Bounds()
+	dst := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()+2*quietZoneLines))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(0, quietZoneLines, b.Dx(), quietZoneLines+b.Dy()), img, b.Min, draw.Src)
+	return dst
+}
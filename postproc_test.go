@@ -0,0 +1,90 @@
+package thermoprint
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// testImage returns the 4x2 image with the black pixel in the top left
+// corner.
+func testImage() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 4, 2))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	img.SetGray(0, 0, color.Gray{})
+	return img
+}
+
+func TestPostProcessors(t *testing.T) {
+	tests := []struct {
+		name      string
+		pp        PostProcessor
+		wantSize  image.Point
+		wantBlack image.Point
+	}{
+		{"mirror", Mirror, image.Pt(4, 2), image.Pt(3, 0)},
+		{"flip", Flip, image.Pt(4, 2), image.Pt(3, 1)},
+		{"quiet zone", QuietZone, image.Pt(4, 2+2*quietZoneLines), image.Pt(0, quietZoneLines)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.pp(testImage())
+			if size := got.Bounds().Size(); size != tt.wantSize {
+				t.Fatalf("size = %v, want %v", size, tt.wantSize)
+			}
+			b := got.Bounds()
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					black := color.GrayModel.Convert(got.At(x, y)).(color.Gray).Y < 0x80
+					if want := image.Pt(x, y) == tt.wantBlack; black != want {
+						t.Errorf("pixel (%d, %d) black = %v, want %v", x, y, black, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParsePostProcessors(t *testing.T) {
+	tests := []struct {
+		names   string
+		want    int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"mirror", 1, false},
+		{"mirror, quiet-zone,flip", 3, false},
+		{"mirror,,", 1, false},
+		{"mirror,upside-down", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.names, func(t *testing.T) {
+			got, err := ParsePostProcessors(tt.names)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePostProcessors() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("ParsePostProcessors() = %d post-processors, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintOptions_postProcess(t *testing.T) {
+	var o printOptions
+	WithPostProcessors(Mirror, QuietZone)(&o)
+	got := o.postProcess(testImage())
+	if got.Bounds().Dy() != 2+2*quietZoneLines {
+		t.Fatalf("height = %d, want the quiet zone", got.Bounds().Dy())
+	}
+	if c := color.GrayModel.Convert(got.At(3, quietZoneLines)).(color.Gray); c.Y != 0 {
+		t.Errorf("image is not mirrored")
+	}
+	// the options are replaced, not appended.
+	WithPostProcessors()(&o)
+	if got := o.postProcess(testImage()); got.Bounds().Dy() != 2 {
+		t.Errorf("height = %d, want the image unchanged", got.Bounds().Dy())
+	}
+}
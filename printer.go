@@ -0,0 +1,83 @@
+package thermoprint
+
+import (
+	"context"
+	"image"
+
+	"golang.org/x/image/font"
+
+	"github.com/rusq/thermoprint/bitmap"
+)
+
+// Printer is a thermal printer, it is implemented by all printer drivers.
+type Printer interface {
+	// PrintImage resizes, dithers and prints the image.
+	PrintImage(ctx context.Context, img image.Image) error
+	// PrintTextTTF renders the text with the font face and the options, i.e.
+	// the wrapping, and prints it.
+	PrintTextTTF(ctx context.Context, text string, face font.Face, opts ...bitmap.TextOption) error
+	// PrintPattern prints the test pattern by name.
+	PrintPattern(ctx context.Context, pattern string) error
+	// PrintGenerativePattern prints one of the [GenerativePatterns].
+	PrintGenerativePattern(ctx context.Context, pattern string, seed uint64) error
+	// SetOptions sets the print options.
+	SetOptions(opts ...Option) error
+	// Width returns the width of the print output in pixels.
+	Width() int
+	// DPI returns the resolution of the printer.
+	DPI() float64
+	// Address returns the address of the connected printer.
+	Address() string
+	// Snapshot returns the observable state of the printer.
+	Snapshot() PrinterSnapshot
+	// Alerts returns the history of device alerts, oldest first.
+	Alerts() []Alert
+	// Disconnect disconnects from the printer.
+	Disconnect() error
+}
+
+// RawPrinter is implemented by the drivers, that print the pre-built packets,
+// i.e. captured from the vendor app.
+type RawPrinter interface {
+	// SplitRAW validates the raw data and splits it into the packets for
+	// PrintRAW, the error wraps [ErrInvalidRAW].
+	SplitRAW(data []byte) ([][]byte, error)
+	// PrintRAW sends the packets to the printer as they are.
+	PrintRAW(ctx context.Context, data [][]byte) error
+}
+
+var (
+	_ RawPrinter = (*LXD02)(nil)
+	_ RawPrinter = (*VirtualPrinter)(nil)
+)
+
+var (
+	_ Printer = (*LXD02)(nil)
+	_ Printer = (*CatPrinter)(nil)
+	_ Printer = (*Phomemo)(nil)
+)
+
+// NewPrinter connects to the printer, selecting the driver by the printer
+// name in the search parameters: [CatPrinter] for the [CatPrinterModels],
+// [Phomemo] for the [PhomemoModels], and [LXD02] otherwise.
+func NewPrinter(ctx context.Context, adapter *Adapter, sp SearchParameters, opt ...Option) (Printer, error) {
+	if IsCatPrinter(sp.Name) {
+		p, err := NewCatPrinter(ctx, adapter, sp, opt...)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+	if IsPhomemo(sp.Name) {
+		p, err := NewPhomemo(ctx, adapter, sp, opt...)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+	p, err := NewLXD02(ctx, adapter, sp, opt...)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,297 @@
+package printers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rusq/thermoprint"
+)
+
+// ModelMock is the model name of the [Mock] printer.
+const ModelMock = "mock"
+
+// MockOptions configure the behaviour of the [Mock] printer.  The zero
+// value is the printer that prints every job without errors.
+type MockOptions struct {
+	// ResponseDelay is the delay before every notification is delivered.
+	ResponseDelay time.Duration
+	// PrintTime is the time the printer takes to finish the job after
+	// receiving the last packet.
+	PrintTime time.Duration
+	// BatteryLevel is the reported battery level, percent.  Default is
+	// 100.
+	BatteryLevel uint8
+	// Retransmit lists the packets that the printer requests to
+	// retransmit, once per job.
+	Retransmit []int
+	// HoldAfter is the number of packets after which the printer holds
+	// the job for HoldFor.  Zero disables the hold.
+	HoldAfter int
+	HoldFor   time.Duration
+	// NoPaperAfter is the number of packets of the first job after which
+	// the printer runs out of paper.  Zero disables it, use
+	// [Mock.SetNoPaper] to load the paper, or to start without paper.
+	NoPaperAfter int
+}
+
+// Mock is the in-memory LX-D02 printer.  It runs the real [thermoprint.LXD02]
+// driver against the emulated device, that acknowledges the commands,
+// records the raster packets and sends the notifications as configured by
+// [MockOptions].  It is meant for the tests of the code that uses the
+// printer, i.e. the print server, without hardware.
+type Mock struct {
+	*thermoprint.LXD02
+	dev *mockDevice
+}
+
+// NewMock returns the connected mock printer.  The options are passed to
+// the driver, i.e. use [thermoprint.WithPrintInterval] to speed up the
+// tests.
+func NewMock(ctx context.Context, mo MockOptions, opt ...thermoprint.Option) (*Mock, error) {
+	dev := newMockDevice(mo)
+	prn, err := thermoprint.NewLXD02(ctx, nil, thermoprint.SearchParameters{Name: ModelMock}, append(slices.Clip(opt), thermoprint.WithTransport(dev))...)
+	if err != nil {
+		dev.Disconnect()
+		return nil, err
+	}
+	return &Mock{LXD02: prn, dev: dev}, nil
+}
+
+// Jobs returns the raster packets of the completed jobs, oldest first.  The
+// packets of each job are in the packet order, the retransmitted packets
+// are recorded once.
+func (m *Mock) Jobs() [][][]byte {
+	m.dev.mu.Lock()
+	defer m.dev.mu.Unlock()
+	jobs := make([][][]byte, len(m.dev.jobs))
+	for i, job := range m.dev.jobs {
+		jobs[i] = slices.Clone(job)
+	}
+	return jobs
+}
+
+// SetNoPaper sets the paper state and sends the status to the driver.
+func (m *Mock) SetNoPaper(noPaper bool) {
+	m.dev.mu.Lock()
+	defer m.dev.mu.Unlock()
+	m.dev.noPaper = noPaper
+	m.dev.sendStatus()
+}
+
+// SetBatteryLevel sets the battery level and sends the status to the
+// driver.
+func (m *Mock) SetBatteryLevel(level uint8) {
+	m.dev.mu.Lock()
+	defer m.dev.mu.Unlock()
+	m.dev.battery = level
+	m.dev.sendStatus()
+}
+
+// LX-D02 protocol.
+const (
+	mockRasterPrefix = 0x55
+	mockCmdPrefix    = 0x5a
+
+	mockCmdStatus     = 0x02
+	mockCmdPrint      = 0x04
+	mockCmdRetransmit = 0x05
+	mockCmdFinished   = 0x06
+	mockCmdHold       = 0x08
+)
+
+// mockAddress is the address of the mock printer.
+const mockAddress = "mock"
+
+// errMockDisconnected is returned by the mock device after disconnect.
+var errMockDisconnected = errors.New("mock printer is disconnected")
+
+// mockDevice emulates the LX-D02 firmware, it implements the
+// [thermoprint.Transport].  Notifications are delivered in order by a
+// separate goroutine, like a Bluetooth stack does.
+type mockDevice struct {
+	opts MockOptions
+	out  chan []byte   // notifications to deliver
+	done chan struct{} // closed on disconnect
+
+	mu       sync.Mutex
+	notify   func([]byte)
+	closed   bool
+	noPaper  bool
+	battery  uint8
+	total    int            // number of packets in the current job
+	received int            // number of packets received in the current job
+	packets  map[int][]byte // packets of the current job by index
+	resent   map[int]bool   // packets requested to retransmit
+	held     bool
+	ranOut   bool // printer ran out of paper after NoPaperAfter packets
+	jobs     [][][]byte
+}
+
+func newMockDevice(mo MockOptions) *mockDevice {
+	d := &mockDevice{
+		opts:    mo,
+		out:     make(chan []byte, 64),
+		done:    make(chan struct{}),
+		battery: 100,
+	}
+	if mo.BatteryLevel != 0 {
+		d.battery = mo.BatteryLevel
+	}
+	go d.deliver()
+	return d
+}
+
+func (d *mockDevice) deliver() {
+	for {
+		select {
+		case <-d.done:
+			return
+		case data := <-d.out:
+			if d.opts.ResponseDelay > 0 {
+				time.Sleep(d.opts.ResponseDelay)
+			}
+			d.mu.Lock()
+			fn := d.notify
+			d.mu.Unlock()
+			if fn != nil {
+				fn(data)
+			}
+		}
+	}
+}
+
+// send queues the notification, d.mu must be held.
+func (d *mockDevice) send(data ...byte) {
+	if d.closed {
+		return
+	}
+	select {
+	case d.out <- data:
+	case <-d.done:
+	}
+}
+
+// sendStatus sends the status notification, d.mu must be held.
+func (d *mockDevice) sendStatus() {
+	var noPaper byte
+	if d.noPaper {
+		noPaper = 1
+	}
+	d.send(mockCmdPrefix, mockCmdStatus, d.battery, noPaper, 0, 0)
+}
+
+func (d *mockDevice) Address() string { return mockAddress }
+
+func (d *mockDevice) Notify(fn func([]byte)) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return errMockDisconnected
+	}
+	d.notify = fn
+	d.sendStatus() // the printer reports the status on connect
+	return nil
+}
+
+func (d *mockDevice) Disconnect() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.closed {
+		d.closed = true
+		close(d.done)
+	}
+	return nil
+}
+
+func (d *mockDevice) Write(data []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return errMockDisconnected
+	}
+	if len(data) < 3 {
+		return fmt.Errorf("mock: packet is too short: % x", data)
+	}
+	switch data[0] {
+	case mockRasterPrefix:
+		d.raster(int(data[1])<<8|int(data[2]), data)
+	case mockCmdPrefix:
+		d.command(data)
+	default:
+		return fmt.Errorf("mock: unknown packet: % x", data)
+	}
+	return nil
+}
+
+// command acknowledges the command, d.mu must be held.
+func (d *mockDevice) command(data []byte) {
+	switch data[1] {
+	case mockCmdStatus:
+		d.sendStatus()
+		return
+	case mockCmdPrint:
+		if len(data) < 5 {
+			break
+		}
+		if data[4] == 0 { // start of the job
+			d.total = int(data[2])<<8 | int(data[3])
+			d.received = 0
+			d.packets = make(map[int][]byte, d.total)
+			d.resent = make(map[int]bool)
+			d.held = false
+			if d.noPaper {
+				d.sendStatus()
+			}
+		} else { // end of the job
+			job := make([][]byte, 0, len(d.packets))
+			for i := range d.total {
+				if p, ok := d.packets[i]; ok {
+					job = append(job, p)
+				}
+			}
+			d.jobs = append(d.jobs, job)
+		}
+	}
+	d.send(bytes.Clone(data)...)
+}
+
+// raster records the raster packet and sends the notifications that the
+// options ask for, d.mu must be held.
+func (d *mockDevice) raster(idx int, data []byte) {
+	if d.packets == nil || d.noPaper {
+		return // not printing
+	}
+	d.packets[idx] = bytes.Clone(data)
+	d.received++
+	if d.opts.NoPaperAfter > 0 && d.received == d.opts.NoPaperAfter && !d.ranOut {
+		d.ranOut = true
+		d.noPaper = true
+		d.sendStatus()
+		return
+	}
+	if slices.Contains(d.opts.Retransmit, idx) && !d.resent[idx] {
+		d.resent[idx] = true
+		d.send(mockCmdPrefix, mockCmdRetransmit, byte(idx>>8), byte(idx))
+		return
+	}
+	delay := d.opts.PrintTime
+	if d.opts.HoldAfter > 0 && d.received == d.opts.HoldAfter && !d.held {
+		d.held = true
+		d.send(mockCmdPrefix, mockCmdHold)
+	}
+	if d.held {
+		delay += d.opts.HoldFor
+	}
+	if idx == d.total-1 {
+		time.AfterFunc(delay, func() {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			d.send(mockCmdPrefix, mockCmdFinished)
+		})
+	}
+}
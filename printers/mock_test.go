@@ -0,0 +1,179 @@
+package printers
+
+import (
+	"context"
+	"errors"
+	"image"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rusq/thermoprint"
+)
+
+func newTestMock(t *testing.T, mo MockOptions) *Mock {
+	t.Helper()
+	m, err := NewMock(t.Context(), mo, thermoprint.WithPrintInterval(time.Millisecond), thermoprint.WithResponseTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Disconnect() })
+	return m
+}
+
+func TestMock(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 384, 40))
+
+	t.Run("prints", func(t *testing.T) {
+		m := newTestMock(t, MockOptions{BatteryLevel: 80})
+		if err := m.PrintImage(t.Context(), img); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.PrintImage(t.Context(), img); err != nil {
+			t.Fatal(err)
+		}
+		jobs := m.Jobs()
+		if len(jobs) != 2 {
+			t.Fatalf("got %d jobs, want 2", len(jobs))
+		}
+		for i, p := range jobs[0] {
+			if p[0] != mockRasterPrefix || int(p[1])<<8|int(p[2]) != i {
+				t.Errorf("packet %d header = % x", i, p[:3])
+			}
+		}
+		if got := m.Snapshot().BatteryLevel; got != 80 {
+			t.Errorf("battery level = %d, want 80", got)
+		}
+	})
+	t.Run("retransmit and hold", func(t *testing.T) {
+		m := newTestMock(t, MockOptions{Retransmit: []int{1}, HoldAfter: 2, HoldFor: 20 * time.Millisecond})
+		if err := m.PrintImage(t.Context(), img); err != nil {
+			t.Fatal(err)
+		}
+		if jobs := m.Jobs(); len(jobs) != 1 || len(jobs[0]) == 0 {
+			t.Errorf("jobs = %d, want 1 job with packets", len(jobs))
+		}
+	})
+	t.Run("no paper", func(t *testing.T) {
+		m := newTestMock(t, MockOptions{NoPaperAfter: 1})
+		if err := m.PrintImage(t.Context(), img); !errors.Is(err, thermoprint.ErrNoPaper) {
+			t.Fatalf("PrintImage error = %v, want %v", err, thermoprint.ErrNoPaper)
+		}
+		if len(m.Jobs()) != 0 {
+			t.Error("failed job is recorded")
+		}
+		m.SetNoPaper(false)
+		if err := m.PrintImage(t.Context(), img); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("starts without paper", func(t *testing.T) {
+		m := newTestMock(t, MockOptions{})
+		m.SetNoPaper(true)
+		if err := m.PrintImage(t.Context(), img); !errors.Is(err, thermoprint.ErrNoPaper) {
+			t.Fatalf("PrintImage error = %v, want %v", err, thermoprint.ErrNoPaper)
+		}
+	})
+}
+
+func TestMock_progress(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 384, 40))
+	m := newTestMock(t, MockOptions{Retransmit: []int{3}})
+
+	var (
+		mu    sync.Mutex
+		calls [][2]int
+	)
+	if err := m.SetOptions(thermoprint.WithProgress(func(sent, total int) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, [2]int{sent, total})
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.PrintImage(t.Context(), img); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) == 0 {
+		t.Fatal("progress is not reported")
+	}
+	total := len(m.Jobs()[0])
+	if last := calls[len(calls)-1]; last != [2]int{total, total} {
+		t.Errorf("last progress = %v, want [%d %d]", last, total, total)
+	}
+	for _, c := range calls {
+		if c[1] != total || c[0] < 1 || c[0] > total {
+			t.Fatalf("progress = %v, want sent in [1, %d] of %[2]d", c, total)
+		}
+	}
+}
+
+func TestMock_cancel(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 384, 200))
+
+	cancelAfter := func(t *testing.T, m *Mock, cancel func()) {
+		t.Helper()
+		if err := m.SetOptions(thermoprint.WithProgress(func(sent, total int) {
+			if sent == 10 {
+				go cancel()
+			}
+		})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(t *testing.T, m *Mock, err, want error) {
+		t.Helper()
+		if !errors.Is(err, want) {
+			t.Fatalf("PrintImage error = %v, want %v", err, want)
+		}
+		jobs := m.Jobs()
+		if len(jobs) != 1 {
+			t.Fatalf("got %d jobs, want the cancelled job ended", len(jobs))
+		}
+		if n := len(jobs[0]); n == 0 || n >= img.Bounds().Dy() {
+			t.Errorf("cancelled job has %d packets", n)
+		}
+		// the printer is idle and prints the next job
+		if err := m.SetOptions(thermoprint.WithProgress(nil)); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.PrintImage(t.Context(), img); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Cancel", func(t *testing.T) {
+		m := newTestMock(t, MockOptions{})
+		cancelAfter(t, m, func() { m.Cancel() })
+		err := m.PrintImage(t.Context(), img)
+		check(t, m, err, thermoprint.ErrCancelled)
+	})
+	t.Run("context", func(t *testing.T) {
+		m := newTestMock(t, MockOptions{})
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		cancelAfter(t, m, cancel)
+		err := m.PrintImage(ctx, img)
+		check(t, m, err, context.Canceled)
+	})
+	t.Run("idle", func(t *testing.T) {
+		m := newTestMock(t, MockOptions{})
+		if err := m.Cancel(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestNew_mock(t *testing.T) {
+	prn, err := New(t.Context(), ModelMock, nil, thermoprint.SearchParameters{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer prn.Disconnect()
+	if _, ok := prn.(*Mock); !ok {
+		t.Errorf("New returned %T, want *Mock", prn)
+	}
+}
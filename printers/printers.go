@@ -0,0 +1,119 @@
+// Package printers is the registry of the printer drivers.  Drivers are
+// registered by the model name, and the printer is created by
+// [New] for the model selected by the user, i.e.:
+//
+//	prn, err := printers.New(ctx, "lx-d02", adapter, sp, opts...)
+package printers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/rusq/thermoprint"
+)
+
+// Factory connects to the printer and returns the driver.
+type Factory func(ctx context.Context, adapter *thermoprint.Adapter, sp thermoprint.SearchParameters, opt ...thermoprint.Option) (thermoprint.Printer, error)
+
+// ModelAuto selects the driver by the name of the printer in the search
+// parameters, see [thermoprint.NewPrinter].
+const ModelAuto = "auto"
+
+// ErrUnknownModel is returned by [New] for the model that is not registered.
+var ErrUnknownModel = errors.New("unknown printer model")
+
+var (
+	mu        sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+func init() {
+	Register(thermoprint.ModelLXD02, func(ctx context.Context, adapter *thermoprint.Adapter, sp thermoprint.SearchParameters, opt ...thermoprint.Option) (thermoprint.Printer, error) {
+		return printer(thermoprint.NewLXD02(ctx, adapter, sp, opt...))
+	})
+	Register(thermoprint.ModelCat, func(ctx context.Context, adapter *thermoprint.Adapter, sp thermoprint.SearchParameters, opt ...thermoprint.Option) (thermoprint.Printer, error) {
+		return printer(thermoprint.NewCatPrinter(ctx, adapter, sp, opt...))
+	})
+	Register(thermoprint.ModelPhomemo, func(ctx context.Context, adapter *thermoprint.Adapter, sp thermoprint.SearchParameters, opt ...thermoprint.Option) (thermoprint.Printer, error) {
+		return printer(thermoprint.NewPhomemo(ctx, adapter, sp, opt...))
+	})
+	Register(ModelMock, func(ctx context.Context, _ *thermoprint.Adapter, _ thermoprint.SearchParameters, opt ...thermoprint.Option) (thermoprint.Printer, error) {
+		return printer(NewMock(ctx, MockOptions{}, opt...))
+	})
+}
+
+// printer converts the driver to the [thermoprint.Printer], so that the nil
+// driver does not become the non-nil interface.
+func printer[P thermoprint.Printer](p P, err error) (thermoprint.Printer, error) {
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Register registers the driver factory for the model.  Model names are
+// case insensitive.  It panics if the model is already registered, or if
+// the factory is nil.
+func Register(model string, f Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	model = strings.ToLower(model)
+	if f == nil {
+		panic("printers: Register factory is nil")
+	}
+	if model == "" || model == ModelAuto {
+		panic(fmt.Sprintf("printers: invalid model name %q", model))
+	}
+	if _, dup := factories[model]; dup {
+		panic("printers: Register called twice for model " + model)
+	}
+	factories[model] = f
+}
+
+// Models returns the sorted list of the registered models.
+func Models() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	models := make([]string, 0, len(factories))
+	for m := range factories {
+		models = append(models, m)
+	}
+	slices.Sort(models)
+	return models
+}
+
+// Lookup returns the factory for the model.
+func Lookup(model string) (Factory, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	f, ok := factories[strings.ToLower(model)]
+	return f, ok
+}
+
+// New connects to the printer of the model.  If model is empty or
+// [ModelAuto], the driver is selected by the printer name, see
+// [thermoprint.NewPrinter].
+func New(ctx context.Context, model string, adapter *thermoprint.Adapter, sp thermoprint.SearchParameters, opt ...thermoprint.Option) (thermoprint.Printer, error) {
+	if model == "" || strings.EqualFold(model, ModelAuto) {
+		return thermoprint.NewPrinter(ctx, adapter, sp, opt...)
+	}
+	f, ok := Lookup(model)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q, supported: %s", ErrUnknownModel, model, strings.Join(Models(), ", "))
+	}
+	return f(ctx, adapter, sp, opt...)
+}
+
+// WithTCPTransport makes the driver print to the network printer at addr
+// over the raw TCP port 9100 (AppSocket/JetDirect) instead of Bluetooth, see
+// [thermoprint.DialTCP].  The printer model can't be detected over TCP, so
+// it should be given to [New].
+func WithTCPTransport(addr string) thermoprint.Option {
+	return thermoprint.WithDialer(func(ctx context.Context) (thermoprint.Transport, error) {
+		return thermoprint.DialTCP(ctx, addr)
+	})
+}
@@ -0,0 +1,99 @@
+package printers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/rusq/thermoprint"
+)
+
+func TestModels(t *testing.T) {
+	got := Models()
+	for _, want := range []string{"cat", "lx-d02", "phomemo"} {
+		if !slices.Contains(got, want) {
+			t.Errorf("Models() = %v, missing %q", got, want)
+		}
+	}
+	if !slices.IsSorted(got) {
+		t.Errorf("Models() = %v, not sorted", got)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	var called bool
+	Register("Test-Model", func(context.Context, *thermoprint.Adapter, thermoprint.SearchParameters, ...thermoprint.Option) (thermoprint.Printer, error) {
+		called = true
+		return nil, errors.New("test")
+	})
+	t.Cleanup(func() {
+		mu.Lock()
+		delete(factories, "test-model")
+		mu.Unlock()
+	})
+	if _, err := New(context.Background(), "TEST-MODEL", nil, thermoprint.SearchParameters{}); err == nil || !called {
+		t.Errorf("New did not call the factory: %v", err)
+	}
+
+	for _, model := range []string{"test-model", "", ModelAuto} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%q) did not panic", model)
+				}
+			}()
+			Register(model, func(context.Context, *thermoprint.Adapter, thermoprint.SearchParameters, ...thermoprint.Option) (thermoprint.Printer, error) {
+				return nil, nil
+			})
+		}()
+	}
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	if _, err := New(ctx, "nonexistent", nil, thermoprint.SearchParameters{}); !errors.Is(err, ErrUnknownModel) {
+		t.Errorf("New error = %v, want %v", err, ErrUnknownModel)
+	}
+	tests := []struct {
+		model string
+		name  string
+		check func(thermoprint.Printer) bool
+	}{
+		{"phomemo", "LX-D02", func(p thermoprint.Printer) bool { _, ok := p.(*thermoprint.Phomemo); return ok }},
+		{"LX-D02", "GB01", func(p thermoprint.Printer) bool { _, ok := p.(*thermoprint.LXD02); return ok }},
+		{"", "GB01", func(p thermoprint.Printer) bool { _, ok := p.(*thermoprint.CatPrinter); return ok }},
+		{ModelAuto, "M02", func(p thermoprint.Printer) bool { _, ok := p.(*thermoprint.Phomemo); return ok }},
+	}
+	for _, tt := range tests {
+		p, err := New(ctx, tt.model, nil, thermoprint.SearchParameters{Name: tt.name}, thermoprint.WithDryRun(true))
+		if err != nil {
+			t.Fatalf("New(%q): %v", tt.model, err)
+		}
+		if !tt.check(p) {
+			t.Errorf("New(%q) with name %q returned %T", tt.model, tt.name, p)
+		}
+	}
+}
+
+func TestWithTCPTransport(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		if conn, err := l.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+	prn, err := New(t.Context(), "cat", nil, thermoprint.SearchParameters{}, WithTCPTransport(l.Addr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer prn.Disconnect()
+	if got := prn.Address(); got != l.Addr().String() {
+		t.Errorf("Address = %q, want %q", got, l.Addr())
+	}
+}
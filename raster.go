@@ -1,9 +1,12 @@
 package thermoprint
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"image"
 	"log/slog"
+	"slices"
 
 	"github.com/rusq/thermoprint/bitmap"
 )
@@ -25,6 +28,8 @@ type Rasteriser interface {
 	// Enumerate prepares the raw data for printing running the packet func
 	// for each byte slice and returning the data ready to be sent to printer.
 	Enumerate(data [][]byte) ([][]byte, error)
+	// Split should split the raw data into the byte slices for Enumerate.
+	Split(data []byte) ([][]byte, error)
 	// DPI should return the DPI of the rasteriser.
 	DPI() int
 	// LineWidth should return the line width in pixels, i.e. for 203 dpi
@@ -57,10 +62,10 @@ func (r *GenericRasteriser) ResizeAndDither(src image.Image, gamma float64, auto
 	}
 
 	resized := bitmap.ResizeToFit(src, r.Width)
-	if autoDither && bitmap.IsDocument(resized, 50, 200) {
-		slog.Info("Image is a document, skipping dithering", "autodither", autoDither, "width", r.Width, "height", resized.Bounds().Dy())
-		// If the image is not a document, apply dithering
-		return resized
+	if autoDither {
+		// the text-like regions are binarised, only the photos are dithered.
+		slog.Debug("dithering the photo regions", "autodither", autoDither, "width", r.Width, "height", resized.Bounds().Dy())
+		return bitmap.RegionDither(dfn, r.Threshold)(resized, gamma)
 	}
 	return dfn(resized, gamma)
 }
@@ -159,3 +164,47 @@ func (r *GenericRasteriser) Enumerate(data [][]byte) ([][]byte, error) {
 	}
 	return ret, nil
 }
+
+// ErrInvalidRAW is returned by [GenericRasteriser.Split], if the raw data are
+// neither the printer packets, nor the raster lines, that fill them.
+var ErrInvalidRAW = errors.New("invalid raw data")
+
+// Split splits the raw data into the byte slices for Enumerate.  The data are
+// either the packets, as they are sent to the printer, i.e. captured from the
+// vendor app, then the prefixes and the terminators are validated and
+// stripped, or the raster lines, LinesPerPacket lines per packet.
+func (r *GenericRasteriser) Split(data []byte) ([][]byte, error) {
+	var (
+		msgPrefixSz     = len(r.PrefixFunc(0)) // 55 m n
+		msgTerminatorSz = 1                    // 00
+
+		msgDataSz    = r.Width / 8 * r.LinesPerPacket
+		msgPayloadSz = msgPrefixSz + msgDataSz + msgTerminatorSz // 55 m n + data + 00
+	)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: no data", ErrInvalidRAW)
+	}
+	if packets, ok := r.unframe(data, msgPrefixSz, msgPayloadSz); ok {
+		return packets, nil
+	}
+	if len(data)%msgDataSz != 0 {
+		return nil, fmt.Errorf("%w: size %d is not a multiple of the packet data size %d, nor of the packet size %d", ErrInvalidRAW, len(data), msgDataSz, msgPayloadSz)
+	}
+	return slices.Collect(slices.Chunk(data, msgDataSz)), nil
+}
+
+// unframe strips the prefixes and the terminators of the packets, it returns
+// false, if any of them does not match, i.e. the data are the raster lines.
+func (r *GenericRasteriser) unframe(data []byte, prefixSz, payloadSz int) ([][]byte, bool) {
+	if len(data)%payloadSz != 0 {
+		return nil, false
+	}
+	packets := make([][]byte, 0, len(data)/payloadSz)
+	for pkt := range slices.Chunk(data, payloadSz) {
+		if !bytes.HasPrefix(pkt, r.PrefixFunc(len(packets))) || pkt[payloadSz-1] != r.Terminator {
+			return nil, false
+		}
+		packets = append(packets, pkt[prefixSz:payloadSz-1])
+	}
+	return packets, true
+}
@@ -2,6 +2,7 @@ package thermoprint
 
 import (
 	"bytes"
+	"errors"
 	"image"
 	"image/color"
 	"reflect"
@@ -130,6 +131,42 @@ func TestGenericRasteriserSerialiseRightSidePadding(t *testing.T) {
 	}
 }
 
+func TestGenericRasteriserSplit(t *testing.T) {
+	r := testRasteriser(16, 1)
+	tests := []struct {
+		name    string
+		data    []byte
+		want    [][]byte
+		wantErr bool
+	}{
+		{name: "lines", data: []byte{1, 2, 3, 4}, want: [][]byte{{1, 2}, {3, 4}}},
+		{name: "packets", data: []byte{0, 1, 2, 0, 1, 3, 4, 0}, want: [][]byte{{1, 2}, {3, 4}}},
+		{name: "packets out of order", data: []byte{1, 1, 2, 0, 0, 3, 4, 0}, want: [][]byte{{1, 1}, {2, 0}, {0, 3}, {4, 0}}},
+		{name: "short", data: []byte{1, 2, 3}, wantErr: true},
+		{name: "empty", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Split(tt.data)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRAW) {
+					t.Fatalf("Split error = %v, want %v", err, ErrInvalidRAW)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Split returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Split = [% x], want [% x]", got, tt.want)
+			}
+			if _, err := r.Enumerate(got); err != nil {
+				t.Fatalf("Enumerate returned error: %v", err)
+			}
+		})
+	}
+}
+
 func testRasteriser(width, linesPerPacket int) *GenericRasteriser {
 	return &GenericRasteriser{
 		Width:          width,
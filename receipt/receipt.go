@@ -0,0 +1,124 @@
+// Package receipt expands the document templates, i.e. receipts, invoices
+// or labels, with the data, so that the same layout is printed for every
+// order.  The template is the Go text/template, that produces the document
+// script, see [bitmap.Document].
+package receipt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/rusq/thermoprint/locale"
+)
+
+// Funcs are the functions available in the templates, in addition to the
+// text/template built-ins.
+var Funcs = template.FuncMap{
+	"add":   add,
+	"sub":   sub,
+	"mul":   mul,
+	"money": money,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"tr":    locale.T,
+}
+
+// Template is the document template.
+type Template struct {
+	t *template.Template
+}
+
+// Parse parses the template text, the name is used in the error messages.
+// The missing keys of the data are reported as the error on execution.
+func Parse(name, text string) (*Template, error) {
+	t, err := template.New(name).Funcs(Funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{t: t}, nil
+}
+
+// Execute expands the template with the data and returns the document
+// script.
+func (t *Template) Execute(data any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.t.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// LoadData decodes the JSON data of the template.
+func LoadData(r io.Reader) (any, error) {
+	var data any
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return nil, fmt.Errorf("decode data: %w", err)
+	}
+	return data, nil
+}
+
+// number converts the JSON number, the integer or the numeric string to
+// float64.
+func number(v any) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case int:
+		return float64(n), nil
+	case json.Number:
+		return n.Float64()
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		if err != nil {
+			return 0, fmt.Errorf("not a number: %q", n)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("not a number: %v", v)
+	}
+}
+
+// fold applies fn to the numbers left to right.
+func fold(fn func(a, b float64) float64, first any, rest ...any) (float64, error) {
+	acc, err := number(first)
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range rest {
+		n, err := number(v)
+		if err != nil {
+			return 0, err
+		}
+		acc = fn(acc, n)
+	}
+	return acc, nil
+}
+
+// add returns the sum of the numbers.
+func add(first any, rest ...any) (float64, error) {
+	return fold(func(a, b float64) float64 { return a + b }, first, rest...)
+}
+
+// sub subtracts the rest of the numbers from the first one.
+func sub(first any, rest ...any) (float64, error) {
+	return fold(func(a, b float64) float64 { return a - b }, first, rest...)
+}
+
+// mul returns the product of the numbers.
+func mul(first any, rest ...any) (float64, error) {
+	return fold(func(a, b float64) float64 { return a * b }, first, rest...)
+}
+
+// money formats the number with two decimals.
+func money(v any) (string, error) {
+	n, err := number(v)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatFloat(n, 'f', 2, 64), nil
+}
@@ -0,0 +1,79 @@
+package receipt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	const data = `{
+		"number": "A-42",
+		"shop": "Corner cafe",
+		"items": [
+			{"name": "Flat white", "qty": 2, "price": 3.5},
+			{"name": "Croissant", "qty": 1, "price": "4.25"}
+		]
+	}`
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{name: "field", text: "Invoice {{.number}}", want: "Invoice A-42"},
+		{name: "upper", text: "{{upper .shop}}", want: "CORNER CAFE"},
+		{name: "lower", text: "{{lower .shop}}", want: "corner cafe"},
+		{
+			name: "range",
+			text: "{{range .items}}{{.name}} | {{money (mul .qty .price)}}\n{{end}}",
+			want: "Flat white | 7.00\nCroissant | 4.25\n",
+		},
+		{
+			name: "total",
+			text: "{{$t := 0.0}}{{range .items}}{{$t = add $t (mul .qty .price)}}{{end}}{{money $t}}",
+			want: "11.25",
+		},
+		{name: "sub", text: "{{sub 10 2.5 1}}", want: "6.5"},
+		{name: "tr", text: "{{tr \"Total\"}}", want: "Total"},
+		{name: "missing key", text: "{{.total}}", wantErr: true},
+		{name: "not a number", text: "{{money .shop}}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := LoadData(strings.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tmpl, err := Parse(tt.name, tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tmpl.Execute(d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && string(got) != tt.want {
+				t.Errorf("Execute() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	if _, err := Parse("bad", "{{.number"); err == nil {
+		t.Error("Parse() expected an error for the unterminated action")
+	}
+}
+
+func TestLoadData(t *testing.T) {
+	if _, err := LoadData(strings.NewReader(`{"number": `)); err == nil {
+		t.Error("LoadData() expected an error for the invalid JSON")
+	}
+	d, err := LoadData(strings.NewReader(`[1, 2]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := d.([]any); !ok {
+		t.Errorf("LoadData() = %T, want the array", d)
+	}
+}
@@ -0,0 +1,123 @@
+package thermoprint
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rusq/thermoprint/internal/ble"
+)
+
+// DefaultScanDuration is the scan duration that is enough for most printers
+// to advertise.
+const DefaultScanDuration = 5 * time.Second
+
+// ErrScanNotSupported is returned by [Scan] if the Bluetooth backend can't
+// scan for devices.
+var ErrScanNotSupported = errors.New("scanning is not supported by the Bluetooth backend")
+
+// Candidate is the printer found by [Scan].
+type Candidate struct {
+	Name string
+	// MACAddress is the MAC address of the printer, or the CoreBluetooth
+	// UUID on macOS.
+	MACAddress string
+	// RSSI is the signal strength, dBm.
+	RSSI int
+	// Model is the driver model guessed by the name and the advertised
+	// services, see [GuessModel].  It is empty for unknown devices.
+	Model string
+}
+
+// SearchParameters returns the search parameters to connect to the
+// candidate.
+func (c Candidate) SearchParameters() SearchParameters {
+	return SearchParameters{Name: c.Name, MACAddress: c.MACAddress}
+}
+
+// Scan scans for the printers for the duration d and returns all devices
+// that match the search parameters, strongest signal first, so that the
+// caller can present the choice instead of connecting to the first match.
+// If sp is empty, all devices that advertise a name are returned.  The
+// backend is selected with [WithBackend], other options are ignored; only
+// the [BackendTinyGo] can scan, and the adapter must be enabled.
+func Scan(ctx context.Context, adapter *Adapter, sp SearchParameters, d time.Duration, opt ...Option) ([]Candidate, error) {
+	var opts printOptions
+	for _, o := range opt {
+		o(&opts)
+	}
+	var t ble.Target
+	if sp.Name != "" || sp.MACAddress != "" {
+		var err error
+		if t, err = sp.target(gattProfile{}); err != nil {
+			return nil, err
+		}
+	}
+	backend, err := newBackend(opts.backend, adapter)
+	if err != nil {
+		return nil, err
+	}
+	scanner, ok := backend.(ble.Scanner)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrScanNotSupported, opts.backend)
+	}
+	found, err := scanner.Scan(ctx, t, cmp.Or(d, DefaultScanDuration))
+	candidates := make([]Candidate, len(found))
+	for i, c := range found {
+		candidates[i] = Candidate{Name: c.Name, MACAddress: c.Address, RSSI: int(c.RSSI), Model: GuessModel(c.Name, c.Services)}
+	}
+	return candidates, err
+}
+
+// Discover scans for the printers for the duration d, and returns the
+// devices that look like the supported printers, strongest signal first.
+// Unlike [Scan], the devices that are not recognised by [GuessModel] are
+// left out.
+func Discover(ctx context.Context, adapter *Adapter, d time.Duration, opt ...Option) ([]Candidate, error) {
+	found, err := Scan(ctx, adapter, SearchParameters{}, d, opt...)
+	printers := slices.DeleteFunc(found, func(c Candidate) bool { return c.Model == "" })
+	return printers, err
+}
+
+// Printer models, the same as the model names in the printers package.
+const (
+	ModelLXD02   = "lx-d02"
+	ModelCat     = "cat"
+	ModelPhomemo = "phomemo"
+)
+
+// modelServices are the GATT services of the models.  The LX-D02 service is
+// also used by the generic Bluetooth serial modules, so the name is checked
+// first.
+var modelServices = []struct {
+	model   string
+	service string
+}{
+	{ModelCat, catGATT.service},
+	{ModelPhomemo, phomemoGATT.service},
+	{ModelLXD02, lxd02GATT.service},
+}
+
+// GuessModel returns the printer model by the advertised name, or by the
+// advertised services, if the name is not known.  It returns an empty string
+// if the device does not look like a supported printer.
+func GuessModel(name string, services []string) string {
+	switch {
+	case IsCatPrinter(name):
+		return ModelCat
+	case IsPhomemo(name):
+		return ModelPhomemo
+	case strings.HasPrefix(strings.ToUpper(name), "LX-D"):
+		return ModelLXD02
+	}
+	for _, ms := range modelServices {
+		if slices.ContainsFunc(services, func(s string) bool { return strings.EqualFold(s, ms.service) }) {
+			return ms.model
+		}
+	}
+	return ""
+}
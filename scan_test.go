@@ -0,0 +1,43 @@
+package thermoprint
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestScan_notSupported(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("BlueZ backend is only available on Linux")
+	}
+	if _, err := Scan(t.Context(), nil, SearchParameters{}, 0, WithBackend(BackendBlueZ)); !errors.Is(err, ErrScanNotSupported) {
+		t.Errorf("Scan error = %v, want %v", err, ErrScanNotSupported)
+	}
+}
+
+func TestCandidate_SearchParameters(t *testing.T) {
+	c := Candidate{Name: "M02", MACAddress: "AA:BB:CC:DD:EE:FF", RSSI: -60}
+	if got, want := c.SearchParameters(), (SearchParameters{Name: "M02", MACAddress: "AA:BB:CC:DD:EE:FF"}); got != want {
+		t.Errorf("SearchParameters() = %+v, want %+v", got, want)
+	}
+}
+
+func TestGuessModel(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []string
+		want     string
+	}{
+		{name: "LX-D02", want: ModelLXD02},
+		{name: "gb03", want: ModelCat},
+		{name: "M02 Pro", want: ModelPhomemo},
+		{name: "", services: []string{"0000AE30-0000-1000-8000-00805F9B34FB"}, want: ModelCat},
+		{name: "HC-08", services: []string{"0000180f-0000-1000-8000-00805f9b34fb", "0000ffe0-0000-1000-8000-00805f9b34fb"}, want: ModelLXD02},
+		{name: "Headphones", services: []string{"0000180f-0000-1000-8000-00805f9b34fb"}, want: ""},
+	}
+	for _, tt := range tests {
+		if got := GuessModel(tt.name, tt.services); got != tt.want {
+			t.Errorf("GuessModel(%q, %v) = %q, want %q", tt.name, tt.services, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package thermoprint
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// OpenSerial opens the serial port, i.e. /dev/ttyUSB0 on Linux or COM3 on
+// Windows, to print over USB when Bluetooth is not available.  The port
+// settings are not changed, USB printers ignore them, and a hardware serial
+// port should be configured beforehand, i.e. with stty(1).
+//
+// The data sent by the printer is passed to the notification function as it
+// is read from the port.
+func OpenSerial(name string) (Transport, error) {
+	path := name
+	if runtime.GOOS == "windows" && !strings.HasPrefix(path, `\\.\`) {
+		path = `\\.\` + path // required for COM10 and above
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDeviceNotFound, err)
+	}
+	return newStreamConn(name, f), nil
+}
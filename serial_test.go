@@ -0,0 +1,65 @@
+package thermoprint
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestStreamConn(t *testing.T) {
+	local, remote := net.Pipe()
+	c := newStreamConn("/dev/ttyTEST", local)
+	defer c.Disconnect()
+
+	got := make(chan []byte, 1)
+	if err := c.Notify(func(b []byte) { got <- b }); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		if err := c.Write([]byte{0x5a, 0x01}); err != nil {
+			t.Error(err)
+		}
+	}()
+	buf := make([]byte, 2)
+	if _, err := remote.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf, []byte{0x5a, 0x01}) {
+		t.Errorf("printer received % x", buf)
+	}
+
+	if _, err := remote.Write([]byte{0x5a, 0x02, 0x64}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case b := <-got:
+		if !bytes.Equal(b, []byte{0x5a, 0x02, 0x64}) {
+			t.Errorf("notification = % x", b)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no notification")
+	}
+	if c.Address() != "/dev/ttyTEST" {
+		t.Errorf("Address = %q", c.Address())
+	}
+}
+
+func TestOpenSerial_notFound(t *testing.T) {
+	if _, err := OpenSerial(t.TempDir() + "/nonexistent"); !errors.Is(err, ErrDeviceNotFound) {
+		t.Errorf("OpenSerial error = %v, want %v", err, ErrDeviceNotFound)
+	}
+}
+
+func TestWithTransport(t *testing.T) {
+	conn := &fakeCatConn{}
+	p, err := NewCatPrinter(t.Context(), nil, SearchParameters{}, WithTransport(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Address(); got != conn.Address() {
+		t.Errorf("Address = %q, want %q", got, conn.Address())
+	}
+}
@@ -0,0 +1,70 @@
+package thermoprint
+
+import "sync"
+
+// StatusSubscriber is implemented by the drivers that report the printer
+// status as it changes, i.e. when the battery runs low or the paper runs out.
+type StatusSubscriber interface {
+	// Subscribe registers fn to be called with every status that the
+	// printer reports.  fn is called from the notification goroutine, it
+	// must not block.  Call the returned function to unsubscribe.
+	Subscribe(fn func(Status)) (unsubscribe func())
+}
+
+var (
+	_ StatusSubscriber = (*LXD02)(nil)
+	_ StatusSubscriber = (*Phomemo)(nil)
+)
+
+// BatteryLow returns true if the battery level is low.
+func (s Status) BatteryLow() bool {
+	return s.BatteryLevel < gBatLow
+}
+
+// statusSubscribers is the list of the status subscribers.  It is safe for
+// concurrent use.
+type statusSubscribers struct {
+	mu   sync.Mutex
+	next int
+	fns  map[int]func(Status)
+}
+
+// add adds the subscriber and returns the function that removes it.
+func (s *statusSubscribers) add(fn func(Status)) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fns == nil {
+		s.fns = make(map[int]func(Status))
+	}
+	id := s.next
+	s.next++
+	s.fns[id] = fn
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.fns, id)
+	}
+}
+
+// publish calls the subscribers with the status.
+func (s *statusSubscribers) publish(st Status) {
+	s.mu.Lock()
+	fns := make([]func(Status), 0, len(s.fns))
+	for _, fn := range s.fns {
+		fns = append(fns, fn)
+	}
+	s.mu.Unlock()
+	for _, fn := range fns {
+		fn(st)
+	}
+}
+
+// public returns the status as reported to the users of the driver.
+func (s lxd02status) public() Status {
+	return Status{
+		BatteryLevel: s.BatteryLevel,
+		NoPaper:      s.NoPaper,
+		Charging:     s.Charging,
+		Charged:      s.Charged,
+	}
+}
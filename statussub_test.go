@@ -0,0 +1,46 @@
+package thermoprint
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLXD02_Subscribe(t *testing.T) {
+	conn := &statusConn{status: []byte{0x5a, 0x02, 80, 0x00, 0x00, 0x00}}
+	p, err := NewLXD02(t.Context(), nil, SearchParameters{}, WithTransport(conn), WithResponseTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(chan Status, 2)
+	unsubscribe := p.Subscribe(func(st Status) { got <- st })
+
+	// reported by the printer on its own
+	conn.notify([]byte{0x5a, 0x02, 5, 0x01, 0x00, 0x00})
+	select {
+	case st := <-got:
+		if want := (Status{BatteryLevel: 5, NoPaper: true}); st != want {
+			t.Errorf("status = %+v, want %+v", st, want)
+		}
+		if !st.BatteryLow() {
+			t.Error("battery is not low")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("status is not published")
+	}
+
+	// requested
+	if _, err := p.Status(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if st := <-got; st.BatteryLevel != 80 {
+		t.Errorf("battery level = %d, want 80", st.BatteryLevel)
+	}
+
+	unsubscribe()
+	if _, err := p.Status(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Error("status is published after unsubscribe")
+	}
+}
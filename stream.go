@@ -0,0 +1,77 @@
+package thermoprint
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+)
+
+// streamReadSize is the size of the buffer for the data read from the
+// stream.
+const streamReadSize = 512
+
+// streamConn is the [Transport] over the byte stream, i.e. the serial port
+// or the network connection.
+type streamConn struct {
+	name string
+	rw   io.ReadWriteCloser
+
+	mu     sync.Mutex
+	notify func([]byte)
+}
+
+func newStreamConn(name string, rw io.ReadWriteCloser) *streamConn {
+	c := &streamConn{name: name, rw: rw}
+	go c.reader()
+	return c
+}
+
+// reader passes the data read from the stream to the notification function,
+// until the stream is closed.
+func (c *streamConn) reader() {
+	buf := make([]byte, streamReadSize)
+	for {
+		n, err := c.rw.Read(buf)
+		if n > 0 {
+			c.mu.Lock()
+			notify := c.notify
+			c.mu.Unlock()
+			if notify != nil {
+				notify(append([]byte(nil), buf[:n]...))
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, os.ErrClosed) && !errors.Is(err, io.ErrClosedPipe) && !errors.Is(err, net.ErrClosed) {
+				slog.Warn("read failed", "address", c.name, "error", err)
+			}
+			return
+		}
+	}
+}
+
+func (c *streamConn) Address() string {
+	return c.name
+}
+
+func (c *streamConn) Write(data []byte) error {
+	if _, err := c.rw.Write(data); err != nil {
+		return fmt.Errorf("write %s: %w", c.name, err)
+	}
+	return nil
+}
+
+func (c *streamConn) Notify(fn func([]byte)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notify = fn
+	return nil
+}
+
+// Disconnect closes the stream, which stops the reader.
+func (c *streamConn) Disconnect() error {
+	return c.rw.Close()
+}
@@ -0,0 +1,39 @@
+package thermoprint
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// DefaultRawPort is the port of the AppSocket (JetDirect) printing
+// protocol.
+const DefaultRawPort = "9100"
+
+// DialTCP connects to the network printer with AppSocket, also known as
+// JetDirect or raw port 9100, printing protocol: the printer data is
+// streamed over the TCP connection as is.  If addr has no port, the
+// [DefaultRawPort] is used.
+//
+// The data sent by the printer is passed to the notification function as it
+// is read from the connection.
+func DialTCP(ctx context.Context, addr string) (Transport, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, DefaultRawPort)
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDeviceNotFound, err)
+	}
+	return newStreamConn(addr, conn), nil
+}
+
+// WithDialer makes the driver connect to the printer with the dial function
+// instead of Bluetooth, i.e. [DialTCP].  The driver closes the transport on
+// disconnect.
+func WithDialer(dial func(ctx context.Context) (Transport, error)) Option {
+	return func(o *printOptions) {
+		o.dialer = dial
+	}
+}
@@ -0,0 +1,78 @@
+package thermoprint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestDialTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		b, _ := io.ReadAll(conn)
+		received <- b
+	}()
+
+	c, err := DialTCP(t.Context(), l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Address() != l.Addr().String() {
+		t.Errorf("Address = %q, want %q", c.Address(), l.Addr())
+	}
+	if err := c.Write([]byte{0x1b, 0x40}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if b := <-received; !bytes.Equal(b, []byte{0x1b, 0x40}) {
+		t.Errorf("printer received % x", b)
+	}
+}
+
+func TestDialTCP_refused(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	if _, err := DialTCP(t.Context(), addr); !errors.Is(err, ErrDeviceNotFound) {
+		t.Errorf("DialTCP error = %v, want %v", err, ErrDeviceNotFound)
+	}
+}
+
+func TestWithDialer(t *testing.T) {
+	conn := &fakeCatConn{}
+	p, err := NewCatPrinter(t.Context(), nil, SearchParameters{}, WithDialer(func(context.Context) (Transport, error) {
+		return conn, nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Address(); got != conn.Address() {
+		t.Errorf("Address = %q, want %q", got, conn.Address())
+	}
+
+	errDial := errors.New("dial failed")
+	if _, err := NewCatPrinter(t.Context(), nil, SearchParameters{}, WithDialer(func(context.Context) (Transport, error) {
+		return nil, errDial
+	})); !errors.Is(err, errDial) {
+		t.Errorf("NewCatPrinter error = %v, want %v", err, errDial)
+	}
+}
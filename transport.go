@@ -0,0 +1,61 @@
+package thermoprint
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rusq/thermoprint/internal/ble"
+)
+
+// Transport is the connection to the printer: the driver writes the
+// commands to it, and receives the notifications from it.  By default, the
+// drivers connect over Bluetooth, use [WithTransport] or [WithDialer] to
+// print over another transport, i.e. the serial port, see [OpenSerial], or
+// the network, see [DialTCP].
+type Transport interface {
+	// Address returns the address of the printer.
+	Address() string
+	// Write writes the data to the printer.
+	Write(data []byte) error
+	// Notify sets the function that receives the data sent by the printer.
+	// Calling it again replaces the function.
+	Notify(fn func([]byte)) error
+	// Disconnect closes the connection.
+	Disconnect() error
+}
+
+var _ Transport = ble.Conn(nil)
+
+// WithTransport makes the driver use the transport instead of connecting to
+// the printer over Bluetooth.  The driver closes the transport on
+// disconnect.
+func WithTransport(t Transport) Option {
+	return func(o *printOptions) {
+		o.transport = t
+	}
+}
+
+// dial returns the transport from the options, connects with the dialer
+// from the options, or connects to the printer with the GATT profile over
+// Bluetooth.
+func (o printOptions) dial(ctx context.Context, adapter *Adapter, sp SearchParameters, prof gattProfile) (Transport, error) {
+	if o.transport != nil {
+		return o.transport, nil
+	}
+	if o.dialer != nil {
+		return o.dialer(ctx)
+	}
+	target, err := sp.target(prof)
+	if err != nil {
+		return nil, err
+	}
+	backend, err := newBackend(o.backend, adapter)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := backend.Connect(ctx, target, o.connectRetry())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDeviceNotFound, err)
+	}
+	return conn, nil
+}
@@ -0,0 +1,171 @@
+package thermoprint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rusq/thermoprint/bitmap"
+)
+
+// VirtualPrinter is a printer that does not need a device: it rasterises
+// the images the same way as [LXD02] does, and saves the printouts as PNG
+// files to the output directory.  It is useful for testing the print server
+// integration without Bluetooth.
+type VirtualPrinter struct {
+	dir        string
+	rasteriser *GenericRasteriser
+
+	mu      sync.Mutex
+	options printOptions
+	seq     int // number of printouts
+}
+
+// NewVirtualPrinter returns a virtual printer that saves the printouts to
+// dir, creating it if necessary.
+func NewVirtualPrinter(dir string, opt ...Option) (*VirtualPrinter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	r := *LXD02Rasteriser
+	vp := &VirtualPrinter{
+		dir:        dir,
+		rasteriser: &r,
+		options:    printOptions{dryrun: true, printInterval: DefaultPrintDelay},
+	}
+	if err := vp.SetOptions(opt...); err != nil {
+		return nil, err
+	}
+	return vp, nil
+}
+
+// SetOptions sets the print options.  Device options, such as energy, are
+// accepted and ignored.
+func (vp *VirtualPrinter) SetOptions(opts ...Option) error {
+	vp.mu.Lock()
+	defer vp.mu.Unlock()
+	for _, o := range opts {
+		o(&vp.options)
+	}
+	if vp.options.dithername != "" {
+		ditherFunc, ok := bitmap.DitherFunction(vp.options.dithername)
+		if !ok {
+			return fmt.Errorf("unknown dither function: %s", vp.options.dithername)
+		}
+		vp.rasteriser.SetDitherFunc(ditherFunc)
+	}
+	return nil
+}
+
+// PrintImage rasterises the image and saves it to the output directory.
+func (vp *VirtualPrinter) PrintImage(ctx context.Context, img image.Image) error {
+	vp.mu.Lock()
+	defer vp.mu.Unlock()
+
+	bmp := vp.options.postProcess(vp.rasteriser.ResizeAndDither(vp.options.channel.Gray(img), vp.options.gamma, vp.options.autoDither))
+	return vp.save(ctx, bmp)
+}
+
+// SplitRAW splits the raw data into the packets for
+// [VirtualPrinter.PrintRAW], see [GenericRasteriser.Split].
+func (vp *VirtualPrinter) SplitRAW(data []byte) ([][]byte, error) {
+	return vp.rasteriser.Split(data)
+}
+
+// PrintRAW decodes the raster lines of the packet data, as [LXD02] would
+// print them, and saves the printout to the output directory.
+func (vp *VirtualPrinter) PrintRAW(ctx context.Context, data [][]byte) error {
+	if len(data) == 0 {
+		return errors.New("empty raw data")
+	}
+	if _, err := vp.rasteriser.Enumerate(data); err != nil {
+		return err
+	}
+	vp.mu.Lock()
+	defer vp.mu.Unlock()
+
+	var (
+		width     = vp.rasteriser.LineWidth()
+		lineBytes = width / 8
+		lines     = len(data) * vp.rasteriser.LinesPerPacket
+	)
+	bmp := image.NewGray(image.Rect(0, 0, width, lines))
+	for i := range bmp.Pix {
+		bmp.Pix[i] = 0xff
+	}
+	for i, chunk := range data {
+		for j, b := range chunk {
+			y := i*vp.rasteriser.LinesPerPacket + j/lineBytes
+			for bit := range 8 {
+				if b&(1<<(7-bit)) != 0 {
+					bmp.SetGray((j%lineBytes)*8+bit, y, color.Gray{})
+				}
+			}
+		}
+	}
+	return vp.save(ctx, bmp)
+}
+
+// save saves the printout to the output directory, the caller holds vp.mu.
+// The printouts of the previous runs are not overwritten, the names that
+// exist are skipped.
+func (vp *VirtualPrinter) save(ctx context.Context, bmp image.Image) error {
+	var (
+		f        *os.File
+		filename string
+	)
+	for {
+		vp.seq++
+		filename = filepath.Join(vp.dir, fmt.Sprintf("printout_%04d.png", vp.seq))
+		var err error
+		f, err = os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("failed to create printout file: %w", err)
+		}
+	}
+	defer f.Close()
+	if err := png.Encode(f, bmp); err != nil {
+		return fmt.Errorf("failed to encode printout: %w", err)
+	}
+	slog.InfoContext(ctx, "virtual printout saved", "filename", filename, "height", bmp.Bounds().Dy())
+	return nil
+}
+
+// PrintInterval returns the print interval, that the printer is configured
+// with, it does not affect the printouts.
+func (vp *VirtualPrinter) PrintInterval() time.Duration {
+	vp.mu.Lock()
+	defer vp.mu.Unlock()
+	return vp.options.printInterval
+}
+
+// Width returns the maximum width of the print output in pixels.
+func (vp *VirtualPrinter) Width() int {
+	return vp.rasteriser.LineWidth()
+}
+
+func (vp *VirtualPrinter) DPI() float64 {
+	return float64(vp.rasteriser.DPI())
+}
+
+// Snapshot returns the state of the virtual printer, it is always connected
+// and idle.
+func (vp *VirtualPrinter) Snapshot() PrinterSnapshot {
+	return PrinterSnapshot{
+		Connected: true,
+		DryRun:    true,
+		State:     stateIdle.String(),
+	}
+}
@@ -0,0 +1,101 @@
+package thermoprint
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVirtualPrinterSavesPrintouts(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	vp, err := NewVirtualPrinter(dir)
+	if err != nil {
+		t.Fatalf("NewVirtualPrinter: %v", err)
+	}
+	for range 2 {
+		if err := vp.PrintImage(context.Background(), image.NewGray(image.Rect(0, 0, 768, 100))); err != nil {
+			t.Fatalf("PrintImage: %v", err)
+		}
+	}
+
+	f, err := os.Open(filepath.Join(dir, "printout_0002.png"))
+	if err != nil {
+		t.Fatalf("second printout: %v", err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("decode printout: %v", err)
+	}
+	if got := img.Bounds().Dx(); got != vp.Width() {
+		t.Fatalf("printout width = %d, want %d", got, vp.Width())
+	}
+}
+
+func TestVirtualPrinterKeepsPreviousPrintouts(t *testing.T) {
+	dir := t.TempDir()
+	prev := filepath.Join(dir, "printout_0001.png")
+	if err := os.WriteFile(prev, []byte("previous run"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	vp, err := NewVirtualPrinter(dir)
+	if err != nil {
+		t.Fatalf("NewVirtualPrinter: %v", err)
+	}
+	if err := vp.PrintImage(context.Background(), image.NewGray(image.Rect(0, 0, 768, 100))); err != nil {
+		t.Fatalf("PrintImage: %v", err)
+	}
+
+	if data, err := os.ReadFile(prev); err != nil || string(data) != "previous run" {
+		t.Fatalf("previous printout = %q, %v, want it unchanged", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "printout_0002.png")); err != nil {
+		t.Fatalf("new printout: %v", err)
+	}
+}
+
+func TestVirtualPrinterRejectsUnknownDither(t *testing.T) {
+	if _, err := NewVirtualPrinter(t.TempDir(), WithDither("no-such-dither")); err == nil {
+		t.Fatal("NewVirtualPrinter error = nil, want error")
+	}
+}
+
+func TestVirtualPrinterPrintRAW(t *testing.T) {
+	dir := t.TempDir()
+	vp, err := NewVirtualPrinter(dir)
+	if err != nil {
+		t.Fatalf("NewVirtualPrinter: %v", err)
+	}
+	line := make([]byte, vp.Width()/8)
+	line[0] = 0x80 // the first dot is black
+	data, err := vp.SplitRAW(bytes.Repeat(line, 4))
+	if err != nil {
+		t.Fatalf("SplitRAW: %v", err)
+	}
+	if err := vp.PrintRAW(context.Background(), data); err != nil {
+		t.Fatalf("PrintRAW: %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "printout_0001.png"))
+	if err != nil {
+		t.Fatalf("printout: %v", err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("decode printout: %v", err)
+	}
+	if got := img.Bounds().Dy(); got != 4 {
+		t.Fatalf("printout height = %d, want 4", got)
+	}
+	for x, want := range []uint8{0, 0xff} {
+		if got := color.GrayModel.Convert(img.At(x, 3)).(color.Gray).Y; got != want {
+			t.Fatalf("dot %d = %#x, want %#x", x, got, want)
+		}
+	}
+}